@@ -27,6 +27,10 @@ type modelEntry struct {
 type Cache struct {
 	mu     sync.Mutex
 	models map[string]*modelEntry
+
+	// watcher is nil unless WatchFiles turned hot reload on.
+	watcher *utils.FileWatcher
+	queue   func(func())
 }
 
 func NewCache() *Cache {
@@ -55,10 +59,18 @@ func (c *Cache) Acquire(path string) (*object.Model, error) {
 		return nil, err
 	}
 
-	c.models[key] = &modelEntry{model: model, refs: 1}
+	c.add(key, model)
 	return model, nil
 }
 
+// add registers a freshly uploaded model with one hold. Caller holds c.mu.
+func (c *Cache) add(key string, model *object.Model) {
+	c.models[key] = &modelEntry{model: model, refs: 1}
+	if c.watcher != nil {
+		c.watcher.Add(key)
+	}
+}
+
 // Release drops one hold and deletes the model's GPU resources when the last
 // holder lets go.
 func (c *Cache) Release(model *object.Model) error {
@@ -86,6 +98,9 @@ func (c *Cache) Release(model *object.Model) error {
 
 	existing.model.Delete()
 	delete(c.models, key)
+	if c.watcher != nil {
+		c.watcher.Remove(key)
+	}
 	utils.Logger().Infoln("Unloaded model:", model.Path)
 	return nil
 }
@@ -0,0 +1,111 @@
+package assets
+
+import "3d-engine/object"
+
+// Prepared is a model whose CPU work is done — the file parsed, its textures
+// decoded — and whose GL upload is waiting to be fed to the frame loop in
+// slices. It is Acquire taken apart so the expensive half can run on a worker.
+//
+// The lifecycle is Prepare (any goroutine), then Step until done and Commit
+// (frame loop), or Discard at any point on the frame loop to give up.
+type Prepared struct {
+	Path string
+
+	key    string
+	upload *object.ModelUpload
+	// resident means the model was already cached when Prepare looked, so
+	// there was nothing to parse; Commit just takes a hold.
+	resident bool
+	done     bool
+}
+
+// Prepare parses path and decodes its textures. Safe from any goroutine, and
+// the point of it: none of this touches GL. A model already in the cache is
+// not parsed again.
+func (c *Cache) Prepare(path string) (*Prepared, error) {
+	key, err := cacheKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	_, resident := c.models[key]
+	c.mu.Unlock()
+	if resident {
+		return &Prepared{Path: path, key: key, resident: true, done: true}, nil
+	}
+
+	data, err := object.ParseModel(path)
+	if err != nil {
+		return nil, err
+	}
+	images, err := object.DecodeTextures(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Prepared{
+		Path:   path,
+		key:    key,
+		upload: object.NewModelUpload(data, images),
+	}, nil
+}
+
+// Step uploads one mesh. It reports done once the model is entirely on the GPU
+// and ready to Commit. Frame loop only.
+func (p *Prepared) Step() (done bool, err error) {
+	if p.done {
+		return true, nil
+	}
+	done, err = p.upload.Step()
+	if err != nil {
+		return false, err
+	}
+	p.done = done
+	return done, nil
+}
+
+// Commit registers the uploaded model and returns it with one hold taken, so
+// it pairs with a Release exactly like Acquire does. If the same file became
+// resident while this one was uploading (another load got there first), the
+// resident copy wins and this upload is freed. Frame loop only.
+func (c *Cache) Commit(p *Prepared) (*object.Model, error) {
+	c.mu.Lock()
+	if existing, ok := c.models[p.key]; ok {
+		existing.refs++
+		c.mu.Unlock()
+		p.Discard()
+		return existing.model, nil
+	}
+	c.mu.Unlock()
+
+	if p.resident {
+		// Resident when prepared, evicted since: nothing was parsed, so fall
+		// back to the synchronous path rather than fail.
+		return c.Acquire(p.Path)
+	}
+
+	for !p.done {
+		if _, err := p.Step(); err != nil {
+			p.Discard()
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	model := p.upload.Model()
+	c.add(p.key, model)
+	p.upload = nil
+	return model, nil
+}
+
+// Discard frees whatever the preparation already put on the GPU. Safe to call
+// on a committed or already discarded preparation. Frame loop only.
+func (p *Prepared) Discard() {
+	if p.upload != nil {
+		p.upload.Abort()
+		p.upload = nil
+	}
+	p.done = true
+}
@@ -0,0 +1,120 @@
+package assets
+
+import (
+	"time"
+
+	"3d-engine/object"
+	tex "3d-engine/textures"
+	"3d-engine/utils"
+)
+
+// WatchFiles turns on hot reload for every model the cache holds, and for
+// every texture through the textures cache. When a model file changes it is
+// parsed again on the watcher's goroutine, and queue — which must run the
+// function on the frame loop, as App.Defer does — uploads it and swaps it into
+// the existing *object.Model. Entities hold that pointer, so all of them update
+// in place; holds are untouched, and the old meshes and texture holds are freed
+// in the swap.
+//
+// A file that fails to parse leaves the resident version alone: an artist
+// saving a broken export shouldn't empty the scene. Must be called from the
+// frame loop. Calling it again replaces the settings.
+func (c *Cache) WatchFiles(interval time.Duration, queue func(func())) {
+	c.StopWatching()
+
+	watcher := utils.NewFileWatcher(interval, func(path string) {
+		c.prepareReload(path)
+	})
+
+	c.mu.Lock()
+	c.watcher = watcher
+	c.queue = queue
+	for key := range c.models {
+		watcher.Add(key)
+	}
+	c.mu.Unlock()
+
+	tex.WatchFiles(interval, queue, c.refreshTexture)
+}
+
+// StopWatching turns hot reload off for models and textures. Frame loop only.
+func (c *Cache) StopWatching() {
+	c.mu.Lock()
+	watcher := c.watcher
+	c.watcher = nil
+	c.queue = nil
+	c.mu.Unlock()
+
+	// Outside the lock: Close waits for a callback that may be parsing.
+	if watcher != nil {
+		watcher.Close()
+		tex.StopWatching()
+	}
+}
+
+// prepareReload is the watcher-goroutine half: everything but GL.
+func (c *Cache) prepareReload(key string) {
+	data, err := object.ParseModel(key)
+	if err != nil {
+		utils.Logger().Printf("Not reloading model %s: %v", key, err)
+		return
+	}
+	images, err := object.DecodeTextures(data)
+	if err != nil {
+		utils.Logger().Printf("Not reloading model %s: %v", key, err)
+		return
+	}
+
+	c.mu.Lock()
+	queue := c.queue
+	c.mu.Unlock()
+	if queue == nil {
+		return
+	}
+	queue(func() { c.applyReload(key, data, images) })
+}
+
+// applyReload uploads the re-parsed model and swaps it in. Frame loop only.
+func (c *Cache) applyReload(key string, data *object.ModelData, images map[object.TextureRef]*tex.Image) {
+	c.mu.Lock()
+	_, resident := c.models[key]
+	c.mu.Unlock()
+	if !resident {
+		// Released while it was being parsed; nobody is left to see it.
+		return
+	}
+
+	upload := object.NewModelUpload(data, images)
+	for {
+		done, err := upload.Step()
+		if err != nil {
+			upload.Abort()
+			utils.Logger().Printf("Not reloading model %s: %v", key, err)
+			return
+		}
+		if done {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, ok := c.models[key]
+	if !ok {
+		upload.Abort()
+		return
+	}
+	existing.model.Replace(upload.Model())
+	utils.Logger().Println("Reloaded model:", key)
+}
+
+// refreshTexture passes a texture reload on to every model using it.
+func (c *Cache) refreshTexture(r tex.Reloaded) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.models {
+		existing.model.RefreshTexture(r.Path, r.Options, r.Transparent)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"3d-engine/utils"
 	"math"
 
-	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -21,10 +20,7 @@ type Camera struct {
 	CameraFov   float32
 	Yaw         float32
 	Pitch       float32
-	LastX       float32
-	LastY       float32
 
-	firstMouse        bool
 	renderDistanceMin float32
 	renderDistanceMax float32
 }
@@ -39,10 +35,7 @@ func NewCamera(config *utils.Config) *Camera {
 		CameraFov:   config.Fov,
 		Yaw:         -90.0,
 		Pitch:       0.0,
-		LastX:       400,
-		LastY:       300,
 
-		firstMouse:        true,
 		renderDistanceMin: config.RenderDistanceMin,
 		renderDistanceMax: config.RenderDistanceMax,
 	}
@@ -103,42 +96,11 @@ func (c *Camera) ProcessDown(isRunning bool, deltaTime float32) {
 	c.CameraPos = c.CameraPos.Sub(c.CameraUp.Mul(c.Step(isRunning, deltaTime)))
 }
 
-// ResetMouse makes the next MouseCallback establish a new origin instead of
-// measuring a delta from the last one.
-//
-// It is what keeps releasing and re-capturing the cursor from snapping the view:
-// while the cursor is free the pointer moves without the camera hearing about it,
-// so LastX/LastY go stale, and the first position after re-capture would
-// otherwise be read as one enormous mouse movement.
-func (c *Camera) ResetMouse() {
-	c.firstMouse = true
-}
-
-func (c *Camera) MouseCallback(window *glfw.Window, xpos, ypos float64) {
-	if c.firstMouse {
-		c.LastX = float32(xpos)
-		c.LastY = float32(ypos)
-		c.firstMouse = false
-	}
-
-	xOffset := float32(xpos) - c.LastX
-	yOffset := c.LastY - float32(ypos)
-	c.LastX = float32(xpos)
-	c.LastY = float32(ypos)
-
-	var sensitivity float32 = 0.1
-	xOffset *= sensitivity
-	yOffset *= sensitivity
-
-	c.Yaw += xOffset
-	c.Pitch += yOffset
-
-	if c.Pitch > 89.0 {
-		c.Pitch = 89.0
-	}
-	if c.Pitch < -89.0 {
-		c.Pitch = -89.0
-	}
+// Look turns the camera by yaw degrees to the right and pitch degrees up,
+// stopping short of straight up or down.
+func (c *Camera) Look(yaw, pitch float32) {
+	c.Yaw += yaw
+	c.Pitch = clampPitch(c.Pitch + pitch)
 
 	c.updateVectors()
 }
@@ -158,14 +120,28 @@ func (c *Camera) updateVectors() {
 	c.CameraUp = c.CameraRight.Cross(c.CameraFront).Normalize()
 }
 
-func (c *Camera) ScrollCallback(window *glfw.Window, xOffset, yOffset float64) {
-	c.CameraFov -= float32(yOffset)
-	if c.CameraFov < 1.0 {
-		c.CameraFov = 1.0
+// clampPitch stops just short of straight up or down, where the front vector
+// would be parallel to worldUp and the right vector undefined.
+func clampPitch(pitch float32) float32 {
+	if pitch > 89.0 {
+		return 89.0
+	}
+	if pitch < -89.0 {
+		return -89.0
+	}
+	return pitch
+}
+
+// SetFov sets the vertical field of view in degrees, kept within the range
+// the scroll wheel allows.
+func (c *Camera) SetFov(fov float32) {
+	if fov < 1.0 {
+		fov = 1.0
 	}
-	if c.CameraFov > 89.0 {
-		c.CameraFov = 89
+	if fov > 89.0 {
+		fov = 89
 	}
+	c.CameraFov = fov
 }
 
 func (c *Camera) ComputeView() mgl32.Mat4 {
@@ -181,10 +157,10 @@ func (c *Camera) ComputeProjection(width, height int) mgl32.Mat4 {
 }
 
 // SetOrientation points the camera and rebuilds its basis vectors. Used when a
-// scene defines a spawn orientation.
+// scene defines a spawn orientation, and when a client moves the camera. The
+// pitch is clamped as Look's is.
 func (c *Camera) SetOrientation(yaw, pitch float32) {
 	c.Yaw = yaw
-	c.Pitch = pitch
-	c.firstMouse = true
+	c.Pitch = clampPitch(pitch)
 	c.updateVectors()
 }
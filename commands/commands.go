@@ -0,0 +1,42 @@
+// Package commands holds the modes the binary runs in instead of starting the
+// engine: offline tools over scene files that want neither a window nor a GL
+// context. main hands All to utils.ParseArgs, which runs the one the command
+// line names.
+package commands
+
+import (
+	"3d-engine/scene"
+	"3d-engine/utils"
+)
+
+// All lists every command, in the order the help shows them.
+func All() []utils.Command {
+	return []utils.Command{
+		{
+			Name:  "migrate",
+			Short: "Upgrade scene and prefab files to the current format",
+			Long: "Rewrites each file in the current scene format, keeping its comments and any " +
+				"fields this build does not know. The original is kept next to it as " +
+				"<file>.v<version>.bak unless --no-backup is given. Files already current are left alone.",
+			Data: &migrateCommand{},
+		},
+		{
+			Name:  "convert",
+			Short: "Convert a scene between YAML, JSON and binary",
+			Long: "Reads the scene at FROM and writes it to TO, each in the format its extension names: " +
+				".json for JSON, " + scene.BinaryExtension + " for binary, anything else for YAML. " +
+				"An older source is migrated on the way. Comments do not survive the conversion.",
+			Data: &convertCommand{},
+		},
+		{
+			Name:  "validate",
+			Short: "Check scene and prefab files and report every problem",
+			Long: "Checks each file for everything that would keep it from loading or make it load " +
+				"wrong — missing models, textures, skyboxes and included files, unknown components and " +
+				"props, duplicate sibling names, zero scales, rotation axes that are not unit length — " +
+				"and reports each with its line and column. Exits non-zero if there are errors, or " +
+				"warnings under --strict.",
+			Data: &validateCommand{},
+		},
+	}
+}
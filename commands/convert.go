@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+
+	"3d-engine/scene"
+)
+
+type convertCommand struct {
+	Args struct {
+		From string `positional-arg-name:"FROM" required:"yes"`
+		To   string `positional-arg-name:"TO" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+// Execute reads one scene file and writes it to another, each in the format
+// its extension names. The scene passes through Load, so an old source is
+// migrated on the way and the output is always current.
+func (c *convertCommand) Execute([]string) error {
+	loaded, err := scene.Load(c.Args.From)
+	if err != nil {
+		return err
+	}
+	if err := scene.Save(c.Args.To, loaded); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%s) -> %s (%s)\n",
+		c.Args.From, scene.FormatOf(c.Args.From), c.Args.To, scene.FormatOf(c.Args.To))
+	return nil
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+
+	"3d-engine/scene"
+	"3d-engine/utils"
+)
+
+type migrateCommand struct {
+	NoBackup bool `long:"no-backup" description:"Do not keep a copy of each original file"`
+
+	Args struct {
+		Files []string `positional-arg-name:"FILE" required:"1"`
+	} `positional-args:"yes"`
+}
+
+// Execute migrates every file it is given, carrying on past a failure so one
+// broken file does not leave the rest of a directory half-done, and reports a
+// line for each.
+func (c *migrateCommand) Execute([]string) error {
+	failed := 0
+	for _, path := range c.Args.Files {
+		from, err := scene.MigrateFile(path, !c.NoBackup)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Println(err)
+		case from == scene.CurrentVersion:
+			fmt.Printf("%s: already version %d\n", path, from)
+		case c.NoBackup:
+			fmt.Printf("%s: version %d -> %d\n", path, from, scene.CurrentVersion)
+		default:
+			fmt.Printf("%s: version %d -> %d, original kept as %s\n",
+				path, from, scene.CurrentVersion, scene.BackupPath(path, from))
+		}
+	}
+
+	if failed > 0 {
+		return utils.Logger().Errorf("%d of %d files could not be migrated", failed, len(c.Args.Files))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"3d-engine/components"
+	"3d-engine/engine"
+	"3d-engine/scene"
+	"3d-engine/utils"
+)
+
+type validateCommand struct {
+	Format     string `long:"format" choice:"text" choice:"json" default:"text" description:"How to write the report"`
+	Strict     bool   `long:"strict" description:"Fail on warnings as well as errors"`
+	NoTextures bool   `long:"no-textures" description:"Do not import models to check the textures they use"`
+
+	Args struct {
+		Files []string `positional-arg-name:"FILE" required:"1"`
+	} `positional-args:"yes"`
+}
+
+// validationReport is what --format json writes: every issue in every file,
+// and the totals a CI step needs to decide without counting.
+type validationReport struct {
+	Files    int           `json:"files"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Issues   []scene.Issue `json:"issues"`
+}
+
+// Execute validates every file and writes one report for all of them. The
+// command fails when there are errors, or warnings under --strict, so CI can
+// use the exit status alone; the report says why.
+func (c *validateCommand) Execute([]string) error {
+	resources, err := engine.ValidationResources(components.Register)
+	if err != nil {
+		return err
+	}
+	if c.NoTextures {
+		resources.ModelTextures = nil
+	}
+
+	report := validationReport{Files: len(c.Args.Files), Issues: []scene.Issue{}}
+	for _, path := range c.Args.Files {
+		for _, issue := range scene.Validate(path, resources) {
+			switch issue.Severity {
+			case scene.SeverityError:
+				report.Errors++
+			case scene.SeverityWarning:
+				report.Warnings++
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	if c.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+		fmt.Printf("%d files, %d errors, %d warnings\n", report.Files, report.Errors, report.Warnings)
+	}
+
+	if report.Errors > 0 || (c.Strict && report.Warnings > 0) {
+		return utils.Logger().Errorf("validation failed: %d errors, %d warnings", report.Errors, report.Warnings)
+	}
+	return nil
+}
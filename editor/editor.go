@@ -24,6 +24,19 @@ type Editor struct {
 	// instead of silently inspecting whatever took its place.
 	selected engine.Handle
 
+	// alsoSelected is the rest of a multi-selection, in the order it was
+	// added; see selection.go.
+	alsoSelected []engine.Handle
+
+	// The bulk actions' inputs.
+	bulkOffset [3]float32
+	bulkColor  [3]float32
+	groupName  string
+	prefabPath string
+
+	// layerPath is the layers panel's file field.
+	layerPath string
+
 	autoApply bool
 
 	// draft holds the values the drag widgets write to. They are applied to the
@@ -48,6 +61,10 @@ type Editor struct {
 
 	editing bool
 
+	// inGesture is set while a widget is held and the edits it makes are
+	// going into one history transaction.
+	inGesture bool
+
 	sceneModes    []string
 	selectedScene int
 
@@ -77,6 +94,15 @@ type Editor struct {
 	// despawned between frames.
 	status string
 
+	// handles is the viewport gizmo; see gizmo.go.
+	handles handleState
+
+	// lastPickAt is where the last viewport click was, so a second click on
+	// the same spot can cycle to the next entity under it. picked says there
+	// has been one.
+	lastPickAt imgui.Vec2
+	picked     bool
+
 	visible bool
 }
 
@@ -93,8 +119,10 @@ func New(app *engine.App) (*Editor, error) {
 	}
 
 	return &Editor{
-		app:     app,
-		visible: true,
+		app:       app,
+		handles:   defaultHandleState(),
+		bulkColor: [3]float32(engine.DefaultBaseColor),
+		visible:   true,
 	}, nil
 }
 
@@ -105,12 +133,15 @@ func (e *Editor) Frame(app *engine.App) {
 
 	if e.visible {
 		e.draw()
+	} else {
+		e.app.SetHighlight()
 	}
 
 	render()
 }
 
-// CapturesMouse reports whether ImGui is using the pointer.
+// CapturesMouse reports whether ImGui is using the pointer, or the gizmo is:
+// a click on a handle is a drag, not a click on the scene behind it.
 //
 // While the cursor is captured for mouselook the editor never takes it, so the
 // two input modes can't fight: press C to release the cursor and drive the UI,
@@ -119,7 +150,7 @@ func (e *Editor) CapturesMouse() bool {
 	if !e.visible || e.app.State.CaptureCursor {
 		return false
 	}
-	return imgui.CurrentIO().WantCaptureMouse()
+	return imgui.CurrentIO().WantCaptureMouse() || e.handles.capturesMouse()
 }
 
 // CapturesKeyboard reports whether ImGui is using the keyboard, which is true
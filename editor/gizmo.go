@@ -0,0 +1,348 @@
+package editor
+
+import (
+	"fmt"
+
+	"3d-engine/engine"
+	"3d-engine/gizmo"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// gizmoPixels is how long an axis handle is on screen, whatever the distance.
+const gizmoPixels = 110
+
+// handleState is the viewport gizmo: the settings in the panel and the drag in
+// progress, if any. The maths is all in package gizmo; this file only feeds it
+// the mouse and the selection, and draws what it says.
+type handleState struct {
+	mode  gizmo.Mode
+	space gizmo.Space
+	pivot gizmo.Pivot
+
+	snapping bool
+	snap     gizmo.Snap
+
+	// hovered is the handle under the cursor this frame. CapturesMouse reports
+	// it, so a click on a handle is the gizmo's rather than the viewport's.
+	hovered gizmo.Axis
+
+	drag *handleDrag
+}
+
+// handleDrag is a drag in progress. Everything is measured from the state when
+// it started, not from last frame: re-applying a small delta to an
+// already-moved entity every frame would accumulate float error and, with
+// snapping, never move at all.
+type handleDrag struct {
+	drag    *gizmo.Drag
+	pivot   mgl32.Vec3
+	targets []handleTarget
+
+	// delta is the latest measurement, kept for the readout by the cursor.
+	delta gizmo.Delta
+}
+
+type handleTarget struct {
+	handle engine.Handle
+	start  gizmo.Target
+}
+
+func defaultHandleState() handleState {
+	return handleState{
+		mode:  gizmo.Translate,
+		space: gizmo.World,
+		pivot: gizmo.PivotCenter,
+		snap:  gizmo.Snap{Translate: 0.5, RotateDegrees: 15, Scale: 0.1},
+	}
+}
+
+// capturesMouse is the gizmo's half of CapturesMouse.
+func (h *handleState) capturesMouse() bool {
+	return h.hovered != gizmo.AxisNone || h.drag != nil
+}
+
+// handleTargets snapshots the selection for the gizmo, dropping any entity
+// whose ancestor is also selected: it already moves with that ancestor, and
+// moving it as well would move it twice.
+func (e *Editor) handleTargets() []handleTarget {
+	var targets []handleTarget
+	for _, handle := range e.app.TopLevel(e.selection()) {
+		info, ok := e.app.ObjectInfo(handle)
+		if !ok {
+			continue
+		}
+		_, parent, ok := e.app.WorldMatrices(handle)
+		if !ok {
+			continue
+		}
+		targets = append(targets, handleTarget{
+			handle: handle,
+			start: gizmo.Target{
+				Local: gizmo.Pose{
+					Position: info.Transform.Position,
+					Rotation: info.Transform.Rotation,
+					Scale:    info.Transform.Scale,
+				},
+				Parent: parent,
+			},
+		})
+	}
+	return targets
+}
+
+// drawGizmo runs the viewport handles for one frame: picks, drags, draws.
+// Called after the panels, outside any window, so WantCaptureMouse already
+// knows whether the pointer is over one.
+func (e *Editor) drawGizmo() {
+	h := &e.handles
+
+	// While the cursor is captured the mouse is flying the camera.
+	if e.app.State.CaptureCursor {
+		e.endHandleDrag()
+		h.hovered = gizmo.AxisNone
+		return
+	}
+
+	targets := e.handleTargets()
+	if len(targets) == 0 {
+		e.endHandleDrag()
+		h.hovered = gizmo.AxisNone
+		return
+	}
+
+	io := imgui.CurrentIO()
+	display := io.DisplaySize()
+	if display.X <= 0 || display.Y <= 0 {
+		return
+	}
+	view := e.app.Camera.ComputeView()
+	projection := e.app.Camera.ComputeProjection(int(display.X), int(display.Y))
+	mouse := io.MousePos()
+	ray := gizmo.ScreenRay(mouse.X, mouse.Y, display.X, display.Y, view, projection)
+
+	if h.drag != nil {
+		e.continueHandleDrag(ray)
+	}
+
+	current := e.currentGizmo(targets, display.Y)
+
+	if h.drag == nil {
+		h.hovered = gizmo.AxisNone
+		if !io.WantCaptureMouse() {
+			h.hovered = current.Pick(ray)
+		}
+		if h.hovered != gizmo.AxisNone && imgui.IsMouseClickedBool(imgui.MouseButtonLeft) {
+			e.startHandleDrag(current, targets, ray)
+		}
+	}
+
+	e.paintGizmo(current, view, projection, display)
+}
+
+// currentGizmo places the handles on the selection as it is now.
+func (e *Editor) currentGizmo(targets []handleTarget, viewportHeight float32) gizmo.Gizmo {
+	positions := make([]mgl32.Vec3, len(targets))
+	for i, target := range targets {
+		positions[i] = target.start.WorldPosition()
+	}
+	pivot := gizmo.PivotPoint(e.handles.pivot, positions)
+
+	distance := pivot.Sub(e.app.Camera.CameraPos).Len()
+	return gizmo.Gizmo{
+		Mode:  e.handles.mode,
+		Frame: gizmo.NewFrame(pivot, targets[0].start.WorldRotation(), e.handles.mode, e.handles.space),
+		Size:  gizmo.HandleSize(distance, e.app.Camera.CameraFov, viewportHeight, gizmoPixels),
+	}
+}
+
+// startHandleDrag grabs the hovered handle. The whole drag is one history
+// transaction, so however many frames it writes the transform, it undoes in
+// one step.
+func (e *Editor) startHandleDrag(current gizmo.Gizmo, targets []handleTarget, ray gizmo.Ray) {
+	h := &e.handles
+
+	snap := gizmo.Snap{}
+	if h.snapping {
+		snap = h.snap
+	}
+	drag, ok := gizmo.StartDrag(current, h.hovered, ray, snap)
+	if !ok {
+		return
+	}
+
+	h.drag = &handleDrag{drag: drag, pivot: current.Frame.Origin, targets: targets}
+	e.app.History.Begin(current.Mode.String())
+}
+
+func (e *Editor) continueHandleDrag(ray gizmo.Ray) {
+	h := &e.handles
+
+	// Right-click while dragging puts everything back where it was.
+	if imgui.IsMouseClickedBool(imgui.MouseButtonRight) {
+		e.app.History.Rollback()
+		h.drag = nil
+		return
+	}
+	if !imgui.IsMouseDown(imgui.MouseButtonLeft) {
+		e.endHandleDrag()
+		return
+	}
+
+	delta := h.drag.drag.Update(ray)
+	h.drag.delta = delta
+	frame := h.drag.drag.Gizmo().Frame
+
+	for _, target := range h.drag.targets {
+		pivot := h.drag.pivot
+		if h.pivot == gizmo.PivotIndividual {
+			pivot = target.start.WorldPosition()
+		}
+		pose := delta.Apply(target.start, pivot, frame)
+
+		// UpdateTransform, the same call the inspector and the RPC server make,
+		// by way of the history so the drag can be undone.
+		err := e.app.History.UpdateTransform(target.handle, func(t *engine.Transform) {
+			t.Position = pose.Position
+			t.Rotation = pose.Rotation
+			t.Scale = pose.Scale
+		})
+		if err != nil {
+			e.status = err.Error()
+		}
+	}
+}
+
+// endHandleDrag lets go of the handle and files the drag as one undo step.
+func (e *Editor) endHandleDrag() {
+	if e.handles.drag == nil {
+		return
+	}
+	e.app.History.Commit()
+	e.handles.drag = nil
+}
+
+// The colours are packed by hand, the way ImGui's IM_COL32 macro does it,
+// rather than through imgui.ColorU32: that one reads the current style, and
+// there is no ImGui context yet when package variables are initialised.
+var (
+	axisColours      = [3]uint32{opaque(230, 64, 64), opaque(90, 217, 77), opaque(77, 115, 242)}
+	activeAxisColour = opaque(255, 217, 51)
+)
+
+func opaque(r, g, b uint32) uint32 {
+	return 0xff<<24 | b<<16 | g<<8 | r
+}
+
+// paintGizmo draws the handles on ImGui's foreground list, over the scene and
+// under nothing. Drawing in 2D this way needs no shader and no depth
+// handling: the handles always show, even through the model they sit inside.
+func (e *Editor) paintGizmo(current gizmo.Gizmo, view, projection mgl32.Mat4, display imgui.Vec2) {
+	h := &e.handles
+	list := imgui.ForegroundDrawListViewportPtr()
+
+	highlighted := h.hovered
+	if h.drag != nil {
+		highlighted = h.drag.drag.Axis()
+	}
+
+	project := func(point mgl32.Vec3) (imgui.Vec2, bool) {
+		pixel, ok := gizmo.Project(point, view, projection, display.X, display.Y)
+		return imgui.Vec2{X: pixel.X(), Y: pixel.Y()}, ok
+	}
+
+	for i, axis := range gizmo.Axes {
+		colour := axisColours[i]
+		if axis == highlighted {
+			colour = activeAxisColour
+		}
+
+		segments := current.Segments(axis)
+		for _, segment := range segments {
+			from, ok1 := project(segment[0])
+			to, ok2 := project(segment[1])
+			if ok1 && ok2 {
+				list.AddLineV(from, to, colour, 2.5)
+			}
+		}
+
+		if current.Mode == gizmo.Rotate {
+			continue
+		}
+		tip, ok := project(segments[0][1])
+		if !ok {
+			continue
+		}
+		if current.Mode == gizmo.Scale {
+			list.AddRectFilled(imgui.Vec2{X: tip.X - 4, Y: tip.Y - 4}, imgui.Vec2{X: tip.X + 4, Y: tip.Y + 4}, colour)
+		} else {
+			list.AddCircleFilled(tip, 5, colour)
+		}
+	}
+
+	if h.drag != nil {
+		mouse := imgui.CurrentIO().MousePos()
+		list.AddTextVec2V(imgui.Vec2{X: mouse.X + 16, Y: mouse.Y + 8}, activeAxisColour, dragLabel(h.drag.delta))
+	}
+}
+
+func dragLabel(delta gizmo.Delta) string {
+	switch delta.Mode {
+	case gizmo.Rotate:
+		return fmt.Sprintf("%s %+.1f°", delta.Axis, delta.Amount)
+	case gizmo.Scale:
+		return fmt.Sprintf("%s ×%.2f", delta.Axis, delta.Amount)
+	}
+	return fmt.Sprintf("%s %+.2f", delta.Axis, delta.Amount)
+}
+
+// drawGizmoSettings is the gizmo's row in the panel.
+func (e *Editor) drawGizmoSettings() {
+	if !imgui.CollapsingHeaderTreeNodeFlagsV("Gizmo", imgui.TreeNodeFlagsDefaultOpen) {
+		return
+	}
+	h := &e.handles
+
+	for _, mode := range []gizmo.Mode{gizmo.Translate, gizmo.Rotate, gizmo.Scale} {
+		if imgui.RadioButtonBool(mode.String(), h.mode == mode) {
+			h.mode = mode
+		}
+		imgui.SameLine()
+	}
+	imgui.NewLine()
+
+	if imgui.RadioButtonBool("World", h.space == gizmo.World) {
+		h.space = gizmo.World
+	}
+	imgui.SameLine()
+	if imgui.RadioButtonBool("Local", h.space == gizmo.Local) {
+		h.space = gizmo.Local
+	}
+	if h.mode == gizmo.Scale {
+		imgui.SameLine()
+		imgui.TextDisabled("(scale is always local)")
+	}
+
+	imgui.Text("Pivot")
+	for _, pivot := range []gizmo.Pivot{gizmo.PivotCenter, gizmo.PivotActive, gizmo.PivotIndividual} {
+		imgui.SameLine()
+		if imgui.RadioButtonBool(pivot.String(), h.pivot == pivot) {
+			h.pivot = pivot
+		}
+	}
+
+	imgui.Checkbox("Snap", &h.snapping)
+	if h.snapping {
+		imgui.PushItemWidth(70)
+		imgui.SameLine()
+		imgui.DragFloatV("Move##snap", &h.snap.Translate, 0.05, 0.01, 100, "%.2f", 0)
+		imgui.SameLine()
+		imgui.DragFloatV("Deg##snap", &h.snap.RotateDegrees, 1, 1, 180, "%.0f", 0)
+		imgui.SameLine()
+		imgui.DragFloatV("Scale##snap", &h.snap.Scale, 0.01, 0.01, 10, "%.2f", 0)
+		imgui.PopItemWidth()
+	}
+
+	imgui.TextDisabled("Drag a handle in the viewport; right-click to cancel")
+}
@@ -0,0 +1,125 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+
+	"3d-engine/engine"
+	"3d-engine/scene"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+)
+
+// drawLayers lists the scene's includes and any other layer loaded since, with
+// a button to load or unload each, and a field for loading a file that is not
+// listed. Save writes a layer's objects back to its own file; the scene's Save
+// only writes the include.
+//
+// Loading and unloading happen right here, on the frame loop, the way the
+// engine's LoadAdditive and Unload require.
+func (e *Editor) drawLayers() {
+	includes := e.app.Scenes.Includes()
+	loaded := e.app.Scenes.Layers()
+	cells := e.app.Scenes.Cells()
+
+	rows := make([]scene.Include, 0, len(includes)+len(loaded))
+	rows = append(rows, includes...)
+	for _, layer := range loaded {
+		listed := slices.ContainsFunc(rows, func(include scene.Include) bool {
+			return scene.LayerKey(include.Path) == layer
+		})
+		cell := slices.ContainsFunc(cells, func(cell engine.CellStatus) bool {
+			return cell.Path == layer
+		})
+		if !listed && !cell {
+			rows = append(rows, scene.Include{Path: layer})
+		}
+	}
+
+	if !imgui.CollapsingHeaderTreeNodeFlagsV(fmt.Sprintf("Layers (%d loaded)###layers", len(loaded)), 0) {
+		return
+	}
+
+	if len(rows) > 0 && imgui.BeginTable("Layers", 3) {
+		imgui.TableSetupColumnV("Path", imgui.TableColumnFlagsWidthStretch, 0, 0)
+		imgui.TableSetupColumnV("Kind", imgui.TableColumnFlagsWidthFixed, 60, 0)
+		imgui.TableSetupColumnV("Action", imgui.TableColumnFlagsWidthFixed, 110, 0)
+		imgui.TableHeadersRow()
+
+		for i, row := range rows {
+			imgui.PushIDStr(fmt.Sprintf("layer-%d", i))
+			imgui.TableNextColumn()
+			imgui.Text(row.Path)
+			imgui.TableNextColumn()
+			if row.Stream {
+				imgui.Text("stream")
+			} else {
+				imgui.Text("layer")
+			}
+			imgui.TableNextColumn()
+			if e.app.Scenes.HasLayer(row.Path) {
+				if imgui.Button("Unload") {
+					e.unloadLayer(row.Path)
+				}
+				imgui.SameLine()
+				if imgui.Button("Save") {
+					e.report(e.app.SaveLayer(row.Path))
+				}
+			} else if imgui.Button("Load") {
+				e.report(e.app.Scenes.LoadAdditive(row.Path))
+			}
+			imgui.PopID()
+		}
+
+		imgui.EndTable()
+	}
+
+	e.drawCells(cells)
+
+	imgui.PushItemWidth(320)
+	imgui.InputTextWithHint("##layer-path", "scene file to load as a layer", &e.layerPath, 0, nil)
+	imgui.PopItemWidth()
+	imgui.SameLine()
+	if imgui.Button("Load layer") {
+		e.report(e.app.Scenes.LoadAdditive(e.layerPath))
+	}
+}
+
+// drawCells lists the scene's streamed cells. They have no buttons: the
+// camera's position decides what is loaded, and the way to load a cell is to
+// fly over to it.
+func (e *Editor) drawCells(cells []engine.CellStatus) {
+	if len(cells) == 0 {
+		return
+	}
+	if imgui.BeginTable("Cells", 3) {
+		imgui.TableSetupColumnV("Cell", imgui.TableColumnFlagsWidthStretch, 0, 0)
+		imgui.TableSetupColumnV("State", imgui.TableColumnFlagsWidthFixed, 70, 0)
+		imgui.TableSetupColumnV("Distance", imgui.TableColumnFlagsWidthFixed, 70, 0)
+		imgui.TableHeadersRow()
+
+		for _, cell := range cells {
+			imgui.TableNextColumn()
+			imgui.Text(cell.Path)
+			imgui.TableNextColumn()
+			imgui.Text(cell.State.String())
+			imgui.TableNextColumn()
+			imgui.Text(fmt.Sprintf("%.0f", cell.Distance))
+		}
+
+		imgui.EndTable()
+	}
+}
+
+// unloadLayer unloads a layer and lets go of the selection if the layer took
+// it: unlike a delete, an unload is not something the inspector's "no longer
+// exists" is there to explain.
+func (e *Editor) unloadLayer(path string) {
+	if !e.report(e.app.Scenes.Unload(path)) {
+		return
+	}
+	if _, ok := e.app.ObjectInfo(e.selected); !ok {
+		e.selectOnly(engine.NoHandle)
+	}
+	e.pruneSelection()
+}
@@ -11,11 +11,16 @@ import (
 )
 
 func (e *Editor) draw() {
+	e.beginGesture()
+	defer e.endGesture()
+
 	imgui.BeginV("3D Engine", nil, imgui.WindowFlagsAlwaysAutoResize)
 
 	e.drawStats()
+	e.drawHistory()
 	imgui.Separator()
 	e.drawSceneModes()
+	e.drawLayers()
 	imgui.Separator()
 
 	e.drawSave()
@@ -23,14 +28,91 @@ func (e *Editor) draw() {
 
 	rows := e.entityRows()
 	byHandle := indexRows(rows)
+	e.pruneSelection()
 
 	e.drawSpawn()
 	imgui.Separator()
 	e.drawEntityTree(rows, byHandle)
+	e.drawSelection()
+	imgui.Separator()
+	e.drawGizmoSettings()
 	imgui.Separator()
 	e.drawInspector(rows, byHandle)
 
 	imgui.End()
+
+	// The gizmo goes first: a click on one of its handles is a drag, and must
+	// not also select whatever is behind it.
+	e.drawGizmo()
+	e.pickInViewport()
+	e.app.SetHighlight(e.selection()...)
+}
+
+// beginGesture opens a history transaction when a widget is being held, so a
+// drag, which edits every frame it moves, undoes as one step rather than one
+// per frame. ImGui's active item carries over from the last frame, so checking
+// before any widget is drawn catches the drag from its second frame on; the
+// first frame of a drag only activates the widget and changes nothing.
+func (e *Editor) beginGesture() {
+	if !e.inGesture && imgui.IsAnyItemActive() {
+		e.app.History.Begin("")
+		e.inGesture = true
+	}
+}
+
+// endGesture commits the transaction once the widget is let go.
+func (e *Editor) endGesture() {
+	if e.inGesture && !imgui.IsAnyItemActive() {
+		e.app.History.Commit()
+		e.inGesture = false
+	}
+}
+
+// drawHistory is the undo/redo row. The buttons name the step they would
+// take, which is the cheapest way to know what Ctrl+Z is about to do.
+func (e *Editor) drawHistory() {
+	history := e.app.History
+
+	undo := history.UndoLabel()
+	imgui.BeginDisabledV(undo == "")
+	if imgui.Button(labelOr("Undo", undo) + "###undo") {
+		e.stepHistory(history.Undo)
+	}
+	imgui.EndDisabled()
+
+	imgui.SameLine()
+	redo := history.RedoLabel()
+	imgui.BeginDisabledV(redo == "")
+	if imgui.Button(labelOr("Redo", redo) + "###redo") {
+		e.stepHistory(history.Redo)
+	}
+	imgui.EndDisabled()
+}
+
+func labelOr(verb, step string) string {
+	if step == "" {
+		return verb
+	}
+	return verb + " " + step
+}
+
+func (e *Editor) stepHistory(step func() error) {
+	// Pressing the button is itself a held widget, so a gesture is open; it
+	// holds nothing, and closing it leaves no trace.
+	if e.inGesture {
+		e.app.History.Commit()
+		e.inGesture = false
+	}
+
+	if err := step(); err != nil {
+		e.status = err.Error()
+		return
+	}
+	// An undone delete comes back under a new handle; follow it, so the
+	// inspector keeps showing the entity rather than "no longer exists".
+	e.resolveSelection()
+	e.reparentTarget = e.app.History.Resolve(e.reparentTarget)
+	e.status = ""
 }
 
 // indexRows makes the snapshot walkable as a tree. ObjectInfo carries child
@@ -96,9 +178,21 @@ func (e *Editor) drawStats() {
 	models, holds := e.app.Assets.Stats()
 	imgui.Text(fmt.Sprintf("Models resident: %d (%d refs)", models, holds))
 
+	// A background load keeps the old scene on screen, so without this there
+	// is nothing to say one is happening.
+	if progress := e.app.Scenes.LoadProgress(); progress.Active() {
+		imgui.Text(fmt.Sprintf("Loading %s (%s)", progress.Path, progress.Stage))
+		overlay := fmt.Sprintf("%d/%d models", progress.Uploaded, progress.Models)
+		imgui.ProgressBarV(progress.Fraction(), imgui.Vec2{X: -1, Y: 0}, overlay)
+	}
+
 	imgui.Text("C releases the cursor to use this panel")
 
 	if imgui.CollapsingHeaderTreeNodeFlagsV("Key bindings", 0) {
+		// Top first, the order Poll reads them in; Describe names the context
+		// each action comes from when it is not the default.
+		imgui.Text("Input contexts: " + strings.Join(e.app.Input.Active(), " > "))
+
 		if imgui.BeginTable("Bindings", 2) {
 			imgui.TableSetupColumnV("Action", imgui.TableColumnFlagsWidthFixed, 180, 0)
 			imgui.TableSetupColumnV("Keys", imgui.TableColumnFlagsWidthStretch, 0, 0)
@@ -154,7 +248,7 @@ func (e *Editor) drawSceneModes() {
 				if err := e.app.Scenes.RequestSceneModeChange(mode); err != nil {
 					imgui.Text(err.Error())
 				}
-				e.selected = engine.NoHandle
+				e.selectOnly(engine.NoHandle)
 			}
 			imgui.PopID()
 		}
@@ -245,14 +339,13 @@ func (e *Editor) spawn(componentChoice int, choices []string) {
 		spec.Transform.Position = e.app.Camera.CameraPos.Add(e.app.Camera.CameraFront.Mul(5))
 	}
 
-	spawned, err := e.app.SpawnObject(spec)
+	spawned, err := e.app.History.SpawnObject(spec)
 	if err != nil {
 		e.spawnStatus = err.Error()
 		return
 	}
 
-	e.selected = spawned.Handle()
-	e.reparentTarget = spec.Parent
+	e.selectOnly(spawned.Handle())
 	e.spawnStatus = fmt.Sprintf("Spawned %s", name)
 	e.status = ""
 }
@@ -289,7 +382,7 @@ func (e *Editor) drawEntityNode(row engine.ObjectInfo, byHandle map[engine.Handl
 	if len(row.Children) == 0 {
 		flags |= imgui.TreeNodeFlagsLeaf
 	}
-	if row.Handle == e.selected {
+	if e.isSelected(row.Handle) {
 		flags |= imgui.TreeNodeFlagsSelected
 	}
 
@@ -300,11 +393,10 @@ func (e *Editor) drawEntityNode(row engine.ObjectInfo, byHandle map[engine.Handl
 		fmt.Sprintf("%s  [%d v%d]", row.Name, row.Handle.Index, row.Handle.Generation),
 		flags)
 
-	// OpenOnArrow above means a click on the label selects instead of collapsing.
+	// OpenOnArrow above means a click on the label selects instead of
+	// collapsing. Shift and Ctrl add and toggle, as in the viewport.
 	if imgui.IsItemClicked() {
-		e.selected = row.Handle
-		e.status = ""
-		e.reparentTarget = row.Parent
+		e.clickSelect(row.Handle)
 	}
 
 	if open {
@@ -383,7 +475,7 @@ func (e *Editor) drawComponentFields(component *engine.ComponentInfo) {
 			// The type name goes with the write: the draft is a snapshot, and if
 			// the entity's components changed underneath it the engine refuses
 			// rather than editing whatever now sits at that index.
-			if err := e.app.SetComponentField(e.selected, component.Index, component.Type, *field); err != nil {
+			if err := e.app.History.SetComponentField(e.selected, component.Index, component.Type, *field); err != nil {
 				e.status = err.Error()
 			} else {
 				e.status = ""
@@ -474,14 +566,14 @@ func (e *Editor) drawMaterial(info engine.ObjectInfo) {
 	}
 
 	if imgui.ColorEdit3("Base colour", &e.draftColor) {
-		if err := e.app.SetBaseColor(e.selected, mgl32.Vec3(e.draftColor)); err != nil {
+		if err := e.app.History.SetBaseColor(e.selected, mgl32.Vec3(e.draftColor)); err != nil {
 			e.status = err.Error()
 		}
 	}
 	imgui.SameLine()
 	if imgui.Button("Reset##colour") {
 		e.draftColor = [3]float32(engine.DefaultBaseColor)
-		if err := e.app.SetBaseColor(e.selected, engine.DefaultBaseColor); err != nil {
+		if err := e.app.History.SetBaseColor(e.selected, engine.DefaultBaseColor); err != nil {
 			e.status = err.Error()
 		}
 	}
@@ -541,7 +633,7 @@ func (e *Editor) drawReparent(info engine.ObjectInfo, rows []engine.ObjectInfo,
 
 	imgui.SameLine()
 	if imgui.Button("Reparent") {
-		if err := e.app.SetParent(e.selected, e.reparentTarget); err != nil {
+		if err := e.app.History.SetParent(e.selected, e.reparentTarget); err != nil {
 			e.status = err.Error()
 		} else {
 			e.status = ""
@@ -580,7 +672,7 @@ func (e *Editor) drawInspector(rows []engine.ObjectInfo, byHandle map[engine.Han
 	if !ok {
 		imgui.Text(fmt.Sprintf("Object %s no longer exists", e.selected))
 		if imgui.Button("Clear selection") {
-			e.selected = engine.NoHandle
+			e.deselect(e.selected)
 		}
 		return
 	}
@@ -594,6 +686,9 @@ func (e *Editor) drawInspector(rows []engine.ObjectInfo, byHandle map[engine.Han
 	if len(info.Children) > 0 {
 		imgui.Text(fmt.Sprintf("Children: %d", len(info.Children)))
 	}
+	if info.Prefab != "" {
+		e.drawPrefab(info)
+	}
 
 	e.drawReparent(info, rows, byHandle)
 
@@ -650,37 +745,36 @@ func (e *Editor) drawInspector(rows []engine.ObjectInfo, byHandle map[engine.Han
 		// The same call the gRPC handler makes, minus the serialization.
 		draft := e.draft
 		draft.Rotation = engine.QuatFromAxisAngle(e.draftRotation)
-		if err := e.app.UpdateTransform(e.selected, func(t *engine.Transform) {
+		if err := e.app.History.UpdateTransform(e.selected, func(t *engine.Transform) {
 			*t = draft
 		}); err != nil {
 			e.status = err.Error()
 		}
 	}
 
-	// Both go through the object API, which releases the entity's model back to
-	// the asset cache. World.Despawn on its own would drop the entity and leak
-	// its GPU memory. Frame runs on the frame-loop goroutine, which is where that
-	// release is allowed, so these are direct calls rather than App.Defer.
+	// Both go through the object API, by way of the history so they can be
+	// undone, which releases the entity's model back to the asset cache.
+	// World.Despawn on its own would drop the entity and leak its GPU memory.
+	// Frame runs on the frame-loop goroutine, which is where that release is
+	// allowed, so these are direct calls rather than App.Defer.
 	imgui.SameLine()
 	if imgui.Button("Delete") {
 		// The subtree, which is what deleting a thing means. Deleting the parent
 		// alone would leave its children behind, lifted to the scene root.
-		if err := e.app.DespawnTree(e.selected); err != nil {
+		if err := e.app.History.DespawnTree(e.selected); err != nil {
 			e.status = err.Error()
 		} else {
-			e.selected = engine.NoHandle
-			e.reparentTarget = engine.NoHandle
+			e.deselect(e.selected)
 		}
 	}
 
 	if len(info.Children) > 0 {
 		imgui.SameLine()
 		if imgui.Button("Delete, keep children") {
-			if err := e.app.DespawnObject(e.selected); err != nil {
+			if err := e.app.History.DespawnObject(e.selected); err != nil {
 				e.status = err.Error()
 			} else {
-				e.selected = engine.NoHandle
-				e.reparentTarget = engine.NoHandle
+				e.deselect(e.selected)
 			}
 		}
 	}
@@ -0,0 +1,53 @@
+package editor
+
+import (
+	"3d-engine/engine"
+	"3d-engine/gizmo"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+)
+
+// repeatClickPixels is how far the cursor may drift between two clicks for
+// the second still to count as "again, on the same spot" and cycle.
+const repeatClickPixels = 4
+
+// pickInViewport selects what a left click in the scene lands on.
+//
+// It only acts when nothing else wants the click: not over a panel, not on a
+// gizmo handle, and not while the cursor is captured for mouselook — which is
+// CapturesMouse's rule, applied here from the editor's side. Clicking empty
+// space clears the selection, the same as in every other editor. Shift and
+// Ctrl change the click the way they do in the hierarchy (see clickSelect), and
+// with either held a click on empty space leaves the selection alone: missing
+// by a pixel should not throw away a selection built up one click at a time.
+func (e *Editor) pickInViewport() {
+	if e.app.State.CaptureCursor || e.handles.capturesMouse() {
+		return
+	}
+	io := imgui.CurrentIO()
+	if io.WantCaptureMouse() || !imgui.IsMouseClickedBool(imgui.MouseButtonLeft) {
+		return
+	}
+
+	display := io.DisplaySize()
+	if display.X <= 0 || display.Y <= 0 {
+		return
+	}
+	view := e.app.Camera.ComputeView()
+	projection := e.app.Camera.ComputeProjection(int(display.X), int(display.Y))
+	mouse := io.MousePos()
+	ray := gizmo.ScreenRay(mouse.X, mouse.Y, display.X, display.Y, view, projection)
+
+	// A modified click never cycles: Ctrl-clicking the same spot twice should
+	// take the entity back out, not add the one behind it.
+	modified := io.KeyShift() || io.KeyCtrl()
+	dx, dy := mouse.X-e.lastPickAt.X, mouse.Y-e.lastPickAt.Y
+	repeat := e.picked && !modified && dx*dx+dy*dy <= repeatClickPixels*repeatClickPixels
+	e.lastPickAt, e.picked = mouse, true
+
+	hit := engine.NextPick(e.app.Pick(ray.Origin, ray.Dir), e.selected, repeat)
+	if hit.IsZero() && modified {
+		return
+	}
+	e.clickSelect(hit)
+}
@@ -0,0 +1,220 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+
+	"3d-engine/engine"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The selection is the active entity, e.selected, which the inspector edits,
+// plus e.alsoSelected, the rest in the order they were added. The bulk actions
+// and the gizmo act on all of it; everything that edits one entity — the
+// inspector, the component panel, the reparent picker — keeps working on the
+// active one, so a single-entity edit never silently lands on twenty.
+
+// selection is every selected entity, the active one first.
+func (e *Editor) selection() []engine.Handle {
+	if e.selected.IsZero() {
+		return nil
+	}
+	return append([]engine.Handle{e.selected}, e.alsoSelected...)
+}
+
+func (e *Editor) isSelected(handle engine.Handle) bool {
+	return handle == e.selected || slices.Contains(e.alsoSelected, handle)
+}
+
+// selectOnly makes handle the whole selection, or clears it for NoHandle.
+func (e *Editor) selectOnly(handle engine.Handle) {
+	e.alsoSelected = nil
+	e.setActive(handle)
+}
+
+// selectAll replaces the selection with handles, the first active.
+func (e *Editor) selectAll(handles []engine.Handle) {
+	if len(handles) == 0 {
+		e.selectOnly(engine.NoHandle)
+		return
+	}
+	e.selectOnly(handles[0])
+	e.alsoSelected = append(e.alsoSelected, handles[1:]...)
+}
+
+// clickSelect is what a click on an entity does to the selection, in the
+// hierarchy or the viewport: on its own it selects just that entity, with
+// Shift it adds the entity, and with Ctrl it toggles it. Either way the entity
+// clicked becomes the active one, so the inspector follows the last click.
+func (e *Editor) clickSelect(handle engine.Handle) {
+	io := imgui.CurrentIO()
+	switch {
+	case handle.IsZero() || e.selected.IsZero():
+		e.selectOnly(handle)
+	case io.KeyCtrl() && e.isSelected(handle):
+		e.deselect(handle)
+	case io.KeyCtrl() || io.KeyShift():
+		e.activate(handle)
+	default:
+		e.selectOnly(handle)
+	}
+}
+
+// activate adds handle to the selection, if it is not already in it, and
+// makes it the active entity.
+func (e *Editor) activate(handle engine.Handle) {
+	if handle == e.selected {
+		return
+	}
+	rest := slices.DeleteFunc(e.alsoSelected, func(h engine.Handle) bool { return h == handle })
+	e.alsoSelected = append([]engine.Handle{e.selected}, rest...)
+	e.setActive(handle)
+}
+
+// deselect takes handle out of the selection. If it was the active entity, the
+// one selected most recently before it takes over.
+func (e *Editor) deselect(handle engine.Handle) {
+	if handle != e.selected {
+		e.alsoSelected = slices.DeleteFunc(e.alsoSelected, func(h engine.Handle) bool { return h == handle })
+		return
+	}
+	if len(e.alsoSelected) == 0 {
+		e.selectOnly(engine.NoHandle)
+		return
+	}
+	next := e.alsoSelected[0]
+	e.alsoSelected = e.alsoSelected[1:]
+	e.setActive(next)
+}
+
+// setActive changes the active entity without touching the rest of the
+// selection. The reparent picker starts from the new entity's parent.
+func (e *Editor) setActive(handle engine.Handle) {
+	e.selected = handle
+	e.status = ""
+	e.reparentTarget = engine.NoHandle
+	if info, ok := e.app.ObjectInfo(handle); ok {
+		e.reparentTarget = info.Parent
+	}
+}
+
+// resolveSelection follows the selection through an undo or redo that brought
+// entities back under new handles.
+func (e *Editor) resolveSelection() {
+	e.selected = e.app.History.Resolve(e.selected)
+	for i, handle := range e.alsoSelected {
+		e.alsoSelected[i] = e.app.History.Resolve(handle)
+	}
+}
+
+// pruneSelection drops the non-active entities that no longer exist. The
+// active one is left for the inspector, which says it has gone rather than
+// letting it vanish from under the user.
+func (e *Editor) pruneSelection() {
+	e.alsoSelected = slices.DeleteFunc(e.alsoSelected, func(handle engine.Handle) bool {
+		_, ok := e.app.ObjectInfo(handle)
+		return !ok
+	})
+}
+
+// drawSelection is the bulk actions, applied to everything selected.
+//
+// Each goes through one History call, which is one transaction: a bulk move
+// or delete undoes in one step, and one that fails part-way — a duplicate that
+// meets an unregistered component, say — leaves the world as it was rather
+// than half done. The actions act on the top of the selection: an entity
+// whose ancestor is also selected is moved, copied or deleted with it, once.
+func (e *Editor) drawSelection() {
+	selection := e.selection()
+	if len(selection) == 0 {
+		imgui.TextDisabled("Shift+click adds to the selection, Ctrl+click toggles")
+		return
+	}
+	if !imgui.CollapsingHeaderTreeNodeFlagsV(fmt.Sprintf("Selection (%d)###selection", len(selection)), 0) {
+		return
+	}
+	imgui.TextDisabled("Shift+click adds to the selection, Ctrl+click toggles")
+
+	history := e.app.History
+	imgui.PushItemWidth(200)
+
+	imgui.DragFloat3V("Offset", &e.bulkOffset, 0.1, -1e6, 1e6, "%.2f", 0)
+	imgui.SameLine()
+	if imgui.Button("Move") {
+		e.report(history.MoveBy(selection, mgl32.Vec3(e.bulkOffset)))
+	}
+
+	imgui.ColorEdit3("Colour", &e.bulkColor)
+	imgui.SameLine()
+	if imgui.Button("Apply##bulk-colour") {
+		e.report(history.SetBaseColors(selection, mgl32.Vec3(e.bulkColor)))
+	}
+
+	imgui.InputTextWithHint("##group-name", "group name", &e.groupName, 0, nil)
+	imgui.SameLine()
+	if imgui.Button("Group") {
+		group, err := history.Group(selection, e.groupName)
+		if e.report(err) {
+			e.selectOnly(group)
+		}
+	}
+
+	// A prefab is one tree, so a selection of several has to become one
+	// first; saving only the active entity would quietly drop the others.
+	imgui.InputTextWithHint("##prefab-path", "prefabs/name.yml", &e.prefabPath, 0, nil)
+	imgui.SameLine()
+	single := len(e.app.TopLevel(selection)) == 1
+	imgui.BeginDisabledV(!single)
+	if imgui.Button("Save as prefab") {
+		e.report(e.app.SavePrefab(e.selected, e.prefabPath))
+	}
+	imgui.EndDisabled()
+	if !single {
+		imgui.SameLine()
+		imgui.TextDisabled("group the selection first")
+	}
+
+	imgui.PopItemWidth()
+
+	if imgui.Button("Duplicate") {
+		copies, err := history.Duplicate(selection)
+		if e.report(err) {
+			// The copies, not the originals: the next thing anyone does with a
+			// fresh duplicate is move it off the original.
+			e.selectAll(copies)
+		}
+	}
+	imgui.SameLine()
+	if imgui.Button("Delete all") {
+		if e.report(history.DespawnTrees(selection)) {
+			e.selectOnly(engine.NoHandle)
+		}
+	}
+}
+
+// report shows err as the editor's status, clearing it on success, and says
+// whether the action worked.
+func (e *Editor) report(err error) bool {
+	if err != nil {
+		e.status = err.Error()
+		return false
+	}
+	e.status = ""
+	return true
+}
+
+// drawPrefab is the inspector's line for a prefab instance: where it came
+// from, and a way back to it. Reverting replaces the instance, so the
+// selection moves to the entity that takes its place.
+func (e *Editor) drawPrefab(info engine.ObjectInfo) {
+	imgui.Text(fmt.Sprintf("Prefab: %s", info.Prefab))
+	imgui.SameLine()
+	if imgui.Button("Revert to prefab") {
+		reverted, err := e.app.History.RevertToPrefab(info.Handle)
+		if e.report(err) {
+			e.selectOnly(reverted)
+		}
+	}
+}
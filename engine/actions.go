@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"fmt"
+
 	"3d-engine/input"
 	"3d-engine/utils"
 
@@ -20,6 +22,12 @@ const (
 	ActionJump        = input.Action("jump")
 	ActionSprint      = input.Action("sprint")
 
+	// Look and zoom are analog. The mouse drives them by Motion, a distance
+	// moved this frame, and a stick by Value, a rate — see handleLook.
+	ActionLookX = input.Action("look_x")
+	ActionLookY = input.Action("look_y")
+	ActionZoom  = input.Action("zoom")
+
 	ActionQuit               = input.Action("quit")
 	ActionToggleCursor       = input.Action("toggle_cursor")
 	ActionToggleWireframe    = input.Action("toggle_wireframe")
@@ -29,10 +37,31 @@ const (
 	ActionTogglePlayerMode   = input.Action("toggle_player_mode")
 	ActionToggleCollisionBox = input.Action("toggle_collision_debug")
 	ActionToggleEditor       = input.Action("toggle_editor")
+
+	ActionUndo = input.Action("undo")
+	ActionRedo = input.Action("redo")
+)
+
+// ContextEditor is the input context pushed while the editor is showing. The
+// engine binds nothing in it; config.yml can, for keys that should only work
+// in the editor or should stop working there.
+const ContextEditor = "editor"
+
+// mouseSensitivity is degrees of turn per pixel of pointer movement, for the
+// default mouse bindings. A binding's own sensitivity replaces it.
+const mouseSensitivity = 0.1
+
+// The rates a full stick deflection turns and zooms at, in degrees a second.
+// A binding's sensitivity scales them.
+const (
+	stickLookRate = 120
+	stickZoomRate = 30
 )
 
 // defaultBindings reproduce the keys the engine used to hardcode, plus F1 for
-// the editor overlay.
+// the editor overlay. Look and zoom are on the mouse, as they were when the
+// window's callbacks drove the camera directly; config.yml shows them on a
+// gamepad too.
 func defaultBindings(m *input.Map) {
 	m.Bind(ActionMoveForward, glfw.KeyW)
 	m.Bind(ActionMoveBack, glfw.KeyS)
@@ -43,6 +72,11 @@ func defaultBindings(m *input.Map) {
 	m.Bind(ActionJump, glfw.KeySpace)
 	m.Bind(ActionSprint, glfw.KeyLeftShift)
 
+	// The pointer's y grows downwards, and moving it up should look up.
+	m.BindInput(ActionLookX, input.Binding{Device: input.DeviceMouseMotion, Code: int(input.MouseX), Sensitivity: mouseSensitivity})
+	m.BindInput(ActionLookY, input.Binding{Device: input.DeviceMouseMotion, Code: int(input.MouseY), Sensitivity: mouseSensitivity, Invert: true})
+	m.BindInput(ActionZoom, input.Binding{Device: input.DeviceMouseMotion, Code: int(input.ScrollY)})
+
 	m.Bind(ActionQuit, glfw.KeyEscape)
 	m.Bind(ActionToggleCursor, glfw.KeyC)
 	m.Bind(ActionToggleWireframe, glfw.KeyZ)
@@ -53,6 +87,11 @@ func defaultBindings(m *input.Map) {
 	m.Bind(ActionToggleCollisionBox, glfw.KeyB)
 	m.Bind(ActionToggleEditor, glfw.KeyF1)
 
+	// Chords, so they outrank Z as the wireframe toggle while Ctrl is held.
+	m.BindChord(ActionUndo, input.ModControl, glfw.KeyZ)
+	m.BindChord(ActionRedo, input.ModControl, glfw.KeyY)
+	m.BindChord(ActionRedo, input.ModControl|input.ModShift, glfw.KeyZ)
+
 	// Exempt from suppression: without this, focusing a text field in the
 	// editor would make the key that closes the editor stop working.
 	m.SetAlwaysActive(ActionToggleEditor)
@@ -70,6 +109,37 @@ func applyConfigBindings(m *input.Map, bindings map[string][]string) error {
 	return nil
 }
 
+// applyConfigContexts fills the input contexts config.yml declares. Like the
+// default context's, an action named here replaces whatever the context bound
+// before.
+func applyConfigContexts(m *input.Map, contexts map[string]utils.InputContextConfig) error {
+	for name, declared := range contexts {
+		context := m.Context(name)
+		context.Blocking = declared.Blocking
+		for action, keys := range declared.Actions {
+			if err := context.BindNames(input.Action(action), keys...); err != nil {
+				return fmt.Errorf("context %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// syncEditorContext keeps ContextEditor on the input stack exactly while an
+// overlay is showing. An overlay that cannot be hidden is showing for as long
+// as it is installed.
+func (a *App) syncEditorContext() {
+	showing := a.overlay != nil
+	if toggler, ok := a.overlay.(interface{ Visible() bool }); ok {
+		showing = toggler.Visible()
+	}
+	if showing {
+		a.Input.Push(ContextEditor)
+	} else {
+		a.Input.Pop(ContextEditor)
+	}
+}
+
 // handleActions runs the engine's own reactions to input. Continuous actions
 // read IsDown; toggles read JustPressed, which is a rising edge and therefore
 // self-debouncing — the old code hand-rolled a timing check per key.
@@ -83,6 +153,11 @@ func (a *App) handleActions() {
 		a.toggleEditor()
 	}
 
+	// Also ahead of the keyboard check: a focused text field never stopped the
+	// mouse from looking around, and Window already holds back the pointer
+	// whenever the editor is the one using it.
+	a.handleLook()
+
 	if a.overlayCapturesKeyboard() {
 		return
 	}
@@ -114,8 +189,38 @@ func (a *App) handleActions() {
 	if in.JustPressed(ActionToggleCollisionBox) {
 		a.State.CollisionDebug = !a.State.CollisionDebug
 	}
+
+	// After the keyboard-capture check, so Ctrl+Z in a focused text field
+	// edits the text rather than the world.
+	if in.JustPressed(ActionUndo) {
+		a.undo()
+	}
+	if in.JustPressed(ActionRedo) {
+		a.redo()
+	}
+}
+
+func (a *App) undo() {
+	label := a.History.UndoLabel()
+	if err := a.History.Undo(); err != nil {
+		utils.Logger().Println("Undo:", err)
+		return
+	}
+	utils.Logger().Infoln("Undid", label)
+}
+
+func (a *App) redo() {
+	label := a.History.RedoLabel()
+	if err := a.History.Redo(); err != nil {
+		utils.Logger().Println("Redo:", err)
+		return
+	}
+	utils.Logger().Infoln("Redid", label)
 }
 
+// handleMovement moves at the speed of the strongest input: a key is full
+// speed, and a stick half pushed walks at half. Holding both does not add up
+// to more than a key alone.
 func (a *App) handleMovement() {
 	in := a.Input
 	sprint := in.IsDown(ActionSprint)
@@ -123,17 +228,21 @@ func (a *App) handleMovement() {
 	// looking up does not lift the player off it.
 	planar := a.State.PlayerGravityMode
 
-	if in.IsDown(ActionMoveForward) {
-		a.Camera.ProcessForward(sprint, planar, a.deltaTime)
+	step := func(action input.Action) float32 {
+		return a.deltaTime * min(max(in.Value(action), 0), 1)
+	}
+
+	if dt := step(ActionMoveForward); dt > 0 {
+		a.Camera.ProcessForward(sprint, planar, dt)
 	}
-	if in.IsDown(ActionMoveBack) {
-		a.Camera.ProcessBack(sprint, planar, a.deltaTime)
+	if dt := step(ActionMoveBack); dt > 0 {
+		a.Camera.ProcessBack(sprint, planar, dt)
 	}
-	if in.IsDown(ActionMoveLeft) {
-		a.Camera.ProcessLeft(sprint, a.deltaTime)
+	if dt := step(ActionMoveLeft); dt > 0 {
+		a.Camera.ProcessLeft(sprint, dt)
 	}
-	if in.IsDown(ActionMoveRight) {
-		a.Camera.ProcessRight(sprint, a.deltaTime)
+	if dt := step(ActionMoveRight); dt > 0 {
+		a.Camera.ProcessRight(sprint, dt)
 	}
 
 	// Space and LeftCtrl fly the camera only when the player is not on the
@@ -141,11 +250,30 @@ func (a *App) handleMovement() {
 	if a.State.PlayerGravityMode {
 		return
 	}
-	if in.IsDown(ActionMoveUp) {
-		a.Camera.ProcessUp(sprint, a.deltaTime)
+	if dt := step(ActionMoveUp); dt > 0 {
+		a.Camera.ProcessUp(sprint, dt)
+	}
+	if dt := step(ActionMoveDown); dt > 0 {
+		a.Camera.ProcessDown(sprint, dt)
+	}
+}
+
+// handleLook turns and zooms the camera. The mouse reports how far it moved
+// this frame, which is already a turn; a stick reports how far it is pushed,
+// which is a turning speed and has to be scaled by the frame time. Both may be
+// bound to the same action, so each half is read on its own.
+func (a *App) handleLook() {
+	in := a.Input
+
+	yaw := in.Motion(ActionLookX) + in.Value(ActionLookX)*stickLookRate*a.deltaTime
+	pitch := in.Motion(ActionLookY) + in.Value(ActionLookY)*stickLookRate*a.deltaTime
+	if yaw != 0 || pitch != 0 {
+		a.Camera.Look(yaw, pitch)
 	}
-	if in.IsDown(ActionMoveDown) {
-		a.Camera.ProcessDown(sprint, a.deltaTime)
+
+	// Scrolling away from you narrows the view, as it always has.
+	if zoom := in.Motion(ActionZoom) + in.Value(ActionZoom)*stickZoomRate*a.deltaTime; zoom != 0 {
+		a.Camera.SetFov(a.Camera.CameraFov - zoom)
 	}
 }
 
@@ -156,7 +284,7 @@ func (a *App) setCursorCaptured(captured bool) {
 		// its idea of where the mouse was is stale. Without this the first
 		// position after re-capturing reads as one huge movement and the view
 		// snaps somewhere else.
-		a.Camera.ResetMouse()
+		a.devices.ResetCursor()
 		a.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
 		return
 	}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"testing"
+
+	"3d-engine/input"
+	"3d-engine/utils"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// TestLookTakesMouseAndStick: the mouse turns the camera by how far it moved,
+// a stick by how long it is held, and both can share an action.
+func TestLookTakesMouseAndStick(t *testing.T) {
+	a := saveTestApp(t)
+	a.Camera.CameraFov = 45
+	a.Input = input.NewMap()
+	defaultBindings(a.Input)
+	if err := applyConfigBindings(a.Input, map[string][]string{
+		"look_x": {"MouseX sensitivity=0.1", "GamepadRightX"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	a.deltaTime = 0.25
+
+	a.Input.Poll(input.State{
+		Mouse:       [2]float64{20, 10},
+		Scroll:      [2]float64{0, 2},
+		GamepadAxes: map[glfw.GamepadAxis]float32{glfw.AxisRightX: 0.5},
+	}, false)
+	a.handleLook()
+
+	// 20 pixels at 0.1 is 2 degrees, and half a stick for a quarter second
+	// another 15; the pointer moving down 10 pixels looks down by 1.
+	if a.Camera.Yaw != -90+2+15 || a.Camera.Pitch != -1 {
+		t.Errorf("yaw %g, pitch %g", a.Camera.Yaw, a.Camera.Pitch)
+	}
+	if a.Camera.CameraFov != 43 {
+		t.Errorf("two notches of the wheel left fov at %g", a.Camera.CameraFov)
+	}
+}
+
+// TestStickWalksAtItsDeflection: a stick half pushed covers half the ground
+// a key does, and a key and a stick together no more than the key.
+func TestStickWalksAtItsDeflection(t *testing.T) {
+	a := saveTestApp(t)
+	a.Camera.CameraSpeed = 4
+	a.Camera.Look(0, 0)
+	a.Input = input.NewMap()
+	defaultBindings(a.Input)
+	if err := applyConfigBindings(a.Input, map[string][]string{
+		"move_forward": {"W", "-GamepadLeftY deadzone=0.5"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	a.deltaTime = 1
+
+	walk := func(state input.State) float32 {
+		start := a.Camera.CameraPos
+		a.Input.Poll(state, false)
+		a.handleMovement()
+		return a.Camera.CameraPos.Sub(start).Len()
+	}
+
+	key := walk(input.State{Keys: map[glfw.Key]bool{glfw.KeyW: true}})
+	half := walk(input.State{GamepadAxes: map[glfw.GamepadAxis]float32{glfw.AxisLeftY: -0.75}})
+	both := walk(input.State{
+		Keys:        map[glfw.Key]bool{glfw.KeyW: true},
+		GamepadAxes: map[glfw.GamepadAxis]float32{glfw.AxisLeftY: -1},
+	})
+	if key != 4 || half != 2 || both != 4 {
+		t.Errorf("walked %g on a key, %g on half a stick, %g on both", key, half, both)
+	}
+}
+
+// TestEditorContext: the editor's context is pushed while the editor shows,
+// and config binds into it.
+func TestEditorContext(t *testing.T) {
+	a := saveTestApp(t)
+	a.Input = input.NewMap()
+	defaultBindings(a.Input)
+	if err := applyConfigContexts(a.Input, map[string]utils.InputContextConfig{
+		ContextEditor: {Actions: map[string][]string{"toggle_flashlight": {}}},
+		"menu":        {Blocking: true, Actions: map[string][]string{"close_menu": {"Escape"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigContexts(a.Input, map[string]utils.InputContextConfig{
+		"vehicle": {Actions: map[string][]string{"brake": {"Hyper+Q"}}},
+	}); err == nil {
+		t.Error("a bad binding in a context was accepted")
+	}
+
+	overlay := &fakeOverlay{visible: true}
+	a.SetOverlay(overlay)
+	a.syncEditorContext()
+	held := input.State{Keys: map[glfw.Key]bool{glfw.KeyF: true}}
+	a.Input.Poll(held, false)
+	if a.Input.IsDown(ActionToggleFlashlight) || a.Input.Describe(ActionToggleFlashlight) != "unbound (editor)" {
+		t.Errorf("the editor did not switch the flashlight off: %q", a.Input.Describe(ActionToggleFlashlight))
+	}
+
+	overlay.visible = false
+	a.syncEditorContext()
+	a.Input.Poll(held, false)
+	if !a.Input.IsDown(ActionToggleFlashlight) {
+		t.Error("the flashlight key stayed off with the editor hidden")
+	}
+	if !a.Input.Context("menu").Blocking {
+		t.Error("the menu context was not made blocking")
+	}
+}
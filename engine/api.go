@@ -45,6 +45,11 @@ type ObjectSpec struct {
 	// in the world. It is resolved before anything is spawned, so a stale handle
 	// fails without leaving a half-attached entity behind.
 	Parent Handle
+
+	// Prefab marks the entity as the root of an instance of that prefab file,
+	// so a save writes it back as a reference. The tree itself is already in
+	// the rest of the spec: buildSpec expanded it.
+	Prefab string
 }
 
 // ObjectInfo is a read-only snapshot, taken under the world lock so callers
@@ -66,6 +71,14 @@ type ObjectInfo struct {
 	// the tree at its own pace, and so one deep subtree does not make every
 	// snapshot expensive.
 	Children []Handle
+
+	// Prefab is the prefab file the entity is an instance of, if it is the root
+	// of one.
+	Prefab string
+
+	// Layer is the scene layer the entity belongs to, or empty for the scene
+	// itself.
+	Layer string
 }
 
 // BuildObject constructs an entity and acquires its assets without adding it to
@@ -76,6 +89,7 @@ type ObjectInfo struct {
 func (a *App) BuildObject(spec ObjectSpec) (*Entity, error) {
 	entity := NewEntity(spec.Name)
 	entity.SetTransform(spec.Transform)
+	entity.prefab = spec.Prefab
 
 	if spec.Model != "" {
 		model, err := a.Assets.Acquire(spec.Model)
@@ -329,6 +343,24 @@ func (a *App) ObjectInfo(handle Handle) (ObjectInfo, bool) {
 	return info, found
 }
 
+// WorldMatrices returns an entity's local-to-world matrix and its parent's, the
+// identity for an entity at the root. The parent's is what turns a world-space
+// edit — a gizmo drag, say — back into the local transform UpdateTransform
+// takes. Safe from any goroutine: the cached matrices are rebuilt under the
+// write lock.
+func (a *App) WorldMatrices(handle Handle) (world, parent mgl32.Mat4, ok bool) {
+	parent = mgl32.Ident4()
+
+	ok = a.World.Mutate(handle, func(entity *Entity) {
+		world = entity.WorldMatrix()
+		if entity.Parent() != nil {
+			parent = entity.Parent().WorldMatrix()
+		}
+	})
+
+	return world, parent, ok
+}
+
 // ListObjects snapshots every entity in the world.
 func (a *App) ListObjects() []ObjectInfo {
 	var objects []ObjectInfo
@@ -348,6 +380,8 @@ func describe(entity *Entity) ObjectInfo {
 		Handle:    entity.Handle(),
 		Name:      entity.Name,
 		Transform: entity.Transform(),
+		Prefab:    entity.prefab,
+		Layer:     entity.owner(),
 	}
 	if entity.Renderer != nil {
 		info.BaseColor = entity.Renderer.BaseColor
@@ -363,3 +397,51 @@ func describe(entity *Entity) ObjectInfo {
 	}
 	return info
 }
+
+// CameraPose is where the camera is and where it looks: yaw and pitch in
+// degrees, as the mouse turns it, and the vertical field of view in degrees.
+type CameraPose struct {
+	Position mgl32.Vec3
+	Yaw      float32
+	Pitch    float32
+	Fov      float32
+}
+
+// CameraPose reads the camera. The frame loop moves it, so this must run on
+// the frame-loop goroutine.
+func (a *App) CameraPose() CameraPose {
+	return CameraPose{
+		Position: a.Camera.CameraPos,
+		Yaw:      a.Camera.Yaw,
+		Pitch:    a.Camera.Pitch,
+		Fov:      a.Camera.CameraFov,
+	}
+}
+
+// SetCameraPose moves the camera, clamping the pitch and fov as the mouse and
+// wheel do. The player's velocity is dropped with the old position: a camera
+// put somewhere mid-fall would otherwise carry on falling from there.
+//
+// It must run on the frame-loop goroutine.
+func (a *App) SetCameraPose(pose CameraPose) {
+	a.Camera.CameraPos = pose.Position
+	a.Camera.SetOrientation(pose.Yaw, pose.Pitch)
+	a.Camera.SetFov(pose.Fov)
+	a.playerVelocity = mgl32.Vec3{}
+}
+
+// ResetCamera puts the camera where the current scene starts it, with the
+// configured fov. It must run on the frame-loop goroutine.
+func (a *App) ResetCamera() {
+	spawn := a.Scenes.CameraSpawn()
+	fov := a.Camera.CameraFov
+	if a.Config != nil {
+		fov = a.Config.Fov
+	}
+	a.SetCameraPose(CameraPose{
+		Position: mgl32.Vec3(spawn.Position),
+		Yaw:      spawn.Yaw,
+		Pitch:    spawn.Pitch,
+		Fov:      fov,
+	})
+}
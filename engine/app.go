@@ -9,9 +9,10 @@ import (
 	"3d-engine/input"
 	"3d-engine/object"
 	"3d-engine/shaders"
+	"3d-engine/textures"
 	"3d-engine/utils"
 	"fmt"
-	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"sort"
@@ -37,6 +38,12 @@ type Options struct {
 	RPCAddr    string
 	DisableRPC bool
 
+	// RecordPath records the session's input to a file, for ReplayPath to
+	// play back; replay.go describes what it holds. At most one of the two
+	// may be set.
+	RecordPath string
+	ReplayPath string
+
 	// RegisterComponents adds the game's component types to the registry.
 	//
 	// It exists because New loads the initial scene before it returns, so
@@ -94,9 +101,12 @@ type App struct {
 	Camera *camera.Camera
 	Scenes *SceneManager
 
-	// Input maps keys to named actions. Rebind through config or at runtime;
-	// nothing downstream knows which key an action came from.
+	// Input maps keys, mouse and gamepad inputs to named actions. Rebind
+	// through config or at runtime; nothing downstream knows which key an
+	// action came from.
 	Input *input.Map
+	// devices is what Input polls: the window, and the gamepad beside it.
+	devices *input.Window
 
 	// Components maps scene-file type names to Go constructors. A game
 	// registers its behaviours here before calling Run.
@@ -113,6 +123,11 @@ type App struct {
 	lastFrameCounter float32
 	nbFrames         int
 
+	// elapsed is game time, the sum of every frame's deltaTime. Unlike the
+	// wall clock it is the same on a replay as it was when recorded, so the
+	// gameplay timers read it instead.
+	elapsed float64
+
 	// World holds the scene's entities and owns their locking.
 	World *World
 
@@ -120,14 +135,42 @@ type App struct {
 	// when the last scene using them goes away.
 	Assets *assets.Cache
 
+	// History is the undoable way to edit the world. The editor and the RPC
+	// server make their changes through it; Ctrl+Z and Ctrl+Y walk it.
+	History *History
+
 	// commands carries work from other goroutines back onto the frame loop.
 	commands commandQueue
 
+	// changes hands each frame's world changes to the subscribers.
+	changes changeHub
+
+	// frames schedules the frame streams, and captureTargets holds an
+	// offscreen framebuffer for each size they render at.
+	frames         frameHub
+	captureTargets map[frameSize]*captureTarget
+
+	// uploadDeadline and uploadStepped meter background asset uploads within
+	// one frame; see uploadBudgetLeft.
+	uploadDeadline time.Time
+	uploadStepped  bool
+
 	lightingShader *shaders.Shader
 	debugBoxShader *shaders.Shader
+	outlineShader  *shaders.Shader
 	debugRenderer  *debugBoxRenderer
 	skybox         *object.Skybox
 
+	// highlight is what SetHighlight last asked to be outlined.
+	highlight []Handle
+
+	// cameraBlock and lightsBlock back the uniform blocks every program
+	// shares; the Std140 buffers are kept to reuse their memory each frame.
+	cameraBlock *shaders.UniformBuffer
+	lightsBlock *shaders.UniformBuffer
+	cameraData  shaders.Std140
+	lightsData  shaders.Std140
+
 	physicsDeltaTime float32
 	gravityStrength  float32
 	gravityDirection mgl32.Vec3
@@ -143,12 +186,18 @@ type App struct {
 	playerGrounded     bool
 	lastJumpTime       float64
 
+	// recorder and replay are set while the session's input is recorded or
+	// played back; see replay.go.
+	recorder *inputRecorder
+	replay   *inputReplay
+
 	collisionDebugDistance float32
 
 	// overlay draws the editor UI on top of the finished frame, if one is set.
 	overlay Overlay
 
 	rpc             *grpc.Server
+	gateway         *http.Server
 	glfwInitialized bool
 }
 
@@ -158,6 +207,10 @@ type App struct {
 func New(opts Options) (*App, error) {
 	opts.applyDefaults()
 
+	if opts.RecordPath != "" && opts.ReplayPath != "" {
+		return nil, fmt.Errorf("cannot record and replay input in one session")
+	}
+
 	config, err := utils.LoadConfig(opts.ConfigPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not load config: %w", err)
@@ -204,6 +257,7 @@ func New(opts Options) (*App, error) {
 	a.Scenes = NewSceneManager(a, config, opts.ScenePath)
 	// Give despawned entities a chance to run OnDestroy.
 	a.World.onDespawn = a.destroyComponents
+	a.History = NewHistory(a)
 
 	if err := a.initWindow(); err != nil {
 		a.Close()
@@ -214,44 +268,89 @@ func New(opts Options) (*App, error) {
 		return nil, err
 	}
 
+	if config.Assets.HotReload {
+		a.watchAssets()
+	}
+
+	// A replay starts where its recording did, whatever the config now says
+	// the initial scene is.
+	scene := a.Scenes.ResolveInitialScenePath()
+	var replay *inputReplay
+	if opts.ReplayPath != "" {
+		var header recordingHeader
+		if replay, header, err = openReplay(opts.ReplayPath); err != nil {
+			a.Close()
+			return nil, err
+		}
+		scene = header.Scene
+	}
+
 	a.Camera = camera.NewCamera(config)
-	if err := a.Scenes.LoadScene(a.Scenes.ResolveInitialScenePath()); err != nil {
+	if err := a.Scenes.LoadScene(scene); err != nil {
+		if replay != nil {
+			replay.file.Close()
+		}
 		a.Close()
 		return nil, fmt.Errorf("could not load scene: %w", err)
 	}
 	a.resetDynamicState()
 
-	// Wrapped rather than passed straight through, for two reasons.
+	if replay != nil {
+		a.startReplay(replay, opts.ReplayPath)
+	}
+	if opts.RecordPath != "" {
+		if err := a.startRecording(opts.RecordPath, scene); err != nil {
+			a.Close()
+			return nil, err
+		}
+	}
+
+	// The pointer and the wheel reach the input map through a.devices, and
+	// only when they are the game's to use.
 	//
 	// State.CaptureCursor is the authority on whether the mouse is flying the
 	// camera at all: with the cursor released the pointer belongs to the editor,
 	// and moving it towards a panel must not drag the camera along on the way.
 	// The overlay check alone is not enough, because an overlay only claims the
 	// pointer once it is already over one of its windows — every pixel of the 3D
-	// view in between would still have turned the camera.
+	// view in between would still have turned the camera. While the pointer is
+	// not ours, its origin is forgotten, so taking it back does not count the
+	// distance it travelled meanwhile.
+	a.devices = &input.Window{Window: a.Window}
 	a.Window.SetCursorPosCallback(func(w *glfw.Window, xpos, ypos float64) {
 		if !a.State.CaptureCursor || a.overlayCapturesMouse() {
+			a.devices.ResetCursor()
 			return
 		}
-		a.Camera.MouseCallback(w, xpos, ypos)
+		a.devices.CursorMoved(xpos, ypos)
 	})
 	a.Window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
 		if a.overlayCapturesMouse() {
 			return
 		}
-		a.Camera.ScrollCallback(w, xoff, yoff)
+		a.devices.Scrolled(xoff, yoff)
 	})
 	defaultBindings(a.Input)
 	if err := applyConfigBindings(a.Input, config.Input.Actions); err != nil {
 		a.Close()
 		return nil, fmt.Errorf("could not apply input bindings: %w", err)
 	}
+	if err := applyConfigContexts(a.Input, config.Input.Contexts); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("could not apply input bindings: %w", err)
+	}
 
 	if !a.rpcDisabled() {
-		if err := a.startRPCServer(a.rpcAddress()); err != nil {
+		if err := a.startRPCServer(a.rpcAddress(), config.RPC); err != nil {
 			a.Close()
 			return nil, err
 		}
+		if config.RPC.HTTPAddress != "" {
+			if err := a.startGateway(config.RPC); err != nil {
+				a.Close()
+				return nil, err
+			}
+		}
 	}
 
 	return a, nil
@@ -266,6 +365,14 @@ func (a *App) initWindow() error {
 	glfw.WindowHint(glfw.ContextVersionMajor, 4)
 	glfw.WindowHint(glfw.ContextVersionMinor, 6)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Colour maps are sampled from sRGB textures into linear values, so the
+	// framebuffer has to encode back to sRGB on write or everything comes out
+	// too dark. Colours that are not textures are decoded on upload to match;
+	// see linearColor.
+	glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	// The selection outline is drawn through the stencil buffer. Eight bits is
+	// GLFW's default already; asking keeps it from depending on that.
+	glfw.WindowHint(glfw.StencilBits, 8)
 
 	window, err := glfw.CreateWindow(a.width, a.height, a.opts.Title, nil, nil)
 	if err != nil {
@@ -283,6 +390,7 @@ func (a *App) initWindow() error {
 
 	gl.Enable(gl.DEPTH_TEST)
 	gl.Enable(gl.CULL_FACE)
+	gl.Enable(gl.FRAMEBUFFER_SRGB)
 
 	gl.CullFace(gl.BACK)
 	gl.FrontFace(gl.CCW)
@@ -299,7 +407,11 @@ func (a *App) initWindow() error {
 func (a *App) initResources() error {
 	var err error
 
-	a.lightingShader, err = shaders.CreateShaderProgram("lighting.vert", "lighting.frag")
+	// Before anything acquires a texture, since the level is baked in at
+	// upload.
+	textures.SetDefaultAnisotropy(a.Config.Textures.Anisotropy)
+
+	a.lightingShader, err = shaders.CreateShaderProgramWithDefines("lighting.vert", "lighting.frag", shaderDefines())
 	if err != nil {
 		return fmt.Errorf("could not create cube shader: %w", err)
 	}
@@ -310,6 +422,14 @@ func (a *App) initResources() error {
 	}
 	a.debugRenderer = newDebugBoxRenderer()
 
+	a.outlineShader, err = shaders.CreateShaderProgram("outline.vert", "outline.frag")
+	if err != nil {
+		return fmt.Errorf("could not create outline shader: %w", err)
+	}
+
+	a.cameraBlock = shaders.NewUniformBuffer(shaders.CameraBlockBinding)
+	a.lightsBlock = shaders.NewUniformBuffer(shaders.LightsBlockBinding)
+
 	// The magenta missing-texture image used to be loaded here and bound by every
 	// mesh draw. Untextured meshes are lit through material.base_color now, so
 	// nothing samples it.
@@ -325,6 +445,14 @@ func (a *App) initResources() error {
 	return nil
 }
 
+// shaderDefines are the constants the engine owns and the shaders read, so
+// each has exactly one definition on the Go side.
+func shaderDefines() shaders.Defines {
+	return shaders.Defines{
+		"MAX_POINT_LIGHT": strconv.Itoa(MaxPointLights),
+	}
+}
+
 // Quit asks the frame loop to stop after the current frame. Safe from any
 // goroutine.
 func (a *App) Quit() {
@@ -353,9 +481,7 @@ func (a *App) Run() error {
 	}()
 
 	for !a.Window.ShouldClose() {
-		currentFrame := float32(glfw.GetTime())
-		a.deltaTime = currentFrame - a.lastFrame
-		a.lastFrame = currentFrame
+		a.beginFrame(float32(glfw.GetTime()))
 
 		utils.Logger().Verbosef("Frame time: %.2f ms\n", a.deltaTime*1000)
 
@@ -364,26 +490,41 @@ func (a *App) Run() error {
 		a.processInput()
 
 		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
 
 		// Anything that needs the GL thread — scene loads today, spawns and
 		// asset loads later — runs here.
 		a.drainCommands()
+		a.awaitLoads()
+		a.Scenes.updateStreaming()
 
+		ticked := false
 		select {
 		case <-ticker.C:
-			a.fixedUpdate()
+			ticked = true
 		default:
 		}
+		for range a.fixedSteps(ticked) {
+			a.fixedUpdate()
+		}
 
 		a.startAndUpdateComponents()
+		// After everything that can change the world this frame, so each
+		// subscriber gets the frame's changes as one batch.
+		a.publishChanges()
 
 		a.render()
+		a.captureFrames()
 
 		if a.overlay != nil {
+			// The UI picks its colours in display space already; letting the
+			// sRGB encode run over it would wash every panel out.
+			gl.Disable(gl.FRAMEBUFFER_SRGB)
 			a.overlay.Frame(a)
+			gl.Enable(gl.FRAMEBUFFER_SRGB)
 		}
 
+		a.endFrame()
 		a.Window.SwapBuffers()
 		glfw.PollEvents()
 	}
@@ -391,12 +532,28 @@ func (a *App) Run() error {
 	return nil
 }
 
-// Close releases the GL resources, the window and the RPC listener. It is safe
+// watchAssets turns on hot reload: models and textures are re-imported when
+// their files change, parsed off the frame loop and swapped in on it.
+func (a *App) watchAssets() {
+	interval := time.Duration(a.Config.Assets.HotReloadIntervalMs) * time.Millisecond
+	a.Assets.WatchFiles(interval, func(fn func()) {
+		a.Defer(func(*App) error {
+			fn()
+			return nil
+		})
+	})
+	utils.Logger().Printf("Watching assets for changes every %v", interval)
+}
+
+// Close releases the GL resources, the window and the RPC listeners. It is safe
 // to call on a partially constructed App.
 func (a *App) Close() {
 	// Release anyone blocked in Do before the loop stops draining.
 	a.commands.close()
 
+	a.stopRecording()
+	a.stopReplay()
+
 	if a.overlay != nil {
 		a.overlay.Close()
 		a.overlay = nil
@@ -405,6 +562,9 @@ func (a *App) Close() {
 	// Drop the live scene so shutdown frees what it allocated. Clearing the
 	// world first runs OnDestroy while the models are still valid; releasing
 	// them beforehand would hand destructors freed GL objects.
+	if a.Assets != nil {
+		a.Assets.StopWatching()
+	}
 	if a.World != nil && a.Assets != nil {
 		outgoing := a.currentSceneEntities()
 		a.World.Replace(nil)
@@ -415,11 +575,22 @@ func (a *App) Close() {
 		a.rpc.Stop()
 		a.rpc = nil
 	}
+	a.stopGateway()
 	if a.debugRenderer != nil {
 		a.debugRenderer.Delete()
 		a.debugRenderer = nil
 	}
-	for _, shader := range []**shaders.Shader{&a.lightingShader, &a.debugBoxShader} {
+	for size, target := range a.captureTargets {
+		target.Delete()
+		delete(a.captureTargets, size)
+	}
+	for _, block := range []**shaders.UniformBuffer{&a.cameraBlock, &a.lightsBlock} {
+		if *block != nil {
+			(*block).Delete()
+			*block = nil
+		}
+	}
+	for _, shader := range []**shaders.Shader{&a.lightingShader, &a.debugBoxShader, &a.outlineShader} {
 		if *shader != nil {
 			(*shader).Delete()
 			*shader = nil
@@ -465,20 +636,23 @@ type renderItem struct {
 func (a *App) render() {
 	shader := a.lightingShader
 
-	// Lighting
-	shader.Use()
-	shader.SetVec3Val("viewPos", a.Camera.CameraPos)
-
 	projection := a.Camera.ComputeProjection(a.width, a.height)
-	shader.SetMat4("projection", projection)
 	view := a.Camera.ComputeView()
-	shader.SetMat4("view", view)
 
+	// The camera block is shared by the lighting and debug programs, so it is
+	// written once here rather than per program.
+	a.cameraData.Reset()
+	packCamera(&a.cameraData, projection, view, a.Camera.CameraPos)
+	a.cameraBlock.Upload(a.cameraData.Bytes())
+
+	// Lighting
+	shader.Use()
 	shader.SetInt("skybox", int32(a.skybox.SkyboxTextureUnit))
 
 	opaqueItems := make([]renderItem, 0)
 	transparentItems := make([]renderItem, 0)
 	debugBoxes := make([]debugBox, 0)
+	outlines := make([]outlineItem, 0)
 	lights := lightSet{}
 
 	a.World.Read(func(entities []*Entity) {
@@ -491,6 +665,7 @@ func (a *App) render() {
 			model := entity.Renderer.Model
 			modelMat := entity.WorldMatrix()
 			baseColor := entity.Renderer.BaseColor
+			highlighted := a.isHighlighted(entity.Handle())
 
 			if a.State.CollisionDebug {
 				a.appendDebugBox(&debugBoxes, entity.WorldAABB(), mgl32.Vec3{1.0, 0.2, 0.2})
@@ -498,6 +673,9 @@ func (a *App) render() {
 
 			for i := range model.Meshes {
 				mesh := &model.Meshes[i]
+				if highlighted {
+					outlines = append(outlines, outlineItem{mesh: mesh, modelMat: modelMat})
+				}
 				if a.State.CollisionDebug {
 					a.appendDebugBox(&debugBoxes, mesh.WorldAABB(modelMat), mgl32.Vec3{1.0, 0.8, 0.2})
 				}
@@ -525,7 +703,7 @@ func (a *App) render() {
 		utils.Logger().Printf("Scene has %d point lights beyond the shader limit of %d; extras ignored",
 			lights.droppedPoints, MaxPointLights)
 	}
-	a.computeLight(shader, &lights)
+	a.computeLight(&lights)
 
 	if a.State.CollisionDebug && a.State.PlayerGravityMode {
 		player := a.playerAABB(a.Camera.CameraPos)
@@ -538,7 +716,7 @@ func (a *App) render() {
 
 	for _, item := range opaqueItems {
 		shader.SetMat4("model", item.modelMat)
-		shader.SetVec3Val("material.base_color", item.baseColor)
+		shader.SetVec3Val("material.base_color", linearColor(item.baseColor))
 		item.mesh.DrawPass(shader, false)
 	}
 
@@ -547,14 +725,12 @@ func (a *App) render() {
 	})
 	for _, item := range transparentItems {
 		shader.SetMat4("model", item.modelMat)
-		shader.SetVec3Val("material.base_color", item.baseColor)
+		shader.SetVec3Val("material.base_color", linearColor(item.baseColor))
 		item.mesh.DrawPass(shader, true)
 	}
 
 	if a.State.CollisionDebug {
 		a.debugBoxShader.Use()
-		a.debugBoxShader.SetMat4("projection", a.Camera.ComputeProjection(a.width, a.height))
-		a.debugBoxShader.SetMat4("view", a.Camera.ComputeView())
 		gl.Disable(gl.CULL_FACE)
 		for _, box := range debugBoxes {
 			a.debugRenderer.Draw(a.debugBoxShader, box.min, box.max, box.color)
@@ -562,7 +738,27 @@ func (a *App) render() {
 		gl.Enable(gl.CULL_FACE)
 	}
 
-	a.skybox.RenderSkybox(a.Camera.ComputeView().Mat3().Mat4(), a.Camera.ComputeProjection(a.width, a.height))
+	a.skybox.RenderSkybox(view.Mat3().Mat4(), projection)
+
+	// After the skybox, which fills every pixel the scene left at the far
+	// plane — including the part of the outline that stands out against the sky.
+	a.drawOutlines(outlines)
+}
+
+func (a *App) isHighlighted(handle Handle) bool {
+	for _, highlighted := range a.highlight {
+		if highlighted == handle {
+			return true
+		}
+	}
+	return false
+}
+
+// packCamera lays out the Camera block declared in shaders/camera.glsl.
+func packCamera(w *shaders.Std140, projection, view mgl32.Mat4, position mgl32.Vec3) {
+	w.Mat4(projection)
+	w.Mat4(view)
+	w.Vec3(position)
 }
 
 func (a *App) fixedUpdate() {
@@ -624,10 +820,10 @@ func (a *App) stepPlayer(entities []*Entity) {
 	// Reads the action rather than the key, so rebinding jump in config works
 	// here too. IsDown rather than JustPressed: holding jump should keep
 	// hopping, and the grounded check already gates repeats.
-	if a.Input.IsDown(ActionJump) && a.playerGrounded && glfw.GetTime()-a.lastJumpTime >= 0.2 {
+	if a.Input.IsDown(ActionJump) && a.playerGrounded && a.elapsed-a.lastJumpTime >= 0.2 {
 		a.playerVelocity = a.playerVelocity.Add(a.gravityDirection.Mul(-a.playerJumpSpeed))
 		a.playerGrounded = false
-		a.lastJumpTime = glfw.GetTime()
+		a.lastJumpTime = a.elapsed
 	}
 
 	a.Camera.CameraPos = a.Camera.CameraPos.Add(a.playerVelocity.Mul(a.physicsDeltaTime))
@@ -689,79 +885,46 @@ func (a *App) appendDebugBox(boxes *[]debugBox, box object.AABB, color mgl32.Vec
 // computeLight uploads the frame's lights. Everything here used to be
 // hardcoded: one fixed directional light, nb_point_light pinned to 0 so the
 // shader's point-light loop never ran, and a flashlight built from constants.
-// It is all scene data now.
-func (a *App) computeLight(shader *shaders.Shader, lights *lightSet) {
-	if lights.directional != nil {
-		shader.SetVec3Val("dirLight.direction", lights.directional.Direction)
-		shader.SetVec3Val("dirLight.ambient", lights.directional.Ambient)
-		shader.SetVec3Val("dirLight.diffuse", lights.directional.Diffuse)
-		shader.SetVec3Val("dirLight.specular", lights.directional.Specular)
-	} else {
-		// No sun in the scene: contribute nothing rather than leaving whatever
-		// the previous frame uploaded.
-		shader.SetVec3("dirLight.direction", 0, -1, 0)
-		shader.SetVec3("dirLight.ambient", 0, 0, 0)
-		shader.SetVec3("dirLight.diffuse", 0, 0, 0)
-		shader.SetVec3("dirLight.specular", 0, 0, 0)
-	}
-
-	shader.SetInt("nb_point_light", int32(len(lights.points)))
-	for i, placed := range lights.points {
-		prefix := "pointLights[" + strconv.Itoa(i) + "]."
-		shader.SetVec3Val(prefix+"position", placed.position)
-		shader.SetVec3Val(prefix+"ambient", placed.light.Ambient)
-		shader.SetVec3Val(prefix+"diffuse", placed.light.Diffuse)
-		shader.SetVec3Val(prefix+"specular", placed.light.Specular)
-		shader.SetFloat(prefix+"constant", placed.light.Constant)
-		shader.SetFloat(prefix+"linear", placed.light.Linear)
-		shader.SetFloat(prefix+"quadratic", placed.light.Quadratic)
-	}
-
-	a.uploadSpotLight(shader, lights.spot)
-}
-
-func (a *App) uploadSpotLight(shader *shaders.Shader, placed *placedSpotLight) {
+// It is all scene data now, packed into the Lights block in one upload rather
+// than a few dozen Set* calls per frame.
+func (a *App) computeLight(lights *lightSet) {
+	a.lightsData.Reset()
+	packLights(&a.lightsData, lights, a.resolveSpotLight(lights.spot))
+	a.lightsBlock.Upload(a.lightsData.Bytes())
+}
+
+// resolveSpotLight applies the flashlight rules to the scene's spot light.
+func (a *App) resolveSpotLight(placed *placedSpotLight) spotUniforms {
 	if placed == nil {
-		shader.SetBool("spotLight.isEnabled", false)
-		return
+		return spotUniforms{}
 	}
 
 	light := placed.light
-	position := placed.position
-	direction := light.Direction
+	spot := spotUniforms{
+		light:     light,
+		position:  placed.position,
+		direction: light.Direction,
+		enabled:   light.Enabled,
+	}
 
 	if light.FollowCamera {
 		// This is the flashlight: it rides the camera and the F key gates it.
 		if !a.State.FlashLight {
-			shader.SetBool("spotLight.isEnabled", false)
-			return
+			spot.enabled = false
+			return spot
 		}
-		position = a.Camera.CameraPos
-		direction = a.Camera.CameraFront
+		spot.position = a.Camera.CameraPos
+		spot.direction = a.Camera.CameraFront
 	}
-
-	if !light.Enabled {
-		shader.SetBool("spotLight.isEnabled", false)
-		return
-	}
-
-	shader.SetVec3Val("spotLight.position", position)
-	shader.SetVec3Val("spotLight.direction", direction)
-	shader.SetVec3Val("spotLight.ambient", light.Ambient)
-	shader.SetVec3Val("spotLight.diffuse", light.Diffuse)
-	shader.SetVec3Val("spotLight.specular", light.Specular)
-	shader.SetFloat("spotLight.constant", light.Constant)
-	shader.SetFloat("spotLight.linear", light.Linear)
-	shader.SetFloat("spotLight.quadratic", light.Quadratic)
-	shader.SetFloat("spotLight.cutOff", float32(math.Cos(float64(mgl32.DegToRad(light.CutOff)))))
-	shader.SetFloat("spotLight.outerCutOff", float32(math.Cos(float64(mgl32.DegToRad(light.OuterCutOff)))))
-	shader.SetBool("spotLight.isEnabled", true)
+	return spot
 }
 
-// processInput samples the keyboard once and lets handleActions react to the
+// processInput samples the devices once and lets handleActions react to the
 // snapshot.
 func (a *App) processInput() {
-	a.Input.Poll(input.Window{Window: a.Window}, a.overlayCapturesKeyboard())
+	a.syncEditorContext()
+	a.devices.BlockButtons = a.overlayCapturesMouse()
+	a.pollInput(a.devices, a.overlayCapturesKeyboard())
 	a.handleActions()
 }
 
@@ -0,0 +1,448 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// This file reports what changed in the world, frame by frame, to whoever
+// subscribed: the RPC server's SUBSCRIBE stream, and any Go code that would
+// rather be told than poll ListObjects.
+//
+// The changes are noted where they happen — in the World's spawn, despawn and
+// reparent, in the Entity's transform setters, in SetComponentField — rather
+// than by the front-ends that ask for them. That is what makes an edit from the
+// editor, one from a client and one from a component's Update all look the
+// same: they all end up in those few places. The one thing that goes unseen is
+// a component writing its own fields directly, which no hook can catch; a
+// change made through SetComponentField is reported.
+//
+// Noting is cheap and off entirely while nobody is subscribed. Notes only mark
+// what was touched; the state is read once, at the end of the frame, so an
+// entity a component moves every frame costs one event per frame however many
+// setters it went through.
+
+// ChangeKind is what happened to an object.
+type ChangeKind int
+
+const (
+	ChangeSpawned ChangeKind = iota + 1
+	ChangeDespawned
+	ChangeReparented
+	ChangeTransform
+	ChangeField
+	// ChangeComponents means components were added to the object or removed
+	// from it, which moves the ones after them to new indices.
+	ChangeComponents
+	// ChangeSceneLoaded means the whole world was swapped for a new scene.
+	// Every handle from before is dead, so a subscriber starts over from
+	// ListObjects rather than expecting an event per object.
+	ChangeSceneLoaded
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeSpawned:
+		return "spawned"
+	case ChangeDespawned:
+		return "despawned"
+	case ChangeReparented:
+		return "reparented"
+	case ChangeTransform:
+		return "transform"
+	case ChangeField:
+		return "field"
+	case ChangeComponents:
+		return "components"
+	case ChangeSceneLoaded:
+		return "scene loaded"
+	}
+	return "unknown"
+}
+
+// Change is one object's change over a frame, already coalesced: an object
+// moved five times reports one ChangeTransform, one spawned and moved reports
+// only ChangeSpawned, and one spawned and despawned again is not reported.
+type Change struct {
+	Kind   ChangeKind
+	Handle Handle
+
+	// Object is the object as it stood at the end of the frame, for spawned,
+	// reparented and transform changes.
+	Object ObjectInfo
+
+	// Component and Field say which property changed and its new value, for a
+	// ChangeField.
+	Component int
+	Type      string
+	Field     ComponentField
+
+	// Components is the object's components at the end of the frame, for a
+	// ChangeComponents.
+	Components []ComponentInfo
+
+	// Scene is the path of the scene that was loaded, for ChangeSceneLoaded.
+	Scene string
+}
+
+// ChangeBatch is one frame's changes, in the order the objects were first
+// touched. Within it, a parent spawns before its children and despawns after
+// them, since that is the order the object API does those in.
+type ChangeBatch struct {
+	Frame   uint64
+	Changes []Change
+
+	// Dropped means the subscriber fell behind and earlier batches were thrown
+	// away rather than holding up the frame loop. What it knows of the world is
+	// stale; it should fetch it again.
+	Dropped bool
+}
+
+// ChangeFilter narrows a subscription. Empty, it passes every change; with
+// handles or subtrees, only changes to those objects, or to anything in or
+// leaving the subtrees below them. A scene load always passes: it ends every
+// handle the filter names, so a subscriber has to hear about it to subscribe
+// again.
+type ChangeFilter struct {
+	Handles  []Handle
+	Subtrees []Handle
+}
+
+func (f ChangeFilter) passes(change observedChange) bool {
+	if change.Kind == ChangeSceneLoaded || (len(f.Handles) == 0 && len(f.Subtrees) == 0) {
+		return true
+	}
+	for _, handle := range f.Handles {
+		if handle == change.Handle {
+			return true
+		}
+	}
+	for _, root := range f.Subtrees {
+		for _, ancestor := range change.ancestry {
+			if ancestor == root {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subscriptionBuffer is how many batches a subscriber can be behind before
+// batches are dropped. About a second at a typical frame rate.
+const subscriptionBuffer = 64
+
+// Subscription delivers change batches until it is closed.
+type Subscription struct {
+	app     *App
+	filter  ChangeFilter
+	batches chan ChangeBatch
+
+	// dropped is set when a batch could not be delivered and cleared by the
+	// next one that is. Guarded by the hub's lock.
+	dropped bool
+	closed  bool
+}
+
+// Changes is the channel batches arrive on. It is closed by Close.
+func (s *Subscription) Changes() <-chan ChangeBatch {
+	return s.batches
+}
+
+// Close stops the subscription and closes its channel. Safe to call twice, and
+// from any goroutine.
+func (s *Subscription) Close() {
+	s.app.changes.remove(s)
+}
+
+// Subscribe starts reporting changes that pass filter, one batch per frame
+// that had any. Safe from any goroutine.
+func (a *App) Subscribe(filter ChangeFilter) *Subscription {
+	subscription := &Subscription{
+		app:     a,
+		filter:  filter,
+		batches: make(chan ChangeBatch, subscriptionBuffer),
+	}
+	a.changes.add(a.World, subscription)
+	return subscription
+}
+
+// changeHub fans each frame's changes out to the subscriptions.
+type changeHub struct {
+	mu            sync.Mutex
+	subscriptions []*Subscription
+	frame         uint64
+}
+
+func (h *changeHub) add(world *World, subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscriptions = append(h.subscriptions, subscription)
+	world.changes.watch(true)
+}
+
+func (h *changeHub) remove(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	close(subscription.batches)
+
+	for i, other := range h.subscriptions {
+		if other == subscription {
+			h.subscriptions = append(h.subscriptions[:i], h.subscriptions[i+1:]...)
+			break
+		}
+	}
+	if len(h.subscriptions) == 0 {
+		subscription.app.World.changes.watch(false)
+	}
+}
+
+// publishChanges coalesces the frame's notes and hands each subscription its
+// share. Frame loop only, once per frame after the components have run, so a
+// batch holds everything the frame did.
+func (a *App) publishChanges() {
+	if !a.World.changes.watching.Load() {
+		return
+	}
+
+	observed := a.observeChanges(a.World.changes.take())
+
+	a.changes.mu.Lock()
+	defer a.changes.mu.Unlock()
+
+	a.changes.frame++
+	for _, subscription := range a.changes.subscriptions {
+		batch := ChangeBatch{Frame: a.changes.frame, Dropped: subscription.dropped}
+		for _, change := range observed {
+			if subscription.filter.passes(change) {
+				batch.Changes = append(batch.Changes, change.Change)
+			}
+		}
+		// A subscriber that missed a batch is told on the next frame even if
+		// nothing else happened, rather than whenever something next does.
+		if len(batch.Changes) == 0 && !batch.Dropped {
+			continue
+		}
+
+		select {
+		case subscription.batches <- batch:
+			subscription.dropped = false
+		default:
+			subscription.dropped = true
+		}
+	}
+}
+
+// observedChange is a Change with what a subtree filter needs to place it:
+// the handles of the object and its ancestors, both when the frame first
+// touched it and at the end, so an object that moved out of a subtree is still
+// reported to it.
+type observedChange struct {
+	Change
+	ancestry []Handle
+}
+
+// observeChanges turns notes into changes, reading the objects' state once.
+func (a *App) observeChanges(notes changeNotes) []observedChange {
+	var observed []observedChange
+	if notes.sceneLoaded {
+		loaded := Change{Kind: ChangeSceneLoaded}
+		if a.Scenes != nil {
+			loaded.Scene = a.Scenes.CurrentScenePath()
+		}
+		observed = append(observed, observedChange{Change: loaded})
+	}
+
+	a.World.Read(func([]*Entity) {
+		for _, handle := range notes.order {
+			note := notes.pending[handle]
+			if note.spawned && note.despawned {
+				continue
+			}
+
+			emit := func(change Change) {
+				observed = append(observed, observedChange{Change: change, ancestry: note.ancestry})
+			}
+			if note.despawned {
+				emit(Change{Kind: ChangeDespawned, Handle: handle})
+				continue
+			}
+
+			entity := a.World.get(handle)
+			if entity == nil {
+				continue
+			}
+			for ancestor := entity; ancestor != nil; ancestor = ancestor.parent {
+				note.ancestry = append(note.ancestry, ancestor.handle)
+			}
+
+			if note.spawned {
+				emit(Change{Kind: ChangeSpawned, Handle: handle, Object: describe(entity)})
+				continue
+			}
+			if note.reparented {
+				emit(Change{Kind: ChangeReparented, Handle: handle, Object: describe(entity)})
+			}
+			if note.moved {
+				emit(Change{Kind: ChangeTransform, Handle: handle, Object: describe(entity)})
+			}
+			if note.components {
+				emit(Change{Kind: ChangeComponents, Handle: handle, Components: a.describeComponents(entity)})
+			}
+			for _, ref := range note.fields {
+				if field, ok := a.currentField(entity, ref); ok {
+					emit(Change{Kind: ChangeField, Handle: handle, Component: ref.index, Type: ref.typeName, Field: field})
+				}
+			}
+		}
+	})
+	return observed
+}
+
+// currentField reads a noted property back. A component removed or replaced
+// since is skipped rather than reported against whatever is there now.
+func (a *App) currentField(entity *Entity, ref fieldRef) (ComponentField, bool) {
+	if ref.index >= len(entity.components) {
+		return ComponentField{}, false
+	}
+	component := entity.components[ref.index]
+	if name, ok := a.Components.NameOf(component); ok && name != ref.typeName {
+		return ComponentField{}, false
+	}
+	for _, field := range readFields(component) {
+		if field.Name == ref.name {
+			return field, true
+		}
+	}
+	return ComponentField{}, false
+}
+
+// changeJournal collects a frame's notes. It has its own lock because notes
+// are taken with the World's held — inside Mutate, Spawn and the rest — and the
+// frame loop takes it without.
+type changeJournal struct {
+	watching atomic.Bool
+
+	mu    sync.Mutex
+	notes changeNotes
+}
+
+type changeNotes struct {
+	order       []Handle
+	pending     map[Handle]*changeNote
+	sceneLoaded bool
+}
+
+// changeNote is what happened to one object this frame.
+type changeNote struct {
+	spawned, despawned, reparented, moved, components bool
+	fields                                            []fieldRef
+
+	// ancestry is the object and its ancestors when it was first touched. For
+	// a despawned object it is all there is to go on.
+	ancestry []Handle
+}
+
+type fieldRef struct {
+	index    int
+	typeName string
+	name     string
+}
+
+func (j *changeJournal) watch(on bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.watching.Store(on)
+	if !on {
+		j.notes = changeNotes{}
+	}
+}
+
+func (j *changeJournal) take() changeNotes {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	notes := j.notes
+	j.notes = changeNotes{}
+	return notes
+}
+
+// note records something about entity. Callers hold the World's lock, which
+// is what makes walking the parent chain safe.
+func (j *changeJournal) note(entity *Entity, fn func(note *changeNote)) {
+	if !j.watching.Load() {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.notes.pending == nil {
+		j.notes.pending = make(map[Handle]*changeNote)
+	}
+	note, ok := j.notes.pending[entity.handle]
+	if !ok {
+		note = &changeNote{}
+		for ancestor := entity; ancestor != nil; ancestor = ancestor.parent {
+			note.ancestry = append(note.ancestry, ancestor.handle)
+		}
+		j.notes.pending[entity.handle] = note
+		j.notes.order = append(j.notes.order, entity.handle)
+	}
+	fn(note)
+}
+
+// replaced forgets the frame's notes so far: they were about a world that is
+// gone.
+func (j *changeJournal) replaced() {
+	if !j.watching.Load() {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.notes = changeNotes{sceneLoaded: true}
+}
+
+// noteMoved, noteReparenting, noteComponents and noteField are the Entity's side. An entity
+// that is not in a World — one still being built, or already despawned — has
+// nothing to report.
+func (e *Entity) noteMoved() {
+	if e.inWorld != nil {
+		e.inWorld.changes.note(e, func(note *changeNote) { note.moved = true })
+	}
+}
+
+func (e *Entity) noteReparenting() {
+	if e.inWorld != nil {
+		e.inWorld.changes.note(e, func(note *changeNote) { note.reparented = true })
+	}
+}
+
+func (e *Entity) noteComponents() {
+	if e.inWorld != nil {
+		e.inWorld.changes.note(e, func(note *changeNote) { note.components = true })
+	}
+}
+
+func (e *Entity) noteField(index int, typeName, name string) {
+	if e.inWorld == nil {
+		return
+	}
+	e.inWorld.changes.note(e, func(note *changeNote) {
+		ref := fieldRef{index: index, typeName: typeName, name: name}
+		for _, noted := range note.fields {
+			if noted == ref {
+				return
+			}
+		}
+		note.fields = append(note.fields, ref)
+	})
+}
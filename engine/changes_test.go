@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// nextBatch ends a frame and returns what the subscription got for it, or
+// nothing if the frame changed nothing it passes.
+func nextBatch(t *testing.T, a *App, subscription *Subscription) (ChangeBatch, bool) {
+	t.Helper()
+
+	a.publishChanges()
+	select {
+	case batch := <-subscription.Changes():
+		return batch, true
+	default:
+		return ChangeBatch{}, false
+	}
+}
+
+// describeBatch renders a batch as "kind name" pairs, which is what the tests
+// below care about.
+func describeBatch(names map[Handle]string, batch ChangeBatch) string {
+	var parts []string
+	for _, change := range batch.Changes {
+		name := names[change.Handle]
+		if change.Kind == ChangeField {
+			name += "." + change.Field.Name
+		}
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %s", change.Kind, name)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// TestChangesCoalescePerFrame: one batch per frame, one change per object and
+// kind, and nothing for an object that came and went within the frame.
+func TestChangesCoalescePerFrame(t *testing.T) {
+	a, h := historyApp(t)
+	subscription := a.Subscribe(ChangeFilter{})
+	defer subscription.Close()
+
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	for _, x := range []float32{1, 2, 3} {
+		if err := a.UpdateTransform(lamp, func(tr *Transform) { tr.Position = mgl32.Vec3{x, 0, 0} }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	batch, ok := nextBatch(t, a, subscription)
+	if !ok || len(batch.Changes) != 1 || batch.Changes[0].Kind != ChangeSpawned {
+		t.Fatalf("spawn and moves reported as %+v", batch)
+	}
+	if got := batch.Changes[0].Object.Transform.Position; got != (mgl32.Vec3{3, 0, 0}) {
+		t.Errorf("spawned at %v, want where the frame left it", got)
+	}
+
+	names := map[Handle]string{lamp: "lamp"}
+	shade := spawnLight(t, h, "shade", NoHandle)
+	names[shade] = "shade"
+	for i := 0; i < 3; i++ {
+		a.World.Mutate(lamp, func(e *Entity) { e.Translate(mgl32.Vec3{0, 1, 0}) })
+	}
+	if err := a.SetParent(shade, lamp); err != nil {
+		t.Fatal(err)
+	}
+	ghost := spawnLight(t, h, "ghost", NoHandle)
+	if err := a.DespawnObject(ghost); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, _ = nextBatch(t, a, subscription)
+	if got, want := describeBatch(names, batch), "spawned shade, transform lamp"; got != want {
+		t.Errorf("second frame: got %q, want %q", got, want)
+	}
+
+	if _, ok := nextBatch(t, a, subscription); ok {
+		t.Error("a frame that changed nothing sent a batch")
+	}
+}
+
+// TestChangesFromEveryFrontEnd: the history, the object API and a component
+// writing its entity all report the same way.
+func TestChangesFromEveryFrontEnd(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	shade := spawnLight(t, h, "shade", NoHandle)
+	names := map[Handle]string{lamp: "lamp", shade: "shade"}
+
+	subscription := a.Subscribe(ChangeFilter{})
+	defer subscription.Close()
+
+	if err := h.SetParent(shade, lamp); err != nil {
+		t.Fatal(err)
+	}
+	field := ComponentField{Name: "linear", Kind: FieldFloat, Float: 0.5}
+	if err := h.SetComponentField(lamp, 0, "PointLight", field); err != nil {
+		t.Fatal(err)
+	}
+	a.World.Write(func(entities []*Entity) {
+		for _, entity := range entities {
+			if entity.Name == "shade" {
+				entity.SetScale(mgl32.Vec3{2, 2, 2})
+			}
+		}
+	})
+
+	batch, _ := nextBatch(t, a, subscription)
+	if got, want := describeBatch(names, batch), "reparented shade, transform shade, field lamp.linear"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, change := range batch.Changes {
+		if change.Kind == ChangeField && (change.Type != "PointLight" || change.Field.Float != 0.5) {
+			t.Errorf("field change %+v", change)
+		}
+		if change.Kind == ChangeReparented && change.Object.Parent != lamp {
+			t.Errorf("reparented under %v, want %v", change.Object.Parent, lamp)
+		}
+	}
+
+	// Undo is an edit like any other.
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	batch, _ = nextBatch(t, a, subscription)
+	if got, want := describeBatch(names, batch), "field lamp.linear"; got != want {
+		t.Errorf("after undo: got %q, want %q", got, want)
+	}
+}
+
+// TestChangesFilterBySubtree: a subtree subscriber hears about what is below
+// its root, including an object that leaves it and one despawned from it, and
+// nothing else.
+func TestChangesFilterBySubtree(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	arm := spawnLight(t, h, "arm", rig)
+	bulb := spawnLight(t, h, "bulb", arm)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	names := map[Handle]string{rig: "rig", arm: "arm", bulb: "bulb", lamp: "lamp"}
+
+	subtree := a.Subscribe(ChangeFilter{Subtrees: []Handle{arm}})
+	defer subtree.Close()
+	single := a.Subscribe(ChangeFilter{Handles: []Handle{lamp}})
+	defer single.Close()
+
+	for _, handle := range []Handle{rig, bulb, lamp} {
+		if err := a.UpdateTransform(handle, func(tr *Transform) { tr.Scale = mgl32.Vec3{2, 2, 2} }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.SetParent(bulb, lamp); err != nil {
+		t.Fatal(err)
+	}
+	batch, _ := nextBatch(t, a, subtree)
+	if got, want := describeBatch(names, batch), "reparented bulb, transform bulb"; got != want {
+		t.Errorf("subtree: got %q, want %q", got, want)
+	}
+	if batch, _ := nextBatch(t, a, single); describeBatch(names, batch) != "transform lamp" {
+		t.Errorf("single: got %q", describeBatch(names, batch))
+	}
+
+	if err := a.DespawnObject(arm); err != nil {
+		t.Fatal(err)
+	}
+	batch, _ = nextBatch(t, a, subtree)
+	if got, want := describeBatch(names, batch), "despawned arm"; got != want {
+		t.Errorf("subtree after despawn: got %q, want %q", got, want)
+	}
+}
+
+func TestChangesSceneLoaded(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	subscription := a.Subscribe(ChangeFilter{Handles: []Handle{lamp}})
+	defer subscription.Close()
+
+	if err := a.UpdateTransform(lamp, func(tr *Transform) { tr.Scale = mgl32.Vec3{2, 2, 2} }); err != nil {
+		t.Fatal(err)
+	}
+	a.World.Replace([]*Entity{NewEntity("fresh")})
+
+	batch, _ := nextBatch(t, a, subscription)
+	if len(batch.Changes) != 1 || batch.Changes[0].Kind != ChangeSceneLoaded {
+		t.Errorf("a scene load reported as %+v; the changes before it are moot", batch.Changes)
+	}
+}
+
+// TestChangesDropWhenBehind: a subscriber that stops reading does not hold up
+// the frame loop, and is told what it missed once it catches up.
+func TestChangesDropWhenBehind(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	subscription := a.Subscribe(ChangeFilter{})
+	defer subscription.Close()
+
+	for i := 0; i < subscriptionBuffer+5; i++ {
+		a.World.Mutate(lamp, func(e *Entity) { e.Translate(mgl32.Vec3{1, 0, 0}) })
+		a.publishChanges()
+	}
+	for i := 0; i < subscriptionBuffer; i++ {
+		if batch := <-subscription.Changes(); batch.Dropped {
+			t.Fatalf("batch %d flagged as after a drop", i)
+		}
+	}
+
+	batch, ok := nextBatch(t, a, subscription)
+	if !ok || !batch.Dropped || len(batch.Changes) != 0 {
+		t.Errorf("after catching up: %+v, %v; want an empty batch saying frames were dropped", batch, ok)
+	}
+	if _, ok := nextBatch(t, a, subscription); ok {
+		t.Error("the drop was reported twice")
+	}
+}
+
+func TestChangesStopWithoutSubscribers(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	subscription := a.Subscribe(ChangeFilter{})
+	subscription.Close()
+	subscription.Close()
+
+	if _, open := <-subscription.Changes(); open {
+		t.Error("a closed subscription's channel is open")
+	}
+	a.World.Mutate(lamp, func(e *Entity) { e.Translate(mgl32.Vec3{1, 0, 0}) })
+	if notes := a.World.changes.take(); len(notes.order) != 0 {
+		t.Errorf("changes noted with nobody subscribed: %v", notes.order)
+	}
+}
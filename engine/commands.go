@@ -91,6 +91,7 @@ func (a *App) Do(fn Command) error {
 
 // drainCommands runs everything queued since the last frame. Called from Run.
 func (a *App) drainCommands() {
+	a.beginUploadBudget()
 	for _, cmd := range a.commands.take() {
 		err := cmd.fn(a)
 		if cmd.done != nil {
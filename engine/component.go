@@ -167,6 +167,34 @@ func (r *ComponentRegistry) New(name string) (Component, error) {
 	return component, nil
 }
 
+// Fields lists a registered component type's properties with the values a
+// new one starts with: what a front-end offers when adding one.
+func (r *ComponentRegistry) Fields(name string) ([]ComponentField, bool) {
+	r.mu.RLock()
+	factory, ok := r.types[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return readFields(factory()), true
+}
+
+// PropNames lists the props a scene file may give a registered component
+// type, by their yaml names: the same fields the inspector edits and the saver
+// writes. It is what scene.Validate checks a props block against.
+func (r *ComponentRegistry) PropNames(name string) ([]string, bool) {
+	fields, ok := r.Fields(name)
+	if !ok {
+		return nil, false
+	}
+
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names, true
+}
+
 // Names lists the registered type names, sorted.
 func (r *ComponentRegistry) Names() []string {
 	r.mu.RLock()
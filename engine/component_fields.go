@@ -93,24 +93,34 @@ func (a *App) ComponentsOf(handle Handle) ([]ComponentInfo, bool) {
 	var infos []ComponentInfo
 
 	found := a.World.Mutate(handle, func(entity *Entity) {
-		infos = make([]ComponentInfo, 0, len(entity.components))
-
-		for i, component := range entity.components {
-			info := ComponentInfo{
-				Index:  i,
-				GoType: reflect.TypeOf(component).String(),
-				Fields: readFields(component),
-			}
-			if name, ok := a.Components.NameOf(component); ok {
-				info.Type = name
-			}
-			infos = append(infos, info)
-		}
+		infos = a.describeComponents(entity)
 	})
 
 	return infos, found
 }
 
+// describeComponents snapshots an entity's components. Callers hold the world
+// lock.
+func (a *App) describeComponents(entity *Entity) []ComponentInfo {
+	infos := make([]ComponentInfo, 0, len(entity.components))
+	for i, component := range entity.components {
+		infos = append(infos, a.componentInfo(i, component))
+	}
+	return infos
+}
+
+func (a *App) componentInfo(index int, component Component) ComponentInfo {
+	info := ComponentInfo{
+		Index:  index,
+		GoType: reflect.TypeOf(component).String(),
+		Fields: readFields(component),
+	}
+	if name, ok := a.Components.NameOf(component); ok {
+		info.Type = name
+	}
+	return info
+}
+
 // SetComponentField writes one property back.
 //
 // The component is addressed by index and checked against the type name the
@@ -121,25 +131,101 @@ func (a *App) SetComponentField(handle Handle, index int, typeName string, field
 	var err error
 
 	found := a.World.Mutate(handle, func(entity *Entity) {
-		if index < 0 || index >= len(entity.components) {
-			err = fmt.Errorf("object %s has no component at index %d", handle, index)
+		var component Component
+		if component, err = a.componentAt(entity, index, typeName); err != nil {
 			return
 		}
+		if err = writeField(component, field); err == nil {
+			name, _ := a.Components.NameOf(component)
+			entity.noteField(index, name, field.Name)
+		}
+	})
+
+	if !found {
+		return fmt.Errorf("object %s not found", handle)
+	}
+	return err
+}
+
+// AddComponent attaches a new component of a registered type and returns its
+// index. It starts with the type's defaults, as one named in a scene file with
+// no props would, and fields are written over them. Its Start runs at the next
+// update. Safe from any goroutine.
+func (a *App) AddComponent(handle Handle, typeName string, fields []ComponentField) (int, error) {
+	return a.insertComponent(handle, -1, typeName, fields)
+}
+
+// insertComponent is AddComponent at a given index, or at the end for -1. It
+// is what puts a removed component back where it was.
+func (a *App) insertComponent(handle Handle, index int, typeName string, fields []ComponentField) (int, error) {
+	component, err := a.Components.New(typeName)
+	if err != nil {
+		return -1, err
+	}
+	// Written before the component is attached, so a bad field leaves the
+	// entity as it was.
+	for _, field := range fields {
+		if err := writeField(component, field); err != nil {
+			return -1, err
+		}
+	}
+
+	inserted := -1
+	found := a.World.Mutate(handle, func(entity *Entity) {
+		if index > len(entity.components) {
+			index = -1
+		}
+		inserted = entity.insertComponent(index, component)
+		entity.noteComponents()
+	})
+	if !found {
+		return -1, fmt.Errorf("object %s not found", handle)
+	}
+	return inserted, nil
+}
 
-		component := entity.components[index]
-		if name, ok := a.Components.NameOf(component); ok && typeName != "" && name != typeName {
-			err = fmt.Errorf("component %d on object %s is now %q, not %q",
-				index, handle, name, typeName)
+// RemoveComponent detaches a component, addressed and checked the way
+// SetComponentField does it, running its OnDestroy first as a despawn would.
+// It returns the component as it was, which is what putting it back takes.
+// Safe from any goroutine.
+func (a *App) RemoveComponent(handle Handle, index int, typeName string) (ComponentInfo, error) {
+	var removed ComponentInfo
+	var err error
+
+	found := a.World.Mutate(handle, func(entity *Entity) {
+		var component Component
+		if component, err = a.componentAt(entity, index, typeName); err != nil {
 			return
 		}
+		removed = a.componentInfo(index, component)
 
-		err = writeField(component, field)
+		if destroyer, ok := component.(Destroyer); ok {
+			destroyer.OnDestroy(a.context(entity, a.deltaTime))
+		}
+		entity.removeComponent(index)
+		entity.noteComponents()
 	})
 
 	if !found {
-		return fmt.Errorf("object %s not found", handle)
+		return ComponentInfo{}, fmt.Errorf("object %s not found", handle)
 	}
-	return err
+	return removed, err
+}
+
+// componentAt finds the component an edit addresses, refusing when the index
+// now holds a different type. An empty typeName skips the check. Callers hold
+// the world write lock.
+func (a *App) componentAt(entity *Entity, index int, typeName string) (Component, error) {
+	if index < 0 || index >= len(entity.components) {
+		return nil, fmt.Errorf("object %s has no component at index %d", entity.handle, index)
+	}
+
+	component := entity.components[index]
+	if name, ok := a.Components.NameOf(component); ok && typeName != "" && name != typeName {
+		return nil, fmt.Errorf("component %d on object %s is now %q, not %q",
+			index, entity.handle, name, typeName)
+	}
+	return component, nil
 }
 
 // readFields reflects a component's editable properties out.
@@ -265,6 +351,17 @@ func writeValue(target reflect.Value, field ComponentField) error {
 		return fmt.Errorf("property %q cannot be set", field.Name)
 	}
 
+	// A value of another kind would make reflect panic. The editor never sends
+	// one, since it builds its widgets from the field, but a remote client can.
+	var current ComponentField
+	readValue(&current, target)
+	switch {
+	case current.Kind == FieldUnsupported:
+		return fmt.Errorf("property %q has no editable type", field.Name)
+	case field.Kind != current.Kind:
+		return fmt.Errorf("property %q is a %s, not a %s", field.Name, current.Kind, field.Kind)
+	}
+
 	switch field.Kind {
 	case FieldBool:
 		target.SetBool(field.Bool)
@@ -294,3 +294,78 @@ func TestBuiltInLightsAreFullyEditable(t *testing.T) {
 		}
 	}
 }
+
+// TestSetComponentFieldRefusesOtherKinds: a value of the wrong shape is an
+// error, not a reflect panic on the frame loop. The editor never sends one;
+// a remote client can.
+func TestSetComponentFieldRefusesOtherKinds(t *testing.T) {
+	a, subject, handle := gadgetApp(t)
+
+	if err := a.SetComponentField(handle, 0, "Gadget", ComponentField{Name: "enabled", Kind: FieldFloat, Float: 1}); err == nil ||
+		!strings.Contains(err.Error(), "bool") {
+		t.Errorf("a float written to a bool: %v", err)
+	}
+	if err := a.SetComponentField(handle, 0, "Gadget", ComponentField{Name: "table", Kind: FieldInt, Int: 1}); err == nil {
+		t.Error("a property with no editable type was written")
+	}
+	if !subject.Enabled {
+		t.Error("the refused edit was applied anyway")
+	}
+}
+
+// TestAddAndRemoveComponent covers both through the History: each undoes, a
+// removed component comes back where it was with the values it had, and a
+// removed component gets its OnDestroy.
+func TestAddAndRemoveComponent(t *testing.T) {
+	a, _, handle := gadgetApp(t)
+	a.Components.MustRegister("Probe", func() Component { return &probe{} })
+	h := NewHistory(a)
+	a.History = h
+
+	index, err := h.AddComponent(handle, "Probe", []ComponentField{{Name: "label", Kind: FieldString, String: "added"}})
+	if err != nil || index != 1 {
+		t.Fatalf("AddComponent = %d, %v", index, err)
+	}
+	if _, err := h.AddComponent(handle, "Nope", nil); err == nil {
+		t.Error("added a component of an unknown type")
+	}
+	if _, err := h.AddComponent(handle, "Probe", []ComponentField{{Name: "speed", Kind: FieldBool}}); err == nil {
+		t.Error("added a component with a bad field")
+	}
+	if infos, _ := a.ComponentsOf(handle); len(infos) != 2 || fieldsByName(infos[1].Fields)["label"].String != "added" {
+		t.Fatalf("after adding: %+v", infos)
+	}
+
+	entity := a.World.Get(handle)
+	added, _ := GetComponent[*probe](entity)
+	if err := h.RemoveComponent(handle, 0, "Probe"); err == nil {
+		t.Error("removed through a stale type name")
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if len(entity.Components()) != 1 || len(added.calls) != 1 || added.calls[0] != "destroy" {
+		t.Errorf("undoing the add left %d components; the probe saw %v", len(entity.Components()), added.calls)
+	}
+
+	if err := h.RemoveComponent(handle, 0, "Gadget"); err != nil {
+		t.Fatal(err)
+	}
+	if len(entity.Components()) != 0 {
+		t.Fatal("the gadget is still attached")
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	restored, ok := GetComponent[*gadget](entity)
+	if !ok || restored.Speed != 12.5 || restored.Label != "hello" || restored.Tint != (mgl32.Vec3{0.1, 0.2, 0.3}) {
+		t.Errorf("the gadget came back as %+v", restored)
+	}
+
+	if err := h.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if len(entity.Components()) != 0 {
+		t.Error("redo did not remove the gadget again")
+	}
+}
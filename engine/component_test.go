@@ -184,6 +184,21 @@ func TestPropsDecodeIntoComponent(t *testing.T) {
 	}
 }
 
+// TestPropNamesMatchDecodedFields: what the validator accepts in a props block
+// is exactly what decoding into the component would use.
+func TestPropNamesMatchDecodedFields(t *testing.T) {
+	r := NewComponentRegistry()
+	r.MustRegister("Probe", func() Component { return &probe{} })
+
+	names, ok := r.PropNames("Probe")
+	if !ok || len(names) != 2 || names[0] != "speed" || names[1] != "label" {
+		t.Fatalf("PropNames = %v, %v", names, ok)
+	}
+	if _, ok := r.PropNames("Nope"); ok {
+		t.Fatal("an unknown type has prop names")
+	}
+}
+
 func TestGetComponent(t *testing.T) {
 	entity := NewEntity("subject")
 	p := &probe{Label: "target"}
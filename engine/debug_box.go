@@ -64,7 +64,7 @@ func (r *debugBoxRenderer) Draw(shader *shaders.Shader, min, max, color mgl32.Ve
 	modelMat = modelMat.Mul4(mgl32.Scale3D(size.X(), size.Y(), size.Z()))
 
 	shader.SetMat4("model", modelMat)
-	shader.SetVec3Val("color", color)
+	shader.SetVec3Val("color", linearColor(color))
 	gl.BindVertexArray(r.vao)
 	gl.DrawArrays(gl.LINES, 0, 24)
 	gl.BindVertexArray(0)
@@ -43,6 +43,9 @@ type Entity struct {
 
 	// handle is assigned by the World on spawn and cleared on despawn.
 	handle Handle
+	// inWorld is the World the entity is in, set and cleared along with
+	// handle, so its setters can note changes for subscribers.
+	inWorld *World
 
 	local    Transform
 	parent   *Entity
@@ -60,6 +63,15 @@ type Entity struct {
 	components []Component
 	// unstarted holds components whose Start has not run yet.
 	unstarted []Component
+
+	// prefab is the prefab file this entity is the root of an instance of, or
+	// empty. Only saving reads it; see prefab.go.
+	prefab string
+
+	// layer is the scene layer the entity was loaded with, or empty for the
+	// scene itself. The entity at the top of a tree decides which layer the
+	// whole tree belongs to; see scene_layers.go.
+	layer string
 }
 
 // AddComponent attaches a component. Its Start runs at the next update.
@@ -73,6 +85,31 @@ func (e *Entity) AddComponent(components ...Component) {
 	}
 }
 
+// insertComponent attaches a component at index, or at the end for -1, and
+// returns where it went. Its Start runs at the next update.
+func (e *Entity) insertComponent(index int, component Component) int {
+	if index < 0 || index >= len(e.components) {
+		e.AddComponent(component)
+		return len(e.components) - 1
+	}
+	e.components = append(e.components[:index], append([]Component{component}, e.components[index:]...)...)
+	e.unstarted = append(e.unstarted, component)
+	return index
+}
+
+// removeComponent detaches the component at index, and forgets it in the
+// Start queue if it never got as far as starting.
+func (e *Entity) removeComponent(index int) {
+	component := e.components[index]
+	e.components = append(e.components[:index], e.components[index+1:]...)
+	for i, pending := range e.unstarted {
+		if pending == component {
+			e.unstarted = append(e.unstarted[:i], e.unstarted[i+1:]...)
+			break
+		}
+	}
+}
+
 // Components returns the attached components; treat the slice as read-only.
 func (e *Entity) Components() []Component {
 	return e.components
@@ -110,6 +147,7 @@ func (e *Entity) Transform() Transform {
 func (e *Entity) SetTransform(t Transform) {
 	e.local = t
 	e.invalidate()
+	e.noteMoved()
 }
 
 func (e *Entity) Position() mgl32.Vec3 { return e.local.Position }
@@ -119,11 +157,13 @@ func (e *Entity) Scale() mgl32.Vec3    { return e.local.Scale }
 func (e *Entity) SetPosition(p mgl32.Vec3) {
 	e.local.Position = p
 	e.invalidate()
+	e.noteMoved()
 }
 
 func (e *Entity) SetRotation(r mgl32.Quat) {
 	e.local.Rotation = r
 	e.invalidate()
+	e.noteMoved()
 }
 
 // RotationAxisAngle reports the rotation as XYZ axis plus degrees, the form the
@@ -150,6 +190,7 @@ func (e *Entity) Rotate(delta mgl32.Quat) {
 func (e *Entity) SetScale(s mgl32.Vec3) {
 	e.local.Scale = s
 	e.invalidate()
+	e.noteMoved()
 }
 
 func (e *Entity) Translate(delta mgl32.Vec3) {
@@ -213,6 +254,7 @@ func (e *Entity) SetParent(parent *Entity) {
 	if parent != nil && e.IsAncestorOf(parent) {
 		return
 	}
+	e.noteReparenting()
 
 	if e.parent != nil {
 		siblings := e.parent.children
@@ -141,6 +141,22 @@ func TestWorldMatrixComposesThroughParents(t *testing.T) {
 	if world != (mgl32.Vec3{0, 5, 2}) {
 		t.Errorf("after moving rig, bulb world position: got %v, want {0 5 2}", world)
 	}
+
+	// WorldMatrices hands out the parent's matrix alongside, which is what an
+	// editor needs to turn a world-space drag back into a local transform.
+	world4, parent, ok := a.WorldMatrices(bulb.Handle())
+	if !ok {
+		t.Fatal("WorldMatrices could not find the bulb")
+	}
+	if got := world4.Col(3).Vec3(); got != (mgl32.Vec3{0, 5, 2}) {
+		t.Errorf("WorldMatrices world position: got %v, want {0 5 2}", got)
+	}
+	if got := parent.Col(3).Vec3(); got != (mgl32.Vec3{0, 5, 0}) {
+		t.Errorf("WorldMatrices parent position: got %v, want the arm's {0 5 0}", got)
+	}
+	if _, root, _ := a.WorldMatrices(a.World.Find("rig").Handle()); root != mgl32.Ident4() {
+		t.Errorf("a root entity's parent matrix is %v, want the identity", root)
+	}
 }
 
 func TestSpawnUnderParent(t *testing.T) {
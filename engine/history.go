@@ -0,0 +1,751 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"3d-engine/scene"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// This file is the undoable front of the object API. History has one method
+// for each mutation a front-end offers — spawn, delete, reparent, move, tint,
+// add, remove or edit a component — which calls through to the App method of
+// the same name and records how to reverse it. The editor and the RPC server go
+// through here, so anything a person can do to the world they can take back.
+//
+// Game code and scene loading still call the App directly: a component moving
+// its entity every frame is not an edit anyone wants to undo.
+
+// ErrNothingToUndo and ErrNothingToRedo are returned when the respective
+// stack is empty.
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// DefaultHistoryLimit is how many transactions are kept before the oldest is
+// forgotten.
+const DefaultHistoryLimit = 200
+
+// edit is one reversible change. undo and redo resolve their handles through
+// the history, because an entity deleted and restored comes back under a new
+// handle.
+type edit interface {
+	undo(h *History) error
+	redo(h *History) error
+	// merge folds a later edit of the same kind and target into this one,
+	// reporting whether it did. It is what keeps a drag from filling the
+	// history with one entry per frame.
+	merge(next edit) bool
+	label() string
+}
+
+// transaction is the unit of undo: everything in it goes back together.
+type transaction struct {
+	label string
+	edits []edit
+}
+
+// History records edits made through it and replays them backwards and
+// forwards. Frame loop only — spawning and restoring deleted entities import
+// models — so the RPC server reaches it through App.Do.
+type History struct {
+	app   *App
+	limit int
+
+	undoStack []transaction
+	redoStack []transaction
+
+	// open is the transaction edits are being added to, if one was begun.
+	// depth lets transactions nest; only the outermost commits.
+	open  *transaction
+	depth int
+
+	// aside counts transactions Separately has set aside and not yet put
+	// back. They are still in progress, so undo and redo wait for them.
+	aside int
+
+	// remap points a handle that died in an undo or redo at the one its entity
+	// came back as. Edits keep the handles they were recorded with and resolve
+	// them through this.
+	remap map[Handle]Handle
+}
+
+// NewHistory creates an empty history for app.
+func NewHistory(app *App) *History {
+	return &History{
+		app:   app,
+		limit: DefaultHistoryLimit,
+		remap: map[Handle]Handle{},
+	}
+}
+
+// Begin opens a transaction: every edit until the matching Commit undoes as
+// one step. label names the step; empty takes the first edit's own label.
+// Transactions nest, and only the outermost Commit closes the step.
+//
+// A nested Begin names the step if the outer one left it unnamed and nothing
+// has been recorded yet: the editor opens an unlabelled transaction around
+// every held widget, including the button that starts a labelled bulk edit.
+func (h *History) Begin(label string) {
+	h.depth++
+	if h.open == nil {
+		h.open = &transaction{label: label}
+	} else if h.open.label == "" && len(h.open.edits) == 0 {
+		h.open.label = label
+	}
+}
+
+// Commit closes the transaction Begin opened. An empty transaction leaves no
+// trace.
+func (h *History) Commit() {
+	if h.depth == 0 {
+		return
+	}
+	h.depth--
+	if h.depth > 0 {
+		return
+	}
+
+	open := h.open
+	h.open = nil
+	if len(open.edits) > 0 {
+		h.push(*open)
+	}
+}
+
+// Rollback undoes whatever the open transaction did and discards it,
+// however deeply nested the caller is.
+func (h *History) Rollback() error {
+	if h.open == nil {
+		return nil
+	}
+	open := h.open
+	h.open = nil
+	h.depth = 0
+
+	return h.undoEdits(open.edits)
+}
+
+// Transaction runs fn inside Begin and Commit, rolling everything back if fn
+// fails — all of it happens or none of it does.
+func (h *History) Transaction(label string, fn func() error) error {
+	h.Begin(label)
+	if err := fn(); err != nil {
+		if rollbackErr := h.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (and rolling back failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	h.Commit()
+	return nil
+}
+
+// InTransaction reports whether a transaction is open.
+func (h *History) InTransaction() bool {
+	return h.open != nil
+}
+
+// Separately runs fn with any open transaction set aside, so what fn records
+// undoes as steps of its own rather than as part of someone else's.
+//
+// The editor holds a transaction open across frames for as long as a widget
+// or a gizmo is held. An edit an RPC client sends in the middle of that is not
+// part of the drag: it must not undo with it, take its label, or be taken back
+// when the drag is cancelled. The set-aside transaction carries on where it
+// was once fn returns; a Rollback inside fn does not reach it.
+func (h *History) Separately(fn func() error) error {
+	open, depth := h.open, h.depth
+	if open == nil {
+		return fn()
+	}
+
+	h.open, h.depth = nil, 0
+	h.aside++
+	defer func() {
+		h.aside--
+		h.open, h.depth = open, depth
+	}()
+	return fn()
+}
+
+// Undo reverses the latest transaction.
+//
+// If an edit cannot be reversed — the entity it touched was removed by
+// something that doesn't record history, say — the transaction is dropped
+// rather than moved to the redo stack: it is now half applied and can be
+// replayed in neither direction.
+func (h *History) Undo() error {
+	if h.open != nil || h.aside > 0 {
+		return fmt.Errorf("cannot undo while an edit is in progress")
+	}
+	if len(h.undoStack) == 0 {
+		return ErrNothingToUndo
+	}
+
+	last := h.undoStack[len(h.undoStack)-1]
+	h.undoStack = h.undoStack[:len(h.undoStack)-1]
+
+	if err := h.undoEdits(last.edits); err != nil {
+		return fmt.Errorf("undo %s: %w", last.label, err)
+	}
+	h.redoStack = append(h.redoStack, last)
+	return nil
+}
+
+// Redo re-applies the transaction Undo last reversed.
+func (h *History) Redo() error {
+	if h.open != nil || h.aside > 0 {
+		return fmt.Errorf("cannot redo while an edit is in progress")
+	}
+	if len(h.redoStack) == 0 {
+		return ErrNothingToRedo
+	}
+
+	next := h.redoStack[len(h.redoStack)-1]
+	h.redoStack = h.redoStack[:len(h.redoStack)-1]
+
+	for _, e := range next.edits {
+		if err := e.redo(h); err != nil {
+			return fmt.Errorf("redo %s: %w", next.label, err)
+		}
+	}
+	h.undoStack = append(h.undoStack, next)
+	return nil
+}
+
+// UndoLabel names the step Undo would reverse, or "" if there is none.
+func (h *History) UndoLabel() string {
+	if len(h.undoStack) == 0 {
+		return ""
+	}
+	return h.undoStack[len(h.undoStack)-1].label
+}
+
+// RedoLabel names the step Redo would re-apply, or "" if there is none.
+func (h *History) RedoLabel() string {
+	if len(h.redoStack) == 0 {
+		return ""
+	}
+	return h.redoStack[len(h.redoStack)-1].label
+}
+
+// Clear forgets everything. A scene load calls it: every handle the history
+// holds died with the old world.
+func (h *History) Clear() {
+	h.undoStack = nil
+	h.redoStack = nil
+	h.open = nil
+	h.depth = 0
+	h.remap = map[Handle]Handle{}
+}
+
+// Resolve follows a handle through any undo and redo that recreated its
+// entity, returning the handle it lives under now. A front-end holding a
+// selection across an undo uses it to keep the selection.
+func (h *History) Resolve(handle Handle) Handle {
+	// Bounded by the number of remaps, so a cycle (which rebind never makes)
+	// could not spin forever.
+	for range len(h.remap) + 1 {
+		next, ok := h.remap[handle]
+		if !ok {
+			return handle
+		}
+		handle = next
+	}
+	return handle
+}
+
+func (h *History) undoEdits(edits []edit) error {
+	for i := len(edits) - 1; i >= 0; i-- {
+		if err := edits[i].undo(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record adds an edit that has already been applied.
+func (h *History) record(e edit) {
+	if h.open != nil {
+		if n := len(h.open.edits); n > 0 && h.open.edits[n-1].merge(e) {
+			return
+		}
+		h.open.edits = append(h.open.edits, e)
+		if h.open.label == "" {
+			h.open.label = e.label()
+		}
+		return
+	}
+	h.push(transaction{label: e.label(), edits: []edit{e}})
+}
+
+func (h *History) push(t transaction) {
+	if t.label == "" {
+		t.label = t.edits[0].label()
+	}
+	h.undoStack = append(h.undoStack, t)
+	if over := len(h.undoStack) - h.limit; over > 0 {
+		h.undoStack = append([]transaction(nil), h.undoStack[over:]...)
+	}
+	// A new edit forks the timeline; what was undone can't be redone on top.
+	h.redoStack = nil
+}
+
+// rebind records that the entity once known as old now lives as current.
+// The end of old's chain is what gets pointed on, so every handle that ever
+// named the entity resolves to the live one.
+func (h *History) rebind(old, current Handle) {
+	end := h.Resolve(old)
+	if end != current {
+		h.remap[end] = current
+	}
+}
+
+// --- the recorded API -------------------------------------------------------
+
+// SpawnObject is App.SpawnObject, recorded.
+func (h *History) SpawnObject(spec ObjectSpec) (*Entity, error) {
+	entity, err := h.app.SpawnObject(spec)
+	if err != nil {
+		return nil, err
+	}
+	h.record(&treeEdit{root: entity.Handle(), name: entity.Name, present: true})
+	return entity, nil
+}
+
+// DespawnTree is App.DespawnTree, recorded. The subtree is captured first, in
+// the scene-file form a save would write, so undo rebuilds it through the same
+// path a load does. That means only what a save keeps comes back: an entity
+// with an unregistered component cannot be captured, and is refused rather
+// than deleted beyond recall.
+func (h *History) DespawnTree(handle Handle) error {
+	snapshot, err := h.capture(handle, true)
+	if err != nil {
+		return err
+	}
+	if err := h.app.DespawnTree(handle); err != nil {
+		return err
+	}
+	h.record(&treeEdit{root: handle, name: snapshot.row.Name, snapshot: snapshot})
+	return nil
+}
+
+// DespawnObject is App.DespawnObject, recorded: the entity goes, its children
+// are lifted to the scene root, and undo puts them back under it.
+func (h *History) DespawnObject(handle Handle) error {
+	snapshot, err := h.capture(handle, false)
+	if err != nil {
+		return err
+	}
+	info, _ := h.app.ObjectInfo(handle)
+
+	if err := h.app.DespawnObject(handle); err != nil {
+		return err
+	}
+	h.record(&treeEdit{root: handle, name: snapshot.row.Name, snapshot: snapshot, orphans: info.Children})
+	return nil
+}
+
+// SetParent is App.SetParent, recorded.
+func (h *History) SetParent(child, parent Handle) error {
+	info, ok := h.app.ObjectInfo(child)
+	if !ok {
+		return fmt.Errorf("object %s not found", child)
+	}
+	if err := h.app.SetParent(child, parent); err != nil {
+		return err
+	}
+	h.record(&parentEdit{child: child, before: info.Parent, after: parent})
+	return nil
+}
+
+// UpdateTransform is App.UpdateTransform, recorded. Successive updates to one
+// entity inside a transaction merge into a single step.
+func (h *History) UpdateTransform(handle Handle, fn func(t *Transform)) error {
+	var before, after Transform
+	err := h.app.UpdateTransform(handle, func(t *Transform) {
+		before = *t
+		fn(t)
+		after = *t
+	})
+	if err != nil {
+		return err
+	}
+	h.record(&transformEdit{handle: handle, before: before, after: after})
+	return nil
+}
+
+// SetBaseColor is App.SetBaseColor, recorded.
+func (h *History) SetBaseColor(handle Handle, color mgl32.Vec3) error {
+	info, ok := h.app.ObjectInfo(handle)
+	if !ok {
+		return fmt.Errorf("object %s not found", handle)
+	}
+	if err := h.app.SetBaseColor(handle, color); err != nil {
+		return err
+	}
+	h.record(&colorEdit{handle: handle, before: info.BaseColor, after: color})
+	return nil
+}
+
+// SetComponentField is App.SetComponentField, recorded.
+func (h *History) SetComponentField(handle Handle, index int, typeName string, field ComponentField) error {
+	before, err := h.componentField(handle, index, field.Name)
+	if err != nil {
+		return err
+	}
+	if err := h.app.SetComponentField(handle, index, typeName, field); err != nil {
+		return err
+	}
+	h.record(&fieldEdit{handle: handle, index: index, typeName: typeName, before: before, after: field})
+	return nil
+}
+
+// AddComponent is App.AddComponent, recorded.
+func (h *History) AddComponent(handle Handle, typeName string, fields []ComponentField) (int, error) {
+	index, err := h.app.AddComponent(handle, typeName, fields)
+	if err != nil {
+		return -1, err
+	}
+	h.record(&componentEdit{handle: handle, index: index, typeName: typeName, present: true})
+	return index, nil
+}
+
+// RemoveComponent is App.RemoveComponent, recorded. A component whose type is
+// not registered is refused, since there would be no way to build it again.
+func (h *History) RemoveComponent(handle Handle, index int, typeName string) error {
+	components, ok := h.app.ComponentsOf(handle)
+	if !ok {
+		return fmt.Errorf("object %s not found", handle)
+	}
+	if index >= 0 && index < len(components) && components[index].Type == "" {
+		return fmt.Errorf("cannot be undone: component %d on object %s is a %s, which is not registered",
+			index, handle, components[index].GoType)
+	}
+
+	removed, err := h.app.RemoveComponent(handle, index, typeName)
+	if err != nil {
+		return err
+	}
+	h.record(&componentEdit{handle: handle, index: index, typeName: removed.Type, fields: removed.Fields})
+	return nil
+}
+
+func (h *History) componentField(handle Handle, index int, name string) (ComponentField, error) {
+	components, ok := h.app.ComponentsOf(handle)
+	if !ok {
+		return ComponentField{}, fmt.Errorf("object %s not found", handle)
+	}
+	if index < 0 || index >= len(components) {
+		return ComponentField{}, fmt.Errorf("object %s has no component at index %d", handle, index)
+	}
+	for _, field := range components[index].Fields {
+		if field.Name == name {
+			return field, nil
+		}
+	}
+	return ComponentField{}, fmt.Errorf("component %d on object %s has no property %q", index, handle, name)
+}
+
+// --- subtree capture ---------------------------------------------------------
+
+// subtreeSnapshot is enough to rebuild a subtree as it was.
+type subtreeSnapshot struct {
+	row    scene.Object
+	parent Handle
+	// layer is the scene layer the subtree was loaded with, which it goes
+	// back to.
+	layer string
+	// handles lists the subtree's entities in pre-order, which is the order
+	// BuildTree creates them in, so the rebuilt entities pair up one to one.
+	handles []Handle
+}
+
+// capture describes a subtree, or the entity alone, for rebuilding later.
+func (h *History) capture(root Handle, withChildren bool) (subtreeSnapshot, error) {
+	var snapshot subtreeSnapshot
+	var err error
+
+	found := h.app.World.Mutate(root, func(entity *Entity) {
+		if parent := entity.Parent(); parent != nil {
+			snapshot.parent = parent.Handle()
+		}
+		snapshot.layer = entity.layer
+		if withChildren {
+			snapshot.row, err = h.app.describeForSave(entity)
+			snapshot.handles = preorderHandles(entity)
+		} else {
+			// The entity's own row, never a prefab reference: rebuilding a
+			// reference would bring the prefab's children back as well as the
+			// ones being put back under it.
+			snapshot.row, err = h.app.describeSelf(entity)
+			snapshot.handles = []Handle{root}
+		}
+	})
+	if !found {
+		return subtreeSnapshot{}, fmt.Errorf("object %s not found", root)
+	}
+	if err != nil {
+		return subtreeSnapshot{}, fmt.Errorf("cannot be undone: %w", err)
+	}
+	return snapshot, nil
+}
+
+// restore rebuilds a captured subtree under its old parent and points the old
+// handles at the new entities.
+func (h *History) restore(snapshot subtreeSnapshot) (Handle, error) {
+	spec, err := h.app.Scenes.buildSpec(&snapshot.row)
+	if err != nil {
+		return NoHandle, err
+	}
+	spec.Parent = h.Resolve(snapshot.parent)
+
+	root, err := h.app.SpawnObject(spec)
+	if err != nil {
+		return NoHandle, err
+	}
+
+	var rebuilt []Handle
+	h.app.World.Mutate(root.Handle(), func(entity *Entity) {
+		markLayer(entity, snapshot.layer)
+		rebuilt = preorderHandles(entity)
+	})
+	for i := range min(len(rebuilt), len(snapshot.handles)) {
+		h.rebind(snapshot.handles[i], rebuilt[i])
+	}
+	return root.Handle(), nil
+}
+
+// preorderHandles lists a subtree parents-first. Callers hold the world lock.
+func preorderHandles(root *Entity) []Handle {
+	order := []Handle{root.Handle()}
+	for _, child := range root.Children() {
+		order = append(order, preorderHandles(child)...)
+	}
+	return order
+}
+
+// --- edits --------------------------------------------------------------------
+
+// treeEdit is a spawn or a delete: the same change seen from either end.
+// present says whether the subtree exists after the edit was applied.
+type treeEdit struct {
+	root     Handle
+	name     string
+	present  bool
+	snapshot subtreeSnapshot
+
+	// orphans are the children a single-entity delete lifted to the root, to
+	// be put back under it on undo.
+	orphans []Handle
+}
+
+func (e *treeEdit) undo(h *History) error {
+	if e.present {
+		return e.remove(h)
+	}
+	return e.add(h)
+}
+
+func (e *treeEdit) redo(h *History) error {
+	if e.present {
+		return e.add(h)
+	}
+	return e.remove(h)
+}
+
+func (e *treeEdit) remove(h *History) error {
+	root := h.Resolve(e.root)
+	snapshot, err := h.capture(root, e.orphans == nil)
+	if err != nil {
+		return err
+	}
+	e.snapshot = snapshot
+
+	if e.orphans != nil {
+		return h.app.DespawnObject(root)
+	}
+	return h.app.DespawnTree(root)
+}
+
+func (e *treeEdit) add(h *History) error {
+	root, err := h.restore(e.snapshot)
+	if err != nil {
+		return err
+	}
+	for _, orphan := range e.orphans {
+		if err := h.app.SetParent(h.Resolve(orphan), root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *treeEdit) merge(edit) bool { return false }
+
+func (e *treeEdit) label() string {
+	if e.present {
+		return "Spawn " + e.name
+	}
+	return "Delete " + e.name
+}
+
+type parentEdit struct {
+	child         Handle
+	before, after Handle
+}
+
+func (e *parentEdit) undo(h *History) error {
+	return h.app.SetParent(h.Resolve(e.child), h.Resolve(e.before))
+}
+
+func (e *parentEdit) redo(h *History) error {
+	return h.app.SetParent(h.Resolve(e.child), h.Resolve(e.after))
+}
+
+func (e *parentEdit) merge(next edit) bool {
+	other, ok := next.(*parentEdit)
+	if !ok || other.child != e.child {
+		return false
+	}
+	e.after = other.after
+	return true
+}
+
+func (e *parentEdit) label() string { return "Reparent" }
+
+type transformEdit struct {
+	handle        Handle
+	before, after Transform
+}
+
+func (e *transformEdit) undo(h *History) error {
+	return h.app.UpdateTransform(h.Resolve(e.handle), func(t *Transform) { *t = e.before })
+}
+
+func (e *transformEdit) redo(h *History) error {
+	return h.app.UpdateTransform(h.Resolve(e.handle), func(t *Transform) { *t = e.after })
+}
+
+func (e *transformEdit) merge(next edit) bool {
+	other, ok := next.(*transformEdit)
+	if !ok || other.handle != e.handle {
+		return false
+	}
+	e.after = other.after
+	return true
+}
+
+func (e *transformEdit) label() string { return "Transform" }
+
+type colorEdit struct {
+	handle        Handle
+	before, after mgl32.Vec3
+}
+
+func (e *colorEdit) undo(h *History) error {
+	return h.app.SetBaseColor(h.Resolve(e.handle), e.before)
+}
+
+func (e *colorEdit) redo(h *History) error {
+	return h.app.SetBaseColor(h.Resolve(e.handle), e.after)
+}
+
+func (e *colorEdit) merge(next edit) bool {
+	other, ok := next.(*colorEdit)
+	if !ok || other.handle != e.handle {
+		return false
+	}
+	e.after = other.after
+	return true
+}
+
+func (e *colorEdit) label() string { return "Base colour" }
+
+type fieldEdit struct {
+	handle        Handle
+	index         int
+	typeName      string
+	before, after ComponentField
+}
+
+func (e *fieldEdit) undo(h *History) error {
+	return h.app.SetComponentField(h.Resolve(e.handle), e.index, e.typeName, e.before)
+}
+
+func (e *fieldEdit) redo(h *History) error {
+	return h.app.SetComponentField(h.Resolve(e.handle), e.index, e.typeName, e.after)
+}
+
+func (e *fieldEdit) merge(next edit) bool {
+	other, ok := next.(*fieldEdit)
+	if !ok || other.handle != e.handle || other.index != e.index || other.after.Name != e.after.Name {
+		return false
+	}
+	e.after = other.after
+	return true
+}
+
+func (e *fieldEdit) label() string {
+	if e.typeName != "" {
+		return e.typeName + "." + e.after.Name
+	}
+	return e.after.Name
+}
+
+// componentEdit is an added or removed component: the same change seen from
+// either end. present says whether the component exists after the edit.
+// fields is what it held when it was last removed, to build it again with.
+type componentEdit struct {
+	handle   Handle
+	index    int
+	typeName string
+	present  bool
+	fields   []ComponentField
+}
+
+func (e *componentEdit) undo(h *History) error {
+	if e.present {
+		return e.remove(h)
+	}
+	return e.add(h)
+}
+
+func (e *componentEdit) redo(h *History) error {
+	if e.present {
+		return e.add(h)
+	}
+	return e.remove(h)
+}
+
+func (e *componentEdit) remove(h *History) error {
+	removed, err := h.app.RemoveComponent(h.Resolve(e.handle), e.index, e.typeName)
+	if err != nil {
+		return err
+	}
+	e.fields = removed.Fields
+	return nil
+}
+
+func (e *componentEdit) add(h *History) error {
+	var editable []ComponentField
+	for _, field := range e.fields {
+		if field.Kind != FieldUnsupported {
+			editable = append(editable, field)
+		}
+	}
+	_, err := h.app.insertComponent(h.Resolve(e.handle), e.index, e.typeName, editable)
+	return err
+}
+
+func (e *componentEdit) merge(edit) bool { return false }
+
+func (e *componentEdit) label() string {
+	if e.present {
+		return "Add " + e.typeName
+	}
+	return "Remove " + e.typeName
+}
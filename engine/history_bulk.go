@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"3d-engine/scene"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// This file is the recorded API applied to a selection rather than to one
+// entity. Each call is one transaction, so a bulk edit undoes in one step and
+// a failure part-way through leaves the world as it was.
+
+// ErrEmptySelection is returned by the bulk edits when given no handles.
+var ErrEmptySelection = errors.New("nothing selected")
+
+// TopLevel filters a selection down to the entities with no ancestor also in
+// it, keeping their order. A child whose parent is selected already moves,
+// duplicates and deletes with that parent; acting on it as well would do so
+// twice — or, for a delete, fail on an entity that went with its parent.
+//
+// Handles that no longer resolve are dropped. Safe from any goroutine.
+func (a *App) TopLevel(handles []Handle) []Handle {
+	selected := make(map[Handle]bool, len(handles))
+	for _, handle := range handles {
+		selected[handle] = true
+	}
+
+	var top []Handle
+	a.World.Read(func([]*Entity) {
+		for _, handle := range handles {
+			entity := a.World.get(handle)
+			if entity == nil {
+				continue
+			}
+			covered := false
+			for parent := entity.Parent(); parent != nil; parent = parent.Parent() {
+				if selected[parent.Handle()] {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				top = append(top, handle)
+			}
+		}
+	})
+	return top
+}
+
+// MoveBy shifts every entity in the selection by the same world-space offset.
+// The offset is taken into each entity's parent space first, so an entity under
+// a turned or scaled parent still moves along the world axes with the rest.
+func (h *History) MoveBy(handles []Handle, offset mgl32.Vec3) error {
+	top := h.app.TopLevel(handles)
+	if len(top) == 0 {
+		return ErrEmptySelection
+	}
+
+	return h.Transaction(fmt.Sprintf("Move %s", countObjects(len(top))), func() error {
+		for _, handle := range top {
+			_, parent, ok := h.app.WorldMatrices(handle)
+			if !ok {
+				return fmt.Errorf("object %s not found", handle)
+			}
+			local := mgl32.TransformNormal(offset, parent.Inv())
+			if err := h.UpdateTransform(handle, func(t *Transform) {
+				t.Position = t.Position.Add(local)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetBaseColors tints every entity in the selection that has a model. The rest
+// — lights, groups — are skipped rather than failing the batch: a selection
+// dragged across a scene nearly always picks some up, and there is no colour
+// they could have taken.
+func (h *History) SetBaseColors(handles []Handle, color mgl32.Vec3) error {
+	var tinted []Handle
+	h.app.World.Read(func([]*Entity) {
+		for _, handle := range handles {
+			if entity := h.app.World.get(handle); entity != nil && entity.Renderer != nil {
+				tinted = append(tinted, handle)
+			}
+		}
+	})
+	if len(tinted) == 0 {
+		return fmt.Errorf("nothing selected has a model to colour")
+	}
+
+	return h.Transaction(fmt.Sprintf("Colour %s", countObjects(len(tinted))), func() error {
+		for _, handle := range tinted {
+			if err := h.SetBaseColor(handle, color); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DespawnTrees deletes every entity in the selection with everything below it.
+func (h *History) DespawnTrees(handles []Handle) error {
+	top := h.app.TopLevel(handles)
+	if len(top) == 0 {
+		return ErrEmptySelection
+	}
+
+	return h.Transaction(fmt.Sprintf("Delete %s", countObjects(len(top))), func() error {
+		for _, handle := range top {
+			if err := h.DespawnTree(handle); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Duplicate copies every entity in the selection, subtree and all, placing each
+// copy beside its original under the same parent. It returns the copies' roots
+// in selection order.
+//
+// The copy goes through the scene file's form, the way a save and a reload
+// would: describeForSave writes each component out through the registry, and
+// buildSpec constructs fresh ones from that. So any registered component
+// duplicates with its properties, without a copy method of its own, and an
+// unregistered one refuses the duplicate instead of being quietly left off the
+// copy — the same rule SaveScene has.
+func (h *History) Duplicate(handles []Handle) ([]Handle, error) {
+	top := h.app.TopLevel(handles)
+	if len(top) == 0 {
+		return nil, ErrEmptySelection
+	}
+
+	var copies []Handle
+	err := h.Transaction(fmt.Sprintf("Duplicate %s", countObjects(len(top))), func() error {
+		for _, handle := range top {
+			duplicate, err := h.duplicate(handle)
+			if err != nil {
+				return err
+			}
+			copies = append(copies, duplicate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+func (h *History) duplicate(handle Handle) (Handle, error) {
+	var row scene.Object
+	var parent Handle
+	var err error
+
+	found := h.app.World.Mutate(handle, func(entity *Entity) {
+		row, err = h.app.describeForSave(entity)
+		if entity.Parent() != nil {
+			parent = entity.Parent().Handle()
+		}
+	})
+	if !found {
+		return NoHandle, fmt.Errorf("object %s not found", handle)
+	}
+	if err != nil {
+		return NoHandle, fmt.Errorf("cannot duplicate: %w", err)
+	}
+
+	spec, err := h.app.Scenes.buildSpec(&row)
+	if err != nil {
+		return NoHandle, fmt.Errorf("cannot duplicate: %w", err)
+	}
+	spec.Parent = parent
+
+	entity, err := h.SpawnObject(spec)
+	if err != nil {
+		return NoHandle, err
+	}
+	return entity.Handle(), nil
+}
+
+// Group puts the selection under a new empty entity named name, and returns it.
+//
+// The group is placed at the centre of the selection with no rotation or
+// scale, under the parent the selection shares or at the scene root if it
+// shares none. Every grouped entity keeps its world position: its local
+// position is worked out again relative to the group. Rotation and scale are
+// kept as local values, the way SetParent keeps them, because a world matrix
+// cannot in general be decomposed back into a transform (see Transform). For a
+// selection that shared a parent that is exact, since the group adds nothing
+// but an offset; an entity brought in from under a turned or scaled parent
+// keeps its place but not its orientation.
+func (h *History) Group(handles []Handle, name string) (Handle, error) {
+	top := h.app.TopLevel(handles)
+	if len(top) == 0 {
+		return NoHandle, ErrEmptySelection
+	}
+	if name == "" {
+		name = "group"
+	}
+
+	parent, shared := NoHandle, true
+	var centre mgl32.Vec3
+	for i, handle := range top {
+		info, ok := h.app.ObjectInfo(handle)
+		if !ok {
+			return NoHandle, fmt.Errorf("object %s not found", handle)
+		}
+		if i == 0 {
+			parent = info.Parent
+		} else if info.Parent != parent {
+			shared = false
+		}
+		world, _, _ := h.app.WorldMatrices(handle)
+		centre = centre.Add(world.Col(3).Vec3())
+	}
+	centre = centre.Mul(1 / float32(len(top)))
+	if !shared {
+		parent = NoHandle
+	}
+
+	var group Handle
+	err := h.Transaction(fmt.Sprintf("Group %s", countObjects(len(top))), func() error {
+		transform := IdentityTransform()
+		transform.Position = centre
+		if !parent.IsZero() {
+			parentWorld, _, _ := h.app.WorldMatrices(parent)
+			transform.Position = mgl32.TransformCoordinate(centre, parentWorld.Inv())
+		}
+
+		entity, err := h.SpawnObject(ObjectSpec{Name: name, Transform: transform, Parent: parent})
+		if err != nil {
+			return err
+		}
+		group = entity.Handle()
+		groupWorld, _, _ := h.app.WorldMatrices(group)
+		toGroup := groupWorld.Inv()
+
+		for _, handle := range top {
+			world, _, ok := h.app.WorldMatrices(handle)
+			if !ok {
+				return fmt.Errorf("object %s not found", handle)
+			}
+			if err := h.SetParent(handle, group); err != nil {
+				return err
+			}
+			local := mgl32.TransformCoordinate(world.Col(3).Vec3(), toGroup)
+			if err := h.UpdateTransform(handle, func(t *Transform) {
+				t.Position = local
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return NoHandle, err
+	}
+	return group, nil
+}
+
+// countObjects is "1 object" or "n objects", for transaction labels.
+func countObjects(n int) string {
+	if n == 1 {
+		return "1 object"
+	}
+	return fmt.Sprintf("%d objects", n)
+}
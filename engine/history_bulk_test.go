@@ -0,0 +1,277 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func sameSpot(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-4
+}
+
+func TestTopLevelDropsSelectedDescendants(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	arm := spawnLight(t, h, "arm", rig)
+	bulb := spawnLight(t, h, "bulb", arm)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	got := a.TopLevel([]Handle{bulb, lamp, rig})
+	if len(got) != 2 || got[0] != lamp || got[1] != rig {
+		t.Errorf("TopLevel = %v, want lamp then rig", got)
+	}
+	// The grandchild is covered by the rig even though the arm between them is
+	// not selected.
+	if got := a.TopLevel([]Handle{bulb, rig}); len(got) != 1 || got[0] != rig {
+		t.Errorf("TopLevel = %v, want just rig", got)
+	}
+}
+
+func TestMoveByIsWorldSpaceAndOneStep(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	bulb := spawnLight(t, h, "bulb", rig)
+
+	// A rig scaled by two: the bulb's local offset must be half the world one.
+	if err := h.UpdateTransform(rig, func(tr *Transform) { tr.Scale = mgl32.Vec3{2, 2, 2} }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.MoveBy([]Handle{lamp, bulb}, mgl32.Vec3{4, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if got := position(t, a, lamp); got != (mgl32.Vec3{4, 0, 0}) {
+		t.Errorf("lamp at %v", got)
+	}
+	if got := position(t, a, bulb); got != (mgl32.Vec3{2, 0, 0}) {
+		t.Errorf("bulb local position %v, want 2 to move 4 in the world", got)
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if position(t, a, lamp) != (mgl32.Vec3{}) || position(t, a, bulb) != (mgl32.Vec3{}) {
+		t.Error("one undo did not take back the whole move")
+	}
+}
+
+func TestSetBaseColorsSkipsEntitiesWithoutModels(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	var tinted []Handle
+	for _, name := range []string{"left", "right"} {
+		entity := NewEntity(name)
+		entity.Renderer = &MeshRenderer{BaseColor: DefaultBaseColor}
+		a.World.Spawn(entity)
+		tinted = append(tinted, entity.Handle())
+	}
+
+	red := mgl32.Vec3{1, 0, 0}
+	if err := h.SetBaseColors(append([]Handle{lamp}, tinted...), red); err != nil {
+		t.Fatal(err)
+	}
+	for _, handle := range tinted {
+		if info, _ := a.ObjectInfo(handle); info.BaseColor != red {
+			t.Errorf("%s colour %v", info.Name, info.BaseColor)
+		}
+	}
+	if got := h.UndoLabel(); got != "Colour 2 objects" {
+		t.Errorf("undo label %q", got)
+	}
+
+	if err := h.SetBaseColors([]Handle{lamp}, red); err == nil {
+		t.Error("colouring only a light should fail")
+	}
+}
+
+func TestDespawnTreesDeletesOnceAndUndoes(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	arm := spawnLight(t, h, "arm", rig)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	// The arm goes with the rig; deleting it again would fail the batch.
+	if err := h.DespawnTrees([]Handle{arm, rig, lamp}); err != nil {
+		t.Fatal(err)
+	}
+	if a.World.Len() != 0 {
+		t.Fatalf("%d entities left", a.World.Len())
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if a.World.Len() != 3 {
+		t.Fatalf("%d entities after undo, want 3", a.World.Len())
+	}
+}
+
+// TestDuplicateCopiesComponentState checks the copy is made through the
+// registry: the arm's edited property must come across, which it would not if
+// the duplicate were built from the registered defaults.
+func TestDuplicateCopiesComponentState(t *testing.T) {
+	a, h := historyApp(t)
+	stage := spawnLight(t, h, "stage", NoHandle)
+	rig := spawnLight(t, h, "rig", stage)
+	arm := spawnLight(t, h, "arm", rig)
+
+	if err := h.UpdateTransform(rig, func(tr *Transform) { tr.Position = mgl32.Vec3{1, 2, 3} }); err != nil {
+		t.Fatal(err)
+	}
+	field := ComponentField{Name: "linear", Kind: FieldFloat, Float: 0.5}
+	if err := h.SetComponentField(arm, 0, "PointLight", field); err != nil {
+		t.Fatal(err)
+	}
+
+	copies, err := h.Duplicate([]Handle{arm, rig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copies) != 1 {
+		t.Fatalf("Duplicate made %d copies, want one of the rig", len(copies))
+	}
+	if a.World.Len() != 5 {
+		t.Fatalf("world has %d entities, want 5", a.World.Len())
+	}
+
+	info, _ := a.ObjectInfo(copies[0])
+	if info.Name != "rig" || info.Parent != stage || info.Transform.Position != (mgl32.Vec3{1, 2, 3}) {
+		t.Errorf("copy is %+v, want a rig beside the original", info)
+	}
+	if len(info.Children) != 1 {
+		t.Fatalf("copy has %d children, want its arm", len(info.Children))
+	}
+	components, _ := a.ComponentsOf(info.Children[0])
+	if got := fieldsByName(components[0].Fields)["linear"].Float; got != 0.5 {
+		t.Errorf("copied arm linear = %v, want the edited 0.5", got)
+	}
+
+	// Editing the copy leaves the original alone.
+	field.Float = 0.25
+	if err := h.SetComponentField(info.Children[0], 0, "PointLight", field); err != nil {
+		t.Fatal(err)
+	}
+	components, _ = a.ComponentsOf(arm)
+	if got := fieldsByName(components[0].Fields)["linear"].Float; got != 0.5 {
+		t.Errorf("original arm linear = %v after editing the copy", got)
+	}
+
+	h.Undo()
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if a.World.Len() != 3 {
+		t.Errorf("world has %d entities after undoing the duplicate", a.World.Len())
+	}
+}
+
+func TestDuplicateRefusesUnregisteredComponent(t *testing.T) {
+	a, h := historyApp(t)
+	entity := NewEntity("mystery")
+	entity.AddComponent(&probe{Label: "unregistered"})
+	a.World.Spawn(entity)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	if _, err := h.Duplicate([]Handle{lamp, entity.Handle()}); err == nil {
+		t.Fatal("duplicating an unregistered component should fail rather than drop it")
+	}
+	// All or nothing: the lamp's copy went with the failure.
+	if a.World.Len() != 2 {
+		t.Errorf("world has %d entities after a failed duplicate", a.World.Len())
+	}
+}
+
+func TestGroupKeepsWorldPositions(t *testing.T) {
+	a, h := historyApp(t)
+	stage := spawnLight(t, h, "stage", NoHandle)
+	left := spawnLight(t, h, "left", stage)
+	right := spawnLight(t, h, "right", stage)
+	h.UpdateTransform(stage, func(tr *Transform) { tr.Position = mgl32.Vec3{10, 0, 0} })
+	h.UpdateTransform(left, func(tr *Transform) { tr.Position = mgl32.Vec3{-2, 0, 0} })
+	h.UpdateTransform(right, func(tr *Transform) { tr.Position = mgl32.Vec3{4, 0, 2} })
+
+	worldOf := func(handle Handle) mgl32.Vec3 {
+		world, _, _ := a.WorldMatrices(handle)
+		return world.Col(3).Vec3()
+	}
+	leftBefore, rightBefore := worldOf(left), worldOf(right)
+
+	group, err := h.Group([]Handle{left, right}, "pair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := a.ObjectInfo(group)
+	if info.Name != "pair" || info.Parent != stage {
+		t.Errorf("group is %+v, want it under the shared parent", info)
+	}
+	if info.Transform.Position != (mgl32.Vec3{1, 0, 1}) {
+		t.Errorf("group at %v, want the selection's centre", info.Transform.Position)
+	}
+	for _, handle := range []Handle{left, right} {
+		if child, _ := a.ObjectInfo(handle); child.Parent != group {
+			t.Errorf("%s not moved under the group", child.Name)
+		}
+	}
+	if !sameSpot(worldOf(left), leftBefore) || !sameSpot(worldOf(right), rightBefore) {
+		t.Errorf("grouping moved things: %v, %v", worldOf(left), worldOf(right))
+	}
+
+	if got := h.UndoLabel(); got != "Group 2 objects" {
+		t.Errorf("undo label %q", got)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if child, _ := a.ObjectInfo(left); child.Parent != stage || child.Transform.Position != (mgl32.Vec3{-2, 0, 0}) {
+		t.Errorf("after undo left is %+v", child)
+	}
+	if a.World.Find("pair") != nil {
+		t.Error("the group survived the undo")
+	}
+}
+
+func TestGroupAcrossParentsGoesToTheRoot(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	h.UpdateTransform(rig, func(tr *Transform) { tr.Position = mgl32.Vec3{0, 5, 0} })
+	bulb := spawnLight(t, h, "bulb", rig)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	group, err := h.Group([]Handle{bulb, lamp}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := a.ObjectInfo(group)
+	if !info.Parent.IsZero() || info.Name != "group" {
+		t.Errorf("group is %+v, want an entity called group at the root", info)
+	}
+	world, _, _ := a.WorldMatrices(bulb)
+	if got := world.Col(3).Vec3(); !sameSpot(got, mgl32.Vec3{0, 5, 0}) {
+		t.Errorf("bulb moved to %v", got)
+	}
+
+	if _, err := h.Group(nil, "empty"); !errors.Is(err, ErrEmptySelection) {
+		t.Errorf("grouping nothing = %v", err)
+	}
+}
+
+// TestBulkEditInsideGestureKeepsItsLabel is the editor's case: the button that
+// starts a bulk edit is a held widget, so the edit runs inside an unlabelled
+// transaction, and the step should still be called what it did.
+func TestBulkEditInsideGestureKeepsItsLabel(t *testing.T) {
+	_, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	h.Begin("")
+	if _, err := h.Duplicate([]Handle{lamp}); err != nil {
+		t.Fatal(err)
+	}
+	h.Commit()
+
+	if got := h.UndoLabel(); got != "Duplicate 1 object" {
+		t.Errorf("undo label %q", got)
+	}
+}
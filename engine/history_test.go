@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// historyApp is saveTestApp with a History attached. No entity here has a
+// model, so spawning and restoring never reach the asset cache or GL.
+func historyApp(t *testing.T) (*App, *History) {
+	t.Helper()
+
+	a := saveTestApp(t)
+	a.History = NewHistory(a)
+	return a, a.History
+}
+
+func spawnLight(t *testing.T, h *History, name string, parent Handle) Handle {
+	t.Helper()
+
+	entity, err := h.SpawnObject(ObjectSpec{
+		Name:       name,
+		Transform:  IdentityTransform(),
+		Components: []Component{NewPointLight()},
+		Parent:     parent,
+	})
+	if err != nil {
+		t.Fatalf("spawning %s: %v", name, err)
+	}
+	return entity.Handle()
+}
+
+func position(t *testing.T, a *App, handle Handle) mgl32.Vec3 {
+	t.Helper()
+
+	info, ok := a.ObjectInfo(handle)
+	if !ok {
+		t.Fatalf("object %s not found", handle)
+	}
+	return info.Transform.Position
+}
+
+func TestUndoRedoTransform(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	if err := h.UpdateTransform(lamp, func(tr *Transform) { tr.Position = mgl32.Vec3{1, 2, 3} }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got := position(t, a, lamp); got != (mgl32.Vec3{}) {
+		t.Fatalf("after undo position = %v, want origin", got)
+	}
+
+	if err := h.Redo(); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if got := position(t, a, lamp); got != (mgl32.Vec3{1, 2, 3}) {
+		t.Fatalf("after redo position = %v", got)
+	}
+}
+
+// TestDragIsOneStep is the editor's case: a drag writes the transform every
+// frame inside one transaction, and one undo must take all of it back.
+func TestDragIsOneStep(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	h.Begin("")
+	for i := 1; i <= 30; i++ {
+		x := float32(i)
+		if err := h.UpdateTransform(lamp, func(tr *Transform) { tr.Position[0] = x }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.Commit()
+
+	if got := h.UndoLabel(); got != "Transform" {
+		t.Errorf("undo label = %q", got)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := position(t, a, lamp); got.X() != 0 {
+		t.Fatalf("one undo left x = %v, want the position before the drag", got.X())
+	}
+
+	// The spawn is the only step left.
+	if got := h.UndoLabel(); got != "Spawn lamp" {
+		t.Errorf("next undo = %q, want the spawn", got)
+	}
+}
+
+// TestUndoDeleteRestoresSubtree also covers handle remapping: the restored
+// entities are new ones under new handles, and an edit recorded against the
+// old handle before the delete still finds its entity.
+func TestUndoDeleteRestoresSubtree(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	arm := spawnLight(t, h, "arm", rig)
+	bulb := spawnLight(t, h, "bulb", arm)
+
+	if err := h.UpdateTransform(bulb, func(tr *Transform) { tr.Position = mgl32.Vec3{0, 0, 2} }); err != nil {
+		t.Fatal(err)
+	}
+	field := ComponentField{Name: "linear", Kind: FieldFloat, Float: 0.5}
+	if err := h.SetComponentField(arm, 0, "PointLight", field); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.DespawnTree(rig); err != nil {
+		t.Fatal(err)
+	}
+	if a.World.Len() != 0 {
+		t.Fatalf("world has %d entities after the delete", a.World.Len())
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatalf("undo delete: %v", err)
+	}
+	if a.World.Len() != 3 {
+		t.Fatalf("world has %d entities after undoing the delete, want 3", a.World.Len())
+	}
+
+	newBulb := h.Resolve(bulb)
+	if newBulb == bulb {
+		t.Fatal("restored entity kept its dead handle")
+	}
+	bulbInfo, ok := a.ObjectInfo(newBulb)
+	if !ok || bulbInfo.Name != "bulb" {
+		t.Fatalf("bulb resolves to %+v", bulbInfo)
+	}
+	armInfo, _ := a.ObjectInfo(bulbInfo.Parent)
+	if armInfo.Name != "arm" || armInfo.Parent != h.Resolve(rig) {
+		t.Fatalf("hierarchy not restored: bulb under %q, arm under %v", armInfo.Name, armInfo.Parent)
+	}
+	components, _ := a.ComponentsOf(h.Resolve(arm))
+	if got := fieldsByName(components[0].Fields)["linear"].Float; got != 0.5 {
+		t.Errorf("restored arm linear = %v, want the edited 0.5", got)
+	}
+
+	// Older steps, recorded against the old handles, still apply.
+	if err := h.Undo(); err != nil {
+		t.Fatalf("undo field edit: %v", err)
+	}
+	components, _ = a.ComponentsOf(h.Resolve(arm))
+	if got := fieldsByName(components[0].Fields)["linear"].Float; got == 0.5 {
+		t.Error("field edit was not undone on the restored entity")
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatalf("undo move: %v", err)
+	}
+	if got := position(t, a, h.Resolve(bulb)); got != (mgl32.Vec3{}) {
+		t.Errorf("restored bulb at %v after undoing its move", got)
+	}
+
+	// And forwards again, through a second round of new handles.
+	for range 3 {
+		if err := h.Redo(); err != nil {
+			t.Fatalf("redo: %v", err)
+		}
+	}
+	if a.World.Len() != 0 {
+		t.Fatalf("world has %d entities after redoing the delete", a.World.Len())
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := position(t, a, h.Resolve(bulb)); got != (mgl32.Vec3{0, 0, 2}) {
+		t.Errorf("bulb at %v after a second restore", got)
+	}
+}
+
+func TestUndoDeleteKeepingChildren(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	arm := spawnLight(t, h, "arm", rig)
+
+	if err := h.DespawnObject(rig); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := a.ObjectInfo(arm); !info.Parent.IsZero() {
+		t.Fatal("child was not lifted to the root")
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := a.ObjectInfo(arm)
+	if info.Parent != h.Resolve(rig) {
+		t.Fatalf("child under %v after undo, want the restored parent %v", info.Parent, h.Resolve(rig))
+	}
+}
+
+func TestUndoSpawnAndReparent(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	if err := h.SetParent(lamp, rig); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := a.ObjectInfo(lamp); !info.Parent.IsZero() {
+		t.Fatal("reparent was not undone")
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.ObjectInfo(lamp); ok {
+		t.Fatal("spawn was not undone")
+	}
+	if err := h.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := a.ObjectInfo(h.Resolve(lamp))
+	if !ok || info.Parent != rig {
+		t.Fatalf("redo left lamp %+v", info)
+	}
+}
+
+func TestBaseColorUndo(t *testing.T) {
+	a, h := historyApp(t)
+	entity := NewEntity("tinted")
+	entity.Renderer = &MeshRenderer{BaseColor: DefaultBaseColor}
+	a.World.Spawn(entity)
+
+	if err := h.SetBaseColor(entity.Handle(), mgl32.Vec3{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := a.ObjectInfo(entity.Handle()); info.BaseColor != DefaultBaseColor {
+		t.Fatalf("base colour %v after undo", info.BaseColor)
+	}
+}
+
+func TestFailedTransactionRollsBack(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	failure := errors.New("boom")
+	err := h.Transaction("batch", func() error {
+		if err := h.UpdateTransform(lamp, func(tr *Transform) { tr.Position = mgl32.Vec3{9, 9, 9} }); err != nil {
+			return err
+		}
+		spawnLight(t, h, "extra", NoHandle)
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Transaction returned %v", err)
+	}
+
+	if got := position(t, a, lamp); got != (mgl32.Vec3{}) {
+		t.Errorf("move survived the rollback: %v", got)
+	}
+	if a.World.Find("extra") != nil {
+		t.Error("spawn survived the rollback")
+	}
+	if got := h.UndoLabel(); got != "Spawn lamp" {
+		t.Errorf("rolled-back transaction left %q on the stack", got)
+	}
+}
+
+func TestNewEditClearsRedo(t *testing.T) {
+	_, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	h.UpdateTransform(lamp, func(tr *Transform) { tr.Position[0] = 1 })
+	h.Undo()
+	h.UpdateTransform(lamp, func(tr *Transform) { tr.Position[1] = 1 })
+
+	if err := h.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("redo after a new edit = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestSceneLoadClearsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.yml")
+	if err := os.WriteFile(path, []byte(hierarchyScene), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, h := historyApp(t)
+	spawnLight(t, h, "lamp", NoHandle)
+	loadAndPlace(t, a, path)
+
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("undo after a scene load = %v, want ErrNothingToUndo", err)
+	}
+}
+
+// TestRPCEditDuringDrag: an edit a client sends while the editor holds a
+// transaction open is a step of its own, and the drag still commits as one.
+func TestRPCEditDuringDrag(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	shade := spawnLight(t, h, "shade", NoHandle)
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	drag := func(x float32) {
+		t.Helper()
+		if err := a.Do(func(*App) error {
+			return h.UpdateTransform(lamp, func(tr *Transform) { tr.Position[0] = x })
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.Do(func(*App) error { h.Begin("Move"); return nil })
+	drag(1)
+	if reply := roundTrip(t, stream, moveRequest(shade, 5)); !reply.GetSuccess() {
+		t.Fatalf("move over RPC: %v", reply)
+	}
+	drag(2)
+	a.Do(func(*App) error { h.Commit(); return nil })
+
+	var labels []string
+	for range 2 {
+		if err := a.Do(func(*App) error { labels = append(labels, h.UndoLabel()); return h.Undo() }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if labels[0] != "Move" || labels[1] != "Transform" {
+		t.Errorf("undid %q, want the drag and then the RPC move", labels)
+	}
+	if got := h.UndoLabel(); got != "Spawn shade" {
+		t.Errorf("next undo = %q, want the spawn", got)
+	}
+	if position(t, a, lamp).X() != 0 || position(t, a, shade).X() != 0 {
+		t.Errorf("two undos left lamp at %v and shade at %v", position(t, a, lamp), position(t, a, shade))
+	}
+}
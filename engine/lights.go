@@ -1,9 +1,15 @@
 package engine
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"3d-engine/shaders"
+	"math"
 
-// MaxPointLights mirrors MAX_POINT_LIGHT in shaders/lighting.frag. Lights past
-// this count are dropped with a warning rather than silently ignored.
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxPointLights is injected into the lighting shader as MAX_POINT_LIGHT, so
+// this is the only place the limit is written down. Lights past this count are
+// dropped with a warning rather than silently ignored.
 const MaxPointLights = 4
 
 // DirectionalLight is the sun: a direction and colours, no position. Attach one
@@ -104,6 +110,24 @@ func registerBuiltinComponents(r *ComponentRegistry) {
 	r.MustRegister("SpotLight", func() Component { return NewSpotLight() })
 }
 
+// linearColor decodes an sRGB colour to linear light. Every colour a person
+// picks — a light's in a scene file, a base colour in the editor's picker or
+// over RPC, the outline and debug colours here — is written in sRGB, the way
+// it looks on screen. The shaders light in linear values and the framebuffer
+// encodes them back to sRGB on write (see App.init), so each of those colours
+// is decoded on its way to the GPU, as sRGB textures are by the sampler.
+// Without it they are encoded twice and everything untextured washes out.
+func linearColor(c mgl32.Vec3) mgl32.Vec3 {
+	for i, v := range c {
+		if v <= 0.04045 {
+			c[i] = v / 12.92
+		} else {
+			c[i] = float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+		}
+	}
+	return c
+}
+
 // placedPointLight pairs a point light with the world position of its entity.
 type placedPointLight struct {
 	light    *PointLight
@@ -154,3 +178,71 @@ func (s *lightSet) collect(entity *Entity) {
 		}
 	}
 }
+
+// spotUniforms is the spot light as the shader sees it, once the flashlight
+// rules have decided where it points and whether it is on.
+type spotUniforms struct {
+	light     *SpotLight
+	position  mgl32.Vec3
+	direction mgl32.Vec3
+	enabled   bool
+}
+
+// packLights lays out the Lights block declared in shaders/lights.glsl. Unused
+// point-light slots are written as zeros so the block always has its declared
+// size; nb_point_light keeps the shader from reading them.
+func packLights(w *shaders.Std140, lights *lightSet, spot spotUniforms) {
+	w.BeginStruct()
+	if sun := lights.directional; sun != nil {
+		w.Vec3(sun.Direction)
+		w.Vec3(linearColor(sun.Ambient))
+		w.Vec3(linearColor(sun.Diffuse))
+		w.Vec3(linearColor(sun.Specular))
+	} else {
+		// No sun in the scene: contribute nothing rather than leaving whatever
+		// the previous frame uploaded.
+		w.Vec3(mgl32.Vec3{0, -1, 0})
+		w.Vec3(mgl32.Vec3{})
+		w.Vec3(mgl32.Vec3{})
+		w.Vec3(mgl32.Vec3{})
+	}
+	w.EndStruct()
+
+	for i := 0; i < MaxPointLights; i++ {
+		var placed placedPointLight
+		light := &PointLight{}
+		if i < len(lights.points) {
+			placed = lights.points[i]
+			light = placed.light
+		}
+		w.BeginStruct()
+		w.Vec3(placed.position)
+		w.Float(light.Constant)
+		w.Float(light.Linear)
+		w.Float(light.Quadratic)
+		w.Vec3(linearColor(light.Ambient))
+		w.Vec3(linearColor(light.Diffuse))
+		w.Vec3(linearColor(light.Specular))
+		w.EndStruct()
+	}
+
+	light := spot.light
+	if light == nil {
+		light = &SpotLight{}
+	}
+	w.BeginStruct()
+	w.Vec3(spot.position)
+	w.Vec3(spot.direction)
+	w.Float(float32(math.Cos(float64(mgl32.DegToRad(light.CutOff)))))
+	w.Float(float32(math.Cos(float64(mgl32.DegToRad(light.OuterCutOff)))))
+	w.Float(light.Constant)
+	w.Float(light.Linear)
+	w.Float(light.Quadratic)
+	w.Vec3(linearColor(light.Ambient))
+	w.Vec3(linearColor(light.Diffuse))
+	w.Vec3(linearColor(light.Specular))
+	w.Bool(spot.enabled && spot.light != nil)
+	w.EndStruct()
+
+	w.Int(int32(len(lights.points)))
+}
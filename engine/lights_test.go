@@ -1,6 +1,9 @@
 package engine
 
 import (
+	"3d-engine/shaders"
+	"encoding/binary"
+	"math"
 	"testing"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -107,3 +110,44 @@ func TestEntitiesWithoutLightsAreIgnored(t *testing.T) {
 		t.Fatal("a non-light entity contributed lights")
 	}
 }
+
+// TestPackLightsMatchesBlockLayout pins the offsets of the Lights block in
+// shaders/lights.glsl: DirLight is 64 bytes, each PointLight 80, SpotLight 96,
+// then nb_point_light.
+func TestPackLightsMatchesBlockLayout(t *testing.T) {
+	lights := lightSet{}
+	lights.collect(lightEntity("lamp", mgl32.Vec3{3, 4, 5}, NewPointLight()))
+	spot := NewSpotLight()
+
+	var w shaders.Std140
+	packLights(&w, &lights, spotUniforms{light: spot, position: mgl32.Vec3{1, 2, 3}, enabled: true})
+	b := w.Bytes()
+
+	const pointsAt, spotAt, countAt = 64, 64 + 80*MaxPointLights, 64 + 80*MaxPointLights + 96
+	if len(b) != countAt+16 {
+		t.Fatalf("block size = %d, want %d", len(b), countAt+16)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(b[pointsAt+4:])); got != 4 {
+		t.Fatalf("pointLights[0].position.y = %v, want 4", got)
+	}
+	// Written as sRGB, uploaded as linear light.
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(b[pointsAt+48:])); math.Abs(float64(got)-0.6038) > 1e-4 {
+		t.Fatalf("pointLights[0].diffuse.r = %v, want 0.8 decoded from sRGB", got)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(b[spotAt+8:])); got != 3 {
+		t.Fatalf("spotLight.position.z = %v, want 3", got)
+	}
+	if got := binary.LittleEndian.Uint32(b[spotAt+92:]); got != 1 {
+		t.Fatalf("spotLight.isEnabled = %d, want 1", got)
+	}
+	if got := binary.LittleEndian.Uint32(b[countAt:]); got != 1 {
+		t.Fatalf("nb_point_light = %d, want 1", got)
+	}
+}
+
+func TestLinearColor(t *testing.T) {
+	got := linearColor(mgl32.Vec3{0, 0.5, 1})
+	if got[0] != 0 || math.Abs(float64(got[1])-0.2140) > 1e-4 || got[2] != 1 {
+		t.Errorf("linearColor(0, 0.5, 1) = %v, want 0, 0.214, 1", got)
+	}
+}
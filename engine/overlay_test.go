@@ -70,7 +70,7 @@ func TestEditorToggleSurvivesKeyboardCapture(t *testing.T) {
 	defaultBindings(m)
 
 	// A frame where the overlay captures the keyboard and both F1 and W are held.
-	held := heldKeys{glfw.KeyF1: true, glfw.KeyW: true}
+	held := input.State{Keys: map[glfw.Key]bool{glfw.KeyF1: true, glfw.KeyW: true}}
 	m.Poll(held, true)
 
 	if !m.IsDown(ActionToggleEditor) {
@@ -80,8 +80,3 @@ func TestEditorToggleSurvivesKeyboardCapture(t *testing.T) {
 		t.Fatal("movement must be suppressed while the overlay owns the keyboard")
 	}
 }
-
-// heldKeys is a KeySource for a fixed set of held keys.
-type heldKeys map[glfw.Key]bool
-
-func (h heldKeys) IsKeyDown(key glfw.Key) bool { return h[key] }
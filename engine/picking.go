@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"sort"
+
+	"3d-engine/object"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PickHit is one entity a ray passes through.
+type PickHit struct {
+	Handle Handle
+
+	// Distance is how far along the ray the entity's nearest triangle is, in
+	// multiples of the ray's direction: world units for a unit direction.
+	Distance float32
+}
+
+// Pick returns every entity whose geometry the world-space ray crosses,
+// nearest first.
+//
+// Each entity's bounds are tried before its triangles, so a click costs a box
+// test per entity plus a triangle pass over the few the ray actually enters.
+// A bounds hit alone is not enough: Sponza's floor has bounds the size of the
+// building, and would otherwise swallow every click. Entities with nothing to
+// render — lights, groups — have no surface to hit and are never picked.
+//
+// Safe from any goroutine; it takes the write lock because reading a world
+// matrix may rebuild its cache.
+func (a *App) Pick(origin, dir mgl32.Vec3) []PickHit {
+	var hits []PickHit
+
+	a.World.Write(func(entities []*Entity) {
+		for _, entity := range entities {
+			if entity.Renderer == nil || entity.Renderer.Model == nil {
+				continue
+			}
+			model := entity.Renderer.Model
+			world := entity.WorldMatrix()
+			if bounds, ok := model.LocalBounds(); ok {
+				if _, ok := bounds.Transform(world).IntersectRay(origin, dir); !ok {
+					continue
+				}
+			}
+			distance, ok := model.IntersectRay(world, origin, dir)
+			if ok {
+				hits = append(hits, PickHit{Handle: entity.Handle(), Distance: distance})
+			}
+		}
+	})
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return hits
+}
+
+// NextPick chooses what a click selects from the hits under it.
+//
+// A fresh click takes the nearest. A repeat click on the same spot steps to
+// the hit behind the current one, wrapping round to the front, which is how a
+// vase standing in front of a wall can be clicked through to the wall. Only a
+// repeat cycles: clicking somewhere new where the current selection happens to
+// be one layer down should still take what is on top.
+func NextPick(hits []PickHit, current Handle, repeat bool) Handle {
+	if len(hits) == 0 {
+		return NoHandle
+	}
+	if repeat {
+		for i, hit := range hits {
+			if hit.Handle == current {
+				return hits[(i+1)%len(hits)].Handle
+			}
+		}
+	}
+	return hits[0].Handle
+}
+
+// SetHighlight chooses the entities drawn with a selection outline, replacing
+// the last set. The editor calls it every frame with its selection. Frame-loop
+// goroutine only, like the render that reads it.
+func (a *App) SetHighlight(handles ...Handle) {
+	a.highlight = append(a.highlight[:0], handles...)
+}
+
+// outlineWidth is the selection outline's thickness in pixels.
+const outlineWidth = 3
+
+// outlineColor is the selection outline's colour: an orange that reads against
+// both Sponza's stone and the sky.
+var outlineColor = mgl32.Vec3{1.0, 0.55, 0.1}
+
+// outlineItem is one mesh of a highlighted entity.
+type outlineItem struct {
+	mesh     *object.Mesh
+	modelMat mgl32.Mat4
+}
+
+// drawOutlines draws an outline around the highlighted entities' silhouettes.
+//
+// Two passes through the stencil buffer. The first draws the meshes as they
+// are, writing only stencil, which marks every pixel the selection covers. The
+// second draws them again pushed outwards by a few pixels, only where the
+// stencil is unmarked, which leaves just the rim. Depth testing is off for
+// both, so a selection behind a pillar still shows where it is.
+func (a *App) drawOutlines(items []outlineItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	shader := a.outlineShader
+	shader.Use()
+	shader.SetVec2("viewport", float32(a.width), float32(a.height))
+	shader.SetVec3Val("color", linearColor(outlineColor))
+
+	gl.Enable(gl.STENCIL_TEST)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.CULL_FACE)
+	gl.StencilMask(0xff)
+
+	// Mark the silhouette.
+	gl.StencilFunc(gl.ALWAYS, 1, 0xff)
+	gl.StencilOp(gl.KEEP, gl.KEEP, gl.REPLACE)
+	gl.ColorMask(false, false, false, false)
+	shader.SetFloat("width", 0)
+	for _, item := range items {
+		shader.SetMat4("model", item.modelMat)
+		item.mesh.DrawGeometry()
+	}
+
+	// Paint the rim around it.
+	gl.StencilFunc(gl.NOTEQUAL, 1, 0xff)
+	gl.StencilOp(gl.KEEP, gl.KEEP, gl.KEEP)
+	gl.ColorMask(true, true, true, true)
+	shader.SetFloat("width", outlineWidth)
+	for _, item := range items {
+		shader.SetMat4("model", item.modelMat)
+		item.mesh.DrawGeometry()
+	}
+
+	gl.Enable(gl.CULL_FACE)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Disable(gl.STENCIL_TEST)
+}
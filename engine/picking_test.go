@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"testing"
+
+	"3d-engine/object"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// spawnPanel puts a unit square facing +Z at position. The model is built
+// straight from vertices, never uploaded, which is all picking looks at.
+func spawnPanel(a *App, name string, position mgl32.Vec3) Handle {
+	panel := &object.Model{Meshes: []object.Mesh{{
+		Vertices: []object.Vertex{
+			{Position: mgl32.Vec3{-0.5, -0.5, 0}},
+			{Position: mgl32.Vec3{0.5, -0.5, 0}},
+			{Position: mgl32.Vec3{0.5, 0.5, 0}},
+			{Position: mgl32.Vec3{-0.5, 0.5, 0}},
+		},
+		Indices: []uint32{0, 1, 2, 0, 2, 3},
+	}}}
+
+	entity := NewEntity(name)
+	entity.Renderer = &MeshRenderer{Model: panel, BaseColor: DefaultBaseColor}
+	entity.SetPosition(position)
+	return a.World.Spawn(entity).Handle()
+}
+
+func TestPickSortsHitsNearestFirst(t *testing.T) {
+	a := saveTestApp(t)
+	back := spawnPanel(a, "back", mgl32.Vec3{0, 0, -5})
+	front := spawnPanel(a, "front", mgl32.Vec3{0, 0, -2})
+	spawnPanel(a, "aside", mgl32.Vec3{3, 0, -3})
+
+	// A light has no surface to click.
+	light := NewEntity("light")
+	light.AddComponent(NewPointLight())
+	a.World.Spawn(light)
+
+	hits := a.Pick(mgl32.Vec3{0.1, 0.1, 0}, mgl32.Vec3{0, 0, -1})
+	if len(hits) != 2 {
+		t.Fatalf("Pick = %+v, want the two panels in line", hits)
+	}
+	if hits[0].Handle != front || hits[1].Handle != back {
+		t.Errorf("Pick order = %v, %v; want front then back", hits[0].Handle, hits[1].Handle)
+	}
+	if hits[0].Distance != 2 || hits[1].Distance != 5 {
+		t.Errorf("distances = %v, %v; want 2, 5", hits[0].Distance, hits[1].Distance)
+	}
+}
+
+func TestPickFollowsParents(t *testing.T) {
+	a := saveTestApp(t)
+
+	rig := NewEntity("rig")
+	rig.SetPosition(mgl32.Vec3{10, 0, 0})
+	a.World.Spawn(rig)
+	panel := spawnPanel(a, "panel", mgl32.Vec3{0, 0, -4})
+	if err := a.SetParent(panel, rig.Handle()); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits := a.Pick(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 0, -1}); len(hits) != 0 {
+		t.Errorf("ray at the panel's local position hit %+v", hits)
+	}
+	hits := a.Pick(mgl32.Vec3{10, 0, 0}, mgl32.Vec3{0, 0, -1})
+	if len(hits) != 1 || hits[0].Handle != panel {
+		t.Errorf("ray at the panel's world position hit %+v", hits)
+	}
+}
+
+func TestNextPickCyclesOnRepeat(t *testing.T) {
+	first := Handle{Index: 1, Generation: 1}
+	second := Handle{Index: 2, Generation: 1}
+	third := Handle{Index: 3, Generation: 1}
+	hits := []PickHit{{Handle: first}, {Handle: second}, {Handle: third}}
+
+	cases := []struct {
+		current Handle
+		repeat  bool
+		want    Handle
+	}{
+		{NoHandle, false, first},
+		{first, true, second},
+		{second, true, third},
+		{third, true, first},
+		// Somewhere new, the top hit wins even if the selection is under it.
+		{second, false, first},
+		// A repeat whose selection is not under the cursor starts at the top.
+		{Handle{Index: 9, Generation: 1}, true, first},
+	}
+	for _, c := range cases {
+		if got := NextPick(hits, c.current, c.repeat); got != c.want {
+			t.Errorf("NextPick(current %v, repeat %v) = %v, want %v", c.current, c.repeat, got, c.want)
+		}
+	}
+
+	if got := NextPick(nil, first, true); !got.IsZero() {
+		t.Errorf("NextPick with no hits = %v, want no selection", got)
+	}
+}
@@ -0,0 +1,340 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"3d-engine/scene"
+	"3d-engine/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// This file is the engine's half of prefabs; the format and the expansion are
+// in package scene. Loading needs nothing here beyond buildSpec expanding the
+// instances before it builds: the world never contains a prefab, only the
+// entities one expanded to, with the instance's root remembering where it
+// came from. Saving is the interesting direction — the root of an instance is
+// compared against a fresh expansion of its prefab, and only the differences
+// are written.
+
+// cachedPrefabs puts a cache in front of load, so a scene placing fifty lamps
+// reads the lamp's file once. It lives only as long as the caller keeps it:
+// a prefab edited on disk is picked up by the next load, not ignored until
+// restart.
+func cachedPrefabs(load scene.PrefabLoader) scene.PrefabLoader {
+	var mu sync.Mutex
+	cache := map[string]*scene.Prefab{}
+
+	return func(path string) (*scene.Prefab, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if prefab, ok := cache[path]; ok {
+			return prefab, nil
+		}
+		prefab, err := load(path)
+		if err != nil {
+			return nil, err
+		}
+		cache[path] = prefab
+		return prefab, nil
+	}
+}
+
+// describeInstance writes the root of a prefab instance as the reference it
+// was loaded from: the prefab's path, the instance's name and placement, and
+// an override for everything that differs from the prefab as it now stands.
+//
+// An override can change and add but not take away, so an instance that has
+// lost part of the prefab — a child deleted, a component removed, a model
+// swapped — cannot be written this way. It reports false for those and is
+// saved expanded, as an ordinary tree, which keeps everything it is but drops
+// the link to the prefab. The same happens when the prefab file can no longer
+// be read: the world is what the user sees, and the save must not lose it.
+//
+// Callers hold the world lock.
+func (a *App) describeInstance(entity *Entity) (scene.Object, bool, error) {
+	prefab, err := scene.LoadPrefab(entity.prefab)
+	if err != nil {
+		utils.Logger().Printf("Object %q: %v; saving it without its prefab", entity.Name, err)
+		return scene.Object{}, false, nil
+	}
+	tree, err := scene.Expand(prefab.Root, cachedPrefabs(scene.LoadPrefab))
+	if err != nil {
+		utils.Logger().Printf("Object %q: prefab %s: %v; saving it without its prefab", entity.Name, entity.prefab, err)
+		return scene.Object{}, false, nil
+	}
+
+	var overrides []scene.Override
+	ok, err := a.diffInstance(entity, &tree, "", &overrides)
+	if err != nil {
+		return scene.Object{}, false, err
+	}
+	if !ok {
+		utils.Logger().Printf("Object %q no longer has everything prefab %s has; saving it without its prefab",
+			entity.Name, entity.prefab)
+		return scene.Object{}, false, nil
+	}
+
+	return scene.Object{
+		Name:      entity.Name,
+		Prefab:    entity.prefab,
+		Transform: transformSpec(entity.Transform()),
+		Overrides: overrides,
+	}, true, nil
+}
+
+// diffInstance compares one entity of an instance with the prefab object it
+// was expanded from, appending an override for what differs and recursing into
+// the children. It reports false if the difference is one an override cannot
+// describe.
+func (a *App) diffInstance(entity *Entity, node *scene.Object, target string, overrides *[]scene.Override) (bool, error) {
+	live, err := a.describeSelf(entity)
+	if err != nil {
+		return false, err
+	}
+	if live.Model != node.Model {
+		return false, nil
+	}
+
+	override := scene.Override{Target: target}
+
+	// The root's placement is the instance's own, written on the row rather
+	// than as an override.
+	if target != "" && !sameTransform(live.ResolveTransform(), node.ResolveTransform()) {
+		override.Transform = live.Transform
+	}
+
+	switch {
+	case live.Body == nil && node.Body != nil:
+		return false, nil
+	case live.Body != nil && (node.Body == nil || *live.Body != *node.Body):
+		override.Body = live.Body
+	}
+
+	// A prefab object with no material block was built with the default
+	// colour, so that is what the live one is compared with. An instance
+	// reset to the default against a tinted prefab then writes the default
+	// out explicitly, where describeSelf would have left the block off.
+	if live.Model != "" {
+		if colour, prefabColour := materialColour(live.Material), materialColour(node.Material); colour != prefabColour {
+			override.Material = &scene.MaterialSpec{Color: colour}
+		}
+	}
+
+	if len(live.Components) != len(node.Components) {
+		return false, nil
+	}
+	seen := map[string]int{}
+	for i := range live.Components {
+		current, original := &live.Components[i], &node.Components[i]
+		if current.Type != original.Type {
+			return false, nil
+		}
+		index := seen[current.Type]
+		seen[current.Type]++
+
+		// Through the registry first: the prefab file may list only the
+		// properties it sets, and the live component encodes all of them.
+		base, err := a.normalizeProps(original)
+		if err != nil {
+			return false, err
+		}
+		if props, changed := scene.DiffProps(base, current.Props); changed {
+			override.Components = append(override.Components, scene.ComponentOverride{
+				Type:  current.Type,
+				Index: index,
+				Props: props,
+			})
+		}
+	}
+
+	// Children pair up by name, in order. A prefab child with no live
+	// counterpart was deleted or renamed, which no override can say; a live
+	// child with no prefab counterpart was added, which one can.
+	children := entity.Children()
+	used := make([]bool, len(children))
+	var below []scene.Override
+	names := map[string]bool{}
+	for i := range node.Children {
+		want := &node.Children[i]
+
+		// Targets are paths of names, so a name that is ambiguous among its
+		// siblings, or has a slash in it, cannot be pointed at.
+		if names[want.Name] || strings.Contains(want.Name, "/") {
+			return false, nil
+		}
+		names[want.Name] = true
+
+		match := -1
+		for j, child := range children {
+			if !used[j] && child.Name == want.Name {
+				match = j
+				break
+			}
+		}
+		if match < 0 {
+			return false, nil
+		}
+		used[match] = true
+
+		ok, err := a.diffInstance(children[match], want, childTarget(target, want.Name), &below)
+		if err != nil || !ok {
+			return ok, err
+		}
+	}
+	for j, child := range children {
+		if used[j] {
+			continue
+		}
+		row, err := a.describeForSave(child)
+		if err != nil {
+			return false, err
+		}
+		override.Children = append(override.Children, row)
+	}
+
+	if !override.IsEmpty() {
+		*overrides = append(*overrides, override)
+	}
+	*overrides = append(*overrides, below...)
+	return true, nil
+}
+
+// normalizeProps is a component spec's props as a live component of that type
+// would write them: decoded into a fresh one, then encoded back.
+func (a *App) normalizeProps(spec *scene.ComponentSpec) (yaml.Node, error) {
+	component, err := a.Components.New(spec.Type)
+	if err != nil {
+		return yaml.Node{}, err
+	}
+	if spec.HasProps() {
+		if err := spec.Props.Decode(component); err != nil {
+			return yaml.Node{}, fmt.Errorf("component %q props: %w", spec.Type, err)
+		}
+	}
+	props, err := encodeProps(component)
+	if err != nil {
+		return yaml.Node{}, fmt.Errorf("encoding component %q: %w", spec.Type, err)
+	}
+	return props, nil
+}
+
+func childTarget(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+func materialColour(material *scene.MaterialSpec) [3]float32 {
+	if material == nil {
+		return [3]float32(DefaultBaseColor)
+	}
+	return material.Color
+}
+
+// sameTransform compares two placements as rotations, not as the axis-angle
+// numbers that name them: the prefab file's [1, 0, 0, 90] comes back from a
+// live entity as whatever AxisAngleFromQuat makes of it.
+func sameTransform(a, b scene.TransformSpec) bool {
+	const epsilon = 1e-4
+
+	for i := range 3 {
+		if math.Abs(float64(a.Position[i]-b.Position[i])) > epsilon ||
+			math.Abs(float64(a.Scale[i]-b.Scale[i])) > epsilon {
+			return false
+		}
+	}
+	first := QuatFromAxisAngle(a.Rotation)
+	second := QuatFromAxisAngle(b.Rotation)
+	// q and -q are the same rotation.
+	return math.Abs(float64(first.Dot(second))) > 1-epsilon
+}
+
+// SavePrefab writes the entity and everything under it to a prefab file, and
+// makes the entity an instance of it, so the next scene save writes a
+// reference rather than the tree.
+//
+// The prefab's root gets no transform: where an instance stands is the
+// instance's business. An entity that is itself an instance becomes a prefab
+// that is an instance of the first — a variant — with its overrides, so a
+// change to the original still reaches it.
+//
+// Not recorded in the history. The file is written either way, and undoing
+// the link alone would only make the next save write the tree expanded.
+func (a *App) SavePrefab(handle Handle, path string) error {
+	if path == "" {
+		return fmt.Errorf("no prefab path to save to")
+	}
+
+	var row scene.Object
+	var err error
+	found := a.World.Mutate(handle, func(entity *Entity) {
+		row, err = a.describeForSave(entity)
+	})
+	if !found {
+		return fmt.Errorf("object %s not found", handle)
+	}
+	if err != nil {
+		return err
+	}
+	row.Transform = nil
+
+	if err := scene.SavePrefab(path, &scene.Prefab{Root: row}); err != nil {
+		return err
+	}
+
+	a.World.Mutate(handle, func(entity *Entity) {
+		entity.prefab = path
+	})
+	utils.Logger().Printf("Saved prefab to %s", path)
+	return nil
+}
+
+// RevertToPrefab replaces a prefab instance with a fresh copy of its prefab,
+// dropping every override and every child added to it. The name and the
+// placement are the instance's and stay. It returns the new root.
+//
+// The prefab is read and built before anything is removed, so a prefab file
+// that has gone missing or stopped parsing fails without touching the world.
+func (h *History) RevertToPrefab(handle Handle) (Handle, error) {
+	info, ok := h.app.ObjectInfo(handle)
+	if !ok {
+		return NoHandle, fmt.Errorf("object %s not found", handle)
+	}
+	if info.Prefab == "" {
+		return NoHandle, fmt.Errorf("object %q is not a prefab instance", info.Name)
+	}
+
+	row := scene.Object{
+		Name:      info.Name,
+		Prefab:    info.Prefab,
+		Transform: transformSpec(info.Transform),
+	}
+	spec, err := h.app.Scenes.buildSpec(&row)
+	if err != nil {
+		return NoHandle, err
+	}
+	spec.Parent = info.Parent
+
+	var reverted Handle
+	err = h.Transaction("Revert "+info.Name, func() error {
+		if err := h.DespawnTree(handle); err != nil {
+			return err
+		}
+		entity, err := h.SpawnObject(spec)
+		if err != nil {
+			return err
+		}
+		reverted = entity.Handle()
+		return nil
+	})
+	if err != nil {
+		return NoHandle, err
+	}
+	return reverted, nil
+}
@@ -0,0 +1,334 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3d-engine/scene"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The prefab here has no model, for the same reason the save tests' scenes
+// have none: everything else about an instance — names, nesting, component
+// props, overrides — round-trips without a GL context.
+const lampPrefab = `version: 2
+prefab:
+  name: lamp
+  components:
+    - type: PointLight
+      props: {linear: 0.14}
+  children:
+    - name: shade
+      children:
+        - name: bulb
+          transform:
+            position: [0, 1, 0]
+          components:
+            - type: PointLight
+              props: {linear: 0.09, quadratic: 0.032}
+`
+
+// prefabScene writes lampPrefab and a scene placing one instance of it, with
+// the given override block, and loads the scene. It returns the prefab's path.
+func prefabScene(t *testing.T, a *App, overrides string) string {
+	t.Helper()
+
+	directory := t.TempDir()
+	prefab := writeScene(t, directory, "lamp.yml", lampPrefab)
+	path := writeScene(t, directory, "scene.yml", `version: 2
+objects:
+  - name: hall-lamp
+    prefab: `+prefab+`
+    transform:
+      position: [4, 0, -2]
+`+overrides)
+	loadAndPlace(t, a, path)
+	return prefab
+}
+
+func pointLight(t *testing.T, a *App, name string) *PointLight {
+	t.Helper()
+
+	entity := a.World.Find(name)
+	if entity == nil {
+		t.Fatalf("no object named %q", name)
+	}
+	for _, component := range entity.Components() {
+		if light, ok := component.(*PointLight); ok {
+			return light
+		}
+	}
+	t.Fatalf("%q has no PointLight", name)
+	return nil
+}
+
+// snapshotInstance saves the world and returns the one top-level row.
+func snapshotInstance(t *testing.T, a *App) scene.Object {
+	t.Helper()
+
+	snapshot, err := a.SceneSnapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(snapshot.Objects) != 1 {
+		t.Fatalf("snapshot has %d top-level objects, want 1", len(snapshot.Objects))
+	}
+	return snapshot.Objects[0]
+}
+
+func TestLoadExpandsPrefabInstance(t *testing.T) {
+	a := saveTestApp(t)
+	prefab := prefabScene(t, a, `    overrides:
+      - target: shade/bulb
+        components:
+          - type: PointLight
+            props: {quadratic: 0.5}
+`)
+
+	root := a.World.Find("hall-lamp")
+	if root == nil {
+		t.Fatal("the instance root was not spawned under the instance's name")
+	}
+	info, _ := a.ObjectInfo(root.Handle())
+	if info.Prefab != prefab {
+		t.Errorf("instance root remembers prefab %q, want %q", info.Prefab, prefab)
+	}
+	if !sameSpot(info.Transform.Position, mgl32.Vec3{4, 0, -2}) {
+		t.Errorf("instance root at %v", info.Transform.Position)
+	}
+
+	bulb := pointLight(t, a, "bulb")
+	if bulb.Quadratic != 0.5 || bulb.Linear != 0.09 {
+		t.Errorf("bulb light has linear %v, quadratic %v; want the prefab's linear and the override's quadratic",
+			bulb.Linear, bulb.Quadratic)
+	}
+	if info, _ := a.ObjectInfo(a.World.Find("bulb").Handle()); info.Prefab != "" {
+		t.Errorf("a child of an instance claims prefab %q", info.Prefab)
+	}
+}
+
+func TestSaveWritesInstanceAsReference(t *testing.T) {
+	a := saveTestApp(t)
+	prefab := prefabScene(t, a, "")
+
+	// Untouched, the instance saves as nothing but the reference.
+	row := snapshotInstance(t, a)
+	if row.Prefab != prefab || len(row.Children) != 0 || len(row.Components) != 0 {
+		t.Fatalf("untouched instance saved as %+v", row)
+	}
+	if len(row.Overrides) != 0 {
+		t.Errorf("untouched instance has overrides %+v", row.Overrides)
+	}
+
+	pointLight(t, a, "bulb").Quadratic = 0.25
+	if err := a.UpdateTransform(a.World.Find("shade").Handle(), func(transform *Transform) {
+		transform.Position = mgl32.Vec3{0, 2, 0}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	row = snapshotInstance(t, a)
+	if len(row.Overrides) != 2 {
+		t.Fatalf("got %d overrides, want one for shade and one for bulb: %+v", len(row.Overrides), row.Overrides)
+	}
+	shade, bulb := row.Overrides[0], row.Overrides[1]
+	if shade.Target != "shade" || shade.Transform == nil || len(shade.Components) != 0 {
+		t.Errorf("shade override %+v", shade)
+	}
+	if bulb.Target != "shade/bulb" || bulb.Transform != nil || len(bulb.Components) != 1 {
+		t.Fatalf("bulb override %+v", bulb)
+	}
+	props := fieldsOf(t, bulb.Components[0])
+	if len(props) != 1 || props["quadratic"] != "0.25" {
+		t.Errorf("bulb override writes %v, want only the changed quadratic", props)
+	}
+}
+
+func fieldsOf(t *testing.T, override scene.ComponentOverride) map[string]string {
+	t.Helper()
+
+	fields := map[string]string{}
+	for i := 0; i+1 < len(override.Props.Content); i += 2 {
+		fields[override.Props.Content[i].Value] = override.Props.Content[i+1].Value
+	}
+	return fields
+}
+
+// TestSavedInstanceReloads closes the loop: what the save wrote, overrides
+// and added children included, loads back to the same world.
+func TestSavedInstanceReloads(t *testing.T) {
+	a := saveTestApp(t)
+	prefabScene(t, a, "")
+
+	pointLight(t, a, "bulb").Linear = 0.7
+	if _, err := a.SpawnObject(ObjectSpec{
+		Name:       "glow",
+		Transform:  IdentityTransform(),
+		Components: []Component{NewPointLight()},
+		Parent:     a.World.Find("shade").Handle(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	row := snapshotInstance(t, a)
+	if row.Prefab == "" {
+		t.Fatal("an instance with an added child should still save as a reference")
+	}
+	var added []scene.Object
+	for _, override := range row.Overrides {
+		if override.Target == "shade" {
+			added = override.Children
+		}
+	}
+	if len(added) != 1 || added[0].Name != "glow" {
+		t.Fatalf("added children saved as %+v", added)
+	}
+
+	path := filepath.Join(t.TempDir(), "saved.yml")
+	if err := a.SaveScene(path); err != nil {
+		t.Fatal(err)
+	}
+	loadAndPlace(t, a, path)
+
+	if got := pointLight(t, a, "bulb").Linear; got != 0.7 {
+		t.Errorf("bulb linear after reload = %v", got)
+	}
+	glow := a.World.Find("glow")
+	if glow == nil || glow.Parent() == nil || glow.Parent().Name != "shade" {
+		t.Error("the added child did not come back under shade")
+	}
+}
+
+// TestInstanceMissingChildSavesExpanded covers what an override cannot say:
+// with a prefab child deleted, the instance is written out in full rather than
+// as a reference that would bring the child back.
+func TestInstanceMissingChildSavesExpanded(t *testing.T) {
+	a := saveTestApp(t)
+	prefabScene(t, a, "")
+
+	if err := a.DespawnTree(a.World.Find("bulb").Handle()); err != nil {
+		t.Fatal(err)
+	}
+
+	row := snapshotInstance(t, a)
+	if row.Prefab != "" {
+		t.Fatalf("an instance missing a child was saved as a reference to %s", row.Prefab)
+	}
+	if len(row.Components) != 1 || len(row.Children) != 1 || row.Children[0].Name != "shade" {
+		t.Errorf("expanded instance saved as %+v", row)
+	}
+	if len(row.Children[0].Children) != 0 {
+		t.Error("the deleted bulb came back")
+	}
+}
+
+// TestUnreadablePrefabSavesExpanded: the world is what the user sees, so a
+// prefab file deleted since the load must not take the instance with it.
+func TestUnreadablePrefabSavesExpanded(t *testing.T) {
+	a := saveTestApp(t)
+	prefab := prefabScene(t, a, "")
+
+	if err := os.Remove(prefab); err != nil {
+		t.Fatal(err)
+	}
+	row := snapshotInstance(t, a)
+	if row.Prefab != "" || len(row.Children) != 1 {
+		t.Errorf("instance of a missing prefab saved as %+v", row)
+	}
+}
+
+func TestSavePrefabLinksEntity(t *testing.T) {
+	a, h := historyApp(t)
+	rig := spawnLight(t, h, "rig", NoHandle)
+	spawnLight(t, h, "arm", rig)
+	if err := a.UpdateTransform(rig, func(transform *Transform) {
+		transform.Position = mgl32.Vec3{1, 2, 3}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "rig.yml")
+	if err := a.SavePrefab(rig, path); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := scene.LoadPrefab(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Root.Transform != nil {
+		t.Errorf("the prefab root kept the entity's placement %+v", saved.Root.Transform)
+	}
+	if len(saved.Root.Children) != 1 || saved.Root.Children[0].Name != "arm" {
+		t.Errorf("prefab tree %+v", saved.Root)
+	}
+
+	row := snapshotInstance(t, a)
+	if row.Prefab != path || len(row.Overrides) != 0 || len(row.Children) != 0 {
+		t.Errorf("the entity saved as a prefab then saved as %+v", row)
+	}
+	if row.Transform == nil || row.Transform.Position != [3]float32{1, 2, 3} {
+		t.Errorf("the instance lost its placement: %+v", row.Transform)
+	}
+}
+
+func TestRevertToPrefab(t *testing.T) {
+	a, h := historyApp(t)
+	prefabScene(t, a, `    overrides:
+      - target: shade/bulb
+        components:
+          - type: PointLight
+            props: {quadratic: 0.5}
+`)
+	if _, err := a.SpawnObject(ObjectSpec{
+		Name:       "glow",
+		Transform:  IdentityTransform(),
+		Components: []Component{NewPointLight()},
+		Parent:     a.World.Find("shade").Handle(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := h.RevertToPrefab(a.World.Find("hall-lamp").Handle())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, ok := a.ObjectInfo(reverted)
+	if !ok || info.Name != "hall-lamp" || !sameSpot(info.Transform.Position, mgl32.Vec3{4, 0, -2}) {
+		t.Fatalf("reverted root %+v", info)
+	}
+	if got := pointLight(t, a, "bulb").Quadratic; got != 0.032 {
+		t.Errorf("bulb quadratic after revert = %v, want the prefab's", got)
+	}
+	if a.World.Find("glow") != nil {
+		t.Error("the added child survived the revert")
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := pointLight(t, a, "bulb").Quadratic; got != 0.5 {
+		t.Errorf("bulb quadratic after undo = %v, want the override back", got)
+	}
+	if a.World.Find("glow") == nil {
+		t.Error("undo did not bring the added child back")
+	}
+	if root := a.World.Find("hall-lamp"); root == nil || !strings.HasSuffix(root.prefab, "lamp.yml") {
+		t.Error("undo did not bring back the instance's link to its prefab")
+	}
+}
+
+func TestRevertRefusesNonInstance(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+
+	if _, err := h.RevertToPrefab(lamp); err == nil {
+		t.Error("reverting an entity that is no instance should fail")
+	}
+	if _, ok := a.ObjectInfo(lamp); !ok {
+		t.Error("the failed revert removed the entity")
+	}
+}
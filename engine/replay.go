@@ -0,0 +1,399 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"3d-engine/input"
+	"3d-engine/utils"
+)
+
+// A recording is what it takes to play a session's input back and have the
+// engine do the same things again: for every frame, what the devices told
+// Poll, and how long the frame was and how many fixed steps it ran. The frame
+// loop reads the wall clock and a ticker for those last two, so without them
+// the same key presses land in different physics steps and the player falls
+// through the floor on one run and not the next.
+//
+// The file is JSON, a header line and then a line a frame, so a bug report
+// can carry one and a reader can see what was pressed when. It starts from the
+// scene the session loaded; the session is recorded from New to Close, so
+// there is no earlier state to capture.
+//
+// Scene changes and streamed cells are prepared on worker goroutines and
+// spawned under a per-frame budget, so on their own they land a frame or ten
+// earlier or later from one run to the next, and an object the player stood
+// on in the session is not there yet in the replay. Each frame therefore also
+// records the loads that changed the world in it: the scene swapped in, or how
+// far a cell had spawned. A replay holds every load back until its recorded
+// frame, and holds that frame until the load is ready, however long the disk
+// and the GPU take.
+//
+// Input and loads are all it holds. Edits made through the editor's panels or
+// over RPC, and anything a component does at random, are not in it, and a
+// replay of a session that used them will drift from the original. A scene
+// change one of them asked for never starts in the replay, and the frame that
+// recorded it goes ahead without it.
+
+// recordingVersion changes whenever the file stops meaning what it did.
+const recordingVersion = 2
+
+// replayLoadWait is how long a replayed frame waits for a load the recording
+// says landed in it before giving up on it, for a model that has since become
+// slow or impossible to read.
+const replayLoadWait = 30 * time.Second
+
+type recordingHeader struct {
+	Version   int       `json:"version"`
+	Scene     string    `json:"scene"`
+	FixedRate int       `json:"fixedRate"`
+	Recorded  time.Time `json:"recorded"`
+}
+
+type recordedFrame struct {
+	Delta      float32 `json:"delta"`
+	FixedSteps int     `json:"fixedSteps,omitempty"`
+
+	// The input context stack and keyboard suppression Poll ran with. Both
+	// come from the editor, which is not recorded, so they are kept outright.
+	Contexts []string `json:"contexts"`
+	Suppress bool     `json:"suppress,omitempty"`
+
+	Input input.State `json:"input"`
+	// Actions is what Poll made of Input, for noticing a replay that no
+	// longer agrees.
+	Actions map[input.Action]input.ActionState `json:"actions,omitempty"`
+
+	Loads []loadStep `json:"loads,omitempty"`
+}
+
+// loadStep is a load changing the world during a frame: Scene swapped in, or
+// Cell's objects spawned as far as its Spawned'th.
+type loadStep struct {
+	Scene   string `json:"scene,omitempty"`
+	Cell    string `json:"cell,omitempty"`
+	Spawned int    `json:"spawned,omitempty"`
+}
+
+type inputRecorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	source  input.Recorder
+	frame   recordedFrame
+}
+
+// startRecording creates the file at path and writes its header. Each frame
+// is written as it ends, unbuffered, so a session that crashes keeps every
+// frame up to the crash — which is often the one being reported.
+func (a *App) startRecording(path, scene string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create recording: %w", err)
+	}
+
+	recorder := &inputRecorder{file: file, encoder: json.NewEncoder(file)}
+	header := recordingHeader{
+		Version:   recordingVersion,
+		Scene:     scene,
+		FixedRate: fixedUpdateRate,
+		Recorded:  time.Now().UTC(),
+	}
+	if err := recorder.encoder.Encode(header); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write recording: %w", err)
+	}
+
+	a.recorder = recorder
+	utils.Logger().Printf("Recording input to %s", path)
+	return nil
+}
+
+// stopRecording closes the recording. The last frame is already on disk.
+func (a *App) stopRecording() {
+	if a.recorder == nil {
+		return
+	}
+	if err := a.recorder.file.Close(); err != nil {
+		utils.Logger().Println("Closing the recording:", err)
+	}
+	a.recorder = nil
+}
+
+type inputReplay struct {
+	file    *os.File
+	decoder *json.Decoder
+	frame   recordedFrame
+	number  int
+
+	// loads is the frame's recorded load steps not yet taken, keyed by the
+	// step with Spawned zeroed.
+	loads map[loadStep]int
+
+	// diverged is the first frame whose actions did not come out as recorded,
+	// zero while they all have.
+	diverged int
+}
+
+// openReplay reads a recording's header and returns it with the replay
+// positioned at the first frame.
+func openReplay(path string) (*inputReplay, recordingHeader, error) {
+	var header recordingHeader
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, header, fmt.Errorf("could not open recording: %w", err)
+	}
+	replay := &inputReplay{file: file, decoder: json.NewDecoder(file)}
+
+	if err := replay.decoder.Decode(&header); err != nil {
+		file.Close()
+		return nil, header, fmt.Errorf("could not read recording %s: %w", path, err)
+	}
+	if header.Version != recordingVersion {
+		file.Close()
+		return nil, header, fmt.Errorf("recording %s is version %d, this engine plays version %d", path, header.Version, recordingVersion)
+	}
+	// The fixed step is a constant of the build. One recorded at another rate
+	// would run its physics in steps of the wrong length.
+	if header.FixedRate != fixedUpdateRate {
+		file.Close()
+		return nil, header, fmt.Errorf("recording %s steps physics at %d Hz, this engine at %d", path, header.FixedRate, fixedUpdateRate)
+	}
+	return replay, header, nil
+}
+
+// next reads the next frame. It reports false at the end of the recording,
+// and on a frame it cannot read, which ends the replay just the same.
+func (r *inputReplay) next() bool {
+	r.frame = recordedFrame{}
+	if err := r.decoder.Decode(&r.frame); err != nil {
+		if !errors.Is(err, io.EOF) {
+			utils.Logger().Printf("Replay stopped at frame %d: %v", r.number+1, err)
+		}
+		return false
+	}
+	r.number++
+
+	r.loads = map[loadStep]int{}
+	for _, step := range r.frame.Loads {
+		r.loads[loadStep{Scene: step.Scene, Cell: step.Cell}] = step.Spawned
+	}
+	return true
+}
+
+// diverge notes the first frame the replay stopped agreeing with the
+// recording.
+func (r *inputReplay) diverge(format string, args ...any) {
+	if r.diverged != 0 {
+		return
+	}
+	r.diverged = r.number
+	utils.Logger().Printf("Replay diverged at frame %d: %s", r.number, fmt.Sprintf(format, args...))
+}
+
+// startReplay plays the recording in place of the devices from the next
+// frame on.
+func (a *App) startReplay(replay *inputReplay, path string) {
+	a.replay = replay
+	utils.Logger().Printf("Replaying input from %s", path)
+}
+
+// stopReplay hands input back to the devices. What they built up while the
+// replay ran is thrown away, or the first live frame would turn the camera by
+// all the mouse movement of the replay at once.
+func (a *App) stopReplay() {
+	if a.replay == nil {
+		return
+	}
+	a.replay.file.Close()
+	if a.replay.diverged != 0 {
+		utils.Logger().Printf("Replay finished after %d frames; it diverged from the recording at frame %d", a.replay.number, a.replay.diverged)
+	} else {
+		utils.Logger().Printf("Replay finished after %d frames", a.replay.number)
+	}
+	a.replay = nil
+
+	if a.devices != nil {
+		a.devices.MouseDelta()
+		a.devices.ScrollDelta()
+		a.devices.ResetCursor()
+	}
+}
+
+// beginFrame works out how long the last frame took: from now, the wall
+// clock, or from the recording while one plays. The recording's delta stands
+// even when the replay runs faster or slower than the original did, so every
+// frame advances the game exactly as far as it did then.
+func (a *App) beginFrame(now float32) {
+	a.deltaTime = now - a.lastFrame
+	a.lastFrame = now
+
+	if a.replay != nil {
+		if a.replay.next() {
+			a.deltaTime = a.replay.frame.Delta
+		} else {
+			a.stopReplay()
+		}
+	}
+
+	a.elapsed += float64(a.deltaTime)
+	if a.recorder != nil {
+		a.recorder.frame = recordedFrame{Delta: a.deltaTime}
+	}
+}
+
+// fixedSteps is how many fixed updates this frame runs: one when the ticker
+// fired, or as many as the recording says.
+func (a *App) fixedSteps(ticked bool) int {
+	steps := 0
+	if ticked {
+		steps = 1
+	}
+	if a.replay != nil {
+		steps = a.replay.frame.FixedSteps
+	}
+	if a.recorder != nil {
+		a.recorder.frame.FixedSteps = steps
+	}
+	return steps
+}
+
+// pollInput runs Poll on the devices, or on the recorded frame while a replay
+// plays, and records what it was given while a recording is made.
+func (a *App) pollInput(source input.KeySource, suppress bool) {
+	if a.replay != nil {
+		frame := a.replay.frame
+		a.Input.SetStack(frame.Contexts)
+		source, suppress = frame.Input, frame.Suppress
+	}
+
+	if a.recorder != nil {
+		a.recorder.source.Source = source
+		source = &a.recorder.source
+	}
+
+	a.Input.Poll(source, suppress)
+
+	if a.recorder != nil {
+		a.recorder.frame.Contexts = a.Input.Stack()
+		a.recorder.frame.Suppress = suppress
+		a.recorder.frame.Input = a.recorder.source.Take()
+		a.recorder.frame.Actions = a.Input.Snapshot()
+	}
+
+	if replay := a.replay; replay != nil && !maps.Equal(replay.frame.Actions, a.Input.Snapshot()) {
+		replay.diverge("the recorded input no longer produces the recorded actions (%s)",
+			divergedActions(replay.frame.Actions, a.Input.Snapshot()))
+	}
+}
+
+// loadDue reports whether a load may change the world now, and limit, how
+// many of a cell's objects it may have spawned by the end of it; -1 leaves
+// that to the spawn budget. Outside a replay every load is due. In one, a load
+// is due only in a frame that recorded a step for it, and only the once.
+func (a *App) loadDue(step loadStep) (limit int, due bool) {
+	if a.replay == nil {
+		return -1, true
+	}
+	limit, due = a.replay.loads[step]
+	delete(a.replay.loads, step)
+	return limit, due
+}
+
+// recordLoad adds a load's step to the frame being recorded.
+func (a *App) recordLoad(step loadStep) {
+	if a.recorder != nil {
+		a.recorder.frame.Loads = append(a.recorder.frame.Loads, step)
+	}
+}
+
+// awaitLoads holds a replayed frame until each load it recorded has taken its
+// step, running the deferred commands that finish them as they come due. A
+// load that was never started, because whatever asked for it is not in the
+// recording, or that has failed, is given up on at once; one that is merely
+// slow, after replayLoadWait. Called from Run, after drainCommands.
+func (a *App) awaitLoads() {
+	replay := a.replay
+	if replay == nil {
+		return
+	}
+
+	deadline := time.Now().Add(replayLoadWait)
+	for len(replay.loads) > 0 {
+		for step := range replay.loads {
+			if !a.Scenes.loadRunning(step) {
+				delete(replay.loads, step)
+				replay.diverge("the recorded load of %s%s never ran", step.Scene, step.Cell)
+			}
+		}
+		if len(replay.loads) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			for step := range replay.loads {
+				replay.diverge("the recorded load of %s%s took longer than %v", step.Scene, step.Cell, replayLoadWait)
+			}
+			clear(replay.loads)
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+		a.drainCommands()
+	}
+}
+
+// loadRunning reports whether the load a step belongs to is on its way, and
+// so will take the step sooner or later. Frame loop only.
+func (sm *SceneManager) loadRunning(step loadStep) bool {
+	if step.Cell != "" {
+		if sm.streamer == nil {
+			return false
+		}
+		for i := range sm.streamer.cells {
+			cell := &sm.streamer.cells[i]
+			if cell.path == step.Cell && cell.state == CellLoading {
+				return !cell.load.stopped()
+			}
+		}
+		return false
+	}
+
+	sm.mu.Lock()
+	load := sm.loading
+	sm.mu.Unlock()
+	return load != nil && load.path == step.Scene && load.snapshot().Active() && !load.cancelled.Load()
+}
+
+// endFrame writes the frame to the recording.
+func (a *App) endFrame() {
+	if a.recorder == nil {
+		return
+	}
+	if err := a.recorder.encoder.Encode(a.recorder.frame); err != nil {
+		utils.Logger().Println("Recording stopped:", err)
+		a.stopRecording()
+	}
+}
+
+// divergedActions names the actions that came out differently, for the log.
+func divergedActions(recorded, replayed map[input.Action]input.ActionState) string {
+	var names []string
+	for action := range recorded {
+		if replayed[action] != recorded[action] {
+			names = append(names, string(action))
+		}
+	}
+	for action := range replayed {
+		if _, ok := recorded[action]; !ok {
+			names = append(names, string(action))
+		}
+	}
+	slices.Sort(names)
+	return fmt.Sprint(names)
+}
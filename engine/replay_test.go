@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3d-engine/input"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// replayTestApp is an App with the engine's bindings and nothing that needs
+// a window.
+func replayTestApp(t *testing.T) *App {
+	t.Helper()
+
+	a := saveTestApp(t)
+	a.Input = input.NewMap()
+	defaultBindings(a.Input)
+	a.Camera.CameraSpeed = 2
+	a.Camera.Look(0, 0)
+	return a
+}
+
+// runRecordedFrame is one pass of Run's loop, as far as timing and input go.
+func runRecordedFrame(a *App, now float32, ticked bool, devices input.KeySource) int {
+	a.beginFrame(now)
+	a.pollInput(devices, false)
+	a.handleLook()
+	a.handleMovement()
+	steps := a.fixedSteps(ticked)
+	a.endFrame()
+	return steps
+}
+
+// TestReplayRepeatsTheSession records a few frames of walking and looking,
+// then plays them into a second App whose own clock and devices say
+// something else entirely: it ends up exactly where the first did.
+func TestReplayRepeatsTheSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	original := replayTestApp(t)
+	if err := original.startRecording(path, "scenes/lion.yml"); err != nil {
+		t.Fatal(err)
+	}
+	walking := input.State{Keys: map[glfw.Key]bool{glfw.KeyW: true}, Mouse: [2]float64{40, -10}}
+	var steps []int
+	for i, now := range []float32{0.016, 0.05, 0.051, 0.2} {
+		steps = append(steps, runRecordedFrame(original, now, i%2 == 1, walking))
+	}
+	original.stopRecording()
+
+	replay, header, err := openReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Scene != "scenes/lion.yml" {
+		t.Errorf("header %+v", header)
+	}
+
+	rerun := replayTestApp(t)
+	rerun.startReplay(replay, path)
+	idle := input.State{Keys: map[glfw.Key]bool{glfw.KeyS: true}}
+	for i := range steps {
+		if got := runRecordedFrame(rerun, float32(i)*7, false, idle); got != steps[i] {
+			t.Errorf("frame %d ran %d fixed steps, recorded %d", i+1, got, steps[i])
+		}
+	}
+
+	if rerun.Camera.CameraPos != original.Camera.CameraPos || rerun.Camera.Yaw != original.Camera.Yaw || rerun.Camera.Pitch != original.Camera.Pitch {
+		t.Errorf("replay ended at %v yaw %g pitch %g, the session at %v yaw %g pitch %g",
+			rerun.Camera.CameraPos, rerun.Camera.Yaw, rerun.Camera.Pitch,
+			original.Camera.CameraPos, original.Camera.Yaw, original.Camera.Pitch)
+	}
+	if rerun.elapsed != original.elapsed {
+		t.Errorf("game time %g, recorded %g", rerun.elapsed, original.elapsed)
+	}
+	if rerun.replay == nil || rerun.replay.diverged != 0 {
+		t.Fatalf("replay state %+v", rerun.replay)
+	}
+
+	// Past the last frame the devices are back in charge.
+	runRecordedFrame(rerun, 100, false, idle)
+	if rerun.replay != nil || !rerun.Input.IsDown(ActionMoveBack) {
+		t.Error("the replay did not hand input back at its end")
+	}
+}
+
+// TestReplayNoticesDivergence: with move_forward rebound since the
+// recording, the same keys no longer walk forward, and the replay says so.
+func TestReplayNoticesDivergence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	original := replayTestApp(t)
+	if err := original.startRecording(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	runRecordedFrame(original, 0.1, false, input.State{})
+	runRecordedFrame(original, 0.2, false, input.State{Keys: map[glfw.Key]bool{glfw.KeyW: true}})
+	original.stopRecording()
+
+	replay, _, err := openReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rerun := replayTestApp(t)
+	rerun.Input.Rebind(ActionMoveForward, glfw.KeyUp)
+	rerun.startReplay(replay, path)
+	runRecordedFrame(rerun, 0, false, input.State{})
+	runRecordedFrame(rerun, 0, false, input.State{})
+	if rerun.replay.diverged != 2 {
+		t.Errorf("diverged at frame %d, want 2", rerun.replay.diverged)
+	}
+}
+
+// streamedFrame is one pass of Run's loop, as far as timing and streaming go.
+func streamedFrame(a *App, now float32) {
+	a.beginFrame(now)
+	a.drainCommands()
+	a.awaitLoads()
+	a.Scenes.updateStreaming()
+	a.endFrame()
+}
+
+// TestReplayStreamsOnTheRecordedFrames: a cell that took a slow session many
+// frames to spawn, two objects a frame, spawns on exactly the same frames in a
+// replay that runs flat out with no budget to speak of.
+func TestReplayStreamsOnTheRecordedFrames(t *testing.T) {
+	path, _, _ := streamedWorld(t, 5)
+	recording := filepath.Join(t.TempDir(), "session.jsonl")
+
+	original := streamingApp(t, 2)
+	loadAndPlace(t, original, path)
+	original.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+	if err := original.startRecording(recording, path); err != nil {
+		t.Fatal(err)
+	}
+	var counts []int
+	deadline := time.Now().Add(5 * time.Second)
+	for len(counts) < 3 || original.Scenes.Cells()[0].State == CellLoading {
+		if time.Now().After(deadline) {
+			t.Fatal("the cell did not finish loading")
+		}
+		streamedFrame(original, float32(len(counts))*0.02)
+		counts = append(counts, original.World.Len())
+		time.Sleep(2 * time.Millisecond)
+	}
+	original.stopRecording()
+
+	replay, _, err := openReplay(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rerun := streamingApp(t, 64)
+	loadAndPlace(t, rerun, path)
+	rerun.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+	rerun.startReplay(replay, recording)
+	for i, want := range counts {
+		streamedFrame(rerun, 0)
+		if got := rerun.World.Len(); got != want {
+			t.Errorf("frame %d holds %d entities, the session %d", i+1, got, want)
+		}
+	}
+	if rerun.replay == nil || rerun.replay.diverged != 0 {
+		t.Fatalf("replay state %+v", rerun.replay)
+	}
+}
+
+func TestOpenReplayRejectsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"future.jsonl":  `{"version": 99, "fixedRate": 50}`,
+		"rate.jsonl":    `{"version": 2, "fixedRate": 60}`,
+		"garbage.jsonl": `not a recording`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := openReplay(path); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The RPC server can load any scene and delete anything, so on a machine it
+// shares it needs to know who is asking. Three things in RPCConfig say so:
+//
+//   - TLS, so a token cannot be read off the wire.
+//   - Bearer tokens, checked by an interceptor before a stream reaches the
+//     engine, each granting a role.
+//   - A Unix socket in place of the TCP port, which the file system keeps
+//     other users out of without any of the above.
+//
+// A role is attached to the stream's context by the interceptor, and each
+// request on the stream is checked against it; see permitted.
+
+// rpcRole is what a client may do.
+type rpcRole int
+
+const (
+	// roleReadWrite may send any operation.
+	roleReadWrite rpcRole = iota
+	// roleReadOnly may look at the world and follow its changes, but every
+	// operation that would change it, or a file, is refused.
+	roleReadOnly
+)
+
+func (r rpcRole) String() string {
+	if r == roleReadOnly {
+		return "readOnly"
+	}
+	return "readWrite"
+}
+
+func parseRole(name string) (rpcRole, error) {
+	switch name {
+	case "", "readWrite":
+		return roleReadWrite, nil
+	case "readOnly":
+		return roleReadOnly, nil
+	}
+	return 0, fmt.Errorf("unknown rpc role %q: want readWrite or readOnly", name)
+}
+
+type roleKey struct{}
+
+// roleOf is the role the interceptor gave the stream. A context it never saw
+// gets the read-only one, so a server built without it fails closed.
+func roleOf(ctx context.Context) rpcRole {
+	role, ok := ctx.Value(roleKey{}).(rpcRole)
+	if !ok {
+		return roleReadOnly
+	}
+	return role
+}
+
+// rpcAuth checks a client's token and decides its role.
+type rpcAuth struct {
+	tokens   []rpcToken
+	readOnly bool
+}
+
+type rpcToken struct {
+	token []byte
+	role  rpcRole
+}
+
+func newRPCAuth(config utils.RPCConfig) (*rpcAuth, error) {
+	auth := &rpcAuth{readOnly: config.ReadOnly}
+	for i, token := range config.Tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("rpc.tokens[%d] is empty", i)
+		}
+		role, err := parseRole(token.Role)
+		if err != nil {
+			return nil, fmt.Errorf("rpc.tokens[%d]: %w", i, err)
+		}
+		auth.tokens = append(auth.tokens, rpcToken{token: []byte(token.Token), role: role})
+	}
+	return auth, nil
+}
+
+// authenticate finds the role for a call's metadata. Every configured token is
+// compared, in constant time, so how long a refusal takes says nothing about
+// how close the guess was.
+func (auth *rpcAuth) authenticate(ctx context.Context) (rpcRole, error) {
+	role := roleReadWrite
+	if len(auth.tokens) > 0 {
+		presented, err := bearerToken(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		matched := false
+		for _, token := range auth.tokens {
+			if subtle.ConstantTimeCompare(presented, token.token) == 1 {
+				matched, role = true, token.role
+			}
+		}
+		if !matched {
+			return 0, status.Error(codes.Unauthenticated, "the bearer token is not valid")
+		}
+	}
+
+	if auth.readOnly {
+		role = roleReadOnly
+	}
+	return role, nil
+}
+
+func bearerToken(ctx context.Context) ([]byte, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "an authorization: Bearer <token> header is required")
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "the authorization header must be Bearer <token>")
+	}
+	return []byte(token), nil
+}
+
+// streamInterceptor authenticates a stream once, when it opens, and hands the
+// role down in its context.
+func (auth *rpcAuth) streamInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	role, err := auth.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &roleStream{ServerStream: stream, ctx: context.WithValue(stream.Context(), roleKey{}, role)})
+}
+
+// unaryInterceptor is the same check for unary calls. Engine has none yet;
+// this is so one added later is not served to anybody who asks.
+func (auth *rpcAuth) unaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	role, err := auth.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, roleKey{}, role), req)
+}
+
+// roleStream is a ServerStream whose context carries the role.
+type roleStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *roleStream) Context() context.Context {
+	return s.ctx
+}
+
+// permitted refuses a request the role may not send.
+func permitted(role rpcRole, req *egrpc.EngineRequest) error {
+	if role == roleReadOnly && mutates(req) {
+		return status.Errorf(codes.PermissionDenied, "%s changes the engine, and this client is read-only", req.GetOperation())
+	}
+	return nil
+}
+
+// mutates lists what does not change anything, rather than what does, so an
+// operation added later is refused to read-only clients until it is added
+// here.
+func mutates(req *egrpc.EngineRequest) bool {
+	switch req.GetOperation() {
+	case egrpc.Operation_OPERATION_GET_OBJECTS,
+		egrpc.Operation_OPERATION_GET_OBJECT,
+		egrpc.Operation_OPERATION_GET_SCENE_MODES,
+		egrpc.Operation_OPERATION_SUBSCRIBE,
+		egrpc.Operation_OPERATION_UNSUBSCRIBE,
+		egrpc.Operation_OPERATION_GET_COMPONENT_TYPES,
+		egrpc.Operation_OPERATION_GET_COMPONENTS,
+		egrpc.Operation_OPERATION_GET_SCENE,
+		egrpc.Operation_OPERATION_GET_CAMERA,
+		egrpc.Operation_OPERATION_STREAM_FRAMES,
+		egrpc.Operation_OPERATION_STOP_FRAMES:
+		return false
+	case egrpc.Operation_OPERATION_BATCH:
+		for _, item := range req.GetBatch().GetRequests() {
+			if mutates(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// newRPCServer builds the gRPC server RPCConfig describes, with the engine
+// registered on it.
+func newRPCServer(a *App, config utils.RPCConfig) (*grpc.Server, error) {
+	auth, err := newRPCAuth(config)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(auth.streamInterceptor),
+		grpc.ChainUnaryInterceptor(auth.unaryInterceptor),
+	}
+	if config.TLS.CertFile != "" || config.TLS.KeyFile != "" {
+		if config.TLS.CertFile == "" || config.TLS.KeyFile == "" {
+			return nil, fmt.Errorf("rpc.tls needs both certFile and keyFile")
+		}
+		creds, err := credentials.NewServerTLSFromFile(config.TLS.CertFile, config.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the rpc certificate: %w", err)
+		}
+		options = append(options, grpc.Creds(creds))
+	} else if len(config.Tokens) > 0 && config.Socket == "" {
+		utils.Logger().Println("rpc: bearer tokens are sent in the clear without rpc.tls")
+	}
+
+	server := grpc.NewServer(options...)
+	egrpc.RegisterEngineServer(server, &engineServer{app: a})
+	return server, nil
+}
+
+// listenRPC opens the socket if one is configured, otherwise the TCP address.
+// The socket is its owner's alone; see listenSocket.
+//
+// A socket file left behind by an engine that did not get to close it would
+// make the listen fail, so one is removed first — but only a socket: a path
+// that names anything else is a mistake in the config, and not the engine's
+// to delete.
+func listenRPC(address, socket string) (net.Listener, error) {
+	if socket == "" {
+		return net.Listen("tcp", address)
+	}
+
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+	return listenSocket(socket)
+}
@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serveRPC serves a on an in-memory listener, as config describes.
+func serveRPC(t *testing.T, a *App, config utils.RPCConfig) *bufconn.Listener {
+	t.Helper()
+
+	server, err := newRPCServer(a, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener
+}
+
+// dialRPC opens a Stream to listener, presenting token if there is one, over
+// creds, or in plaintext if they are nil.
+func dialRPC(t *testing.T, listener *bufconn.Listener, token string, creds credentials.TransportCredentials) egrpc.Engine_StreamClient {
+	t.Helper()
+
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return openOn(t, conn, token)
+}
+
+func openOn(t *testing.T, conn *grpc.ClientConn, token string) egrpc.Engine_StreamClient {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	stream, err := egrpc.NewEngineClient(conn).Stream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// tryRequest sends one request and returns the reply, or the error that ended
+// the stream instead.
+func tryRequest(stream egrpc.Engine_StreamClient, request *egrpc.EngineRequest) (*egrpc.EngineResponse, error) {
+	if err := stream.Send(request); err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+var getObjects = &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_GET_OBJECTS}
+
+// TestRPCRoles: without a valid token the stream is refused outright; with
+// one, a read-only client may look but every change it asks for is refused,
+// and a read-write client may do either.
+func TestRPCRoles(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	runFrames(t, a)
+	listener := serveRPC(t, a, utils.RPCConfig{Tokens: []utils.RPCToken{
+		{Token: "editor-token"},
+		{Token: "viewer-token", Role: "readOnly"},
+	}})
+
+	for _, token := range []string{"", "guess"} {
+		if reply, err := tryRequest(dialRPC(t, listener, token, nil), getObjects); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("token %q: got %v, %v; want Unauthenticated", token, reply, err)
+		}
+	}
+
+	viewer := dialRPC(t, listener, "viewer-token", nil)
+	if reply, err := tryRequest(viewer, getObjects); err != nil || len(reply.GetObjects().GetObjects()) != 1 {
+		t.Fatalf("read-only GET_OBJECTS: %v, %v", reply, err)
+	}
+	for _, request := range []*egrpc.EngineRequest{
+		moveRequest(lamp, 3),
+		batchRequest("", getObjects, moveRequest(lamp, 3)),
+		sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, filepath.Join(t.TempDir(), "saved.yml")),
+		{Operation: egrpc.Operation_OPERATION_UNDO},
+	} {
+		reply, err := tryRequest(viewer, request)
+		if err != nil {
+			t.Fatalf("read-only %s ended the stream: %v", request.GetOperation(), err)
+		}
+		if reply.GetSuccess() || !strings.Contains(reply.GetError(), "read-only") {
+			t.Errorf("read-only %s: %v", request.GetOperation(), reply)
+		}
+	}
+	if info, _ := a.ObjectInfo(lamp); info.Transform.Position.X() != 0 {
+		t.Errorf("a read-only client moved the lamp to %v", info.Transform.Position)
+	}
+	if reply, err := tryRequest(viewer, batchRequest("", getObjects)); err != nil || !reply.GetSuccess() {
+		t.Errorf("read-only batch of reads: %v, %v", reply, err)
+	}
+
+	editor := dialRPC(t, listener, "editor-token", nil)
+	if reply, err := tryRequest(editor, moveRequest(lamp, 3)); err != nil || !reply.GetSuccess() {
+		t.Fatalf("read-write MOVE_OBJECT: %v, %v", reply, err)
+	}
+	if info, _ := a.ObjectInfo(lamp); info.Transform.Position.X() != 3 {
+		t.Errorf("lamp at %v after a read-write move", info.Transform.Position)
+	}
+}
+
+func TestRPCReadOnlyServer(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	listener := serveRPC(t, a, utils.RPCConfig{ReadOnly: true})
+
+	stream := dialRPC(t, listener, "", nil)
+	if reply, err := tryRequest(stream, getObjects); err != nil || !reply.GetSuccess() {
+		t.Fatalf("GET_OBJECTS: %v, %v", reply, err)
+	}
+	reply, err := tryRequest(stream, &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_REMOVE_OBJECT,
+		Body:      &egrpc.EngineRequest_Object{Object: &egrpc.Object{Id: lamp.Encode()}},
+	})
+	if err != nil || reply.GetSuccess() {
+		t.Errorf("REMOVE_OBJECT on a read-only server: %v, %v", reply, err)
+	}
+	if a.World.Len() != 1 {
+		t.Error("the lamp was removed")
+	}
+}
+
+// TestRPCTLS: the server speaks TLS with the configured certificate, and only
+// TLS.
+func TestRPCTLS(t *testing.T) {
+	a := saveTestApp(t)
+	certFile, keyFile, pool := selfSignedCertificate(t)
+	listener := serveRPC(t, a, utils.RPCConfig{TLS: utils.RPCTLSConfig{CertFile: certFile, KeyFile: keyFile}})
+
+	secure := dialRPC(t, listener, "", credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"}))
+	if reply, err := tryRequest(secure, getObjects); err != nil || !reply.GetSuccess() {
+		t.Fatalf("over TLS: %v, %v", reply, err)
+	}
+
+	plain, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if stream, err := egrpc.NewEngineClient(plain).Stream(context.Background()); err == nil {
+		if reply, err := tryRequest(stream, getObjects); err == nil {
+			t.Errorf("a plaintext client got %v", reply)
+		}
+	}
+
+	if _, err := newRPCServer(a, utils.RPCConfig{TLS: utils.RPCTLSConfig{CertFile: certFile}}); err == nil {
+		t.Error("a certificate without its key was accepted")
+	}
+}
+
+func TestRPCUnixSocket(t *testing.T) {
+	a := saveTestApp(t)
+	directory := t.TempDir()
+	socket := filepath.Join(directory, "engine.sock")
+
+	// What an engine that did not close its listener leaves behind.
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenRPC("", socket)
+	if err != nil {
+		t.Fatalf("over a stale socket: %v", err)
+	}
+	server, err := newRPCServer(a, utils.RPCConfig{Socket: socket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode %v, %v; want only its owner let in", info.Mode(), err)
+	}
+
+	conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if reply, err := tryRequest(openOn(t, conn, ""), getObjects); err != nil || !reply.GetSuccess() {
+		t.Errorf("over the socket: %v, %v", reply, err)
+	}
+
+	notSocket := writeScene(t, directory, "scene.yml", hierarchyScene)
+	if _, err := listenRPC("", notSocket); err == nil {
+		t.Error("listening replaced a file that was not a socket")
+	}
+	if _, err := os.Stat(notSocket); err != nil {
+		t.Errorf("the file is gone: %v", err)
+	}
+}
+
+// selfSignedCertificate writes a certificate for localhost and its key, and
+// returns their paths and a pool trusting the certificate.
+func selfSignedCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	certFile := filepath.Join(directory, "cert.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return certFile, keyFile, pool
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+
+	egrpc "3d-engine/grpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batchable is what a BATCH may hold: requests that read the world or edit it
+// through the History, which is what lets the batch take them back. A scene
+// load is queued for a later frame and cannot be taken back, nor can a file
+// once it is saved; undo and redo would step the history from inside a
+// transaction on it; the camera is not in the history at all; subscriptions
+// and frame streams belong to the stream, not to a frame.
+func batchable(op egrpc.Operation) bool {
+	switch op {
+	case egrpc.Operation_OPERATION_LOAD_SCENE,
+		egrpc.Operation_OPERATION_LOAD_SCENE_MODE,
+		egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT,
+		egrpc.Operation_OPERATION_SAVE_SCENE,
+		egrpc.Operation_OPERATION_UNDO,
+		egrpc.Operation_OPERATION_REDO,
+		egrpc.Operation_OPERATION_SUBSCRIBE,
+		egrpc.Operation_OPERATION_UNSUBSCRIBE,
+		egrpc.Operation_OPERATION_SET_CAMERA,
+		egrpc.Operation_OPERATION_RESET_CAMERA,
+		egrpc.Operation_OPERATION_STREAM_FRAMES,
+		egrpc.Operation_OPERATION_STOP_FRAMES,
+		egrpc.Operation_OPERATION_BATCH,
+		egrpc.Operation_OPERATION_UNSPECIFIED:
+		return false
+	}
+	return true
+}
+
+// runBatch applies a batch in one App.Do, inside one History transaction.
+//
+// One Do means one frame: nothing renders, and no subscriber's batch of
+// changes is cut, between the first request and the last, so a client never
+// sees a layout half-applied. One transaction means one undo step, and is what
+// rolls the batch back if a request fails.
+//
+// The transaction is the batch's own even when the editor has one open for a
+// drag (see History.Separately): a batch that succeeds does not undo with the
+// drag, and one that fails rolls back only itself.
+//
+// The requests go through the same handlers as when sent alone, on a server
+// whose edits run where they are rather than queueing on the frame loop —
+// which they are already on, and which would wait for them forever.
+func (eg *engineServer) runBatch(batch *egrpc.Batch) (*egrpc.BatchResults, error) {
+	requests := batch.GetRequests()
+	if len(requests) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "batch.requests must not be empty")
+	}
+	for i, request := range requests {
+		if !batchable(request.GetOperation()) {
+			return nil, status.Errorf(codes.InvalidArgument, "request %d: %s cannot be batched", i, request.GetOperation())
+		}
+	}
+
+	label := batch.GetLabel()
+	if label == "" {
+		label = fmt.Sprintf("Batch of %d", len(requests))
+	}
+
+	inFrame := &engineServer{app: eg.app, inFrame: true}
+	results := &egrpc.BatchResults{Responses: make([]*egrpc.EngineResponse, len(requests)), Failed: -1}
+
+	err := eg.app.Do(func(app *App) error {
+		return app.History.Separately(func() error {
+			return app.History.Transaction(label, func() error {
+				for i, request := range requests {
+					resp := inFrame.handleRequest(request)
+					results.Responses[i] = resp
+					if !resp.GetSuccess() {
+						results.Failed = int32(i)
+						return status.Errorf(codes.Aborted, "request %d: %s", i, resp.GetError())
+					}
+				}
+				return nil
+			})
+		})
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	// What the other requests returned describes a world that was rolled
+	// back — ids of objects that no longer exist — so it is replaced.
+	failed := int(results.Failed)
+	for i, request := range requests {
+		switch {
+		case failed < 0 || i > failed:
+			results.Responses[i] = errorResponse(request.GetOperation(),
+				status.Error(codes.Aborted, "not run: the batch failed"))
+		case i < failed:
+			results.Responses[i] = errorResponse(request.GetOperation(),
+				status.Errorf(codes.Aborted, "rolled back: request %d failed", failed))
+		}
+	}
+	return results, err
+}
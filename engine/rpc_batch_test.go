@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	egrpc "3d-engine/grpc"
+)
+
+func moveRequest(handle Handle, x float32) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_MOVE_OBJECT,
+		Body: &egrpc.EngineRequest_Object{Object: &egrpc.Object{
+			Id:       handle.Encode(),
+			Location: &egrpc.Location{Position: &egrpc.Vector3{X: x}},
+		}},
+	}
+}
+
+func batchRequest(label string, requests ...*egrpc.EngineRequest) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_BATCH,
+		Body:      &egrpc.EngineRequest_Batch{Batch: &egrpc.Batch{Requests: requests, Label: label}},
+	}
+}
+
+// TestStreamBatch: a batch applies as one undo step, and a failing request in
+// it leaves the world and the history as they were, with a result per request
+// saying what happened to it.
+func TestStreamBatch(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	shade := spawnLight(t, h, "shade", NoHandle)
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	positionX := func(handle Handle) float32 {
+		info, ok := a.ObjectInfo(handle)
+		if !ok {
+			t.Fatalf("object %s is gone", handle)
+		}
+		return info.Transform.Position.X()
+	}
+
+	reply := roundTrip(t, stream, batchRequest("Arrange",
+		moveRequest(lamp, 1),
+		moveRequest(shade, 2),
+		&egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_SET_PARENT,
+			Body:      &egrpc.EngineRequest_Object{Object: &egrpc.Object{Id: shade.Encode(), ParentId: lamp.Encode()}},
+		},
+	))
+	results := reply.GetBatch()
+	if !reply.GetSuccess() || len(results.GetResponses()) != 3 || results.GetFailed() != -1 {
+		t.Fatalf("batch: %v", reply)
+	}
+	if info, _ := a.ObjectInfo(shade); positionX(lamp) != 1 || positionX(shade) != 2 || info.Parent != lamp {
+		t.Errorf("batch applied as lamp x=%v, shade %+v", positionX(lamp), info)
+	}
+
+	var label string
+	if err := a.Do(func(app *App) error { label = app.History.UndoLabel(); return app.History.Undo() }); err != nil {
+		t.Fatal(err)
+	}
+	if label != "Arrange" || positionX(lamp) != 0 || positionX(shade) != 0 {
+		t.Errorf("undoing %q left lamp x=%v, shade x=%v", label, positionX(lamp), positionX(shade))
+	}
+
+	missing := Handle{Index: 99, Generation: 1}
+	reply = roundTrip(t, stream, batchRequest("", moveRequest(lamp, 5), moveRequest(missing, 5), moveRequest(shade, 5)))
+	results = reply.GetBatch()
+	if reply.GetSuccess() || results.GetFailed() != 1 || len(results.GetResponses()) != 3 {
+		t.Fatalf("failing batch: %v", reply)
+	}
+	for i, want := range []string{"rolled back", "not found", "not run"} {
+		if got := results.GetResponses()[i]; got.GetSuccess() || !strings.Contains(got.GetError(), want) {
+			t.Errorf("request %d: %v, want an error saying %q", i, got, want)
+		}
+	}
+	if positionX(lamp) != 0 || positionX(shade) != 0 {
+		t.Errorf("failed batch left lamp x=%v, shade x=%v", positionX(lamp), positionX(shade))
+	}
+	if err := a.Do(func(app *App) error { label = app.History.UndoLabel(); return nil }); err != nil || label != "Spawn shade" {
+		t.Errorf("failed batch left an undo step %q", label)
+	}
+
+	reply = roundTrip(t, stream, batchRequest("", moveRequest(lamp, 7),
+		&egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_UNDO}))
+	if reply.GetSuccess() || reply.GetBatch() != nil || positionX(lamp) != 0 {
+		t.Errorf("a batch holding UNDO: %v", reply)
+	}
+}
+
+// TestBatchDuringDrag: a batch sent while the editor holds a transaction open
+// rolls back only itself when it fails, and is its own step when it succeeds;
+// either way the drag survives and commits as one step.
+func TestBatchDuringDrag(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	shade := spawnLight(t, h, "shade", NoHandle)
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	drag := func(x float32) {
+		t.Helper()
+		if err := a.Do(func(*App) error {
+			return h.UpdateTransform(lamp, func(tr *Transform) { tr.Position[0] = x })
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.Do(func(*App) error { h.Begin("Move"); return nil })
+	drag(1)
+	missing := Handle{Index: 99, Generation: 1}
+	if reply := roundTrip(t, stream, batchRequest("", moveRequest(shade, 5), moveRequest(missing, 5))); reply.GetSuccess() {
+		t.Fatalf("batch naming a missing object: %v", reply)
+	}
+	if position(t, a, lamp).X() != 1 || position(t, a, shade).X() != 0 {
+		t.Errorf("failed batch left lamp at %v and shade at %v", position(t, a, lamp), position(t, a, shade))
+	}
+	drag(2)
+	if reply := roundTrip(t, stream, batchRequest("Arrange", moveRequest(shade, 3))); !reply.GetSuccess() {
+		t.Fatalf("batch: %v", reply)
+	}
+	drag(3)
+	a.Do(func(*App) error { h.Commit(); return nil })
+
+	var labels []string
+	for range 2 {
+		if err := a.Do(func(*App) error { labels = append(labels, h.UndoLabel()); return h.Undo() }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if labels[0] != "Move" || labels[1] != "Arrange" {
+		t.Errorf("undid %q, want the drag and then the batch", labels)
+	}
+	if got := h.UndoLabel(); got != "Spawn shade" {
+		t.Errorf("next undo = %q, want the spawn", got)
+	}
+	if position(t, a, lamp).X() != 0 || position(t, a, shade).X() != 0 {
+		t.Errorf("two undos left lamp at %v and shade at %v", position(t, a, lamp), position(t, a, shade))
+	}
+}
+
+// TestBatchIsOneFrameOfChanges: a subscriber gets a whole batch in one
+// ChangeBatch, never part of one.
+func TestBatchIsOneFrameOfChanges(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	shade := spawnLight(t, h, "shade", NoHandle)
+	names := map[Handle]string{lamp: "lamp", shade: "shade"}
+	eg := &engineServer{app: a}
+
+	subscription := a.Subscribe(ChangeFilter{})
+	defer subscription.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := eg.runBatch(&egrpc.Batch{Requests: []*egrpc.EngineRequest{moveRequest(lamp, 1), moveRequest(shade, 1)}})
+		done <- err
+	}()
+	for {
+		a.drainCommands()
+		batch, ok := nextBatch(t, a, subscription)
+		if ok {
+			if got, want := describeBatch(names, batch), "transform lamp, transform shade"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			break
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"fmt"
+
+	egrpc "3d-engine/grpc"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// These handlers put the inspector's component API on the wire: the same
+// ComponentsOf snapshot, and edits through the same History calls, so a
+// client's component edit undoes like one made in the overlay.
+
+func (eg *engineServer) getComponentTypes() *egrpc.ComponentTypes {
+	registry := eg.app.Components
+	names := registry.Names()
+
+	types := &egrpc.ComponentTypes{Types: make([]*egrpc.ComponentType, 0, len(names))}
+	for _, name := range names {
+		fields, _ := registry.Fields(name)
+		types.Types = append(types.Types, &egrpc.ComponentType{
+			Name:   name,
+			Fields: toProtoFields(fields),
+		})
+	}
+	return types
+}
+
+func (eg *engineServer) getComponents(obj *egrpc.Object) (*egrpc.Components, error) {
+	if obj == nil {
+		return nil, status.Error(codes.InvalidArgument, "object is required")
+	}
+
+	handle := DecodeHandle(obj.GetId())
+	components, ok := eg.app.ComponentsOf(handle)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "object %s not found", handle)
+	}
+	return &egrpc.Components{Id: obj.GetId(), Components: toProtoComponents(components)}, nil
+}
+
+// setComponentFields writes every field in the request as one undo step, and
+// none of them if any fails.
+func (eg *engineServer) setComponentFields(request *egrpc.ComponentEdit) error {
+	if request == nil || len(request.GetFields()) == 0 {
+		return status.Error(codes.InvalidArgument, "component with at least one field is required")
+	}
+	fields, err := fieldsFromProto(request.GetFields())
+	if err != nil {
+		return err
+	}
+
+	handle := DecodeHandle(request.GetId())
+	index := int(request.GetComponent())
+	err = eg.edit(func(h *History) error {
+		return h.Transaction("", func() error {
+			for _, field := range fields {
+				if err := h.SetComponentField(handle, index, request.GetType(), field); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+func (eg *engineServer) addComponent(request *egrpc.ComponentEdit) (*egrpc.Components, error) {
+	if request == nil || request.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "component.type is required")
+	}
+	fields, err := fieldsFromProto(request.GetFields())
+	if err != nil {
+		return nil, err
+	}
+
+	handle := DecodeHandle(request.GetId())
+	err = eg.edit(func(h *History) error {
+		_, err := h.AddComponent(handle, request.GetType(), fields)
+		return err
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return eg.getComponents(&egrpc.Object{Id: request.GetId()})
+}
+
+func (eg *engineServer) removeComponent(request *egrpc.ComponentEdit) error {
+	if request == nil {
+		return status.Error(codes.InvalidArgument, "component is required")
+	}
+
+	handle := DecodeHandle(request.GetId())
+	index := int(request.GetComponent())
+	if err := eg.edit(func(h *History) error { return h.RemoveComponent(handle, index, request.GetType()) }); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+func toProtoComponents(components []ComponentInfo) []*egrpc.ComponentInfo {
+	wire := make([]*egrpc.ComponentInfo, 0, len(components))
+	for _, component := range components {
+		wire = append(wire, &egrpc.ComponentInfo{
+			Index:  int32(component.Index),
+			Type:   component.Type,
+			GoType: component.GoType,
+			Fields: toProtoFields(component.Fields),
+		})
+	}
+	return wire
+}
+
+func toProtoFields(fields []ComponentField) []*egrpc.ComponentField {
+	wire := make([]*egrpc.ComponentField, 0, len(fields))
+	for _, field := range fields {
+		wire = append(wire, toProtoField(field))
+	}
+	return wire
+}
+
+// toProtoField is where a ComponentField's Kind picks the oneof member, and
+// fieldFromProto where the member picks the Kind back.
+func toProtoField(field ComponentField) *egrpc.ComponentField {
+	wire := &egrpc.ComponentField{Name: field.Name}
+
+	switch field.Kind {
+	case FieldBool:
+		wire.Value = &egrpc.ComponentField_BoolValue{BoolValue: field.Bool}
+	case FieldInt:
+		wire.Value = &egrpc.ComponentField_IntValue{IntValue: field.Int}
+	case FieldFloat:
+		wire.Value = &egrpc.ComponentField_FloatValue{FloatValue: field.Float}
+	case FieldVec3:
+		wire.Value = &egrpc.ComponentField_Vec3Value{Vec3Value: &egrpc.Vector3{
+			X: field.Vec3.X(), Y: field.Vec3.Y(), Z: field.Vec3.Z(),
+		}}
+	case FieldVec4:
+		wire.Value = &egrpc.ComponentField_Vec4Value{Vec4Value: &egrpc.Vector4{
+			X: field.Vec4.X(), Y: field.Vec4.Y(), Z: field.Vec4.Z(), W: field.Vec4.W(),
+		}}
+	case FieldString:
+		wire.Value = &egrpc.ComponentField_StringValue{StringValue: field.String}
+	}
+	return wire
+}
+
+func fieldFromProto(wire *egrpc.ComponentField) (ComponentField, error) {
+	field := ComponentField{Name: wire.GetName()}
+
+	switch value := wire.GetValue().(type) {
+	case *egrpc.ComponentField_BoolValue:
+		field.Kind, field.Bool = FieldBool, value.BoolValue
+	case *egrpc.ComponentField_IntValue:
+		field.Kind, field.Int = FieldInt, value.IntValue
+	case *egrpc.ComponentField_FloatValue:
+		field.Kind, field.Float = FieldFloat, value.FloatValue
+	case *egrpc.ComponentField_Vec3Value:
+		field.Kind, field.Vec3 = FieldVec3, mgl32.Vec3{value.Vec3Value.GetX(), value.Vec3Value.GetY(), value.Vec3Value.GetZ()}
+	case *egrpc.ComponentField_Vec4Value:
+		field.Kind, field.Vec4 = FieldVec4, toVec4(value.Vec4Value)
+	case *egrpc.ComponentField_StringValue:
+		field.Kind, field.String = FieldString, value.StringValue
+	default:
+		return ComponentField{}, fmt.Errorf("field %q has no value", wire.GetName())
+	}
+	return field, nil
+}
+
+func fieldsFromProto(wire []*egrpc.ComponentField) ([]ComponentField, error) {
+	fields := make([]ComponentField, 0, len(wire))
+	for _, field := range wire {
+		converted, err := fieldFromProto(field)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		fields = append(fields, converted)
+	}
+	return fields, nil
+}
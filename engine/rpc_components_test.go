@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	egrpc "3d-engine/grpc"
+)
+
+// runFrames stands in for the frame loop, running what handlers queue with
+// App.Do, until the test ends.
+func runFrames(t *testing.T, a *App) {
+	t.Helper()
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				a.drainCommands()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-stopped
+	})
+}
+
+func roundTrip(t *testing.T, stream egrpc.Engine_StreamClient, request *egrpc.EngineRequest) *egrpc.EngineResponse {
+	t.Helper()
+
+	if err := stream.Send(request); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func componentRequest(op egrpc.Operation, edit *egrpc.ComponentEdit) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{Operation: op, Body: &egrpc.EngineRequest_Component{Component: edit}}
+}
+
+// TestStreamComponents lists types, adds a component, edits it, and removes
+// it again, all over the wire, and undoes the last step as a client would.
+func TestStreamComponents(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	types := roundTrip(t, stream, &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_GET_COMPONENT_TYPES})
+	var pointLight *egrpc.ComponentType
+	for _, componentType := range types.GetComponentTypes().GetTypes() {
+		if componentType.GetName() == "PointLight" {
+			pointLight = componentType
+		}
+	}
+	if pointLight == nil || len(pointLight.GetFields()) == 0 {
+		t.Fatalf("component types %v", types)
+	}
+
+	added := roundTrip(t, stream, componentRequest(egrpc.Operation_OPERATION_ADD_COMPONENT, &egrpc.ComponentEdit{
+		Id:     lamp.Encode(),
+		Type:   "PointLight",
+		Fields: []*egrpc.ComponentField{{Name: "linear", Value: &egrpc.ComponentField_FloatValue{FloatValue: 0.25}}},
+	}))
+	components := added.GetComponents().GetComponents()
+	if !added.GetSuccess() || len(components) != 2 || components[1].GetType() != "PointLight" {
+		t.Fatalf("add component: %v", added)
+	}
+	for _, field := range components[1].GetFields() {
+		if field.GetName() == "linear" && field.GetFloatValue() != 0.25 {
+			t.Errorf("the added light's linear is %v", field.GetFloatValue())
+		}
+	}
+
+	set := roundTrip(t, stream, componentRequest(egrpc.Operation_OPERATION_SET_COMPONENT_FIELD, &egrpc.ComponentEdit{
+		Id:        lamp.Encode(),
+		Component: 1,
+		Type:      "PointLight",
+		Fields: []*egrpc.ComponentField{
+			{Name: "diffuse", Value: &egrpc.ComponentField_Vec3Value{Vec3Value: &egrpc.Vector3{X: 1}}},
+			{Name: "quadratic", Value: &egrpc.ComponentField_FloatValue{FloatValue: 0.5}},
+		},
+	}))
+	if !set.GetSuccess() {
+		t.Fatalf("set fields: %v", set.GetError())
+	}
+	light := a.World.Get(lamp).Components()[1].(*PointLight)
+	if light.Diffuse.X() != 1 || light.Quadratic != 0.5 {
+		t.Errorf("fields not applied: %+v", light)
+	}
+
+	// All or nothing: the second field is the wrong shape, so the first is
+	// not applied either.
+	bad := roundTrip(t, stream, componentRequest(egrpc.Operation_OPERATION_SET_COMPONENT_FIELD, &egrpc.ComponentEdit{
+		Id:        lamp.Encode(),
+		Component: 1,
+		Type:      "PointLight",
+		Fields: []*egrpc.ComponentField{
+			{Name: "quadratic", Value: &egrpc.ComponentField_FloatValue{FloatValue: 0.9}},
+			{Name: "linear", Value: &egrpc.ComponentField_StringValue{StringValue: "fast"}},
+		},
+	}))
+	if bad.GetSuccess() || light.Quadratic != 0.5 {
+		t.Errorf("a failed set applied part of itself: %v, quadratic %v", bad, light.Quadratic)
+	}
+
+	removed := roundTrip(t, stream, componentRequest(egrpc.Operation_OPERATION_REMOVE_COMPONENT, &egrpc.ComponentEdit{
+		Id:        lamp.Encode(),
+		Component: 1,
+		Type:      "PointLight",
+	}))
+	if !removed.GetSuccess() || len(a.World.Get(lamp).Components()) != 1 {
+		t.Fatalf("remove component: %v", removed)
+	}
+
+	undo := roundTrip(t, stream, &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_UNDO})
+	if undo.GetHistory().GetApplied() != "Remove PointLight" {
+		t.Errorf("undo applied %q", undo.GetHistory().GetApplied())
+	}
+	listed := roundTrip(t, stream, &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_GET_COMPONENTS,
+		Body:      &egrpc.EngineRequest_Object{Object: &egrpc.Object{Id: lamp.Encode()}},
+	})
+	if got := listed.GetComponents().GetComponents(); len(got) != 2 || got[1].GetFields()[1].GetVec3Value().GetX() != 1 {
+		t.Errorf("after undo the lamp has %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"sync"
+
+	egrpc "3d-engine/grpc"
+)
+
+// requestStream is what a session reads requests from and answers on: a gRPC
+// Stream, or the HTTP gateway's WebSocket.
+type requestStream interface {
+	Recv() (*egrpc.EngineRequest, error)
+	Send(*egrpc.EngineResponse) error
+}
+
+// streamSession is one client's Stream. Replies and pushed Events share the
+// stream, and gRPC allows one sender at a time, so every Send goes through
+// send.
+type streamSession struct {
+	server *engineServer
+	stream requestStream
+
+	sendMu sync.Mutex
+
+	// subscription is the live SUBSCRIBE, if any, and forwarded is closed when
+	// the goroutine pushing its batches has stopped.
+	subscription *Subscription
+	forwarded    chan struct{}
+
+	// feed is the live STREAM_FRAMES, if any, and framesSent is closed when
+	// the goroutine sending its frames has stopped.
+	feed       *FrameFeed
+	framesSent chan struct{}
+}
+
+func (s *streamSession) send(resp *egrpc.EngineResponse) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.Send(resp)
+}
+
+// subscribe replaces any earlier subscription on this stream. The reply is
+// sent before the forwarding starts, so a client never sees Events before
+// it has been told it is subscribed.
+func (s *streamSession) subscribe(request *egrpc.Subscription) error {
+	s.unsubscribe()
+
+	subscription := s.server.app.Subscribe(changeFilterFromProto(request))
+	if err := s.send(emptySuccessResponse(egrpc.Operation_OPERATION_SUBSCRIBE)); err != nil {
+		subscription.Close()
+		return err
+	}
+
+	s.subscription = subscription
+	s.forwarded = make(chan struct{})
+	go s.forward(subscription, s.forwarded)
+	return nil
+}
+
+func (s *streamSession) forward(subscription *Subscription, done chan struct{}) {
+	defer close(done)
+
+	for batch := range subscription.Changes() {
+		resp := &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_SUBSCRIBE,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_Events{Events: toProtoEvents(batch)},
+		}
+		if err := s.send(resp); err != nil {
+			// The stream is broken, and Recv will say so; stop taking batches
+			// so the frame loop drops them rather than queueing them.
+			subscription.Close()
+			return
+		}
+	}
+}
+
+// unsubscribe stops the subscription and waits for its forwarding to finish,
+// so no Events follow an UNSUBSCRIBE reply, or the end of the stream.
+func (s *streamSession) unsubscribe() {
+	if s.subscription == nil {
+		return
+	}
+	s.subscription.Close()
+	<-s.forwarded
+	s.subscription = nil
+	s.forwarded = nil
+}
+
+func changeFilterFromProto(request *egrpc.Subscription) ChangeFilter {
+	var filter ChangeFilter
+	for _, id := range request.GetIds() {
+		filter.Handles = append(filter.Handles, DecodeHandle(id))
+	}
+	for _, id := range request.GetSubtrees() {
+		filter.Subtrees = append(filter.Subtrees, DecodeHandle(id))
+	}
+	return filter
+}
+
+func toProtoEvents(batch ChangeBatch) *egrpc.Events {
+	events := &egrpc.Events{
+		Frame:   batch.Frame,
+		Dropped: batch.Dropped,
+		Changes: make([]*egrpc.Change, 0, len(batch.Changes)),
+	}
+	for _, change := range batch.Changes {
+		events.Changes = append(events.Changes, toProtoChange(change))
+	}
+	return events
+}
+
+func toProtoChange(change Change) *egrpc.Change {
+	wire := &egrpc.Change{Id: change.Handle.Encode()}
+
+	switch change.Kind {
+	case ChangeSpawned:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_SPAWNED
+	case ChangeDespawned:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_DESPAWNED
+	case ChangeReparented:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_REPARENTED
+	case ChangeTransform:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_TRANSFORM
+	case ChangeField:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_FIELD
+		wire.Field = &egrpc.ComponentFieldChange{
+			Component: int32(change.Component),
+			Type:      change.Type,
+			Field:     toProtoField(change.Field),
+		}
+	case ChangeComponents:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_COMPONENTS
+		wire.Components = toProtoComponents(change.Components)
+	case ChangeSceneLoaded:
+		wire.Kind = egrpc.ChangeKind_CHANGE_KIND_SCENE_LOADED
+		wire.ScenePath = change.Scene
+	}
+
+	switch change.Kind {
+	case ChangeSpawned, ChangeReparented, ChangeTransform:
+		wire.Object = toProtoObject(change.Object)
+	}
+	return wire
+}
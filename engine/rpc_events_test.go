@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"testing"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// openStream serves a, with the default configuration, on an in-memory
+// listener and opens a Stream to it.
+func openStream(t *testing.T, a *App) egrpc.Engine_StreamClient {
+	t.Helper()
+	return dialRPC(t, serveRPC(t, a, utils.RPCConfig{}), "", nil)
+}
+
+// TestStreamSubscribe drives SUBSCRIBE end to end: the reply comes before any
+// Events, Events arrive with the same operation, and none follow the reply to
+// UNSUBSCRIBE.
+func TestStreamSubscribe(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	stream := openStream(t, a)
+
+	request := &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_SUBSCRIBE,
+		Body: &egrpc.EngineRequest_Subscription{
+			Subscription: &egrpc.Subscription{Ids: []uint64{lamp.Encode()}},
+		},
+	}
+	if err := stream.Send(request); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := stream.Recv()
+	if err != nil || !reply.GetSuccess() || reply.GetEvents() != nil {
+		t.Fatalf("subscribe reply %v, %v", reply, err)
+	}
+
+	a.World.Mutate(lamp, func(e *Entity) { e.SetPosition(mgl32.Vec3{0, 4, 0}) })
+	a.publishChanges()
+
+	pushed, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := pushed.GetEvents().GetChanges()
+	if pushed.GetOperation() != egrpc.Operation_OPERATION_SUBSCRIBE || len(changes) != 1 ||
+		changes[0].GetKind() != egrpc.ChangeKind_CHANGE_KIND_TRANSFORM ||
+		changes[0].GetObject().GetLocation().GetPosition().GetY() != 4 {
+		t.Fatalf("pushed %v", pushed)
+	}
+
+	if err := stream.Send(&egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_UNSUBSCRIBE}); err != nil {
+		t.Fatal(err)
+	}
+	reply, err = stream.Recv()
+	if err != nil || reply.GetOperation() != egrpc.Operation_OPERATION_UNSUBSCRIBE {
+		t.Fatalf("unsubscribe reply %v, %v", reply, err)
+	}
+
+	a.World.Mutate(lamp, func(e *Entity) { e.SetPosition(mgl32.Vec3{0, 8, 0}) })
+	a.publishChanges()
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if extra, err := stream.Recv(); err == nil {
+		t.Errorf("an event followed UNSUBSCRIBE: %v", extra)
+	}
+}
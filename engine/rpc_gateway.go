@@ -0,0 +1,617 @@
+package engine
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The gateway is the RPC server for clients that speak HTTP: a browser, curl,
+// a script with no gRPC stubs. It has no handlers of its own. Each route
+// builds the EngineRequest a gRPC client would send and hands it to the same
+// engineServer, and /v1/stream is the Stream itself, carried over a
+// WebSocket as protojson text frames. So the two cannot drift apart: the same
+// request is refused for the same reason on both, and an edit made through
+// either is one undo step.
+//
+// It shares RPCConfig's TLS, tokens and read-only switch with the gRPC
+// server, and is checked the same way: the token becomes the same
+// "authorization" metadata authenticate reads, and every request goes
+// through permitted.
+
+// gatewayBodyLimit bounds a request body. Scene documents are the largest
+// thing a client sends.
+const gatewayBodyLimit = 16 << 20
+
+type gateway struct {
+	server *engineServer
+	auth   *rpcAuth
+}
+
+// newGateway builds the gateway's routes.
+func newGateway(a *App, config utils.RPCConfig) (http.Handler, error) {
+	auth, err := newRPCAuth(config)
+	if err != nil {
+		return nil, err
+	}
+	gw := &gateway{server: &engineServer{app: a}, auth: auth}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/objects", gw.route(func(*http.Request) (*egrpc.EngineRequest, error) {
+		return &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_GET_OBJECTS}, nil
+	}))
+	mux.HandleFunc("POST /v1/objects", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		object := &egrpc.Object{}
+		if err := readBody(r, object); err != nil {
+			return nil, err
+		}
+		return objectRequest(egrpc.Operation_OPERATION_ADD_OBJECT, object), nil
+	}))
+	mux.HandleFunc("GET /v1/objects/{id}", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		id, err := pathID(r)
+		if err != nil {
+			return nil, err
+		}
+		return objectRequest(egrpc.Operation_OPERATION_GET_OBJECT, &egrpc.Object{Id: id}), nil
+	}))
+	mux.HandleFunc("DELETE /v1/objects/{id}", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		id, err := pathID(r)
+		if err != nil {
+			return nil, err
+		}
+		op := egrpc.Operation_OPERATION_REMOVE_OBJECT
+		if tree, _ := strconv.ParseBool(r.URL.Query().Get("tree")); tree {
+			op = egrpc.Operation_OPERATION_REMOVE_TREE
+		}
+		return objectRequest(op, &egrpc.Object{Id: id}), nil
+	}))
+	mux.HandleFunc("PUT /v1/objects/{id}/parent", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		id, err := pathID(r)
+		if err != nil {
+			return nil, err
+		}
+		object := &egrpc.Object{}
+		if err := readBody(r, object); err != nil {
+			return nil, err
+		}
+		return objectRequest(egrpc.Operation_OPERATION_SET_PARENT, &egrpc.Object{Id: id, ParentId: object.GetParentId()}), nil
+	}))
+	mux.HandleFunc("PUT /v1/objects/{id}/transform", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		id, err := pathID(r)
+		if err != nil {
+			return nil, err
+		}
+		location := &egrpc.Location{}
+		if err := readBody(r, location); err != nil {
+			return nil, err
+		}
+		return transformRequest(id, location)
+	}))
+	mux.HandleFunc("PUT /v1/objects/{id}/color", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		id, err := pathID(r)
+		if err != nil {
+			return nil, err
+		}
+		color := &egrpc.Vector3{}
+		if err := readBody(r, color); err != nil {
+			return nil, err
+		}
+		return objectRequest(egrpc.Operation_OPERATION_SET_BASE_COLOR, &egrpc.Object{Id: id, BaseColor: color}), nil
+	}))
+
+	mux.HandleFunc("GET /v1/scene", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		query := &egrpc.SceneQuery{}
+		if name := r.URL.Query().Get("encoding"); name != "" {
+			encoding, ok := egrpc.SceneEncoding_value["SCENE_ENCODING_"+strings.ToUpper(name)]
+			if !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "unknown scene encoding %q: want yaml, json, binary or proto", name)
+			}
+			query.Encoding = egrpc.SceneEncoding(encoding)
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_GET_SCENE,
+			Body:      &egrpc.EngineRequest_SceneQuery{SceneQuery: query},
+		}, nil
+	}))
+	mux.HandleFunc("GET /v1/scene/modes", gw.route(func(*http.Request) (*egrpc.EngineRequest, error) {
+		return &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_GET_SCENE_MODES}, nil
+	}))
+	mux.HandleFunc("POST /v1/scene/load", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		ref := &egrpc.SceneRef{}
+		if err := readBody(r, ref); err != nil {
+			return nil, err
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_LOAD_SCENE,
+			Body:      &egrpc.EngineRequest_Scene{Scene: ref},
+		}, nil
+	}))
+	mux.HandleFunc("POST /v1/scene/mode", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		ref := &egrpc.SceneModeRef{}
+		if err := readBody(r, ref); err != nil {
+			return nil, err
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_LOAD_SCENE_MODE,
+			Body:      &egrpc.EngineRequest_SceneMode{SceneMode: ref},
+		}, nil
+	}))
+	mux.HandleFunc("POST /v1/scene/document", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		document := &egrpc.SceneDocument{}
+		if err := readBody(r, document); err != nil {
+			return nil, err
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT,
+			Body:      &egrpc.EngineRequest_SceneDocument{SceneDocument: document},
+		}, nil
+	}))
+	mux.HandleFunc("POST /v1/scene/save", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		ref := &egrpc.SceneRef{}
+		if err := readBody(r, ref); err != nil {
+			return nil, err
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_SAVE_SCENE,
+			Body:      &egrpc.EngineRequest_Scene{Scene: ref},
+		}, nil
+	}))
+
+	mux.HandleFunc("GET /v1/camera", gw.route(func(*http.Request) (*egrpc.EngineRequest, error) {
+		return &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_GET_CAMERA}, nil
+	}))
+	mux.HandleFunc("PUT /v1/camera", gw.route(func(r *http.Request) (*egrpc.EngineRequest, error) {
+		pose := &egrpc.CameraPose{}
+		if err := readBody(r, pose); err != nil {
+			return nil, err
+		}
+		return &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_SET_CAMERA,
+			Body:      &egrpc.EngineRequest_Camera{Camera: pose},
+		}, nil
+	}))
+	mux.HandleFunc("POST /v1/camera/reset", gw.route(func(*http.Request) (*egrpc.EngineRequest, error) {
+		return &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_RESET_CAMERA}, nil
+	}))
+
+	mux.HandleFunc("GET /v1/viewport", gw.viewport)
+	mux.HandleFunc("GET /v1/stream", gw.stream)
+	return mux, nil
+}
+
+// route adapts a function that reads an HTTP request into the EngineRequest
+// it stands for.
+func (gw *gateway) route(build func(*http.Request) (*egrpc.EngineRequest, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, err := gw.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req, err := build(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := permitted(role, req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := gw.server.handleRequest(req)
+		if !resp.GetSuccess() {
+			// The reply's error is the status's own text, code and all; the
+			// code goes in its own field here.
+			message := resp.GetError()
+			if prefix, desc, ok := strings.Cut(message, " desc = "); ok && strings.HasPrefix(prefix, "rpc error: ") {
+				message = desc
+			}
+			writeError(w, status.Error(codes.Code(resp.GetCode()), message))
+			return
+		}
+		writeBody(w, req.GetOperation(), resp)
+	}
+}
+
+// authenticate runs the gRPC server's check on the request's authorization
+// header or, for a browser's WebSocket, which cannot set one, its
+// access_token parameter.
+func (gw *gateway) authenticate(r *http.Request) (rpcRole, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		if token := r.URL.Query().Get("access_token"); token != "" {
+			header = "Bearer " + token
+		}
+	}
+
+	ctx := r.Context()
+	if header != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", header))
+	}
+	return gw.auth.authenticate(ctx)
+}
+
+func pathID(r *http.Request) (uint64, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "%q is not an object id", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+func objectRequest(op egrpc.Operation, object *egrpc.Object) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{Operation: op, Body: &egrpc.EngineRequest_Object{Object: object}}
+}
+
+// transformRequest sets whichever of position, rotation and scale the body
+// holds. More than one, short of all three, is a batch, so the object never
+// stands half moved and one undo puts it back.
+func transformRequest(id uint64, location *egrpc.Location) (*egrpc.EngineRequest, error) {
+	if location.Position != nil && location.Rotation != nil && location.Scale != nil {
+		return objectRequest(egrpc.Operation_OPERATION_UPDATE_OBJECT, &egrpc.Object{Id: id, Location: location}), nil
+	}
+
+	var parts []*egrpc.EngineRequest
+	if location.Position != nil {
+		parts = append(parts, objectRequest(egrpc.Operation_OPERATION_MOVE_OBJECT,
+			&egrpc.Object{Id: id, Location: &egrpc.Location{Position: location.Position}}))
+	}
+	if location.Rotation != nil {
+		parts = append(parts, objectRequest(egrpc.Operation_OPERATION_ROTATE_OBJECT,
+			&egrpc.Object{Id: id, Location: &egrpc.Location{Rotation: location.Rotation}}))
+	}
+	if location.Scale != nil {
+		parts = append(parts, objectRequest(egrpc.Operation_OPERATION_SCALE_OBJECT,
+			&egrpc.Object{Id: id, Location: &egrpc.Location{Scale: location.Scale}}))
+	}
+
+	switch len(parts) {
+	case 0:
+		return nil, status.Error(codes.InvalidArgument, "the body needs a position, rotation or scale")
+	case 1:
+		return parts[0], nil
+	}
+	return &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_BATCH,
+		Body:      &egrpc.EngineRequest_Batch{Batch: &egrpc.Batch{Requests: parts, Label: "Transform object"}},
+	}, nil
+}
+
+// readBody decodes a JSON request body into message. Only JSON is taken: a
+// form a browser can post from another site would otherwise reach the engine
+// without the preflight a JSON request needs.
+func readBody(r *http.Request, message proto.Message) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errUnsupportedMediaType
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, gatewayBodyLimit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errTooLarge
+		}
+		return status.Errorf(codes.InvalidArgument, "reading the body: %v", err)
+	}
+	if err := protojson.Unmarshal(content, message); err != nil {
+		return status.Errorf(codes.InvalidArgument, "the body is not a valid %s: %v", message.ProtoReflect().Descriptor().Name(), err)
+	}
+	return nil
+}
+
+// Failures with no gRPC code of their own.
+var (
+	errUnsupportedMediaType = errors.New("the body must be application/json")
+	errTooLarge             = fmt.Errorf("the body is over %d bytes", gatewayBodyLimit)
+)
+
+// writeBody writes the response's body message, or nothing when it has none
+// to give.
+func writeBody(w http.ResponseWriter, op egrpc.Operation, resp *egrpc.EngineResponse) {
+	reflected := resp.ProtoReflect()
+	field := reflected.WhichOneof(reflected.Descriptor().Oneofs().ByName("body"))
+	code := http.StatusOK
+	if op == egrpc.Operation_OPERATION_ADD_OBJECT {
+		code = http.StatusCreated
+		if id := resp.GetObject().GetId(); id != 0 {
+			w.Header().Set("Location", "/v1/objects/"+strconv.FormatUint(id, 10))
+		}
+	}
+	// The only batches here are the gateway's own, made from one PUT: the
+	// client asked for nothing back.
+	if field == nil || resp.GetEmpty() != nil || resp.GetBatch() != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	content, err := protojson.Marshal(reflected.Get(field).Message().Interface())
+	if err != nil {
+		writeError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(content)
+}
+
+// writeError writes {"error", "code"}, code being the gRPC one's name, under
+// the HTTP status that stands for it.
+func writeError(w http.ResponseWriter, err error) {
+	code, httpStatus := codes.Unknown, http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errUnsupportedMediaType):
+		code, httpStatus = codes.InvalidArgument, http.StatusUnsupportedMediaType
+	case errors.Is(err, errTooLarge):
+		code, httpStatus = codes.InvalidArgument, http.StatusRequestEntityTooLarge
+	default:
+		code = status.Code(err)
+		httpStatus = httpStatusOf(code)
+		err = errors.New(status.Convert(err).Message())
+	}
+
+	if code == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{err.Error(), code.String()})
+}
+
+// httpStatusOf is the usual mapping of a gRPC code onto HTTP, as gRPC's own
+// HTTP gateways do it.
+func httpStatusOf(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// viewport streams the camera's view as MJPEG: a multipart response of JPEG
+// frames, each replacing the last, which a browser shows in a plain <img>.
+// The query takes the FrameRequest's width, height, rate and quality. It runs
+// until the client goes away.
+func (gw *gateway) viewport(w http.ResponseWriter, r *http.Request) {
+	role, err := gw.authenticate(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := permitted(role, &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_STREAM_FRAMES}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	request := FrameRequest{Format: FrameJPEG}
+	query := r.URL.Query()
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{{"width", &request.Width}, {"height", &request.Height}, {"quality", &request.Quality}} {
+		if text := query.Get(field.name); text != "" {
+			if *field.value, err = strconv.Atoi(text); err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "%s %q is not a number", field.name, text))
+				return
+			}
+		}
+	}
+	if text := query.Get("rate"); text != "" {
+		rate, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			writeError(w, status.Errorf(codes.InvalidArgument, "rate %q is not a number", text))
+			return
+		}
+		request.Rate = float32(rate)
+	}
+
+	feed, err := gw.server.app.StreamFrames(request)
+	if err != nil {
+		writeError(w, frameStreamError(err))
+		return
+	}
+	defer feed.Close()
+
+	parts := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+parts.Boundary())
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	request = feed.Request()
+	for {
+		var frame CapturedFrame
+		select {
+		case <-r.Context().Done():
+			return
+		case frame = <-feed.Frames():
+		}
+		if frame.Image == nil {
+			// The feed was closed under us.
+			return
+		}
+
+		data, err := EncodeFrame(frame.Image, FrameJPEG, request.Quality)
+		if err != nil {
+			utils.Logger().Println("Encoding a frame:", err)
+			continue
+		}
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":   {"image/jpeg"},
+			"Content-Length": {strconv.Itoa(len(data))},
+		})
+		if err != nil {
+			return
+		}
+		if _, err := part.Write(data); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// stream upgrades to a WebSocket that carries the Stream: one EngineRequest
+// per text frame in, one EngineResponse per frame out, pushed Events among
+// them. The client is authenticated before the upgrade, and a page may only
+// open one on its own host, so a site the user happens to visit cannot drive
+// a local engine through their browser.
+func (gw *gateway) stream(w http.ResponseWriter, r *http.Request) {
+	role, err := gw.authenticate(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			origin, err := websocket.Origin(config, r)
+			if err != nil {
+				return err
+			}
+			if origin != nil && origin.Host != r.Host {
+				return fmt.Errorf("origin %s is not %s", origin, r.Host)
+			}
+			config.Origin = origin
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			if err := gw.server.serve(&socketStream{conn: conn}, role); err != nil {
+				utils.Logger().Println("rpc gateway stream ended:", err)
+			}
+		},
+	}.ServeHTTP(w, r)
+}
+
+// socketStream is the requestStream a WebSocket carries. A websocket.Conn may
+// be written from several goroutines, so Send needs no lock of its own.
+type socketStream struct {
+	conn *websocket.Conn
+}
+
+// Recv answers a frame that is not a request with an error and reads on: a
+// typo from a hand-written client should not cost it its subscription.
+func (s *socketStream) Recv() (*egrpc.EngineRequest, error) {
+	for {
+		var frame string
+		if err := websocket.Message.Receive(s.conn, &frame); err != nil {
+			return nil, err
+		}
+
+		req := &egrpc.EngineRequest{}
+		err := protojson.Unmarshal([]byte(frame), req)
+		if err == nil {
+			return req, nil
+		}
+		reply := errorResponse(egrpc.Operation_OPERATION_UNSPECIFIED, status.Errorf(codes.InvalidArgument, "not an EngineRequest: %v", err))
+		if err := s.Send(reply); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *socketStream) Send(resp *egrpc.EngineResponse) error {
+	content, err := protojson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return websocket.Message.Send(s.conn, string(content))
+}
+
+// startGateway serves the gateway on config.HTTPAddress, over TLS when the
+// gRPC server has it. Like startRPCServer, it listens before returning so a
+// port conflict is New's error.
+func (a *App) startGateway(config utils.RPCConfig) error {
+	handler, err := newGateway(a, config)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	if config.TLS.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.TLS.CertFile, config.TLS.KeyFile)
+		if err != nil {
+			return fmt.Errorf("could not load the rpc certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+
+	listener, err := net.Listen("tcp", config.HTTPAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", config.HTTPAddress, err)
+	}
+	a.gateway = server
+
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.Logger().Println("rpc gateway stopped:", err)
+		}
+	}()
+
+	return nil
+}
+
+// stopGateway closes the listener and every connection without waiting for
+// requests to finish: the engine they would finish on is shutting down.
+// WebSockets were hijacked from the server, so Close does not reach them;
+// they end when their next request finds the frame loop gone.
+func (a *App) stopGateway() {
+	if a.gateway == nil {
+		return
+	}
+	a.gateway.Close()
+	a.gateway = nil
+}
@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func serveGateway(t *testing.T, a *App, config utils.RPCConfig) *httptest.Server {
+	t.Helper()
+
+	handler, err := newGateway(a, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// call sends one request to the gateway, with a JSON body if there is one,
+// and returns the status and the body that came back.
+func call(t *testing.T, server *httptest.Server, method, path, token, body string) (int, string) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(content)
+}
+
+// TestGatewayObjects walks an object through the REST routes: made, read,
+// moved, tinted, reparented and deleted, each an undoable edit like its gRPC
+// twin.
+func TestGatewayObjects(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	crate := NewEntity("crate")
+	crate.SetTransform(IdentityTransform())
+	crate.Renderer = &MeshRenderer{BaseColor: DefaultBaseColor}
+	a.World.Spawn(crate)
+	runFrames(t, a)
+	server := serveGateway(t, a, utils.RPCConfig{})
+
+	code, body := call(t, server, "POST", "/v1/objects", "", `{"name": "marker", "location": {"position": {"x": 1}}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /v1/objects: %d %s", code, body)
+	}
+	created := &egrpc.Object{}
+	if err := protojson.Unmarshal([]byte(body), created); err != nil || created.GetName() != "marker" {
+		t.Fatalf("created %s, %v", body, err)
+	}
+	marker := "/v1/objects/" + strconv.FormatUint(created.GetId(), 10)
+
+	code, body = call(t, server, "GET", "/v1/objects", "", "")
+	listed := &egrpc.Objects{}
+	if err := protojson.Unmarshal([]byte(body), listed); code != http.StatusOK || err != nil || len(listed.GetObjects()) != 3 {
+		t.Fatalf("GET /v1/objects: %d %s", code, body)
+	}
+
+	if code, body := call(t, server, "PUT", marker+"/transform", "", `{"position": {"x": 4}, "scale": {"x": 2, "y": 2, "z": 2}}`); code != http.StatusNoContent {
+		t.Fatalf("PUT transform: %d %s", code, body)
+	}
+	info, _ := a.ObjectInfo(DecodeHandle(created.GetId()))
+	if info.Transform.Position.X() != 4 || info.Transform.Scale != (mgl32.Vec3{2, 2, 2}) {
+		t.Errorf("transform now %+v", info.Transform)
+	}
+	if label := h.UndoLabel(); label != "Transform object" {
+		t.Errorf("a two-part transform is undone as %q, want one step", label)
+	}
+
+	if code, body := call(t, server, "PUT", marker+"/parent", "", `{"parentId": "`+strconv.FormatUint(lamp.Encode(), 10)+`"}`); code != http.StatusNoContent {
+		t.Fatalf("PUT parent: %d %s", code, body)
+	}
+	code, body = call(t, server, "GET", marker, "", "")
+	fetched := &egrpc.Object{}
+	if err := protojson.Unmarshal([]byte(body), fetched); code != http.StatusOK || err != nil || fetched.GetParentId() != lamp.Encode() {
+		t.Errorf("GET %s after reparenting: %d %s", marker, code, body)
+	}
+
+	crateColor := "/v1/objects/" + strconv.FormatUint(crate.Handle().Encode(), 10) + "/color"
+	if code, body := call(t, server, "PUT", crateColor, "", `{"x": 1, "y": 0.5}`); code != http.StatusNoContent {
+		t.Fatalf("PUT color: %d %s", code, body)
+	}
+	if info, _ := a.ObjectInfo(crate.Handle()); info.BaseColor != (mgl32.Vec3{1, 0.5, 0}) {
+		t.Errorf("crate colour %v", info.BaseColor)
+	}
+	if code, body := call(t, server, "PUT", marker+"/color", "", `{"x": 1}`); code != http.StatusBadRequest {
+		t.Errorf("colouring an object with no model: %d %s", code, body)
+	}
+
+	if code, body := call(t, server, "DELETE", "/v1/objects/"+strconv.FormatUint(lamp.Encode(), 10)+"?tree=true", "", ""); code != http.StatusNoContent {
+		t.Fatalf("DELETE tree: %d %s", code, body)
+	}
+	if got := a.World.Len(); got != 1 {
+		t.Errorf("%d entities left, want the crate alone", got)
+	}
+}
+
+// TestGatewayErrors: a failure comes back as JSON naming its gRPC code, under
+// the HTTP status that goes with it.
+func TestGatewayErrors(t *testing.T) {
+	a, _ := historyApp(t)
+	runFrames(t, a)
+	server := serveGateway(t, a, utils.RPCConfig{})
+
+	code, body := call(t, server, "GET", "/v1/objects/12345", "", "")
+	var failure struct{ Error, Code string }
+	if err := json.Unmarshal([]byte(body), &failure); code != http.StatusNotFound || err != nil || failure.Code != "NotFound" || strings.Contains(failure.Error, "rpc error") {
+		t.Errorf("a missing object: %d %s", code, body)
+	}
+	if code, body := call(t, server, "GET", "/v1/objects/crate", "", ""); code != http.StatusBadRequest {
+		t.Errorf("a malformed id: %d %s", code, body)
+	}
+	if code, body := call(t, server, "POST", "/v1/objects", "", `{"name": `); code != http.StatusBadRequest {
+		t.Errorf("malformed JSON: %d %s", code, body)
+	}
+	if code, body := call(t, server, "GET", "/v1/scene?encoding=xml", "", ""); code != http.StatusBadRequest {
+		t.Errorf("an unknown encoding: %d %s", code, body)
+	}
+
+	response, err := server.Client().Post(server.URL+"/v1/objects", "application/x-www-form-urlencoded", strings.NewReader("name=marker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("a form post: %d", response.StatusCode)
+	}
+	if a.World.Len() != 0 {
+		t.Error("a refused request spawned something")
+	}
+}
+
+// TestGatewayAuth: the gateway takes the gRPC server's tokens and roles.
+func TestGatewayAuth(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	runFrames(t, a)
+	server := serveGateway(t, a, utils.RPCConfig{Tokens: []utils.RPCToken{
+		{Token: "editor-token"},
+		{Token: "viewer-token", Role: "readOnly"},
+	}})
+	path := "/v1/objects/" + strconv.FormatUint(lamp.Encode(), 10)
+
+	for _, token := range []string{"", "guess"} {
+		if code, body := call(t, server, "GET", path, token, ""); code != http.StatusUnauthorized {
+			t.Errorf("token %q: %d %s", token, code, body)
+		}
+	}
+	if code, body := call(t, server, "GET", path, "viewer-token", ""); code != http.StatusOK {
+		t.Errorf("read-only GET: %d %s", code, body)
+	}
+	if code, body := call(t, server, "DELETE", path, "viewer-token", ""); code != http.StatusForbidden {
+		t.Errorf("read-only DELETE: %d %s", code, body)
+	}
+	if a.World.Len() != 1 {
+		t.Fatal("a read-only client deleted the lamp")
+	}
+	if code, body := call(t, server, "DELETE", path, "editor-token", ""); code != http.StatusNoContent {
+		t.Errorf("read-write DELETE: %d %s", code, body)
+	}
+}
+
+// TestGatewayStream speaks the Stream over the WebSocket: a bad frame is
+// answered and the socket stays up, and a subscription pushes Events.
+func TestGatewayStream(t *testing.T) {
+	a, h := historyApp(t)
+	lamp := spawnLight(t, h, "lamp", NoHandle)
+	runFrames(t, a)
+	server := serveGateway(t, a, utils.RPCConfig{Tokens: []utils.RPCToken{{Token: "editor-token"}}})
+	socketURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/stream?access_token=editor-token"
+
+	if _, err := websocket.Dial(socketURL, "", "http://elsewhere.example"); err == nil {
+		t.Error("a page on another host opened the stream")
+	}
+	if _, err := websocket.Dial(strings.TrimSuffix(socketURL, "editor-token")+"guess", "", server.URL); err == nil {
+		t.Error("the stream opened without a valid token")
+	}
+
+	conn, err := websocket.Dial(socketURL, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	exchange := func(frame string) *egrpc.EngineResponse {
+		t.Helper()
+		if err := websocket.Message.Send(conn, frame); err != nil {
+			t.Fatal(err)
+		}
+		return receive(t, conn)
+	}
+
+	if reply := exchange("{not json"); reply.GetSuccess() || codes.Code(reply.GetCode()) != codes.InvalidArgument {
+		t.Errorf("a bad frame: %v", reply)
+	}
+	if reply := exchange(`{"operation": "OPERATION_SUBSCRIBE"}`); !reply.GetSuccess() {
+		t.Fatalf("SUBSCRIBE: %v", reply)
+	}
+
+	move := `{"operation": "OPERATION_MOVE_OBJECT", "object": {"id": "` + strconv.FormatUint(lamp.Encode(), 10) + `", "location": {"position": {"x": 2}}}}`
+	if reply := exchange(move); !reply.GetSuccess() {
+		t.Fatalf("MOVE_OBJECT: %v", reply)
+	}
+	a.Do(func(app *App) error {
+		app.publishChanges()
+		return nil
+	})
+	events := receive(t, conn)
+	if changes := events.GetEvents().GetChanges(); events.GetOperation() != egrpc.Operation_OPERATION_SUBSCRIBE || len(changes) == 0 || changes[0].GetId() != lamp.Encode() {
+		t.Errorf("events %v", events)
+	}
+}
+
+func receive(t *testing.T, conn *websocket.Conn) *egrpc.EngineResponse {
+	t.Helper()
+
+	var frame string
+	if err := websocket.Message.Receive(conn, &frame); err != nil {
+		t.Fatal(err)
+	}
+	resp := &egrpc.EngineResponse{}
+	if err := protojson.Unmarshal([]byte(frame), resp); err != nil {
+		t.Fatalf("frame %s: %v", frame, err)
+	}
+	return resp
+}
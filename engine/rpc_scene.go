@@ -0,0 +1,280 @@
+package engine
+
+import (
+	egrpc "3d-engine/grpc"
+	"3d-engine/scene"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+// These handlers put the scene file on the wire: the same SceneSnapshot and
+// SaveScene the editor's save uses, and the background loader fed a document
+// instead of a path. A serialized scene is exactly the bytes a file would
+// hold, so a client can write it out, diff it or send it back as it is.
+
+func (eg *engineServer) getScene(query *egrpc.SceneQuery) (*egrpc.SceneDocument, error) {
+	encoding := query.GetEncoding()
+	var format scene.Format
+	if encoding != egrpc.SceneEncoding_SCENE_ENCODING_PROTO {
+		var err error
+		if format, err = sceneFormat(encoding); err != nil {
+			return nil, err
+		}
+	}
+
+	var snapshot *scene.Scene
+	err := eg.onFrame(func(app *App) error {
+		var err error
+		snapshot, err = app.SceneSnapshot()
+		return err
+	})
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	document := &egrpc.SceneDocument{Encoding: encoding, Path: eg.app.Scenes.CurrentScenePath()}
+	if encoding == egrpc.SceneEncoding_SCENE_ENCODING_PROTO {
+		document.Scene, err = toProtoScene(snapshot)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return document, nil
+	}
+
+	name := document.Path
+	if name == "" {
+		name = "the live scene"
+	}
+	document.Content, err = scene.Encode(name, format, snapshot)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return document, nil
+}
+
+// saveScene writes to the client's path, or else to the current scene's.
+// Either is held to the scene root. The current scene's may be one the engine
+// was started with, or a scene mode's, which the operator chose but did not
+// put in the root for clients to overwrite.
+func (eg *engineServer) saveScene(sceneRef *egrpc.SceneRef) (*egrpc.SceneRef, error) {
+	path := sceneRef.GetPath()
+	if path == "" {
+		path = eg.app.Scenes.CurrentScenePath()
+		if path == "" {
+			return nil, status.Error(codes.FailedPrecondition, "scene.path is required: the current scene has no file of its own")
+		}
+	}
+	if root := eg.sceneRoot(); root != "" {
+		if err := insideRoot(root, "scene", path); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	if err := eg.onFrame(func(app *App) error { return app.SaveScene(path) }); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &egrpc.SceneRef{Path: path}, nil
+}
+
+// loadSceneDocument decodes and checks the document before queueing it, so a
+// scene that would not load is refused in the reply rather than only in the
+// engine log a frame later.
+func (eg *engineServer) loadSceneDocument(document *egrpc.SceneDocument) error {
+	if len(document.GetContent()) == 0 {
+		return status.Error(codes.InvalidArgument, "scene_document.content is required")
+	}
+	format, err := sceneFormat(document.GetEncoding())
+	if err != nil {
+		return err
+	}
+
+	root := eg.sceneRoot()
+	path := document.GetPath()
+	if path != "" && root != "" {
+		if err := insideRoot(root, "scene", path); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	name := path
+	if name == "" {
+		name = "inline scene"
+	}
+	loaded, err := scene.Decode(name, format, document.GetContent())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := eg.app.Scenes.RequestSceneDocument(loaded, path, root); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+func (eg *engineServer) sceneRoot() string {
+	if eg.app.Config == nil {
+		return ""
+	}
+	return eg.app.Config.RPC.SceneRoot
+}
+
+// sceneFormat is the file format an encoding names. PROTO is not one.
+func sceneFormat(encoding egrpc.SceneEncoding) (scene.Format, error) {
+	switch encoding {
+	case egrpc.SceneEncoding_SCENE_ENCODING_UNSPECIFIED, egrpc.SceneEncoding_SCENE_ENCODING_YAML:
+		return scene.FormatYAML, nil
+	case egrpc.SceneEncoding_SCENE_ENCODING_JSON:
+		return scene.FormatJSON, nil
+	case egrpc.SceneEncoding_SCENE_ENCODING_BINARY:
+		return scene.FormatBinary, nil
+	}
+	return 0, status.Errorf(codes.InvalidArgument, "%s is not a scene file format", encoding)
+}
+
+// toProtoScene and the functions below it copy the scene file structs into
+// their proto mirrors, one for one.
+func toProtoScene(s *scene.Scene) (*egrpc.SceneDescription, error) {
+	description := &egrpc.SceneDescription{
+		Version: int32(s.Version),
+		Skybox:  s.Skybox,
+	}
+	if s.Camera != nil {
+		description.Camera = &egrpc.SceneCamera{
+			Position: toProtoVector3(s.Camera.Position),
+			Yaw:      s.Camera.Yaw,
+			Pitch:    s.Camera.Pitch,
+		}
+	}
+	for _, include := range s.Includes {
+		description.Includes = append(description.Includes, &egrpc.SceneInclude{Path: include.Path, Stream: include.Stream})
+	}
+	for _, cell := range s.Cells {
+		description.Cells = append(description.Cells, &egrpc.SceneCell{
+			Path: cell.Path,
+			Min:  toProtoVector3(cell.Bounds.Min),
+			Max:  toProtoVector3(cell.Bounds.Max),
+		})
+	}
+
+	var err error
+	description.Objects, err = toProtoSceneObjects(s.Objects)
+	if err != nil {
+		return nil, err
+	}
+	return description, nil
+}
+
+func toProtoSceneObjects(objects []scene.Object) ([]*egrpc.SceneObject, error) {
+	wire := make([]*egrpc.SceneObject, 0, len(objects))
+	for i := range objects {
+		object := &objects[i]
+
+		row := &egrpc.SceneObject{
+			Name:      object.Name,
+			Model:     object.Model,
+			Transform: toProtoTransformSpec(object.Transform),
+			Body:      toProtoBody(object.Body),
+			Material:  toProtoMaterial(object.Material),
+			Prefab:    object.Prefab,
+		}
+
+		var err error
+		for _, component := range object.Components {
+			converted, err := toProtoSceneComponent(component.Type, 0, component.Props)
+			if err != nil {
+				return nil, err
+			}
+			row.Components = append(row.Components, converted)
+		}
+		if row.Children, err = toProtoSceneObjects(object.Children); err != nil {
+			return nil, err
+		}
+		for _, override := range object.Overrides {
+			converted, err := toProtoOverride(override)
+			if err != nil {
+				return nil, err
+			}
+			row.Overrides = append(row.Overrides, converted)
+		}
+		wire = append(wire, row)
+	}
+	return wire, nil
+}
+
+func toProtoOverride(override scene.Override) (*egrpc.SceneOverride, error) {
+	wire := &egrpc.SceneOverride{
+		Target:    override.Target,
+		Transform: toProtoTransformSpec(override.Transform),
+		Body:      toProtoBody(override.Body),
+		Material:  toProtoMaterial(override.Material),
+	}
+	for _, component := range override.Components {
+		converted, err := toProtoSceneComponent(component.Type, component.Index, component.Props)
+		if err != nil {
+			return nil, err
+		}
+		wire.Components = append(wire.Components, converted)
+	}
+
+	var err error
+	if wire.Children, err = toProtoSceneObjects(override.Children); err != nil {
+		return nil, err
+	}
+	return wire, nil
+}
+
+// toProtoSceneComponent turns the props node into the value it reads as, the
+// same one the scene's JSON form would hold.
+func toProtoSceneComponent(typeName string, index int, props yaml.Node) (*egrpc.SceneComponent, error) {
+	wire := &egrpc.SceneComponent{Type: typeName, Index: int32(index)}
+	if props.IsZero() {
+		return wire, nil
+	}
+
+	var value any
+	if err := props.Decode(&value); err != nil {
+		return nil, err
+	}
+	converted, err := structpb.NewValue(value)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "component %s: %v", typeName, err)
+	}
+	wire.Props = converted
+	return wire, nil
+}
+
+func toProtoTransformSpec(transform *scene.TransformSpec) *egrpc.Location {
+	if transform == nil {
+		return nil
+	}
+	return &egrpc.Location{
+		Position: toProtoVector3(transform.Position),
+		Rotation: &egrpc.Vector4{
+			X: transform.Rotation[0],
+			Y: transform.Rotation[1],
+			Z: transform.Rotation[2],
+			W: transform.Rotation[3],
+		},
+		Scale: toProtoVector3(transform.Scale),
+	}
+}
+
+func toProtoBody(body *scene.BodySpec) *egrpc.SceneBody {
+	if body == nil {
+		return nil
+	}
+	return &egrpc.SceneBody{Static: body.Static}
+}
+
+func toProtoMaterial(material *scene.MaterialSpec) *egrpc.SceneMaterial {
+	if material == nil {
+		return nil
+	}
+	return &egrpc.SceneMaterial{Color: toProtoVector3(material.Color)}
+}
+
+func toProtoVector3(v [3]float32) *egrpc.Vector3 {
+	return &egrpc.Vector3{X: v[0], Y: v[1], Z: v[2]}
+}
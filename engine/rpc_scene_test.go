@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/scene"
+	"3d-engine/utils"
+
+	"google.golang.org/grpc/codes"
+)
+
+func sceneRequest(op egrpc.Operation, path string) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{
+		Operation: op,
+		Body:      &egrpc.EngineRequest_Scene{Scene: &egrpc.SceneRef{Path: path}},
+	}
+}
+
+func documentRequest(path, content string) *egrpc.EngineRequest {
+	return &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT,
+		Body: &egrpc.EngineRequest_SceneDocument{SceneDocument: &egrpc.SceneDocument{
+			Encoding: egrpc.SceneEncoding_SCENE_ENCODING_YAML,
+			Content:  []byte(content),
+			Path:     path,
+		}},
+	}
+}
+
+// TestStreamGetAndSaveScene: the snapshot comes back as the file it would
+// save, or as its proto mirror, and a save goes where it is told as long as
+// that is inside the scene root.
+func TestStreamGetAndSaveScene(t *testing.T) {
+	a, h := historyApp(t)
+	spawnLight(t, h, "lamp", NoHandle)
+	root := t.TempDir()
+	a.Config = &utils.Config{RPC: utils.RPCConfig{SceneRoot: root}}
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	getScene := func(encoding egrpc.SceneEncoding) *egrpc.SceneDocument {
+		t.Helper()
+		reply := roundTrip(t, stream, &egrpc.EngineRequest{
+			Operation: egrpc.Operation_OPERATION_GET_SCENE,
+			Body:      &egrpc.EngineRequest_SceneQuery{SceneQuery: &egrpc.SceneQuery{Encoding: encoding}},
+		})
+		if !reply.GetSuccess() {
+			t.Fatalf("get scene as %s: %v", encoding, reply)
+		}
+		return reply.GetSceneDocument()
+	}
+
+	for encoding, format := range map[egrpc.SceneEncoding]scene.Format{
+		egrpc.SceneEncoding_SCENE_ENCODING_YAML:   scene.FormatYAML,
+		egrpc.SceneEncoding_SCENE_ENCODING_JSON:   scene.FormatJSON,
+		egrpc.SceneEncoding_SCENE_ENCODING_BINARY: scene.FormatBinary,
+	} {
+		decoded, err := scene.Decode("reply", format, getScene(encoding).GetContent())
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if len(decoded.Objects) != 1 || decoded.Objects[0].Name != "lamp" || decoded.Objects[0].Components[0].Type != "PointLight" {
+			t.Errorf("%s scene has objects %+v", encoding, decoded.Objects)
+		}
+	}
+
+	description := getScene(egrpc.SceneEncoding_SCENE_ENCODING_PROTO).GetScene()
+	objects := description.GetObjects()
+	if description.GetVersion() != scene.CurrentVersion || len(objects) != 1 || objects[0].GetName() != "lamp" {
+		t.Fatalf("proto scene %v", description)
+	}
+	props := objects[0].GetComponents()[0].GetProps().GetStructValue().GetFields()
+	if props["linear"].GetNumberValue() == 0 {
+		t.Errorf("PointLight props came over as %v", props)
+	}
+
+	reply := roundTrip(t, stream, sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, ""))
+	if reply.GetSuccess() || !strings.Contains(reply.GetError(), codes.FailedPrecondition.String()) {
+		t.Errorf("saving a scene with no file of its own: %v", reply)
+	}
+
+	outside := filepath.Join(t.TempDir(), "escaped.yml")
+	reply = roundTrip(t, stream, sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, outside))
+	if reply.GetSuccess() || !strings.Contains(reply.GetError(), codes.PermissionDenied.String()) {
+		t.Errorf("saving outside the root: %v", reply)
+	}
+	if _, err := os.Stat(outside); err == nil {
+		t.Error("a save outside the root was written")
+	}
+
+	inside := filepath.Join(root, "saved.json")
+	reply = roundTrip(t, stream, sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, inside))
+	if !reply.GetSuccess() || reply.GetScene().GetPath() != inside {
+		t.Fatalf("saving inside the root: %v", reply)
+	}
+	if saved, err := scene.Load(inside); err != nil || len(saved.Objects) != 1 {
+		t.Errorf("saved scene %+v, %v", saved, err)
+	}
+}
+
+// TestLoadSceneDocument: an inline scene loads like a file, under the path it
+// was given, and may not reach outside the scene root — directly, or through
+// a prefab that is itself inside.
+func TestLoadSceneDocument(t *testing.T) {
+	a := saveTestApp(t)
+	root := t.TempDir()
+	a.Config = &utils.Config{RPC: utils.RPCConfig{SceneRoot: root}}
+	eg := &engineServer{app: a}
+
+	refused := []struct {
+		name    string
+		request *egrpc.EngineRequest
+	}{
+		{"a path outside", documentRequest(filepath.Join(t.TempDir(), "inline.yml"), hierarchyScene)},
+		{"a model outside", documentRequest("", "version: 2\nobjects:\n  - name: crate\n    model: ../crate.obj\n")},
+		{"a nested prefab outside", documentRequest("", "version: 2\nobjects:\n  - name: rig\n    children:\n      - name: lamp\n        prefab: /etc/lamp.yml\n")},
+	}
+	for _, test := range refused {
+		reply := eg.handleRequest(test.request)
+		if reply.GetSuccess() || !strings.Contains(reply.GetError(), "outside the scene root") {
+			t.Errorf("%s: %v", test.name, reply)
+		}
+	}
+	if reply := eg.handleRequest(documentRequest("", "version: 2\nobjects: [{name: empty}]\n")); reply.GetSuccess() {
+		t.Errorf("a scene that would not load was accepted: %v", reply)
+	}
+	if progress := a.Scenes.LoadProgress(); progress.Stage != LoadIdle {
+		t.Fatalf("a refused document started a load: %+v", progress)
+	}
+
+	path := filepath.Join(root, "inline.yml")
+	if reply := eg.handleRequest(documentRequest(path, hierarchyScene)); !reply.GetSuccess() {
+		t.Fatalf("loading an inline scene: %v", reply)
+	}
+	if progress := pumpLoad(t, a); progress.Stage != LoadDone {
+		t.Fatalf("load ended %s: %v", progress.Stage, progress.Err)
+	}
+	if got := a.World.Len(); got != 5 {
+		t.Errorf("world has %d entities, want 5", got)
+	}
+	if got := a.Scenes.CurrentScenePath(); got != path {
+		t.Errorf("current scene = %q, want %q", got, path)
+	}
+
+	prefab := writeScene(t, root, "crate.yml", "version: 2\nprefab:\n  name: crate\n  model: ../crate.obj\n")
+	if reply := eg.handleRequest(documentRequest("", "version: 2\nobjects:\n  - name: crate\n    prefab: "+prefab+"\n")); !reply.GetSuccess() {
+		t.Fatalf("a prefab inside the root was refused: %v", reply)
+	}
+	progress := pumpLoad(t, a)
+	if progress.Err == nil || !strings.Contains(progress.Err.Error(), "outside the scene root") {
+		t.Errorf("a prefab's model outside the root loaded: %s, %v", progress.Stage, progress.Err)
+	}
+	if got := a.World.Len(); got != 5 {
+		t.Errorf("the failed load replaced the scene: %d entities", got)
+	}
+}
+
+// TestLoadSceneThenSaveInRoot: LOAD_SCENE is held to the scene root like a
+// document is, and a save with no path writes the current scene's file only
+// when that is inside the root too.
+func TestLoadSceneThenSaveInRoot(t *testing.T) {
+	a := saveTestApp(t)
+	root, elsewhere := t.TempDir(), t.TempDir()
+	a.Config = &utils.Config{RPC: utils.RPCConfig{SceneRoot: root}}
+	eg := &engineServer{app: a}
+
+	outside := writeScene(t, elsewhere, "outside.yml", hierarchyScene)
+	if reply := eg.handleRequest(sceneRequest(egrpc.Operation_OPERATION_LOAD_SCENE, outside)); reply.GetSuccess() ||
+		!strings.Contains(reply.GetError(), codes.PermissionDenied.String()) {
+		t.Errorf("loading a scene outside the root: %v", reply)
+	}
+	if progress := a.Scenes.LoadProgress(); progress.Stage != LoadIdle {
+		t.Fatalf("a refused LOAD_SCENE started a load: %+v", progress)
+	}
+
+	reaching := writeScene(t, root, "reaching.yml", "version: 2\nobjects:\n  - name: crate\n    model: "+filepath.Join(elsewhere, "crate.obj")+"\n")
+	if reply := eg.handleRequest(sceneRequest(egrpc.Operation_OPERATION_LOAD_SCENE, reaching)); !reply.GetSuccess() {
+		t.Fatalf("loading a scene inside the root: %v", reply)
+	}
+	if progress := pumpLoad(t, a); progress.Err == nil || !strings.Contains(progress.Err.Error(), "outside the scene root") {
+		t.Errorf("a scene file naming a model outside the root loaded: %s, %v", progress.Stage, progress.Err)
+	}
+
+	// The operator's own scene, from outside the root, is not the client's to
+	// overwrite by leaving the path out.
+	loadAndPlace(t, a, outside)
+	if reply := eg.handleRequest(sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, "")); reply.GetSuccess() ||
+		!strings.Contains(reply.GetError(), codes.PermissionDenied.String()) {
+		t.Errorf("saving over the current scene outside the root: %v", reply)
+	}
+	if content, err := os.ReadFile(outside); err != nil || string(content) != hierarchyScene {
+		t.Errorf("the scene outside the root was rewritten: %v", err)
+	}
+
+	inside := writeScene(t, root, "inside.yml", hierarchyScene)
+	if reply := eg.handleRequest(sceneRequest(egrpc.Operation_OPERATION_LOAD_SCENE, inside)); !reply.GetSuccess() {
+		t.Fatalf("loading a scene inside the root: %v", reply)
+	}
+	if progress := pumpLoad(t, a); progress.Stage != LoadDone {
+		t.Fatalf("load ended %s: %v", progress.Stage, progress.Err)
+	}
+	runFrames(t, a)
+	reply := eg.handleRequest(sceneRequest(egrpc.Operation_OPERATION_SAVE_SCENE, ""))
+	if !reply.GetSuccess() || reply.GetScene().GetPath() != inside {
+		t.Errorf("saving the current scene inside the root: %v", reply)
+	}
+}
@@ -1,15 +1,14 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"net"
 
 	egrpc "3d-engine/grpc"
 	"3d-engine/utils"
 
 	"github.com/go-gl/mathgl/mgl32"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
@@ -18,20 +17,30 @@ import (
 type engineServer struct {
 	egrpc.UnimplementedEngineServer
 	app *App
+
+	// inFrame is set on the server a BATCH runs its requests through: they are
+	// already on the frame loop, inside the batch's transaction.
+	inFrame bool
 }
 
 // startRPCServer binds the listener synchronously so a port conflict surfaces
 // as an error from New, then serves on its own goroutine. Handlers never touch
-// GL: they either mutate transforms under the model lock or queue a scene
-// change for the frame loop.
-func (a *App) startRPCServer(addr string) error {
-	lis, err := net.Listen("tcp", addr)
+// GL themselves: edits run on the frame loop through the History, so a client
+// can undo them exactly like an editor change, and scene changes are queued.
+// Who may connect, and what they may do, is rpc_auth.go's business.
+func (a *App) startRPCServer(addr string, config utils.RPCConfig) error {
+	server, err := newRPCServer(a, config)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		return err
 	}
 
-	server := grpc.NewServer()
-	egrpc.RegisterEngineServer(server, &engineServer{app: a})
+	lis, err := listenRPC(addr, config.Socket)
+	if err != nil {
+		if config.Socket != "" {
+			addr = config.Socket
+		}
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
 	a.rpc = server
 
 	go func() {
@@ -44,6 +53,17 @@ func (a *App) startRPCServer(addr string) error {
 }
 
 func (eg *engineServer) Stream(stream egrpc.Engine_StreamServer) error {
+	return eg.serve(stream, roleOf(stream.Context()))
+}
+
+// serve answers a client's requests, in order, until it stops sending. The
+// gRPC Stream and the HTTP gateway's WebSocket both come through here, so the
+// two cannot answer the same request differently.
+func (eg *engineServer) serve(stream requestStream, role rpcRole) error {
+	session := &streamSession{server: eg, stream: stream}
+	defer session.unsubscribe()
+	defer session.stopFrames()
+
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
@@ -53,8 +73,39 @@ func (eg *engineServer) Stream(stream egrpc.Engine_StreamServer) error {
 			return err
 		}
 
-		resp := eg.handleRequest(req)
-		if err := stream.Send(resp); err != nil {
+		if err := permitted(role, req); err != nil {
+			// Refused like any failed request: the stream stays open for the
+			// ones the client may send.
+			if err := session.send(errorResponse(req.GetOperation(), err)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var resp *egrpc.EngineResponse
+		switch req.GetOperation() {
+		case egrpc.Operation_OPERATION_SUBSCRIBE:
+			// Sent by subscribe itself, so the reply goes out before the
+			// first Events.
+			if err := session.subscribe(req.GetSubscription()); err != nil {
+				return err
+			}
+			continue
+		case egrpc.Operation_OPERATION_UNSUBSCRIBE:
+			session.unsubscribe()
+			resp = emptySuccessResponse(egrpc.Operation_OPERATION_UNSUBSCRIBE)
+		case egrpc.Operation_OPERATION_STREAM_FRAMES:
+			if err := session.streamFrames(req.GetFrames()); err != nil {
+				return err
+			}
+			continue
+		case egrpc.Operation_OPERATION_STOP_FRAMES:
+			session.stopFrames()
+			resp = emptySuccessResponse(egrpc.Operation_OPERATION_STOP_FRAMES)
+		default:
+			resp = eg.handleRequest(req)
+		}
+		if err := session.send(resp); err != nil {
 			return err
 		}
 	}
@@ -138,6 +189,112 @@ func (eg *engineServer) handleRequest(req *egrpc.EngineRequest) *egrpc.EngineRes
 			return errorResponse(egrpc.Operation_OPERATION_LOAD_SCENE_MODE, err)
 		}
 		return emptySuccessResponse(egrpc.Operation_OPERATION_LOAD_SCENE_MODE)
+	case egrpc.Operation_OPERATION_UNDO, egrpc.Operation_OPERATION_REDO:
+		history, err := eg.stepHistory(req.GetOperation() == egrpc.Operation_OPERATION_REDO)
+		if err != nil {
+			return errorResponse(req.GetOperation(), err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: req.GetOperation(),
+			Success:   true,
+			Body:      &egrpc.EngineResponse_History{History: history},
+		}
+	case egrpc.Operation_OPERATION_GET_COMPONENT_TYPES:
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_GET_COMPONENT_TYPES,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_ComponentTypes{ComponentTypes: eg.getComponentTypes()},
+		}
+	case egrpc.Operation_OPERATION_GET_COMPONENTS:
+		components, err := eg.getComponents(req.GetObject())
+		if err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_GET_COMPONENTS, err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_GET_COMPONENTS,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_Components{Components: components},
+		}
+	case egrpc.Operation_OPERATION_SET_COMPONENT_FIELD:
+		if err := eg.setComponentFields(req.GetComponent()); err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_SET_COMPONENT_FIELD, err)
+		}
+		return emptySuccessResponse(egrpc.Operation_OPERATION_SET_COMPONENT_FIELD)
+	case egrpc.Operation_OPERATION_ADD_COMPONENT:
+		components, err := eg.addComponent(req.GetComponent())
+		if err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_ADD_COMPONENT, err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_ADD_COMPONENT,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_Components{Components: components},
+		}
+	case egrpc.Operation_OPERATION_REMOVE_COMPONENT:
+		if err := eg.removeComponent(req.GetComponent()); err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_REMOVE_COMPONENT, err)
+		}
+		return emptySuccessResponse(egrpc.Operation_OPERATION_REMOVE_COMPONENT)
+	case egrpc.Operation_OPERATION_BATCH:
+		results, err := eg.runBatch(req.GetBatch())
+		resp := &egrpc.EngineResponse{Operation: egrpc.Operation_OPERATION_BATCH, Success: err == nil}
+		if err != nil {
+			resp.Error = err.Error()
+			resp.Code = int32(status.Code(err))
+		}
+		if results != nil {
+			resp.Body = &egrpc.EngineResponse_Batch{Batch: results}
+		}
+		return resp
+	case egrpc.Operation_OPERATION_GET_SCENE:
+		document, err := eg.getScene(req.GetSceneQuery())
+		if err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_GET_SCENE, err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_GET_SCENE,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_SceneDocument{SceneDocument: document},
+		}
+	case egrpc.Operation_OPERATION_SAVE_SCENE:
+		saved, err := eg.saveScene(req.GetScene())
+		if err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_SAVE_SCENE, err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_SAVE_SCENE,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_Scene{Scene: saved},
+		}
+	case egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT:
+		if err := eg.loadSceneDocument(req.GetSceneDocument()); err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT, err)
+		}
+		return emptySuccessResponse(egrpc.Operation_OPERATION_LOAD_SCENE_DOCUMENT)
+	case egrpc.Operation_OPERATION_GET_OBJECT:
+		object, err := eg.getObject(req.GetObject())
+		if err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_GET_OBJECT, err)
+		}
+		return &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_GET_OBJECT,
+			Success:   true,
+			Body:      &egrpc.EngineResponse_Object{Object: object},
+		}
+	case egrpc.Operation_OPERATION_SET_BASE_COLOR:
+		if err := eg.setBaseColor(req.GetObject()); err != nil {
+			return errorResponse(egrpc.Operation_OPERATION_SET_BASE_COLOR, err)
+		}
+		return emptySuccessResponse(egrpc.Operation_OPERATION_SET_BASE_COLOR)
+	case egrpc.Operation_OPERATION_GET_CAMERA:
+		pose, err := eg.getCamera()
+		return cameraResponse(egrpc.Operation_OPERATION_GET_CAMERA, pose, err)
+	case egrpc.Operation_OPERATION_SET_CAMERA:
+		pose, err := eg.setCamera(req.GetCamera())
+		return cameraResponse(egrpc.Operation_OPERATION_SET_CAMERA, pose, err)
+	case egrpc.Operation_OPERATION_RESET_CAMERA:
+		pose, err := eg.resetCamera()
+		return cameraResponse(egrpc.Operation_OPERATION_RESET_CAMERA, pose, err)
 	default:
 		return errorResponse(req.GetOperation(), status.Error(codes.InvalidArgument, "unsupported operation"))
 	}
@@ -158,6 +315,7 @@ func errorResponse(op egrpc.Operation, err error) *egrpc.EngineResponse {
 		Operation: op,
 		Success:   false,
 		Error:     err.Error(),
+		Code:      int32(status.Code(err)),
 	}
 }
 
@@ -203,7 +361,25 @@ func toProtoObject(info ObjectInfo) *egrpc.Object {
 				Z: info.Transform.Scale.Z(),
 			},
 		},
+		BaseColor: &egrpc.Vector3{
+			X: info.BaseColor.X(),
+			Y: info.BaseColor.Y(),
+			Z: info.BaseColor.Z(),
+		},
+	}
+}
+
+func (eg *engineServer) getObject(obj *egrpc.Object) (*egrpc.Object, error) {
+	if obj == nil {
+		return nil, status.Error(codes.InvalidArgument, "object is required")
+	}
+
+	handle := DecodeHandle(obj.GetId())
+	info, ok := eg.app.ObjectInfo(handle)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "object %s not found", handle)
 	}
+	return toProtoObject(info), nil
 }
 
 // addObject spawns through the same API a scene file and the editor use. The
@@ -225,8 +401,20 @@ func (eg *engineServer) addObject(obj *egrpc.Object) (*egrpc.Object, error) {
 	// Zero decodes to NoHandle, which SpawnObject reads as "no parent", so a
 	// client that never sets the field behaves exactly as before.
 	spec.Parent = DecodeHandle(obj.GetParentId())
+	if obj.BaseColor != nil {
+		color := toVec3(obj.BaseColor)
+		spec.BaseColor = &color
+	}
 
-	handle, err := eg.app.Spawn(spec)
+	var handle Handle
+	err := eg.edit(func(h *History) error {
+		entity, err := h.SpawnObject(spec)
+		if err != nil {
+			return err
+		}
+		handle = entity.Handle()
+		return nil
+	})
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -244,7 +432,7 @@ func (eg *engineServer) removeObject(obj *egrpc.Object) error {
 	}
 
 	handle := DecodeHandle(obj.GetId())
-	if err := eg.app.Despawn(handle); err != nil {
+	if err := eg.edit(func(h *History) error { return h.DespawnObject(handle) }); err != nil {
 		return status.Errorf(codes.NotFound, "%v", err)
 	}
 	return nil
@@ -259,7 +447,7 @@ func (eg *engineServer) removeTree(obj *egrpc.Object) error {
 	}
 
 	handle := DecodeHandle(obj.GetId())
-	if err := eg.app.DespawnSubtree(handle); err != nil {
+	if err := eg.edit(func(h *History) error { return h.DespawnTree(handle) }); err != nil {
 		return status.Errorf(codes.NotFound, "%v", err)
 	}
 	return nil
@@ -275,7 +463,7 @@ func (eg *engineServer) setParent(obj *egrpc.Object) error {
 	child := DecodeHandle(obj.GetId())
 	parent := DecodeHandle(obj.GetParentId())
 
-	if err := eg.app.SetParent(child, parent); err != nil {
+	if err := eg.edit(func(h *History) error { return h.SetParent(child, parent) }); err != nil {
 		// A cycle is a bad request, not a missing object: both handles resolved.
 		return status.Errorf(codes.InvalidArgument, "%v", err)
 	}
@@ -338,17 +526,88 @@ func (eg *engineServer) updateObject(obj *egrpc.Object) error {
 	})
 }
 
+// setBaseColor tells a missing object from one with nothing to colour, which
+// SetBaseColor's error alone does not.
+func (eg *engineServer) setBaseColor(obj *egrpc.Object) error {
+	if obj == nil || obj.BaseColor == nil {
+		return status.Error(codes.InvalidArgument, "object.base_color is required")
+	}
+
+	handle := DecodeHandle(obj.GetId())
+	return eg.edit(func(h *History) error {
+		if _, ok := eg.app.ObjectInfo(handle); !ok {
+			return status.Errorf(codes.NotFound, "object %s not found", handle)
+		}
+		if err := h.SetBaseColor(handle, toVec3(obj.BaseColor)); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	})
+}
+
 // update translates a wire id into a handle and a miss into a NotFound. A
 // handle that no longer resolves — because the entity was despawned or the
 // scene reloaded — fails rather than writing to whatever now occupies the slot.
 func (eg *engineServer) update(id uint64, fn func(t *Transform)) error {
 	handle := DecodeHandle(id)
-	if err := eg.app.UpdateTransform(handle, fn); err != nil {
+	if err := eg.edit(func(h *History) error { return h.UpdateTransform(handle, fn) }); err != nil {
 		return status.Errorf(codes.NotFound, "%v", err)
 	}
 	return nil
 }
 
+// edit runs fn against the History on the frame loop and waits for it. The
+// History is frame-loop only, and running there also orders a client's edits
+// with the editor's, so undo reverses whichever really came last.
+//
+// The edit is kept apart from any transaction the editor has open (see
+// History.Separately), so it is a step of its own. Inside a batch it is not:
+// there the open transaction is the batch's, and the edit belongs in it.
+func (eg *engineServer) edit(fn func(h *History) error) error {
+	return eg.onFrame(func(app *App) error {
+		if eg.inFrame {
+			return fn(app.History)
+		}
+		return app.History.Separately(func() error { return fn(app.History) })
+	})
+}
+
+// onFrame runs fn on the frame loop and waits for it, or, on a server whose
+// requests are already there (see runBatch), runs it directly.
+func (eg *engineServer) onFrame(fn func(a *App) error) error {
+	if eg.inFrame {
+		return fn(eg.app)
+	}
+	return eg.app.Do(fn)
+}
+
+// stepHistory undoes or redoes one step and reports where the history stands.
+func (eg *engineServer) stepHistory(redo bool) (*egrpc.History, error) {
+	var result egrpc.History
+
+	err := eg.edit(func(h *History) error {
+		step, apply := h.UndoLabel, h.Undo
+		if redo {
+			step, apply = h.RedoLabel, h.Redo
+		}
+
+		result.Applied = step()
+		if err := apply(); err != nil {
+			if errors.Is(err, ErrNothingToUndo) || errors.Is(err, ErrNothingToRedo) {
+				return status.Error(codes.FailedPrecondition, err.Error())
+			}
+			return status.Error(codes.Aborted, err.Error())
+		}
+		result.NextUndo = h.UndoLabel()
+		result.NextRedo = h.RedoLabel()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 func toVec3(v *egrpc.Vector3) mgl32.Vec3 {
 	return mgl32.Vec3{v.X, v.Y, v.Z}
 }
@@ -362,7 +621,15 @@ func (eg *engineServer) loadScene(sceneRef *egrpc.SceneRef) error {
 		return status.Error(codes.InvalidArgument, "scene.path is required")
 	}
 
-	eg.app.Scenes.RequestSceneChange(sceneRef.GetPath())
+	path := sceneRef.GetPath()
+	if root := eg.sceneRoot(); root != "" {
+		if err := insideRoot(root, "scene", path); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		eg.app.Scenes.RequestSceneChangeWithin(path, root)
+		return nil
+	}
+	eg.app.Scenes.RequestSceneChange(path)
 	return nil
 }
 
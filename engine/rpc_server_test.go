@@ -92,3 +92,38 @@ func TestToProtoObjectReportsAxisAngle(t *testing.T) {
 			rotation.GetX(), rotation.GetY(), rotation.GetZ())
 	}
 }
+
+// TestToProtoChangeCarriesWhatChanged: each kind puts its payload where the
+// proto says, and only there.
+func TestToProtoChangeCarriesWhatChanged(t *testing.T) {
+	handle := Handle{Index: 3, Generation: 2}
+
+	field := toProtoChange(Change{
+		Kind:      ChangeField,
+		Handle:    handle,
+		Component: 1,
+		Type:      "PointLight",
+		Field:     ComponentField{Name: "diffuse", Kind: FieldVec3, Vec3: mgl32.Vec3{1, 0.5, 0}},
+	})
+	if field.GetId() != handle.Encode() || field.GetObject() != nil {
+		t.Errorf("field change %v", field)
+	}
+	if got := field.GetField(); got.GetComponent() != 1 || got.GetType() != "PointLight" ||
+		got.GetField().GetName() != "diffuse" || got.GetField().GetVec3Value().GetY() != 0.5 {
+		t.Errorf("field change body %v", got)
+	}
+
+	moved := toProtoChange(Change{Kind: ChangeTransform, Handle: handle, Object: ObjectInfo{
+		Handle:    handle,
+		Name:      "lamp",
+		Transform: IdentityTransform(),
+	}})
+	if moved.GetObject().GetName() != "lamp" || moved.GetField() != nil {
+		t.Errorf("transform change %v", moved)
+	}
+
+	loaded := toProtoChange(Change{Kind: ChangeSceneLoaded, Scene: "scenes/town.yml"})
+	if loaded.GetScenePath() != "scenes/town.yml" || loaded.GetId() != 0 || loaded.GetObject() != nil {
+		t.Errorf("scene loaded change %v", loaded)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package engine
+
+import "net"
+
+// listenSocket has no mode to set where sockets are not files with Unix
+// permissions; there, access is whatever the directory holding it allows.
+func listenSocket(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package engine
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenSocket creates the socket with no permission for anyone but its
+// owner from the start. Tightening the mode after the listen would leave a
+// moment in which any local user could connect, and a connection accepted
+// then is not undone by the chmod.
+//
+// The umask is the process's, so for the length of the call any file another
+// goroutine creates is held to the owner too; that errs the safe way.
+func listenSocket(socket string) (net.Listener, error) {
+	previous := syscall.Umask(0o177)
+	defer syscall.Umask(previous)
+
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"errors"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// These handlers let a client see what the engine sees: the camera's pose,
+// read and set on the frame loop like any other edit, and the frames of
+// viewport.go, pushed down the stream the way SUBSCRIBE pushes Events.
+//
+// Camera moves are not recorded in the History. The editor's own flying
+// about is not either, and an undo that put the camera back would undo
+// nothing a user made.
+
+func (eg *engineServer) getCamera() (*egrpc.CameraPose, error) {
+	var pose CameraPose
+	err := eg.onFrame(func(app *App) error {
+		pose = app.CameraPose()
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return toProtoCameraPose(pose), nil
+}
+
+// setCamera starts from the current pose, so a client can turn the camera
+// without knowing where it is, or move it without turning it.
+func (eg *engineServer) setCamera(wire *egrpc.CameraPose) (*egrpc.CameraPose, error) {
+	if wire == nil {
+		return nil, status.Error(codes.InvalidArgument, "camera is required")
+	}
+	if fov := wire.Fov; fov != nil && *fov <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "camera.fov %g is not a field of view", *fov)
+	}
+
+	var pose CameraPose
+	err := eg.onFrame(func(app *App) error {
+		pose = app.CameraPose()
+		if wire.Position != nil {
+			pose.Position = toVec3(wire.Position)
+		}
+		if wire.Yaw != nil {
+			pose.Yaw = *wire.Yaw
+		}
+		if wire.Pitch != nil {
+			pose.Pitch = *wire.Pitch
+		}
+		if wire.Fov != nil {
+			pose.Fov = *wire.Fov
+		}
+		app.SetCameraPose(pose)
+		pose = app.CameraPose()
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return toProtoCameraPose(pose), nil
+}
+
+func (eg *engineServer) resetCamera() (*egrpc.CameraPose, error) {
+	var pose CameraPose
+	err := eg.onFrame(func(app *App) error {
+		app.ResetCamera()
+		pose = app.CameraPose()
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return toProtoCameraPose(pose), nil
+}
+
+func cameraResponse(op egrpc.Operation, pose *egrpc.CameraPose, err error) *egrpc.EngineResponse {
+	if err != nil {
+		return errorResponse(op, err)
+	}
+	return &egrpc.EngineResponse{
+		Operation: op,
+		Success:   true,
+		Body:      &egrpc.EngineResponse_Camera{Camera: pose},
+	}
+}
+
+func toProtoCameraPose(pose CameraPose) *egrpc.CameraPose {
+	return &egrpc.CameraPose{
+		Position: &egrpc.Vector3{X: pose.Position.X(), Y: pose.Position.Y(), Z: pose.Position.Z()},
+		Yaw:      &pose.Yaw,
+		Pitch:    &pose.Pitch,
+		Fov:      &pose.Fov,
+	}
+}
+
+// streamFrames replaces any earlier frame stream on this session. A request
+// that cannot be streamed is refused in the reply, and the session carries on.
+func (s *streamSession) streamFrames(request *egrpc.FrameRequest) error {
+	s.stopFrames()
+
+	feed, err := s.server.app.StreamFrames(frameRequestFromProto(request))
+	if err != nil {
+		return s.send(errorResponse(egrpc.Operation_OPERATION_STREAM_FRAMES, frameStreamError(err)))
+	}
+	if err := s.send(emptySuccessResponse(egrpc.Operation_OPERATION_STREAM_FRAMES)); err != nil {
+		feed.Close()
+		return err
+	}
+
+	s.feed = feed
+	s.framesSent = make(chan struct{})
+	go s.forwardFrames(feed, s.framesSent)
+	return nil
+}
+
+// forwardFrames encodes each capture and sends it. Encoding here, on the
+// session's goroutine, keeps it off the frame loop; while it runs, the feed's
+// buffer is full and the engine skips this stream's frames rather than queue
+// them.
+func (s *streamSession) forwardFrames(feed *FrameFeed, done chan struct{}) {
+	defer close(done)
+
+	request := feed.Request()
+	encoding := egrpc.FrameEncoding_FRAME_ENCODING_PNG
+	if request.Format == FrameJPEG {
+		encoding = egrpc.FrameEncoding_FRAME_ENCODING_JPEG
+	}
+
+	for frame := range feed.Frames() {
+		data, err := EncodeFrame(frame.Image, request.Format, request.Quality)
+		if err != nil {
+			utils.Logger().Println("Encoding a frame:", err)
+			continue
+		}
+
+		bounds := frame.Image.Bounds()
+		resp := &egrpc.EngineResponse{
+			Operation: egrpc.Operation_OPERATION_STREAM_FRAMES,
+			Success:   true,
+			Body: &egrpc.EngineResponse_Frame{Frame: &egrpc.Frame{
+				Frame:    frame.Number,
+				Width:    uint32(bounds.Dx()),
+				Height:   uint32(bounds.Dy()),
+				Encoding: encoding,
+				Data:     data,
+				Dropped:  uint32(frame.Dropped),
+			}},
+		}
+		if err := s.send(resp); err != nil {
+			feed.Close()
+			return
+		}
+	}
+}
+
+// stopFrames ends the frame stream and waits for its last frame to be sent,
+// so none follows a STOP_FRAMES reply.
+func (s *streamSession) stopFrames() {
+	if s.feed == nil {
+		return
+	}
+	s.feed.Close()
+	<-s.framesSent
+	s.feed = nil
+	s.framesSent = nil
+}
+
+func frameRequestFromProto(request *egrpc.FrameRequest) FrameRequest {
+	converted := FrameRequest{
+		Width:   int(request.GetWidth()),
+		Height:  int(request.GetHeight()),
+		Rate:    request.GetRate(),
+		Quality: int(request.GetQuality()),
+	}
+	if request.GetEncoding() == egrpc.FrameEncoding_FRAME_ENCODING_JPEG {
+		converted.Format = FrameJPEG
+	}
+	return converted
+}
+
+// frameStreamError is the status for a stream StreamFrames would not open: the
+// engine being at its limit is no fault of the request.
+func frameStreamError(err error) error {
+	if errors.Is(err, ErrFrameBudget) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
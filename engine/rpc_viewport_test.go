@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"bytes"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	egrpc "3d-engine/grpc"
+	"3d-engine/scene"
+	"3d-engine/utils"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func cameraRequest(op egrpc.Operation, pose *egrpc.CameraPose) *egrpc.EngineRequest {
+	request := &egrpc.EngineRequest{Operation: op}
+	if pose != nil {
+		request.Body = &egrpc.EngineRequest_Camera{Camera: pose}
+	}
+	return request
+}
+
+func optional(value float32) *float32 {
+	return &value
+}
+
+// TestStreamCamera: a client reads the pose, changes part of it and keeps the
+// rest, and puts the camera back where the scene starts it.
+func TestStreamCamera(t *testing.T) {
+	a := saveTestApp(t)
+	a.Camera.CameraFov = 45
+	a.Config = &utils.Config{Fov: 60}
+	a.Scenes.cameraSpawn = scene.CameraSpec{Position: [3]float32{1, 2, 3}, Yaw: 45, Pitch: -10}
+	runFrames(t, a)
+	stream := openStream(t, a)
+
+	reply := roundTrip(t, stream, cameraRequest(egrpc.Operation_OPERATION_GET_CAMERA, nil))
+	if pose := reply.GetCamera(); pose.GetPosition().GetZ() != 3 || pose.GetYaw() != -90 || pose.GetFov() != 45 {
+		t.Fatalf("GET_CAMERA: %v", reply)
+	}
+
+	reply = roundTrip(t, stream, cameraRequest(egrpc.Operation_OPERATION_SET_CAMERA, &egrpc.CameraPose{
+		Pitch: optional(120),
+		Fov:   optional(30),
+	}))
+	pose := reply.GetCamera()
+	if !reply.GetSuccess() || pose.GetPitch() != 89 || pose.GetFov() != 30 || pose.GetYaw() != -90 || pose.GetPosition().GetZ() != 3 {
+		t.Errorf("SET_CAMERA of pitch and fov: %v", reply)
+	}
+	if a.Camera.CameraFront.Y() < 0.99 {
+		t.Errorf("camera looks along %v after pitching up", a.Camera.CameraFront)
+	}
+
+	reply = roundTrip(t, stream, cameraRequest(egrpc.Operation_OPERATION_SET_CAMERA, &egrpc.CameraPose{Fov: optional(0)}))
+	if reply.GetSuccess() {
+		t.Errorf("a zero fov was accepted: %v", reply)
+	}
+
+	reply = roundTrip(t, stream, cameraRequest(egrpc.Operation_OPERATION_RESET_CAMERA, nil))
+	if pose := reply.GetCamera(); pose.GetPosition().GetY() != 2 || pose.GetYaw() != 45 || pose.GetPitch() != -10 || pose.GetFov() != 60 {
+		t.Errorf("RESET_CAMERA: %v", reply)
+	}
+	if a.Camera.CameraPos != (mgl32.Vec3{1, 2, 3}) {
+		t.Errorf("camera at %v after reset", a.Camera.CameraPos)
+	}
+
+	reply = roundTrip(t, stream, batchRequest("", cameraRequest(egrpc.Operation_OPERATION_SET_CAMERA, &egrpc.CameraPose{Yaw: optional(0)})))
+	if reply.GetSuccess() {
+		t.Errorf("a camera move was batched: %v", reply)
+	}
+}
+
+// TestStreamFrames: frames follow the STREAM_FRAMES reply, encoded as asked,
+// and none follow STOP_FRAMES.
+func TestStreamFrames(t *testing.T) {
+	a := saveTestApp(t)
+	stream := openStream(t, a)
+
+	reply := roundTrip(t, stream, &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_STREAM_FRAMES,
+		Body:      &egrpc.EngineRequest_Frames{Frames: &egrpc.FrameRequest{Width: 99999, Height: 10}},
+	})
+	if reply.GetSuccess() || !strings.Contains(reply.GetError(), "frame size") {
+		t.Errorf("an oversized stream: %v", reply)
+	}
+
+	reply = roundTrip(t, stream, &egrpc.EngineRequest{
+		Operation: egrpc.Operation_OPERATION_STREAM_FRAMES,
+		Body:      &egrpc.EngineRequest_Frames{Frames: &egrpc.FrameRequest{Width: 32, Height: 24, Rate: 30}},
+	})
+	if !reply.GetSuccess() {
+		t.Fatalf("STREAM_FRAMES: %v", reply)
+	}
+
+	if _, err := a.frames.capture(time.Now(), 800, 600, solidFrame); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := pushed.GetFrame()
+	if pushed.GetOperation() != egrpc.Operation_OPERATION_STREAM_FRAMES || frame.GetWidth() != 32 || frame.GetEncoding() != egrpc.FrameEncoding_FRAME_ENCODING_PNG {
+		t.Fatalf("pushed %v", pushed)
+	}
+	if config, err := png.DecodeConfig(bytes.NewReader(frame.GetData())); err != nil || config.Width != 32 || config.Height != 24 {
+		t.Errorf("frame decodes as %+v, %v", config, err)
+	}
+
+	reply = roundTrip(t, stream, &egrpc.EngineRequest{Operation: egrpc.Operation_OPERATION_STOP_FRAMES})
+	if !reply.GetSuccess() || reply.GetOperation() != egrpc.Operation_OPERATION_STOP_FRAMES {
+		t.Fatalf("STOP_FRAMES: %v", reply)
+	}
+	if wanted, _ := a.frames.capture(time.Now().Add(time.Second), 800, 600, solidFrame); len(wanted) != 0 {
+		t.Errorf("still capturing %v after STOP_FRAMES", wanted)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if extra, err := stream.Recv(); err == nil {
+		t.Errorf("a frame followed STOP_FRAMES: %v", extra)
+	}
+}
+
+// TestGatewayViewport reads the first JPEG off the MJPEG viewport.
+func TestGatewayViewport(t *testing.T) {
+	a := saveTestApp(t)
+	server := serveGateway(t, a, utils.RPCConfig{})
+
+	if code, body := call(t, server, "GET", "/v1/viewport?width=wide", "", ""); code != http.StatusBadRequest {
+		t.Errorf("a malformed width: %d %s", code, body)
+	}
+
+	// Capture in the background, as the frame loop would, until the test is
+	// done reading.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				a.frames.capture(time.Now(), 800, 600, solidFrame)
+			}
+		}
+	}()
+
+	response, err := server.Client().Get(server.URL + "/v1/viewport?width=40&height=30&rate=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("content type %q, %v", response.Header.Get("Content-Type"), err)
+	}
+	part, err := multipart.NewReader(response.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("part is %q", part.Header.Get("Content-Type"))
+	}
+	if config, err := jpeg.DecodeConfig(part); err != nil || config.Width != 40 || config.Height != 30 {
+		t.Errorf("part decodes as %+v, %v", config, err)
+	}
+}
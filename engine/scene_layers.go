@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"3d-engine/scene"
+	"3d-engine/utils"
+)
+
+// A layer is a scene file loaded on top of the current scene rather than in
+// place of it: the includes a scene declares, loaded with it, and anything
+// loaded later with LoadAdditive. Each layer is known by its path.
+//
+// Ownership is by tree. Every entity a layer builds is marked with it, and an
+// entity belongs to the layer of the entity at the top of its tree, so a child
+// added under a layer's object in the editor goes with that layer, and a layer
+// object moved under one of the scene's goes with the scene. Unloading a layer
+// despawns exactly the trees it owns, and the asset cache's holds go with
+// them, so a model only that layer used is released and one still in use
+// elsewhere stays resident.
+
+// layerSpecs is one layer's objects, resolved and ready to build.
+type layerSpecs struct {
+	path  string
+	specs []ObjectSpec
+}
+
+// owner is the layer the entity's tree belongs to. Callers hold the world lock.
+func (e *Entity) owner() string {
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.layer
+}
+
+// markLayer marks a subtree as built by a layer. Callers hold the world lock,
+// or own entities not yet in the world.
+func markLayer(entity *Entity, layer string) {
+	entity.layer = layer
+	for _, child := range entity.Children() {
+		markLayer(child, layer)
+	}
+}
+
+// buildIncludes resolves the layers a scene loads with it: every include not
+// marked for streaming. It touches no GL, so the background loader runs it on
+// a worker alongside buildSpecs, with the same root.
+func (sm *SceneManager) buildIncludes(loadedScene *scene.Scene, root string) ([]layerSpecs, error) {
+	var layers []layerSpecs
+	for _, include := range loadedScene.Includes {
+		if include.Stream {
+			continue
+		}
+		specs, err := sm.buildLayerSpecs(include.Path, root)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layerSpecs{path: scene.LayerKey(include.Path), specs: specs})
+	}
+	return layers, nil
+}
+
+func (sm *SceneManager) buildLayerSpecs(path, root string) ([]ObjectSpec, error) {
+	layer, err := scene.LoadLayer(path)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := sm.buildSpecs(layer, root)
+	if err != nil {
+		return nil, fmt.Errorf("layer %s: %w", path, err)
+	}
+	return specs, nil
+}
+
+// buildEntities builds specs into one flat list of entities, each marked with
+// layer. A failure releases whatever was already acquired. Frame loop only.
+func (sm *SceneManager) buildEntities(specs []ObjectSpec, layer string) ([]*Entity, error) {
+	entities := make([]*Entity, 0, len(specs))
+	for _, spec := range specs {
+		subtree, err := sm.app.BuildTree(spec)
+		if err != nil {
+			sm.app.releaseModels(entities)
+			return nil, err
+		}
+		markLayer(subtree[0], layer)
+		entities = append(entities, subtree...)
+	}
+	return entities, nil
+}
+
+// LoadAdditive loads a scene file as a layer, adding its objects to the world
+// without touching anything already in it. Like LoadScene it imports models
+// as it goes, so it must only be called from the frame loop.
+//
+// A file that includes others cannot be a layer; see scene.LoadLayer.
+func (sm *SceneManager) LoadAdditive(path string) error {
+	key := scene.LayerKey(path)
+	if key == scene.LayerKey(sm.CurrentScenePath()) {
+		return fmt.Errorf("%s is the current scene, not a layer", path)
+	}
+	if sm.isCell(key) {
+		return fmt.Errorf("%s is a streamed cell; it loads when the camera comes near", path)
+	}
+	if sm.HasLayer(key) {
+		return fmt.Errorf("layer %s is already loaded", path)
+	}
+
+	specs, err := sm.buildLayerSpecs(path, "")
+	if err != nil {
+		return err
+	}
+	entities, err := sm.buildEntities(specs, key)
+	if err != nil {
+		return fmt.Errorf("layer %s: %w", path, err)
+	}
+	for _, entity := range entities {
+		sm.app.World.Spawn(entity)
+	}
+
+	sm.mu.Lock()
+	sm.layers = append(sm.layers, key)
+	sm.mu.Unlock()
+
+	utils.Logger().Printf("Loaded layer %s (%d entities)", key, len(entities))
+	return nil
+}
+
+// Unload despawns everything a layer owns, releasing its models. Frame loop
+// only. A streamed cell cannot be unloaded this way; the streaming would only
+// load it straight back.
+//
+// The undo history is cleared, as it is by a scene change: edits recorded
+// against the layer's entities could no longer be undone, and a history that
+// fails half-way through an undo is worse than one that starts again.
+func (sm *SceneManager) Unload(path string) error {
+	key := scene.LayerKey(path)
+	if sm.isCell(key) {
+		return fmt.Errorf("%s is a streamed cell; it unloads when the camera moves away", path)
+	}
+	if !sm.HasLayer(key) {
+		return fmt.Errorf("layer %s is not loaded", path)
+	}
+
+	if sm.unloadLayer(key) > 0 && sm.app.History != nil {
+		sm.app.History.Clear()
+	}
+
+	utils.Logger().Printf("Unloaded layer %s", key)
+	return nil
+}
+
+// unloadLayer despawns a layer's trees and forgets the layer, returning how
+// many trees went.
+func (sm *SceneManager) unloadLayer(key string) int {
+	roots := sm.app.layerRoots(key)
+	for _, root := range roots {
+		if err := sm.app.DespawnTree(root); err != nil {
+			utils.Logger().Printf("Unloading layer %s: %v", key, err)
+		}
+	}
+
+	sm.mu.Lock()
+	sm.layers = slices.DeleteFunc(sm.layers, func(layer string) bool { return layer == key })
+	sm.mu.Unlock()
+	return len(roots)
+}
+
+// HasLayer reports whether the layer at path is loaded.
+func (sm *SceneManager) HasLayer(path string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return slices.Contains(sm.layers, scene.LayerKey(path))
+}
+
+// Layers lists the loaded layers in the order they were loaded.
+func (sm *SceneManager) Layers() []string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return slices.Clone(sm.layers)
+}
+
+// Includes is the current scene's include list, loaded or not.
+func (sm *SceneManager) Includes() []scene.Include {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return slices.Clone(sm.includes)
+}
+
+// includesForSave is the include list a save of the current scene writes: the
+// one it was loaded with, plus any layer loaded since that it does not
+// mention, so the saved scene comes back the way it looks now. Streamed cells
+// are not layers the user loaded and are written as cells instead. A layer
+// unloaded since is still written; the scene declared it, and an unload is a
+// runtime decision, not an edit to the scene.
+func (sm *SceneManager) includesForSave() []scene.Include {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	includes := slices.Clone(sm.includes)
+	for _, layer := range sm.layers {
+		if sm.isCell(layer) {
+			continue
+		}
+		declared := slices.ContainsFunc(includes, func(include scene.Include) bool {
+			return scene.LayerKey(include.Path) == layer
+		})
+		if !declared {
+			includes = append(includes, scene.Include{Path: layer})
+		}
+	}
+	return includes
+}
+
+// layerRoots lists the top-level entities a layer owns.
+func (a *App) layerRoots(layer string) []Handle {
+	var roots []Handle
+	a.World.Read(func(entities []*Entity) {
+		for _, entity := range entities {
+			if entity.Parent() == nil && entity.layer == layer {
+				roots = append(roots, entity.Handle())
+			}
+		}
+	})
+	return roots
+}
+
+// SaveLayer writes a loaded layer's objects back to its own file. SaveScene
+// writes only the scene's objects and the include that brings the layer in,
+// so this is how edits to a layer are kept.
+//
+// The file's skybox and camera are kept as they were: a layer does not use
+// them, and it has no live ones of its own to write instead. Frame loop only,
+// like SaveScene.
+func (a *App) SaveLayer(path string) error {
+	key := scene.LayerKey(path)
+	if !a.Scenes.HasLayer(key) {
+		return fmt.Errorf("layer %s is not loaded", path)
+	}
+
+	layer := &scene.Scene{}
+	if existing, err := scene.LoadLayer(key); err == nil {
+		layer = existing
+	}
+
+	var err error
+	layer.Objects = nil
+	a.World.Read(func(entities []*Entity) {
+		for _, entity := range entities {
+			if entity.Parent() != nil || entity.layer != key {
+				continue
+			}
+			var row scene.Object
+			row, err = a.describeForSave(entity)
+			if err != nil {
+				return
+			}
+			layer.Objects = append(layer.Objects, row)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := scene.Save(key, layer); err != nil {
+		return err
+	}
+	utils.Logger().Printf("Saved layer %s", key)
+	return nil
+}
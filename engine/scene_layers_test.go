@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"3d-engine/scene"
+)
+
+// layeredScene writes a scene with one layer loaded with it and one left for
+// streaming, and returns the paths of the scene and the two layers.
+func layeredScene(t *testing.T) (string, string, string) {
+	t.Helper()
+
+	directory := t.TempDir()
+	buildings := writeScene(t, directory, "buildings.yml", `version: 2
+objects:
+  - name: tower
+    children:
+      - name: beacon
+        components:
+          - type: PointLight
+`)
+	interiors := writeScene(t, directory, "interiors.yml", `version: 2
+objects:
+  - name: lamp
+    components:
+      - type: PointLight
+`)
+	path := writeScene(t, directory, "town.yml", `version: 2
+includes:
+  - path: `+buildings+`
+  - path: `+interiors+`
+    stream: true
+objects:
+  - name: ground
+    components:
+      - type: DirectionalLight
+`)
+	return path, buildings, interiors
+}
+
+func layerOf(t *testing.T, a *App, name string) string {
+	t.Helper()
+
+	entity := a.World.Find(name)
+	if entity == nil {
+		t.Fatalf("no object named %q", name)
+	}
+	info, _ := a.ObjectInfo(entity.Handle())
+	return info.Layer
+}
+
+func TestSceneLoadsIncludedLayers(t *testing.T) {
+	a := saveTestApp(t)
+	path, buildings, interiors := layeredScene(t)
+	loadAndPlace(t, a, path)
+
+	if got := layerOf(t, a, "ground"); got != "" {
+		t.Errorf("the scene's own object is in layer %q", got)
+	}
+	if got := layerOf(t, a, "beacon"); got != buildings {
+		t.Errorf("an included child is in layer %q, want %q", got, buildings)
+	}
+	if a.World.Find("lamp") != nil {
+		t.Error("a streamed include was loaded with the scene")
+	}
+	if got := a.Scenes.Layers(); !slices.Equal(got, []string{buildings}) {
+		t.Errorf("loaded layers %v", got)
+	}
+
+	if err := a.Scenes.LoadAdditive(interiors); err != nil {
+		t.Fatal(err)
+	}
+	if got := layerOf(t, a, "lamp"); got != interiors {
+		t.Errorf("the streamed layer's object is in layer %q", got)
+	}
+	if err := a.Scenes.LoadAdditive(interiors); err == nil {
+		t.Error("loading a layer twice should fail")
+	}
+}
+
+// TestBackgroundLoadIncludesLayers: a scene change at runtime goes through the
+// worker pipeline, not LoadScene, and has to bring the layers too.
+func TestBackgroundLoadIncludesLayers(t *testing.T) {
+	a := saveTestApp(t)
+	path, buildings, _ := layeredScene(t)
+
+	a.Scenes.RequestSceneChange(path)
+	if progress := pumpLoad(t, a); progress.Err != nil {
+		t.Fatal(progress.Err)
+	}
+	if got := layerOf(t, a, "tower"); got != buildings {
+		t.Errorf("tower is in layer %q after a background load", got)
+	}
+}
+
+func TestUnloadRemovesExactlyTheLayer(t *testing.T) {
+	a, h := historyApp(t)
+	path, buildings, _ := layeredScene(t)
+	loadAndPlace(t, a, path)
+
+	// Ownership follows the tree: a child added under the layer's object goes
+	// with the layer, and the layer's object moved under the scene's stays.
+	tower := a.World.Find("tower").Handle()
+	spawnLight(t, h, "antenna", tower)
+	if err := a.SetParent(a.World.Find("beacon").Handle(), a.World.Find("ground").Handle()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Scenes.Unload(buildings); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"tower", "antenna"} {
+		if a.World.Find(name) != nil {
+			t.Errorf("%q survived unloading its layer", name)
+		}
+	}
+	for _, name := range []string{"ground", "beacon"} {
+		if a.World.Find(name) == nil {
+			t.Errorf("%q went with a layer that does not own it", name)
+		}
+	}
+	if len(a.Scenes.Layers()) != 0 {
+		t.Errorf("layers after unload: %v", a.Scenes.Layers())
+	}
+	if h.UndoLabel() != "" {
+		t.Errorf("the history still offers %q after an unload", h.UndoLabel())
+	}
+	if err := a.Scenes.Unload(buildings); err == nil {
+		t.Error("unloading a layer that is not loaded should fail")
+	}
+}
+
+func TestSaveWritesIncludesNotLayerObjects(t *testing.T) {
+	a := saveTestApp(t)
+	path, buildings, interiors := layeredScene(t)
+	loadAndPlace(t, a, path)
+
+	extra := writeScene(t, t.TempDir(), "props.yml", `version: 2
+objects:
+  - name: crate-light
+    components:
+      - type: PointLight
+`)
+	if err := a.Scenes.LoadAdditive(extra); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := a.SceneSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Objects) != 1 || snapshot.Objects[0].Name != "ground" {
+		t.Errorf("the scene saved %+v, want only its own object", snapshot.Objects)
+	}
+	want := []scene.Include{{Path: buildings}, {Path: interiors, Stream: true}, {Path: extra}}
+	if !slices.Equal(snapshot.Includes, want) {
+		t.Errorf("includes saved as %+v, want %+v", snapshot.Includes, want)
+	}
+
+	saved := filepath.Join(t.TempDir(), "saved.yml")
+	if err := a.SaveScene(saved); err != nil {
+		t.Fatal(err)
+	}
+	loadAndPlace(t, a, saved)
+	for _, name := range []string{"ground", "tower", "crate-light"} {
+		if a.World.Find(name) == nil {
+			t.Errorf("%q is missing after reloading the save", name)
+		}
+	}
+}
+
+func TestSaveLayerKeepsEdits(t *testing.T) {
+	a, h := historyApp(t)
+	path, buildings, _ := layeredScene(t)
+	loadAndPlace(t, a, path)
+
+	spawnLight(t, h, "antenna", a.World.Find("tower").Handle())
+	if err := a.SaveLayer(buildings); err != nil {
+		t.Fatal(err)
+	}
+
+	layer, err := scene.LoadLayer(buildings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layer.Objects) != 1 || len(layer.Objects[0].Children) != 2 {
+		t.Errorf("saved layer %+v, want tower with beacon and antenna", layer.Objects)
+	}
+}
+
+// TestUndoDeleteKeepsLayer: a layer object deleted and brought back by undo
+// must come back in its layer, or the next unload would leave it behind and
+// the next save would write it into the scene.
+func TestUndoDeleteKeepsLayer(t *testing.T) {
+	a, h := historyApp(t)
+	path, buildings, _ := layeredScene(t)
+	loadAndPlace(t, a, path)
+
+	if err := h.DespawnTree(a.World.Find("tower").Handle()); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := layerOf(t, a, "tower"); got != buildings {
+		t.Errorf("tower came back in layer %q", got)
+	}
+}
+
+func TestLoadAdditiveRefusesNestedIncludes(t *testing.T) {
+	a := saveTestApp(t)
+	path, _, _ := layeredScene(t)
+	if err := a.Scenes.LoadAdditive(path); err == nil {
+		t.Error("a scene with includes should not load as a layer")
+	}
+}
@@ -0,0 +1,455 @@
+package engine
+
+import (
+	"3d-engine/assets"
+	"3d-engine/object"
+	"3d-engine/scene"
+	"3d-engine/utils"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadStage is where an asynchronous scene change has got to.
+type LoadStage int
+
+const (
+	LoadIdle LoadStage = iota
+	// LoadParsing reads the scene file and builds the object specs.
+	LoadParsing
+	// LoadAssets parses models and decodes textures on workers while the
+	// frame loop uploads whatever is ready, a budget's worth per frame.
+	LoadAssets
+	// LoadDone and LoadFailed are terminal. A superseded load reports
+	// LoadFailed with ErrLoadSuperseded.
+	LoadDone
+	LoadFailed
+)
+
+func (s LoadStage) String() string {
+	switch s {
+	case LoadParsing:
+		return "parsing"
+	case LoadAssets:
+		return "loading assets"
+	case LoadDone:
+		return "done"
+	case LoadFailed:
+		return "failed"
+	}
+	return "idle"
+}
+
+// ErrLoadSuperseded is the error a scene load reports when a newer request
+// replaced it before it finished.
+var ErrLoadSuperseded = fmt.Errorf("superseded by a newer scene request")
+
+// LoadProgress is a snapshot of the current (or last) scene change.
+type LoadProgress struct {
+	Path  string
+	Stage LoadStage
+
+	// Models is how many distinct models the scene uses; Prepared and
+	// Uploaded count how far each half of the pipeline has got through them.
+	Models   int
+	Prepared int
+	Uploaded int
+
+	Err error
+}
+
+// Fraction is the load's completion in [0, 1], weighting the worker half and
+// the upload half equally.
+func (p LoadProgress) Fraction() float32 {
+	switch {
+	case p.Stage == LoadDone:
+		return 1
+	case p.Models == 0:
+		return 0
+	}
+	return float32(p.Prepared+p.Uploaded) / float32(2*p.Models)
+}
+
+// Active reports whether the load is still running.
+func (p LoadProgress) Active() bool {
+	return p.Stage == LoadParsing || p.Stage == LoadAssets
+}
+
+// sceneLoad is one asynchronous scene change in flight, or one streamed cell
+// on its way in; see streaming.go.
+//
+// Work moves in one direction: a worker goroutine parses the scene and
+// prepares its models, handing each prepared model to the frame loop with
+// App.Defer; the frame loop uploads and commits them under the per-frame
+// budget; a final deferred command swaps the scene in once nothing is pending.
+// Everything touching GL or the asset cache's holds happens in those deferred
+// commands, so the frame loop is the only goroutine that ever does.
+type sceneLoad struct {
+	path string
+	mode string
+
+	// cancelled is set when a newer request supersedes this one.
+	cancelled atomic.Bool
+	// pending counts prepared models handed to the frame loop and not yet
+	// committed or discarded. The final swap waits for it to reach zero, so
+	// no upload can land after the load has released its holds.
+	pending atomic.Int32
+
+	mu       sync.Mutex
+	progress LoadProgress
+
+	// document is the scene itself, for a load that was handed one instead of
+	// a file to read; path is then only what it will be known by. root, when
+	// set, is the directory every model the load reads must be in; see
+	// scene_root.go.
+	document *scene.Scene
+	root     string
+
+	// Set by the worker before it defers finish; read only by finish.
+	loaded *scene.Scene
+	specs  []ObjectSpec
+	layers []layerSpecs
+
+	// layer is set for a streamed cell's load, which spawns its objects into
+	// that layer rather than replacing the scene. next and spawned track how
+	// far spawnCell has got. Frame loop only.
+	layer   string
+	next    int
+	spawned []Handle
+
+	// committed holds one cache reference per model this load uploaded,
+	// keeping them resident until the new scene's entities take their own.
+	// Frame loop only.
+	committed []*object.Model
+}
+
+func newSceneLoad(path, mode string) *sceneLoad {
+	load := &sceneLoad{path: path, mode: mode}
+	load.progress = LoadProgress{Path: path, Stage: LoadParsing}
+	return load
+}
+
+func (l *sceneLoad) snapshot() LoadProgress {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.progress
+}
+
+func (l *sceneLoad) update(fn func(p *LoadProgress)) {
+	l.mu.Lock()
+	fn(&l.progress)
+	l.mu.Unlock()
+}
+
+// fail records the first error; later ones are consequences of it.
+func (l *sceneLoad) fail(err error) {
+	l.update(func(p *LoadProgress) {
+		if p.Err == nil {
+			p.Err = err
+			p.Stage = LoadFailed
+		}
+	})
+}
+
+func (l *sceneLoad) failed() bool {
+	return l.snapshot().Err != nil
+}
+
+// stopped means no further work should be started for this load.
+func (l *sceneLoad) stopped() bool {
+	return l.cancelled.Load() || l.failed()
+}
+
+// releaseCommitted drops the load's holds. Frame loop only.
+func (l *sceneLoad) releaseCommitted(a *App) {
+	for _, model := range l.committed {
+		if err := a.Assets.Release(model); err != nil {
+			utils.Logger().Printf("Releasing model %s: %v", model.Path, err)
+		}
+	}
+	l.committed = nil
+}
+
+// startLoad begins an asynchronous change to scenePath, superseding any load
+// already running. The current scene keeps rendering until the new one is
+// completely uploaded.
+func (sm *SceneManager) startLoad(scenePath, sceneMode string) {
+	sm.begin(newSceneLoad(scenePath, sceneMode))
+}
+
+// begin makes load the newest scene change and starts its worker.
+func (sm *SceneManager) begin(load *sceneLoad) {
+	sm.mu.Lock()
+	previous := sm.loading
+	sm.loading = load
+	sm.mu.Unlock()
+
+	if previous != nil {
+		previous.cancelled.Store(true)
+	}
+
+	go sm.prepareLoad(load)
+}
+
+// LoadProgress reports the scene change in flight, or the last one to finish.
+// Safe from any goroutine.
+func (sm *SceneManager) LoadProgress() LoadProgress {
+	sm.mu.Lock()
+	load := sm.loading
+	sm.mu.Unlock()
+
+	if load == nil {
+		return LoadProgress{Stage: LoadIdle}
+	}
+	return load.snapshot()
+}
+
+// prepareLoad is the worker half. It always ends by deferring finishLoad, so
+// whatever happened, the frame loop gets to clean up.
+func (sm *SceneManager) prepareLoad(load *sceneLoad) {
+	defer sm.app.Defer(func(a *App) error {
+		return sm.finishLoad(a, load)
+	})
+
+	loaded := load.document
+	if loaded == nil {
+		read := scene.Load
+		if load.layer != "" {
+			read = scene.LoadLayer
+		}
+		var err error
+		if loaded, err = read(load.path); err != nil {
+			load.fail(err)
+			return
+		}
+		// A file read under a scene root — a client's LOAD_SCENE, or a
+		// streamed cell of a confined scene — names only what it may, as a
+		// document handed over is checked before its load starts.
+		if load.root != "" {
+			if err := sceneInsideRoot(load.root, loaded); err != nil {
+				load.fail(fmt.Errorf("%s: %w", load.path, err))
+				return
+			}
+		}
+	}
+	specs, err := sm.buildSpecs(loaded, load.root)
+	if err != nil {
+		load.fail(err)
+		return
+	}
+	var layers []layerSpecs
+	if load.layer == "" {
+		layers, err = sm.buildIncludes(loaded, load.root)
+		if err != nil {
+			load.fail(err)
+			return
+		}
+	}
+	load.loaded = loaded
+	load.specs = specs
+	load.layers = layers
+
+	everything := slices.Clone(specs)
+	for _, layer := range layers {
+		everything = append(everything, layer.specs...)
+	}
+	paths := modelPaths(everything)
+	if load.root != "" {
+		// Checked here rather than up front because this is the first point
+		// the models brought in by prefabs and included layers are known.
+		for _, path := range paths {
+			if err := insideRoot(load.root, "model", path); err != nil {
+				load.fail(err)
+				return
+			}
+		}
+	}
+	load.update(func(p *LoadProgress) {
+		p.Stage = LoadAssets
+		p.Models = len(paths)
+	})
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < sm.app.loadWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				if load.stopped() {
+					continue
+				}
+				prepared, err := sm.app.Assets.Prepare(path)
+				if err != nil {
+					load.fail(fmt.Errorf("could not load model %q: %w", path, err))
+					continue
+				}
+				load.update(func(p *LoadProgress) { p.Prepared++ })
+				load.pending.Add(1)
+				sm.app.Defer(func(a *App) error {
+					return sm.uploadModel(a, load, prepared)
+				})
+			}
+		}()
+	}
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// uploadModel feeds one prepared model to the GPU until the frame's upload
+// budget runs out, then queues itself for the next frame.
+func (sm *SceneManager) uploadModel(a *App, load *sceneLoad, prepared *assets.Prepared) error {
+	if load.stopped() {
+		prepared.Discard()
+		load.pending.Add(-1)
+		return nil
+	}
+
+	for a.uploadBudgetLeft() {
+		done, err := prepared.Step()
+		a.spendUploadBudget()
+		if err != nil {
+			prepared.Discard()
+			load.fail(fmt.Errorf("could not upload model %q: %w", prepared.Path, err))
+			load.pending.Add(-1)
+			return nil
+		}
+		if !done {
+			continue
+		}
+
+		model, err := a.Assets.Commit(prepared)
+		load.pending.Add(-1)
+		if err != nil {
+			load.fail(fmt.Errorf("could not upload model %q: %w", prepared.Path, err))
+			return nil
+		}
+		load.committed = append(load.committed, model)
+		load.update(func(p *LoadProgress) { p.Uploaded++ })
+		return nil
+	}
+
+	a.Defer(func(a *App) error {
+		return sm.uploadModel(a, load, prepared)
+	})
+	return nil
+}
+
+// finishLoad swaps the new scene in once every model is resident, or cleans
+// up after a load that failed or was superseded. A cell's load goes on to
+// spawnCell instead.
+func (sm *SceneManager) finishLoad(a *App, load *sceneLoad) error {
+	if load.pending.Load() > 0 {
+		a.Defer(func(a *App) error {
+			return sm.finishLoad(a, load)
+		})
+		return nil
+	}
+
+	if load.layer != "" {
+		return sm.spawnCell(a, load)
+	}
+
+	// A replay swaps the scene in on the frame the recording did; see
+	// replay.go.
+	if !load.stopped() {
+		if _, due := a.loadDue(loadStep{Scene: load.path}); !due {
+			a.Defer(func(a *App) error {
+				return sm.finishLoad(a, load)
+			})
+			return nil
+		}
+	}
+
+	// The entities built below take their own holds, so the load's can go
+	// whatever the outcome.
+	defer load.releaseCommitted(a)
+
+	if load.cancelled.Load() {
+		load.fail(ErrLoadSuperseded)
+		return nil
+	}
+	if err := load.snapshot().Err; err != nil {
+		return fmt.Errorf("failed to switch scene: %w", err)
+	}
+
+	if err := sm.install(load.loaded, load.specs, load.layers, load.path, load.root); err != nil {
+		load.fail(err)
+		return fmt.Errorf("failed to switch scene: %w", err)
+	}
+
+	if load.mode != "" {
+		sm.mu.Lock()
+		sm.currentSceneMode = load.mode
+		sm.mu.Unlock()
+	}
+	load.update(func(p *LoadProgress) { p.Stage = LoadDone })
+	a.recordLoad(loadStep{Scene: load.path})
+
+	utils.Logger().Printf("Switched scene to %s", load.path)
+	a.resetDynamicState()
+	return nil
+}
+
+// modelPaths lists the distinct model paths a set of specs uses, in order.
+func modelPaths(specs []ObjectSpec) []string {
+	var paths []string
+	seen := map[string]bool{}
+
+	var walk func(spec *ObjectSpec)
+	walk = func(spec *ObjectSpec) {
+		if spec.Model != "" && !seen[spec.Model] {
+			seen[spec.Model] = true
+			paths = append(paths, spec.Model)
+		}
+		for i := range spec.Children {
+			walk(&spec.Children[i])
+		}
+	}
+	for i := range specs {
+		walk(&specs[i])
+	}
+	return paths
+}
+
+// loadWorkers is how many models are prepared in parallel. Parsing is CPU
+// bound, but assimp and stb are memory hungry on big assets, so it is capped
+// rather than one per core on a large machine.
+func (a *App) loadWorkers() int {
+	if a.Config != nil && a.Config.Assets.LoadWorkers > 0 {
+		return a.Config.Assets.LoadWorkers
+	}
+	return min(runtime.NumCPU(), 4)
+}
+
+// uploadBudget is the per-frame time slice for asset uploads.
+func (a *App) uploadBudget() time.Duration {
+	if a.Config != nil && a.Config.Assets.UploadBudgetMs > 0 {
+		return time.Duration(a.Config.Assets.UploadBudgetMs * float32(time.Millisecond))
+	}
+	return 4 * time.Millisecond
+}
+
+// beginUploadBudget opens this frame's upload window. Called from
+// drainCommands, before any deferred upload runs.
+func (a *App) beginUploadBudget() {
+	a.uploadDeadline = time.Now().Add(a.uploadBudget())
+	a.uploadStepped = false
+}
+
+// uploadBudgetLeft reports whether another upload step fits in this frame.
+// The first step always fits, so a single mesh bigger than the whole budget
+// still makes progress instead of stalling the load forever.
+func (a *App) uploadBudgetLeft() bool {
+	return !a.uploadStepped || time.Now().Before(a.uploadDeadline)
+}
+
+func (a *App) spendUploadBudget() {
+	a.uploadStepped = true
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pumpLoad runs the frame loop's half of a background load — draining
+// deferred commands — until the newest load has finished one way or the other.
+// Like the save tests, the scenes here carry no models, so the workers and the
+// hand-off are exercised without needing a GL context for the uploads.
+func pumpLoad(t *testing.T, a *App) LoadProgress {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		a.drainCommands()
+		if progress := a.Scenes.LoadProgress(); !progress.Active() {
+			// The newest load may finish while an older one it cancelled still
+			// has its clean-up queued; drain once more so none is left over.
+			a.drainCommands()
+			return progress
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("scene load did not finish")
+	return LoadProgress{}
+}
+
+func writeScene(t *testing.T, directory, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(directory, name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	return path
+}
+
+func TestRequestSceneChangeLoadsInBackground(t *testing.T) {
+	path := writeScene(t, t.TempDir(), "scene.yml", hierarchyScene)
+
+	a := saveTestApp(t)
+	a.Scenes.RequestSceneChange(path)
+
+	progress := pumpLoad(t, a)
+	if progress.Stage != LoadDone || progress.Err != nil {
+		t.Fatalf("load ended %s: %v", progress.Stage, progress.Err)
+	}
+	if progress.Fraction() != 1 {
+		t.Errorf("finished load reports %v complete", progress.Fraction())
+	}
+	if got := a.Scenes.CurrentScenePath(); got != path {
+		t.Errorf("current scene = %q, want %q", got, path)
+	}
+	if got := a.World.Len(); got != 5 {
+		t.Errorf("world has %d entities, want 5", got)
+	}
+}
+
+func TestNewerSceneRequestWins(t *testing.T) {
+	directory := t.TempDir()
+	first := writeScene(t, directory, "first.yml", hierarchyScene)
+	second := writeScene(t, directory, "second.yml", roundTripScene)
+
+	a := saveTestApp(t)
+	a.Scenes.RequestSceneChange(first)
+	a.Scenes.RequestSceneChange(second)
+
+	if progress := pumpLoad(t, a); progress.Stage != LoadDone {
+		t.Fatalf("load ended %s: %v", progress.Stage, progress.Err)
+	}
+	if got := a.Scenes.CurrentScenePath(); got != second {
+		t.Errorf("current scene = %q, want %q", got, second)
+	}
+	if got := a.World.Len(); got != 3 {
+		t.Errorf("world has %d entities, want the second scene's 3", got)
+	}
+}
+
+func TestFailedLoadKeepsCurrentScene(t *testing.T) {
+	directory := t.TempDir()
+	good := writeScene(t, directory, "good.yml", hierarchyScene)
+	bad := writeScene(t, directory, "bad.yml", "version: 2\nobjects:\n  - name: x\n    components:\n      - type: NoSuchComponent\n")
+
+	a := saveTestApp(t)
+	loadAndPlace(t, a, good)
+
+	a.Scenes.RequestSceneChange(bad)
+	progress := pumpLoad(t, a)
+	if progress.Stage != LoadFailed || progress.Err == nil {
+		t.Fatalf("load ended %s with error %v, want a failure", progress.Stage, progress.Err)
+	}
+	if errors.Is(progress.Err, ErrLoadSuperseded) {
+		t.Errorf("failure reported as superseded: %v", progress.Err)
+	}
+	if got := a.Scenes.CurrentScenePath(); got != good {
+		t.Errorf("current scene = %q, want it left at %q", got, good)
+	}
+	if got := a.World.Len(); got != 5 {
+		t.Errorf("world has %d entities, want the original 5", got)
+	}
+}
+
+func TestModelPathsAreDistinctAndNested(t *testing.T) {
+	specs := []ObjectSpec{
+		{Model: "a.obj", Children: []ObjectSpec{{Model: "b.obj"}, {}}},
+		{Model: "a.obj"},
+		{Children: []ObjectSpec{{Children: []ObjectSpec{{Model: "c.obj"}}}}},
+	}
+
+	got := modelPaths(specs)
+	want := []string{"a.obj", "b.obj", "c.obj"}
+	if len(got) != len(want) {
+		t.Fatalf("modelPaths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("modelPaths = %v, want %v", got, want)
+		}
+	}
+}
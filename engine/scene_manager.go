@@ -10,9 +10,10 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-// SceneManager owns the current scene selection. Because LoadScene uploads
-// meshes and textures to the GPU, changes requested from other goroutines are
-// queued onto the frame loop through App.Defer.
+// SceneManager owns the current scene selection. Scene changes load in the
+// background: files are parsed on worker goroutines, GPU uploads are fed to the
+// frame loop through App.Defer a few milliseconds at a time, and the old scene
+// keeps rendering until the new one is ready to swap in.
 type SceneManager struct {
 	app *App
 
@@ -23,15 +24,22 @@ type SceneManager struct {
 	defaultSceneMode string
 	fallbackScene    string
 
-	// requested* hold the latest pending request. Only one command is ever in
-	// flight, so spamming scene changes during a slow load still loads once,
-	// with the newest request winning.
-	requestQueued bool
-	requestedPath string
-	requestedMode string
+	// loading is the newest scene change, in flight or finished. Starting
+	// another cancels it, so spamming scene changes during a slow load wastes
+	// at most the work already under way and the newest request wins.
+	loading *sceneLoad
 
 	// cameraSpawn is where the current scene puts the camera on load and reset.
 	cameraSpawn scene.CameraSpec
+
+	// layers are the loaded layers, in the order they were loaded, and
+	// includes the current scene's include list; see scene_layers.go.
+	layers   []string
+	includes []scene.Include
+
+	// streamer is nil unless the current scene declares cells; see
+	// streaming.go. Frame loop only.
+	streamer *cellStreamer
 }
 
 func NewSceneManager(app *App, config *utils.Config, fallbackScenePath string) *SceneManager {
@@ -53,37 +61,75 @@ func NewSceneManager(app *App, config *utils.Config, fallbackScenePath string) *
 	}
 }
 
-// LoadScene imports every object in the scene file and swaps it in. It uploads
-// to the GPU, so it must only be called from the goroutine running the frame
-// loop.
+// LoadScene imports every object in the scene file and swaps it in, all in one
+// go. It uploads to the GPU, so it must only be called from the goroutine
+// running the frame loop. Startup uses it, since there is no scene to keep
+// showing; changes at runtime go through RequestSceneChange, which loads in the
+// background instead of freezing the frame.
 func (sm *SceneManager) LoadScene(scenePath string) error {
 	loadedScene, err := scene.Load(scenePath)
 	if err != nil {
 		return err
 	}
 
-	// Build the new scene before tearing down the old one. If a model fails to
-	// import we release only what this attempt acquired and leave the running
-	// scene untouched, rather than unloading it and having nothing to show.
-	entities := make([]*Entity, 0, len(loadedScene.Objects))
+	specs, err := sm.buildSpecs(loadedScene, "")
+	if err != nil {
+		return err
+	}
+	layers, err := sm.buildIncludes(loadedScene, "")
+	if err != nil {
+		return err
+	}
 
-	for i := range loadedScene.Objects {
-		obj := &loadedScene.Objects[i]
+	return sm.install(loadedScene, specs, layers, scenePath, "")
+}
 
-		spec, err := sm.buildSpec(obj)
+// buildSpecs resolves every top-level object of a scene. It touches no GL, so
+// the background loader runs it on a worker. With root set, a prefab outside
+// it is refused before it is read, at whatever depth of nesting it is named.
+func (sm *SceneManager) buildSpecs(loadedScene *scene.Scene, root string) ([]ObjectSpec, error) {
+	// One read of each prefab file per load, however many instances of it the
+	// scene places.
+	load := cachedPrefabs(prefabsInsideRoot(root, scene.LoadPrefab))
+
+	specs := make([]ObjectSpec, 0, len(loadedScene.Objects))
+	for i := range loadedScene.Objects {
+		spec, err := sm.buildSpecWith(&loadedScene.Objects[i], load)
 		if err != nil {
-			sm.app.releaseModels(entities)
-			return err
+			return nil, err
 		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
 
-		// One flat list of every entity in the scene, however deep: the tree is
-		// in their parent pointers, and World.Replace wants the lot.
-		subtree, err := sm.app.BuildTree(spec)
+// install builds the entities for specs, and for the layers the scene
+// includes, and swaps them in for the current scene and all its layers. Frame
+// loop only. When the background loader calls it every model is already
+// resident, so this is just cache hits and entity bookkeeping.
+//
+// root is the scene root the scene was confined to, if any. The scene's cells
+// load long after this, and are held to it as well.
+func (sm *SceneManager) install(loadedScene *scene.Scene, specs []ObjectSpec, layers []layerSpecs, scenePath, root string) error {
+	// Build the new scene before tearing down the old one. If a model fails to
+	// import we release only what this attempt acquired and leave the running
+	// scene untouched, rather than unloading it and having nothing to show.
+	//
+	// One flat list of every entity in the scene, however deep: the tree is in
+	// their parent pointers, and World.Replace wants the lot.
+	entities, err := sm.buildEntities(specs, "")
+	if err != nil {
+		return err
+	}
+	loadedLayers := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		built, err := sm.buildEntities(layer.specs, layer.path)
 		if err != nil {
 			sm.app.releaseModels(entities)
-			return err
+			return fmt.Errorf("layer %s: %w", layer.path, err)
 		}
-		entities = append(entities, subtree...)
+		entities = append(entities, built...)
+		loadedLayers = append(loadedLayers, layer.path)
 	}
 
 	// Hold a reference to the outgoing scene's models until after the swap, so
@@ -95,6 +141,11 @@ func (sm *SceneManager) LoadScene(scenePath string) error {
 
 	sm.app.releaseModels(outgoing)
 
+	// Every handle the history recorded died in the swap.
+	if sm.app.History != nil {
+		sm.app.History.Clear()
+	}
+
 	if err := sm.app.setSkybox(loadedScene.Skybox); err != nil {
 		utils.Logger().Printf("Loading skybox: %v", err)
 	}
@@ -103,8 +154,12 @@ func (sm *SceneManager) LoadScene(scenePath string) error {
 	sm.currentScenePath = scenePath
 	sm.currentSceneMode = sm.resolveModeFromPath(scenePath)
 	sm.cameraSpawn = loadedScene.ResolveCamera()
+	sm.layers = loadedLayers
+	sm.includes = loadedScene.Includes
 	sm.mu.Unlock()
 
+	sm.startStreaming(loadedScene.Cells, root)
+
 	return nil
 }
 
@@ -121,12 +176,28 @@ func (sm *SceneManager) CameraSpawn() scene.CameraSpec {
 //
 // It resolves components but touches no assets, which keeps the GL work in
 // BuildTree and makes this half testable on its own.
+//
+// Prefab instances anywhere in the row are expanded first, reading the prefab
+// files they name.
 func (sm *SceneManager) buildSpec(obj *scene.Object) (ObjectSpec, error) {
+	return sm.buildSpecWith(obj, scene.LoadPrefab)
+}
+
+func (sm *SceneManager) buildSpecWith(obj *scene.Object, load scene.PrefabLoader) (ObjectSpec, error) {
+	expanded, err := scene.Expand(*obj, load)
+	if err != nil {
+		return ObjectSpec{}, fmt.Errorf("object %q: %w", obj.Name, err)
+	}
+	return sm.buildExpanded(&expanded)
+}
+
+func (sm *SceneManager) buildExpanded(obj *scene.Object) (ObjectSpec, error) {
 	transform := obj.ResolveTransform()
 
 	spec := ObjectSpec{
-		Name:  obj.Name,
-		Model: obj.Model,
+		Name:   obj.Name,
+		Model:  obj.Model,
+		Prefab: obj.From,
 		Transform: Transform{
 			Position: mgl32.Vec3(transform.Position),
 			// The scene file's axis-angle becomes a quaternion here, at the
@@ -171,7 +242,7 @@ func (sm *SceneManager) buildSpec(obj *scene.Object) (ObjectSpec, error) {
 	}
 
 	for i := range obj.Children {
-		child, err := sm.buildSpec(&obj.Children[i])
+		child, err := sm.buildExpanded(&obj.Children[i])
 		if err != nil {
 			return ObjectSpec{}, err
 		}
@@ -228,10 +299,40 @@ func (sm *SceneManager) ListModeNames() []string {
 	return names
 }
 
-// RequestSceneChange queues a scene load onto the frame loop. Safe to call from
-// any goroutine.
+// RequestSceneChange starts loading a scene in the background. Safe to call
+// from any goroutine; LoadProgress reports how far it has got.
 func (sm *SceneManager) RequestSceneChange(scenePath string) {
-	sm.request(scenePath, "")
+	sm.startLoad(scenePath, "")
+}
+
+// RequestSceneChangeWithin is RequestSceneChange for a scene that, with
+// everything it names, must be inside root: the scene root an RPC client's
+// LOAD_SCENE is confined to. The path itself is the caller's to check; what the
+// file names is checked as the load reads it.
+func (sm *SceneManager) RequestSceneChangeWithin(scenePath, root string) {
+	load := newSceneLoad(scenePath, "")
+	load.root = root
+	sm.begin(load)
+}
+
+// RequestSceneDocument starts loading a scene that was handed over rather than
+// read from a file, as RequestSceneChange does one that is. path is what the
+// scene is known by once loaded, and may be empty. With root set, the scene
+// may only name files inside it; see scene_root.go. A scene that names one
+// outside is refused here, and one whose prefabs, layers or cells bring in a
+// file from outside fails as its load gets to them.
+func (sm *SceneManager) RequestSceneDocument(document *scene.Scene, path, root string) error {
+	if root != "" {
+		if err := sceneInsideRoot(root, document); err != nil {
+			return err
+		}
+	}
+
+	load := newSceneLoad(path, "")
+	load.document = document
+	load.root = root
+	sm.begin(load)
+	return nil
 }
 
 // RequestSceneModeChange resolves the mode and queues its scene. Unlike the
@@ -246,52 +347,7 @@ func (sm *SceneManager) RequestSceneModeChange(sceneMode string) error {
 		return fmt.Errorf("scene mode %q is not configured", sceneMode)
 	}
 
-	sm.request(scenePath, sceneMode)
-	return nil
-}
-
-func (sm *SceneManager) request(scenePath, sceneMode string) {
-	sm.mu.Lock()
-	sm.requestedPath = scenePath
-	sm.requestedMode = sceneMode
-	alreadyQueued := sm.requestQueued
-	sm.requestQueued = true
-	sm.mu.Unlock()
-
-	// A command is already in flight and will pick up the newest request, so
-	// queueing another would just load twice.
-	if alreadyQueued {
-		return
-	}
-
-	sm.app.Defer(sm.applyRequest)
-}
-
-func (sm *SceneManager) applyRequest(a *App) error {
-	sm.mu.Lock()
-	scenePath := sm.requestedPath
-	sceneMode := sm.requestedMode
-	sm.requestedPath = ""
-	sm.requestedMode = ""
-	sm.requestQueued = false
-	sm.mu.Unlock()
-
-	if scenePath == "" {
-		return nil
-	}
-
-	if err := sm.LoadScene(scenePath); err != nil {
-		return fmt.Errorf("failed to switch scene: %w", err)
-	}
-
-	if sceneMode != "" {
-		sm.mu.Lock()
-		sm.currentSceneMode = sceneMode
-		sm.mu.Unlock()
-	}
-
-	utils.Logger().Printf("Switched scene to %s", scenePath)
-	a.resetDynamicState()
+	sm.startLoad(scenePath, sceneMode)
 	return nil
 }
 
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"3d-engine/scene"
+)
+
+// A scene root is the directory an RPC client's scene files are confined to;
+// see utils.RPCConfig.SceneRoot. A scene sent inline could otherwise name any
+// file the engine can read as its model, skybox or layer, and a save could
+// write anywhere the engine can.
+//
+// The check is on paths, the way the engine will open them: relative ones
+// against the working directory, cleaned, with no ".." left climbing out.
+// It does not follow symlinks. The root is the operator's directory, and a
+// link they put in it goes where they meant it to.
+
+// insideRoot refuses path unless it is in root. what names the path in the
+// error.
+func insideRoot(root, what, path string) error {
+	absoluteRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("scene root %s: %w", root, err)
+	}
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", what, path, err)
+	}
+
+	relative, err := filepath.Rel(absoluteRoot, absolute)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s %s is outside the scene root %s", what, path, root)
+	}
+	return nil
+}
+
+// sceneInsideRoot refuses a scene that names a file outside root: its skybox,
+// its layers and cells, and the models and prefabs of its objects, at every
+// depth. What those files name in turn is for the load to check, as it reads
+// them.
+func sceneInsideRoot(root string, document *scene.Scene) error {
+	if document.Skybox != "" {
+		if err := insideRoot(root, "skybox", document.Skybox); err != nil {
+			return err
+		}
+	}
+	for _, include := range document.Includes {
+		if err := insideRoot(root, "include", include.Path); err != nil {
+			return err
+		}
+	}
+	for _, cell := range document.Cells {
+		if err := insideRoot(root, "cell", cell.Path); err != nil {
+			return err
+		}
+	}
+	return objectsInsideRoot(root, document.Objects)
+}
+
+func objectsInsideRoot(root string, objects []scene.Object) error {
+	for i := range objects {
+		object := &objects[i]
+
+		if object.Model != "" {
+			if err := insideRoot(root, "model", object.Model); err != nil {
+				return fmt.Errorf("object %q: %w", object.Name, err)
+			}
+		}
+		if object.Prefab != "" {
+			if err := insideRoot(root, "prefab", object.Prefab); err != nil {
+				return fmt.Errorf("object %q: %w", object.Name, err)
+			}
+		}
+		if err := objectsInsideRoot(root, object.Children); err != nil {
+			return err
+		}
+		for _, override := range object.Overrides {
+			if err := objectsInsideRoot(root, override.Children); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prefabsInsideRoot wraps load so it refuses a prefab outside root before
+// reading it. A prefab a scene names directly is checked with the scene, but
+// one that prefab names in turn is only known once the first is read. An
+// empty root leaves load as it is.
+func prefabsInsideRoot(root string, load scene.PrefabLoader) scene.PrefabLoader {
+	if root == "" {
+		return load
+	}
+	return func(path string) (*scene.Prefab, error) {
+		if err := insideRoot(root, "prefab", path); err != nil {
+			return nil, err
+		}
+		return load(path)
+	}
+}
@@ -41,6 +41,10 @@ func (a *App) SceneSnapshot() (*scene.Scene, error) {
 		}
 	}
 	snapshot.Camera = &camera
+	if a.Scenes != nil {
+		snapshot.Includes = a.Scenes.includesForSave()
+		snapshot.Cells = a.Scenes.cellsForSave()
+	}
 
 	var err error
 	a.World.Read(func(entities []*Entity) {
@@ -54,6 +58,11 @@ func (a *App) SceneSnapshot() (*scene.Scene, error) {
 			if entity.Parent() != nil {
 				continue
 			}
+			// A layer's objects are in the layer's file, brought back by the
+			// include written below; SaveLayer writes them.
+			if entity.layer != "" {
+				continue
+			}
 
 			var row scene.Object
 
@@ -91,20 +100,47 @@ func (a *App) SaveScene(path string) error {
 	return nil
 }
 
-// describeForSave turns one entity into its scene-file row.
+// describeForSave turns one entity into its scene-file row. The root of a
+// prefab instance is written as a reference to the prefab plus what the
+// instance changes, not as the tree it expanded to; see describeInstance.
 func (a *App) describeForSave(entity *Entity) (scene.Object, error) {
-	transform := entity.Transform()
+	if entity.prefab != "" {
+		row, ok, err := a.describeInstance(entity)
+		if err != nil {
+			return scene.Object{}, err
+		}
+		if ok {
+			return row, nil
+		}
+	}
+	return a.describeTree(entity, a.describeForSave)
+}
+
+// describeTree is an entity's own row with each child described by child.
+func (a *App) describeTree(entity *Entity, child func(*Entity) (scene.Object, error)) (scene.Object, error) {
+	row, err := a.describeSelf(entity)
+	if err != nil {
+		return scene.Object{}, err
+	}
 
+	// Recurses, so a subtree of any depth comes out nested the way a person would
+	// have written it.
+	for _, c := range entity.Children() {
+		childRow, err := child(c)
+		if err != nil {
+			return scene.Object{}, err
+		}
+		row.Children = append(row.Children, childRow)
+	}
+
+	return row, nil
+}
+
+// describeSelf is an entity's row without its children.
+func (a *App) describeSelf(entity *Entity) (scene.Object, error) {
 	row := scene.Object{
-		Name: entity.Name,
-		Transform: &scene.TransformSpec{
-			Position: [3]float32(transform.Position),
-			// Back to axis-angle for the file. The pair written is not
-			// necessarily the one the scene was authored with — it is the
-			// canonical one naming the same rotation.
-			Rotation: [4]float32(AxisAngleFromQuat(transform.Rotation)),
-			Scale:    [3]float32(transform.Scale),
-		},
+		Name:      entity.Name,
+		Transform: transformSpec(entity.Transform()),
 	}
 
 	// The path the model was imported with, not the asset cache's absolute key,
@@ -134,19 +170,20 @@ func (a *App) describeForSave(entity *Entity) (scene.Object, error) {
 		row.Components = append(row.Components, spec)
 	}
 
-	// Recurses, so a subtree of any depth comes out nested the way a person would
-	// have written it.
-	for _, child := range entity.Children() {
-		childRow, err := a.describeForSave(child)
-		if err != nil {
-			return scene.Object{}, err
-		}
-		row.Children = append(row.Children, childRow)
-	}
-
 	return row, nil
 }
 
+func transformSpec(transform Transform) *scene.TransformSpec {
+	return &scene.TransformSpec{
+		Position: [3]float32(transform.Position),
+		// Back to axis-angle for the file. The pair written is not
+		// necessarily the one the scene was authored with — it is the
+		// canonical one naming the same rotation.
+		Rotation: [4]float32(AxisAngleFromQuat(transform.Rotation)),
+		Scale:    [3]float32(transform.Scale),
+	}
+}
+
 // describeComponent writes a live component back out under the name a scene file
 // would use to ask for it.
 //
@@ -161,19 +198,26 @@ func (a *App) describeComponent(entity *Entity, component Component) (scene.Comp
 			entity.Name, reflect.TypeOf(component))
 	}
 
-	spec := scene.ComponentSpec{Type: name}
+	props, err := encodeProps(component)
+	if err != nil {
+		return scene.ComponentSpec{}, fmt.Errorf(
+			"object %q: encoding component %q: %w", entity.Name, name, err)
+	}
+
+	return scene.ComponentSpec{Type: name, Props: props}, nil
+}
 
+// encodeProps is a component's properties as a scene file holds them.
+func encodeProps(component Component) (yaml.Node, error) {
 	var props yaml.Node
 	if err := props.Encode(component); err != nil {
-		return scene.ComponentSpec{}, fmt.Errorf(
-			"object %q: encoding component %q: %w", entity.Name, name, err)
+		return yaml.Node{}, err
 	}
 
 	// A component with no exported fields encodes to an empty mapping. Leaving
 	// the node zero keeps it a bare `type:` line instead of `props: {}`.
-	if props.Kind != yaml.MappingNode || len(props.Content) > 0 {
-		spec.Props = props
+	if props.Kind == yaml.MappingNode && len(props.Content) == 0 {
+		return yaml.Node{}, nil
 	}
-
-	return spec, nil
+	return props, nil
 }
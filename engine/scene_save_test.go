@@ -254,6 +254,39 @@ func TestSceneRoundTripIsLossless(t *testing.T) {
 	assertScenesMatch(t, firstSave, secondSave)
 }
 
+// TestSceneRoundTripAcrossFormats saves the world as JSON, reloads that, saves
+// it as binary, reloads that, and saves YAML again, which has to match a plain
+// YAML save: the format a scene was kept in is not allowed to change it.
+func TestSceneRoundTripAcrossFormats(t *testing.T) {
+	directory := t.TempDir()
+	original := filepath.Join(directory, "original.yml")
+	if err := os.WriteFile(original, []byte(roundTripScene), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	a := saveTestApp(t)
+	loadAndPlace(t, a, original)
+
+	direct := filepath.Join(directory, "direct.yml")
+	if err := a.SaveScene(direct); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	for _, name := range []string{"scene.json", "scene" + scene.BinaryExtension} {
+		path := filepath.Join(directory, name)
+		if err := a.SaveScene(path); err != nil {
+			t.Fatalf("saving %s: %v", name, err)
+		}
+		loadAndPlace(t, a, path)
+	}
+
+	converted := filepath.Join(directory, "converted.yml")
+	if err := a.SaveScene(converted); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	assertScenesMatch(t, direct, converted)
+}
+
 // TestSceneRoundTripPreservesWorld checks the world itself, not just that two
 // saves agree — two identically wrong files would compare equal.
 func TestSceneRoundTripPreservesWorld(t *testing.T) {
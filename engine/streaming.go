@@ -0,0 +1,361 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"3d-engine/object"
+	"3d-engine/scene"
+	"3d-engine/utils"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Streaming turns a scene's cells into layers as the camera moves. Each frame
+// the camera's distance to every cell's bounds is checked: a cell that comes
+// within the load radius starts loading through the same background pipeline
+// as a scene change, and is spawned a budget's worth of entities per frame once
+// its models are resident; a cell that ends up beyond the unload radius is
+// unloaded like any other layer, releasing its models.
+//
+// The two radii are the hysteresis. Between them a cell stays as it is, loaded
+// or not, so a camera pacing along one cell's edge does not load and unload it
+// over and over.
+//
+// Unloading a cell leaves the undo history alone, unlike unloading a layer by
+// hand: walking around is not an edit, and clearing the history every time a
+// cell streams out would make it useless. An undo that reaches an edit to an
+// entity that has since streamed out fails and says so. Edits to a cell are
+// kept only by saving it, with SaveLayer, before it streams out.
+
+// CellState is where a streamed cell has got to.
+type CellState int
+
+const (
+	CellUnloaded CellState = iota
+	// CellLoading covers the whole way in: parsing, uploading, spawning.
+	CellLoading
+	CellLoaded
+	// CellFailed is a cell whose load failed. It is not tried again until the
+	// camera has moved out of its unload radius, so a broken file is reported
+	// once rather than every frame.
+	CellFailed
+)
+
+func (s CellState) String() string {
+	switch s {
+	case CellLoading:
+		return "loading"
+	case CellLoaded:
+		return "loaded"
+	case CellFailed:
+		return "failed"
+	}
+	return "unloaded"
+}
+
+// CellStatus is one cell as the editor shows it.
+type CellStatus struct {
+	Path     string
+	State    CellState
+	Distance float32
+}
+
+// streamedCell is one cell and its bookkeeping.
+type streamedCell struct {
+	spec   scene.Cell
+	path   string
+	bounds object.AABB
+	state  CellState
+	// load is the load in flight while the cell is CellLoading.
+	load *sceneLoad
+}
+
+// cellStreamer decides which cells should be loaded. It does no loading of
+// its own and touches neither GL nor the world, which is what keeps it
+// testable on its own: plan says what to do, and the scene manager does it
+// and reports back by setting the cells' states.
+type cellStreamer struct {
+	cells        []streamedCell
+	loadRadius   float32
+	unloadRadius float32
+
+	// root is the scene root the scene was confined to, or empty. Each cell's
+	// load is held to it in turn; see scene_root.go.
+	root string
+}
+
+func newCellStreamer(cells []scene.Cell, loadRadius, unloadRadius float32) *cellStreamer {
+	s := &cellStreamer{
+		cells:        make([]streamedCell, len(cells)),
+		loadRadius:   loadRadius,
+		unloadRadius: max(unloadRadius, loadRadius),
+	}
+	for i, cell := range cells {
+		s.cells[i] = streamedCell{
+			spec: cell,
+			path: scene.LayerKey(cell.Path),
+			bounds: object.AABB{
+				Min: mgl32.Vec3(cell.Bounds.Min),
+				Max: mgl32.Vec3(cell.Bounds.Max),
+			},
+		}
+	}
+	return s
+}
+
+// plan returns, for a camera at position, the cells to start loading, nearest
+// first, and the cells to unload — loaded, loading or failed ones that have
+// moved beyond the unload radius.
+func (s *cellStreamer) plan(position mgl32.Vec3) (load, unload []int) {
+	distances := make([]float32, len(s.cells))
+	for i := range s.cells {
+		cell := &s.cells[i]
+		distances[i] = boxDistance(cell.bounds, position)
+
+		switch {
+		case cell.state == CellUnloaded && distances[i] <= s.loadRadius:
+			load = append(load, i)
+		case cell.state != CellUnloaded && distances[i] > s.unloadRadius:
+			unload = append(unload, i)
+		}
+	}
+	slices.SortStableFunc(load, func(a, b int) int {
+		switch {
+		case distances[a] < distances[b]:
+			return -1
+		case distances[a] > distances[b]:
+			return 1
+		}
+		return 0
+	})
+	return load, unload
+}
+
+// find is the cell whose load is load, or nil if that load is no longer the
+// one the cell is waiting for.
+func (s *cellStreamer) find(load *sceneLoad) *streamedCell {
+	for i := range s.cells {
+		if s.cells[i].load == load {
+			return &s.cells[i]
+		}
+	}
+	return nil
+}
+
+func (s *cellStreamer) isCell(path string) bool {
+	key := scene.LayerKey(path)
+	return slices.ContainsFunc(s.cells, func(cell streamedCell) bool { return cell.path == key })
+}
+
+// cancel abandons every load in flight, for a scene change.
+func (s *cellStreamer) cancel() {
+	for i := range s.cells {
+		if load := s.cells[i].load; load != nil {
+			load.cancelled.Store(true)
+			s.cells[i].load = nil
+		}
+	}
+}
+
+// boxDistance is how far p is from the nearest point of box; zero inside it.
+func boxDistance(box object.AABB, p mgl32.Vec3) float32 {
+	var outside mgl32.Vec3
+	for axis := range 3 {
+		switch {
+		case p[axis] < box.Min[axis]:
+			outside[axis] = box.Min[axis] - p[axis]
+		case p[axis] > box.Max[axis]:
+			outside[axis] = p[axis] - box.Max[axis]
+		}
+	}
+	return outside.Len()
+}
+
+// streamingConfig is the configured radii and budget, or the defaults.
+func (a *App) streamingConfig() utils.StreamingConfig {
+	if a.Config != nil && a.Config.Streaming.LoadRadius > 0 {
+		return a.Config.Streaming
+	}
+	return utils.StreamingConfig{LoadRadius: 150, UnloadRadius: 190, SpawnBudget: 64}
+}
+
+// startStreaming replaces the streamer with one for the scene's cells, or
+// none, abandoning the old one's loads. root is the scene root the scene was
+// loaded under, if any. Frame loop only.
+func (sm *SceneManager) startStreaming(cells []scene.Cell, root string) {
+	if sm.streamer != nil {
+		sm.streamer.cancel()
+		sm.streamer = nil
+	}
+	if len(cells) == 0 {
+		return
+	}
+	config := sm.app.streamingConfig()
+	sm.streamer = newCellStreamer(cells, config.LoadRadius, config.UnloadRadius)
+	sm.streamer.root = root
+}
+
+// updateStreaming loads and unloads cells around the camera. The frame loop
+// calls it once a frame; it only starts work, so it is cheap.
+func (sm *SceneManager) updateStreaming() {
+	if sm.streamer == nil || sm.app.Camera == nil {
+		return
+	}
+
+	load, unload := sm.streamer.plan(sm.app.Camera.CameraPos)
+	for _, i := range unload {
+		sm.dropCell(&sm.streamer.cells[i])
+	}
+	for _, i := range load {
+		cell := &sm.streamer.cells[i]
+		cell.state = CellLoading
+		cell.load = newSceneLoad(cell.path, "")
+		cell.load.layer = cell.path
+		cell.load.root = sm.streamer.root
+		go sm.prepareLoad(cell.load)
+	}
+}
+
+// dropCell unloads a cell, or abandons its load. A load abandoned part-way
+// through spawning takes back what it spawned when it next runs.
+func (sm *SceneManager) dropCell(cell *streamedCell) {
+	if cell.load != nil {
+		cell.load.cancelled.Store(true)
+		cell.load = nil
+	}
+	if cell.state == CellLoaded {
+		sm.unloadLayer(cell.path)
+		utils.Logger().Printf("Streamed out %s", cell.path)
+	}
+	cell.state = CellUnloaded
+}
+
+// settleCell records how a cell's load ended, if the cell is still waiting
+// for that load.
+func (sm *SceneManager) settleCell(load *sceneLoad, state CellState) {
+	if sm.streamer == nil {
+		return
+	}
+	if cell := sm.streamer.find(load); cell != nil {
+		cell.state = state
+		cell.load = nil
+	}
+}
+
+// spawnCell is the last step of a cell's load, run on the frame loop once
+// every model is resident: it builds and spawns the cell's objects, no more
+// than the spawn budget's worth of entities a frame, and requeues itself until
+// they are all in. A whole object tree goes in at once, so a frame may go over
+// the budget by one tree, and always spawns at least one.
+func (sm *SceneManager) spawnCell(a *App, load *sceneLoad) error {
+	abandon := func() {
+		for _, root := range load.spawned {
+			// Gone already if a scene change replaced the world meanwhile.
+			_ = a.DespawnTree(root)
+		}
+		load.spawned = nil
+		load.releaseCommitted(a)
+	}
+
+	if load.cancelled.Load() {
+		abandon()
+		load.fail(ErrLoadSuperseded)
+		return nil
+	}
+	if err := load.snapshot().Err; err != nil {
+		abandon()
+		sm.settleCell(load, CellFailed)
+		return fmt.Errorf("failed to stream in %s: %w", load.path, err)
+	}
+
+	// In a replay the recording says when the cell spawns and how far it
+	// gets, not the budget; see replay.go.
+	limit, due := a.loadDue(loadStep{Cell: load.layer})
+	if !due {
+		a.Defer(func(a *App) error {
+			return sm.spawnCell(a, load)
+		})
+		return nil
+	}
+
+	budget := a.streamingConfig().SpawnBudget
+	for spawned := 0; load.next < len(load.specs); load.next++ {
+		if limit >= 0 && load.next >= limit || limit < 0 && spawned > 0 && spawned >= budget {
+			break
+		}
+		entities, err := sm.buildEntities(load.specs[load.next:load.next+1], load.layer)
+		if err != nil {
+			load.fail(err)
+			abandon()
+			sm.settleCell(load, CellFailed)
+			return fmt.Errorf("failed to stream in %s: %w", load.path, err)
+		}
+		for _, entity := range entities {
+			a.World.Spawn(entity)
+		}
+		load.spawned = append(load.spawned, entities[0].Handle())
+		spawned += len(entities)
+	}
+	a.recordLoad(loadStep{Cell: load.layer, Spawned: load.next})
+
+	if load.next < len(load.specs) {
+		a.Defer(func(a *App) error {
+			return sm.spawnCell(a, load)
+		})
+		return nil
+	}
+
+	// The entities hold their own references now.
+	load.releaseCommitted(a)
+
+	sm.mu.Lock()
+	sm.layers = append(sm.layers, load.layer)
+	sm.mu.Unlock()
+	sm.settleCell(load, CellLoaded)
+	load.update(func(p *LoadProgress) { p.Stage = LoadDone })
+
+	utils.Logger().Printf("Streamed in %s (%d objects)", load.path, len(load.spawned))
+	return nil
+}
+
+// isCell reports whether path is one of the current scene's cells. Frame loop
+// only.
+func (sm *SceneManager) isCell(path string) bool {
+	return sm.streamer != nil && sm.streamer.isCell(path)
+}
+
+// Cells reports every cell of the current scene, its state, and how far the
+// camera is from it. Frame loop only, like everything else about streaming.
+func (sm *SceneManager) Cells() []CellStatus {
+	if sm.streamer == nil {
+		return nil
+	}
+
+	var position mgl32.Vec3
+	if sm.app.Camera != nil {
+		position = sm.app.Camera.CameraPos
+	}
+	statuses := make([]CellStatus, len(sm.streamer.cells))
+	for i, cell := range sm.streamer.cells {
+		statuses[i] = CellStatus{
+			Path:     cell.path,
+			State:    cell.state,
+			Distance: boxDistance(cell.bounds, position),
+		}
+	}
+	return statuses
+}
+
+// cellsForSave is the cell list a save of the current scene writes: the one
+// it was loaded with, whatever is streamed in at the moment.
+func (sm *SceneManager) cellsForSave() []scene.Cell {
+	if sm.streamer == nil {
+		return nil
+	}
+	cells := make([]scene.Cell, len(sm.streamer.cells))
+	for i, cell := range sm.streamer.cells {
+		cells[i] = cell.spec
+	}
+	return cells
+}
@@ -0,0 +1,334 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"3d-engine/object"
+	"3d-engine/scene"
+	"3d-engine/utils"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Two cells side by side along X, each 10 wide.
+func testStreamer() *cellStreamer {
+	return newCellStreamer([]scene.Cell{
+		{Path: "west.yml", Bounds: scene.Bounds{Min: [3]float32{0, 0, 0}, Max: [3]float32{10, 10, 10}}},
+		{Path: "east.yml", Bounds: scene.Bounds{Min: [3]float32{10, 0, 0}, Max: [3]float32{20, 10, 10}}},
+	}, 5, 8)
+}
+
+func TestBoxDistance(t *testing.T) {
+	box := object.AABB{Min: mgl32.Vec3{0, 0, 0}, Max: mgl32.Vec3{10, 10, 10}}
+	cases := []struct {
+		point mgl32.Vec3
+		want  float32
+	}{
+		{mgl32.Vec3{5, 5, 5}, 0},
+		{mgl32.Vec3{13, 5, 5}, 3},
+		{mgl32.Vec3{-3, 14, 5}, 5},
+	}
+	for _, c := range cases {
+		if got := boxDistance(box, c.point); !nearly(got, c.want, 1e-5) {
+			t.Errorf("distance from %v = %v, want %v", c.point, got, c.want)
+		}
+	}
+}
+
+func TestCellStreamerLoadsNearestFirst(t *testing.T) {
+	s := testStreamer()
+
+	// Inside east, 2 from west's edge: both in range, east first.
+	load, unload := s.plan(mgl32.Vec3{12, 5, 5})
+	if !slices.Equal(load, []int{1, 0}) || len(unload) != 0 {
+		t.Errorf("plan = load %v, unload %v; want load [1 0]", load, unload)
+	}
+}
+
+func TestCellStreamerHysteresis(t *testing.T) {
+	s := testStreamer()
+	west := &s.cells[0]
+
+	// 6 from west: outside the load radius, so nothing to do.
+	if load, _ := s.plan(mgl32.Vec3{-6, 5, 5}); slices.Contains(load, 0) {
+		t.Fatal("west loads from beyond the load radius")
+	}
+
+	west.state = CellLoaded
+	// Still 6 away, but between the radii a loaded cell stays loaded.
+	if _, unload := s.plan(mgl32.Vec3{-6, 5, 5}); len(unload) != 0 {
+		t.Errorf("west unloads inside the unload radius: %v", unload)
+	}
+	if _, unload := s.plan(mgl32.Vec3{-9, 5, 5}); !slices.Equal(unload, []int{0}) {
+		t.Errorf("west is not unloaded beyond the unload radius: %v", unload)
+	}
+
+	// A failed cell is left alone until the camera has been far enough away
+	// to unload it, and only then tried again.
+	west.state = CellFailed
+	if load, unload := s.plan(mgl32.Vec3{5, 5, 5}); len(load) != 1 || len(unload) != 0 {
+		t.Errorf("plan for a failed cell in range = load %v, unload %v; want only east to load", load, unload)
+	}
+	if _, unload := s.plan(mgl32.Vec3{-9, 5, 5}); !slices.Contains(unload, 0) {
+		t.Error("a failed cell out of range is not reset")
+	}
+}
+
+// streamedWorld writes a scene of two cells 100 apart, each holding lights
+// named after the cell, and returns the scene's path and the cells'.
+func streamedWorld(t *testing.T, lightsPerCell int) (string, string, string) {
+	t.Helper()
+
+	directory := t.TempDir()
+	cell := func(name string) string {
+		var body strings.Builder
+		body.WriteString("version: 2\nobjects:\n")
+		for i := range lightsPerCell {
+			fmt.Fprintf(&body, "  - name: %s-%d\n    components:\n      - type: PointLight\n", name, i)
+		}
+		return writeScene(t, directory, name+".yml", body.String())
+	}
+	west, east := cell("west"), cell("east")
+	path := writeScene(t, directory, "world.yml", `version: 2
+cells:
+  - path: `+west+`
+    bounds: {min: [0, 0, 0], max: [10, 10, 10]}
+  - path: `+east+`
+    bounds: {min: [100, 0, 0], max: [110, 10, 10]}
+objects:
+  - name: sun
+    components:
+      - type: DirectionalLight
+`)
+	return path, west, east
+}
+
+func streamingApp(t *testing.T, budget int) *App {
+	t.Helper()
+
+	a := saveTestApp(t)
+	a.Config = &utils.Config{Streaming: utils.StreamingConfig{
+		LoadRadius:   20,
+		UnloadRadius: 30,
+		SpawnBudget:  budget,
+	}}
+	return a
+}
+
+// pumpStreaming runs frames — streaming, then the deferred commands — until
+// no cell is loading.
+func pumpStreaming(t *testing.T, a *App) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		a.Scenes.updateStreaming()
+		a.drainCommands()
+		loading := slices.ContainsFunc(a.Scenes.Cells(), func(cell CellStatus) bool {
+			return cell.State == CellLoading
+		})
+		if !loading {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("streaming did not settle")
+}
+
+func cellStates(a *App) []CellState {
+	var states []CellState
+	for _, cell := range a.Scenes.Cells() {
+		states = append(states, cell.State)
+	}
+	return states
+}
+
+func TestStreamingFollowsCamera(t *testing.T) {
+	a := streamingApp(t, 64)
+	path, west, _ := streamedWorld(t, 2)
+	loadAndPlace(t, a, path)
+
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+	pumpStreaming(t, a)
+	if got := cellStates(a); !slices.Equal(got, []CellState{CellLoaded, CellUnloaded}) {
+		t.Fatalf("cells near west are %v", got)
+	}
+	if a.World.Find("west-1") == nil || a.World.Find("east-0") != nil {
+		t.Error("the world does not hold exactly the west cell")
+	}
+	if got := layerOf(t, a, "west-0"); got != west {
+		t.Errorf("a streamed object is in layer %q", got)
+	}
+
+	a.Camera.CameraPos = mgl32.Vec3{105, 5, 5}
+	pumpStreaming(t, a)
+	if got := cellStates(a); !slices.Equal(got, []CellState{CellUnloaded, CellLoaded}) {
+		t.Fatalf("cells near east are %v", got)
+	}
+	if a.World.Find("west-0") != nil || a.World.Find("east-1") == nil {
+		t.Error("the world does not hold exactly the east cell")
+	}
+	if a.World.Find("sun") == nil {
+		t.Error("streaming took the scene's own object")
+	}
+}
+
+func TestStreamingSpawnBudget(t *testing.T) {
+	a := streamingApp(t, 2)
+	path, _, _ := streamedWorld(t, 5)
+	loadAndPlace(t, a, path)
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+
+	before := a.World.Len()
+	a.Scenes.updateStreaming()
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Scenes.Cells()[0].State == CellLoading {
+		if time.Now().After(deadline) {
+			t.Fatal("the cell did not finish loading")
+		}
+		count := a.World.Len()
+		a.drainCommands()
+		if added := a.World.Len() - count; added > 2 {
+			t.Fatalf("one frame spawned %d entities with a budget of 2", added)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := a.World.Len() - before; got != 5 {
+		t.Errorf("the cell spawned %d entities, want 5", got)
+	}
+}
+
+// TestStreamingOutMidSpawn: a camera that turns back while a cell is still
+// spawning must not leave half the cell behind.
+func TestStreamingOutMidSpawn(t *testing.T) {
+	a := streamingApp(t, 1)
+	path, _, _ := streamedWorld(t, 4)
+	loadAndPlace(t, a, path)
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+
+	a.Scenes.updateStreaming()
+	deadline := time.Now().Add(5 * time.Second)
+	for a.World.Find("west-0") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the cell never started spawning")
+		}
+		a.drainCommands()
+		time.Sleep(time.Millisecond)
+	}
+
+	a.Camera.CameraPos = mgl32.Vec3{-50, 5, 5}
+	pumpStreaming(t, a)
+	a.drainCommands()
+	for i := range 4 {
+		if a.World.Find(fmt.Sprintf("west-%d", i)) != nil {
+			t.Errorf("west-%d survived streaming out mid-spawn", i)
+		}
+	}
+	if len(a.Scenes.Layers()) != 0 {
+		t.Errorf("layers after streaming out: %v", a.Scenes.Layers())
+	}
+}
+
+func TestStreamedCellFailsOnce(t *testing.T) {
+	a := streamingApp(t, 64)
+	directory := t.TempDir()
+	path := writeScene(t, directory, "world.yml", `version: 2
+cells:
+  - path: `+filepath.Join(directory, "missing.yml")+`
+    bounds: {min: [0, 0, 0], max: [10, 10, 10]}
+objects: []
+`)
+	loadAndPlace(t, a, path)
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+
+	pumpStreaming(t, a)
+	if got := cellStates(a); !slices.Equal(got, []CellState{CellFailed}) {
+		t.Fatalf("a missing cell is %v", got)
+	}
+	a.Scenes.updateStreaming()
+	if got := cellStates(a); !slices.Equal(got, []CellState{CellFailed}) {
+		t.Errorf("a failed cell was retried in place: %v", got)
+	}
+}
+
+func TestSaveWritesCellsNotTheirObjects(t *testing.T) {
+	a := streamingApp(t, 64)
+	path, west, east := streamedWorld(t, 1)
+	loadAndPlace(t, a, path)
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+	pumpStreaming(t, a)
+
+	snapshot, err := a.SceneSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Objects) != 1 || len(snapshot.Includes) != 0 {
+		t.Errorf("saved %d objects and includes %+v; want the sun and no includes",
+			len(snapshot.Objects), snapshot.Includes)
+	}
+	if len(snapshot.Cells) != 2 || snapshot.Cells[0].Path != west || snapshot.Cells[1].Path != east {
+		t.Errorf("saved cells %+v", snapshot.Cells)
+	}
+
+	if err := a.Scenes.Unload(west); err == nil {
+		t.Error("unloading a streamed cell by hand should fail")
+	}
+}
+
+// TestConfinedSceneConfinesItsCells: the cells of a scene loaded under a scene
+// root are held to it as they stream in, though their own paths are inside
+// it — a model a cell names, and a prefab another prefab names, may not reach
+// out.
+func TestConfinedSceneConfinesItsCells(t *testing.T) {
+	a := streamingApp(t, 64)
+	root, elsewhere := t.TempDir(), t.TempDir()
+
+	outsidePrefab := writeScene(t, elsewhere, "bulb.yml", "version: 2\nprefab:\n  name: bulb\n")
+	insidePrefab := writeScene(t, root, "lamp.yml", "version: 2\nprefab:\n  name: lamp\n  children:\n    - name: bulb\n      prefab: "+outsidePrefab+"\n")
+	crates := writeScene(t, root, "crates.yml", "version: 2\nobjects:\n  - name: crate\n    model: "+filepath.Join(elsewhere, "crate.obj")+"\n")
+	lamps := writeScene(t, root, "lamps.yml", "version: 2\nobjects:\n  - name: lamp\n    prefab: "+insidePrefab+"\n")
+	path := writeScene(t, root, "world.yml", `version: 2
+cells:
+  - path: `+crates+`
+    bounds: {min: [0, 0, 0], max: [10, 10, 10]}
+  - path: `+lamps+`
+    bounds: {min: [0, 0, 0], max: [10, 10, 10]}
+objects: []
+`)
+
+	document, err := scene.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Scenes.RequestSceneDocument(document, path, root); err != nil {
+		t.Fatalf("a scene inside the root was refused: %v", err)
+	}
+	if progress := pumpLoad(t, a); progress.Stage != LoadDone {
+		t.Fatalf("load ended %s: %v", progress.Stage, progress.Err)
+	}
+
+	a.Camera.CameraPos = mgl32.Vec3{5, 5, 5}
+	a.Scenes.updateStreaming()
+	var loads []*sceneLoad
+	for _, cell := range a.Scenes.streamer.cells {
+		loads = append(loads, cell.load)
+	}
+	pumpStreaming(t, a)
+
+	if got := cellStates(a); !slices.Equal(got, []CellState{CellFailed, CellFailed}) {
+		t.Errorf("cells reaching outside the root are %v", got)
+	}
+	for i, load := range loads {
+		if err := load.snapshot().Err; err == nil || !strings.Contains(err.Error(), "outside the scene root") {
+			t.Errorf("cell %d failed with %v", i, err)
+		}
+	}
+	if a.World.Find("crate") != nil || a.World.Find("lamp") != nil {
+		t.Error("a cell reaching outside the root spawned its objects")
+	}
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"slices"
+
+	"3d-engine/object"
+	"3d-engine/scene"
+	"3d-engine/textures"
+)
+
+// ValidationResources is what scene.Validate needs to know about this engine:
+// the component types a scene may name — the built-in lights plus whatever
+// register adds, exactly as Options.RegisterComponents would add them — how
+// to find the textures a model samples, and which files a skybox needs.
+//
+// None of it touches GL, so a validation runs headless, in CI. The textures
+// are found by importing each model, which is slow for big ones; leave
+// ModelTextures nil on the result to skip that.
+func ValidationResources(register func(*ComponentRegistry) error) (scene.Resources, error) {
+	registry := NewComponentRegistry()
+	registerBuiltinComponents(registry)
+	if register != nil {
+		if err := register(registry); err != nil {
+			return scene.Resources{}, err
+		}
+	}
+
+	return scene.Resources{
+		Components:    registry,
+		ModelTextures: modelTextures,
+		SkyboxFiles:   textures.CubemapFiles,
+	}, nil
+}
+
+func modelTextures(path string) ([]string, error) {
+	data, err := object.ParseModel(path)
+	if err != nil {
+		return nil, err
+	}
+	// One file can fill several slots, and TextureRefs lists each slot.
+	var files []string
+	for _, ref := range data.TextureRefs() {
+		if !slices.Contains(files, ref.Path) {
+			files = append(files, ref.Path)
+		}
+	}
+	return files, nil
+}
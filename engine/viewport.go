@@ -0,0 +1,427 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"sync"
+	"time"
+
+	"3d-engine/utils"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// A frame stream is the camera's view, rendered again for a client that has no
+// window of its own onto the engine: a remote editor, a dashboard, a test
+// watching a scene play out.
+//
+// Each stream asks for a size and a rate. When one is due, the frame loop
+// renders the scene into an offscreen framebuffer of that size, once for every
+// stream that wants it, and reads the pixels back. Encoding is left to the
+// stream's own goroutine, since a PNG of a large frame takes longer to write
+// than the engine has for a whole frame.
+//
+// The read back waits for the GPU to finish, so a stream costs a stall as well
+// as a second draw each time it is due. Rates are capped accordingly, and so is
+// the number of streams and the area they render between them: STREAM_FRAMES
+// is open to read-only clients, and one that asked for stream after stream at
+// sizes of its own would otherwise have the frame loop drawing the scene
+// dozens of times over.
+
+const (
+	maxFrameSize    = 4096
+	maxFrameRate    = 60
+	defaultRate     = 10
+	defaultQuality  = 75
+	frameFeedBuffer = 1
+
+	// maxFrameFeeds is how many streams may be open at once, across every
+	// client.
+	maxFrameFeeds = 16
+	// maxFramePixels bounds the sizes the streams render at, added up with
+	// each size counted once, since streams of one size share its render: two
+	// of the largest, or a dozen or so at 1080p. A stream following the window
+	// is not counted; the engine draws that size anyway.
+	maxFramePixels = 2 * maxFrameSize * maxFrameSize
+)
+
+// ErrFrameBudget is returned by StreamFrames when the streams already open
+// use up what the engine will render for them.
+var ErrFrameBudget = errors.New("frame streams are at the engine's limit")
+
+// FrameFormat is how a streamed frame is encoded.
+type FrameFormat int
+
+const (
+	FramePNG FrameFormat = iota
+	FrameJPEG
+)
+
+// FrameRequest says what a stream wants.
+type FrameRequest struct {
+	// Width and Height are the size to render at. Zero for both follows the
+	// window.
+	Width, Height int
+
+	// Rate is in frames per second. Zero means defaultRate.
+	Rate float32
+
+	Format FrameFormat
+
+	// Quality is JPEG's, from 1 to 100. Zero means defaultQuality.
+	Quality int
+}
+
+// normalize fills in the defaults and refuses what cannot be streamed.
+func (r FrameRequest) normalize() (FrameRequest, error) {
+	if (r.Width == 0) != (r.Height == 0) {
+		return r, fmt.Errorf("frame size %dx%d: give both sides, or neither for the window's", r.Width, r.Height)
+	}
+	if r.Width < 0 || r.Height < 0 || r.Width > maxFrameSize || r.Height > maxFrameSize {
+		return r, fmt.Errorf("frame size %dx%d is outside 1 to %d a side", r.Width, r.Height, maxFrameSize)
+	}
+	if r.Rate < 0 || r.Rate > maxFrameRate {
+		return r, fmt.Errorf("frame rate %g is outside 0 to %d", r.Rate, maxFrameRate)
+	}
+	if r.Rate == 0 {
+		r.Rate = defaultRate
+	}
+	if r.Quality < 0 || r.Quality > 100 {
+		return r, fmt.Errorf("quality %d is outside 1 to 100", r.Quality)
+	}
+	if r.Quality == 0 {
+		r.Quality = defaultQuality
+	}
+	return r, nil
+}
+
+// CapturedFrame is one rendered view, not yet encoded.
+type CapturedFrame struct {
+	// Number counts capture rounds, shared by every stream.
+	Number uint64
+
+	// Image is top row first and opaque. Streams of the same size share it,
+	// so it must not be written to.
+	Image *image.RGBA
+
+	// Dropped is how many frames were skipped since the last one taken,
+	// because this stream had not taken that one yet.
+	Dropped int
+}
+
+// EncodeFrame writes a captured image as format.
+func EncodeFrame(img *image.RGBA, format FrameFormat, quality int) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+	switch format {
+	case FrameJPEG:
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: quality})
+	default:
+		// Frames go out as fast as they are drawn; smaller files are not
+		// worth the extra time.
+		encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+		err = encoder.Encode(&buffer, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// FrameFeed is one frame stream.
+type FrameFeed struct {
+	app     *App
+	request FrameRequest
+	frames  chan CapturedFrame
+
+	// Guarded by the hub's lock.
+	next    time.Time
+	dropped int
+	closed  bool
+}
+
+// Frames is the channel captures arrive on. It is closed by Close.
+func (f *FrameFeed) Frames() <-chan CapturedFrame {
+	return f.frames
+}
+
+// Request is what the feed was opened with, defaults filled in.
+func (f *FrameFeed) Request() FrameRequest {
+	return f.request
+}
+
+// Close stops the feed and closes its channel. Safe to call twice, and from
+// any goroutine.
+func (f *FrameFeed) Close() {
+	f.app.frames.remove(f)
+}
+
+// StreamFrames starts rendering the camera's view as request asks. Safe from
+// any goroutine. It fails with ErrFrameBudget while the streams already open
+// leave no room for this one.
+func (a *App) StreamFrames(request FrameRequest) (*FrameFeed, error) {
+	request, err := request.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &FrameFeed{
+		app:     a,
+		request: request,
+		frames:  make(chan CapturedFrame, frameFeedBuffer),
+	}
+	if err := a.frames.add(feed); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// frameHub schedules the feeds' captures.
+type frameHub struct {
+	mu    sync.Mutex
+	feeds []*FrameFeed
+	frame uint64
+}
+
+func (h *frameHub) add(feed *FrameFeed) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.feeds) >= maxFrameFeeds {
+		return fmt.Errorf("%w of %d streams", ErrFrameBudget, maxFrameFeeds)
+	}
+	if feed.request.Width != 0 {
+		size := frameSize{feed.request.Width, feed.request.Height}
+		sizes := h.sizes()
+		if !sizes[size] {
+			pixels := size.width * size.height
+			for other := range sizes {
+				pixels += other.width * other.height
+			}
+			if pixels > maxFramePixels {
+				return fmt.Errorf("%w: a %dx%d stream would take the streams past %d pixels a frame",
+					ErrFrameBudget, size.width, size.height, maxFramePixels)
+			}
+		}
+	}
+
+	h.feeds = append(h.feeds, feed)
+	return nil
+}
+
+// sizes is the set of fixed sizes the open feeds render at. Guarded by the
+// hub's lock.
+func (h *frameHub) sizes() map[frameSize]bool {
+	sizes := map[frameSize]bool{}
+	for _, feed := range h.feeds {
+		if feed.request.Width != 0 {
+			sizes[frameSize{feed.request.Width, feed.request.Height}] = true
+		}
+	}
+	return sizes
+}
+
+func (h *frameHub) remove(feed *FrameFeed) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if feed.closed {
+		return
+	}
+	feed.closed = true
+	close(feed.frames)
+
+	for i, other := range h.feeds {
+		if other == feed {
+			h.feeds = append(h.feeds[:i], h.feeds[i+1:]...)
+			break
+		}
+	}
+}
+
+type frameSize struct{ width, height int }
+
+// sizeOf is the size feed renders at while the window is width by height.
+func (f *FrameFeed) sizeOf(width, height int) frameSize {
+	if f.request.Width == 0 {
+		return frameSize{width, height}
+	}
+	return frameSize{f.request.Width, f.request.Height}
+}
+
+// capture renders one image for each size some feed is due at, and hands it
+// to those feeds. It returns every size a feed wants, due or not, so the
+// caller can let go of targets nobody will ask for again.
+//
+// render is called without the hub's lock, so a feed may be closed while its
+// frame is drawn; it is then simply not delivered.
+func (h *frameHub) capture(now time.Time, width, height int, render func(frameSize) (*image.RGBA, error)) (map[frameSize]bool, error) {
+	h.mu.Lock()
+	wanted := make(map[frameSize]bool, len(h.feeds))
+	due := make(map[frameSize][]*FrameFeed)
+	for _, feed := range h.feeds {
+		size := feed.sizeOf(width, height)
+		wanted[size] = true
+		// A minimized window has no size to follow.
+		if size.width == 0 || size.height == 0 || now.Before(feed.next) {
+			continue
+		}
+		due[size] = append(due[size], feed)
+
+		// Keep to the rate, but never try to catch up after a slow frame:
+		// frames owed from the past would only arrive late.
+		interval := time.Duration(float64(time.Second) / float64(feed.request.Rate))
+		feed.next = feed.next.Add(interval)
+		if feed.next.Before(now) {
+			feed.next = now.Add(interval)
+		}
+	}
+	if len(due) > 0 {
+		h.frame++
+	}
+	number := h.frame
+	h.mu.Unlock()
+
+	var firstErr error
+	for size, feeds := range due {
+		img, err := render(size)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		h.deliver(feeds, CapturedFrame{Number: number, Image: img})
+	}
+	return wanted, firstErr
+}
+
+// deliver offers the frame to each feed without waiting: one still holding
+// its last frame skips this one.
+func (h *frameHub) deliver(feeds []*FrameFeed, frame CapturedFrame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, feed := range feeds {
+		if feed.closed {
+			continue
+		}
+		frame.Dropped = feed.dropped
+		select {
+		case feed.frames <- frame:
+			feed.dropped = 0
+		default:
+			feed.dropped++
+		}
+	}
+}
+
+// captureFrames renders whatever the frame streams are due, after the frame
+// itself and before the editor UI, which a remote viewer has no use for.
+func (a *App) captureFrames() {
+	wanted, err := a.frames.capture(time.Now(), a.width, a.height, a.renderOffscreen)
+	if err != nil {
+		utils.Logger().Println("Capturing a frame:", err)
+	}
+
+	for size, target := range a.captureTargets {
+		if !wanted[size] {
+			target.Delete()
+			delete(a.captureTargets, size)
+		}
+	}
+}
+
+// renderOffscreen draws the scene at size into a framebuffer of its own and
+// reads it back, top row first. The window's framebuffer and viewport are put
+// back afterwards.
+func (a *App) renderOffscreen(size frameSize) (*image.RGBA, error) {
+	target := a.captureTargets[size]
+	if target == nil {
+		var err error
+		if target, err = newCaptureTarget(size); err != nil {
+			return nil, err
+		}
+		if a.captureTargets == nil {
+			a.captureTargets = make(map[frameSize]*captureTarget)
+		}
+		a.captureTargets[size] = target
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, target.fbo)
+	gl.Viewport(0, 0, int32(size.width), int32(size.height))
+	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+
+	// render sizes its projection, and the outline's width, from these.
+	width, height := a.width, a.height
+	a.width, a.height = size.width, size.height
+	a.render()
+	a.width, a.height = width, height
+
+	pixels := make([]byte, size.width*size.height*4)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(size.width), int32(size.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(a.width), int32(a.height))
+
+	return imageFromPixels(pixels, size.width, size.height), nil
+}
+
+// imageFromPixels turns GL's bottom-up rows into an image's top-down ones,
+// and makes it opaque: a transparent mesh leaves alpha below one in the
+// framebuffer, which would show through in a PNG as holes in the scene.
+func imageFromPixels(pixels []byte, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	stride := width * 4
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+stride]
+		copy(row, pixels[(height-1-y)*stride:(height-y)*stride])
+		for x := 3; x < stride; x += 4 {
+			row[x] = 0xff
+		}
+	}
+	return img
+}
+
+// captureTarget is an offscreen framebuffer: an sRGB colour buffer, like the
+// window's, and depth and stencil for the outline pass.
+type captureTarget struct {
+	fbo, color, depth uint32
+}
+
+func newCaptureTarget(size frameSize) (*captureTarget, error) {
+	target := &captureTarget{}
+	gl.GenFramebuffers(1, &target.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, target.fbo)
+
+	gl.GenRenderbuffers(1, &target.color)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, target.color)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.SRGB8_ALPHA8, int32(size.width), int32(size.height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, target.color)
+
+	gl.GenRenderbuffers(1, &target.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, target.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, int32(size.width), int32(size.height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, target.depth)
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		target.Delete()
+		return nil, fmt.Errorf("offscreen framebuffer %dx%d is incomplete: 0x%x", size.width, size.height, status)
+	}
+	return target, nil
+}
+
+func (t *captureTarget) Delete() {
+	gl.DeleteRenderbuffers(1, &t.color)
+	gl.DeleteRenderbuffers(1, &t.depth)
+	gl.DeleteFramebuffers(1, &t.fbo)
+}
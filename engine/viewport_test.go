@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// solidFrame stands in for renderOffscreen: an image of the size asked for,
+// all one grey.
+func solidFrame(size frameSize) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size.width, size.height))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	return img, nil
+}
+
+func TestFrameRequestNormalize(t *testing.T) {
+	request, err := FrameRequest{}.normalize()
+	if err != nil || request.Rate != defaultRate || request.Quality != defaultQuality {
+		t.Errorf("defaults: %+v, %v", request, err)
+	}
+
+	for _, bad := range []FrameRequest{
+		{Width: 640},
+		{Width: -1, Height: 480},
+		{Width: maxFrameSize + 1, Height: 480},
+		{Rate: maxFrameRate + 1},
+		{Rate: -1},
+		{Quality: 101},
+	} {
+		if _, err := bad.normalize(); err == nil {
+			t.Errorf("%+v was accepted", bad)
+		}
+	}
+}
+
+// TestFrameHubCapture: a size is rendered once however many feeds want it, a
+// feed is only captured for as often as its rate allows, and one that has not
+// taken its last frame skips the next and is told so.
+func TestFrameHubCapture(t *testing.T) {
+	a := saveTestApp(t)
+	fast, err := a.StreamFrames(FrameRequest{Width: 64, Height: 48, Rate: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, _ := a.StreamFrames(FrameRequest{Width: 64, Height: 48, Rate: 1})
+	window, _ := a.StreamFrames(FrameRequest{Rate: 60})
+
+	renders := map[frameSize]int{}
+	render := func(size frameSize) (*image.RGBA, error) {
+		renders[size]++
+		return solidFrame(size)
+	}
+
+	now := time.Now()
+	wanted, err := a.frames.capture(now, 800, 600, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wanted) != 2 || renders[frameSize{64, 48}] != 1 || renders[frameSize{800, 600}] != 1 {
+		t.Errorf("wanted %v, rendered %v", wanted, renders)
+	}
+	for name, feed := range map[string]*FrameFeed{"fast": fast, "slow": slow, "window": window} {
+		select {
+		case frame := <-feed.Frames():
+			if frame.Number != 1 || frame.Dropped != 0 {
+				t.Errorf("%s got %+v", name, frame)
+			}
+		default:
+			t.Errorf("%s got no frame", name)
+		}
+	}
+
+	// Two more rounds within the second: the 60 fps feeds are due at both,
+	// the 1 fps one at neither. Nothing takes fast's frame from the first, so
+	// it skips the second.
+	a.frames.capture(now.Add(50*time.Millisecond), 800, 600, render)
+	a.frames.capture(now.Add(100*time.Millisecond), 800, 600, render)
+	if len(slow.Frames()) != 0 {
+		t.Error("the 1 fps feed was captured again within the second")
+	}
+	<-fast.Frames()
+	<-window.Frames()
+	a.frames.capture(now.Add(150*time.Millisecond), 800, 600, render)
+	if frame := <-fast.Frames(); frame.Dropped != 1 || frame.Number != 4 {
+		t.Errorf("after one skipped frame, got %+v", frame)
+	}
+
+	window.Close()
+	window.Close()
+	for len(window.Frames()) > 0 {
+		<-window.Frames()
+	}
+	if _, ok := <-window.Frames(); ok {
+		t.Error("a closed feed's channel is still open")
+	}
+	fast.Close()
+	slow.Close()
+	if wanted, _ := a.frames.capture(now.Add(time.Hour), 800, 600, render); len(wanted) != 0 {
+		t.Errorf("sizes still wanted with every feed closed: %v", wanted)
+	}
+}
+
+// TestFrameFeedBudget: streams share a size's render and its share of the
+// budget; a new size past the budget, or a stream past the count, is refused
+// as the engine's limit rather than the request's fault.
+func TestFrameFeedBudget(t *testing.T) {
+	a := saveTestApp(t)
+	open := func(request FrameRequest) (*FrameFeed, error) {
+		t.Helper()
+		feed, err := a.StreamFrames(request)
+		if err == nil {
+			t.Cleanup(feed.Close)
+		}
+		return feed, err
+	}
+
+	var last *FrameFeed
+	for _, request := range []FrameRequest{
+		{Width: maxFrameSize, Height: maxFrameSize},
+		{Width: maxFrameSize, Height: maxFrameSize, Rate: 1},
+		{Width: maxFrameSize, Height: maxFrameSize - 1},
+	} {
+		var err error
+		if last, err = open(request); err != nil {
+			t.Fatalf("%dx%d: %v", request.Width, request.Height, err)
+		}
+	}
+	_, err := open(FrameRequest{Width: 65, Height: 64})
+	if !errors.Is(err, ErrFrameBudget) || status.Code(frameStreamError(err)) != codes.ResourceExhausted {
+		t.Fatalf("a size past the pixel budget: %v", err)
+	}
+	last.Close()
+	if _, err := open(FrameRequest{Width: 65, Height: 64}); err != nil {
+		t.Fatalf("a size that fits once a stream closed: %v", err)
+	}
+
+	for range maxFrameFeeds - 3 {
+		if _, err := open(FrameRequest{}); err != nil {
+			t.Fatalf("following the window: %v", err)
+		}
+	}
+	if _, err := open(FrameRequest{}); !errors.Is(err, ErrFrameBudget) {
+		t.Errorf("stream %d was opened: %v", maxFrameFeeds+1, err)
+	}
+	if _, err := open(FrameRequest{Width: 0, Height: 1}); errors.Is(err, ErrFrameBudget) {
+		t.Errorf("a bad request was blamed on the budget: %v", err)
+	}
+}
+
+// TestImageFromPixels: GL reads rows bottom first, and keeps whatever alpha
+// the last draw left.
+func TestImageFromPixels(t *testing.T) {
+	pixels := []byte{
+		10, 20, 30, 0, 40, 50, 60, 128, // bottom row
+		70, 80, 90, 255, 100, 110, 120, 1, // top row
+	}
+	img := imageFromPixels(pixels, 2, 2)
+
+	want := map[image.Point]color.RGBA{
+		{0, 0}: {70, 80, 90, 255},
+		{1, 0}: {100, 110, 120, 255},
+		{0, 1}: {10, 20, 30, 255},
+		{1, 1}: {40, 50, 60, 255},
+	}
+	for point, colour := range want {
+		if got := img.RGBAAt(point.X, point.Y); got != colour {
+			t.Errorf("pixel %v = %v, want %v", point, got, colour)
+		}
+	}
+
+	encoded, err := EncodeFrame(img, FramePNG, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	if err != nil || color.RGBAModel.Convert(decoded.At(1, 0)) != (color.RGBA{100, 110, 120, 255}) {
+		t.Errorf("PNG round trip: %v, %v", err, decoded)
+	}
+
+	encoded, err = EncodeFrame(img, FrameJPEG, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config, err := jpeg.DecodeConfig(bytes.NewReader(encoded)); err != nil || config.Width != 2 || config.Height != 2 {
+		t.Errorf("JPEG: %+v, %v", config, err)
+	}
+}
@@ -30,6 +30,10 @@ type World struct {
 	slots    []slot
 	free     []uint32
 
+	// changes notes what happens to the entities for subscribers; see
+	// changes.go.
+	changes changeJournal
+
 	// onDespawn, when set, runs just before an entity leaves the world. The App
 	// uses it to fire Destroyer components. It is called with the write lock
 	// held, so it must not re-enter the World.
@@ -134,6 +138,7 @@ func (w *World) Spawn(e *Entity) *Entity {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.spawn(e)
+	w.changes.note(e, func(note *changeNote) { note.spawned = true })
 	return e
 }
 
@@ -149,6 +154,10 @@ func (w *World) Despawn(h Handle) bool {
 		return false
 	}
 
+	// Noted before it is detached, while its ancestors still say which
+	// subtrees it was in.
+	w.changes.note(entity, func(note *changeNote) { note.despawned = true })
+
 	if w.onDespawn != nil {
 		w.onDespawn(entity)
 	}
@@ -187,10 +196,16 @@ func (w *World) Replace(entities []*Entity) {
 		}
 	}
 
+	for _, outgoing := range w.entities {
+		outgoing.inWorld = nil
+	}
+
 	w.entities = make([]*Entity, 0, len(entities))
 	for _, entity := range entities {
 		w.spawn(entity)
 	}
+	// One scene-loaded change rather than a despawn and a spawn per entity.
+	w.changes.replaced()
 }
 
 // get resolves a handle. Callers hold at least the read lock.
@@ -222,6 +237,7 @@ func (w *World) spawn(e *Entity) {
 
 	w.slots[index].dense = len(w.entities)
 	e.handle = Handle{Index: index, Generation: w.slots[index].generation}
+	e.inWorld = w
 	w.entities = append(w.entities, e)
 }
 
@@ -230,6 +246,7 @@ func (w *World) spawn(e *Entity) {
 func (w *World) despawn(h Handle) {
 	dense := w.slots[h.Index].dense
 	last := len(w.entities) - 1
+	w.entities[dense].inWorld = nil
 
 	if dense != last {
 		moved := w.entities[last]
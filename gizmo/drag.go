@@ -0,0 +1,266 @@
+package gizmo
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Snap holds the increments a drag moves in. A zero field leaves that kind of
+// edit free.
+type Snap struct {
+	// Translate is a distance in world units.
+	Translate float32
+
+	// RotateDegrees is an angle.
+	RotateDegrees float32
+
+	// Scale is a step of the scale factor, so 0.1 moves 1.0 to 1.1 to 1.2.
+	Scale float32
+}
+
+func snap(value, step float32) float32 {
+	if step <= 0 {
+		return value
+	}
+	return float32(math.Round(float64(value/step))) * step
+}
+
+// minScaleFactor stops a scale drag at a tenth of a percent rather than at
+// zero. A zero scale is not undoable by scaling back up — anything times zero
+// stays zero — and it makes the model matrix singular.
+const minScaleFactor = 1e-3
+
+// Delta is what a drag has done so far, measured from where it started.
+type Delta struct {
+	Mode Mode
+	Axis Axis
+
+	// Translation is a world-space offset.
+	Translation mgl32.Vec3
+
+	// Rotation is a world-space turn about the drag's axis.
+	Rotation mgl32.Quat
+
+	// Scale multiplies each entity's local scale. It is one on the two axes
+	// not being dragged.
+	Scale mgl32.Vec3
+
+	// Amount is the snapped distance, angle in degrees or scale factor, for the
+	// editor to show next to the cursor.
+	Amount float32
+}
+
+// identityDelta is a drag that has not moved yet.
+func identityDelta(mode Mode, axis Axis) Delta {
+	delta := Delta{
+		Mode:     mode,
+		Axis:     axis,
+		Rotation: mgl32.QuatIdent(),
+		Scale:    mgl32.Vec3{1, 1, 1},
+	}
+	if mode == Scale {
+		delta.Amount = 1
+	}
+	return delta
+}
+
+// Drag follows the mouse along one handle. The frame and size are frozen when
+// it starts, so the handle being dragged cannot move out from under the cursor
+// as the selection moves.
+type Drag struct {
+	gizmo Gizmo
+	axis  Axis
+	snap  Snap
+
+	// startAlong is where on the axis the drag was grabbed, for move and scale.
+	startAlong float32
+	// startArm is the grab point's offset from the centre of a ring, for rotate.
+	startArm mgl32.Vec3
+
+	last Delta
+}
+
+// StartDrag grabs a handle. ok is false if the ray does not meet the handle's
+// axis or plane well enough to measure from, which Pick would already have
+// refused.
+func StartDrag(g Gizmo, axis Axis, ray Ray, snap Snap) (*Drag, bool) {
+	if axis == AxisNone {
+		return nil, false
+	}
+
+	d := &Drag{gizmo: g, axis: axis, snap: snap, last: identityDelta(g.Mode, axis)}
+	direction := g.Frame.Axis(axis)
+
+	switch g.Mode {
+	case Rotate:
+		arm, ok := d.arm(ray)
+		if !ok {
+			return nil, false
+		}
+		d.startArm = arm
+	default:
+		along, _, _, ok := ray.ClosestToLine(g.Frame.Origin, direction)
+		if !ok {
+			return nil, false
+		}
+		d.startAlong = along
+	}
+	return d, true
+}
+
+// Gizmo returns the handles as they were when the drag started.
+func (d *Drag) Gizmo() Gizmo { return d.gizmo }
+
+// Axis returns the handle being dragged.
+func (d *Drag) Axis() Axis { return d.axis }
+
+// arm is where the ray crosses the plane of the dragged ring, relative to the
+// centre.
+func (d *Drag) arm(ray Ray) (mgl32.Vec3, bool) {
+	origin := d.gizmo.Frame.Origin
+	t, ok := ray.IntersectPlane(origin, d.gizmo.Frame.Axis(d.axis))
+	if !ok {
+		return mgl32.Vec3{}, false
+	}
+	arm := ray.At(t).Sub(origin)
+	if arm.Len() < 1e-6 {
+		return mgl32.Vec3{}, false
+	}
+	return arm, true
+}
+
+// Update measures the drag against the current mouse ray. A ray the handle
+// cannot be measured against — the axis turned end-on to the camera, say —
+// keeps the last good answer rather than jumping.
+func (d *Drag) Update(ray Ray) Delta {
+	direction := d.gizmo.Frame.Axis(d.axis)
+	delta := identityDelta(d.gizmo.Mode, d.axis)
+
+	switch d.gizmo.Mode {
+	case Translate:
+		along, _, _, ok := ray.ClosestToLine(d.gizmo.Frame.Origin, direction)
+		if !ok {
+			return d.last
+		}
+		distance := snap(along-d.startAlong, d.snap.Translate)
+		delta.Translation = direction.Mul(distance)
+		delta.Amount = distance
+
+	case Rotate:
+		arm, ok := d.arm(ray)
+		if !ok {
+			return d.last
+		}
+		// Signed angle from the grab point to here, about the axis. atan2 of
+		// the sine against the cosine is well-behaved all the way round, where
+		// acos of the dot product loses the sign and the precision near zero.
+		sine := d.startArm.Cross(arm).Dot(direction)
+		cosine := d.startArm.Dot(arm)
+		degrees := mgl32.RadToDeg(float32(math.Atan2(float64(sine), float64(cosine))))
+		degrees = snap(degrees, d.snap.RotateDegrees)
+		delta.Rotation = mgl32.QuatRotate(mgl32.DegToRad(degrees), direction)
+		delta.Amount = degrees
+
+	case Scale:
+		along, _, _, ok := ray.ClosestToLine(d.gizmo.Frame.Origin, direction)
+		if !ok {
+			return d.last
+		}
+		// Relative to the handle's length rather than to where it was grabbed:
+		// a ratio against the grab point would explode for a grab near the
+		// origin, and dragging one handle-length out doubling the scale reads
+		// naturally.
+		factor := 1 + (along-d.startAlong)/d.gizmo.Size
+		factor = max(snap(factor, d.snap.Scale), minScaleFactor)
+		delta.Scale[d.axis.index()] = factor
+		delta.Amount = factor
+	}
+
+	d.last = delta
+	return delta
+}
+
+// Pose is a placement: the engine's Transform, without depending on the engine.
+type Pose struct {
+	Position mgl32.Vec3
+	Rotation mgl32.Quat
+	Scale    mgl32.Vec3
+}
+
+// Target is one entity being dragged, as it was when the drag started.
+type Target struct {
+	// Local is the entity's own transform, relative to its parent.
+	Local Pose
+
+	// Parent is the parent's local-to-world matrix, or the identity for an
+	// entity at the root.
+	Parent mgl32.Mat4
+}
+
+// WorldPosition is where the entity's origin is in the world.
+func (t Target) WorldPosition() mgl32.Vec3 {
+	return mgl32.TransformCoordinate(t.Local.Position, t.Parent)
+}
+
+// WorldRotation is the entity's orientation in the world. A parent with
+// non-uniform scale skews its children, which no rotation describes exactly;
+// this is the rotation part of the parent with the scale divided out, which is
+// what the handles are drawn with and close enough to drag by.
+func (t Target) WorldRotation() mgl32.Quat {
+	return rotationOf(t.Parent).Mul(t.Local.Rotation).Normalize()
+}
+
+func rotationOf(m mgl32.Mat4) mgl32.Quat {
+	basis := m.Mat3()
+	for i := range 3 {
+		column := basis.Col(i)
+		if length := column.Len(); length > 1e-9 {
+			basis.SetCol(i, column.Mul(1/length))
+		}
+	}
+	return mgl32.Mat4ToQuat(basis.Mat4()).Normalize()
+}
+
+// Apply is the target's new local transform after the delta. pivot is the
+// point rotation and scale happen about; pass the target's own WorldPosition
+// for PivotIndividual.
+//
+// The edit is made in world space, where the handles are, and converted back
+// through the parent's matrix at the end. That is what keeps a child moving
+// along the arrow it was dragged by even when its parent is turned or scaled:
+// adding the world offset to the local position would move it along its
+// parent's axes instead.
+func (d Delta) Apply(target Target, pivot mgl32.Vec3, frame Frame) Pose {
+	result := target.Local
+	position := target.WorldPosition()
+
+	switch d.Mode {
+	case Translate:
+		position = position.Add(d.Translation)
+
+	case Rotate:
+		position = pivot.Add(d.Rotation.Rotate(position.Sub(pivot)))
+		world := d.Rotation.Mul(target.WorldRotation())
+		result.Rotation = rotationOf(target.Parent).Inverse().Mul(world).Normalize()
+
+	case Scale:
+		result.Scale = mgl32.Vec3{
+			target.Local.Scale[0] * d.Scale[0],
+			target.Local.Scale[1] * d.Scale[1],
+			target.Local.Scale[2] * d.Scale[2],
+		}
+		// Spread the group out (or pull it in) along the same axis, so a row
+		// of fence posts scaled about its centre gets longer rather than each
+		// post just getting fatter.
+		offset := position.Sub(pivot)
+		for i, axis := range frame.Axes {
+			along := offset.Dot(axis)
+			offset = offset.Add(axis.Mul(along * (d.Scale[i] - 1)))
+		}
+		position = pivot.Add(offset)
+	}
+
+	result.Position = mgl32.TransformCoordinate(position, target.Parent.Inv())
+	return result
+}
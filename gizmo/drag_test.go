@@ -0,0 +1,199 @@
+package gizmo
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// lookingDown is a ray straight down -Z through (x, y), the view of a camera
+// on the Z axis looking at the XY plane.
+func lookingDown(x, y float32) Ray {
+	return Ray{Origin: mgl32.Vec3{x, y, 10}, Dir: mgl32.Vec3{0, 0, -1}}
+}
+
+func identityPose() Pose {
+	return Pose{Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}
+}
+
+func startDrag(t *testing.T, mode Mode, axis Axis, grab Ray, snap Snap) *Drag {
+	t.Helper()
+
+	g := Gizmo{Mode: mode, Frame: NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), mode, World), Size: 1}
+	drag, ok := StartDrag(g, axis, grab, snap)
+	if !ok {
+		t.Fatalf("could not grab %v %v", mode, axis)
+	}
+	return drag
+}
+
+func TestTranslateFollowsTheAxis(t *testing.T) {
+	drag := startDrag(t, Translate, AxisX, lookingDown(0.5, 0), Snap{})
+
+	// Moving off the axis sideways only counts the part along it.
+	delta := drag.Update(lookingDown(2.5, 0.4))
+	if !near(delta.Translation, mgl32.Vec3{2, 0, 0}) {
+		t.Errorf("translation = %v, want 2 along X", delta.Translation)
+	}
+	if abs(delta.Amount-2) > epsilon {
+		t.Errorf("amount = %v", delta.Amount)
+	}
+}
+
+func TestTranslateSnaps(t *testing.T) {
+	drag := startDrag(t, Translate, AxisX, lookingDown(0.5, 0), Snap{Translate: 0.5})
+
+	delta := drag.Update(lookingDown(1.8, 0))
+	if !near(delta.Translation, mgl32.Vec3{1.5, 0, 0}) {
+		t.Errorf("1.3 snapped to 0.5 steps = %v, want 1.5", delta.Translation.X())
+	}
+}
+
+func TestTranslateKeepsLastGoodDelta(t *testing.T) {
+	drag := startDrag(t, Translate, AxisX, lookingDown(0.5, 0), Snap{})
+	drag.Update(lookingDown(1.5, 0))
+
+	// A ray parallel to the axis has no nearest point on it.
+	along := Ray{Origin: mgl32.Vec3{0, 0, 0}, Dir: mgl32.Vec3{1, 0, 0}}
+	if delta := drag.Update(along); !near(delta.Translation, mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("degenerate ray gave %v, want the previous delta", delta.Translation)
+	}
+}
+
+func TestRotateMeasuresSignedAngle(t *testing.T) {
+	// Grab the Z ring at 3 o'clock and drag to 12 o'clock: a quarter turn
+	// anticlockwise seen from +Z, which is positive about Z.
+	drag := startDrag(t, Rotate, AxisZ, lookingDown(1, 0), Snap{})
+
+	delta := drag.Update(lookingDown(0, 1))
+	if abs(delta.Amount-90) > 1e-3 {
+		t.Errorf("angle = %v, want 90", delta.Amount)
+	}
+	if got := delta.Rotation.Rotate(mgl32.Vec3{1, 0, 0}); !near(got, mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("rotation takes X to %v, want Y", got)
+	}
+
+	delta = drag.Update(lookingDown(0, -1))
+	if abs(delta.Amount+90) > 1e-3 {
+		t.Errorf("clockwise angle = %v, want -90", delta.Amount)
+	}
+}
+
+func TestRotateSnaps(t *testing.T) {
+	drag := startDrag(t, Rotate, AxisZ, lookingDown(1, 0), Snap{RotateDegrees: 15})
+
+	// About 40 degrees.
+	delta := drag.Update(lookingDown(0.766, 0.643))
+	if abs(delta.Amount-45) > 1e-3 {
+		t.Errorf("snapped angle = %v, want 45", delta.Amount)
+	}
+}
+
+func TestScaleIsRelativeToHandleLength(t *testing.T) {
+	drag := startDrag(t, Scale, AxisY, lookingDown(0, 1), Snap{Scale: 0.25})
+
+	delta := drag.Update(lookingDown(0, 2.1))
+	if !near(delta.Scale, mgl32.Vec3{1, 2, 1}) {
+		t.Errorf("scale = %v, want 2 on Y only", delta.Scale)
+	}
+
+	// Dragged back through the origin, the scale bottoms out instead of
+	// reaching zero or flipping.
+	delta = drag.Update(lookingDown(0, -3))
+	if delta.Scale.Y() <= 0 {
+		t.Errorf("scale went to %v", delta.Scale.Y())
+	}
+}
+
+func TestApplyConvertsIntoParentSpace(t *testing.T) {
+	// A parent turned a quarter about Y and doubled in size: its local X is
+	// the world's -Z, and one local unit is two world units.
+	parent := mgl32.HomogRotate3DY(mgl32.DegToRad(90)).Mul4(mgl32.Scale3D(2, 2, 2))
+	target := Target{Local: identityPose(), Parent: parent}
+	target.Local.Position = mgl32.Vec3{1, 0, 0}
+
+	if got := target.WorldPosition(); !near(got, mgl32.Vec3{0, 0, -2}) {
+		t.Fatalf("world position = %v", got)
+	}
+
+	// Move +4 along world X: the child must go 4 world units in that direction,
+	// which is 2 local units along the parent's +Z.
+	delta := identityDelta(Translate, AxisX)
+	delta.Translation = mgl32.Vec3{4, 0, 0}
+	moved := delta.Apply(target, target.WorldPosition(), NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Translate, World))
+
+	if !near(moved.Position, mgl32.Vec3{1, 0, 2}) {
+		t.Errorf("local position = %v, want (1, 0, 2)", moved.Position)
+	}
+	moved.Position = mgl32.TransformCoordinate(moved.Position, parent)
+	if !near(moved.Position, mgl32.Vec3{4, 0, -2}) {
+		t.Errorf("world position after the move = %v", moved.Position)
+	}
+}
+
+func TestApplyRotatesAboutPivot(t *testing.T) {
+	quarter := identityDelta(Rotate, AxisY)
+	quarter.Rotation = mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 1, 0})
+	frame := NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Rotate, World)
+
+	target := Target{Local: identityPose(), Parent: mgl32.Ident4()}
+	target.Local.Position = mgl32.Vec3{3, 0, 0}
+
+	// About a shared pivot the entity swings round it...
+	swung := quarter.Apply(target, mgl32.Vec3{}, frame)
+	if !near(swung.Position, mgl32.Vec3{0, 0, -3}) {
+		t.Errorf("position about the origin = %v, want (0, 0, -3)", swung.Position)
+	}
+
+	// ...about its own origin it turns in place.
+	turned := quarter.Apply(target, target.WorldPosition(), frame)
+	if !near(turned.Position, target.Local.Position) {
+		t.Errorf("individual pivot moved the entity to %v", turned.Position)
+	}
+	for _, pose := range []Pose{swung, turned} {
+		if got := pose.Rotation.Rotate(mgl32.Vec3{1, 0, 0}); !near(got, mgl32.Vec3{0, 0, -1}) {
+			t.Errorf("rotation takes X to %v", got)
+		}
+	}
+}
+
+func TestApplyRotationUnderTurnedParent(t *testing.T) {
+	parentTurn := mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 0, 1})
+	target := Target{Local: identityPose(), Parent: parentTurn.Mat4()}
+
+	delta := identityDelta(Rotate, AxisY)
+	delta.Rotation = mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 1, 0})
+	rotated := delta.Apply(target, mgl32.Vec3{}, NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Rotate, World))
+
+	// The world rotation must be the world-space turn applied after the
+	// parent's, whatever the local value has to be to get there.
+	world := parentTurn.Mul(rotated.Rotation)
+	want := delta.Rotation.Mul(parentTurn)
+	for _, v := range []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		if !near(world.Rotate(v), want.Rotate(v)) {
+			t.Errorf("%v ends up at %v, want %v", v, world.Rotate(v), want.Rotate(v))
+		}
+	}
+}
+
+func TestApplyScaleSpreadsGroup(t *testing.T) {
+	double := identityDelta(Scale, AxisX)
+	double.Scale = mgl32.Vec3{2, 1, 1}
+	frame := NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Scale, World)
+
+	target := Target{Local: identityPose(), Parent: mgl32.Ident4()}
+	target.Local.Position = mgl32.Vec3{1, 1, 0}
+
+	scaled := double.Apply(target, mgl32.Vec3{}, frame)
+	if !near(scaled.Position, mgl32.Vec3{2, 1, 0}) {
+		t.Errorf("position about the centre = %v, want (2, 1, 0)", scaled.Position)
+	}
+	if !near(scaled.Scale, mgl32.Vec3{2, 1, 1}) {
+		t.Errorf("scale = %v", scaled.Scale)
+	}
+
+	inPlace := double.Apply(target, target.WorldPosition(), frame)
+	if !near(inPlace.Position, target.Local.Position) {
+		t.Errorf("individual pivot moved the entity to %v", inPlace.Position)
+	}
+}
@@ -0,0 +1,240 @@
+package gizmo
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mode is which transform the handles edit.
+type Mode int
+
+const (
+	Translate Mode = iota
+	Rotate
+	Scale
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Translate:
+		return "Move"
+	case Rotate:
+		return "Rotate"
+	case Scale:
+		return "Scale"
+	}
+	return "Unknown"
+}
+
+// Space is the orientation of the handles.
+type Space int
+
+const (
+	// World lines the handles up with the world axes, so "up" is up whatever
+	// way the selection is turned.
+	World Space = iota
+
+	// Local lines them up with the active entity's own axes, so a tilted shelf
+	// slides along its own length.
+	Local
+)
+
+// Axis names a handle. AxisNone is the zero value, so an unset Axis means
+// nothing is hovered or held.
+type Axis int
+
+const (
+	AxisNone Axis = iota
+	AxisX
+	AxisY
+	AxisZ
+)
+
+func (a Axis) String() string {
+	switch a {
+	case AxisX:
+		return "X"
+	case AxisY:
+		return "Y"
+	case AxisZ:
+		return "Z"
+	}
+	return "none"
+}
+
+// index is the axis as 0, 1 or 2. Only valid for X, Y and Z.
+func (a Axis) index() int {
+	return int(a - AxisX)
+}
+
+// Axes lists the three handles, in the order they are drawn and picked.
+var Axes = [3]Axis{AxisX, AxisY, AxisZ}
+
+// Pivot is the point a multi-selection rotates and scales about.
+type Pivot int
+
+const (
+	// PivotCenter turns the selection as one rigid group about the mean of the
+	// entities' positions.
+	PivotCenter Pivot = iota
+
+	// PivotActive turns the group about the active (first selected) entity,
+	// which is what you want when one of them is the thing the rest hang off.
+	PivotActive
+
+	// PivotIndividual turns each entity in place about its own origin. The
+	// handles are still drawn at the centre, since there is only one gizmo.
+	PivotIndividual
+)
+
+func (p Pivot) String() string {
+	switch p {
+	case PivotCenter:
+		return "Center"
+	case PivotActive:
+		return "Active"
+	case PivotIndividual:
+		return "Individual"
+	}
+	return "Unknown"
+}
+
+// PivotPoint is where the gizmo sits for the given world positions, the active
+// entity's first.
+func PivotPoint(pivot Pivot, positions []mgl32.Vec3) mgl32.Vec3 {
+	if len(positions) == 0 {
+		return mgl32.Vec3{}
+	}
+	if pivot == PivotActive {
+		return positions[0]
+	}
+
+	var sum mgl32.Vec3
+	for _, p := range positions {
+		sum = sum.Add(p)
+	}
+	return sum.Mul(1 / float32(len(positions)))
+}
+
+// Frame is where the gizmo sits and which way its handles point. The axes are
+// unit length and mutually perpendicular.
+type Frame struct {
+	Origin mgl32.Vec3
+	Axes   [3]mgl32.Vec3
+}
+
+// NewFrame orients a gizmo at origin. rotation is the active entity's world
+// rotation, used in local space.
+//
+// Scale ignores space and is always local: a scale is stored per local axis,
+// so stretching a turned entity along a world axis would need a shear the
+// transform cannot hold. Every editor that offers world-space scaling ends up
+// quietly doing the same thing.
+func NewFrame(origin mgl32.Vec3, rotation mgl32.Quat, mode Mode, space Space) Frame {
+	frame := Frame{
+		Origin: origin,
+		Axes:   [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+	if space == Local || mode == Scale {
+		for i, axis := range frame.Axes {
+			frame.Axes[i] = rotation.Rotate(axis).Normalize()
+		}
+	}
+	return frame
+}
+
+// Axis returns the direction of one handle.
+func (f Frame) Axis(axis Axis) mgl32.Vec3 {
+	return f.Axes[axis.index()]
+}
+
+// Gizmo is one set of handles as drawn this frame.
+type Gizmo struct {
+	Mode  Mode
+	Frame Frame
+
+	// Size is the world-space length of an axis handle and the radius of a
+	// rotation ring. HandleSize gives one that stays constant on screen.
+	Size float32
+}
+
+// pickTolerance is how close, as a fraction of the handle size, the ray has to
+// pass to a handle to grab it. About a tenth is a few pixels either side of the
+// line as drawn, which is forgiving without letting neighbouring handles
+// overlap near the origin.
+const pickTolerance = 0.1
+
+// ringSegments is how many straight pieces a rotation ring is drawn with.
+const ringSegments = 64
+
+// Pick returns the handle under the ray, or AxisNone. Where two handles are
+// both in reach the closer one to the ray wins for axes, and the nearer one to
+// the camera for rings, which is the one drawn on top.
+func (g Gizmo) Pick(ray Ray) Axis {
+	best := AxisNone
+	bestScore := float32(math.MaxFloat32)
+	tolerance := g.Size * pickTolerance
+
+	for _, axis := range Axes {
+		direction := g.Frame.Axis(axis)
+
+		var score float32
+		switch g.Mode {
+		case Rotate:
+			t, ok := ray.IntersectPlane(g.Frame.Origin, direction)
+			if !ok {
+				continue
+			}
+			radius := ray.At(t).Sub(g.Frame.Origin).Len()
+			if abs(radius-g.Size) > tolerance {
+				continue
+			}
+			score = t
+
+		default:
+			along, rayT, distance, ok := ray.ClosestToLine(g.Frame.Origin, direction)
+			if !ok || rayT < 0 || distance > tolerance {
+				continue
+			}
+			// A little past the tip, where the arrowhead or box is drawn.
+			if along < 0 || along > g.Size*(1+2*pickTolerance) {
+				continue
+			}
+			score = distance
+		}
+
+		if score < bestScore {
+			best, bestScore = axis, score
+		}
+	}
+	return best
+}
+
+// Segments returns the pieces of line that draw the given handle, in world
+// space, for the editor to project and stroke. An axis is one segment; a ring
+// is ringSegments of them.
+func (g Gizmo) Segments(axis Axis) [][2]mgl32.Vec3 {
+	origin := g.Frame.Origin
+	direction := g.Frame.Axis(axis)
+
+	if g.Mode != Rotate {
+		return [][2]mgl32.Vec3{{origin, origin.Add(direction.Mul(g.Size))}}
+	}
+
+	// Two vectors spanning the ring's plane: the other two axes of the frame.
+	u := g.Frame.Axes[(axis.index()+1)%3]
+	v := g.Frame.Axes[(axis.index()+2)%3]
+
+	segments := make([][2]mgl32.Vec3, 0, ringSegments)
+	previous := origin.Add(u.Mul(g.Size))
+	for i := 1; i <= ringSegments; i++ {
+		angle := 2 * math.Pi * float64(i) / ringSegments
+		point := origin.
+			Add(u.Mul(g.Size * float32(math.Cos(angle)))).
+			Add(v.Mul(g.Size * float32(math.Sin(angle))))
+		segments = append(segments, [2]mgl32.Vec3{previous, point})
+		previous = point
+	}
+	return segments
+}
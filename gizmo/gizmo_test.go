@@ -0,0 +1,199 @@
+package gizmo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const epsilon = 1e-4
+
+// near compares absolutely. mgl32's ApproxEqual is relative, which fails the
+// float noise a rotation leaves where the answer is exactly zero.
+func near(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < epsilon
+}
+
+// testCamera looks down -Z from z = 10 at an 800x600 viewport.
+func testCamera() (view, projection mgl32.Mat4) {
+	view = mgl32.LookAtV(mgl32.Vec3{0, 0, 10}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	projection = mgl32.Perspective(mgl32.DegToRad(60), 800.0/600.0, 0.1, 100)
+	return view, projection
+}
+
+func TestScreenRayThroughCentre(t *testing.T) {
+	view, projection := testCamera()
+
+	ray := ScreenRay(400, 300, 800, 600, view, projection)
+	if !near(ray.Dir, mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("centre ray direction = %v, want straight ahead", ray.Dir)
+	}
+	if abs(ray.Origin.Z()-9.9) > epsilon {
+		t.Errorf("centre ray starts at %v, want the near plane", ray.Origin)
+	}
+}
+
+func TestProjectInvertsScreenRay(t *testing.T) {
+	view, projection := testCamera()
+
+	for _, point := range []mgl32.Vec3{{0, 0, 0}, {2, 1, -3}, {-4, -2, 5}} {
+		pixel, ok := Project(point, view, projection, 800, 600)
+		if !ok {
+			t.Fatalf("%v in front of the camera did not project", point)
+		}
+		ray := ScreenRay(pixel.X(), pixel.Y(), 800, 600, view, projection)
+		_, _, distance, _ := ray.ClosestToLine(point, mgl32.Vec3{1, 0, 0})
+		if distance > 1e-3 {
+			t.Errorf("ray through %v's pixel %v misses it by %v", point, pixel, distance)
+		}
+	}
+
+	// Y grows downwards on screen.
+	if above, _ := Project(mgl32.Vec3{0, 1, 0}, view, projection, 800, 600); above.Y() >= 300 {
+		t.Errorf("a point above the centre projects to y = %v", above.Y())
+	}
+	if _, ok := Project(mgl32.Vec3{0, 0, 20}, view, projection, 800, 600); ok {
+		t.Error("a point behind the camera projected")
+	}
+}
+
+func TestClosestToLine(t *testing.T) {
+	// A ray along -Z passing over the X axis at x = 3, two units above it.
+	ray := Ray{Origin: mgl32.Vec3{3, 2, 10}, Dir: mgl32.Vec3{0, 0, -1}}
+
+	along, rayT, distance, ok := ray.ClosestToLine(mgl32.Vec3{}, mgl32.Vec3{1, 0, 0})
+	if !ok {
+		t.Fatal("perpendicular lines reported parallel")
+	}
+	if abs(along-3) > epsilon || abs(rayT-10) > epsilon || abs(distance-2) > epsilon {
+		t.Errorf("along %v, rayT %v, distance %v; want 3, 10, 2", along, rayT, distance)
+	}
+
+	if _, _, _, ok := ray.ClosestToLine(mgl32.Vec3{}, mgl32.Vec3{0, 0, 1}); ok {
+		t.Error("parallel lines reported a closest point")
+	}
+}
+
+func TestIntersectPlane(t *testing.T) {
+	ray := Ray{Origin: mgl32.Vec3{1, 5, 0}, Dir: mgl32.Vec3{0, -1, 0}}
+
+	hit, ok := ray.IntersectPlane(mgl32.Vec3{0, 2, 0}, mgl32.Vec3{0, 1, 0})
+	if !ok || abs(hit-3) > epsilon {
+		t.Errorf("IntersectPlane = %v, %v; want 3", hit, ok)
+	}
+	if _, ok := ray.IntersectPlane(mgl32.Vec3{0, 8, 0}, mgl32.Vec3{0, 1, 0}); ok {
+		t.Error("plane behind the ray was hit")
+	}
+	if _, ok := ray.IntersectPlane(mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}); ok {
+		t.Error("plane the ray runs along was hit")
+	}
+}
+
+func TestHandleSizeScalesWithDistance(t *testing.T) {
+	close := HandleSize(5, 60, 600, 100)
+	far := HandleSize(50, 60, 600, 100)
+	if abs(far/close-10) > epsilon {
+		t.Errorf("ten times the distance gives %v times the size", far/close)
+	}
+
+	// At 90 degrees the visible height is twice the distance.
+	if got := HandleSize(10, 90, 600, 300); abs(got-10) > epsilon {
+		t.Errorf("half the screen at distance 10 and 90 degrees = %v, want 10", got)
+	}
+}
+
+func TestPickAxis(t *testing.T) {
+	g := Gizmo{Mode: Translate, Frame: NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Translate, World), Size: 1}
+
+	down := func(x, y float32) Ray {
+		return Ray{Origin: mgl32.Vec3{x, y, 10}, Dir: mgl32.Vec3{0, 0, -1}}
+	}
+
+	cases := []struct {
+		ray  Ray
+		want Axis
+	}{
+		{down(0.5, 0.02), AxisX},
+		{down(0.01, 0.7), AxisY},
+		{down(0.5, 0.5), AxisNone},
+		{down(-0.5, 0), AxisNone}, // behind the origin: the handle only goes one way
+		{down(1.5, 0), AxisNone},  // past the tip
+		// Z points straight at the camera, so it cannot be dragged from here.
+		{down(0, 0.5), AxisY},
+	}
+	for _, c := range cases {
+		if got := g.Pick(c.ray); got != c.want {
+			t.Errorf("Pick(%v) = %v, want %v", c.ray.Origin, got, c.want)
+		}
+	}
+}
+
+func TestPickRing(t *testing.T) {
+	g := Gizmo{Mode: Rotate, Frame: NewFrame(mgl32.Vec3{}, mgl32.QuatIdent(), Rotate, World), Size: 2}
+
+	// Looking down Z, the Z ring is the circle of radius 2 facing the camera.
+	onRing := Ray{Origin: mgl32.Vec3{0, 2, 10}, Dir: mgl32.Vec3{0, 0, -1}}
+	if got := g.Pick(onRing); got != AxisZ {
+		t.Errorf("ray on the Z ring picked %v", got)
+	}
+
+	inside := Ray{Origin: mgl32.Vec3{0.7, 0.7, 10}, Dir: mgl32.Vec3{0, 0, -1}}
+	if got := g.Pick(inside); got != AxisNone {
+		t.Errorf("ray inside the ring picked %v", got)
+	}
+}
+
+func TestLocalFrameFollowsRotation(t *testing.T) {
+	turned := mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 1, 0})
+
+	local := NewFrame(mgl32.Vec3{}, turned, Translate, Local)
+	if !near(local.Axis(AxisX), mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("local X after a quarter turn about Y = %v", local.Axis(AxisX))
+	}
+
+	world := NewFrame(mgl32.Vec3{}, turned, Translate, World)
+	if !near(world.Axis(AxisX), mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("world X = %v", world.Axis(AxisX))
+	}
+
+	// Scale is local whatever the space says.
+	scale := NewFrame(mgl32.Vec3{}, turned, Scale, World)
+	if !near(scale.Axis(AxisX), local.Axis(AxisX)) {
+		t.Errorf("world-space scale frame X = %v, want the local axis", scale.Axis(AxisX))
+	}
+}
+
+func TestPivotPoint(t *testing.T) {
+	positions := []mgl32.Vec3{{2, 0, 0}, {4, 0, 0}, {0, 6, 0}}
+
+	if got := PivotPoint(PivotCenter, positions); !near(got, mgl32.Vec3{2, 2, 0}) {
+		t.Errorf("centre = %v", got)
+	}
+	if got := PivotPoint(PivotActive, positions); !near(got, positions[0]) {
+		t.Errorf("active = %v", got)
+	}
+	if got := PivotPoint(PivotCenter, nil); got != (mgl32.Vec3{}) {
+		t.Errorf("empty selection pivot = %v", got)
+	}
+}
+
+func TestRingSegmentsCloseTheLoop(t *testing.T) {
+	g := Gizmo{Mode: Rotate, Frame: NewFrame(mgl32.Vec3{1, 1, 1}, mgl32.QuatIdent(), Rotate, World), Size: 3}
+
+	segments := g.Segments(AxisY)
+	if len(segments) != ringSegments {
+		t.Fatalf("%d segments", len(segments))
+	}
+	if !near(segments[0][0], segments[len(segments)-1][1]) {
+		t.Error("ring does not close")
+	}
+	for _, segment := range segments {
+		if segment[0].Y() != 1 {
+			t.Fatalf("Y ring leaves its plane at %v", segment[0])
+		}
+		if radius := segment[0].Sub(g.Frame.Origin).Len(); math.Abs(float64(radius-3)) > epsilon {
+			t.Fatalf("point %v at radius %v", segment[0], radius)
+		}
+	}
+}
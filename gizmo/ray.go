@@ -0,0 +1,130 @@
+// Package gizmo is the maths behind the editor's translate, rotate and scale
+// handles: turning the mouse into a ray, deciding which handle the ray is on,
+// and turning a drag along a handle into a change of transform.
+//
+// It is pure Go on top of mgl32, with no GL, no ImGui and no engine types, so
+// every part of it can be tested without a window. The editor owns the drawing
+// and the input; this package only ever sees rays and matrices.
+package gizmo
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// parallel is the sine below which two directions are treated as parallel.
+// Dragging along an axis that points straight at the camera would turn a
+// pixel of mouse movement into an unbounded distance, so those cases report
+// no answer instead.
+const parallel = 1e-3
+
+// Ray is a half-line in world space. Dir is unit length.
+type Ray struct {
+	Origin mgl32.Vec3
+	Dir    mgl32.Vec3
+}
+
+// At returns the point t along the ray.
+func (r Ray) At(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Dir.Mul(t))
+}
+
+// ScreenRay unprojects a point on the screen into a world-space ray starting
+// on the near plane. x and y are in pixels from the top-left corner, which is
+// how both GLFW and ImGui report the cursor; the flip to GL's bottom-up NDC
+// happens here so no caller has to remember it.
+func ScreenRay(x, y, width, height float32, view, projection mgl32.Mat4) Ray {
+	ndcX := 2*x/width - 1
+	ndcY := 1 - 2*y/height
+
+	inverse := projection.Mul4(view).Inv()
+	near := unproject(inverse, mgl32.Vec4{ndcX, ndcY, -1, 1})
+	far := unproject(inverse, mgl32.Vec4{ndcX, ndcY, 1, 1})
+
+	return Ray{Origin: near, Dir: far.Sub(near).Normalize()}
+}
+
+func unproject(inverse mgl32.Mat4, ndc mgl32.Vec4) mgl32.Vec3 {
+	p := inverse.Mul4x1(ndc)
+	return p.Vec3().Mul(1 / p.W())
+}
+
+// Project is ScreenRay's inverse: the pixel a world-space point lands on. It
+// reports false for a point behind the camera, whose projection would come
+// out mirrored through the centre of the screen rather than off it.
+func Project(point mgl32.Vec3, view, projection mgl32.Mat4, width, height float32) (mgl32.Vec2, bool) {
+	clip := projection.Mul4(view).Mul4x1(point.Vec4(1))
+	if clip.W() <= 1e-6 {
+		return mgl32.Vec2{}, false
+	}
+
+	ndcX := clip.X() / clip.W()
+	ndcY := clip.Y() / clip.W()
+	return mgl32.Vec2{(ndcX + 1) / 2 * width, (1 - ndcY) / 2 * height}, true
+}
+
+// ClosestToLine finds where the ray comes nearest the infinite line through
+// origin along dir. along is the distance from origin along the line, rayT the
+// distance along the ray, and distance the gap between the two points. ok is
+// false when the two are parallel and there is no single nearest point.
+//
+// It is what an axis handle is built on: the point on the axis under the
+// cursor is the one nearest the mouse ray, and following it as the mouse moves
+// is the drag.
+func (r Ray) ClosestToLine(origin, dir mgl32.Vec3) (along, rayT, distance float32, ok bool) {
+	dir = dir.Normalize()
+	w := r.Origin.Sub(origin)
+
+	b := r.Dir.Dot(dir)
+	d := r.Dir.Dot(w)
+	e := dir.Dot(w)
+
+	// With both directions unit length the usual a*c - b*b is 1 - b*b, the
+	// squared sine of the angle between them.
+	denominator := 1 - b*b
+	if denominator < parallel*parallel {
+		return 0, 0, 0, false
+	}
+
+	rayT = (b*e - d) / denominator
+	along = (e - b*d) / denominator
+
+	gap := r.At(rayT).Sub(origin.Add(dir.Mul(along)))
+	return along, rayT, gap.Len(), true
+}
+
+// IntersectPlane returns how far along the ray it crosses the plane through
+// point with the given normal. ok is false for a ray running along the plane
+// or pointing away from it.
+func (r Ray) IntersectPlane(point, normal mgl32.Vec3) (t float32, ok bool) {
+	denominator := r.Dir.Dot(normal)
+	if abs(denominator) < parallel {
+		return 0, false
+	}
+
+	t = point.Sub(r.Origin).Dot(normal) / denominator
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// HandleSize is the world-space length that spans pixels on screen at the given
+// distance from the camera. Sizing the gizmo with it keeps the handles the same
+// size on screen however far away the selection is, which is the only way they
+// stay grabbable on both a distant building and a teacup at the camera's feet.
+func HandleSize(distance, fovYDegrees, viewportHeight, pixels float32) float32 {
+	if viewportHeight <= 0 {
+		return 1
+	}
+	visible := 2 * distance * float32(math.Tan(float64(mgl32.DegToRad(fovYDegrees))/2))
+	return visible * pixels / viewportHeight
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -42,6 +43,81 @@ const (
 	// Remove an object together with everything below it. REMOVE_OBJECT leaves
 	// the children behind, lifted to the scene root.
 	Operation_OPERATION_REMOVE_TREE Operation = 12
+	// Step the edit history. Every mutating operation above is recorded, as
+	// are the editor's, so these undo whichever came last. The response carries
+	// a History body.
+	Operation_OPERATION_UNDO Operation = 13
+	Operation_OPERATION_REDO Operation = 14
+	// Start pushing world changes on this stream. The request's subscription
+	// body narrows them; without one, everything is sent. The reply is an empty
+	// success, and after it come responses with this operation and an Events
+	// body, one per frame that changed anything the filter passes, in between
+	// the replies to whatever else the client asks. Subscribing again replaces
+	// the filter.
+	Operation_OPERATION_SUBSCRIBE Operation = 15
+	// Stop pushing changes. No Events response follows the reply.
+	Operation_OPERATION_UNSUBSCRIBE Operation = 16
+	// List the component types that can be added, each with the properties a
+	// new one starts with. The response carries a ComponentTypes body.
+	Operation_OPERATION_GET_COMPONENT_TYPES Operation = 17
+	// List object.id's components with their current properties. The response
+	// carries a Components body.
+	Operation_OPERATION_GET_COMPONENTS Operation = 18
+	// Set properties on one component. The component body names the object,
+	// the component's index and, as a check, its type: an index that now holds
+	// a different type fails rather than editing the wrong component.
+	Operation_OPERATION_SET_COMPONENT_FIELD Operation = 19
+	// Add a component of component.type, its fields written over the type's
+	// defaults. The response carries the object's components, the new one last.
+	Operation_OPERATION_ADD_COMPONENT Operation = 20
+	// Remove the component at component.component, checked against
+	// component.type like SET_COMPONENT_FIELD.
+	Operation_OPERATION_REMOVE_COMPONENT Operation = 21
+	// Apply the requests in the batch body together: in order, in one frame,
+	// and as one undo step. If any of them fails, the ones before it are rolled
+	// back and the ones after it are not run, so the world is as it was. The
+	// response carries a BatchResults body with a result for each request,
+	// even when it fails. Scene loads and saves, undo and redo, camera moves,
+	// subscriptions, frame streams and batches cannot be batched.
+	Operation_OPERATION_BATCH Operation = 22
+	// Describe the live world as a scene, in the encoding the scene_query body
+	// asks for. The response's SceneDocument holds it as a serialized document,
+	// or, for SCENE_ENCODING_PROTO, as a SceneDescription.
+	Operation_OPERATION_GET_SCENE Operation = 23
+	// Save the live world to the path in the scene body, or to the current
+	// scene's own file if it has none. The response's scene body is the path
+	// written. With an RPC scene root configured, a path sent by the client
+	// must be inside it.
+	Operation_OPERATION_SAVE_SCENE Operation = 24
+	// Load the scene in the scene_document body, as LOAD_SCENE loads a file:
+	// in the background, replacing the current scene when it is ready. The
+	// document's path, if any, is what the scene is known by afterwards, and
+	// where a SAVE_SCENE with no path writes it. With an RPC scene root
+	// configured, the path and every file the scene reads must be inside it.
+	Operation_OPERATION_LOAD_SCENE_DOCUMENT Operation = 25
+	// Read the object whose id is in the object body.
+	Operation_OPERATION_GET_OBJECT Operation = 26
+	// Set the base_color of the object whose id is in the object body: the
+	// tint of the parts of its model with no texture of their own.
+	Operation_OPERATION_SET_BASE_COLOR Operation = 27
+	// Read the camera's pose. The response carries a CameraPose body.
+	Operation_OPERATION_GET_CAMERA Operation = 28
+	// Move the camera to the pose in the camera body. A field left out keeps
+	// its current value. The response carries the pose the camera ends up in,
+	// with the pitch and fov kept to the range the mouse and wheel allow.
+	Operation_OPERATION_SET_CAMERA Operation = 29
+	// Put the camera back where the current scene starts it, with the
+	// configured fov. The response carries the pose.
+	Operation_OPERATION_RESET_CAMERA Operation = 30
+	// Start sending rendered frames of the camera's view on this stream, at the
+	// rate, size and encoding in the frames body. The reply is an empty
+	// success, and after it come responses with this operation and a Frame
+	// body, in between the replies to whatever else the client asks. A frame
+	// the client is not ready for is skipped rather than queued; the next one
+	// says how many were. Streaming again replaces the request.
+	Operation_OPERATION_STREAM_FRAMES Operation = 31
+	// Stop sending frames. No Frame response follows the reply.
+	Operation_OPERATION_STOP_FRAMES Operation = 32
 )
 
 // Enum value maps for Operation.
@@ -60,21 +136,61 @@ var (
 		10: "OPERATION_LOAD_SCENE_MODE",
 		11: "OPERATION_SET_PARENT",
 		12: "OPERATION_REMOVE_TREE",
+		13: "OPERATION_UNDO",
+		14: "OPERATION_REDO",
+		15: "OPERATION_SUBSCRIBE",
+		16: "OPERATION_UNSUBSCRIBE",
+		17: "OPERATION_GET_COMPONENT_TYPES",
+		18: "OPERATION_GET_COMPONENTS",
+		19: "OPERATION_SET_COMPONENT_FIELD",
+		20: "OPERATION_ADD_COMPONENT",
+		21: "OPERATION_REMOVE_COMPONENT",
+		22: "OPERATION_BATCH",
+		23: "OPERATION_GET_SCENE",
+		24: "OPERATION_SAVE_SCENE",
+		25: "OPERATION_LOAD_SCENE_DOCUMENT",
+		26: "OPERATION_GET_OBJECT",
+		27: "OPERATION_SET_BASE_COLOR",
+		28: "OPERATION_GET_CAMERA",
+		29: "OPERATION_SET_CAMERA",
+		30: "OPERATION_RESET_CAMERA",
+		31: "OPERATION_STREAM_FRAMES",
+		32: "OPERATION_STOP_FRAMES",
 	}
 	Operation_value = map[string]int32{
-		"OPERATION_UNSPECIFIED":     0,
-		"OPERATION_GET_OBJECTS":     1,
-		"OPERATION_ADD_OBJECT":      2,
-		"OPERATION_REMOVE_OBJECT":   3,
-		"OPERATION_MOVE_OBJECT":     4,
-		"OPERATION_ROTATE_OBJECT":   5,
-		"OPERATION_SCALE_OBJECT":    6,
-		"OPERATION_UPDATE_OBJECT":   7,
-		"OPERATION_LOAD_SCENE":      8,
-		"OPERATION_GET_SCENE_MODES": 9,
-		"OPERATION_LOAD_SCENE_MODE": 10,
-		"OPERATION_SET_PARENT":      11,
-		"OPERATION_REMOVE_TREE":     12,
+		"OPERATION_UNSPECIFIED":         0,
+		"OPERATION_GET_OBJECTS":         1,
+		"OPERATION_ADD_OBJECT":          2,
+		"OPERATION_REMOVE_OBJECT":       3,
+		"OPERATION_MOVE_OBJECT":         4,
+		"OPERATION_ROTATE_OBJECT":       5,
+		"OPERATION_SCALE_OBJECT":        6,
+		"OPERATION_UPDATE_OBJECT":       7,
+		"OPERATION_LOAD_SCENE":          8,
+		"OPERATION_GET_SCENE_MODES":     9,
+		"OPERATION_LOAD_SCENE_MODE":     10,
+		"OPERATION_SET_PARENT":          11,
+		"OPERATION_REMOVE_TREE":         12,
+		"OPERATION_UNDO":                13,
+		"OPERATION_REDO":                14,
+		"OPERATION_SUBSCRIBE":           15,
+		"OPERATION_UNSUBSCRIBE":         16,
+		"OPERATION_GET_COMPONENT_TYPES": 17,
+		"OPERATION_GET_COMPONENTS":      18,
+		"OPERATION_SET_COMPONENT_FIELD": 19,
+		"OPERATION_ADD_COMPONENT":       20,
+		"OPERATION_REMOVE_COMPONENT":    21,
+		"OPERATION_BATCH":               22,
+		"OPERATION_GET_SCENE":           23,
+		"OPERATION_SAVE_SCENE":          24,
+		"OPERATION_LOAD_SCENE_DOCUMENT": 25,
+		"OPERATION_GET_OBJECT":          26,
+		"OPERATION_SET_BASE_COLOR":      27,
+		"OPERATION_GET_CAMERA":          28,
+		"OPERATION_SET_CAMERA":          29,
+		"OPERATION_RESET_CAMERA":        30,
+		"OPERATION_STREAM_FRAMES":       31,
+		"OPERATION_STOP_FRAMES":         32,
 	}
 )
 
@@ -105,6 +221,183 @@ func (Operation) EnumDescriptor() ([]byte, []int) {
 	return file_grpc_engine_proto_rawDescGZIP(), []int{0}
 }
 
+type FrameEncoding int32
+
+const (
+	// PNG.
+	FrameEncoding_FRAME_ENCODING_UNSPECIFIED FrameEncoding = 0
+	FrameEncoding_FRAME_ENCODING_PNG         FrameEncoding = 1
+	FrameEncoding_FRAME_ENCODING_JPEG        FrameEncoding = 2
+)
+
+// Enum value maps for FrameEncoding.
+var (
+	FrameEncoding_name = map[int32]string{
+		0: "FRAME_ENCODING_UNSPECIFIED",
+		1: "FRAME_ENCODING_PNG",
+		2: "FRAME_ENCODING_JPEG",
+	}
+	FrameEncoding_value = map[string]int32{
+		"FRAME_ENCODING_UNSPECIFIED": 0,
+		"FRAME_ENCODING_PNG":         1,
+		"FRAME_ENCODING_JPEG":        2,
+	}
+)
+
+func (x FrameEncoding) Enum() *FrameEncoding {
+	p := new(FrameEncoding)
+	*p = x
+	return p
+}
+
+func (x FrameEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FrameEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_grpc_engine_proto_enumTypes[1].Descriptor()
+}
+
+func (FrameEncoding) Type() protoreflect.EnumType {
+	return &file_grpc_engine_proto_enumTypes[1]
+}
+
+func (x FrameEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FrameEncoding.Descriptor instead.
+func (FrameEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{1}
+}
+
+type ChangeKind int32
+
+const (
+	ChangeKind_CHANGE_KIND_UNSPECIFIED ChangeKind = 0
+	ChangeKind_CHANGE_KIND_SPAWNED     ChangeKind = 1
+	ChangeKind_CHANGE_KIND_DESPAWNED   ChangeKind = 2
+	ChangeKind_CHANGE_KIND_REPARENTED  ChangeKind = 3
+	ChangeKind_CHANGE_KIND_TRANSFORM   ChangeKind = 4
+	ChangeKind_CHANGE_KIND_FIELD       ChangeKind = 5
+	// The world was swapped for a new scene. No per-object events come with
+	// it; fetch GET_OBJECTS again.
+	ChangeKind_CHANGE_KIND_SCENE_LOADED ChangeKind = 6
+	// Components were added to the object or removed from it, which moves the
+	// ones after them to new indices. The change carries the new list.
+	ChangeKind_CHANGE_KIND_COMPONENTS ChangeKind = 7
+)
+
+// Enum value maps for ChangeKind.
+var (
+	ChangeKind_name = map[int32]string{
+		0: "CHANGE_KIND_UNSPECIFIED",
+		1: "CHANGE_KIND_SPAWNED",
+		2: "CHANGE_KIND_DESPAWNED",
+		3: "CHANGE_KIND_REPARENTED",
+		4: "CHANGE_KIND_TRANSFORM",
+		5: "CHANGE_KIND_FIELD",
+		6: "CHANGE_KIND_SCENE_LOADED",
+		7: "CHANGE_KIND_COMPONENTS",
+	}
+	ChangeKind_value = map[string]int32{
+		"CHANGE_KIND_UNSPECIFIED":  0,
+		"CHANGE_KIND_SPAWNED":      1,
+		"CHANGE_KIND_DESPAWNED":    2,
+		"CHANGE_KIND_REPARENTED":   3,
+		"CHANGE_KIND_TRANSFORM":    4,
+		"CHANGE_KIND_FIELD":        5,
+		"CHANGE_KIND_SCENE_LOADED": 6,
+		"CHANGE_KIND_COMPONENTS":   7,
+	}
+)
+
+func (x ChangeKind) Enum() *ChangeKind {
+	p := new(ChangeKind)
+	*p = x
+	return p
+}
+
+func (x ChangeKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_grpc_engine_proto_enumTypes[2].Descriptor()
+}
+
+func (ChangeKind) Type() protoreflect.EnumType {
+	return &file_grpc_engine_proto_enumTypes[2]
+}
+
+func (x ChangeKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeKind.Descriptor instead.
+func (ChangeKind) EnumDescriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{2}
+}
+
+// SceneEncoding is how a scene travels. The first three are the scene file
+// formats, byte for byte what a file of that format would hold; PROTO is the
+// structured SceneDescription, which GET_SCENE returns but a load does not
+// take.
+type SceneEncoding int32
+
+const (
+	SceneEncoding_SCENE_ENCODING_UNSPECIFIED SceneEncoding = 0 // YAML
+	SceneEncoding_SCENE_ENCODING_YAML        SceneEncoding = 1
+	SceneEncoding_SCENE_ENCODING_JSON        SceneEncoding = 2
+	SceneEncoding_SCENE_ENCODING_BINARY      SceneEncoding = 3
+	SceneEncoding_SCENE_ENCODING_PROTO       SceneEncoding = 4
+)
+
+// Enum value maps for SceneEncoding.
+var (
+	SceneEncoding_name = map[int32]string{
+		0: "SCENE_ENCODING_UNSPECIFIED",
+		1: "SCENE_ENCODING_YAML",
+		2: "SCENE_ENCODING_JSON",
+		3: "SCENE_ENCODING_BINARY",
+		4: "SCENE_ENCODING_PROTO",
+	}
+	SceneEncoding_value = map[string]int32{
+		"SCENE_ENCODING_UNSPECIFIED": 0,
+		"SCENE_ENCODING_YAML":        1,
+		"SCENE_ENCODING_JSON":        2,
+		"SCENE_ENCODING_BINARY":      3,
+		"SCENE_ENCODING_PROTO":       4,
+	}
+)
+
+func (x SceneEncoding) Enum() *SceneEncoding {
+	p := new(SceneEncoding)
+	*p = x
+	return p
+}
+
+func (x SceneEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SceneEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_grpc_engine_proto_enumTypes[3].Descriptor()
+}
+
+func (SceneEncoding) Type() protoreflect.EnumType {
+	return &file_grpc_engine_proto_enumTypes[3]
+}
+
+func (x SceneEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SceneEncoding.Descriptor instead.
+func (SceneEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{3}
+}
+
 type EngineRequest struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	Operation Operation              `protobuf:"varint,1,opt,name=operation,proto3,enum=grpc.Operation" json:"operation,omitempty"`
@@ -114,6 +407,13 @@ type EngineRequest struct {
 	//	*EngineRequest_Object
 	//	*EngineRequest_Scene
 	//	*EngineRequest_SceneMode
+	//	*EngineRequest_Subscription
+	//	*EngineRequest_Component
+	//	*EngineRequest_Batch
+	//	*EngineRequest_SceneQuery
+	//	*EngineRequest_SceneDocument
+	//	*EngineRequest_Camera
+	//	*EngineRequest_Frames
 	Body          isEngineRequest_Body `protobuf_oneof:"body"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -199,6 +499,69 @@ func (x *EngineRequest) GetSceneMode() *SceneModeRef {
 	return nil
 }
 
+func (x *EngineRequest) GetSubscription() *Subscription {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_Subscription); ok {
+			return x.Subscription
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetComponent() *ComponentEdit {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_Component); ok {
+			return x.Component
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetBatch() *Batch {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_Batch); ok {
+			return x.Batch
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetSceneQuery() *SceneQuery {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_SceneQuery); ok {
+			return x.SceneQuery
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetSceneDocument() *SceneDocument {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_SceneDocument); ok {
+			return x.SceneDocument
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetCamera() *CameraPose {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_Camera); ok {
+			return x.Camera
+		}
+	}
+	return nil
+}
+
+func (x *EngineRequest) GetFrames() *FrameRequest {
+	if x != nil {
+		if x, ok := x.Body.(*EngineRequest_Frames); ok {
+			return x.Frames
+		}
+	}
+	return nil
+}
+
 type isEngineRequest_Body interface {
 	isEngineRequest_Body()
 }
@@ -219,6 +582,34 @@ type EngineRequest_SceneMode struct {
 	SceneMode *SceneModeRef `protobuf:"bytes,5,opt,name=scene_mode,json=sceneMode,proto3,oneof"`
 }
 
+type EngineRequest_Subscription struct {
+	Subscription *Subscription `protobuf:"bytes,6,opt,name=subscription,proto3,oneof"`
+}
+
+type EngineRequest_Component struct {
+	Component *ComponentEdit `protobuf:"bytes,7,opt,name=component,proto3,oneof"`
+}
+
+type EngineRequest_Batch struct {
+	Batch *Batch `protobuf:"bytes,8,opt,name=batch,proto3,oneof"`
+}
+
+type EngineRequest_SceneQuery struct {
+	SceneQuery *SceneQuery `protobuf:"bytes,9,opt,name=scene_query,json=sceneQuery,proto3,oneof"`
+}
+
+type EngineRequest_SceneDocument struct {
+	SceneDocument *SceneDocument `protobuf:"bytes,10,opt,name=scene_document,json=sceneDocument,proto3,oneof"`
+}
+
+type EngineRequest_Camera struct {
+	Camera *CameraPose `protobuf:"bytes,11,opt,name=camera,proto3,oneof"`
+}
+
+type EngineRequest_Frames struct {
+	Frames *FrameRequest `protobuf:"bytes,12,opt,name=frames,proto3,oneof"`
+}
+
 func (*EngineRequest_Empty) isEngineRequest_Body() {}
 
 func (*EngineRequest_Object) isEngineRequest_Body() {}
@@ -227,17 +618,43 @@ func (*EngineRequest_Scene) isEngineRequest_Body() {}
 
 func (*EngineRequest_SceneMode) isEngineRequest_Body() {}
 
+func (*EngineRequest_Subscription) isEngineRequest_Body() {}
+
+func (*EngineRequest_Component) isEngineRequest_Body() {}
+
+func (*EngineRequest_Batch) isEngineRequest_Body() {}
+
+func (*EngineRequest_SceneQuery) isEngineRequest_Body() {}
+
+func (*EngineRequest_SceneDocument) isEngineRequest_Body() {}
+
+func (*EngineRequest_Camera) isEngineRequest_Body() {}
+
+func (*EngineRequest_Frames) isEngineRequest_Body() {}
+
 type EngineResponse struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	Operation Operation              `protobuf:"varint,1,opt,name=operation,proto3,enum=grpc.Operation" json:"operation,omitempty"`
 	Success   bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
 	Error     string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// The gRPC status code of error, so a client can tell a missing object from
+	// a bad request without reading the message; 0 (OK) on success.
+	Code int32 `protobuf:"varint,15,opt,name=code,proto3" json:"code,omitempty"`
 	// Types that are valid to be assigned to Body:
 	//
 	//	*EngineResponse_Empty
 	//	*EngineResponse_Objects
 	//	*EngineResponse_Object
 	//	*EngineResponse_SceneModes
+	//	*EngineResponse_History
+	//	*EngineResponse_Events
+	//	*EngineResponse_Components
+	//	*EngineResponse_ComponentTypes
+	//	*EngineResponse_Batch
+	//	*EngineResponse_SceneDocument
+	//	*EngineResponse_Scene
+	//	*EngineResponse_Camera
+	//	*EngineResponse_Frame
 	Body          isEngineResponse_Body `protobuf_oneof:"body"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -294,6 +711,13 @@ func (x *EngineResponse) GetError() string {
 	return ""
 }
 
+func (x *EngineResponse) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
 func (x *EngineResponse) GetBody() isEngineResponse_Body {
 	if x != nil {
 		return x.Body
@@ -337,6 +761,87 @@ func (x *EngineResponse) GetSceneModes() *SceneModes {
 	return nil
 }
 
+func (x *EngineResponse) GetHistory() *History {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_History); ok {
+			return x.History
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetEvents() *Events {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Events); ok {
+			return x.Events
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetComponents() *Components {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Components); ok {
+			return x.Components
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetComponentTypes() *ComponentTypes {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_ComponentTypes); ok {
+			return x.ComponentTypes
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetBatch() *BatchResults {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Batch); ok {
+			return x.Batch
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetSceneDocument() *SceneDocument {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_SceneDocument); ok {
+			return x.SceneDocument
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetScene() *SceneRef {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Scene); ok {
+			return x.Scene
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetCamera() *CameraPose {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Camera); ok {
+			return x.Camera
+		}
+	}
+	return nil
+}
+
+func (x *EngineResponse) GetFrame() *Frame {
+	if x != nil {
+		if x, ok := x.Body.(*EngineResponse_Frame); ok {
+			return x.Frame
+		}
+	}
+	return nil
+}
+
 type isEngineResponse_Body interface {
 	isEngineResponse_Body()
 }
@@ -357,6 +862,42 @@ type EngineResponse_SceneModes struct {
 	SceneModes *SceneModes `protobuf:"bytes,7,opt,name=scene_modes,json=sceneModes,proto3,oneof"`
 }
 
+type EngineResponse_History struct {
+	History *History `protobuf:"bytes,8,opt,name=history,proto3,oneof"`
+}
+
+type EngineResponse_Events struct {
+	Events *Events `protobuf:"bytes,9,opt,name=events,proto3,oneof"`
+}
+
+type EngineResponse_Components struct {
+	Components *Components `protobuf:"bytes,10,opt,name=components,proto3,oneof"`
+}
+
+type EngineResponse_ComponentTypes struct {
+	ComponentTypes *ComponentTypes `protobuf:"bytes,11,opt,name=component_types,json=componentTypes,proto3,oneof"`
+}
+
+type EngineResponse_Batch struct {
+	Batch *BatchResults `protobuf:"bytes,12,opt,name=batch,proto3,oneof"`
+}
+
+type EngineResponse_SceneDocument struct {
+	SceneDocument *SceneDocument `protobuf:"bytes,13,opt,name=scene_document,json=sceneDocument,proto3,oneof"`
+}
+
+type EngineResponse_Scene struct {
+	Scene *SceneRef `protobuf:"bytes,14,opt,name=scene,proto3,oneof"`
+}
+
+type EngineResponse_Camera struct {
+	Camera *CameraPose `protobuf:"bytes,16,opt,name=camera,proto3,oneof"`
+}
+
+type EngineResponse_Frame struct {
+	Frame *Frame `protobuf:"bytes,17,opt,name=frame,proto3,oneof"`
+}
+
 func (*EngineResponse_Empty) isEngineResponse_Body() {}
 
 func (*EngineResponse_Objects) isEngineResponse_Body() {}
@@ -365,27 +906,50 @@ func (*EngineResponse_Object) isEngineResponse_Body() {}
 
 func (*EngineResponse_SceneModes) isEngineResponse_Body() {}
 
-type Objects struct {
+func (*EngineResponse_History) isEngineResponse_Body() {}
+
+func (*EngineResponse_Events) isEngineResponse_Body() {}
+
+func (*EngineResponse_Components) isEngineResponse_Body() {}
+
+func (*EngineResponse_ComponentTypes) isEngineResponse_Body() {}
+
+func (*EngineResponse_Batch) isEngineResponse_Body() {}
+
+func (*EngineResponse_SceneDocument) isEngineResponse_Body() {}
+
+func (*EngineResponse_Scene) isEngineResponse_Body() {}
+
+func (*EngineResponse_Camera) isEngineResponse_Body() {}
+
+func (*EngineResponse_Frame) isEngineResponse_Body() {}
+
+// CameraPose is where the camera is and where it looks: yaw and pitch in
+// degrees, as the mouse turns it, and the vertical field of view in degrees.
+type CameraPose struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Objects       []*Object              `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	Position      *Vector3               `protobuf:"bytes,1,opt,name=position,proto3" json:"position,omitempty"`
+	Yaw           *float32               `protobuf:"fixed32,2,opt,name=yaw,proto3,oneof" json:"yaw,omitempty"`
+	Pitch         *float32               `protobuf:"fixed32,3,opt,name=pitch,proto3,oneof" json:"pitch,omitempty"`
+	Fov           *float32               `protobuf:"fixed32,4,opt,name=fov,proto3,oneof" json:"fov,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Objects) Reset() {
-	*x = Objects{}
+func (x *CameraPose) Reset() {
+	*x = CameraPose{}
 	mi := &file_grpc_engine_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Objects) String() string {
+func (x *CameraPose) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Objects) ProtoMessage() {}
+func (*CameraPose) ProtoMessage() {}
 
-func (x *Objects) ProtoReflect() protoreflect.Message {
+func (x *CameraPose) ProtoReflect() protoreflect.Message {
 	mi := &file_grpc_engine_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -397,41 +961,278 @@ func (x *Objects) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Objects.ProtoReflect.Descriptor instead.
-func (*Objects) Descriptor() ([]byte, []int) {
+// Deprecated: Use CameraPose.ProtoReflect.Descriptor instead.
+func (*CameraPose) Descriptor() ([]byte, []int) {
 	return file_grpc_engine_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Objects) GetObjects() []*Object {
+func (x *CameraPose) GetPosition() *Vector3 {
 	if x != nil {
-		return x.Objects
+		return x.Position
 	}
 	return nil
 }
 
-type Object struct {
-	state    protoimpl.MessageState `protogen:"open.v1"`
-	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Id       uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
-	Location *Location              `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
-	// Asset path, used by ADD_OBJECT to say what to load. Populated on read so a
-	// client can round-trip an object it fetched.
-	Model string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
-	// Encoded handle of this object's parent, or 0 when it sits at the scene
-	// root. GET_OBJECTS returns the world flat — the same way the engine stores
-	// it — and this is what lets a client rebuild the tree. ADD_OBJECT reads it to
-	// attach the new object under an existing one.
-	//
-	// Note that `location` is the object's LOCAL transform, so a child of a moving
-	// parent reports a constant position while sweeping through the world.
-	ParentId      uint64 `protobuf:"varint,5,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+func (x *CameraPose) GetYaw() float32 {
+	if x != nil && x.Yaw != nil {
+		return *x.Yaw
+	}
+	return 0
+}
+
+func (x *CameraPose) GetPitch() float32 {
+	if x != nil && x.Pitch != nil {
+		return *x.Pitch
+	}
+	return 0
+}
+
+func (x *CameraPose) GetFov() float32 {
+	if x != nil && x.Fov != nil {
+		return *x.Fov
+	}
+	return 0
+}
+
+type FrameRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The size to render at, in pixels, up to 4096 on a side. Zero for both is
+	// the window's size.
+	Width  uint32 `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	// Frames per second, up to 60; zero is 10. The engine sends no more often
+	// than it draws, so the real rate may be lower.
+	Rate     float32       `protobuf:"fixed32,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	Encoding FrameEncoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=grpc.FrameEncoding" json:"encoding,omitempty"`
+	// JPEG quality from 1 to 100; zero is 75. Ignored for PNG.
+	Quality       int32 `protobuf:"varint,5,opt,name=quality,proto3" json:"quality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FrameRequest) Reset() {
+	*x = FrameRequest{}
+	mi := &file_grpc_engine_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FrameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrameRequest) ProtoMessage() {}
+
+func (x *FrameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrameRequest.ProtoReflect.Descriptor instead.
+func (*FrameRequest) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FrameRequest) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *FrameRequest) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *FrameRequest) GetRate() float32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *FrameRequest) GetEncoding() FrameEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return FrameEncoding_FRAME_ENCODING_UNSPECIFIED
+}
+
+func (x *FrameRequest) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+type Frame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Counts the frames rendered for streams, so a gap shows frames skipped.
+	Frame    uint64        `protobuf:"varint,1,opt,name=frame,proto3" json:"frame,omitempty"`
+	Width    uint32        `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height   uint32        `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Encoding FrameEncoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=grpc.FrameEncoding" json:"encoding,omitempty"`
+	// The encoded image, top row first.
+	Data []byte `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	// Frames skipped since the last one sent, because the client had not taken
+	// that one yet.
+	Dropped       uint32 `protobuf:"varint,6,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_grpc_engine_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Frame) GetFrame() uint64 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+func (x *Frame) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Frame) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Frame) GetEncoding() FrameEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return FrameEncoding_FRAME_ENCODING_UNSPECIFIED
+}
+
+func (x *Frame) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Frame) GetDropped() uint32 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+type Objects struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Objects       []*Object              `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Objects) Reset() {
+	*x = Objects{}
+	mi := &file_grpc_engine_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Objects) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Objects) ProtoMessage() {}
+
+func (x *Objects) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Objects.ProtoReflect.Descriptor instead.
+func (*Objects) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Objects) GetObjects() []*Object {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+type Object struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Id       uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Location *Location              `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	// Asset path, used by ADD_OBJECT to say what to load. Populated on read so a
+	// client can round-trip an object it fetched.
+	Model string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	// Encoded handle of this object's parent, or 0 when it sits at the scene
+	// root. GET_OBJECTS returns the world flat — the same way the engine stores
+	// it — and this is what lets a client rebuild the tree. ADD_OBJECT reads it to
+	// attach the new object under an existing one.
+	//
+	// Note that `location` is the object's LOCAL transform, so a child of a moving
+	// parent reports a constant position while sweeping through the world.
+	ParentId uint64 `protobuf:"varint,5,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	// Tint of the model's untextured parts; zero for an object with no model.
+	// ADD_OBJECT reads it too, and leaves the default white if it is unset.
+	BaseColor     *Vector3 `protobuf:"bytes,6,opt,name=base_color,json=baseColor,proto3" json:"base_color,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Object) Reset() {
 	*x = Object{}
-	mi := &file_grpc_engine_proto_msgTypes[3]
+	mi := &file_grpc_engine_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -443,7 +1244,7 @@ func (x *Object) String() string {
 func (*Object) ProtoMessage() {}
 
 func (x *Object) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[3]
+	mi := &file_grpc_engine_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -456,7 +1257,7 @@ func (x *Object) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Object.ProtoReflect.Descriptor instead.
 func (*Object) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{3}
+	return file_grpc_engine_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Object) GetName() string {
@@ -473,51 +1274,1596 @@ func (x *Object) GetId() uint64 {
 	return 0
 }
 
-func (x *Object) GetLocation() *Location {
+func (x *Object) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Object) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Object) GetParentId() uint64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *Object) GetBaseColor() *Vector3 {
+	if x != nil {
+		return x.BaseColor
+	}
+	return nil
+}
+
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      *Vector3               `protobuf:"bytes,1,opt,name=position,proto3" json:"position,omitempty"`
+	Rotation      *Vector4               `protobuf:"bytes,2,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	Scale         *Vector3               `protobuf:"bytes,3,opt,name=scale,proto3" json:"scale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_grpc_engine_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Location) GetPosition() *Vector3 {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Location) GetRotation() *Vector4 {
+	if x != nil {
+		return x.Rotation
+	}
+	return nil
+}
+
+func (x *Location) GetScale() *Vector3 {
+	if x != nil {
+		return x.Scale
+	}
+	return nil
+}
+
+type Vector4 struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float32                `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float32                `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
+	Z             float32                `protobuf:"fixed32,3,opt,name=z,proto3" json:"z,omitempty"`
+	W             float32                `protobuf:"fixed32,4,opt,name=w,proto3" json:"w,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Vector4) Reset() {
+	*x = Vector4{}
+	mi := &file_grpc_engine_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Vector4) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vector4) ProtoMessage() {}
+
+func (x *Vector4) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vector4.ProtoReflect.Descriptor instead.
+func (*Vector4) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Vector4) GetX() float32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Vector4) GetY() float32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Vector4) GetZ() float32 {
+	if x != nil {
+		return x.Z
+	}
+	return 0
+}
+
+func (x *Vector4) GetW() float32 {
+	if x != nil {
+		return x.W
+	}
+	return 0
+}
+
+type Vector3 struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float32                `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float32                `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
+	Z             float32                `protobuf:"fixed32,3,opt,name=z,proto3" json:"z,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Vector3) Reset() {
+	*x = Vector3{}
+	mi := &file_grpc_engine_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Vector3) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vector3) ProtoMessage() {}
+
+func (x *Vector3) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vector3.ProtoReflect.Descriptor instead.
+func (*Vector3) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Vector3) GetX() float32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Vector3) GetY() float32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Vector3) GetZ() float32 {
+	if x != nil {
+		return x.Z
+	}
+	return 0
+}
+
+type SceneRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneRef) Reset() {
+	*x = SceneRef{}
+	mi := &file_grpc_engine_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneRef) ProtoMessage() {}
+
+func (x *SceneRef) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneRef.ProtoReflect.Descriptor instead.
+func (*SceneRef) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SceneRef) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type SceneModeRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneModeRef) Reset() {
+	*x = SceneModeRef{}
+	mi := &file_grpc_engine_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneModeRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneModeRef) ProtoMessage() {}
+
+func (x *SceneModeRef) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneModeRef.ProtoReflect.Descriptor instead.
+func (*SceneModeRef) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SceneModeRef) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type SceneMode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneMode) Reset() {
+	*x = SceneMode{}
+	mi := &file_grpc_engine_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneMode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneMode) ProtoMessage() {}
+
+func (x *SceneMode) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneMode.ProtoReflect.Descriptor instead.
+func (*SceneMode) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SceneMode) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SceneMode) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type SceneModes struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Modes            []*SceneMode           `protobuf:"bytes,1,rep,name=modes,proto3" json:"modes,omitempty"`
+	CurrentMode      string                 `protobuf:"bytes,2,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
+	CurrentScenePath string                 `protobuf:"bytes,3,opt,name=current_scene_path,json=currentScenePath,proto3" json:"current_scene_path,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SceneModes) Reset() {
+	*x = SceneModes{}
+	mi := &file_grpc_engine_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneModes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneModes) ProtoMessage() {}
+
+func (x *SceneModes) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneModes.ProtoReflect.Descriptor instead.
+func (*SceneModes) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SceneModes) GetModes() []*SceneMode {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
+}
+
+func (x *SceneModes) GetCurrentMode() string {
+	if x != nil {
+		return x.CurrentMode
+	}
+	return ""
+}
+
+func (x *SceneModes) GetCurrentScenePath() string {
+	if x != nil {
+		return x.CurrentScenePath
+	}
+	return ""
+}
+
+// History reports an undo or redo: the step it applied, and what the next
+// undo and redo would do. Empty labels mean there is nothing that way.
+type History struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Applied       string                 `protobuf:"bytes,1,opt,name=applied,proto3" json:"applied,omitempty"`
+	NextUndo      string                 `protobuf:"bytes,2,opt,name=next_undo,json=nextUndo,proto3" json:"next_undo,omitempty"`
+	NextRedo      string                 `protobuf:"bytes,3,opt,name=next_redo,json=nextRedo,proto3" json:"next_redo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *History) Reset() {
+	*x = History{}
+	mi := &file_grpc_engine_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *History) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*History) ProtoMessage() {}
+
+func (x *History) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use History.ProtoReflect.Descriptor instead.
+func (*History) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *History) GetApplied() string {
+	if x != nil {
+		return x.Applied
+	}
+	return ""
+}
+
+func (x *History) GetNextUndo() string {
+	if x != nil {
+		return x.NextUndo
+	}
+	return ""
+}
+
+func (x *History) GetNextRedo() string {
+	if x != nil {
+		return x.NextRedo
+	}
+	return ""
+}
+
+// Subscription narrows SUBSCRIBE to the objects with these ids, and to the
+// objects in the subtrees below these, including objects leaving them. With
+// neither, every change is sent. A scene load always is: it ends every id the
+// filter names, so the client has to subscribe again.
+type Subscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []uint64               `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	Subtrees      []uint64               `protobuf:"varint,2,rep,packed,name=subtrees,proto3" json:"subtrees,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_grpc_engine_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Subscription) GetIds() []uint64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *Subscription) GetSubtrees() []uint64 {
+	if x != nil {
+		return x.Subtrees
+	}
+	return nil
+}
+
+// Events is one frame's changes, coalesced: an object moved many times in the
+// frame is reported once, one spawned and moved is only reported spawned, and
+// one spawned and removed again not at all.
+type Events struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Frame   uint64                 `protobuf:"varint,1,opt,name=frame,proto3" json:"frame,omitempty"`
+	Changes []*Change              `protobuf:"bytes,2,rep,name=changes,proto3" json:"changes,omitempty"`
+	// Frames of changes were dropped because the client was not reading them
+	// fast enough. Fetch GET_OBJECTS again rather than trust what it has.
+	Dropped       bool `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Events) Reset() {
+	*x = Events{}
+	mi := &file_grpc_engine_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Events) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Events) ProtoMessage() {}
+
+func (x *Events) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Events.ProtoReflect.Descriptor instead.
+func (*Events) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Events) GetFrame() uint64 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+func (x *Events) GetChanges() []*Change {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *Events) GetDropped() bool {
+	if x != nil {
+		return x.Dropped
+	}
+	return false
+}
+
+type Change struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  ChangeKind             `protobuf:"varint,1,opt,name=kind,proto3,enum=grpc.ChangeKind" json:"kind,omitempty"`
+	Id    uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	// The object at the end of the frame, for SPAWNED, REPARENTED and TRANSFORM.
+	Object *Object `protobuf:"bytes,3,opt,name=object,proto3" json:"object,omitempty"`
+	// Which property changed and its new value, for FIELD.
+	Field *ComponentFieldChange `protobuf:"bytes,4,opt,name=field,proto3" json:"field,omitempty"`
+	// The scene that was loaded, for SCENE_LOADED.
+	ScenePath string `protobuf:"bytes,5,opt,name=scene_path,json=scenePath,proto3" json:"scene_path,omitempty"`
+	// The object's components, for COMPONENTS.
+	Components    []*ComponentInfo `protobuf:"bytes,6,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_grpc_engine_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Change) GetKind() ChangeKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ChangeKind_CHANGE_KIND_UNSPECIFIED
+}
+
+func (x *Change) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Change) GetObject() *Object {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *Change) GetField() *ComponentFieldChange {
+	if x != nil {
+		return x.Field
+	}
+	return nil
+}
+
+func (x *Change) GetScenePath() string {
+	if x != nil {
+		return x.ScenePath
+	}
+	return ""
+}
+
+func (x *Change) GetComponents() []*ComponentInfo {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+type ComponentFieldChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The component's position on the object, and its registered type.
+	Component     int32           `protobuf:"varint,1,opt,name=component,proto3" json:"component,omitempty"`
+	Type          string          `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Field         *ComponentField `protobuf:"bytes,3,opt,name=field,proto3" json:"field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentFieldChange) Reset() {
+	*x = ComponentFieldChange{}
+	mi := &file_grpc_engine_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentFieldChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentFieldChange) ProtoMessage() {}
+
+func (x *ComponentFieldChange) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentFieldChange.ProtoReflect.Descriptor instead.
+func (*ComponentFieldChange) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ComponentFieldChange) GetComponent() int32 {
+	if x != nil {
+		return x.Component
+	}
+	return 0
+}
+
+func (x *ComponentFieldChange) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ComponentFieldChange) GetField() *ComponentField {
+	if x != nil {
+		return x.Field
+	}
+	return nil
+}
+
+// ComponentField is one component property, named as a scene file names it.
+// A property whose type has no representation here has no value set.
+type ComponentField struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*ComponentField_BoolValue
+	//	*ComponentField_IntValue
+	//	*ComponentField_FloatValue
+	//	*ComponentField_Vec3Value
+	//	*ComponentField_Vec4Value
+	//	*ComponentField_StringValue
+	Value         isComponentField_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentField) Reset() {
+	*x = ComponentField{}
+	mi := &file_grpc_engine_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentField) ProtoMessage() {}
+
+func (x *ComponentField) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentField.ProtoReflect.Descriptor instead.
+func (*ComponentField) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ComponentField) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ComponentField) GetValue() isComponentField_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ComponentField) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *ComponentField) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *ComponentField) GetFloatValue() float32 {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_FloatValue); ok {
+			return x.FloatValue
+		}
+	}
+	return 0
+}
+
+func (x *ComponentField) GetVec3Value() *Vector3 {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_Vec3Value); ok {
+			return x.Vec3Value
+		}
+	}
+	return nil
+}
+
+func (x *ComponentField) GetVec4Value() *Vector4 {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_Vec4Value); ok {
+			return x.Vec4Value
+		}
+	}
+	return nil
+}
+
+func (x *ComponentField) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Value.(*ComponentField_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+type isComponentField_Value interface {
+	isComponentField_Value()
+}
+
+type ComponentField_BoolValue struct {
+	BoolValue bool `protobuf:"varint,2,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type ComponentField_IntValue struct {
+	IntValue int64 `protobuf:"varint,3,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type ComponentField_FloatValue struct {
+	FloatValue float32 `protobuf:"fixed32,4,opt,name=float_value,json=floatValue,proto3,oneof"`
+}
+
+type ComponentField_Vec3Value struct {
+	Vec3Value *Vector3 `protobuf:"bytes,5,opt,name=vec3_value,json=vec3Value,proto3,oneof"`
+}
+
+type ComponentField_Vec4Value struct {
+	Vec4Value *Vector4 `protobuf:"bytes,6,opt,name=vec4_value,json=vec4Value,proto3,oneof"`
+}
+
+type ComponentField_StringValue struct {
+	StringValue string `protobuf:"bytes,7,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+func (*ComponentField_BoolValue) isComponentField_Value() {}
+
+func (*ComponentField_IntValue) isComponentField_Value() {}
+
+func (*ComponentField_FloatValue) isComponentField_Value() {}
+
+func (*ComponentField_Vec3Value) isComponentField_Value() {}
+
+func (*ComponentField_Vec4Value) isComponentField_Value() {}
+
+func (*ComponentField_StringValue) isComponentField_Value() {}
+
+// ComponentInfo is one component attached to an object.
+type ComponentInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The component's position on the object, which is how edits address it.
+	Index int32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// The registered type name, as a scene file names it; empty for a component
+	// the registry cannot name, which go_type still identifies.
+	Type          string            `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	GoType        string            `protobuf:"bytes,3,opt,name=go_type,json=goType,proto3" json:"go_type,omitempty"`
+	Fields        []*ComponentField `protobuf:"bytes,4,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentInfo) Reset() {
+	*x = ComponentInfo{}
+	mi := &file_grpc_engine_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentInfo) ProtoMessage() {}
+
+func (x *ComponentInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentInfo.ProtoReflect.Descriptor instead.
+func (*ComponentInfo) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ComponentInfo) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ComponentInfo) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ComponentInfo) GetGoType() string {
+	if x != nil {
+		return x.GoType
+	}
+	return ""
+}
+
+func (x *ComponentInfo) GetFields() []*ComponentField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type Components struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Components    []*ComponentInfo       `protobuf:"bytes,2,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Components) Reset() {
+	*x = Components{}
+	mi := &file_grpc_engine_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Components) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Components) ProtoMessage() {}
+
+func (x *Components) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Components.ProtoReflect.Descriptor instead.
+func (*Components) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Components) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Components) GetComponents() []*ComponentInfo {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+type ComponentType struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The properties a new component of this type starts with.
+	Fields        []*ComponentField `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentType) Reset() {
+	*x = ComponentType{}
+	mi := &file_grpc_engine_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentType) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentType) ProtoMessage() {}
+
+func (x *ComponentType) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentType.ProtoReflect.Descriptor instead.
+func (*ComponentType) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ComponentType) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ComponentType) GetFields() []*ComponentField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type ComponentTypes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Types         []*ComponentType       `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentTypes) Reset() {
+	*x = ComponentTypes{}
+	mi := &file_grpc_engine_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentTypes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentTypes) ProtoMessage() {}
+
+func (x *ComponentTypes) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentTypes.ProtoReflect.Descriptor instead.
+func (*ComponentTypes) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ComponentTypes) GetTypes() []*ComponentType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+// ComponentEdit addresses a component for SET_COMPONENT_FIELD, ADD_COMPONENT
+// and REMOVE_COMPONENT. The index is ignored by ADD_COMPONENT.
+type ComponentEdit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Component     int32                  `protobuf:"varint,2,opt,name=component,proto3" json:"component,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Fields        []*ComponentField      `protobuf:"bytes,4,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentEdit) Reset() {
+	*x = ComponentEdit{}
+	mi := &file_grpc_engine_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentEdit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentEdit) ProtoMessage() {}
+
+func (x *ComponentEdit) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentEdit.ProtoReflect.Descriptor instead.
+func (*ComponentEdit) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ComponentEdit) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ComponentEdit) GetComponent() int32 {
+	if x != nil {
+		return x.Component
+	}
+	return 0
+}
+
+func (x *ComponentEdit) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ComponentEdit) GetFields() []*ComponentField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type Batch struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Requests []*EngineRequest       `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	// Names the undo step; empty names it after the number of requests.
+	Label         string `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Batch) Reset() {
+	*x = Batch{}
+	mi := &file_grpc_engine_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Batch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Batch) ProtoMessage() {}
+
+func (x *Batch) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Batch.ProtoReflect.Descriptor instead.
+func (*Batch) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Batch) GetRequests() []*EngineRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *Batch) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+// BatchResults holds one response per request, by index. When the batch
+// failed, the request that failed carries its own error, the ones before it
+// say they were rolled back, and the ones after say they were not run.
+type BatchResults struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Responses []*EngineResponse      `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+	// The index of the request that failed, or -1.
+	Failed        int32 `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResults) Reset() {
+	*x = BatchResults{}
+	mi := &file_grpc_engine_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResults) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResults) ProtoMessage() {}
+
+func (x *BatchResults) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResults.ProtoReflect.Descriptor instead.
+func (*BatchResults) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BatchResults) GetResponses() []*EngineResponse {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
+func (x *BatchResults) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+type SceneQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Encoding      SceneEncoding          `protobuf:"varint,1,opt,name=encoding,proto3,enum=grpc.SceneEncoding" json:"encoding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneQuery) Reset() {
+	*x = SceneQuery{}
+	mi := &file_grpc_engine_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneQuery) ProtoMessage() {}
+
+func (x *SceneQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneQuery.ProtoReflect.Descriptor instead.
+func (*SceneQuery) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SceneQuery) GetEncoding() SceneEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return SceneEncoding_SCENE_ENCODING_UNSPECIFIED
+}
+
+type SceneDocument struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Encoding SceneEncoding          `protobuf:"varint,1,opt,name=encoding,proto3,enum=grpc.SceneEncoding" json:"encoding,omitempty"`
+	// The serialized scene, for every encoding but PROTO.
+	Content []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	// The scene, for PROTO.
+	Scene *SceneDescription `protobuf:"bytes,3,opt,name=scene,proto3" json:"scene,omitempty"`
+	// From GET_SCENE, the current scene's path. To LOAD_SCENE_DOCUMENT, the
+	// path the loaded scene is known by; it is not read.
+	Path          string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneDocument) Reset() {
+	*x = SceneDocument{}
+	mi := &file_grpc_engine_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneDocument) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneDocument) ProtoMessage() {}
+
+func (x *SceneDocument) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneDocument.ProtoReflect.Descriptor instead.
+func (*SceneDocument) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SceneDocument) GetEncoding() SceneEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return SceneEncoding_SCENE_ENCODING_UNSPECIFIED
+}
+
+func (x *SceneDocument) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *SceneDocument) GetScene() *SceneDescription {
+	if x != nil {
+		return x.Scene
+	}
+	return nil
+}
+
+func (x *SceneDocument) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// SceneDescription mirrors the scene file format field for field. Rotations
+// are axis-angle, as in Location, and component props are whatever the file
+// would hold, as a JSON-shaped value.
+type SceneDescription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Skybox        string                 `protobuf:"bytes,2,opt,name=skybox,proto3" json:"skybox,omitempty"`
+	Camera        *SceneCamera           `protobuf:"bytes,3,opt,name=camera,proto3" json:"camera,omitempty"`
+	Includes      []*SceneInclude        `protobuf:"bytes,4,rep,name=includes,proto3" json:"includes,omitempty"`
+	Cells         []*SceneCell           `protobuf:"bytes,5,rep,name=cells,proto3" json:"cells,omitempty"`
+	Objects       []*SceneObject         `protobuf:"bytes,6,rep,name=objects,proto3" json:"objects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneDescription) Reset() {
+	*x = SceneDescription{}
+	mi := &file_grpc_engine_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneDescription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneDescription) ProtoMessage() {}
+
+func (x *SceneDescription) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneDescription.ProtoReflect.Descriptor instead.
+func (*SceneDescription) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SceneDescription) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SceneDescription) GetSkybox() string {
+	if x != nil {
+		return x.Skybox
+	}
+	return ""
+}
+
+func (x *SceneDescription) GetCamera() *SceneCamera {
+	if x != nil {
+		return x.Camera
+	}
+	return nil
+}
+
+func (x *SceneDescription) GetIncludes() []*SceneInclude {
+	if x != nil {
+		return x.Includes
+	}
+	return nil
+}
+
+func (x *SceneDescription) GetCells() []*SceneCell {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+func (x *SceneDescription) GetObjects() []*SceneObject {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+type SceneCamera struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      *Vector3               `protobuf:"bytes,1,opt,name=position,proto3" json:"position,omitempty"`
+	Yaw           float32                `protobuf:"fixed32,2,opt,name=yaw,proto3" json:"yaw,omitempty"`
+	Pitch         float32                `protobuf:"fixed32,3,opt,name=pitch,proto3" json:"pitch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneCamera) Reset() {
+	*x = SceneCamera{}
+	mi := &file_grpc_engine_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SceneCamera) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SceneCamera) ProtoMessage() {}
+
+func (x *SceneCamera) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SceneCamera.ProtoReflect.Descriptor instead.
+func (*SceneCamera) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *SceneCamera) GetPosition() *Vector3 {
 	if x != nil {
-		return x.Location
+		return x.Position
 	}
 	return nil
 }
 
-func (x *Object) GetModel() string {
+func (x *SceneCamera) GetYaw() float32 {
 	if x != nil {
-		return x.Model
+		return x.Yaw
 	}
-	return ""
+	return 0
 }
 
-func (x *Object) GetParentId() uint64 {
+func (x *SceneCamera) GetPitch() float32 {
 	if x != nil {
-		return x.ParentId
+		return x.Pitch
 	}
 	return 0
 }
 
-type Location struct {
+type SceneInclude struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Position      *Vector3               `protobuf:"bytes,1,opt,name=position,proto3" json:"position,omitempty"`
-	Rotation      *Vector4               `protobuf:"bytes,2,opt,name=rotation,proto3" json:"rotation,omitempty"`
-	Scale         *Vector3               `protobuf:"bytes,3,opt,name=scale,proto3" json:"scale,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Stream        bool                   `protobuf:"varint,2,opt,name=stream,proto3" json:"stream,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Location) Reset() {
-	*x = Location{}
-	mi := &file_grpc_engine_proto_msgTypes[4]
+func (x *SceneInclude) Reset() {
+	*x = SceneInclude{}
+	mi := &file_grpc_engine_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Location) String() string {
+func (x *SceneInclude) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Location) ProtoMessage() {}
+func (*SceneInclude) ProtoMessage() {}
 
-func (x *Location) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[4]
+func (x *SceneInclude) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -528,57 +2874,49 @@ func (x *Location) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Location.ProtoReflect.Descriptor instead.
-func (*Location) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{4}
-}
-
-func (x *Location) GetPosition() *Vector3 {
-	if x != nil {
-		return x.Position
-	}
-	return nil
+// Deprecated: Use SceneInclude.ProtoReflect.Descriptor instead.
+func (*SceneInclude) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *Location) GetRotation() *Vector4 {
+func (x *SceneInclude) GetPath() string {
 	if x != nil {
-		return x.Rotation
+		return x.Path
 	}
-	return nil
+	return ""
 }
 
-func (x *Location) GetScale() *Vector3 {
+func (x *SceneInclude) GetStream() bool {
 	if x != nil {
-		return x.Scale
+		return x.Stream
 	}
-	return nil
+	return false
 }
 
-type Vector4 struct {
+type SceneCell struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	X             float32                `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
-	Y             float32                `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
-	Z             float32                `protobuf:"fixed32,3,opt,name=z,proto3" json:"z,omitempty"`
-	W             float32                `protobuf:"fixed32,4,opt,name=w,proto3" json:"w,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Min           *Vector3               `protobuf:"bytes,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           *Vector3               `protobuf:"bytes,3,opt,name=max,proto3" json:"max,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Vector4) Reset() {
-	*x = Vector4{}
-	mi := &file_grpc_engine_proto_msgTypes[5]
+func (x *SceneCell) Reset() {
+	*x = SceneCell{}
+	mi := &file_grpc_engine_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Vector4) String() string {
+func (x *SceneCell) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Vector4) ProtoMessage() {}
+func (*SceneCell) ProtoMessage() {}
 
-func (x *Vector4) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[5]
+func (x *SceneCell) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -589,63 +2927,62 @@ func (x *Vector4) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Vector4.ProtoReflect.Descriptor instead.
-func (*Vector4) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{5}
-}
-
-func (x *Vector4) GetX() float32 {
-	if x != nil {
-		return x.X
-	}
-	return 0
+// Deprecated: Use SceneCell.ProtoReflect.Descriptor instead.
+func (*SceneCell) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *Vector4) GetY() float32 {
+func (x *SceneCell) GetPath() string {
 	if x != nil {
-		return x.Y
+		return x.Path
 	}
-	return 0
+	return ""
 }
 
-func (x *Vector4) GetZ() float32 {
+func (x *SceneCell) GetMin() *Vector3 {
 	if x != nil {
-		return x.Z
+		return x.Min
 	}
-	return 0
+	return nil
 }
 
-func (x *Vector4) GetW() float32 {
+func (x *SceneCell) GetMax() *Vector3 {
 	if x != nil {
-		return x.W
+		return x.Max
 	}
-	return 0
+	return nil
 }
 
-type Vector3 struct {
+type SceneObject struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	X             float32                `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
-	Y             float32                `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
-	Z             float32                `protobuf:"fixed32,3,opt,name=z,proto3" json:"z,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Model         string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	Transform     *Location              `protobuf:"bytes,3,opt,name=transform,proto3" json:"transform,omitempty"`
+	Body          *SceneBody             `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	Material      *SceneMaterial         `protobuf:"bytes,5,opt,name=material,proto3" json:"material,omitempty"`
+	Components    []*SceneComponent      `protobuf:"bytes,6,rep,name=components,proto3" json:"components,omitempty"`
+	Children      []*SceneObject         `protobuf:"bytes,7,rep,name=children,proto3" json:"children,omitempty"`
+	Prefab        string                 `protobuf:"bytes,8,opt,name=prefab,proto3" json:"prefab,omitempty"`
+	Overrides     []*SceneOverride       `protobuf:"bytes,9,rep,name=overrides,proto3" json:"overrides,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Vector3) Reset() {
-	*x = Vector3{}
-	mi := &file_grpc_engine_proto_msgTypes[6]
+func (x *SceneObject) Reset() {
+	*x = SceneObject{}
+	mi := &file_grpc_engine_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Vector3) String() string {
+func (x *SceneObject) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Vector3) ProtoMessage() {}
+func (*SceneObject) ProtoMessage() {}
 
-func (x *Vector3) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[6]
+func (x *SceneObject) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -656,54 +2993,96 @@ func (x *Vector3) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Vector3.ProtoReflect.Descriptor instead.
-func (*Vector3) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use SceneObject.ProtoReflect.Descriptor instead.
+func (*SceneObject) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *Vector3) GetX() float32 {
+func (x *SceneObject) GetName() string {
 	if x != nil {
-		return x.X
+		return x.Name
 	}
-	return 0
+	return ""
 }
 
-func (x *Vector3) GetY() float32 {
+func (x *SceneObject) GetModel() string {
 	if x != nil {
-		return x.Y
+		return x.Model
 	}
-	return 0
+	return ""
 }
 
-func (x *Vector3) GetZ() float32 {
+func (x *SceneObject) GetTransform() *Location {
 	if x != nil {
-		return x.Z
+		return x.Transform
 	}
-	return 0
+	return nil
 }
 
-type SceneRef struct {
+func (x *SceneObject) GetBody() *SceneBody {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *SceneObject) GetMaterial() *SceneMaterial {
+	if x != nil {
+		return x.Material
+	}
+	return nil
+}
+
+func (x *SceneObject) GetComponents() []*SceneComponent {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *SceneObject) GetChildren() []*SceneObject {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *SceneObject) GetPrefab() string {
+	if x != nil {
+		return x.Prefab
+	}
+	return ""
+}
+
+func (x *SceneObject) GetOverrides() []*SceneOverride {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+type SceneBody struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Static        bool                   `protobuf:"varint,1,opt,name=static,proto3" json:"static,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SceneRef) Reset() {
-	*x = SceneRef{}
-	mi := &file_grpc_engine_proto_msgTypes[7]
+func (x *SceneBody) Reset() {
+	*x = SceneBody{}
+	mi := &file_grpc_engine_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SceneRef) String() string {
+func (x *SceneBody) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SceneRef) ProtoMessage() {}
+func (*SceneBody) ProtoMessage() {}
 
-func (x *SceneRef) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[7]
+func (x *SceneBody) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -714,40 +3093,40 @@ func (x *SceneRef) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SceneRef.ProtoReflect.Descriptor instead.
-func (*SceneRef) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use SceneBody.ProtoReflect.Descriptor instead.
+func (*SceneBody) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *SceneRef) GetPath() string {
+func (x *SceneBody) GetStatic() bool {
 	if x != nil {
-		return x.Path
+		return x.Static
 	}
-	return ""
+	return false
 }
 
-type SceneModeRef struct {
+type SceneMaterial struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Color         *Vector3               `protobuf:"bytes,1,opt,name=color,proto3" json:"color,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SceneModeRef) Reset() {
-	*x = SceneModeRef{}
-	mi := &file_grpc_engine_proto_msgTypes[8]
+func (x *SceneMaterial) Reset() {
+	*x = SceneMaterial{}
+	mi := &file_grpc_engine_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SceneModeRef) String() string {
+func (x *SceneMaterial) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SceneModeRef) ProtoMessage() {}
+func (*SceneMaterial) ProtoMessage() {}
 
-func (x *SceneModeRef) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[8]
+func (x *SceneMaterial) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,41 +3137,44 @@ func (x *SceneModeRef) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SceneModeRef.ProtoReflect.Descriptor instead.
-func (*SceneModeRef) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use SceneMaterial.ProtoReflect.Descriptor instead.
+func (*SceneMaterial) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *SceneModeRef) GetMode() string {
+func (x *SceneMaterial) GetColor() *Vector3 {
 	if x != nil {
-		return x.Mode
+		return x.Color
 	}
-	return ""
+	return nil
 }
 
-type SceneMode struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+type SceneComponent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Props *structpb.Value        `protobuf:"bytes,2,opt,name=props,proto3" json:"props,omitempty"`
+	// Which of the target's components of this type an override patches;
+	// only set in overrides.
+	Index         int32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SceneMode) Reset() {
-	*x = SceneMode{}
-	mi := &file_grpc_engine_proto_msgTypes[9]
+func (x *SceneComponent) Reset() {
+	*x = SceneComponent{}
+	mi := &file_grpc_engine_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SceneMode) String() string {
+func (x *SceneComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SceneMode) ProtoMessage() {}
+func (*SceneComponent) ProtoMessage() {}
 
-func (x *SceneMode) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[9]
+func (x *SceneComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -803,49 +3185,59 @@ func (x *SceneMode) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SceneMode.ProtoReflect.Descriptor instead.
-func (*SceneMode) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use SceneComponent.ProtoReflect.Descriptor instead.
+func (*SceneComponent) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *SceneMode) GetName() string {
+func (x *SceneComponent) GetType() string {
 	if x != nil {
-		return x.Name
+		return x.Type
 	}
 	return ""
 }
 
-func (x *SceneMode) GetPath() string {
+func (x *SceneComponent) GetProps() *structpb.Value {
 	if x != nil {
-		return x.Path
+		return x.Props
 	}
-	return ""
+	return nil
 }
 
-type SceneModes struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Modes            []*SceneMode           `protobuf:"bytes,1,rep,name=modes,proto3" json:"modes,omitempty"`
-	CurrentMode      string                 `protobuf:"bytes,2,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
-	CurrentScenePath string                 `protobuf:"bytes,3,opt,name=current_scene_path,json=currentScenePath,proto3" json:"current_scene_path,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *SceneComponent) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
 }
 
-func (x *SceneModes) Reset() {
-	*x = SceneModes{}
-	mi := &file_grpc_engine_proto_msgTypes[10]
+type SceneOverride struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Transform     *Location              `protobuf:"bytes,2,opt,name=transform,proto3" json:"transform,omitempty"`
+	Body          *SceneBody             `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	Material      *SceneMaterial         `protobuf:"bytes,4,opt,name=material,proto3" json:"material,omitempty"`
+	Components    []*SceneComponent      `protobuf:"bytes,5,rep,name=components,proto3" json:"components,omitempty"`
+	Children      []*SceneObject         `protobuf:"bytes,6,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SceneOverride) Reset() {
+	*x = SceneOverride{}
+	mi := &file_grpc_engine_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SceneModes) String() string {
+func (x *SceneOverride) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SceneModes) ProtoMessage() {}
+func (*SceneOverride) ProtoMessage() {}
 
-func (x *SceneModes) ProtoReflect() protoreflect.Message {
-	mi := &file_grpc_engine_proto_msgTypes[10]
+func (x *SceneOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_engine_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -856,63 +3248,130 @@ func (x *SceneModes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SceneModes.ProtoReflect.Descriptor instead.
-func (*SceneModes) Descriptor() ([]byte, []int) {
-	return file_grpc_engine_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use SceneOverride.ProtoReflect.Descriptor instead.
+func (*SceneOverride) Descriptor() ([]byte, []int) {
+	return file_grpc_engine_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *SceneModes) GetModes() []*SceneMode {
+func (x *SceneOverride) GetTarget() string {
 	if x != nil {
-		return x.Modes
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SceneOverride) GetTransform() *Location {
+	if x != nil {
+		return x.Transform
 	}
 	return nil
 }
 
-func (x *SceneModes) GetCurrentMode() string {
+func (x *SceneOverride) GetBody() *SceneBody {
 	if x != nil {
-		return x.CurrentMode
+		return x.Body
 	}
-	return ""
+	return nil
 }
 
-func (x *SceneModes) GetCurrentScenePath() string {
+func (x *SceneOverride) GetMaterial() *SceneMaterial {
 	if x != nil {
-		return x.CurrentScenePath
+		return x.Material
 	}
-	return ""
+	return nil
+}
+
+func (x *SceneOverride) GetComponents() []*SceneComponent {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *SceneOverride) GetChildren() []*SceneObject {
+	if x != nil {
+		return x.Children
+	}
+	return nil
 }
 
 var File_grpc_engine_proto protoreflect.FileDescriptor
 
 const file_grpc_engine_proto_rawDesc = "" +
 	"\n" +
-	"\x11grpc/engine.proto\x12\x04grpc\x1a\x1bgoogle/protobuf/empty.proto\"\xfb\x01\n" +
+	"\x11grpc/engine.proto\x12\x04grpc\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/protobuf/struct.proto\"\xdc\x04\n" +
 	"\rEngineRequest\x12-\n" +
 	"\toperation\x18\x01 \x01(\x0e2\x0f.grpc.OperationR\toperation\x12.\n" +
 	"\x05empty\x18\x02 \x01(\v2\x16.google.protobuf.EmptyH\x00R\x05empty\x12&\n" +
 	"\x06object\x18\x03 \x01(\v2\f.grpc.ObjectH\x00R\x06object\x12&\n" +
 	"\x05scene\x18\x04 \x01(\v2\x0e.grpc.SceneRefH\x00R\x05scene\x123\n" +
 	"\n" +
-	"scene_mode\x18\x05 \x01(\v2\x12.grpc.SceneModeRefH\x00R\tsceneModeB\x06\n" +
-	"\x04body\"\xaf\x02\n" +
+	"scene_mode\x18\x05 \x01(\v2\x12.grpc.SceneModeRefH\x00R\tsceneMode\x128\n" +
+	"\fsubscription\x18\x06 \x01(\v2\x12.grpc.SubscriptionH\x00R\fsubscription\x123\n" +
+	"\tcomponent\x18\a \x01(\v2\x13.grpc.ComponentEditH\x00R\tcomponent\x12#\n" +
+	"\x05batch\x18\b \x01(\v2\v.grpc.BatchH\x00R\x05batch\x123\n" +
+	"\vscene_query\x18\t \x01(\v2\x10.grpc.SceneQueryH\x00R\n" +
+	"sceneQuery\x12<\n" +
+	"\x0escene_document\x18\n" +
+	" \x01(\v2\x13.grpc.SceneDocumentH\x00R\rsceneDocument\x12*\n" +
+	"\x06camera\x18\v \x01(\v2\x10.grpc.CameraPoseH\x00R\x06camera\x12,\n" +
+	"\x06frames\x18\f \x01(\v2\x12.grpc.FrameRequestH\x00R\x06framesB\x06\n" +
+	"\x04body\"\xee\x05\n" +
 	"\x0eEngineResponse\x12-\n" +
 	"\toperation\x18\x01 \x01(\x0e2\x0f.grpc.OperationR\toperation\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\x12.\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x12\n" +
+	"\x04code\x18\x0f \x01(\x05R\x04code\x12.\n" +
 	"\x05empty\x18\x04 \x01(\v2\x16.google.protobuf.EmptyH\x00R\x05empty\x12)\n" +
 	"\aobjects\x18\x05 \x01(\v2\r.grpc.ObjectsH\x00R\aobjects\x12&\n" +
 	"\x06object\x18\x06 \x01(\v2\f.grpc.ObjectH\x00R\x06object\x123\n" +
 	"\vscene_modes\x18\a \x01(\v2\x10.grpc.SceneModesH\x00R\n" +
-	"sceneModesB\x06\n" +
-	"\x04body\"1\n" +
+	"sceneModes\x12)\n" +
+	"\ahistory\x18\b \x01(\v2\r.grpc.HistoryH\x00R\ahistory\x12&\n" +
+	"\x06events\x18\t \x01(\v2\f.grpc.EventsH\x00R\x06events\x122\n" +
+	"\n" +
+	"components\x18\n" +
+	" \x01(\v2\x10.grpc.ComponentsH\x00R\n" +
+	"components\x12?\n" +
+	"\x0fcomponent_types\x18\v \x01(\v2\x14.grpc.ComponentTypesH\x00R\x0ecomponentTypes\x12*\n" +
+	"\x05batch\x18\f \x01(\v2\x12.grpc.BatchResultsH\x00R\x05batch\x12<\n" +
+	"\x0escene_document\x18\r \x01(\v2\x13.grpc.SceneDocumentH\x00R\rsceneDocument\x12&\n" +
+	"\x05scene\x18\x0e \x01(\v2\x0e.grpc.SceneRefH\x00R\x05scene\x12*\n" +
+	"\x06camera\x18\x10 \x01(\v2\x10.grpc.CameraPoseH\x00R\x06camera\x12#\n" +
+	"\x05frame\x18\x11 \x01(\v2\v.grpc.FrameH\x00R\x05frameB\x06\n" +
+	"\x04body\"\x9a\x01\n" +
+	"\n" +
+	"CameraPose\x12)\n" +
+	"\bposition\x18\x01 \x01(\v2\r.grpc.Vector3R\bposition\x12\x15\n" +
+	"\x03yaw\x18\x02 \x01(\x02H\x00R\x03yaw\x88\x01\x01\x12\x19\n" +
+	"\x05pitch\x18\x03 \x01(\x02H\x01R\x05pitch\x88\x01\x01\x12\x15\n" +
+	"\x03fov\x18\x04 \x01(\x02H\x02R\x03fov\x88\x01\x01B\x06\n" +
+	"\x04_yawB\b\n" +
+	"\x06_pitchB\x06\n" +
+	"\x04_fov\"\x9b\x01\n" +
+	"\fFrameRequest\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\rR\x06height\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x02R\x04rate\x12/\n" +
+	"\bencoding\x18\x04 \x01(\x0e2\x13.grpc.FrameEncodingR\bencoding\x12\x18\n" +
+	"\aquality\x18\x05 \x01(\x05R\aquality\"\xaa\x01\n" +
+	"\x05Frame\x12\x14\n" +
+	"\x05frame\x18\x01 \x01(\x04R\x05frame\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\rR\x06height\x12/\n" +
+	"\bencoding\x18\x04 \x01(\x0e2\x13.grpc.FrameEncodingR\bencoding\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\x12\x18\n" +
+	"\adropped\x18\x06 \x01(\rR\adropped\"1\n" +
 	"\aObjects\x12&\n" +
-	"\aobjects\x18\x01 \x03(\v2\f.grpc.ObjectR\aobjects\"\x8b\x01\n" +
+	"\aobjects\x18\x01 \x03(\v2\f.grpc.ObjectR\aobjects\"\xb9\x01\n" +
 	"\x06Object\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\x04R\x02id\x12*\n" +
 	"\blocation\x18\x03 \x01(\v2\x0e.grpc.LocationR\blocation\x12\x14\n" +
 	"\x05model\x18\x04 \x01(\tR\x05model\x12\x1b\n" +
-	"\tparent_id\x18\x05 \x01(\x04R\bparentId\"\x85\x01\n" +
+	"\tparent_id\x18\x05 \x01(\x04R\bparentId\x12,\n" +
+	"\n" +
+	"base_color\x18\x06 \x01(\v2\r.grpc.Vector3R\tbaseColor\"\x85\x01\n" +
 	"\bLocation\x12)\n" +
 	"\bposition\x18\x01 \x01(\v2\r.grpc.Vector3R\bposition\x12)\n" +
 	"\brotation\x18\x02 \x01(\v2\r.grpc.Vector4R\brotation\x12#\n" +
@@ -937,7 +3396,127 @@ const file_grpc_engine_proto_rawDesc = "" +
 	"SceneModes\x12%\n" +
 	"\x05modes\x18\x01 \x03(\v2\x0f.grpc.SceneModeR\x05modes\x12!\n" +
 	"\fcurrent_mode\x18\x02 \x01(\tR\vcurrentMode\x12,\n" +
-	"\x12current_scene_path\x18\x03 \x01(\tR\x10currentScenePath*\xf6\x02\n" +
+	"\x12current_scene_path\x18\x03 \x01(\tR\x10currentScenePath\"]\n" +
+	"\aHistory\x12\x18\n" +
+	"\aapplied\x18\x01 \x01(\tR\aapplied\x12\x1b\n" +
+	"\tnext_undo\x18\x02 \x01(\tR\bnextUndo\x12\x1b\n" +
+	"\tnext_redo\x18\x03 \x01(\tR\bnextRedo\"<\n" +
+	"\fSubscription\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x04R\x03ids\x12\x1a\n" +
+	"\bsubtrees\x18\x02 \x03(\x04R\bsubtrees\"`\n" +
+	"\x06Events\x12\x14\n" +
+	"\x05frame\x18\x01 \x01(\x04R\x05frame\x12&\n" +
+	"\achanges\x18\x02 \x03(\v2\f.grpc.ChangeR\achanges\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\bR\adropped\"\xea\x01\n" +
+	"\x06Change\x12$\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x10.grpc.ChangeKindR\x04kind\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x04R\x02id\x12$\n" +
+	"\x06object\x18\x03 \x01(\v2\f.grpc.ObjectR\x06object\x120\n" +
+	"\x05field\x18\x04 \x01(\v2\x1a.grpc.ComponentFieldChangeR\x05field\x12\x1d\n" +
+	"\n" +
+	"scene_path\x18\x05 \x01(\tR\tscenePath\x123\n" +
+	"\n" +
+	"components\x18\x06 \x03(\v2\x13.grpc.ComponentInfoR\n" +
+	"components\"t\n" +
+	"\x14ComponentFieldChange\x12\x1c\n" +
+	"\tcomponent\x18\x01 \x01(\x05R\tcomponent\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12*\n" +
+	"\x05field\x18\x03 \x01(\v2\x14.grpc.ComponentFieldR\x05field\"\x95\x02\n" +
+	"\x0eComponentField\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x02 \x01(\bH\x00R\tboolValue\x12\x1d\n" +
+	"\tint_value\x18\x03 \x01(\x03H\x00R\bintValue\x12!\n" +
+	"\vfloat_value\x18\x04 \x01(\x02H\x00R\n" +
+	"floatValue\x12.\n" +
+	"\n" +
+	"vec3_value\x18\x05 \x01(\v2\r.grpc.Vector3H\x00R\tvec3Value\x12.\n" +
+	"\n" +
+	"vec4_value\x18\x06 \x01(\v2\r.grpc.Vector4H\x00R\tvec4Value\x12#\n" +
+	"\fstring_value\x18\a \x01(\tH\x00R\vstringValueB\a\n" +
+	"\x05value\"\x80\x01\n" +
+	"\rComponentInfo\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x17\n" +
+	"\ago_type\x18\x03 \x01(\tR\x06goType\x12,\n" +
+	"\x06fields\x18\x04 \x03(\v2\x14.grpc.ComponentFieldR\x06fields\"Q\n" +
+	"\n" +
+	"Components\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x123\n" +
+	"\n" +
+	"components\x18\x02 \x03(\v2\x13.grpc.ComponentInfoR\n" +
+	"components\"Q\n" +
+	"\rComponentType\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12,\n" +
+	"\x06fields\x18\x02 \x03(\v2\x14.grpc.ComponentFieldR\x06fields\";\n" +
+	"\x0eComponentTypes\x12)\n" +
+	"\x05types\x18\x01 \x03(\v2\x13.grpc.ComponentTypeR\x05types\"\x7f\n" +
+	"\rComponentEdit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1c\n" +
+	"\tcomponent\x18\x02 \x01(\x05R\tcomponent\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12,\n" +
+	"\x06fields\x18\x04 \x03(\v2\x14.grpc.ComponentFieldR\x06fields\"N\n" +
+	"\x05Batch\x12/\n" +
+	"\brequests\x18\x01 \x03(\v2\x13.grpc.EngineRequestR\brequests\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\"Z\n" +
+	"\fBatchResults\x122\n" +
+	"\tresponses\x18\x01 \x03(\v2\x14.grpc.EngineResponseR\tresponses\x12\x16\n" +
+	"\x06failed\x18\x02 \x01(\x05R\x06failed\"=\n" +
+	"\n" +
+	"SceneQuery\x12/\n" +
+	"\bencoding\x18\x01 \x01(\x0e2\x13.grpc.SceneEncodingR\bencoding\"\x9c\x01\n" +
+	"\rSceneDocument\x12/\n" +
+	"\bencoding\x18\x01 \x01(\x0e2\x13.grpc.SceneEncodingR\bencoding\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\x12,\n" +
+	"\x05scene\x18\x03 \x01(\v2\x16.grpc.SceneDescriptionR\x05scene\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\"\xf3\x01\n" +
+	"\x10SceneDescription\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x16\n" +
+	"\x06skybox\x18\x02 \x01(\tR\x06skybox\x12)\n" +
+	"\x06camera\x18\x03 \x01(\v2\x11.grpc.SceneCameraR\x06camera\x12.\n" +
+	"\bincludes\x18\x04 \x03(\v2\x12.grpc.SceneIncludeR\bincludes\x12%\n" +
+	"\x05cells\x18\x05 \x03(\v2\x0f.grpc.SceneCellR\x05cells\x12+\n" +
+	"\aobjects\x18\x06 \x03(\v2\x11.grpc.SceneObjectR\aobjects\"`\n" +
+	"\vSceneCamera\x12)\n" +
+	"\bposition\x18\x01 \x01(\v2\r.grpc.Vector3R\bposition\x12\x10\n" +
+	"\x03yaw\x18\x02 \x01(\x02R\x03yaw\x12\x14\n" +
+	"\x05pitch\x18\x03 \x01(\x02R\x05pitch\":\n" +
+	"\fSceneInclude\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06stream\x18\x02 \x01(\bR\x06stream\"a\n" +
+	"\tSceneCell\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1f\n" +
+	"\x03min\x18\x02 \x01(\v2\r.grpc.Vector3R\x03min\x12\x1f\n" +
+	"\x03max\x18\x03 \x01(\v2\r.grpc.Vector3R\x03max\"\xeb\x02\n" +
+	"\vSceneObject\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12,\n" +
+	"\ttransform\x18\x03 \x01(\v2\x0e.grpc.LocationR\ttransform\x12#\n" +
+	"\x04body\x18\x04 \x01(\v2\x0f.grpc.SceneBodyR\x04body\x12/\n" +
+	"\bmaterial\x18\x05 \x01(\v2\x13.grpc.SceneMaterialR\bmaterial\x124\n" +
+	"\n" +
+	"components\x18\x06 \x03(\v2\x14.grpc.SceneComponentR\n" +
+	"components\x12-\n" +
+	"\bchildren\x18\a \x03(\v2\x11.grpc.SceneObjectR\bchildren\x12\x16\n" +
+	"\x06prefab\x18\b \x01(\tR\x06prefab\x121\n" +
+	"\toverrides\x18\t \x03(\v2\x13.grpc.SceneOverrideR\toverrides\"#\n" +
+	"\tSceneBody\x12\x16\n" +
+	"\x06static\x18\x01 \x01(\bR\x06static\"4\n" +
+	"\rSceneMaterial\x12#\n" +
+	"\x05color\x18\x01 \x01(\v2\r.grpc.Vector3R\x05color\"h\n" +
+	"\x0eSceneComponent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12,\n" +
+	"\x05props\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05props\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\x05R\x05index\"\x90\x02\n" +
+	"\rSceneOverride\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12,\n" +
+	"\ttransform\x18\x02 \x01(\v2\x0e.grpc.LocationR\ttransform\x12#\n" +
+	"\x04body\x18\x03 \x01(\v2\x0f.grpc.SceneBodyR\x04body\x12/\n" +
+	"\bmaterial\x18\x04 \x01(\v2\x13.grpc.SceneMaterialR\bmaterial\x124\n" +
+	"\n" +
+	"components\x18\x05 \x03(\v2\x14.grpc.SceneComponentR\n" +
+	"components\x12-\n" +
+	"\bchildren\x18\x06 \x03(\v2\x11.grpc.SceneObjectR\bchildren*\x9e\a\n" +
 	"\tOperation\x12\x19\n" +
 	"\x15OPERATION_UNSPECIFIED\x10\x00\x12\x19\n" +
 	"\x15OPERATION_GET_OBJECTS\x10\x01\x12\x18\n" +
@@ -952,7 +3531,47 @@ const file_grpc_engine_proto_rawDesc = "" +
 	"\x19OPERATION_LOAD_SCENE_MODE\x10\n" +
 	"\x12\x18\n" +
 	"\x14OPERATION_SET_PARENT\x10\v\x12\x19\n" +
-	"\x15OPERATION_REMOVE_TREE\x10\f2A\n" +
+	"\x15OPERATION_REMOVE_TREE\x10\f\x12\x12\n" +
+	"\x0eOPERATION_UNDO\x10\r\x12\x12\n" +
+	"\x0eOPERATION_REDO\x10\x0e\x12\x17\n" +
+	"\x13OPERATION_SUBSCRIBE\x10\x0f\x12\x19\n" +
+	"\x15OPERATION_UNSUBSCRIBE\x10\x10\x12!\n" +
+	"\x1dOPERATION_GET_COMPONENT_TYPES\x10\x11\x12\x1c\n" +
+	"\x18OPERATION_GET_COMPONENTS\x10\x12\x12!\n" +
+	"\x1dOPERATION_SET_COMPONENT_FIELD\x10\x13\x12\x1b\n" +
+	"\x17OPERATION_ADD_COMPONENT\x10\x14\x12\x1e\n" +
+	"\x1aOPERATION_REMOVE_COMPONENT\x10\x15\x12\x13\n" +
+	"\x0fOPERATION_BATCH\x10\x16\x12\x17\n" +
+	"\x13OPERATION_GET_SCENE\x10\x17\x12\x18\n" +
+	"\x14OPERATION_SAVE_SCENE\x10\x18\x12!\n" +
+	"\x1dOPERATION_LOAD_SCENE_DOCUMENT\x10\x19\x12\x18\n" +
+	"\x14OPERATION_GET_OBJECT\x10\x1a\x12\x1c\n" +
+	"\x18OPERATION_SET_BASE_COLOR\x10\x1b\x12\x18\n" +
+	"\x14OPERATION_GET_CAMERA\x10\x1c\x12\x18\n" +
+	"\x14OPERATION_SET_CAMERA\x10\x1d\x12\x1a\n" +
+	"\x16OPERATION_RESET_CAMERA\x10\x1e\x12\x1b\n" +
+	"\x17OPERATION_STREAM_FRAMES\x10\x1f\x12\x19\n" +
+	"\x15OPERATION_STOP_FRAMES\x10 *`\n" +
+	"\rFrameEncoding\x12\x1e\n" +
+	"\x1aFRAME_ENCODING_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12FRAME_ENCODING_PNG\x10\x01\x12\x17\n" +
+	"\x13FRAME_ENCODING_JPEG\x10\x02*\xe5\x01\n" +
+	"\n" +
+	"ChangeKind\x12\x1b\n" +
+	"\x17CHANGE_KIND_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13CHANGE_KIND_SPAWNED\x10\x01\x12\x19\n" +
+	"\x15CHANGE_KIND_DESPAWNED\x10\x02\x12\x1a\n" +
+	"\x16CHANGE_KIND_REPARENTED\x10\x03\x12\x19\n" +
+	"\x15CHANGE_KIND_TRANSFORM\x10\x04\x12\x15\n" +
+	"\x11CHANGE_KIND_FIELD\x10\x05\x12\x1c\n" +
+	"\x18CHANGE_KIND_SCENE_LOADED\x10\x06\x12\x1a\n" +
+	"\x16CHANGE_KIND_COMPONENTS\x10\a*\x96\x01\n" +
+	"\rSceneEncoding\x12\x1e\n" +
+	"\x1aSCENE_ENCODING_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13SCENE_ENCODING_YAML\x10\x01\x12\x17\n" +
+	"\x13SCENE_ENCODING_JSON\x10\x02\x12\x19\n" +
+	"\x15SCENE_ENCODING_BINARY\x10\x03\x12\x18\n" +
+	"\x14SCENE_ENCODING_PROTO\x10\x042A\n" +
 	"\x06Engine\x127\n" +
 	"\x06Stream\x12\x13.grpc.EngineRequest\x1a\x14.grpc.EngineResponse(\x010\x01B\x10Z\x0e3d-engine/grpcb\x06proto3"
 
@@ -968,47 +3587,136 @@ func file_grpc_engine_proto_rawDescGZIP() []byte {
 	return file_grpc_engine_proto_rawDescData
 }
 
-var file_grpc_engine_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_grpc_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_grpc_engine_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_grpc_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_grpc_engine_proto_goTypes = []any{
-	(Operation)(0),         // 0: grpc.Operation
-	(*EngineRequest)(nil),  // 1: grpc.EngineRequest
-	(*EngineResponse)(nil), // 2: grpc.EngineResponse
-	(*Objects)(nil),        // 3: grpc.Objects
-	(*Object)(nil),         // 4: grpc.Object
-	(*Location)(nil),       // 5: grpc.Location
-	(*Vector4)(nil),        // 6: grpc.Vector4
-	(*Vector3)(nil),        // 7: grpc.Vector3
-	(*SceneRef)(nil),       // 8: grpc.SceneRef
-	(*SceneModeRef)(nil),   // 9: grpc.SceneModeRef
-	(*SceneMode)(nil),      // 10: grpc.SceneMode
-	(*SceneModes)(nil),     // 11: grpc.SceneModes
-	(*emptypb.Empty)(nil),  // 12: google.protobuf.Empty
+	(Operation)(0),               // 0: grpc.Operation
+	(FrameEncoding)(0),           // 1: grpc.FrameEncoding
+	(ChangeKind)(0),              // 2: grpc.ChangeKind
+	(SceneEncoding)(0),           // 3: grpc.SceneEncoding
+	(*EngineRequest)(nil),        // 4: grpc.EngineRequest
+	(*EngineResponse)(nil),       // 5: grpc.EngineResponse
+	(*CameraPose)(nil),           // 6: grpc.CameraPose
+	(*FrameRequest)(nil),         // 7: grpc.FrameRequest
+	(*Frame)(nil),                // 8: grpc.Frame
+	(*Objects)(nil),              // 9: grpc.Objects
+	(*Object)(nil),               // 10: grpc.Object
+	(*Location)(nil),             // 11: grpc.Location
+	(*Vector4)(nil),              // 12: grpc.Vector4
+	(*Vector3)(nil),              // 13: grpc.Vector3
+	(*SceneRef)(nil),             // 14: grpc.SceneRef
+	(*SceneModeRef)(nil),         // 15: grpc.SceneModeRef
+	(*SceneMode)(nil),            // 16: grpc.SceneMode
+	(*SceneModes)(nil),           // 17: grpc.SceneModes
+	(*History)(nil),              // 18: grpc.History
+	(*Subscription)(nil),         // 19: grpc.Subscription
+	(*Events)(nil),               // 20: grpc.Events
+	(*Change)(nil),               // 21: grpc.Change
+	(*ComponentFieldChange)(nil), // 22: grpc.ComponentFieldChange
+	(*ComponentField)(nil),       // 23: grpc.ComponentField
+	(*ComponentInfo)(nil),        // 24: grpc.ComponentInfo
+	(*Components)(nil),           // 25: grpc.Components
+	(*ComponentType)(nil),        // 26: grpc.ComponentType
+	(*ComponentTypes)(nil),       // 27: grpc.ComponentTypes
+	(*ComponentEdit)(nil),        // 28: grpc.ComponentEdit
+	(*Batch)(nil),                // 29: grpc.Batch
+	(*BatchResults)(nil),         // 30: grpc.BatchResults
+	(*SceneQuery)(nil),           // 31: grpc.SceneQuery
+	(*SceneDocument)(nil),        // 32: grpc.SceneDocument
+	(*SceneDescription)(nil),     // 33: grpc.SceneDescription
+	(*SceneCamera)(nil),          // 34: grpc.SceneCamera
+	(*SceneInclude)(nil),         // 35: grpc.SceneInclude
+	(*SceneCell)(nil),            // 36: grpc.SceneCell
+	(*SceneObject)(nil),          // 37: grpc.SceneObject
+	(*SceneBody)(nil),            // 38: grpc.SceneBody
+	(*SceneMaterial)(nil),        // 39: grpc.SceneMaterial
+	(*SceneComponent)(nil),       // 40: grpc.SceneComponent
+	(*SceneOverride)(nil),        // 41: grpc.SceneOverride
+	(*emptypb.Empty)(nil),        // 42: google.protobuf.Empty
+	(*structpb.Value)(nil),       // 43: google.protobuf.Value
 }
 var file_grpc_engine_proto_depIdxs = []int32{
 	0,  // 0: grpc.EngineRequest.operation:type_name -> grpc.Operation
-	12, // 1: grpc.EngineRequest.empty:type_name -> google.protobuf.Empty
-	4,  // 2: grpc.EngineRequest.object:type_name -> grpc.Object
-	8,  // 3: grpc.EngineRequest.scene:type_name -> grpc.SceneRef
-	9,  // 4: grpc.EngineRequest.scene_mode:type_name -> grpc.SceneModeRef
-	0,  // 5: grpc.EngineResponse.operation:type_name -> grpc.Operation
-	12, // 6: grpc.EngineResponse.empty:type_name -> google.protobuf.Empty
-	3,  // 7: grpc.EngineResponse.objects:type_name -> grpc.Objects
-	4,  // 8: grpc.EngineResponse.object:type_name -> grpc.Object
-	11, // 9: grpc.EngineResponse.scene_modes:type_name -> grpc.SceneModes
-	4,  // 10: grpc.Objects.objects:type_name -> grpc.Object
-	5,  // 11: grpc.Object.location:type_name -> grpc.Location
-	7,  // 12: grpc.Location.position:type_name -> grpc.Vector3
-	6,  // 13: grpc.Location.rotation:type_name -> grpc.Vector4
-	7,  // 14: grpc.Location.scale:type_name -> grpc.Vector3
-	10, // 15: grpc.SceneModes.modes:type_name -> grpc.SceneMode
-	1,  // 16: grpc.Engine.Stream:input_type -> grpc.EngineRequest
-	2,  // 17: grpc.Engine.Stream:output_type -> grpc.EngineResponse
-	17, // [17:18] is the sub-list for method output_type
-	16, // [16:17] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	42, // 1: grpc.EngineRequest.empty:type_name -> google.protobuf.Empty
+	10, // 2: grpc.EngineRequest.object:type_name -> grpc.Object
+	14, // 3: grpc.EngineRequest.scene:type_name -> grpc.SceneRef
+	15, // 4: grpc.EngineRequest.scene_mode:type_name -> grpc.SceneModeRef
+	19, // 5: grpc.EngineRequest.subscription:type_name -> grpc.Subscription
+	28, // 6: grpc.EngineRequest.component:type_name -> grpc.ComponentEdit
+	29, // 7: grpc.EngineRequest.batch:type_name -> grpc.Batch
+	31, // 8: grpc.EngineRequest.scene_query:type_name -> grpc.SceneQuery
+	32, // 9: grpc.EngineRequest.scene_document:type_name -> grpc.SceneDocument
+	6,  // 10: grpc.EngineRequest.camera:type_name -> grpc.CameraPose
+	7,  // 11: grpc.EngineRequest.frames:type_name -> grpc.FrameRequest
+	0,  // 12: grpc.EngineResponse.operation:type_name -> grpc.Operation
+	42, // 13: grpc.EngineResponse.empty:type_name -> google.protobuf.Empty
+	9,  // 14: grpc.EngineResponse.objects:type_name -> grpc.Objects
+	10, // 15: grpc.EngineResponse.object:type_name -> grpc.Object
+	17, // 16: grpc.EngineResponse.scene_modes:type_name -> grpc.SceneModes
+	18, // 17: grpc.EngineResponse.history:type_name -> grpc.History
+	20, // 18: grpc.EngineResponse.events:type_name -> grpc.Events
+	25, // 19: grpc.EngineResponse.components:type_name -> grpc.Components
+	27, // 20: grpc.EngineResponse.component_types:type_name -> grpc.ComponentTypes
+	30, // 21: grpc.EngineResponse.batch:type_name -> grpc.BatchResults
+	32, // 22: grpc.EngineResponse.scene_document:type_name -> grpc.SceneDocument
+	14, // 23: grpc.EngineResponse.scene:type_name -> grpc.SceneRef
+	6,  // 24: grpc.EngineResponse.camera:type_name -> grpc.CameraPose
+	8,  // 25: grpc.EngineResponse.frame:type_name -> grpc.Frame
+	13, // 26: grpc.CameraPose.position:type_name -> grpc.Vector3
+	1,  // 27: grpc.FrameRequest.encoding:type_name -> grpc.FrameEncoding
+	1,  // 28: grpc.Frame.encoding:type_name -> grpc.FrameEncoding
+	10, // 29: grpc.Objects.objects:type_name -> grpc.Object
+	11, // 30: grpc.Object.location:type_name -> grpc.Location
+	13, // 31: grpc.Object.base_color:type_name -> grpc.Vector3
+	13, // 32: grpc.Location.position:type_name -> grpc.Vector3
+	12, // 33: grpc.Location.rotation:type_name -> grpc.Vector4
+	13, // 34: grpc.Location.scale:type_name -> grpc.Vector3
+	16, // 35: grpc.SceneModes.modes:type_name -> grpc.SceneMode
+	21, // 36: grpc.Events.changes:type_name -> grpc.Change
+	2,  // 37: grpc.Change.kind:type_name -> grpc.ChangeKind
+	10, // 38: grpc.Change.object:type_name -> grpc.Object
+	22, // 39: grpc.Change.field:type_name -> grpc.ComponentFieldChange
+	24, // 40: grpc.Change.components:type_name -> grpc.ComponentInfo
+	23, // 41: grpc.ComponentFieldChange.field:type_name -> grpc.ComponentField
+	13, // 42: grpc.ComponentField.vec3_value:type_name -> grpc.Vector3
+	12, // 43: grpc.ComponentField.vec4_value:type_name -> grpc.Vector4
+	23, // 44: grpc.ComponentInfo.fields:type_name -> grpc.ComponentField
+	24, // 45: grpc.Components.components:type_name -> grpc.ComponentInfo
+	23, // 46: grpc.ComponentType.fields:type_name -> grpc.ComponentField
+	26, // 47: grpc.ComponentTypes.types:type_name -> grpc.ComponentType
+	23, // 48: grpc.ComponentEdit.fields:type_name -> grpc.ComponentField
+	4,  // 49: grpc.Batch.requests:type_name -> grpc.EngineRequest
+	5,  // 50: grpc.BatchResults.responses:type_name -> grpc.EngineResponse
+	3,  // 51: grpc.SceneQuery.encoding:type_name -> grpc.SceneEncoding
+	3,  // 52: grpc.SceneDocument.encoding:type_name -> grpc.SceneEncoding
+	33, // 53: grpc.SceneDocument.scene:type_name -> grpc.SceneDescription
+	34, // 54: grpc.SceneDescription.camera:type_name -> grpc.SceneCamera
+	35, // 55: grpc.SceneDescription.includes:type_name -> grpc.SceneInclude
+	36, // 56: grpc.SceneDescription.cells:type_name -> grpc.SceneCell
+	37, // 57: grpc.SceneDescription.objects:type_name -> grpc.SceneObject
+	13, // 58: grpc.SceneCamera.position:type_name -> grpc.Vector3
+	13, // 59: grpc.SceneCell.min:type_name -> grpc.Vector3
+	13, // 60: grpc.SceneCell.max:type_name -> grpc.Vector3
+	11, // 61: grpc.SceneObject.transform:type_name -> grpc.Location
+	38, // 62: grpc.SceneObject.body:type_name -> grpc.SceneBody
+	39, // 63: grpc.SceneObject.material:type_name -> grpc.SceneMaterial
+	40, // 64: grpc.SceneObject.components:type_name -> grpc.SceneComponent
+	37, // 65: grpc.SceneObject.children:type_name -> grpc.SceneObject
+	41, // 66: grpc.SceneObject.overrides:type_name -> grpc.SceneOverride
+	13, // 67: grpc.SceneMaterial.color:type_name -> grpc.Vector3
+	43, // 68: grpc.SceneComponent.props:type_name -> google.protobuf.Value
+	11, // 69: grpc.SceneOverride.transform:type_name -> grpc.Location
+	38, // 70: grpc.SceneOverride.body:type_name -> grpc.SceneBody
+	39, // 71: grpc.SceneOverride.material:type_name -> grpc.SceneMaterial
+	40, // 72: grpc.SceneOverride.components:type_name -> grpc.SceneComponent
+	37, // 73: grpc.SceneOverride.children:type_name -> grpc.SceneObject
+	4,  // 74: grpc.Engine.Stream:input_type -> grpc.EngineRequest
+	5,  // 75: grpc.Engine.Stream:output_type -> grpc.EngineResponse
+	75, // [75:76] is the sub-list for method output_type
+	74, // [74:75] is the sub-list for method input_type
+	74, // [74:74] is the sub-list for extension type_name
+	74, // [74:74] is the sub-list for extension extendee
+	0,  // [0:74] is the sub-list for field type_name
 }
 
 func init() { file_grpc_engine_proto_init() }
@@ -1021,20 +3729,45 @@ func file_grpc_engine_proto_init() {
 		(*EngineRequest_Object)(nil),
 		(*EngineRequest_Scene)(nil),
 		(*EngineRequest_SceneMode)(nil),
+		(*EngineRequest_Subscription)(nil),
+		(*EngineRequest_Component)(nil),
+		(*EngineRequest_Batch)(nil),
+		(*EngineRequest_SceneQuery)(nil),
+		(*EngineRequest_SceneDocument)(nil),
+		(*EngineRequest_Camera)(nil),
+		(*EngineRequest_Frames)(nil),
 	}
 	file_grpc_engine_proto_msgTypes[1].OneofWrappers = []any{
 		(*EngineResponse_Empty)(nil),
 		(*EngineResponse_Objects)(nil),
 		(*EngineResponse_Object)(nil),
 		(*EngineResponse_SceneModes)(nil),
+		(*EngineResponse_History)(nil),
+		(*EngineResponse_Events)(nil),
+		(*EngineResponse_Components)(nil),
+		(*EngineResponse_ComponentTypes)(nil),
+		(*EngineResponse_Batch)(nil),
+		(*EngineResponse_SceneDocument)(nil),
+		(*EngineResponse_Scene)(nil),
+		(*EngineResponse_Camera)(nil),
+		(*EngineResponse_Frame)(nil),
+	}
+	file_grpc_engine_proto_msgTypes[2].OneofWrappers = []any{}
+	file_grpc_engine_proto_msgTypes[19].OneofWrappers = []any{
+		(*ComponentField_BoolValue)(nil),
+		(*ComponentField_IntValue)(nil),
+		(*ComponentField_FloatValue)(nil),
+		(*ComponentField_Vec3Value)(nil),
+		(*ComponentField_Vec4Value)(nil),
+		(*ComponentField_StringValue)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grpc_engine_proto_rawDesc), len(file_grpc_engine_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   11,
+			NumEnums:      4,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -1,26 +1,124 @@
 package input
 
 import (
-	"fmt"
+	"math"
 	"sort"
+	"strings"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
-// KeySource reports whether a physical key is held. Poll reads through this
-// rather than touching GLFW directly, which keeps the edge-detection logic
-// testable and leaves room for a gamepad or replay source later.
+// KeySource reports the state of every device a binding can name. Poll reads
+// through this rather than touching GLFW directly, which keeps the
+// edge-detection logic testable and leaves room for a replay source later.
+//
+// Buttons and axes are state: asking twice in a frame gives the same answer.
+// Mouse movement and the wheel are not — GLFW reports them as events — so
+// MouseDelta and ScrollDelta return what built up since they were last called,
+// and Poll calls each exactly once.
 type KeySource interface {
 	IsKeyDown(key glfw.Key) bool
+	IsMouseButtonDown(button glfw.MouseButton) bool
+
+	// MouseDelta is how far the pointer moved, in screen pixels with y
+	// growing downwards.
+	MouseDelta() (x, y float64)
+	// ScrollDelta is how far the wheel turned, y positive away from the user.
+	ScrollDelta() (x, y float64)
+
+	IsGamepadButtonDown(button glfw.GamepadButton) bool
+	// GamepadAxis reads a stick from -1 to 1, or a trigger from 0 at rest to
+	// 1 fully pulled. A missing gamepad reads 0 on every axis.
+	GamepadAxis(axis glfw.GamepadAxis) float32
 }
 
-// Window adapts a GLFW window to KeySource.
-type Window struct {
-	Window *glfw.Window
+// Mods is a set of modifiers a binding requires. Left and right variants are
+// not distinguished: Ctrl means either Control key.
+type Mods uint8
+
+const (
+	ModShift Mods = 1 << iota
+	ModControl
+	ModAlt
+	ModSuper
+)
+
+// modKeys are the physical keys behind each modifier.
+var modKeys = []struct {
+	mod  Mods
+	keys [2]glfw.Key
+}{
+	{ModShift, [2]glfw.Key{glfw.KeyLeftShift, glfw.KeyRightShift}},
+	{ModControl, [2]glfw.Key{glfw.KeyLeftControl, glfw.KeyRightControl}},
+	{ModAlt, [2]glfw.Key{glfw.KeyLeftAlt, glfw.KeyRightAlt}},
+	{ModSuper, [2]glfw.Key{glfw.KeyLeftSuper, glfw.KeyRightSuper}},
 }
 
-func (w Window) IsKeyDown(key glfw.Key) bool {
-	return w.Window.GetKey(key) == glfw.Press
+// count is how many modifiers the set holds, which is how specific a chord is.
+func (m Mods) count() int {
+	n := 0
+	for _, modifier := range modKeys {
+		if m&modifier.mod != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Device is the kind of input a binding reads.
+type Device uint8
+
+const (
+	DeviceKeyboard Device = iota
+	DeviceMouseButton
+	// DeviceMouseMotion covers the pointer and the wheel, the inputs that
+	// report movement rather than a position.
+	DeviceMouseMotion
+	DeviceGamepadButton
+	DeviceGamepadAxis
+)
+
+// MotionAxis numbers the mouse's relative axes, for Binding.Code.
+type MotionAxis int
+
+const (
+	MouseX MotionAxis = iota
+	MouseY
+	ScrollX
+	ScrollY
+)
+
+// Binding is one way to trigger an action: a key plus any modifiers that must
+// be held with it, or a mouse or gamepad input. A plain key binding has no
+// Mods, and the zero Device is the keyboard, so Binding{Key: glfw.KeyW} is W.
+//
+// The remaining fields shape what the binding contributes to Map.Value and
+// Map.Motion. Left zero, a button counts 1 while held and an axis counts its
+// own reading.
+type Binding struct {
+	Device Device
+	Key    glfw.Key
+	Mods   Mods
+
+	// Code is the glfw.MouseButton, MotionAxis, glfw.GamepadButton or
+	// glfw.GamepadAxis, whichever Device calls for.
+	Code int
+
+	// Half takes one side of an axis: +1 reads only its positive half and -1
+	// only its negative half, flipped to count upwards. A stick's Y is two
+	// actions this way, move_forward on -1 and move_back on +1. Zero reads the
+	// whole axis, signed.
+	Half int8
+
+	// DeadZone is the reading an axis must pass before it counts. A stick's
+	// dead zone is a fraction of its travel and the rest is stretched to fill
+	// 0..1 again, so a binding still reaches full strength; the pointer's is
+	// in pixels and simply subtracted.
+	DeadZone float32
+	// Sensitivity scales the reading. Zero means 1.
+	Sensitivity float32
+	// Invert negates the reading, before Half picks a side.
+	Invert bool
 }
 
 // Action is the name a binding is known by, e.g. "move_forward". Game code and
@@ -35,89 +133,141 @@ type Action string
 // `glfw.GetTime() - LastPress >= N` debounce. Debouncing is inherent here —
 // JustPressed is a rising edge, so a toggle fires exactly once per physical
 // press regardless of frame rate.
+//
+// A binding can be a chord such as Ctrl+Z. When several bindings on one key are
+// satisfied, only those with the most modifiers fire, so holding Ctrl turns Z
+// from "toggle wireframe" into "undo" rather than doing both. While a chord
+// fires, actions bound to its modifier keys alone are held off too: Ctrl+Z
+// should not also fly the camera down because Ctrl is move_down.
+//
+// Bindings live in contexts, stacked as contexts.go describes. The Map's own
+// Bind methods fill the default context, which is always at the bottom.
 type Map struct {
-	bindings map[Action][]glfw.Key
+	base     *Context
+	contexts map[string]*Context
+	stack    []*Context
 
-	// keys is the set of keys any action binds, so Poll touches only those.
+	// keys is the set of keys any context binds, so Poll touches only those.
 	keys []glfw.Key
 
 	down     map[Action]bool
 	previous map[Action]bool
 
+	// value and motion are the analog side of the snapshot: what held inputs
+	// read this frame, and how far relative ones moved since the last.
+	value  map[Action]float32
+	motion map[Action]float32
+
+	// hidden is the actions the stack hid at the last Poll. One that comes
+	// back does so without an edge, see Poll.
+	hidden map[Action]bool
+
 	// alwaysActive survives suppression. Without it, an action bound to
 	// "close the panel that is capturing the keyboard" could never fire.
 	alwaysActive map[Action]bool
 }
 
 func NewMap() *Map {
-	return &Map{
-		bindings:     map[Action][]glfw.Key{},
+	m := &Map{
+		contexts:     map[string]*Context{},
 		down:         map[Action]bool{},
 		previous:     map[Action]bool{},
+		value:        map[Action]float32{},
+		motion:       map[Action]float32{},
+		hidden:       map[Action]bool{},
 		alwaysActive: map[Action]bool{},
 	}
+	m.base = m.Context(DefaultContext)
+	m.stack = []*Context{m.base}
+	return m
 }
 
-// Bind points an action at one or more keys. Any of them triggers it.
+// Bind points an action at one or more keys in the default context. Any of
+// them triggers it.
 func (m *Map) Bind(action Action, keys ...glfw.Key) {
-	m.bindings[action] = append(m.bindings[action], keys...)
-	m.rebuildKeySet()
+	m.base.Bind(action, keys...)
 }
 
-// BindNames is Bind with config-file key names.
-func (m *Map) BindNames(action Action, names ...string) error {
-	keys := make([]glfw.Key, 0, len(names))
+// BindChord adds a key-plus-modifiers binding to the default context, e.g.
+// Ctrl+Z.
+func (m *Map) BindChord(action Action, mods Mods, key glfw.Key) {
+	m.base.BindChord(action, mods, key)
+}
 
-	for _, name := range names {
-		key, err := ParseKey(name)
-		if err != nil {
-			return fmt.Errorf("action %q: %w", action, err)
-		}
-		keys = append(keys, key)
-	}
+// BindInput adds bindings of any device to the default context, e.g. the
+// mouse's X axis for looking around.
+func (m *Map) BindInput(action Action, bindings ...Binding) {
+	m.base.BindInput(action, bindings...)
+}
 
-	m.bindings[action] = keys
-	m.rebuildKeySet()
-	return nil
+// BindNames is Bind with config-file names, in the form ParseBinding reads. A
+// name may be a chord such as "Ctrl+Z", or a mouse or gamepad input such as
+// "-GamepadLeftY deadzone=0.2".
+func (m *Map) BindNames(action Action, names ...string) error {
+	return m.base.BindNames(action, names...)
 }
 
-// Rebind replaces an action's keys outright, which is what a config file does
-// on top of the engine defaults.
+// Rebind replaces an action's keys in the default context outright, which is
+// what a config file does on top of the engine defaults.
 func (m *Map) Rebind(action Action, keys ...glfw.Key) {
-	m.bindings[action] = keys
-	m.rebuildKeySet()
+	m.base.Rebind(action, keys...)
 }
 
-// Keys returns the keys bound to an action.
-func (m *Map) Keys(action Action) []glfw.Key {
-	return m.bindings[action]
+// Bindings returns the bindings of an action in the default context.
+func (m *Map) Bindings(action Action) []Binding {
+	return m.base.Bindings(action)
 }
 
-// Actions lists every bound action, sorted.
+// Actions lists every action bound in any context, sorted.
 func (m *Map) Actions() []Action {
-	actions := make([]Action, 0, len(m.bindings))
-	for action := range m.bindings {
-		actions = append(actions, action)
+	seen := map[Action]bool{}
+	actions := []Action{}
+	for _, context := range m.contexts {
+		for action := range context.bindings {
+			if !seen[action] {
+				seen[action] = true
+				actions = append(actions, action)
+			}
+		}
 	}
 	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
 	return actions
 }
 
-// Describe renders an action's binding for display, e.g. "W, Up".
+// Describe renders the bindings that drive an action right now, e.g. "W, Up".
+// When they come from a context other than the default one, its name follows
+// in brackets, "Ctrl+S (editor)". An action bound only in contexts that are
+// off the stack or blocked is listed with those contexts, as "inactive
+// (vehicle)".
 func (m *Map) Describe(action Action) string {
-	keys := m.bindings[action]
-	if len(keys) == 0 {
-		return "unbound"
+	if context := m.resolve()[action]; context != nil {
+		label := ""
+		for i, binding := range context.bindings[action] {
+			if i > 0 {
+				label += ", "
+			}
+			label += binding.String()
+		}
+		if label == "" {
+			label = "unbound"
+		}
+		if context != m.base {
+			label += " (" + context.Name + ")"
+		}
+		return label
 	}
 
-	label := ""
-	for i, key := range keys {
-		if i > 0 {
-			label += ", "
+	var owners []string
+	for name, context := range m.contexts {
+		if len(context.bindings[action]) > 0 {
+			owners = append(owners, name)
 		}
-		label += KeyLabel(key)
 	}
-	return label
+	if len(owners) == 0 {
+		return "unbound"
+	}
+	sort.Strings(owners)
+	return "inactive (" + strings.Join(owners, ", ") + ")"
 }
 
 // SetAlwaysActive exempts an action from suppression, so it keeps working while
@@ -134,13 +284,15 @@ func (m *Map) AlwaysActive(action Action) bool {
 	return m.alwaysActive[action]
 }
 
-// Poll samples the keyboard once per frame. Everything else reads the snapshot
+// Poll samples the devices once per frame. Everything else reads the snapshot
 // it produces, so all queries within a frame agree with each other.
 //
-// suppress blanks the map without losing edge tracking — used while the editor
-// overlay owns the keyboard, so typing in a text field cannot also drive the
-// camera, and releasing focus does not read as a fresh key press. Actions
-// marked with SetAlwaysActive are still evaluated.
+// suppress blanks the keyboard bindings without losing edge tracking — used
+// while the editor overlay owns the keyboard, so typing in a text field cannot
+// also drive the camera, and releasing focus does not read as a fresh key
+// press. Actions marked with SetAlwaysActive are still evaluated. The mouse
+// is not covered: the overlay claims the pointer separately, and Window
+// stops reporting it while it does.
 func (m *Map) Poll(source KeySource, suppress bool) {
 	for action, isDown := range m.down {
 		m.previous[action] = isDown
@@ -152,21 +304,210 @@ func (m *Map) Poll(source KeySource, suppress bool) {
 		pressed[key] = source.IsKeyDown(key)
 	}
 
-	for action, keys := range m.bindings {
-		if suppress && !m.alwaysActive[action] {
-			m.down[action] = false
-			continue
+	var held Mods
+	for _, modifier := range modKeys {
+		if pressed[modifier.keys[0]] || pressed[modifier.keys[1]] {
+			held |= modifier.mod
 		}
+	}
+
+	live := m.resolve()
+
+	// The most specific satisfied chord on each key wins, and a firing chord
+	// claims its modifier keys. Only live bindings compete: a chord in a
+	// context that is off the stack cannot hold off a plain key in one that
+	// is on it.
+	best := map[glfw.Key]int{}
+	var claimed Mods
+	for action, context := range live {
+		for _, binding := range context.bindings[action] {
+			if binding.Device != DeviceKeyboard || !binding.satisfied(pressed, held) {
+				continue
+			}
+			n := binding.Mods.count()
+			if current, ok := best[binding.Key]; !ok || n > current {
+				best[binding.Key] = n
+			}
+			claimed |= binding.Mods
+		}
+	}
+
+	samples := newSampler(source)
+
+	for action, context := range live {
+		keyboard := !suppress || m.alwaysActive[action]
 
 		state := false
-		for _, key := range keys {
-			if pressed[key] {
+		var value, motion float32
+		for _, binding := range context.bindings[action] {
+			if binding.Device == DeviceKeyboard {
+				if !keyboard || !binding.satisfied(pressed, held) || binding.Mods.count() < best[binding.Key] {
+					continue
+				}
+				if binding.Mods == 0 && claimed.includesKey(binding.Key) {
+					continue
+				}
 				state = true
-				break
+				value += binding.shape(1)
+				continue
+			}
+
+			reading, down := binding.read(samples.sample(binding))
+			state = state || down
+			if binding.Device == DeviceMouseMotion {
+				motion += reading
+			} else {
+				value += reading
 			}
 		}
 		m.down[action] = state
+		m.value[action] = value
+		m.motion[action] = motion
+
+		// An action the stack just gave back starts from whatever is held,
+		// without an edge. Otherwise the Escape that pushed a menu would, on
+		// the next frame, press the menu's own Escape-bound "close".
+		if m.hidden[action] {
+			m.previous[action] = state
+			delete(m.hidden, action)
+		}
 	}
+
+	// Everything else is off the stack or under a blocking context. It reads
+	// as idle, and does not report a release either: what was held when the
+	// menu opened was not let go.
+	hide := func(action Action) {
+		if live[action] != nil {
+			return
+		}
+		m.down[action] = false
+		m.previous[action] = false
+		m.value[action] = 0
+		m.motion[action] = 0
+		m.hidden[action] = true
+	}
+	for _, context := range m.contexts {
+		for action := range context.bindings {
+			hide(action)
+		}
+	}
+	for action := range m.down {
+		hide(action)
+	}
+}
+
+func (b Binding) satisfied(pressed map[glfw.Key]bool, held Mods) bool {
+	return pressed[b.Key] && held&b.Mods == b.Mods
+}
+
+// pressThreshold is how far an axis must be pushed to count as held, so a
+// stick bound to move_forward also satisfies IsDown.
+const pressThreshold = 0.5
+
+// read turns a raw sample into what the binding contributes, and whether it
+// holds the action down. Relative motion never does: the pointer passing
+// through is not a press.
+func (b Binding) read(raw float32) (float32, bool) {
+	switch b.Device {
+	case DeviceMouseButton, DeviceGamepadButton:
+		return b.shape(raw), raw != 0
+	}
+
+	magnitude := float32(math.Abs(float64(raw)))
+	if magnitude <= b.DeadZone {
+		return 0, false
+	}
+	if b.Device == DeviceGamepadAxis {
+		magnitude = (magnitude - b.DeadZone) / (1 - b.DeadZone)
+	} else {
+		magnitude -= b.DeadZone
+	}
+	reading := float32(math.Copysign(float64(magnitude), float64(raw)))
+
+	if b.Invert {
+		reading = -reading
+	}
+	switch {
+	case b.Half > 0:
+		reading = max(reading, 0)
+	case b.Half < 0:
+		reading = max(-reading, 0)
+	}
+
+	down := b.Device == DeviceGamepadAxis && math.Abs(float64(reading)) >= pressThreshold
+	return reading * b.sensitivity(), down
+}
+
+// shape applies inversion and sensitivity to a button's reading, for which a
+// dead zone and a half mean nothing.
+func (b Binding) shape(reading float32) float32 {
+	if b.Invert {
+		reading = -reading
+	}
+	return reading * b.sensitivity()
+}
+
+func (b Binding) sensitivity() float32 {
+	if b.Sensitivity == 0 {
+		return 1
+	}
+	return b.Sensitivity
+}
+
+// sampler reads each non-keyboard input once per Poll however many bindings
+// share it, and takes the mouse's deltas whether or not anything is bound to
+// them, so movement from a frame nobody read cannot turn up later.
+type sampler struct {
+	source  KeySource
+	motion  [4]float64
+	samples map[[2]int]float32
+}
+
+func newSampler(source KeySource) *sampler {
+	s := &sampler{source: source, samples: map[[2]int]float32{}}
+	s.motion[MouseX], s.motion[MouseY] = source.MouseDelta()
+	s.motion[ScrollX], s.motion[ScrollY] = source.ScrollDelta()
+	return s
+}
+
+func (s *sampler) sample(b Binding) float32 {
+	if b.Device == DeviceMouseMotion {
+		if b.Code < 0 || b.Code >= len(s.motion) {
+			return 0
+		}
+		return float32(s.motion[b.Code])
+	}
+
+	id := [2]int{int(b.Device), b.Code}
+	if reading, ok := s.samples[id]; ok {
+		return reading
+	}
+
+	var reading float32
+	switch b.Device {
+	case DeviceMouseButton:
+		if s.source.IsMouseButtonDown(glfw.MouseButton(b.Code)) {
+			reading = 1
+		}
+	case DeviceGamepadButton:
+		if s.source.IsGamepadButtonDown(glfw.GamepadButton(b.Code)) {
+			reading = 1
+		}
+	case DeviceGamepadAxis:
+		reading = s.source.GamepadAxis(glfw.GamepadAxis(b.Code))
+	}
+	s.samples[id] = reading
+	return reading
+}
+
+// includesKey reports whether key is one of the physical keys behind mods.
+func (m Mods) includesKey(key glfw.Key) bool {
+	for _, modifier := range modKeys {
+		if m&modifier.mod != 0 && (key == modifier.keys[0] || key == modifier.keys[1]) {
+			return true
+		}
+	}
+	return false
 }
 
 // IsDown reports whether the action is held this frame.
@@ -174,6 +515,25 @@ func (m *Map) IsDown(action Action) bool {
 	return m.down[action]
 }
 
+// Value is what the action's held inputs read this frame, summed: 1 for each
+// pressed key or button, and a stick's deflection past its dead zone. Each
+// binding's Sensitivity and Invert apply, so Value can exceed 1 or go
+// negative.
+//
+// Use Value for something that moves at a rate while held, and scale it by the
+// frame time; a stick half pushed walks at half speed.
+func (m *Map) Value(action Action) float32 {
+	return m.value[action]
+}
+
+// Motion is how far the action's relative inputs — the pointer and the wheel
+// — moved since the last Poll, scaled by each binding's Sensitivity. It is
+// already a distance for the frame: scaling it by the frame time as well would
+// make looking around slower the faster the game runs.
+func (m *Map) Motion(action Action) float32 {
+	return m.motion[action]
+}
+
 // JustPressed reports the rising edge: true only on the frame the action went
 // down. This is what toggles should use.
 func (m *Map) JustPressed(action Action) bool {
@@ -189,13 +549,27 @@ func (m *Map) rebuildKeySet() {
 	seen := map[glfw.Key]bool{}
 	m.keys = m.keys[:0]
 
-	for _, keys := range m.bindings {
-		for _, key := range keys {
-			if seen[key] {
-				continue
-			}
+	add := func(key glfw.Key) {
+		if !seen[key] {
 			seen[key] = true
 			m.keys = append(m.keys, key)
 		}
 	}
+
+	for _, context := range m.contexts {
+		for _, bindings := range context.bindings {
+			for _, binding := range bindings {
+				if binding.Device != DeviceKeyboard {
+					continue
+				}
+				add(binding.Key)
+				for _, modifier := range modKeys {
+					if binding.Mods&modifier.mod != 0 {
+						add(modifier.keys[0])
+						add(modifier.keys[1])
+					}
+				}
+			}
+		}
+	}
 }
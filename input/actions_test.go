@@ -1,14 +1,17 @@
 package input
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 // fakeKeyboard is a KeySource backed by a set of held keys, so the tests drive
-// the real Poll rather than a copy of it.
+// the real Poll rather than a copy of it. The embedded State answers for the
+// other devices, with nothing pressed.
 type fakeKeyboard struct {
+	State
 	pressed map[glfw.Key]bool
 }
 
@@ -165,3 +168,189 @@ func TestKeySetIsDeduplicated(t *testing.T) {
 		t.Fatalf("expected 2 distinct keys to poll, got %d: %v", len(m.keys), m.keys)
 	}
 }
+
+// TestChordOutranksPlainKey is the Ctrl+Z case: Z alone toggles wireframe, and
+// holding Ctrl must turn it into undo instead of doing both.
+func TestChordOutranksPlainKey(t *testing.T) {
+	m := NewMap()
+	m.Bind("wireframe", glfw.KeyZ)
+	m.Bind("move_down", glfw.KeyLeftControl)
+	m.BindChord("undo", ModControl, glfw.KeyZ)
+	m.BindChord("redo", ModControl|ModShift, glfw.KeyZ)
+
+	kb := &fakeKeyboard{pressed: map[glfw.Key]bool{glfw.KeyZ: true}}
+	m.Poll(kb, false)
+	if !m.IsDown("wireframe") || m.IsDown("undo") {
+		t.Fatal("Z alone should only toggle wireframe")
+	}
+
+	kb.pressed = map[glfw.Key]bool{glfw.KeyLeftControl: true}
+	m.Poll(kb, false)
+	if !m.IsDown("move_down") {
+		t.Fatal("Ctrl alone should still be move_down")
+	}
+
+	kb.pressed = map[glfw.Key]bool{glfw.KeyRightControl: true, glfw.KeyZ: true}
+	m.Poll(kb, false)
+	if !m.IsDown("undo") || m.IsDown("wireframe") || m.IsDown("redo") {
+		t.Fatal("Ctrl+Z should undo and nothing else")
+	}
+
+	kb.pressed = map[glfw.Key]bool{glfw.KeyLeftControl: true, glfw.KeyLeftShift: true, glfw.KeyZ: true}
+	m.Poll(kb, false)
+	if !m.IsDown("redo") || m.IsDown("undo") {
+		t.Fatal("Ctrl+Shift+Z should pick the more specific chord")
+	}
+	if m.IsDown("move_down") {
+		t.Fatal("a firing chord should hold off its modifier's own action")
+	}
+}
+
+func TestChordNamesRoundTrip(t *testing.T) {
+	m := NewMap()
+	if err := m.BindNames("redo", "ctrl+shift+z", "Ctrl+Y"); err != nil {
+		t.Fatalf("BindNames: %v", err)
+	}
+	if got := m.Describe("redo"); got != "Ctrl+Shift+Z, Ctrl+Y" {
+		t.Fatalf("Describe: got %q", got)
+	}
+	if err := m.BindNames("bad", "Hyper+Z"); err == nil {
+		t.Fatal("an unknown modifier should be an error")
+	}
+}
+
+// TestAnalogBindings: a stick's dead zone is cut out and the rest stretched
+// back to full travel, a half reads one direction only, and a stick pushed
+// far enough holds its action down like a key.
+func TestAnalogBindings(t *testing.T) {
+	m := NewMap()
+	if err := m.BindNames("move_forward", "W", "-GamepadLeftY deadzone=0.25"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.BindNames("move_back", "+GamepadLeftY deadzone=0.25"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.BindNames("look_y", "GamepadRightY invert sensitivity=2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.BindNames("brake", "GamepadLeftTrigger"); err != nil {
+		t.Fatal(err)
+	}
+	pad := State{GamepadAxes: map[glfw.GamepadAxis]float32{}}
+
+	pad.GamepadAxes[glfw.AxisLeftY] = -0.1
+	m.Poll(pad, false)
+	if m.Value("move_forward") != 0 || m.IsDown("move_forward") {
+		t.Errorf("inside the dead zone: value %g, down %v", m.Value("move_forward"), m.IsDown("move_forward"))
+	}
+
+	pad.GamepadAxes[glfw.AxisLeftY] = -0.625
+	m.Poll(pad, false)
+	if got := m.Value("move_forward"); got != 0.5 || !m.IsDown("move_forward") {
+		t.Errorf("pushed forward to 0.625: value %g, down %v", got, m.IsDown("move_forward"))
+	}
+	if m.Value("move_back") != 0 {
+		t.Errorf("pushing forward reads %g on move_back", m.Value("move_back"))
+	}
+
+	pad.GamepadAxes[glfw.AxisLeftY] = -1
+	pad.GamepadAxes[glfw.AxisRightY] = -0.5
+	pad.GamepadAxes[glfw.AxisLeftTrigger] = 0.25
+	m.Poll(pad, false)
+	if got := m.Value("move_forward"); got != 1 {
+		t.Errorf("a full push reads %g, want 1", got)
+	}
+	if got := m.Value("look_y"); got != 1 {
+		t.Errorf("stick up, inverted and doubled, reads %g, want 1", got)
+	}
+	if m.Value("brake") != 0.25 || m.IsDown("brake") {
+		t.Errorf("a light pull on the trigger: value %g, down %v", m.Value("brake"), m.IsDown("brake"))
+	}
+
+	// A key and the stick together add up; the caller decides whether that
+	// means faster.
+	pad.Keys = map[glfw.Key]bool{glfw.KeyW: true}
+	m.Poll(pad, false)
+	if got := m.Value("move_forward"); got != 2 {
+		t.Errorf("W and a full push read %g, want 2", got)
+	}
+}
+
+// TestMotionIsRelative: the pointer and the wheel are distances for the
+// frame, reported by Motion, and never hold an action down. Buttons are held
+// like keys, and the keyboard's suppression does not reach them.
+func TestMotionIsRelative(t *testing.T) {
+	m := NewMap()
+	if err := m.BindNames("look_x", "MouseX sensitivity=0.1", "GamepadRightX"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.BindNames("zoom", "ScrollY deadzone=1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.BindNames("fire", "MouseLeft", "GamepadRightBumper"); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Poll(State{
+		Mouse:        [2]float64{30, 4},
+		Scroll:       [2]float64{0, 0.5},
+		MouseButtons: map[glfw.MouseButton]bool{glfw.MouseButtonLeft: true},
+		GamepadAxes:  map[glfw.GamepadAxis]float32{glfw.AxisRightX: 1},
+	}, true)
+	if got := m.Motion("look_x"); got != 3 {
+		t.Errorf("30 pixels at 0.1 moved %g, want 3", got)
+	}
+	if m.Value("look_x") != 1 || !m.IsDown("look_x") {
+		t.Errorf("the stick beside the mouse: value %g, down %v", m.Value("look_x"), m.IsDown("look_x"))
+	}
+	if m.Motion("zoom") != 0 {
+		t.Errorf("half a notch against a dead zone of one moved %g", m.Motion("zoom"))
+	}
+	if !m.JustPressed("fire") {
+		t.Error("the mouse button was suppressed with the keyboard")
+	}
+
+	m.Poll(State{Mouse: [2]float64{30, 0}}, false)
+	if m.IsDown("fire") || m.Value("look_x") != 0 || m.Motion("look_x") != 3 {
+		t.Errorf("pointer moving alone: down %v, value %g", m.IsDown("fire"), m.Value("look_x"))
+	}
+}
+
+// TestDeviceNamesRoundTrip: every spelling ParseBinding takes comes back out
+// of Describe as written, and the ones it refuses say why.
+func TestDeviceNamesRoundTrip(t *testing.T) {
+	m := NewMap()
+	names := []string{
+		"MouseLeft",
+		"Mouse5",
+		"-GamepadLeftY deadzone=0.25",
+		"MouseY invert sensitivity=0.1",
+		"GamepadDpadUp",
+		"Ctrl+Z",
+	}
+	if err := m.BindNames("any", names...); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Describe("any"), strings.Join(names, ", "); got != want {
+		t.Errorf("Describe: got %q, want %q", got, want)
+	}
+	if binding, err := ParseBinding("Ctrl + Z"); err != nil || binding != (Binding{Key: glfw.KeyZ, Mods: ModControl}) {
+		t.Errorf("a chord written with spaces: %+v, %v", binding, err)
+	}
+
+	for _, bad := range []string{
+		"+GamepadA",
+		"-W",
+		"Ctrl+MouseLeft",
+		"GamepadLeftX deadzone=1",
+		"W deadzone=0.1",
+		"MouseX sensitivity=-1",
+		"MouseX sensitivity=fast",
+		"MouseX smooth=2",
+		"invert",
+	} {
+		if _, err := ParseBinding(bad); err == nil {
+			t.Errorf("%q was accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// DefaultContext is the context at the bottom of every Map's stack. The Map's
+// own Bind methods, and config.yml's input.actions, fill it.
+const DefaultContext = "default"
+
+// Context is a named set of bindings that can be switched on and off as a
+// whole: the game on foot, the game in a vehicle, a menu, the editor.
+//
+// Contexts are stacked. Poll reads an action from the topmost context on the
+// stack that binds it, so a vehicle context that binds move_forward to the
+// throttle takes the action over while it is pushed and gives it back when it
+// is popped. An action a context binds to nothing at all is still its to
+// answer, which is how a context switches one off: a vehicle binding jump to
+// no keys keeps the player's jump from firing while driving.
+//
+// Actions a context does not mention fall through to the contexts beneath,
+// unless it is Blocking. A blocking context is the only one that answers:
+// everything under it reads as idle until it is popped, which is what a menu
+// wants — its arrow keys must not also walk the player about.
+type Context struct {
+	Name string
+
+	// Blocking hides the contexts beneath this one from Poll.
+	Blocking bool
+
+	bindings map[Action][]Binding
+	m        *Map
+}
+
+// Context returns the named context, creating an empty one on first use.
+// Creating a context does not push it.
+func (m *Map) Context(name string) *Context {
+	if context, ok := m.contexts[name]; ok {
+		return context
+	}
+	context := &Context{Name: name, bindings: map[Action][]Binding{}, m: m}
+	m.contexts[name] = context
+	return context
+}
+
+// Push puts the named context on top of the stack, creating it if need be.
+// A context is on the stack at most once, and pushing one that is already
+// there leaves it where it is: something else may have been pushed above it
+// since, and still expects to be on top.
+func (m *Map) Push(name string) {
+	context := m.Context(name)
+	for _, stacked := range m.stack {
+		if stacked == context {
+			return
+		}
+	}
+	m.stack = append(m.stack, context)
+}
+
+// Pop takes the named context off the stack, wherever it is on it, so a mode
+// that ends out of turn does not take another's context with it. The default
+// context stays; popping it, or a context that is not pushed, does nothing.
+func (m *Map) Pop(name string) {
+	for i, context := range m.stack {
+		if context.Name == name && context != m.base {
+			m.stack = append(m.stack[:i], m.stack[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stack lists the pushed contexts from the bottom up, the default first.
+func (m *Map) Stack() []string {
+	names := make([]string, len(m.stack))
+	for i, context := range m.stack {
+		names[i] = context.Name
+	}
+	return names
+}
+
+// Active reports which contexts Poll reads this frame: the stack from its top
+// down to the first blocking context, listed top first.
+func (m *Map) Active() []string {
+	var names []string
+	for i := len(m.stack) - 1; i >= 0; i-- {
+		names = append(names, m.stack[i].Name)
+		if m.stack[i].Blocking {
+			break
+		}
+	}
+	return names
+}
+
+// resolve works out which context answers for each action: the topmost
+// active one that binds it.
+func (m *Map) resolve() map[Action]*Context {
+	live := map[Action]*Context{}
+	for i := len(m.stack) - 1; i >= 0; i-- {
+		context := m.stack[i]
+		for action := range context.bindings {
+			if _, taken := live[action]; !taken {
+				live[action] = context
+			}
+		}
+		if context.Blocking {
+			break
+		}
+	}
+	return live
+}
+
+// Bind points an action at one or more keys. Any of them triggers it.
+func (c *Context) Bind(action Action, keys ...glfw.Key) {
+	for _, key := range keys {
+		c.bindings[action] = append(c.bindings[action], Binding{Key: key})
+	}
+	c.m.rebuildKeySet()
+}
+
+// BindChord adds a key-plus-modifiers binding, e.g. Ctrl+Z.
+func (c *Context) BindChord(action Action, mods Mods, key glfw.Key) {
+	c.bindings[action] = append(c.bindings[action], Binding{Key: key, Mods: mods})
+	c.m.rebuildKeySet()
+}
+
+// BindInput adds bindings of any device.
+func (c *Context) BindInput(action Action, bindings ...Binding) {
+	c.bindings[action] = append(c.bindings[action], bindings...)
+	c.m.rebuildKeySet()
+}
+
+// BindNames replaces an action's bindings with config-file names. No names
+// leaves the action bound to nothing, which in a pushed context switches it
+// off.
+func (c *Context) BindNames(action Action, names ...string) error {
+	bindings := make([]Binding, 0, len(names))
+
+	for _, name := range names {
+		binding, err := ParseBinding(name)
+		if err != nil {
+			return fmt.Errorf("action %q: %w", action, err)
+		}
+		bindings = append(bindings, binding)
+	}
+
+	c.bindings[action] = bindings
+	c.m.rebuildKeySet()
+	return nil
+}
+
+// Rebind replaces an action's keys outright.
+func (c *Context) Rebind(action Action, keys ...glfw.Key) {
+	c.bindings[action] = nil
+	c.Bind(action, keys...)
+}
+
+// Bindings returns the bindings of an action in this context.
+func (c *Context) Bindings(action Action) []Binding {
+	return c.bindings[action]
+}
@@ -0,0 +1,124 @@
+package input
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// TestContextLayersOverDefault: a pushed context takes over the actions it
+// binds, switches off one it binds to nothing, and leaves the rest to the
+// default context beneath it.
+func TestContextLayersOverDefault(t *testing.T) {
+	m := NewMap()
+	m.Bind("move_forward", glfw.KeyW)
+	m.Bind("jump", glfw.KeySpace)
+	m.Bind("sprint", glfw.KeyLeftShift)
+
+	vehicle := m.Context("vehicle")
+	vehicle.Bind("move_forward", glfw.KeyUp)
+	if err := vehicle.BindNames("jump"); err != nil {
+		t.Fatal(err)
+	}
+	kb := &fakeKeyboard{pressed: map[glfw.Key]bool{glfw.KeyW: true, glfw.KeySpace: true, glfw.KeyLeftShift: true}}
+
+	m.Poll(kb, false)
+	if !m.IsDown("move_forward") || !m.IsDown("jump") {
+		t.Fatal("a context that is not pushed took effect")
+	}
+
+	m.Push("vehicle")
+	m.Poll(kb, false)
+	if m.IsDown("move_forward") || m.IsDown("jump") || !m.IsDown("sprint") {
+		t.Errorf("in the vehicle: forward %v, jump %v, sprint %v", m.IsDown("move_forward"), m.IsDown("jump"), m.IsDown("sprint"))
+	}
+	if got := m.Describe("move_forward"); got != "Up (vehicle)" {
+		t.Errorf("Describe: got %q", got)
+	}
+	if got := m.Describe("jump"); got != "unbound (vehicle)" {
+		t.Errorf("Describe of a switched-off action: got %q", got)
+	}
+
+	kb.pressed[glfw.KeyUp] = true
+	m.Poll(kb, false)
+	if !m.JustPressed("move_forward") {
+		t.Error("the vehicle's own key did not press its action")
+	}
+
+	m.Pop("vehicle")
+	m.Poll(kb, false)
+	if !m.IsDown("move_forward") || m.Describe("move_forward") != "W" {
+		t.Errorf("after popping, forward %v, described %q", m.IsDown("move_forward"), m.Describe("move_forward"))
+	}
+	if got := m.Describe("never_bound"); got != "unbound" {
+		t.Errorf("Describe of an unknown action: got %q", got)
+	}
+}
+
+// TestBlockingContext is the menu case: nothing beneath answers, what was
+// held when it opened neither releases nor presses, and the key that opened it
+// does not also close it.
+func TestBlockingContext(t *testing.T) {
+	m := NewMap()
+	m.Bind("open_menu", glfw.KeyEscape)
+	m.Bind("move_forward", glfw.KeyW)
+	menu := m.Context("menu")
+	menu.Blocking = true
+	menu.Bind("close_menu", glfw.KeyEscape)
+	menu.Bind("menu_up", glfw.KeyW)
+
+	kb := &fakeKeyboard{pressed: map[glfw.Key]bool{glfw.KeyW: true}}
+	m.Poll(kb, false)
+	if got := m.Describe("close_menu"); got != "inactive (menu)" {
+		t.Errorf("Describe before the menu opens: got %q", got)
+	}
+
+	kb.pressed[glfw.KeyEscape] = true
+	m.Poll(kb, false)
+	if !m.JustPressed("open_menu") {
+		t.Fatal("Escape did not open the menu")
+	}
+	m.Push("menu")
+
+	m.Poll(kb, false)
+	if m.JustPressed("close_menu") || !m.IsDown("close_menu") {
+		t.Error("the Escape that opened the menu pressed its close as well")
+	}
+	if m.IsDown("move_forward") || m.JustReleased("move_forward") || m.JustPressed("menu_up") {
+		t.Error("W under the menu: walked, released, or pressed menu_up from a held key")
+	}
+	if active := m.Active(); !slices.Equal(active, []string{"menu"}) {
+		t.Errorf("active contexts %v", active)
+	}
+
+	kb.pressed[glfw.KeyEscape] = false
+	m.Poll(kb, false)
+	kb.pressed[glfw.KeyEscape] = true
+	m.Poll(kb, false)
+	if !m.JustPressed("close_menu") || m.IsDown("open_menu") {
+		t.Error("a fresh Escape in the menu should close it, and only that")
+	}
+}
+
+// TestStackOrder: a context is pushed once, pops from wherever it is, and the
+// default context cannot be popped at all.
+func TestStackOrder(t *testing.T) {
+	m := NewMap()
+	m.Push("editor")
+	m.Push("menu")
+	m.Push("editor")
+	if stack := m.Stack(); !slices.Equal(stack, []string{DefaultContext, "editor", "menu"}) {
+		t.Fatalf("stack %v", stack)
+	}
+
+	m.Pop("editor")
+	m.Pop(DefaultContext)
+	m.Pop("never_pushed")
+	if stack := m.Stack(); !slices.Equal(stack, []string{DefaultContext, "menu"}) {
+		t.Errorf("stack after pops %v", stack)
+	}
+	if active := m.Active(); !slices.Equal(active, []string{"menu", DefaultContext}) {
+		t.Errorf("active %v", active)
+	}
+}
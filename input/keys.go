@@ -1,10 +1,12 @@
-// Package input maps physical keys to named actions, so bindings live in
-// config rather than in the code that reacts to them.
+// Package input maps physical keys, mouse and gamepad inputs to named
+// actions, so bindings live in config rather than in the code that reacts to
+// them.
 package input
 
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -101,3 +103,230 @@ func KeyNames() []string {
 	sort.Strings(names)
 	return names
 }
+
+// modNames are the config-file spellings of modifiers, matched
+// case-insensitively like key names.
+var modNames = map[string]Mods{
+	"shift":   ModShift,
+	"ctrl":    ModControl,
+	"control": ModControl,
+	"alt":     ModAlt,
+	"super":   ModSuper,
+}
+
+// inputNames is the config-file spelling of every mouse and gamepad input,
+// matched case-insensitively like key names. Each entry is the Binding the
+// name stands for, with only Device and Code set.
+var inputNames = map[string]Binding{}
+
+// inputLabels is the reverse lookup, keyed by Device and Code.
+var inputLabels = map[[2]int]string{}
+
+func registerInput(name string, device Device, code int) {
+	inputNames[strings.ToLower(name)] = Binding{Device: device, Code: code}
+	inputLabels[[2]int{int(device), code}] = name
+}
+
+func init() {
+	registerInput("MouseLeft", DeviceMouseButton, int(glfw.MouseButtonLeft))
+	registerInput("MouseRight", DeviceMouseButton, int(glfw.MouseButtonRight))
+	registerInput("MouseMiddle", DeviceMouseButton, int(glfw.MouseButtonMiddle))
+	for n := 4; n <= 8; n++ {
+		registerInput(fmt.Sprintf("Mouse%d", n), DeviceMouseButton, int(glfw.MouseButton4)+n-4)
+	}
+
+	registerInput("MouseX", DeviceMouseMotion, int(MouseX))
+	registerInput("MouseY", DeviceMouseMotion, int(MouseY))
+	registerInput("ScrollX", DeviceMouseMotion, int(ScrollX))
+	registerInput("ScrollY", DeviceMouseMotion, int(ScrollY))
+
+	buttons := map[string]glfw.GamepadButton{
+		"GamepadA":           glfw.ButtonA,
+		"GamepadB":           glfw.ButtonB,
+		"GamepadX":           glfw.ButtonX,
+		"GamepadY":           glfw.ButtonY,
+		"GamepadLeftBumper":  glfw.ButtonLeftBumper,
+		"GamepadRightBumper": glfw.ButtonRightBumper,
+		"GamepadBack":        glfw.ButtonBack,
+		"GamepadStart":       glfw.ButtonStart,
+		"GamepadGuide":       glfw.ButtonGuide,
+		"GamepadLeftThumb":   glfw.ButtonLeftThumb,
+		"GamepadRightThumb":  glfw.ButtonRightThumb,
+		"GamepadDpadUp":      glfw.ButtonDpadUp,
+		"GamepadDpadRight":   glfw.ButtonDpadRight,
+		"GamepadDpadDown":    glfw.ButtonDpadDown,
+		"GamepadDpadLeft":    glfw.ButtonDpadLeft,
+	}
+	for name, button := range buttons {
+		registerInput(name, DeviceGamepadButton, int(button))
+	}
+
+	axes := map[string]glfw.GamepadAxis{
+		"GamepadLeftX":        glfw.AxisLeftX,
+		"GamepadLeftY":        glfw.AxisLeftY,
+		"GamepadRightX":       glfw.AxisRightX,
+		"GamepadRightY":       glfw.AxisRightY,
+		"GamepadLeftTrigger":  glfw.AxisLeftTrigger,
+		"GamepadRightTrigger": glfw.AxisRightTrigger,
+	}
+	for name, axis := range axes {
+		registerInput(name, DeviceGamepadAxis, int(axis))
+	}
+}
+
+// ParseBinding resolves a config-file binding. The input comes first: a key
+// name, optionally preceded by modifiers as in "Ctrl+Shift+Z", or a mouse or
+// gamepad input such as "MouseLeft" or "GamepadRightX". An axis may be
+// prefixed with + or - to take only that half of it.
+//
+// Options follow, separated by spaces: "invert", "deadzone=0.2" and
+// "sensitivity=0.1". So "-GamepadLeftY deadzone=0.2" is the stick pushed
+// forward, ignoring the first fifth of its travel, and "MouseY invert
+// sensitivity=0.1" is the pointer moving up, a tenth of a degree a pixel.
+func ParseBinding(name string) (Binding, error) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 || strings.Contains(fields[0], "=") {
+		return Binding{}, fmt.Errorf("empty binding %q", name)
+	}
+
+	// The options start at the first word that reads as one, so "Ctrl + Z"
+	// still parses as the chord it always did.
+	split := len(fields)
+	for i, field := range fields {
+		if strings.Contains(field, "=") || strings.EqualFold(field, "invert") {
+			split = i
+			break
+		}
+	}
+
+	binding, err := parseInput(strings.Join(fields[:split], " "))
+	if err != nil {
+		return Binding{}, err
+	}
+
+	for _, option := range fields[split:] {
+		key, value, hasValue := strings.Cut(option, "=")
+		switch strings.ToLower(key) {
+		case "invert":
+			if hasValue {
+				return Binding{}, fmt.Errorf("option invert in %q takes no value", name)
+			}
+			binding.Invert = true
+		case "deadzone":
+			if binding.Device != DeviceGamepadAxis && binding.Device != DeviceMouseMotion {
+				return Binding{}, fmt.Errorf("%q: only an axis has a dead zone", name)
+			}
+			deadZone, err := parseOption(option, value)
+			if err != nil {
+				return Binding{}, err
+			}
+			if deadZone < 0 || (binding.Device == DeviceGamepadAxis && deadZone >= 1) {
+				return Binding{}, fmt.Errorf("%q: dead zone %g is out of range", name, deadZone)
+			}
+			binding.DeadZone = deadZone
+		case "sensitivity":
+			sensitivity, err := parseOption(option, value)
+			if err != nil {
+				return Binding{}, err
+			}
+			if sensitivity <= 0 {
+				return Binding{}, fmt.Errorf("%q: sensitivity must be positive; use invert to reverse an input", name)
+			}
+			binding.Sensitivity = sensitivity
+		default:
+			return Binding{}, fmt.Errorf("unknown option %q in %q", option, name)
+		}
+	}
+	return binding, nil
+}
+
+// parseInput reads the part of a binding before its options.
+func parseInput(name string) (Binding, error) {
+	var half int8
+	if len(name) > 1 && (name[0] == '+' || name[0] == '-') {
+		half = 1
+		if name[0] == '-' {
+			half = -1
+		}
+		name = name[1:]
+	}
+
+	if binding, ok := inputNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		if half != 0 && binding.Device != DeviceGamepadAxis && binding.Device != DeviceMouseMotion {
+			return Binding{}, fmt.Errorf("%q is a button, and has no halves", name)
+		}
+		binding.Half = half
+		return binding, nil
+	}
+	if half != 0 {
+		return Binding{}, fmt.Errorf("unknown axis %q", name)
+	}
+
+	parts := strings.Split(name, "+")
+
+	var mods Mods
+	for _, part := range parts[:len(parts)-1] {
+		mod, ok := modNames[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return Binding{}, fmt.Errorf("unknown modifier %q in %q", part, name)
+		}
+		mods |= mod
+	}
+
+	last := parts[len(parts)-1]
+	if _, ok := inputNames[strings.ToLower(strings.TrimSpace(last))]; ok {
+		return Binding{}, fmt.Errorf("%q: modifiers only combine with keys", name)
+	}
+	key, err := ParseKey(last)
+	if err != nil {
+		return Binding{}, err
+	}
+	return Binding{Key: key, Mods: mods}, nil
+}
+
+func parseOption(option, value string) (float32, error) {
+	parsed, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return 0, fmt.Errorf("option %q is not a number", option)
+	}
+	return float32(parsed), nil
+}
+
+// String renders a binding the way ParseBinding reads it.
+func (b Binding) String() string {
+	label := ""
+	if b.Device == DeviceKeyboard {
+		for _, modifier := range []struct {
+			mod  Mods
+			name string
+		}{{ModControl, "Ctrl"}, {ModShift, "Shift"}, {ModAlt, "Alt"}, {ModSuper, "Super"}} {
+			if b.Mods&modifier.mod != 0 {
+				label += modifier.name + "+"
+			}
+		}
+		label += KeyLabel(b.Key)
+	} else {
+		switch {
+		case b.Half > 0:
+			label = "+"
+		case b.Half < 0:
+			label = "-"
+		}
+		if name, ok := inputLabels[[2]int{int(b.Device), b.Code}]; ok {
+			label += name
+		} else {
+			label += fmt.Sprintf("input(%d,%d)", b.Device, b.Code)
+		}
+	}
+
+	if b.Invert {
+		label += " invert"
+	}
+	if b.DeadZone != 0 {
+		label += " deadzone=" + strconv.FormatFloat(float64(b.DeadZone), 'g', -1, 32)
+	}
+	if b.Sensitivity != 0 && b.Sensitivity != 1 {
+		label += " sensitivity=" + strconv.FormatFloat(float64(b.Sensitivity), 'g', -1, 32)
+	}
+	return label
+}
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"slices"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Recorder is a KeySource that passes another through and keeps what it
+// answered. Poll asks only about what is bound, so a frame of answers is
+// exactly the input Poll saw: fed back through a State, the same bindings
+// produce the same snapshot again, whatever the real devices are doing.
+type Recorder struct {
+	Source KeySource
+
+	frame State
+}
+
+func (r *Recorder) IsKeyDown(key glfw.Key) bool {
+	down := r.Source.IsKeyDown(key)
+	if down {
+		if r.frame.Keys == nil {
+			r.frame.Keys = map[glfw.Key]bool{}
+		}
+		r.frame.Keys[key] = true
+	}
+	return down
+}
+
+func (r *Recorder) IsMouseButtonDown(button glfw.MouseButton) bool {
+	down := r.Source.IsMouseButtonDown(button)
+	if down {
+		if r.frame.MouseButtons == nil {
+			r.frame.MouseButtons = map[glfw.MouseButton]bool{}
+		}
+		r.frame.MouseButtons[button] = true
+	}
+	return down
+}
+
+func (r *Recorder) MouseDelta() (float64, float64) {
+	x, y := r.Source.MouseDelta()
+	r.frame.Mouse = [2]float64{x, y}
+	return x, y
+}
+
+func (r *Recorder) ScrollDelta() (float64, float64) {
+	x, y := r.Source.ScrollDelta()
+	r.frame.Scroll = [2]float64{x, y}
+	return x, y
+}
+
+func (r *Recorder) IsGamepadButtonDown(button glfw.GamepadButton) bool {
+	down := r.Source.IsGamepadButtonDown(button)
+	if down {
+		if r.frame.GamepadButtons == nil {
+			r.frame.GamepadButtons = map[glfw.GamepadButton]bool{}
+		}
+		r.frame.GamepadButtons[button] = true
+	}
+	return down
+}
+
+func (r *Recorder) GamepadAxis(axis glfw.GamepadAxis) float32 {
+	reading := r.Source.GamepadAxis(axis)
+	if reading != 0 {
+		if r.frame.GamepadAxes == nil {
+			r.frame.GamepadAxes = map[glfw.GamepadAxis]float32{}
+		}
+		r.frame.GamepadAxes[axis] = reading
+	}
+	return reading
+}
+
+// Take returns what the source answered since the last Take, and starts the
+// next frame empty.
+func (r *Recorder) Take() State {
+	frame := r.frame
+	r.frame = State{}
+	return frame
+}
+
+// ActionState is one action's part of what Poll worked out.
+type ActionState struct {
+	Down   bool    `json:"down,omitempty"`
+	Value  float32 `json:"value,omitempty"`
+	Motion float32 `json:"motion,omitempty"`
+}
+
+// Snapshot returns the state of every action that is not idle after the last
+// Poll. A replay compares it with the one recorded to notice when the same
+// input no longer means the same thing — the bindings changed since, say —
+// and what follows cannot be trusted to match.
+func (m *Map) Snapshot() map[Action]ActionState {
+	snapshot := map[Action]ActionState{}
+	for action, down := range m.down {
+		state := ActionState{Down: down, Value: m.value[action], Motion: m.motion[action]}
+		if state != (ActionState{}) {
+			snapshot[action] = state
+		}
+	}
+	return snapshot
+}
+
+// SetStack replaces the pushed contexts with names, bottom first, as Stack
+// lists them. The default context stays at the bottom whether or not names
+// starts with it. It is for putting back a recorded stack; anything else
+// should Push and Pop.
+func (m *Map) SetStack(names []string) {
+	m.stack = []*Context{m.base}
+	for _, name := range names {
+		if name == DefaultContext {
+			continue
+		}
+		if context := m.Context(name); !slices.Contains(m.stack, context) {
+			m.stack = append(m.stack, context)
+		}
+	}
+}
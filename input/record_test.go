@@ -0,0 +1,54 @@
+package input
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// TestRecordedFrameReplays: what a Recorder kept of a frame, played back
+// through a State, gives Poll the same snapshot as the devices did.
+func TestRecordedFrameReplays(t *testing.T) {
+	bind := func(m *Map) {
+		if err := m.BindNames("move_forward", "W", "-GamepadLeftY deadzone=0.25"); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.BindNames("look_x", "MouseX sensitivity=0.1"); err != nil {
+			t.Fatal(err)
+		}
+		m.Bind("jump", glfw.KeySpace)
+		m.Push("vehicle")
+	}
+	live, replayed := NewMap(), NewMap()
+	bind(live)
+	bind(replayed)
+
+	devices := State{
+		Keys:        map[glfw.Key]bool{glfw.KeyW: true, glfw.KeyQ: true},
+		Mouse:       [2]float64{12, -3},
+		GamepadAxes: map[glfw.GamepadAxis]float32{glfw.AxisLeftY: -0.5, glfw.AxisRightX: 1},
+	}
+	recorder := &Recorder{Source: devices}
+	live.Poll(recorder, false)
+	frame := recorder.Take()
+
+	if frame.Keys[glfw.KeyQ] || frame.GamepadAxes[glfw.AxisRightX] != 0 {
+		t.Errorf("recorded inputs nothing is bound to: %+v", frame)
+	}
+	if frame.Mouse != devices.Mouse || !frame.Keys[glfw.KeyW] {
+		t.Errorf("recorded %+v", frame)
+	}
+	if again := recorder.Take(); again.Keys != nil || again.Mouse != [2]float64{} {
+		t.Errorf("Take did not start a new frame: %+v", again)
+	}
+
+	replayed.SetStack([]string{DefaultContext, "vehicle", "vehicle"})
+	replayed.Poll(frame, false)
+	if !maps.Equal(live.Snapshot(), replayed.Snapshot()) {
+		t.Errorf("replayed %v, live %v", replayed.Snapshot(), live.Snapshot())
+	}
+	if len(live.Snapshot()) != 2 || live.Snapshot()["jump"] != (ActionState{}) {
+		t.Errorf("snapshot holds idle actions: %v", live.Snapshot())
+	}
+}
@@ -0,0 +1,125 @@
+package input
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+// Window adapts a GLFW window, and a gamepad, to KeySource.
+//
+// Keys, buttons and sticks are state GLFW can be asked for at any time, so
+// those are read straight through. The pointer and the wheel only arrive as
+// callbacks, and GLFW allows one callback of each kind per window — the engine
+// owns those, to decide when the mouse is the game's at all — so the engine
+// forwards the events it lets through to CursorMoved and Scrolled, and they
+// build up here until Poll takes them.
+type Window struct {
+	Window *glfw.Window
+
+	// Joystick is the gamepad bindings read. The zero value is
+	// glfw.Joystick1, the first one connected.
+	Joystick glfw.Joystick
+
+	// BlockButtons hides the mouse buttons, for while something else — the
+	// editor overlay — is under the pointer and a click is meant for it.
+	BlockButtons bool
+
+	lastX, lastY     float64
+	tracking         bool
+	moveX, moveY     float64
+	scrollX, scrollY float64
+}
+
+func (w *Window) IsKeyDown(key glfw.Key) bool {
+	return w.Window.GetKey(key) == glfw.Press
+}
+
+func (w *Window) IsMouseButtonDown(button glfw.MouseButton) bool {
+	return !w.BlockButtons && w.Window.GetMouseButton(button) == glfw.Press
+}
+
+// CursorMoved takes a cursor position from the window's callback. The first
+// position after a ResetCursor only sets the origin: the pointer got there
+// while nobody was measuring, and counting the jump would snap the view.
+func (w *Window) CursorMoved(x, y float64) {
+	if w.tracking {
+		w.moveX += x - w.lastX
+		w.moveY += y - w.lastY
+	}
+	w.lastX, w.lastY = x, y
+	w.tracking = true
+}
+
+// ResetCursor forgets where the pointer was, for when it has been free to move
+// without the game following it.
+func (w *Window) ResetCursor() {
+	w.tracking = false
+}
+
+// Scrolled takes a wheel movement from the window's callback.
+func (w *Window) Scrolled(x, y float64) {
+	w.scrollX += x
+	w.scrollY += y
+}
+
+func (w *Window) MouseDelta() (float64, float64) {
+	x, y := w.moveX, w.moveY
+	w.moveX, w.moveY = 0, 0
+	return x, y
+}
+
+func (w *Window) ScrollDelta() (float64, float64) {
+	x, y := w.scrollX, w.scrollY
+	w.scrollX, w.scrollY = 0, 0
+	return x, y
+}
+
+func (w *Window) IsGamepadButtonDown(button glfw.GamepadButton) bool {
+	state := w.Joystick.GetGamepadState()
+	if state == nil || button < 0 || int(button) >= len(state.Buttons) {
+		return false
+	}
+	return state.Buttons[button] == glfw.Press
+}
+
+// GamepadAxis reads through GLFW, which reports a trigger from -1 at rest;
+// KeySource promises 0.
+func (w *Window) GamepadAxis(axis glfw.GamepadAxis) float32 {
+	state := w.Joystick.GetGamepadState()
+	if state == nil || axis < 0 || int(axis) >= len(state.Axes) {
+		return 0
+	}
+	reading := state.Axes[axis]
+	if axis == glfw.AxisLeftTrigger || axis == glfw.AxisRightTrigger {
+		reading = (reading + 1) / 2
+	}
+	return reading
+}
+
+// State is a KeySource that reports whatever its fields hold. Tests use it as
+// a fake, setting the fields a frame at a time and calling Poll; a Recorder
+// fills one in per frame, and a replay plays them back, which is why it has
+// JSON tags. The zero State has nothing pressed, so a fake for one device can
+// embed it for the others.
+//
+// Unlike Window, State does not consume its deltas: Mouse and Scroll are
+// reported on every Poll until they are changed.
+type State struct {
+	Keys           map[glfw.Key]bool            `json:"keys,omitempty"`
+	MouseButtons   map[glfw.MouseButton]bool    `json:"mouseButtons,omitempty"`
+	Mouse          [2]float64                   `json:"mouse"`
+	Scroll         [2]float64                   `json:"scroll"`
+	GamepadButtons map[glfw.GamepadButton]bool  `json:"gamepadButtons,omitempty"`
+	GamepadAxes    map[glfw.GamepadAxis]float32 `json:"gamepadAxes,omitempty"`
+}
+
+func (s State) IsKeyDown(key glfw.Key) bool { return s.Keys[key] }
+
+func (s State) IsMouseButtonDown(button glfw.MouseButton) bool { return s.MouseButtons[button] }
+
+func (s State) MouseDelta() (float64, float64) { return s.Mouse[0], s.Mouse[1] }
+
+func (s State) ScrollDelta() (float64, float64) { return s.Scroll[0], s.Scroll[1] }
+
+func (s State) IsGamepadButtonDown(button glfw.GamepadButton) bool {
+	return s.GamepadButtons[button]
+}
+
+func (s State) GamepadAxis(axis glfw.GamepadAxis) float32 { return s.GamepadAxes[axis] }
@@ -3,6 +3,7 @@ package main
 import (
 	"runtime"
 
+	"3d-engine/commands"
 	"3d-engine/components"
 	"3d-engine/editor"
 	"3d-engine/engine"
@@ -15,11 +16,15 @@ func init() {
 }
 
 func main() {
-	args := utils.ParseArgs()
+	// A command such as migrate runs and exits inside ParseArgs, before there
+	// is a window.
+	args := utils.ParseArgs(commands.All()...)
 
 	app, err := engine.New(engine.Options{
 		ConfigPath: args.ConfigPath,
 		ScenePath:  args.ScenePath,
+		RecordPath: args.RecordPath,
+		ReplayPath: args.ReplayPath,
 
 		// A hook rather than a call on the returned App, because New loads the
 		// initial scene before it returns and that scene has to be able to name
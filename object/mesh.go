@@ -2,6 +2,7 @@ package object
 
 import (
 	"3d-engine/shaders"
+	tex "3d-engine/textures"
 	"3d-engine/utils"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
@@ -19,6 +20,19 @@ type Texture struct {
 	Type            string
 	Path            string
 	HasTransparency bool
+
+	// Options are what the texture was acquired with, and so what it must be
+	// released with: the cache keys on them.
+	Options tex.Options
+}
+
+// textureOptions picks the upload settings for a material slot. Diffuse is the
+// only colour map a mesh has; the rest hold data and must not be sRGB-decoded.
+func textureOptions(typeName string) tex.Options {
+	if typeName == "texture_diffuse" {
+		return tex.ColorMap()
+	}
+	return tex.DataMap()
 }
 
 type Mesh struct {
@@ -86,8 +100,13 @@ func (m *Mesh) computeMetadata() {
 		m.hasLocalBounds = true
 	}
 
-	for _, tex := range m.Textures {
-		if tex.HasTransparency {
+	m.refreshTransparency()
+}
+
+func (m *Mesh) refreshTransparency() {
+	m.hasTransparency = false
+	for _, texture := range m.Textures {
+		if texture.HasTransparency {
 			m.hasTransparency = true
 			break
 		}
@@ -213,6 +232,15 @@ func (m *Mesh) DrawPass(shader *shaders.Shader, drawTransparent bool) {
 	}
 }
 
+// DrawGeometry draws the triangles and nothing else: no textures, no material
+// uniforms, no blending. For passes that only care about the shape, like the
+// selection outline.
+func (m *Mesh) DrawGeometry() {
+	gl.BindVertexArray(m.vao)
+	gl.DrawElements(gl.TRIANGLES, int32(len(m.Indices)), gl.UNSIGNED_INT, nil)
+	gl.BindVertexArray(0)
+}
+
 // Delete frees the mesh's GPU buffers. The textures are owned by the texture
 // cache and released by Model.Delete instead.
 func (m *Mesh) Delete() {
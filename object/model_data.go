@@ -0,0 +1,271 @@
+package object
+
+import (
+	tex "3d-engine/textures"
+	"3d-engine/utils"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bloeys/assimp-go/asig"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ModelData is a model file parsed into memory: vertices, indices and the
+// textures each mesh wants, with nothing on the GPU yet. Producing one is pure
+// CPU work, safe on any goroutine, which is what lets a scene's models be
+// parsed in parallel while the frame loop keeps drawing the old scene.
+type ModelData struct {
+	Path      string
+	Directory string
+	Meshes    []MeshData
+}
+
+// MeshData is one mesh of a ModelData.
+type MeshData struct {
+	Vertices []Vertex
+	Indices  []uint32
+	Textures []TextureRef
+}
+
+// TextureRef names a texture a mesh samples: the resolved file, the material
+// slot it fills, and the upload options that slot implies.
+type TextureRef struct {
+	Path    string
+	Type    string
+	Options tex.Options
+}
+
+// TextureRefs lists the distinct textures the model uses, in first-use order.
+func (d *ModelData) TextureRefs() []TextureRef {
+	var refs []TextureRef
+	seen := map[TextureRef]bool{}
+	for _, mesh := range d.Meshes {
+		for _, ref := range mesh.Textures {
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// ParseModel imports a model file with assimp and copies out what the
+// renderer needs. It touches no GL.
+func ParseModel(path string) (*ModelData, error) {
+	utils.Logger().Infoln("Importing file: ", path)
+	scene, release, err := asig.ImportFile(path, asig.PostProcessTriangulate|asig.PostProcessJoinIdenticalVertices|asig.PostProcessOptimizeMeshes|asig.PostProcessFlipUVs|asig.PostProcessSplitLargeMeshes|asig.PostProcessGenNormals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import model %q: %w", path, err)
+	}
+	defer release()
+
+	data := &ModelData{Path: path, Directory: filepath.Dir(path)}
+	if err := data.processNode(scene.RootNode, scene); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *ModelData) processNode(node *asig.Node, scene *asig.Scene) error {
+	utils.Logger().Infoln("Processing node: ", node.Name)
+	for i := 0; i < len(node.MeshIndicies); i++ {
+		mesh := scene.Meshes[node.MeshIndicies[i]]
+		processedMesh, err := d.processMesh(mesh, scene)
+		if err != nil {
+			return err
+		}
+		d.Meshes = append(d.Meshes, processedMesh)
+	}
+
+	for i := 0; i < len(node.Children); i++ {
+		if err := d.processNode(node.Children[i], scene); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ModelData) processMesh(mesh *asig.Mesh, scene *asig.Scene) (MeshData, error) {
+	var out MeshData
+
+	for i := 0; i < len(mesh.Vertices); i++ {
+		var vertex Vertex
+
+		vertex.Position = mesh.Vertices[i].Data
+
+		if len(mesh.Normals) > 0 {
+			vertex.Normal = mesh.Normals[i].Data
+		}
+
+		if len(mesh.TexCoords) > 0 && len(mesh.TexCoords[0]) > i {
+			vertex.TexCoords = mgl32.Vec2{mesh.TexCoords[0][i].X(), mesh.TexCoords[0][i].Y()}
+		} else {
+			vertex.TexCoords = mgl32.Vec2{0.0, 0.0}
+		}
+
+		out.Vertices = append(out.Vertices, vertex)
+	}
+
+	for _, face := range mesh.Faces {
+		for _, indice := range face.Indices {
+			out.Indices = append(out.Indices, uint32(indice))
+		}
+	}
+
+	if mesh.MaterialIndex >= 0 {
+		material := scene.Materials[mesh.MaterialIndex]
+
+		slots := []struct {
+			kind asig.TextureType
+			name string
+		}{
+			{asig.TextureTypeDiffuse, "texture_diffuse"},
+			{asig.TextureTypeSpecular, "texture_specular"},
+			{asig.TextureTypeNormal, "texture_normal"},
+			{asig.TextureTypeHeight, "texture_height"},
+		}
+		for _, slot := range slots {
+			refs, err := d.materialTextures(material, slot.kind, slot.name)
+			if err != nil {
+				return MeshData{}, err
+			}
+			out.Textures = append(out.Textures, refs...)
+		}
+	}
+
+	return out, nil
+}
+
+func (d *ModelData) materialTextures(material *asig.Material, textureType asig.TextureType, typeName string) ([]TextureRef, error) {
+	var refs []TextureRef
+
+	for i := 0; i < asig.GetMaterialTextureCount(material, textureType); i++ {
+		aTexture, err := asig.GetMaterialTexture(material, textureType, uint(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get material texture: %w", err)
+		}
+		// Textures are keyed by their resolved path and options, since that is
+		// what has to be handed back to the cache on release.
+		refs = append(refs, TextureRef{
+			Path:    filepath.Join(d.Directory, aTexture.Path),
+			Type:    typeName,
+			Options: textureOptions(typeName),
+		})
+	}
+
+	return refs, nil
+}
+
+// DecodeTextures decodes every texture the model uses that the texture cache
+// doesn't already hold, so the upload that follows only has GL work left.
+// Like ParseModel it is safe off the frame loop. A texture that becomes
+// resident in the meantime is simply not re-uploaded; one that is evicted is
+// decoded on the spot by the upload instead.
+func DecodeTextures(data *ModelData) (map[TextureRef]*tex.Image, error) {
+	images := map[TextureRef]*tex.Image{}
+	for _, ref := range data.TextureRefs() {
+		if tex.Resident(ref.Path, ref.Options) {
+			continue
+		}
+		img, err := tex.Decode(ref.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load texture %q: %w", ref.Path, err)
+		}
+		images[ref] = img
+	}
+	return images, nil
+}
+
+// ModelUpload is the GL half of an import, done one mesh per Step so a caller
+// can spread a large model over several frames.
+type ModelUpload struct {
+	data   *ModelData
+	images map[TextureRef]*tex.Image
+	model  *Model
+	next   int
+}
+
+// NewModelUpload prepares to upload data. images may hold pre-decoded
+// textures from DecodeTextures; anything missing is loaded when needed.
+func NewModelUpload(data *ModelData, images map[TextureRef]*tex.Image) *ModelUpload {
+	return &ModelUpload{
+		data:   data,
+		images: images,
+		model:  &Model{Path: data.Path, Directory: data.Directory},
+	}
+}
+
+// Step uploads the next mesh and any texture it is first to use, reporting
+// done once every mesh is on the GPU. GL thread only.
+func (u *ModelUpload) Step() (done bool, err error) {
+	if u.next >= len(u.data.Meshes) {
+		u.finish()
+		return true, nil
+	}
+
+	meshData := u.data.Meshes[u.next]
+	textures := make([]Texture, 0, len(meshData.Textures))
+	for _, ref := range meshData.Textures {
+		texture, err := u.model.acquireTexture(ref, u.images[ref])
+		if err != nil {
+			return false, err
+		}
+		textures = append(textures, texture)
+	}
+	u.model.Meshes = append(u.model.Meshes, *CreateMesh(meshData.Vertices, meshData.Indices, textures))
+	u.next++
+
+	if u.next < len(u.data.Meshes) {
+		return false, nil
+	}
+	u.finish()
+	return true, nil
+}
+
+func (u *ModelUpload) finish() {
+	if !u.model.hasLocalBounds {
+		u.model.computeLocalBounds()
+	}
+	// The decoded pixels are on the GPU now; don't keep a second copy alive.
+	u.images = nil
+}
+
+// Model returns the uploaded model. Only meaningful once Step reports done.
+func (u *ModelUpload) Model() *Model {
+	return u.model
+}
+
+// Abort frees whatever the upload created so far.
+func (u *ModelUpload) Abort() {
+	u.model.Delete()
+	u.images = nil
+}
+
+// acquireTexture returns the model's texture for ref, acquiring it from the
+// cache the first time the model uses it. One Acquire per distinct texture in
+// this model, matched by one Release in Model.Delete.
+func (m *Model) acquireTexture(ref TextureRef, img *tex.Image) (Texture, error) {
+	for _, loaded := range m.TexturesLoaded {
+		if loaded.Path == ref.Path && loaded.Options == ref.Options {
+			// Same file in another slot keeps that slot's type.
+			loaded.Type = ref.Type
+			return loaded, nil
+		}
+	}
+
+	textureId, isTransparent, err := tex.AcquireImage(ref.Path, ref.Options, img)
+	if err != nil {
+		return Texture{}, fmt.Errorf("failed to load texture %q: %w", ref.Path, err)
+	}
+
+	texture := Texture{
+		Id:              textureId,
+		Path:            ref.Path,
+		Type:            ref.Type,
+		HasTransparency: isTransparent,
+		Options:         ref.Options,
+	}
+	m.TexturesLoaded = append(m.TexturesLoaded, texture)
+	return texture, nil
+}
@@ -0,0 +1,38 @@
+package object
+
+import (
+	"testing"
+
+	tex "3d-engine/textures"
+)
+
+func TestRefreshTextureUpdatesMatchingMeshes(t *testing.T) {
+	albedo := Texture{Id: 1, Path: "a.png", Type: "texture_diffuse", Options: tex.ColorMap()}
+	// Same file as a data map is a different upload, and must be left alone.
+	albedoAsData := Texture{Id: 2, Path: "a.png", Type: "texture_specular", Options: tex.DataMap()}
+
+	m := &Model{
+		TexturesLoaded: []Texture{albedo, albedoAsData},
+		Meshes: []Mesh{
+			{Textures: []Texture{albedo}},
+			{Textures: []Texture{albedoAsData}},
+		},
+	}
+
+	m.RefreshTexture("a.png", tex.ColorMap(), true)
+
+	if !m.Meshes[0].IsTransparent() {
+		t.Error("mesh sampling the reloaded texture did not become transparent")
+	}
+	if m.Meshes[1].IsTransparent() {
+		t.Error("mesh sampling the same file under other options changed")
+	}
+	if !m.TexturesLoaded[0].HasTransparency || m.TexturesLoaded[1].HasTransparency {
+		t.Errorf("TexturesLoaded = %+v", m.TexturesLoaded)
+	}
+
+	m.RefreshTexture("a.png", tex.ColorMap(), false)
+	if m.Meshes[0].IsTransparent() {
+		t.Error("mesh stayed transparent after the alpha was painted out")
+	}
+}
@@ -0,0 +1,134 @@
+package object
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// IntersectRay returns how far along the ray it enters the box, or 0 if it
+// starts inside. The slab test: the ray is inside the box exactly where it is
+// inside all three pairs of planes at once.
+//
+// dir need not be unit length; the answer is in multiples of it.
+func (b AABB) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	near := float32(math.Inf(-1))
+	far := float32(math.Inf(1))
+
+	for axis := 0; axis < 3; axis++ {
+		if dir[axis] == 0 {
+			// Parallel to this pair of planes: in between them or never.
+			if origin[axis] < b.Min[axis] || origin[axis] > b.Max[axis] {
+				return 0, false
+			}
+			continue
+		}
+
+		t1 := (b.Min[axis] - origin[axis]) / dir[axis]
+		t2 := (b.Max[axis] - origin[axis]) / dir[axis]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		near = max(near, t1)
+		far = min(far, t2)
+		if near > far {
+			return 0, false
+		}
+	}
+
+	if far < 0 {
+		return 0, false
+	}
+	return max(near, 0), true
+}
+
+// IntersectRay returns the nearest triangle the model-space ray crosses. The
+// bounds are tried first, so a ray that misses the mesh entirely costs six
+// divisions rather than a pass over every triangle.
+//
+// Triangles are hit from either side. The renderer culls back faces, but a
+// click on the inside of a room still means the wall, not whatever is behind it.
+func (m *Mesh) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	if m.hasLocalBounds {
+		bounds := AABB{Min: m.localBoundsMin, Max: m.localBoundsMax}
+		if _, ok := bounds.IntersectRay(origin, dir); !ok {
+			return 0, false
+		}
+	}
+
+	nearest := float32(math.Inf(1))
+	hit := false
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		t, ok := intersectTriangle(origin, dir,
+			m.Vertices[m.Indices[i]].Position,
+			m.Vertices[m.Indices[i+1]].Position,
+			m.Vertices[m.Indices[i+2]].Position)
+		if ok && t < nearest {
+			nearest, hit = t, true
+		}
+	}
+	return nearest, hit
+}
+
+// IntersectRay returns how far along a world-space ray it first meets the
+// model placed by modelMat.
+//
+// The ray is taken into model space rather than every vertex out of it. An
+// affine map keeps the parameter along a line, so the t found against the
+// untransformed mesh is the t along the world ray: with a unit dir, the
+// distance from origin.
+func (m *Model) IntersectRay(modelMat mgl32.Mat4, origin, dir mgl32.Vec3) (float32, bool) {
+	inverse := modelMat.Inv()
+	localOrigin := mgl32.TransformCoordinate(origin, inverse)
+	localDir := mgl32.TransformNormal(dir, inverse)
+
+	if m.hasLocalBounds {
+		if _, ok := m.localBounds.IntersectRay(localOrigin, localDir); !ok {
+			return 0, false
+		}
+	}
+
+	nearest := float32(math.Inf(1))
+	hit := false
+	for i := range m.Meshes {
+		if t, ok := m.Meshes[i].IntersectRay(localOrigin, localDir); ok && t < nearest {
+			nearest, hit = t, true
+		}
+	}
+	return nearest, hit
+}
+
+// intersectTriangle is Möller–Trumbore: solve for the hit point's barycentric
+// coordinates directly, without building the triangle's plane first.
+func intersectTriangle(origin, dir, v0, v1, v2 mgl32.Vec3) (float32, bool) {
+	const epsilon = 1e-7
+
+	edge1 := v1.Sub(v0)
+	edge2 := v2.Sub(v0)
+
+	p := dir.Cross(edge2)
+	determinant := edge1.Dot(p)
+	if determinant > -epsilon && determinant < epsilon {
+		// The ray runs along the triangle's plane.
+		return 0, false
+	}
+	inverse := 1 / determinant
+
+	s := origin.Sub(v0)
+	u := s.Dot(p) * inverse
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+
+	q := s.Cross(edge1)
+	v := dir.Dot(q) * inverse
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+
+	t := edge2.Dot(q) * inverse
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
@@ -0,0 +1,107 @@
+package object
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// quad is a unit square in the XY plane at z = 0, as two triangles, with its
+// metadata computed the way CreateMesh would minus the GL upload.
+func quad() Mesh {
+	m := Mesh{
+		Vertices: []Vertex{
+			{Position: mgl32.Vec3{-0.5, -0.5, 0}},
+			{Position: mgl32.Vec3{0.5, -0.5, 0}},
+			{Position: mgl32.Vec3{0.5, 0.5, 0}},
+			{Position: mgl32.Vec3{-0.5, 0.5, 0}},
+		},
+		Indices: []uint32{0, 1, 2, 0, 2, 3},
+	}
+	m.computeMetadata()
+	return m
+}
+
+func close(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestAABBIntersectRay(t *testing.T) {
+	box := AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}}
+
+	cases := []struct {
+		name   string
+		origin mgl32.Vec3
+		dir    mgl32.Vec3
+		want   float32
+		hit    bool
+	}{
+		{"head on", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}, 4, true},
+		{"diagonal", mgl32.Vec3{3, 3, 0}, mgl32.Vec3{-1, -1, 0}.Normalize(), 2 * math.Sqrt2, true},
+		{"from inside", mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}, 0, true},
+		{"pointing away", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, 1}, 0, false},
+		{"parallel outside", mgl32.Vec3{0, 2, 5}, mgl32.Vec3{0, 0, -1}, 0, false},
+		{"passes beside", mgl32.Vec3{3, 0, 5}, mgl32.Vec3{0, 0, -1}, 0, false},
+	}
+	for _, c := range cases {
+		got, hit := box.IntersectRay(c.origin, c.dir)
+		if hit != c.hit || (hit && !close(got, c.want)) {
+			t.Errorf("%s: got %v, %v; want %v, %v", c.name, got, hit, c.want, c.hit)
+		}
+	}
+}
+
+func TestMeshIntersectRayHitsTriangles(t *testing.T) {
+	m := quad()
+
+	if got, ok := m.IntersectRay(mgl32.Vec3{0.2, 0.3, 3}, mgl32.Vec3{0, 0, -1}); !ok || !close(got, 3) {
+		t.Errorf("ray onto the quad: %v, %v", got, ok)
+	}
+	// From behind counts too.
+	if got, ok := m.IntersectRay(mgl32.Vec3{0, 0, -2}, mgl32.Vec3{0, 0, 1}); !ok || !close(got, 2) {
+		t.Errorf("ray onto the back of the quad: %v, %v", got, ok)
+	}
+	if _, ok := m.IntersectRay(mgl32.Vec3{0.7, 0, 3}, mgl32.Vec3{0, 0, -1}); ok {
+		t.Error("ray beside the quad hit it")
+	}
+}
+
+// TestMeshIntersectRayNeedsATriangle is the case bounds alone get wrong: the
+// ray passes through the box around a triangle but not the triangle.
+func TestMeshIntersectRayNeedsATriangle(t *testing.T) {
+	m := Mesh{
+		Vertices: []Vertex{
+			{Position: mgl32.Vec3{0, 0, 0}},
+			{Position: mgl32.Vec3{1, 0, 0}},
+			{Position: mgl32.Vec3{0, 1, 0}},
+		},
+		Indices: []uint32{0, 1, 2},
+	}
+	m.computeMetadata()
+
+	if _, ok := m.IntersectRay(mgl32.Vec3{0.9, 0.9, 1}, mgl32.Vec3{0, 0, -1}); ok {
+		t.Error("ray through the empty corner of the bounds hit the triangle")
+	}
+	if _, ok := m.IntersectRay(mgl32.Vec3{0.2, 0.2, 1}, mgl32.Vec3{0, 0, -1}); !ok {
+		t.Error("ray through the triangle missed")
+	}
+}
+
+func TestModelIntersectRayInWorldUnits(t *testing.T) {
+	m := &Model{Meshes: []Mesh{quad()}}
+	m.computeLocalBounds()
+
+	// Moved back along Z and scaled up: the distance must come out in world
+	// units, not model units.
+	modelMat := mgl32.Translate3D(0, 0, -4).Mul4(mgl32.Scale3D(10, 10, 10))
+
+	got, ok := m.IntersectRay(modelMat, mgl32.Vec3{3, 3, 6}, mgl32.Vec3{0, 0, -1})
+	if !ok || !close(got, 10) {
+		t.Errorf("IntersectRay = %v, %v; want 10", got, ok)
+	}
+	// Inside the scaled quad, outside the unscaled one.
+	if _, ok := m.IntersectRay(modelMat, mgl32.Vec3{6, 0, 6}, mgl32.Vec3{0, 0, -1}); ok {
+		t.Error("ray beside the scaled quad hit it")
+	}
+}
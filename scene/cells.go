@@ -0,0 +1,59 @@
+package scene
+
+import (
+	"3d-engine/utils"
+)
+
+// A scene too big to hold in memory at once declares cells instead of, or as
+// well as, objects:
+//
+//	cells:
+//	  - path: scenes/island/cell_0_0.yml
+//	    bounds: {min: [0, -20, 0], max: [128, 60, 128]}
+//	  - path: scenes/island/cell_1_0.yml
+//	    bounds: {min: [128, -20, 0], max: [256, 60, 128]}
+//
+// Each cell is a scene file like an included layer, but nothing loads it with
+// the scene: the engine streams it in when the camera comes within range of
+// its bounds and out again when the camera moves away.
+
+// Cell is one streamed piece of a scene: a scene file and the box its objects
+// occupy. The box is what the camera's distance is measured to, so it should
+// cover everything in the file; an object sticking out of it may pop in late.
+type Cell struct {
+	Path   string `yaml:"path"`
+	Bounds Bounds `yaml:"bounds"`
+}
+
+// Bounds is an axis-aligned box in world space.
+type Bounds struct {
+	Min [3]float32 `yaml:"min"`
+	Max [3]float32 `yaml:"max"`
+}
+
+// checkCells rejects cells with no path or an inside-out box, and cells that
+// repeat one another, an include, or the scene itself: a layer is known by
+// its path, and two owners of one path would unload each other's objects.
+func checkCells(s *Scene, path string) error {
+	seen := map[string]bool{LayerKey(path): true}
+	for _, include := range s.Includes {
+		seen[LayerKey(include.Path)] = true
+	}
+
+	for _, cell := range s.Cells {
+		if cell.Path == "" {
+			return utils.Logger().Errorf("scene %s: a cell has no path", path)
+		}
+		for axis := range 3 {
+			if cell.Bounds.Min[axis] > cell.Bounds.Max[axis] {
+				return utils.Logger().Errorf("scene %s: cell %s has bounds with min above max", path, cell.Path)
+			}
+		}
+		key := LayerKey(cell.Path)
+		if seen[key] {
+			return utils.Logger().Errorf("scene %s: cell %s is already the scene, an include or another cell", path, cell.Path)
+		}
+		seen[key] = true
+	}
+	return nil
+}
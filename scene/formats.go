@@ -0,0 +1,369 @@
+package scene
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"3d-engine/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A scene file can be written three ways, all holding the same Scene:
+//
+//   - YAML, the format people write and the editor saves, with comments.
+//   - JSON, for tools that produce scenes and would rather not emit YAML. It
+//     uses the same keys and the same version numbers as YAML.
+//   - Binary, for shipping builds: a compact encoding of the structs that
+//     decodes without parsing any text, so a big scene loads in a fraction
+//     of the time. It is a build artefact, converted from a YAML or JSON
+//     source, and is only ever read by the build that wrote its version.
+//
+// Load and Save pick the format from the file's extension; see FormatOf.
+// Decode and Encode are the same two without the file, for a scene that
+// arrives or leaves some other way, such as over the engine's RPC server.
+// Prefab files are YAML only, for now: they are small, and written by hand.
+
+// Format is one of the ways a scene file can be written.
+type Format int
+
+const (
+	FormatYAML Format = iota
+	FormatJSON
+	FormatBinary
+)
+
+// BinaryExtension is the extension that marks a binary scene file.
+const BinaryExtension = ".scb"
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "JSON"
+	case FormatBinary:
+		return "binary"
+	}
+	return "YAML"
+}
+
+// FormatOf picks a scene file's format from its extension: .json is JSON,
+// .scb is binary, and anything else — .yml, .yaml, or none — is YAML, which
+// is what every scene was before there was a choice.
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case BinaryExtension:
+		return FormatBinary
+	}
+	return FormatYAML
+}
+
+// Decode reads a scene document in the given format, with every check Load
+// makes; Load is this and a file read. name is what errors call the document,
+// and a file's path is the obvious one to give.
+func Decode(name string, format Format, content []byte) (*Scene, error) {
+	scene, err := decodeScene(name, format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkIncludes(scene.Includes, name); err != nil {
+		return nil, err
+	}
+	if err := checkCells(scene, name); err != nil {
+		return nil, err
+	}
+	if err := checkObjects(scene.Objects, name); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+// Encode renders a scene in the given format, in the current version, as Save
+// would write it; and like Save it refuses a scene that would not load back.
+func Encode(name string, format Format, s *Scene) ([]byte, error) {
+	if s == nil {
+		return nil, utils.Logger().Errorf("cannot save a nil scene to %s", name)
+	}
+
+	// The version is the writer's, not whatever the struct happened to carry:
+	// this build only knows how to emit the current format.
+	saved := *s
+	saved.Version = CurrentVersion
+
+	if err := validate(&saved, name); err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodeScene(format, &saved)
+	if err != nil {
+		return nil, utils.Logger().Errorf("failed to encode scene %s as %s: %s", name, format, err)
+	}
+	return encoded, nil
+}
+
+// decodeScene turns a scene document into a Scene.
+//
+// JSON is read by the YAML parser. Every JSON document is a YAML one, so this
+// is not a shortcut so much as the one path: a JSON scene goes through the
+// same migrations and the same field defaults as a YAML one, and its errors
+// name lines the same way.
+func decodeScene(path string, format Format, content []byte) (*Scene, error) {
+	if format == FormatBinary {
+		return decodeBinary(path, content)
+	}
+
+	document, from, err := parseMigrated(path, content)
+	if err != nil {
+		return nil, err
+	}
+	noteUpgraded(path, from)
+	if format == FormatJSON {
+		plainStyles(document)
+	}
+
+	scene := &Scene{}
+	if err := document.Decode(scene); err != nil {
+		return nil, utils.Logger().Errorf("failed to parse %s scene %s: %s", format, path, err)
+	}
+	return scene, nil
+}
+
+// encodeScene renders a scene in the given format.
+func encodeScene(format Format, scene *Scene) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return encodeJSON(scene)
+	case FormatBinary:
+		return encodeBinary(scene)
+	}
+	return encode(scene)
+}
+
+// plainStyles forgets how a JSON document quoted and bracketed things. JSON
+// has no choice in the matter, so it says nothing about how the props should
+// look if the scene is later saved as YAML.
+func plainStyles(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plainStyles(child)
+	}
+}
+
+// --- JSON ---------------------------------------------------------------------
+
+// encodeJSON writes the scene as JSON. Like the YAML encoder it goes through a
+// node tree, so the keys, their order and what is left out are exactly the
+// YAML ones, and a scene converted between the two reads the same; and like
+// it, vectors go on one line.
+func encodeJSON(document any) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(document); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, &root, ""); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeJSON(out *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			out.WriteString("null")
+			return nil
+		}
+		return writeJSON(out, node.Content[0], indent)
+
+	case yaml.AliasNode:
+		return writeJSON(out, node.Alias, indent)
+
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			out.WriteString("{}")
+			return nil
+		}
+		out.WriteString("{")
+		inner := indent + "  "
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n" + inner)
+			key, _ := json.Marshal(node.Content[i].Value)
+			out.Write(key)
+			out.WriteString(": ")
+			if err := writeJSON(out, node.Content[i+1], inner); err != nil {
+				return err
+			}
+		}
+		out.WriteString("\n" + indent + "}")
+		return nil
+
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			out.WriteString("[]")
+			return nil
+		}
+		flat := true
+		for _, child := range node.Content {
+			if child.Kind != yaml.ScalarNode {
+				flat = false
+				break
+			}
+		}
+		out.WriteString("[")
+		inner := indent + "  "
+		for i, child := range node.Content {
+			switch {
+			case i > 0 && flat:
+				out.WriteString(", ")
+			case i > 0:
+				out.WriteString(",")
+			}
+			if !flat {
+				out.WriteString("\n" + inner)
+			}
+			if err := writeJSON(out, child, inner); err != nil {
+				return err
+			}
+		}
+		if !flat {
+			out.WriteString("\n" + indent)
+		}
+		out.WriteString("]")
+		return nil
+	}
+
+	// A scalar is decoded the way YAML reads it and re-encoded as JSON, which
+	// settles hex integers, quoted numbers and the like without a case for each.
+	// What JSON has no way to say, such as an infinite float, is an error.
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return utils.Logger().Errorf("line %d: %s", node.Line, err)
+	}
+	out.Write(encoded)
+	return nil
+}
+
+// --- binary -------------------------------------------------------------------
+
+// binaryMagic starts every binary scene file. Its last byte is the layout of
+// what follows, bumped if the encoding itself changes — which is a separate
+// matter from the scene version inside.
+const binaryMagic = "3DSCENE\x01"
+
+// encodeBinary writes the magic and then the scene as a gob stream. Gob is
+// what makes the decode fast: it is a straight copy into the structs, with
+// the type description written once at the front.
+//
+// Component props are kept as node trees, since they stay undecoded until the
+// engine knows their types, but stripped to what they mean — kind, tag, value
+// — leaving behind the positions and comments a YAML parse hung on them.
+func encodeBinary(scene *Scene) ([]byte, error) {
+	stripped := *scene
+	stripped.Objects = bareObjects(scene.Objects)
+
+	var out bytes.Buffer
+	out.WriteString(binaryMagic)
+	if err := gob.NewEncoder(&out).Encode(&stripped); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func decodeBinary(path string, content []byte) (*Scene, error) {
+	if !bytes.HasPrefix(content, []byte(binaryMagic)) {
+		return nil, utils.Logger().Errorf("%s is not a binary scene this build can read", path)
+	}
+
+	scene := &Scene{}
+	if err := gob.NewDecoder(bytes.NewReader(content[len(binaryMagic):])).Decode(scene); err != nil {
+		return nil, utils.Logger().Errorf("failed to decode binary scene %s: %s", path, err)
+	}
+
+	// There is no document to migrate, only structs in this build's shape, so
+	// an old binary file cannot be upgraded; it is regenerated from its source.
+	if scene.Version != CurrentVersion {
+		return nil, utils.Logger().Errorf(
+			"binary scene %s is version %d; this build reads version %d. Convert it again from its YAML or JSON source",
+			path, scene.Version, CurrentVersion)
+	}
+	return scene, nil
+}
+
+// bareObjects copies an object tree with its props reduced to bareNode.
+func bareObjects(objects []Object) []Object {
+	if objects == nil {
+		return nil
+	}
+	bare := make([]Object, len(objects))
+	for i, object := range objects {
+		object.Components = bareComponents(object.Components)
+		object.Children = bareObjects(object.Children)
+		if object.Overrides != nil {
+			overrides := make([]Override, len(object.Overrides))
+			for j, override := range object.Overrides {
+				if override.Components != nil {
+					components := make([]ComponentOverride, len(override.Components))
+					for k, component := range override.Components {
+						component.Props = bareNode(component.Props)
+						components[k] = component
+					}
+					override.Components = components
+				}
+				override.Children = bareObjects(override.Children)
+				overrides[j] = override
+			}
+			object.Overrides = overrides
+		}
+		bare[i] = object
+	}
+	return bare
+}
+
+func bareComponents(components []ComponentSpec) []ComponentSpec {
+	if components == nil {
+		return nil
+	}
+	bare := make([]ComponentSpec, len(components))
+	for i, component := range components {
+		component.Props = bareNode(component.Props)
+		bare[i] = component
+	}
+	return bare
+}
+
+// bareNode copies a node with only its kind, tag, value and children, aliases
+// replaced by what they point at. The tag is kept only where the value alone
+// would not bring it back — the quoted "true" that is a string — since a tag
+// on every scalar is most of the size of a props block.
+func bareNode(node yaml.Node) yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return bareNode(*node.Alias)
+	}
+	if node.Kind == 0 {
+		return yaml.Node{}
+	}
+	bare := yaml.Node{Kind: node.Kind, Value: node.Value}
+	if implied := (yaml.Node{Kind: node.Kind, Value: node.Value}); implied.ShortTag() != node.ShortTag() {
+		bare.Tag = node.ShortTag()
+	}
+	for _, child := range node.Content {
+		copied := bareNode(*child)
+		bare.Content = append(bare.Content, &copied)
+	}
+	return bare
+}
@@ -0,0 +1,204 @@
+package scene
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// formatsScene uses every part of the format: camera, includes, cells, nested
+// children, a prefab instance with overrides, and props of every scalar kind,
+// including a string that looks like a bool and would come back as one if a
+// format dropped its tag.
+const formatsScene = `version: 2
+skybox: assets/skybox/day
+camera:
+  position: [1.5, 2, -3]
+  yaw: -120
+  pitch: -15
+includes:
+  - path: scenes/town/buildings.yml
+  - path: scenes/town/interiors.yml
+    stream: true
+cells:
+  - path: scenes/town/north.yml
+    bounds:
+      min: [-100, -10, 50]
+      max: [100, 40, 250]
+objects:
+  - name: crate
+    model: assets/models/crate.obj
+    transform:
+      position: [1, 2, 3]
+      rotation: [0, 1, 0, 45]
+      scale: [0.5, 0.5, 0.5]
+    body:
+      static: false
+    material:
+      color: [0.9, 0.1, 0.35]
+    components:
+      - type: PointLight
+        props:
+          diffuse: [1, 0, 0]
+          linear: 0.14
+          label: "true"
+          count: 3
+          nested:
+            on: false
+            weights: [0.25, 0.75]
+      - type: Marker
+    children:
+      - name: lid
+        model: assets/models/lid.obj
+  - name: hall-lamp
+    prefab: prefabs/lamp.yml
+    transform:
+      position: [4, 0, -2]
+    overrides:
+      - target: shade/bulb
+        body:
+          static: true
+        components:
+          - type: PointLight
+            index: 1
+            props: {quadratic: 0.5}
+        children:
+          - name: glow
+            components:
+              - type: PointLight
+`
+
+// canonical renders a scene as YAML with every node in its plain style, so
+// two scenes can be compared as text however they were read. Style is the one
+// thing the formats do not agree on: JSON has none, binary keeps none, and
+// whether a props block was written {on: one line} is not part of the scene.
+func canonical(t *testing.T, s *Scene) string {
+	t.Helper()
+
+	var root yaml.Node
+	if err := root.Encode(s); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	plainStyles(&root)
+	encoded, err := yaml.Marshal(&root)
+	if err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	return string(encoded)
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]Format{
+		"scene.yml":         FormatYAML,
+		"scene.yaml":        FormatYAML,
+		"scene":             FormatYAML,
+		"exports/town.json": FormatJSON,
+		"TOWN.JSON":         FormatJSON,
+		"build/town.scb":    FormatBinary,
+	} {
+		if got := FormatOf(path); got != want {
+			t.Errorf("FormatOf(%q) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+// TestRoundTripAcrossFormats saves the scene in each format, converts it to
+// each format, and checks that what comes back is the scene it started as.
+func TestRoundTripAcrossFormats(t *testing.T) {
+	original, err := Load(writeScene(t, formatsScene))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := canonical(t, original)
+
+	extensions := []string{".yml", ".json", BinaryExtension}
+	for _, from := range extensions {
+		for _, to := range extensions {
+			t.Run(from+"->"+to, func(t *testing.T) {
+				directory := t.TempDir()
+				first := filepath.Join(directory, "first"+from)
+				second := filepath.Join(directory, "second"+to)
+
+				if err := Save(first, original); err != nil {
+					t.Fatal(err)
+				}
+				read, err := Load(first)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := Save(second, read); err != nil {
+					t.Fatal(err)
+				}
+				converted, err := Load(second)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := canonical(t, converted); got != want {
+					t.Errorf("after %s and %s the scene reads:\n%s\nwant:\n%s", from, to, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestJSONUsesSceneKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := os.WriteFile(path, []byte(`{
+  "version": 2,
+  "objects": [
+    {"name": "crate", "model": "crate.obj", "transform": {"position": [1, 2, 3]},
+     "components": [{"type": "PointLight", "props": {"linear": 0.14, "label": "on"}}]}
+  ]
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform := loaded.Objects[0].ResolveTransform()
+	if transform.Position != [3]float32{1, 2, 3} || transform.Scale != [3]float32{1, 1, 1} {
+		t.Errorf("transform %+v; omitted fields should take the YAML defaults", transform)
+	}
+
+	// Saved as YAML, the props look hand-written rather than like JSON.
+	yamlPath := filepath.Join(t.TempDir(), "scene.yml")
+	if err := Save(yamlPath, loaded); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := os.ReadFile(yamlPath)
+	if !strings.Contains(string(written), "linear: 0.14") || strings.Contains(string(written), `"`) {
+		t.Errorf("props kept their JSON styling:\n%s", written)
+	}
+}
+
+func TestBinaryRefusesOtherFiles(t *testing.T) {
+	directory := t.TempDir()
+
+	yamlAsBinary := filepath.Join(directory, "scene.scb")
+	if err := os.WriteFile(yamlAsBinary, []byte(formatsScene), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(yamlAsBinary); err == nil {
+		t.Error("loaded YAML from a binary path")
+	}
+
+	// An older binary file cannot be migrated, so it is refused rather than
+	// read as if it were current.
+	old := filepath.Join(directory, "old.scb")
+	encoded, err := encodeBinary(&Scene{Version: 1, Objects: []Object{{Name: "a", Model: "a.obj"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, encoded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(old); err == nil || !strings.Contains(err.Error(), "Convert it again") {
+		t.Errorf("loading an old binary scene: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package scene
+
+import (
+	"path/filepath"
+
+	"3d-engine/utils"
+)
+
+// A scene can pull in other scene files as layers:
+//
+//	includes:
+//	  - path: scenes/town/buildings.yml
+//	  - path: scenes/town/interiors.yml
+//	    stream: true
+//
+// An included file is an ordinary scene file. Its objects are loaded alongside
+// the including scene's, but owned by a layer of their own, so the engine can
+// unload them again without touching the rest of the world. Its skybox and
+// camera are ignored: those belong to the scene that includes it.
+//
+// Paths are resolved like model paths, against the working directory, not
+// against the including file.
+
+// Include names a scene file to load as a layer. A streamed include is not
+// loaded with the scene; it is listed so that something — the editor, a
+// script, the streaming system — can load and unload it on demand.
+type Include struct {
+	Path   string `yaml:"path"`
+	Stream bool   `yaml:"stream,omitempty"`
+}
+
+// LayerKey is the name a layer is known by: its path, cleaned, so that
+// "./a.yml" and "a.yml" are the same layer.
+func LayerKey(path string) string {
+	return filepath.Clean(path)
+}
+
+// checkIncludes rejects includes that are empty or repeated. An included file
+// including others is refused when it is loaded as a layer, not here, since
+// only the loader knows which file is the top.
+func checkIncludes(includes []Include, path string) error {
+	seen := map[string]bool{}
+	for _, include := range includes {
+		if include.Path == "" {
+			return utils.Logger().Errorf("scene %s: an include has no path", path)
+		}
+		key := LayerKey(include.Path)
+		if key == LayerKey(path) {
+			return utils.Logger().Errorf("scene %s includes itself", path)
+		}
+		if seen[key] {
+			return utils.Logger().Errorf("scene %s includes %s twice", path, include.Path)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// LoadLayer reads a scene file to be loaded as a layer. Layers do not nest: a
+// file that includes others, or streams cells, is refused, because unloading
+// it would have to decide whether those go too, and either answer surprises
+// someone.
+func LoadLayer(path string) (*Scene, error) {
+	layer, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(layer.Includes) > 0 || len(layer.Cells) > 0 {
+		return nil, utils.Logger().Errorf("scene %s has includes or cells of its own and cannot be loaded as a layer", path)
+	}
+	return layer, nil
+}
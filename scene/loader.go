@@ -13,14 +13,22 @@ import (
 // transform spread across originX/scaleY/rotationAngle/... fields, and
 // `isStatic`. It had no room for per-component properties, so version 2
 // replaced it with a nested transform plus `body` and `components` blocks.
-// Version 1 is no longer accepted; a file must declare its version.
+// Older files are upgraded on load by the chain in migrate.go, which is also
+// where a version 3 would start.
 const CurrentVersion = 2
 
 type Scene struct {
 	Version int         `yaml:"version"`
 	Skybox  string      `yaml:"skybox,omitempty"`
 	Camera  *CameraSpec `yaml:"camera,omitempty"`
-	Objects []Object    `yaml:"objects"`
+
+	// Includes are other scene files loaded as layers; see include.go.
+	Includes []Include `yaml:"includes,omitempty"`
+
+	// Cells are scene files streamed in and out by distance; see cells.go.
+	Cells []Cell `yaml:"cells,omitempty"`
+
+	Objects []Object `yaml:"objects"`
 }
 
 // CameraSpec is where the camera starts, and where it returns to when the scene
@@ -149,12 +157,24 @@ type Object struct {
 	// An object with children needs neither a model nor components — a bare
 	// grouping node that exists to be a pivot is legitimate.
 	Children []Object `yaml:"children,omitempty"`
+
+	// Prefab makes the object an instance of the tree in a prefab file; see
+	// prefab.go. An instance has a name, a transform placing the prefab's root,
+	// Overrides for anything it changes, and Children added under the root. It
+	// has no model, body, material or components of its own: those come from
+	// the prefab and are changed through Overrides.
+	Prefab    string     `yaml:"prefab,omitempty"`
+	Overrides []Override `yaml:"overrides,omitempty"`
+
+	// From is the prefab an expanded tree came from, set by Expand on the
+	// instance's root. It is never read from or written to a file.
+	From string `yaml:"-"`
 }
 
 // DoesNothing reports whether the object would have no effect at all: nothing to
 // draw, no behaviour, and nothing hanging off it.
 func (o *Object) DoesNothing() bool {
-	return o.Model == "" && len(o.Components) == 0 && len(o.Children) == 0
+	return o.Prefab == "" && o.Model == "" && len(o.Components) == 0 && len(o.Children) == 0
 }
 
 // ResolveTransform returns the placement, defaulting to the identity when the
@@ -175,6 +195,8 @@ func (o *Object) IsStatic() bool {
 	return o.Body != nil && o.Body.Static
 }
 
+// Load reads a scene file in whichever format its extension names; see
+// formats.go.
 func Load(path string) (*Scene, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, utils.Logger().Errorf("scene file does not exist: %s", path)
@@ -185,22 +207,7 @@ func Load(path string) (*Scene, error) {
 		return nil, utils.Logger().Errorf("failed to read scene file: %s", err)
 	}
 
-	scene := &Scene{}
-	if err := yaml.Unmarshal(fileContent, scene); err != nil {
-		return nil, utils.Logger().Errorf("failed to parse YAML scene: %s", err)
-	}
-
-	if scene.Version != CurrentVersion {
-		return nil, utils.Logger().Errorf(
-			"scene %s declares version %d; this build reads version %d",
-			path, scene.Version, CurrentVersion)
-	}
-
-	if err := checkObjects(scene.Objects, path); err != nil {
-		return nil, err
-	}
-
-	return scene, nil
+	return Decode(path, FormatOf(path), fileContent)
 }
 
 // checkObjects rejects objects that would do nothing, at every depth.
@@ -213,9 +220,17 @@ func checkObjects(objects []Object, path string) error {
 				"scene %s: object %q has no model, no components and no children, so it would do nothing",
 				path, obj.Name)
 		}
+		if err := obj.checkInstance(); err != nil {
+			return utils.Logger().Errorf("scene %s: %s", path, err)
+		}
 		if err := checkObjects(obj.Children, path); err != nil {
 			return err
 		}
+		for j := range obj.Overrides {
+			if err := checkObjects(obj.Overrides[j].Children, path); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -164,3 +164,23 @@ objects:
 		t.Fatal("a nested object that does nothing should be rejected")
 	}
 }
+
+func TestIncludesAreChecked(t *testing.T) {
+	cases := map[string]string{
+		"no path":  "version: 2\nincludes: [{stream: true}]\nobjects: []\n",
+		"repeated": "version: 2\nincludes: [{path: a.yml}, {path: ./a.yml}]\nobjects: []\n",
+	}
+	for name, body := range cases {
+		if _, err := Load(writeScene(t, body)); err == nil {
+			t.Errorf("%s: loaded without complaint", name)
+		}
+	}
+
+	path := writeScene(t, "version: 2\nobjects: []\n")
+	if err := os.WriteFile(path, []byte("version: 2\nincludes: [{path: "+path+"}]\nobjects: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("Load = %v, want a complaint about including itself", err)
+	}
+}
@@ -0,0 +1,363 @@
+package scene
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"3d-engine/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migrations upgrade an old document to the current format one version at a
+// time, on the parsed YAML rather than on the structs: the structs only know
+// the current format, and going through the node tree is what lets a field
+// this build has never heard of — a tool's annotation, a property for a
+// component not registered here — and the file's comments come through the
+// upgrade untouched.
+//
+// Adding a format version means bumping CurrentVersion and appending the
+// migration from the old one. Old migrations are never edited: a file written
+// by any earlier build has to keep upgrading exactly as it did.
+
+// Migration upgrades a document from version From to From+1.
+type Migration struct {
+	From    int
+	Summary string
+	Apply   func(document *yaml.Node) error
+}
+
+// Migrations is the chain, in order. Each entry's From is one more than the
+// last's, ending at CurrentVersion-1.
+var Migrations = []Migration{
+	{From: 1, Summary: "nested transform, model and body blocks", Apply: migrateV1},
+}
+
+// Migrate upgrades a parsed document in place to CurrentVersion and returns
+// the version it started at. A document with no version key is version 1,
+// which predates the key. One newer than this build is refused; guessing at a
+// format from the future would be worse.
+func Migrate(document *yaml.Node) (int, error) {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return 0, fmt.Errorf("expected a mapping at the top of the document")
+	}
+
+	from := 1
+	if node := mappingValue(root, "version"); node != nil {
+		version, err := strconv.Atoi(node.Value)
+		if err != nil {
+			return 0, fmt.Errorf("version %q is not a number", node.Value)
+		}
+		from = version
+	}
+	if from > CurrentVersion {
+		return 0, fmt.Errorf("version %d is newer than this build, which reads up to version %d", from, CurrentVersion)
+	}
+	if from < 1 {
+		return 0, fmt.Errorf("version %d does not exist", from)
+	}
+
+	for version := from; version < CurrentVersion; version++ {
+		migration := Migrations[version-1]
+		if err := migration.Apply(root); err != nil {
+			return 0, fmt.Errorf("upgrading from version %d: %w", version, err)
+		}
+		setMappingValue(root, "version", intNode(version+1))
+	}
+	return from, nil
+}
+
+// parseMigrated parses a scene or prefab file and upgrades it, returning the
+// document and the version it was written in.
+func parseMigrated(path string, content []byte) (*yaml.Node, int, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, 0, utils.Logger().Errorf("failed to parse %s: %s", path, err)
+	}
+	if document.Kind == 0 {
+		return nil, 0, utils.Logger().Errorf("%s is empty", path)
+	}
+
+	from, err := Migrate(&document)
+	if err != nil {
+		return nil, 0, utils.Logger().Errorf("%s: %s", path, err)
+	}
+	return &document, from, nil
+}
+
+// noteUpgraded tells the user a file loaded only because it was upgraded in
+// memory, and how to make that permanent.
+func noteUpgraded(path string, from int) {
+	if from != CurrentVersion {
+		utils.Logger().Printf("%s is version %d; upgraded it to version %d in memory. Run migrate to rewrite the file.",
+			path, from, CurrentVersion)
+	}
+}
+
+// MigrateFile rewrites a scene or prefab file in the current version, and in
+// the format it was already in, first copying the original to a backup next to
+// it, named for the version it was — scene.yml.v1.bak — unless backup is false.
+// It returns the version the file was in; a file already current is left
+// alone, with no backup. Binary files are refused; see decodeBinary.
+//
+// An existing backup is never overwritten: it may be the only copy of a file
+// an earlier migration rewrote.
+func MigrateFile(path string, backup bool) (int, error) {
+	if FormatOf(path) == FormatBinary {
+		return 0, utils.Logger().Errorf("%s is a binary scene; convert it again from its source instead", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, utils.Logger().Errorf("failed to read %s: %s", path, err)
+	}
+	document, from, err := parseMigrated(path, content)
+	if err != nil {
+		return 0, err
+	}
+	if from == CurrentVersion {
+		return from, nil
+	}
+
+	var out bytes.Buffer
+	if FormatOf(path) == FormatJSON {
+		if err := writeJSON(&out, document, ""); err != nil {
+			return 0, utils.Logger().Errorf("failed to encode %s: %s", path, err)
+		}
+		out.WriteByte('\n')
+	} else {
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return 0, utils.Logger().Errorf("failed to encode %s: %s", path, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return 0, utils.Logger().Errorf("failed to encode %s: %s", path, err)
+		}
+	}
+
+	if backup {
+		backupPath := BackupPath(path, from)
+		if _, err := os.Stat(backupPath); err == nil {
+			return 0, utils.Logger().Errorf("backup %s already exists; move it aside first", backupPath)
+		}
+		if err := os.WriteFile(backupPath, content, 0o644); err != nil {
+			return 0, utils.Logger().Errorf("failed to back up %s: %s", path, err)
+		}
+	}
+	if err := writeReplacing(path, out.Bytes()); err != nil {
+		return 0, err
+	}
+	return from, nil
+}
+
+// BackupPath is where MigrateFile keeps the original of a file it upgraded
+// from version from.
+func BackupPath(path string, from int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, from)
+}
+
+// --- version 1 -> 2 -----------------------------------------------------------
+
+// migrateV1 rewrites version 1's flat objects:
+//
+//	objects:
+//	  - name: crate
+//	    path: crate.obj
+//	    originX: 1
+//	    rotationY: 1
+//	    rotationAngle: 90
+//	    scaleX: 2
+//	    isStatic: true
+//
+// as version 2's nested blocks: path becomes model, the nine transform fields
+// become a transform block, and isStatic a body. Every version 1 object was in
+// the physics pass, static or not, so every one gets a body — leaving it off
+// would stop the non-static ones falling.
+func migrateV1(root *yaml.Node) error {
+	objects := mappingValue(root, "objects")
+	if objects == nil {
+		return nil
+	}
+	if objects.Kind != yaml.SequenceNode {
+		return fmt.Errorf("objects is not a list")
+	}
+
+	for _, object := range objects.Content {
+		if object.Kind != yaml.MappingNode {
+			return fmt.Errorf("an object is not a mapping")
+		}
+		if err := migrateV1Object(object); err != nil {
+			name := "?"
+			if node := mappingValue(object, "name"); node != nil {
+				name = node.Value
+			}
+			return fmt.Errorf("object %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func migrateV1Object(object *yaml.Node) error {
+	// A version 2 file that forgot its version key reads as version 1. Going
+	// on would replace its transform with an identity one, so stop instead.
+	for _, key := range []string{"model", "transform", "body"} {
+		if mappingKey(object, key) != nil {
+			return fmt.Errorf("has a version 2 %s block in a version 1 file; is the version key missing?", key)
+		}
+	}
+
+	if key := mappingKey(object, "path"); key != nil {
+		key.Value = "model"
+	}
+
+	// The new blocks go where the first of the fields they replace was, so a
+	// field after them in the file stays after them.
+	at := len(object.Content)
+	for i := 0; i+1 < len(object.Content); i += 2 {
+		if slices.Contains(v1Fields, object.Content[i].Value) {
+			at = i
+			break
+		}
+	}
+
+	position, err := takeFloats(object, []string{"originX", "originY", "originZ"}, []float64{0, 0, 0})
+	if err != nil {
+		return err
+	}
+	rotation, err := takeFloats(object,
+		[]string{"rotationX", "rotationY", "rotationZ", "rotationAngle"}, []float64{0, 1, 0, 0})
+	if err != nil {
+		return err
+	}
+	scale, err := takeFloats(object, []string{"scaleX", "scaleY", "scaleZ"}, []float64{1, 1, 1})
+	if err != nil {
+		return err
+	}
+
+	static := false
+	if node := takeMappingValue(object, "isStatic"); node != nil {
+		if err := node.Decode(&static); err != nil {
+			return fmt.Errorf("isStatic: %w", err)
+		}
+	}
+
+	transform := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(transform, "position", floatsNode(position))
+	setMappingValue(transform, "rotation", floatsNode(rotation))
+	setMappingValue(transform, "scale", floatsNode(scale))
+
+	body := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(body, "static", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(static)})
+
+	object.Content = slices.Insert(object.Content, at,
+		keyNode("transform"), transform,
+		keyNode("body"), body)
+	return nil
+}
+
+// v1Fields are the version 1 object fields migrateV1 folds into blocks.
+var v1Fields = []string{
+	"originX", "originY", "originZ",
+	"rotationX", "rotationY", "rotationZ", "rotationAngle",
+	"scaleX", "scaleY", "scaleZ",
+	"isStatic",
+}
+
+// takeFloats removes the named keys from a mapping and returns their values,
+// with defaults for the ones it lacks.
+func takeFloats(mapping *yaml.Node, keys []string, defaults []float64) ([]float64, error) {
+	values := append([]float64(nil), defaults...)
+	for i, key := range keys {
+		node := takeMappingValue(mapping, key)
+		if node == nil {
+			continue
+		}
+		if err := node.Decode(&values[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return values, nil
+}
+
+// --- node helpers -------------------------------------------------------------
+
+func mappingKey(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i]
+		}
+	}
+	return nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// takeMappingValue removes a key from a mapping, returning its value.
+func takeMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value := mapping.Content[i+1]
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return value
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces a key's value, or appends the key. A new version
+// key goes first, where a reader looks for it, taking over the comment at the
+// top of the file so that stays at the top.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	newKey := keyNode(key)
+	if key == "version" {
+		if len(mapping.Content) > 0 {
+			first := mapping.Content[0]
+			newKey.HeadComment, first.HeadComment = first.HeadComment, ""
+		}
+		mapping.Content = append([]*yaml.Node{newKey, value}, mapping.Content...)
+		return
+	}
+	mapping.Content = append(mapping.Content, newKey, value)
+}
+
+func keyNode(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+func intNode(value int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(value)}
+}
+
+// floatsNode is a vector the way the saver writes one: on one line, with a
+// decimal point on every number.
+func floatsNode(values []float64) *yaml.Node {
+	sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, value := range values {
+		text := strconv.FormatFloat(value, 'f', -1, 64)
+		if !bytes.ContainsAny([]byte(text), ".eE") {
+			text += ".0"
+		}
+		sequence.Content = append(sequence.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: text})
+	}
+	return sequence
+}
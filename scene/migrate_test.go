@@ -0,0 +1,186 @@
+package scene
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Each migration has a directory of fixtures under testdata/migrate, named for
+// the version it upgrades from: name.yml is a file as that version wrote it,
+// and name.want.yml is the file the chain turns it into, byte for byte. The
+// comparison is on the text, not the decoded values, because keeping the
+// file's comments, its key order and the fields this build does not know is
+// half of what a migration is for.
+
+// migrateFixture runs the chain over one fixture and returns the result as
+// MigrateFile would write it.
+func migrateFixture(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(&document); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		t.Fatal(err)
+	}
+	encoder.Close()
+	return out.String()
+}
+
+func TestMigrationFixtures(t *testing.T) {
+	for _, migration := range Migrations {
+		directory := filepath.Join("testdata", "migrate", fmt.Sprintf("v%d", migration.From))
+		inputs, err := filepath.Glob(filepath.Join(directory, "*.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fixtures := 0
+		for _, input := range inputs {
+			if strings.HasSuffix(input, ".want.yml") {
+				continue
+			}
+			fixtures++
+			t.Run(filepath.Base(input), func(t *testing.T) {
+				want, err := os.ReadFile(strings.TrimSuffix(input, ".yml") + ".want.yml")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := migrateFixture(t, input); got != string(want) {
+					t.Errorf("migrated:\n%s\nwant:\n%s", got, want)
+				}
+			})
+		}
+		if fixtures == 0 {
+			t.Errorf("the migration from version %d has no fixtures in %s", migration.From, directory)
+		}
+	}
+}
+
+// TestMigrationChainIsComplete guards the one rule of adding a version: there
+// is a step from every old version to the next.
+func TestMigrationChainIsComplete(t *testing.T) {
+	if len(Migrations) != CurrentVersion-1 {
+		t.Fatalf("%d migrations for current version %d", len(Migrations), CurrentVersion)
+	}
+	for i, migration := range Migrations {
+		if migration.From != i+1 {
+			t.Errorf("migration %d upgrades from version %d, want %d", i, migration.From, i+1)
+		}
+	}
+}
+
+func TestLoadUpgradesVersion1(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "migrate", "v1", "flat-objects.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(writeScene(t, string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Version != CurrentVersion || len(loaded.Objects) != 2 {
+		t.Fatalf("loaded version %d with %d objects", loaded.Version, len(loaded.Objects))
+	}
+	ground, crate := loaded.Objects[0], loaded.Objects[1]
+	if ground.Model != "assets/models/ground.obj" || !ground.IsStatic() {
+		t.Errorf("ground loaded as %+v", ground)
+	}
+	if ground.ResolveTransform().Scale != [3]float32{50, 1, 50} {
+		t.Errorf("ground scale %v", ground.ResolveTransform().Scale)
+	}
+	transform := crate.ResolveTransform()
+	if transform.Position != [3]float32{1.5, 4, -2} || transform.Rotation != [4]float32{0, 1, 0, 45} {
+		t.Errorf("crate transform %+v", transform)
+	}
+	if crate.Body == nil || crate.IsStatic() {
+		t.Errorf("crate body %+v; every version 1 object was a dynamic or static body", crate.Body)
+	}
+}
+
+func TestMigrateRefuses(t *testing.T) {
+	for name, body := range map[string]string{
+		"newer":              "version: 3\nobjects: []\n",
+		"not a number":       "version: two\nobjects: []\n",
+		"zero":               "version: 0\nobjects: []\n",
+		"version 2 untagged": "objects:\n  - name: a\n    model: a.obj\n    transform: {position: [1, 2, 3]}\n",
+		"bad field":          "version: 1\nobjects:\n  - name: a\n    path: a.obj\n    originX: left\n",
+	} {
+		if _, err := Load(writeScene(t, body)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "migrate", "v1", "flat-objects.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "migrate", "v1", "flat-objects.want.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeScene(t, string(content))
+
+	from, err := MigrateFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1 {
+		t.Errorf("MigrateFile reports version %d, want 1", from)
+	}
+	if rewritten, _ := os.ReadFile(path); string(rewritten) != string(want) {
+		t.Errorf("rewrote the file as:\n%s", rewritten)
+	}
+	if backup, err := os.ReadFile(BackupPath(path, 1)); err != nil || string(backup) != string(content) {
+		t.Errorf("backup %q, %v; want the original", backup, err)
+	}
+
+	// Current already: nothing to do and no second backup.
+	if from, err := MigrateFile(path, true); err != nil || from != CurrentVersion {
+		t.Errorf("migrating a current file: version %d, %v", from, err)
+	}
+	if _, err := os.Stat(BackupPath(path, CurrentVersion)); err == nil {
+		t.Error("a current file was backed up")
+	}
+}
+
+// TestMigrateFileKeepsOldBackup: a backup already there may be the only copy
+// of an original, so a second migration of a restored file must not replace
+// it, or touch the file.
+func TestMigrateFileKeepsOldBackup(t *testing.T) {
+	path := writeScene(t, "version: 1\nobjects:\n  - name: a\n    path: a.obj\n")
+	if err := os.WriteFile(BackupPath(path, 1), []byte("older"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateFile(path, true); err == nil {
+		t.Fatal("migrated over an existing backup")
+	}
+	if backup, _ := os.ReadFile(BackupPath(path, 1)); string(backup) != "older" {
+		t.Errorf("the existing backup became %q", backup)
+	}
+	if content, _ := os.ReadFile(path); !strings.HasPrefix(string(content), "version: 1") {
+		t.Errorf("the file was rewritten anyway:\n%s", content)
+	}
+}
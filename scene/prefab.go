@@ -0,0 +1,397 @@
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"3d-engine/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A prefab is one object tree kept in a file of its own, so that a lamp placed
+// fifty times is described once and fixed once. A scene refers to it by path:
+//
+//	- name: hall-lamp
+//	  prefab: prefabs/lamp.yml
+//	  transform:
+//	    position: [4, 0, -2]
+//	  overrides:
+//	    - target: shade/bulb
+//	      components:
+//	        - type: PointLight
+//	          props: {linear: 0.05}
+//
+// and gets the prefab's tree with the instance's name and placement on the root
+// and the overrides applied. Everything not overridden follows the prefab file,
+// so editing the file changes every instance the next time the scene loads.
+
+// Prefab is a prefab file: a version like a scene's, and one object tree.
+type Prefab struct {
+	Version int    `yaml:"version"`
+	Root    Object `yaml:"prefab"`
+}
+
+// Override changes one object inside a prefab instance. Target names it by
+// the path of child names from the instance's root — "shade/bulb" — with the
+// empty path meaning the root itself. Names containing a slash cannot be
+// targeted.
+//
+// Transform, Body and Material replace the prefab's. Components patch the
+// prefab's component of the same type, property by property, so a prefab
+// change to a property the instance never touched still comes through.
+// Children are added after the prefab's own.
+type Override struct {
+	Target     string              `yaml:"target,omitempty"`
+	Transform  *TransformSpec      `yaml:"transform,omitempty"`
+	Body       *BodySpec           `yaml:"body,omitempty"`
+	Material   *MaterialSpec       `yaml:"material,omitempty"`
+	Components []ComponentOverride `yaml:"components,omitempty"`
+	Children   []Object            `yaml:"children,omitempty"`
+}
+
+// ComponentOverride patches the properties of one of the target's components.
+// Index picks among several of the same type, counting from zero in the order
+// the prefab lists them.
+type ComponentOverride struct {
+	Type  string    `yaml:"type"`
+	Index int       `yaml:"index,omitempty"`
+	Props yaml.Node `yaml:"props"`
+}
+
+// IsEmpty reports whether the override changes nothing.
+func (o *Override) IsEmpty() bool {
+	return o.Transform == nil && o.Body == nil && o.Material == nil &&
+		len(o.Components) == 0 && len(o.Children) == 0
+}
+
+// checkInstance rejects a prefab instance that also describes an object of its
+// own. What would a model on an instance mean — replace the prefab's, or add
+// a second? Rather than pick, the file has to say it with an override.
+func (o *Object) checkInstance() error {
+	if o.Prefab == "" {
+		if len(o.Overrides) > 0 {
+			return fmt.Errorf("object %q has overrides but is not a prefab instance", o.Name)
+		}
+		return nil
+	}
+	if o.Model != "" || o.Body != nil || o.Material != nil || len(o.Components) > 0 {
+		return fmt.Errorf(
+			"object %q is an instance of %s and cannot also set a model, body, material or components; use overrides",
+			o.Name, o.Prefab)
+	}
+	return nil
+}
+
+// LoadPrefab reads a prefab file. Instances inside it are left as they are;
+// Expand resolves them.
+func LoadPrefab(path string) (*Prefab, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.Logger().Errorf("failed to read prefab %s: %s", path, err)
+	}
+
+	document, from, err := parseMigrated(path, content)
+	if err != nil {
+		return nil, err
+	}
+	noteUpgraded(path, from)
+	prefab := &Prefab{}
+	if err := document.Decode(prefab); err != nil {
+		return nil, utils.Logger().Errorf("failed to parse prefab %s: %s", path, err)
+	}
+	if err := checkObjects([]Object{prefab.Root}, path); err != nil {
+		return nil, err
+	}
+	return prefab, nil
+}
+
+// SavePrefab writes a prefab file, replacing any file at path the way Save
+// replaces a scene.
+func SavePrefab(path string, prefab *Prefab) error {
+	if prefab == nil {
+		return utils.Logger().Errorf("cannot save a nil prefab to %s", path)
+	}
+
+	saved := *prefab
+	saved.Version = CurrentVersion
+	if err := validateObjects([]Object{saved.Root}, path); err != nil {
+		return err
+	}
+
+	encoded, err := encode(&saved)
+	if err != nil {
+		return utils.Logger().Errorf("failed to encode prefab %s: %s", path, err)
+	}
+	return writeReplacing(path, encoded)
+}
+
+// PrefabLoader fetches a prefab by the path a scene names it with. LoadPrefab
+// is one; a caller expanding many instances of few prefabs can put a cache in
+// front of it.
+type PrefabLoader func(path string) (*Prefab, error)
+
+// ErrPrefabCycle is returned when a prefab contains an instance of itself,
+// directly or further down.
+var ErrPrefabCycle = errors.New("prefab contains itself")
+
+// Expand returns obj with every prefab instance in it, at any depth, replaced
+// by the tree it refers to. The result shares nothing with obj or with what
+// load returned, so either can be kept and the result edited.
+//
+// An expanded instance's root has From set to the prefab's path, which is how
+// a live entity remembers what it is an instance of.
+func Expand(obj Object, load PrefabLoader) (Object, error) {
+	return expand(&obj, load, nil)
+}
+
+func expand(obj *Object, load PrefabLoader, within []string) (Object, error) {
+	if obj.Prefab == "" {
+		expanded := copyObject(obj)
+		children, err := expandAll(obj.Children, load, within)
+		if err != nil {
+			return Object{}, err
+		}
+		expanded.Children = children
+		return expanded, nil
+	}
+
+	if slices.Contains(within, obj.Prefab) {
+		return Object{}, fmt.Errorf("%w: %s", ErrPrefabCycle, strings.Join(append(within, obj.Prefab), " -> "))
+	}
+	prefab, err := load(obj.Prefab)
+	if err != nil {
+		return Object{}, err
+	}
+
+	// The prefab's own tree first, which may be an instance of another prefab —
+	// a variant — or contain some.
+	tree, err := expand(&prefab.Root, load, append(within, obj.Prefab))
+	if err != nil {
+		return Object{}, err
+	}
+
+	if obj.Name != "" {
+		tree.Name = obj.Name
+	}
+	if obj.Transform != nil {
+		transform := *obj.Transform
+		tree.Transform = &transform
+	}
+
+	// Children added under an instance are scene objects like any other and
+	// may be instances themselves.
+	added, err := expandAll(obj.Children, load, within)
+	if err != nil {
+		return Object{}, err
+	}
+	tree.Children = append(tree.Children, added...)
+
+	for i := range obj.Overrides {
+		override := &obj.Overrides[i]
+		target, err := tree.find(override.Target)
+		if err != nil {
+			return Object{}, fmt.Errorf("instance %q of %s: %w", obj.Name, obj.Prefab, err)
+		}
+		if err := target.apply(override, load, within); err != nil {
+			return Object{}, fmt.Errorf("instance %q of %s, override of %q: %w",
+				obj.Name, obj.Prefab, override.Target, err)
+		}
+	}
+
+	tree.From = obj.Prefab
+	return tree, nil
+}
+
+func expandAll(objects []Object, load PrefabLoader, within []string) ([]Object, error) {
+	if objects == nil {
+		return nil, nil
+	}
+	expanded := make([]Object, 0, len(objects))
+	for i := range objects {
+		child, err := expand(&objects[i], load, within)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, child)
+	}
+	return expanded, nil
+}
+
+// find resolves an override's target path below o.
+func (o *Object) find(target string) (*Object, error) {
+	node := o
+	if target == "" {
+		return node, nil
+	}
+	for _, name := range strings.Split(target, "/") {
+		next := -1
+		for i := range node.Children {
+			if node.Children[i].Name == name {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("override target %q: %q has no child %q", target, node.Name, name)
+		}
+		node = &node.Children[next]
+	}
+	return node, nil
+}
+
+// apply makes an override's changes to the expanded object it targets.
+func (o *Object) apply(override *Override, load PrefabLoader, within []string) error {
+	if override.Transform != nil {
+		transform := *override.Transform
+		o.Transform = &transform
+	}
+	if override.Body != nil {
+		body := *override.Body
+		o.Body = &body
+	}
+	if override.Material != nil {
+		material := *override.Material
+		o.Material = &material
+	}
+
+	for _, patch := range override.Components {
+		seen := 0
+		found := false
+		for i := range o.Components {
+			if o.Components[i].Type != patch.Type {
+				continue
+			}
+			if seen == patch.Index {
+				o.Components[i].Props = mergeProps(o.Components[i].Props, patch.Props)
+				found = true
+				break
+			}
+			seen++
+		}
+		if !found {
+			return fmt.Errorf("%q has no %s component number %d to override", o.Name, patch.Type, patch.Index)
+		}
+	}
+
+	added, err := expandAll(override.Children, load, within)
+	if err != nil {
+		return err
+	}
+	o.Children = append(o.Children, added...)
+	return nil
+}
+
+// mergeProps lays patch's properties over base's. Both are normally mappings,
+// merged key by key; anything else is replaced whole.
+func mergeProps(base, patch yaml.Node) yaml.Node {
+	if base.Kind != yaml.MappingNode || patch.Kind != yaml.MappingNode {
+		return *copyNode(&patch)
+	}
+
+	merged := *copyNode(&base)
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i], copyNode(patch.Content[i+1])
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, copyNode(key), value)
+		}
+	}
+	return merged
+}
+
+// DiffProps returns the properties of live that differ from base, as a mapping,
+// and whether there were any. It is mergeProps run backwards: merging the
+// result over base gives live back. Properties are compared by their YAML, so
+// a float that round-trips to the same text counts as unchanged.
+func DiffProps(base, live yaml.Node) (yaml.Node, bool) {
+	if base.Kind != yaml.MappingNode || live.Kind != yaml.MappingNode {
+		if sameNode(&base, &live) {
+			return yaml.Node{}, false
+		}
+		return *copyNode(&live), true
+	}
+
+	diff := yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(live.Content); i += 2 {
+		key, value := live.Content[i], live.Content[i+1]
+		var before *yaml.Node
+		for j := 0; j+1 < len(base.Content); j += 2 {
+			if base.Content[j].Value == key.Value {
+				before = base.Content[j+1]
+				break
+			}
+		}
+		if before == nil || !sameNode(before, value) {
+			diff.Content = append(diff.Content, copyNode(key), copyNode(value))
+		}
+	}
+	return diff, len(diff.Content) > 0
+}
+
+func sameNode(a, b *yaml.Node) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() == b.IsZero()
+	}
+	first, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	second, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(first) == string(second)
+}
+
+// copyObject copies o deeply enough that editing the copy — its transform,
+// its components' props, its children — leaves o alone.
+func copyObject(o *Object) Object {
+	c := *o
+	if o.Transform != nil {
+		transform := *o.Transform
+		c.Transform = &transform
+	}
+	if o.Body != nil {
+		body := *o.Body
+		c.Body = &body
+	}
+	if o.Material != nil {
+		material := *o.Material
+		c.Material = &material
+	}
+	if o.Components != nil {
+		c.Components = make([]ComponentSpec, len(o.Components))
+		for i, component := range o.Components {
+			c.Components[i] = ComponentSpec{Type: component.Type, Props: *copyNode(&component.Props)}
+		}
+	}
+	if o.Children != nil {
+		c.Children = make([]Object, len(o.Children))
+		for i := range o.Children {
+			c.Children[i] = copyObject(&o.Children[i])
+		}
+	}
+	return c
+}
+
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	if n.Content != nil {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = copyNode(child)
+		}
+	}
+	return &c
+}
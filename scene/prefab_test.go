@@ -0,0 +1,315 @@
+package scene
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// prefabs is a loader over in-memory prefab files, keyed by path.
+func prefabs(t *testing.T, files map[string]string) PrefabLoader {
+	t.Helper()
+
+	return func(path string) (*Prefab, error) {
+		body, ok := files[path]
+		if !ok {
+			return nil, errors.New("no prefab at " + path)
+		}
+		prefab := &Prefab{}
+		if err := yaml.Unmarshal([]byte(body), prefab); err != nil {
+			t.Fatalf("prefab %s: %v", path, err)
+		}
+		return prefab, nil
+	}
+}
+
+const lampPrefab = `version: 2
+prefab:
+  name: lamp
+  model: lamp.obj
+  components:
+    - type: PointLight
+      props: {linear: 0.14, quadratic: 0.07}
+  children:
+    - name: shade
+      children:
+        - name: bulb
+          components:
+            - type: PointLight
+              props: {linear: 0.09}
+            - type: PointLight
+              props: {linear: 0.5}
+`
+
+func decodeObject(t *testing.T, body string) Object {
+	t.Helper()
+
+	var obj Object
+	if err := yaml.Unmarshal([]byte(body), &obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func prop(t *testing.T, props yaml.Node, key string) string {
+	t.Helper()
+
+	for i := 0; i+1 < len(props.Content); i += 2 {
+		if props.Content[i].Value == key {
+			return props.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+func TestExpandAppliesInstanceAndOverrides(t *testing.T) {
+	load := prefabs(t, map[string]string{"lamp.yml": lampPrefab})
+	instance := decodeObject(t, `
+name: hall-lamp
+prefab: lamp.yml
+transform:
+  position: [4, 0, -2]
+overrides:
+  - material: {color: [1, 0, 0]}
+  - target: shade/bulb
+    components:
+      - type: PointLight
+        index: 1
+        props: {quadratic: 0.3}
+    children:
+      - name: glow
+        components:
+          - type: PointLight
+`)
+
+	tree, err := Expand(instance, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Name != "hall-lamp" || tree.From != "lamp.yml" || tree.Prefab != "" {
+		t.Errorf("root is %q from %q (prefab %q)", tree.Name, tree.From, tree.Prefab)
+	}
+	if tree.Model != "lamp.obj" || tree.Transform.Position != [3]float32{4, 0, -2} {
+		t.Errorf("root model %q at %v", tree.Model, tree.Transform.Position)
+	}
+	if tree.Material == nil || tree.Material.Color != [3]float32{1, 0, 0} {
+		t.Errorf("root material %+v", tree.Material)
+	}
+
+	bulb := &tree.Children[0].Children[0]
+	// The second PointLight gets the patch, and keeps the property the patch
+	// did not mention.
+	if got := prop(t, bulb.Components[1].Props, "quadratic"); got != "0.3" {
+		t.Errorf("patched quadratic = %q", got)
+	}
+	if got := prop(t, bulb.Components[1].Props, "linear"); got != "0.5" {
+		t.Errorf("unpatched linear = %q", got)
+	}
+	if got := prop(t, bulb.Components[0].Props, "quadratic"); got != "" {
+		t.Errorf("the first PointLight was patched too: quadratic %q", got)
+	}
+	if len(bulb.Children) != 1 || bulb.Children[0].Name != "glow" {
+		t.Errorf("added children %+v", bulb.Children)
+	}
+}
+
+// TestExpandLeavesPrefabAlone matters because the loader caches: two instances
+// share one parsed prefab, and the first one's overrides must not show up in
+// the second.
+func TestExpandLeavesPrefabAlone(t *testing.T) {
+	parsed := map[string]*Prefab{}
+	files := prefabs(t, map[string]string{"lamp.yml": lampPrefab})
+	load := func(path string) (*Prefab, error) {
+		if prefab, ok := parsed[path]; ok {
+			return prefab, nil
+		}
+		prefab, err := files(path)
+		parsed[path] = prefab
+		return prefab, err
+	}
+
+	tinted := decodeObject(t, `
+name: a
+prefab: lamp.yml
+overrides:
+  - target: shade/bulb
+    components:
+      - type: PointLight
+        props: {linear: 9}
+`)
+	plain := decodeObject(t, "name: b\nprefab: lamp.yml\n")
+
+	if _, err := Expand(tinted, load); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Expand(plain, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prop(t, tree.Children[0].Children[0].Components[0].Props, "linear"); got != "0.09" {
+		t.Errorf("second instance sees linear %q", got)
+	}
+}
+
+func TestExpandNestedAndVariantPrefabs(t *testing.T) {
+	load := prefabs(t, map[string]string{
+		"lamp.yml": lampPrefab,
+		// A variant: a prefab whose root is an instance of another.
+		"red-lamp.yml": `version: 2
+prefab:
+  name: red-lamp
+  prefab: lamp.yml
+  overrides:
+    - material: {color: [1, 0, 0]}
+`,
+		"street.yml": `version: 2
+prefab:
+  name: street
+  children:
+    - name: left
+      prefab: red-lamp.yml
+    - name: right
+      prefab: lamp.yml
+`,
+	})
+
+	tree, err := Expand(decodeObject(t, "name: main\nprefab: street.yml\n"), load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right := tree.Children[0], tree.Children[1]
+	if left.Name != "left" || left.From != "red-lamp.yml" || left.Material == nil {
+		t.Errorf("left is %q from %q with material %+v", left.Name, left.From, left.Material)
+	}
+	if right.Model != "lamp.obj" || right.Material != nil || len(right.Children) != 1 {
+		t.Errorf("right is %+v", right)
+	}
+}
+
+func TestExpandRejectsCycles(t *testing.T) {
+	load := prefabs(t, map[string]string{
+		"a.yml": "version: 2\nprefab: {name: a, children: [{name: b, prefab: b.yml}]}\n",
+		"b.yml": "version: 2\nprefab: {name: b, prefab: a.yml}\n",
+	})
+
+	_, err := Expand(decodeObject(t, "name: x\nprefab: a.yml\n"), load)
+	if !errors.Is(err, ErrPrefabCycle) {
+		t.Fatalf("Expand = %v, want a cycle error", err)
+	}
+	if !strings.Contains(err.Error(), "a.yml -> b.yml -> a.yml") {
+		t.Errorf("cycle error should show the loop: %v", err)
+	}
+}
+
+func TestExpandRejectsMissingTargets(t *testing.T) {
+	load := prefabs(t, map[string]string{"lamp.yml": lampPrefab})
+
+	cases := map[string]string{
+		"no such child": `
+name: x
+prefab: lamp.yml
+overrides:
+  - target: shade/wick
+    transform: {}
+`,
+		"no such component": `
+name: x
+prefab: lamp.yml
+overrides:
+  - components:
+      - type: SpotLight
+        props: {cutOff: 1}
+`,
+		"index past the end": `
+name: x
+prefab: lamp.yml
+overrides:
+  - components:
+      - type: PointLight
+        index: 1
+        props: {linear: 1}
+`,
+	}
+	for name, body := range cases {
+		if _, err := Expand(decodeObject(t, body), load); err == nil {
+			t.Errorf("%s: expanded without complaint", name)
+		}
+	}
+}
+
+func TestInstanceCannotDescribeItsOwnObject(t *testing.T) {
+	path := writeScene(t, `version: 2
+objects:
+  - name: lamp
+    prefab: lamp.yml
+    model: other.obj
+`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "use overrides") {
+		t.Errorf("Load = %v, want a complaint about the model", err)
+	}
+}
+
+// TestBareInstanceLoads is the common case: an instance with nothing but a
+// name and a prefab does something, unlike an object with neither model nor
+// components.
+func TestBareInstanceLoads(t *testing.T) {
+	path := writeScene(t, `version: 2
+objects:
+  - name: lamp
+    prefab: lamp.yml
+`)
+	if _, err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffPropsInvertsMerge(t *testing.T) {
+	var base, live yaml.Node
+	if err := yaml.Unmarshal([]byte("{linear: 0.1, quadratic: 0.2, diffuse: [1, 1, 1]}"), &base); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte("{linear: 0.1, quadratic: 0.5, diffuse: [1, 0, 1]}"), &live); err != nil {
+		t.Fatal(err)
+	}
+	// Unmarshal wraps the mapping in a document node.
+	baseMap, liveMap := *base.Content[0], *live.Content[0]
+
+	diff, changed := DiffProps(baseMap, liveMap)
+	if !changed || len(diff.Content) != 4 {
+		t.Fatalf("diff has %d nodes, want quadratic and diffuse", len(diff.Content))
+	}
+	if prop(t, diff, "linear") != "" {
+		t.Error("unchanged linear is in the diff")
+	}
+
+	merged := mergeProps(baseMap, diff)
+	if _, changed := DiffProps(merged, liveMap); changed {
+		t.Error("merging the diff over the base did not give the live props back")
+	}
+	if _, changed := DiffProps(liveMap, liveMap); changed {
+		t.Error("props differ from themselves")
+	}
+}
+
+func TestPrefabRoundTrip(t *testing.T) {
+	load := prefabs(t, map[string]string{"lamp.yml": lampPrefab})
+	original, err := load("lamp.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeScene(t, "")
+	if err := SavePrefab(path, original); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadPrefab(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Root.Name != "lamp" || len(reloaded.Root.Children[0].Children[0].Components) != 2 {
+		t.Errorf("reloaded prefab %+v", reloaded.Root)
+	}
+}
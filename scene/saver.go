@@ -10,7 +10,8 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// Save writes the scene as version 2 YAML.
+// Save writes the scene in the current version, in the format the path's
+// extension names — YAML unless it says otherwise; see formats.go.
 //
 // The write goes to a temporary file in the same directory and is renamed into
 // place, so a failure part-way through leaves the previous scene file intact
@@ -18,24 +19,17 @@ import (
 // case for an editor save, and losing it to a half-written file would be the
 // worst possible outcome.
 func Save(path string, s *Scene) error {
-	if s == nil {
-		return utils.Logger().Errorf("cannot save a nil scene to %s", path)
-	}
-
-	// The version is the writer's, not whatever the struct happened to carry:
-	// this build only knows how to emit the current format.
-	saved := *s
-	saved.Version = CurrentVersion
-
-	if err := validate(&saved, path); err != nil {
+	encoded, err := Encode(path, FormatOf(path), s)
+	if err != nil {
 		return err
 	}
 
-	encoded, err := encode(&saved)
-	if err != nil {
-		return utils.Logger().Errorf("failed to encode scene %s: %s", path, err)
-	}
+	return writeReplacing(path, encoded)
+}
 
+// writeReplacing writes data to path through a temporary file in the same
+// directory, renamed into place once it is complete.
+func writeReplacing(path string, data []byte) error {
 	directory := filepath.Dir(path)
 	temporary, err := os.CreateTemp(directory, ".scene-*.yml")
 	if err != nil {
@@ -47,16 +41,16 @@ func Save(path string, s *Scene) error {
 	// succeeds there is nothing left at that name and the removal is a no-op.
 	defer os.Remove(temporaryPath)
 
-	if _, err := temporary.Write(encoded); err != nil {
+	if _, err := temporary.Write(data); err != nil {
 		temporary.Close()
-		return utils.Logger().Errorf("failed to write scene %s: %s", path, err)
+		return utils.Logger().Errorf("failed to write %s: %s", path, err)
 	}
 	if err := temporary.Close(); err != nil {
-		return utils.Logger().Errorf("failed to close scene %s: %s", path, err)
+		return utils.Logger().Errorf("failed to close %s: %s", path, err)
 	}
 
 	if err := os.Rename(temporaryPath, path); err != nil {
-		return utils.Logger().Errorf("failed to replace scene %s: %s", path, err)
+		return utils.Logger().Errorf("failed to replace %s: %s", path, err)
 	}
 
 	return nil
@@ -71,9 +65,9 @@ func Save(path string, s *Scene) error {
 // and this is also the only point where component props — arbitrary structs the
 // engine knows nothing about — can be reached and formatted alongside the
 // transforms.
-func encode(s *Scene) ([]byte, error) {
+func encode(document any) ([]byte, error) {
 	var root yaml.Node
-	if err := root.Encode(s); err != nil {
+	if err := root.Encode(document); err != nil {
 		return nil, err
 	}
 	compactScalarSequences(&root)
@@ -124,6 +118,12 @@ func compactScalarSequences(node *yaml.Node) {
 // reloaded is not a save, so it is better to fail here, naming the object, than
 // to write a file that only breaks on the next load.
 func validate(s *Scene, path string) error {
+	if err := checkIncludes(s.Includes, path); err != nil {
+		return err
+	}
+	if err := checkCells(s, path); err != nil {
+		return err
+	}
 	return validateObjects(s.Objects, path)
 }
 
@@ -136,9 +136,17 @@ func validateObjects(objects []Object, path string) error {
 				"cannot save scene %s: object %q has no model, no components and no children, so reloading it would fail",
 				path, obj.Name)
 		}
+		if err := obj.checkInstance(); err != nil {
+			return utils.Logger().Errorf("cannot save scene %s: %s", path, err)
+		}
 		if err := validateObjects(obj.Children, path); err != nil {
 			return err
 		}
+		for j := range obj.Overrides {
+			if err := validateObjects(obj.Overrides[j].Children, path); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
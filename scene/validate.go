@@ -0,0 +1,573 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate is the thorough counterpart to the checks Load makes. Load stops at
+// the first thing that would keep the scene from loading at all; Validate goes
+// on, and reports everything it can find that is wrong or suspicious, each
+// with the line and column it is at, so a CI run over a directory of scenes
+// lists every problem at once rather than one per push.
+//
+// It works on the parsed YAML rather than on the Scene structs, because the
+// structs do not remember where anything came from. JSON files are parsed the
+// same way and get real positions too. Binary files have no positions to give
+// and are not validated; validate the file they were converted from.
+//
+// What it knows about components, models and skyboxes comes from Resources,
+// since those live in the engine; the scene package only knows the format.
+
+// Severity says whether an issue makes a scene wrong or only suspicious.
+type Severity string
+
+const (
+	// SeverityError is a scene that will not load, or will load without
+	// something it asks for.
+	SeverityError Severity = "error"
+	// SeverityWarning is a scene that loads as written but probably not as
+	// meant: a rotation axis that is not unit length, a key nothing reads.
+	SeverityWarning Severity = "warning"
+)
+
+// The rules an issue can come from. They are part of the report's contract:
+// CI scripts filter on them, so they are never renamed.
+const (
+	RuleUnreadable       = "unreadable"
+	RuleSyntax           = "syntax"
+	RuleVersion          = "version"
+	RuleInvalid          = "invalid"
+	RuleUnknownField     = "unknown-field"
+	RuleDoesNothing      = "does-nothing"
+	RuleInstance         = "instance"
+	RuleDuplicateName    = "duplicate-name"
+	RuleMissingFile      = "missing-file"
+	RuleMissingModel     = "missing-model"
+	RuleUnreadableModel  = "unreadable-model"
+	RuleMissingTexture   = "missing-texture"
+	RuleUnknownComponent = "unknown-component"
+	RuleUnknownProp      = "unknown-prop"
+	RuleInvalidScale     = "invalid-scale"
+	RuleRotationAxis     = "rotation-axis"
+	RuleMissingSkybox    = "missing-skybox"
+)
+
+// Issue is one problem in one file. Line and Column are 1-based; a zero Line
+// is a problem with the file as a whole. Object is the path of names to the
+// object the issue is in, as in "/town/hall/lamp", when there is one.
+type Issue struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Object   string   `json:"object,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// String formats the issue the way compilers do, so editors can jump to it.
+func (i Issue) String() string {
+	where := i.File
+	if i.Line > 0 {
+		where += ":" + strconv.Itoa(i.Line)
+		if i.Column > 0 {
+			where += ":" + strconv.Itoa(i.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", where, i.Severity, i.Message, i.Rule)
+}
+
+// ComponentTypes is what Validate asks about components: whether a type is
+// registered and, if so, the props it reads. The engine's ComponentRegistry
+// is one.
+type ComponentTypes interface {
+	PropNames(typeName string) ([]string, bool)
+}
+
+// Resources is what Validate needs from outside the scene package. Any of them
+// may be nil, which skips the checks that need it.
+type Resources struct {
+	Components ComponentTypes
+
+	// ModelTextures lists the texture files a model samples. Finding out means
+	// importing the model, so it is the slow part of a validation; each model
+	// is asked about once however many objects use it.
+	ModelTextures func(model string) ([]string, error)
+
+	// SkyboxFiles lists the files a skybox directory has to hold.
+	SkyboxFiles func(directory string) []string
+}
+
+// Validate checks the scene or prefab file at path and returns every issue
+// found, ordered by position. No issues means a clean file.
+func Validate(path string, resources Resources) []Issue {
+	v := &validator{path: path, resources: resources, models: map[string]bool{}}
+	v.file()
+
+	sort.SliceStable(v.issues, func(a, b int) bool {
+		if v.issues[a].Line != v.issues[b].Line {
+			return v.issues[a].Line < v.issues[b].Line
+		}
+		return v.issues[a].Column < v.issues[b].Column
+	})
+	return v.issues
+}
+
+type validator struct {
+	path      string
+	resources Resources
+	issues    []Issue
+
+	// models holds the models already asked about, so a missing texture on a
+	// model used by five hundred objects is reported once.
+	models map[string]bool
+
+	// fallback is where to point an issue at a node with no position — one a
+	// migration made rather than read from the file.
+	fallback *yaml.Node
+}
+
+func (v *validator) add(node *yaml.Node, severity Severity, rule, object, format string, args ...any) {
+	issue := Issue{
+		File:     v.path,
+		Severity: severity,
+		Rule:     rule,
+		Object:   object,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if node == nil || node.Line == 0 {
+		node = v.fallback
+	}
+	if node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	v.issues = append(v.issues, issue)
+}
+
+func (v *validator) errorf(node *yaml.Node, rule, object, format string, args ...any) {
+	v.add(node, SeverityError, rule, object, format, args...)
+}
+
+func (v *validator) warnf(node *yaml.Node, rule, object, format string, args ...any) {
+	v.add(node, SeverityWarning, rule, object, format, args...)
+}
+
+var errorLine = regexp.MustCompile(`line (\d+): `)
+
+// decode decodes node into target, reporting each thing yaml could not fit at
+// the line yaml names for it. It returns whether the decode was clean.
+func (v *validator) decode(node *yaml.Node, target any, object string) bool {
+	err := node.Decode(target)
+	if err == nil {
+		return true
+	}
+
+	messages := []string{err.Error()}
+	if typeError, ok := err.(*yaml.TypeError); ok {
+		messages = typeError.Errors
+	}
+	for _, message := range messages {
+		at := node
+		if match := errorLine.FindStringSubmatch(message); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			value := ""
+			if quoted := quotedValue.FindStringSubmatch(message); quoted != nil {
+				value = quoted[1]
+			}
+			if found := nodeOnLine(node, line, value); found != nil {
+				at = found
+			}
+			message = strings.Replace(message, match[0], "", 1)
+		}
+		v.errorf(at, RuleInvalid, object, "%s", message)
+	}
+	return false
+}
+
+// quotedValue is the offending value in a yaml decode error: "cannot unmarshal
+// !!str `abc` into float32".
+var quotedValue = regexp.MustCompile("`([^`]*)`")
+
+// nodeOnLine finds the node a decode error at line is about, for a column to go
+// with the line yaml reported: the scalar holding value if there is one on that
+// line — in [1, abc, 1] it is the abc — and otherwise the first node there.
+func nodeOnLine(node *yaml.Node, line int, value string) *yaml.Node {
+	var first *yaml.Node
+	var search func(node *yaml.Node) *yaml.Node
+	search = func(node *yaml.Node) *yaml.Node {
+		if node.Line == line {
+			if node.Kind == yaml.ScalarNode && value != "" && node.Value == value {
+				return node
+			}
+			if first == nil {
+				first = node
+			}
+		}
+		for _, child := range node.Content {
+			if found := search(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if found := search(node); found != nil {
+		return found
+	}
+	return first
+}
+
+func (v *validator) file() {
+	if FormatOf(v.path) == FormatBinary {
+		v.errorf(nil, RuleUnreadable, "", "binary scenes cannot be validated; validate the file this one was converted from")
+		return
+	}
+	content, err := os.ReadFile(v.path)
+	if err != nil {
+		v.errorf(nil, RuleUnreadable, "", "%s", err)
+		return
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		line := 0
+		if match := errorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		v.issues = append(v.issues, Issue{
+			File: v.path, Line: line, Severity: SeverityError, Rule: RuleSyntax,
+			Message: strings.TrimPrefix(errorLine.ReplaceAllString(err.Error(), ""), "yaml: "),
+		})
+		return
+	}
+	if document.Kind == 0 {
+		v.errorf(nil, RuleSyntax, "", "the file is empty")
+		return
+	}
+	root := document.Content[0]
+	v.fallback = root
+	if root.Kind != yaml.MappingNode {
+		v.errorf(root, RuleInvalid, "", "expected a scene or prefab, a mapping with version and objects or prefab keys")
+		return
+	}
+
+	versionNode := mappingValue(root, "version")
+	if _, err := Migrate(&document); err != nil {
+		v.errorf(versionNode, RuleVersion, "", "%s", err)
+		return
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "skybox":
+			v.skybox(value)
+		case "camera":
+			var camera CameraSpec
+			v.decode(value, &camera, "")
+		case "includes":
+			v.includes(value)
+		case "cells":
+			v.cells(value)
+		case "objects":
+			v.objects(value, "")
+		case "prefab":
+			v.object(value, "", map[string]*yaml.Node{})
+		case "version":
+		default:
+			v.warnf(key, RuleUnknownField, "", "%q is not a scene field and is ignored", key.Value)
+		}
+	}
+}
+
+func (v *validator) skybox(node *yaml.Node) {
+	var directory string
+	if !v.decode(node, &directory, "") || directory == "" || v.resources.SkyboxFiles == nil {
+		return
+	}
+	if info, err := os.Stat(directory); err != nil || !info.IsDir() {
+		v.errorf(node, RuleMissingSkybox, "", "skybox directory %s does not exist", directory)
+		return
+	}
+
+	var missing []string
+	for _, file := range v.resources.SkyboxFiles(directory) {
+		if _, err := os.Stat(file); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) > 0 {
+		v.errorf(node, RuleMissingSkybox, "", "skybox %s is missing %s", directory, strings.Join(missing, ", "))
+	}
+}
+
+func (v *validator) includes(node *yaml.Node) {
+	var includes []Include
+	if !v.decode(node, &includes, "") {
+		return
+	}
+	if err := checkIncludes(includes, v.path); err != nil {
+		v.errorf(node, RuleInvalid, "", "%s", err)
+	}
+	for _, item := range node.Content {
+		v.fileExists(mappingValue(item, "path"), "", "included scene")
+	}
+}
+
+func (v *validator) cells(node *yaml.Node) {
+	var cells []Cell
+	if !v.decode(node, &cells, "") {
+		return
+	}
+	if err := checkCells(&Scene{Cells: cells}, v.path); err != nil {
+		v.errorf(node, RuleInvalid, "", "%s", err)
+	}
+	for _, item := range node.Content {
+		v.fileExists(mappingValue(item, "path"), "", "cell")
+	}
+}
+
+// fileExists reports a path node naming a file that is not there.
+func (v *validator) fileExists(node *yaml.Node, object, what string) bool {
+	if node == nil || node.Value == "" {
+		return false
+	}
+	if _, err := os.Stat(node.Value); err != nil {
+		v.errorf(node, RuleMissingFile, object, "%s %s does not exist", what, node.Value)
+		return false
+	}
+	return true
+}
+
+func (v *validator) objects(node *yaml.Node, parent string) {
+	if node.Kind != yaml.SequenceNode {
+		if node.Tag != "!!null" {
+			v.errorf(node, RuleInvalid, parent, "expected a list of objects")
+		}
+		return
+	}
+	siblings := map[string]*yaml.Node{}
+	for _, item := range node.Content {
+		v.object(item, parent, siblings)
+	}
+}
+
+// object checks one object and everything under it. siblings holds the names
+// already taken among its siblings.
+func (v *validator) object(node *yaml.Node, parent string, siblings map[string]*yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		v.errorf(node, RuleInvalid, parent, "expected an object")
+		return
+	}
+
+	outer := v.fallback
+	v.fallback = node
+	defer func() { v.fallback = outer }()
+
+	var object Object
+	if nameNode := mappingValue(node, "name"); nameNode != nil && v.decode(nameNode, &object.Name, parent) && object.Name != "" {
+		if first, taken := siblings[object.Name]; taken {
+			v.errorf(nameNode, RuleDuplicateName, parent+"/"+object.Name,
+				"another object here is already named %q, at line %d; names among siblings must differ so paths and overrides can find them",
+				object.Name, first.Line)
+		} else {
+			siblings[object.Name] = nameNode
+		}
+	}
+	where := parent + "/" + object.Name
+
+	// Every field is decoded on its own, so a mistake in one is reported at
+	// its own line and does not hide mistakes in the rest. Children and
+	// overrides are only counted here and checked below, each on its own.
+	var children, overrides *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "name":
+		case "model":
+			if v.decode(value, &object.Model, where) {
+				v.model(value, where)
+			}
+		case "transform":
+			object.Transform = &TransformSpec{}
+			v.transform(value, where)
+		case "body":
+			object.Body = &BodySpec{}
+			v.decode(value, object.Body, where)
+		case "material":
+			object.Material = &MaterialSpec{}
+			v.decode(value, object.Material, where)
+		case "components":
+			if v.decode(value, &object.Components, where) {
+				v.components(value, where)
+			}
+		case "prefab":
+			if v.decode(value, &object.Prefab, where) {
+				v.fileExists(value, where, "prefab")
+			}
+		case "children":
+			children = value
+			object.Children = make([]Object, len(value.Content))
+		case "overrides":
+			overrides = value
+			object.Overrides = make([]Override, len(value.Content))
+		default:
+			v.warnf(key, RuleUnknownField, where, "%q is not an object field and is ignored", key.Value)
+		}
+	}
+
+	if object.DoesNothing() {
+		v.errorf(node, RuleDoesNothing, where,
+			"object %q has no model, no components and no children, so it would do nothing", object.Name)
+	}
+	if err := object.checkInstance(); err != nil {
+		v.errorf(node, RuleInstance, where, "%s", err)
+	}
+
+	if overrides != nil {
+		v.overrides(overrides, where)
+	}
+	if children != nil {
+		v.objects(children, where)
+	}
+}
+
+func (v *validator) overrides(node *yaml.Node, where string) {
+	if node.Kind != yaml.SequenceNode {
+		v.errorf(node, RuleInvalid, where, "expected a list of overrides")
+		return
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			v.errorf(item, RuleInvalid, where, "expected an override")
+			continue
+		}
+		target := where
+		if targetNode := mappingValue(item, "target"); targetNode != nil && targetNode.Value != "" {
+			target += "/" + targetNode.Value
+		}
+
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			switch key.Value {
+			case "target":
+			case "transform":
+				v.transform(value, target)
+			case "body":
+				v.decode(value, &BodySpec{}, target)
+			case "material":
+				v.decode(value, &MaterialSpec{}, target)
+			case "components":
+				var components []ComponentOverride
+				if v.decode(value, &components, target) {
+					v.components(value, target)
+				}
+			case "children":
+				v.objects(value, target)
+			default:
+				v.warnf(key, RuleUnknownField, target, "%q is not an override field and is ignored", key.Value)
+			}
+		}
+	}
+}
+
+// model checks a model file is there and, if Resources can say, that the
+// textures it samples are too.
+func (v *validator) model(node *yaml.Node, where string) {
+	path := node.Value
+	if path == "" {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		v.errorf(node, RuleMissingModel, where, "model %s does not exist", path)
+		return
+	}
+	if v.resources.ModelTextures == nil || v.models[path] {
+		return
+	}
+	v.models[path] = true
+
+	textures, err := v.resources.ModelTextures(path)
+	if err != nil {
+		v.errorf(node, RuleUnreadableModel, where, "model %s cannot be read: %s", path, err)
+		return
+	}
+	for _, texture := range textures {
+		if _, err := os.Stat(texture); err != nil {
+			v.errorf(node, RuleMissingTexture, where, "model %s uses texture %s, which does not exist", path, texture)
+		}
+	}
+}
+
+func (v *validator) transform(node *yaml.Node, where string) {
+	var transform TransformSpec
+	if !v.decode(node, &transform, where) {
+		return
+	}
+
+	if scaleNode := mappingValue(node, "scale"); scaleNode != nil {
+		for axis, value := range transform.Scale {
+			f := float64(value)
+			if value == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+				v.errorf(scaleNode, RuleInvalidScale, where,
+					"scale %v is %v on the %c axis; a scale must be finite and non-zero", transform.Scale, value, "xyz"[axis])
+				break
+			}
+		}
+	}
+
+	if rotationNode := mappingValue(node, "rotation"); rotationNode != nil {
+		axis := transform.Rotation
+		length := math.Sqrt(float64(axis[0]*axis[0] + axis[1]*axis[1] + axis[2]*axis[2]))
+		switch {
+		case length == 0 || math.IsNaN(length):
+			v.errorf(rotationNode, RuleRotationAxis, where,
+				"rotation axis is zero, which names no rotation; the engine would turn about Y instead")
+		case math.Abs(length-1) > 1e-3:
+			v.warnf(rotationNode, RuleRotationAxis, where,
+				"rotation axis %v has length %.4g, not 1; it is normalised when loaded, to [%.4g, %.4g, %.4g]",
+				[3]float32(axis[:3]), length,
+				float64(axis[0])/length, float64(axis[1])/length, float64(axis[2])/length)
+		}
+	}
+}
+
+// components checks each component's type and prop names. It works on
+// ComponentSpec and ComponentOverride lists alike; both have type and props.
+func (v *validator) components(node *yaml.Node, where string) {
+	if v.resources.Components == nil {
+		return
+	}
+	for _, item := range node.Content {
+		typeNode := mappingValue(item, "type")
+		if typeNode == nil {
+			v.errorf(item, RuleInvalid, where, "a component has no type")
+			continue
+		}
+		names, ok := v.resources.Components.PropNames(typeNode.Value)
+		if !ok {
+			v.errorf(typeNode, RuleUnknownComponent, where, "unknown component type %q", typeNode.Value)
+			continue
+		}
+
+		props := mappingValue(item, "props")
+		if props == nil || props.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(props.Content); i += 2 {
+			key := props.Content[i]
+			if !slices.Contains(names, key.Value) {
+				v.errorf(key, RuleUnknownProp, where, "%s has no property %q; it has %s",
+					typeNode.Value, key.Value, strings.Join(names, ", "))
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package scene
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeComponents stands in for the engine's registry.
+type fakeComponents map[string][]string
+
+func (f fakeComponents) PropNames(typeName string) ([]string, bool) {
+	names, ok := f[typeName]
+	return names, ok
+}
+
+// validationFixture is a directory holding a model and a skybox with one face
+// missing, and Resources that know about them: the model samples one texture
+// that is there and one that is not.
+type validationFixture struct {
+	directory string
+	model     string
+	skybox    string
+	resources Resources
+	imports   int
+}
+
+func newValidationFixture(t *testing.T) *validationFixture {
+	t.Helper()
+
+	f := &validationFixture{directory: t.TempDir()}
+	f.model = filepath.Join(f.directory, "lamp.obj")
+	f.skybox = filepath.Join(f.directory, "sky")
+	touch := func(path string) {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	touch(f.model)
+	touch(filepath.Join(f.directory, "lamp.png"))
+	if err := os.Mkdir(f.skybox, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, face := range []string{"right", "left", "top"} {
+		touch(filepath.Join(f.skybox, face+".jpg"))
+	}
+
+	f.resources = Resources{
+		Components: fakeComponents{"PointLight": {"diffuse", "linear", "quadratic"}},
+		ModelTextures: func(model string) ([]string, error) {
+			f.imports++
+			if model != f.model {
+				return nil, fmt.Errorf("not a model")
+			}
+			return []string{filepath.Join(f.directory, "lamp.png"), filepath.Join(f.directory, "lamp-normal.png")}, nil
+		},
+		SkyboxFiles: func(directory string) []string {
+			var files []string
+			for _, face := range []string{"right", "left", "top", "bottom"} {
+				files = append(files, filepath.Join(directory, face+".jpg"))
+			}
+			return files
+		},
+	}
+	return f
+}
+
+// write puts a scene next to the fixture's files, with {model} and {skybox}
+// standing for their paths.
+func (f *validationFixture) write(t *testing.T, body string) string {
+	t.Helper()
+
+	body = strings.NewReplacer("{model}", f.model, "{skybox}", f.skybox).Replace(body)
+	path := filepath.Join(f.directory, "scene.yml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+type wantIssue struct {
+	line, column int
+	rule         string
+}
+
+func assertIssues(t *testing.T, issues []Issue, want []wantIssue) {
+	t.Helper()
+
+	var got []wantIssue
+	for _, issue := range issues {
+		got = append(got, wantIssue{issue.Line, issue.Column, issue.Rule})
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		var report strings.Builder
+		for _, issue := range issues {
+			report.WriteString(issue.String() + "\n")
+		}
+		t.Errorf("got issues %v\nwant %v\n%s", got, want, report.String())
+	}
+}
+
+// TestValidateReportsEveryIssue puts one of nearly every problem in one file:
+// all of them have to be found, in order, each at the node it is about.
+func TestValidateReportsEveryIssue(t *testing.T) {
+	f := newValidationFixture(t)
+	path := f.write(t, `version: 2
+skybox: {skybox}
+includes:
+  - path: missing-layer.yml
+objects:
+  - name: lamp
+    model: {model}
+    transform:
+      rotation: [0, 2, 0, 90]
+      scale: [1, 0, 1]
+    components:
+      - type: PointLight
+        props: {diffuse: [1, 1, 1], lineer: 0.1}
+      - type: Flicker
+  - name: lamp
+    model: missing.obj
+    transform:
+      position: [1, high, 3]
+    children:
+      - name: shade
+      - name: bulb
+        modle: bulb.obj
+        components:
+          - type: PointLight
+  - name: crate
+    transform:
+      rotation: [0, 0, 0, 45]
+    body: {static: true}
+    prefab: crate.yml
+`)
+
+	issues := Validate(path, f.resources)
+	assertIssues(t, issues, []wantIssue{
+		{2, 9, RuleMissingSkybox},
+		{4, 11, RuleMissingFile},
+		{7, 12, RuleMissingTexture},
+		{9, 17, RuleRotationAxis},
+		{10, 14, RuleInvalidScale},
+		{13, 37, RuleUnknownProp},
+		{14, 15, RuleUnknownComponent},
+		{15, 11, RuleDuplicateName},
+		{16, 12, RuleMissingModel},
+		{18, 21, RuleInvalid},
+		{20, 9, RuleDoesNothing},
+		{22, 9, RuleUnknownField},
+		{25, 5, RuleInstance},
+		{27, 17, RuleRotationAxis},
+		{29, 13, RuleMissingFile},
+	})
+
+	for _, issue := range issues {
+		if issue.File != path {
+			t.Errorf("issue in %q, want %q", issue.File, path)
+		}
+		switch issue.Rule {
+		case RuleRotationAxis:
+			if (issue.Line == 9) != (issue.Severity == SeverityWarning) {
+				t.Errorf("a long axis should warn and a zero one fail: %s", issue)
+			}
+		case RuleUnknownField:
+			if issue.Severity != SeverityWarning || issue.Object != "/lamp/bulb" {
+				t.Errorf("unknown field reported as %s in %q", issue.Severity, issue.Object)
+			}
+		case RuleMissingSkybox:
+			if !strings.Contains(issue.Message, "bottom.jpg") || strings.Contains(issue.Message, "top.jpg") {
+				t.Errorf("skybox issue should name the missing face only: %s", issue.Message)
+			}
+		}
+	}
+}
+
+func TestValidateCleanScene(t *testing.T) {
+	f := newValidationFixture(t)
+	if err := os.WriteFile(filepath.Join(f.directory, "lamp-normal.png"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(f.skybox, "bottom.jpg"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two objects on one model: it is imported once.
+	path := f.write(t, `version: 2
+skybox: {skybox}
+objects:
+  - name: lamp
+    model: {model}
+    transform:
+      rotation: [0.6, 0.8, 0, 90]
+    components:
+      - type: PointLight
+        props: {linear: 0.1}
+  - name: lamp-2
+    model: {model}
+    children:
+      - name: lamp
+        components:
+          - type: PointLight
+`)
+	if issues := Validate(path, f.resources); len(issues) != 0 {
+		t.Errorf("a clean scene has issues: %v", issues)
+	}
+	if f.imports != 1 {
+		t.Errorf("the model was imported %d times", f.imports)
+	}
+}
+
+// TestValidateWithoutResources: with nothing known about the engine, only the
+// format itself is checked.
+func TestValidateWithoutResources(t *testing.T) {
+	path := writeScene(t, `version: 2
+objects:
+  - name: ghost
+    components:
+      - type: Anything
+        props: {at: all}
+`)
+	if issues := Validate(path, Resources{}); len(issues) != 0 {
+		t.Errorf("issues without resources: %v", issues)
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	path := writeScene(t, "version: 2\nobjects:\n  - name: a\n   model: [\n")
+	assertIssues(t, Validate(path, Resources{}), []wantIssue{{2, 0, RuleSyntax}})
+}
+
+func TestValidateVersion(t *testing.T) {
+	path := writeScene(t, "objects: []\nversion: 7\n")
+	assertIssues(t, Validate(path, Resources{}), []wantIssue{{2, 10, RuleVersion}})
+}
+
+// TestValidateMigratedFile: a version 1 file is checked as what it upgrades
+// to: issues in the blocks the migration made point at their object, and a
+// renamed field keeps its place.
+func TestValidateMigratedFile(t *testing.T) {
+	path := writeScene(t, `objects:
+  - name: crate
+    path: crate.obj
+    scaleY: 0
+`)
+	assertIssues(t, Validate(path, Resources{}), []wantIssue{
+		{2, 5, RuleInvalidScale},
+		{3, 11, RuleMissingModel},
+	})
+}
+
+func TestValidateRefusesBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene"+BinaryExtension)
+	if err := Save(path, &Scene{Objects: []Object{{Name: "a", Model: "a.obj"}}}); err != nil {
+		t.Fatal(err)
+	}
+	assertIssues(t, Validate(path, Resources{}), []wantIssue{{0, 0, RuleUnreadable}})
+}
@@ -0,0 +1,7 @@
+// The camera block, uploaded once per frame by the engine and shared by every
+// program that includes this chunk. Binding point: shaders.CameraBlockBinding.
+layout(std140) uniform Camera {
+    mat4 projection;
+    mat4 view;
+    vec3 viewPos;
+};
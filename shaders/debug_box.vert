@@ -1,9 +1,9 @@
 #version 460 core
 layout (location = 0) in vec3 aPos;
 
+#include "camera.glsl"
+
 uniform mat4 model;
-uniform mat4 view;
-uniform mat4 projection;
 
 void main() {
     gl_Position = projection * view * model * vec4(aPos, 1.0);
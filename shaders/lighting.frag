@@ -24,54 +24,13 @@ struct Material {
     vec3 base_color;
 };
 
-struct DirLight {
-    vec3 direction;
+#include "camera.glsl"
+#include "lights.glsl"
 
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-};
-
-struct PointLight {
-    vec3 position;
-
-    float constant;
-    float linear;
-    float quadratic;
-
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-};
-
-struct SpotLight {
-    vec3 position;
-    vec3 direction;
-    float cutOff;
-    float outerCutOff;
-
-    float constant;
-    float linear;
-    float quadratic;
-
-    vec3 ambient;
-    vec3 diffuse;
-    vec3 specular;
-
-    bool isEnabled;
-};
-
-uniform vec3 viewPos;
 uniform Material material;
 
 uniform samplerCube skybox;
 
-#define MAX_POINT_LIGHT 4
-uniform int nb_point_light;
-uniform DirLight dirLight;
-uniform PointLight pointLights[MAX_POINT_LIGHT];
-uniform SpotLight spotLight;
-
 vec3 DiffuseColor();
 vec3 CalcDirLight(DirLight light, vec3 normal, vec3 viewDir);
 vec3 CalcPointLight(PointLight light, vec3 normal, vec3 fragPos, vec3 viewDir);
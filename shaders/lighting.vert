@@ -8,9 +8,9 @@ out vec3 FragPos;
 out vec3 Normal;
 out vec2 TexCoords;
 
+#include "camera.glsl"
+
 uniform mat4 model;
-uniform mat4 view;
-uniform mat4 projection;
 
 void main()
 {
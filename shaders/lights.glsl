@@ -0,0 +1,54 @@
+// Light structs and the Lights block shared by every program that shades with
+// the scene's lights. The engine packs the block once per frame (packLights in
+// engine/lights.go, std140 layout), so the member order here and there must
+// match. Binding point: shaders.LightsBlockBinding.
+
+// MAX_POINT_LIGHT is injected by the engine from engine.MaxPointLights so the
+// two can't drift apart.
+#ifndef MAX_POINT_LIGHT
+#error MAX_POINT_LIGHT must be defined by the program that compiles this shader
+#endif
+
+struct DirLight {
+    vec3 direction;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+};
+
+struct PointLight {
+    vec3 position;
+
+    float constant;
+    float linear;
+    float quadratic;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+};
+
+struct SpotLight {
+    vec3 position;
+    vec3 direction;
+    float cutOff;
+    float outerCutOff;
+
+    float constant;
+    float linear;
+    float quadratic;
+
+    vec3 ambient;
+    vec3 diffuse;
+    vec3 specular;
+
+    bool isEnabled;
+};
+
+layout(std140) uniform Lights {
+    DirLight dirLight;
+    PointLight pointLights[MAX_POINT_LIGHT];
+    SpotLight spotLight;
+    int nb_point_light;
+};
@@ -0,0 +1,8 @@
+#version 460 core
+out vec4 FragColor;
+
+uniform vec3 color;
+
+void main() {
+    FragColor = vec4(color, 1.0);
+}
@@ -0,0 +1,28 @@
+#version 460 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+
+#include "camera.glsl"
+
+uniform mat4 model;
+
+// width is the outline's thickness in pixels and viewport the framebuffer size,
+// so the push below can be measured in pixels. Zero draws the mesh unchanged,
+// which is how the stencil pass marks the silhouette.
+uniform float width;
+uniform vec2 viewport;
+
+void main() {
+    vec4 clip = projection * view * model * vec4(aPos, 1.0);
+
+    // Push each vertex out along its normal as seen on screen. Doing it in clip
+    // space, scaled by w, keeps the outline the same thickness at any distance
+    // instead of growing with the model.
+    vec3 normal = mat3(transpose(inverse(model))) * aNormal;
+    vec2 screenNormal = (projection * view * vec4(normal, 0.0)).xy;
+    if (width > 0.0 && length(screenNormal) > 0.0) {
+        clip.xy += normalize(screenNormal) * width * 2.0 / viewport * clip.w;
+    }
+
+    gl_Position = clip;
+}
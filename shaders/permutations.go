@@ -0,0 +1,50 @@
+package shaders
+
+// Permutations compiles variants of one vertex/fragment pair on first use and
+// keeps them, so a renderer can ask for "lighting with HAS_NORMAL_MAP" per
+// draw without paying a compile for every mesh, and without compiling every
+// combination up front when most scenes only ever need one or two.
+//
+// It is not safe for concurrent use; like the rest of the package it belongs
+// to the GL thread.
+type Permutations struct {
+	vertex, fragment string
+	base             Defines
+	programs         map[string]*Shader
+}
+
+// NewPermutations returns an empty set for the given stages. base is injected
+// into every variant; Get layers its own defines on top.
+func NewPermutations(nameVertex, nameFragment string, base Defines) *Permutations {
+	return &Permutations{
+		vertex:   nameVertex,
+		fragment: nameFragment,
+		base:     base,
+		programs: map[string]*Shader{},
+	}
+}
+
+// Get returns the program compiled with base plus extra, compiling it if this
+// is the first request for that combination. A failed compile is not cached,
+// so fixing the shader and asking again works.
+func (p *Permutations) Get(extra Defines) (*Shader, error) {
+	defines := p.base.merge(extra)
+	key := defines.Key()
+	if shader, ok := p.programs[key]; ok {
+		return shader, nil
+	}
+	shader, err := CreateShaderProgramWithDefines(p.vertex, p.fragment, defines)
+	if err != nil {
+		return nil, err
+	}
+	p.programs[key] = shader
+	return shader, nil
+}
+
+// Delete releases every compiled variant.
+func (p *Permutations) Delete() {
+	for key, shader := range p.programs {
+		shader.Delete()
+		delete(p.programs, key)
+	}
+}
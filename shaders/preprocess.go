@@ -0,0 +1,265 @@
+package shaders
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Defines are #define lines injected into a shader right after its #version
+// directive. An empty value produces a bare flag (`#define HAS_NORMAL_MAP`),
+// which is what `#ifdef` permutations want; anything else is pasted verbatim
+// as the macro body.
+//
+// They exist so a constant shared by Go and GLSL has one source of truth: the
+// engine passes MaxPointLights in rather than the shader hardcoding a number
+// someone has to remember to bump in both places.
+type Defines map[string]string
+
+// Key is a canonical spelling of the defines, stable across map iteration
+// order, for callers that cache programs per permutation.
+func (d Defines) Key() string {
+	var b strings.Builder
+	for i, name := range d.names() {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(name)
+		if v := d[name]; v != "" {
+			b.WriteByte('=')
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// names returns the define names sorted, so the injected block (and therefore
+// the compiled source) is identical from run to run.
+func (d Defines) names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d Defines) validate() error {
+	for name, value := range d {
+		if !isIdentifier(name) {
+			return fmt.Errorf("define %q is not a valid identifier", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("define %s: value must be a single line", name)
+		}
+	}
+	return nil
+}
+
+// merge returns d with extra layered on top. Neither input is modified.
+func (d Defines) merge(extra Defines) Defines {
+	out := make(Defines, len(d)+len(extra))
+	for k, v := range d {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// source is a preprocessed shader stage ready for glShaderSource.
+type source struct {
+	text string
+	// files names each GLSL source-string number used in the #line directives,
+	// so a driver error like "1:12" can be turned back into "lights.glsl:12".
+	files []string
+}
+
+// legend spells out files for appending to a compile error.
+func (s source) legend() string {
+	parts := make([]string, len(s.files))
+	for i, f := range s.files {
+		parts[i] = strconv.Itoa(i) + "=" + f
+	}
+	return "source strings: " + strings.Join(parts, ", ")
+}
+
+// preprocess expands `#include "chunk"` directives and injects defines after
+// the #version line of name. read fetches a file by name; getShader passes the
+// embedded FS, tests pass a map.
+//
+// Each chunk is pasted at most once per stage, the way #pragma once behaves in
+// C, because the chunks mostly declare structs and a second copy is a
+// redefinition error; two chunks can then share a third without the shader
+// having to know. A chunk that includes itself, directly or not, is still an
+// error rather than being quietly skipped: it is always a mistake and the
+// once-rule would otherwise hide which file was meant to win.
+//
+// #line directives keep the driver's line numbers pointing at the original
+// files, so compile errors stay readable after expansion.
+func preprocess(name string, defines Defines, read func(string) (string, error)) (source, error) {
+	if err := defines.validate(); err != nil {
+		return source{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	p := &preprocessor{read: read, included: map[string]bool{}}
+	root, err := read(name)
+	if err != nil {
+		return source{}, err
+	}
+
+	lines := splitLines(root)
+	versionAt := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#version") {
+			versionAt = i
+		}
+		break
+	}
+	if versionAt < 0 {
+		return source{}, fmt.Errorf("%s: first directive must be #version", name)
+	}
+
+	p.files = append(p.files, name)
+	p.included[name] = true
+	p.stack = append(p.stack, name)
+
+	for _, line := range lines[:versionAt+1] {
+		p.out.WriteString(line)
+		p.out.WriteByte('\n')
+	}
+	for _, define := range defines.names() {
+		p.out.WriteString("#define " + define)
+		if v := defines[define]; v != "" {
+			p.out.WriteString(" " + v)
+		}
+		p.out.WriteByte('\n')
+	}
+	if len(defines) > 0 {
+		p.lineDirective(versionAt+2, 0)
+	}
+
+	if err := p.expand(name, 0, lines, versionAt+1); err != nil {
+		return source{}, err
+	}
+	return source{text: p.out.String(), files: p.files}, nil
+}
+
+type preprocessor struct {
+	read     func(string) (string, error)
+	out      strings.Builder
+	files    []string
+	included map[string]bool
+	stack    []string
+}
+
+// expand writes lines[from:] of file (source-string number index) to the
+// output, recursing into includes.
+func (p *preprocessor) expand(file string, index int, lines []string, from int) error {
+	for i := from; i < len(lines); i++ {
+		line := lines[i]
+		target, ok, err := parseInclude(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, i+1, err)
+		}
+		if !ok {
+			p.out.WriteString(line)
+			p.out.WriteByte('\n')
+			continue
+		}
+
+		for _, open := range p.stack {
+			if open == target {
+				return fmt.Errorf("%s:%d: include cycle: %s -> %s",
+					file, i+1, strings.Join(p.stack, " -> "), target)
+			}
+		}
+		if p.included[target] {
+			// Keep the line count intact so the #line bookkeeping below still
+			// matches what the author sees in their editor.
+			p.out.WriteString("// " + strings.TrimSpace(line) + " (already included)\n")
+			continue
+		}
+
+		content, err := p.read(target)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, i+1, err)
+		}
+		chunk := splitLines(content)
+		for _, l := range chunk {
+			if strings.HasPrefix(strings.TrimSpace(l), "#version") {
+				return fmt.Errorf("%s: included chunks must not declare #version", target)
+			}
+		}
+
+		p.included[target] = true
+		p.files = append(p.files, target)
+		chunkIndex := len(p.files) - 1
+		p.stack = append(p.stack, target)
+
+		p.lineDirective(1, chunkIndex)
+		if err := p.expand(target, chunkIndex, chunk, 0); err != nil {
+			return err
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+		p.lineDirective(i+2, index)
+	}
+	return nil
+}
+
+func (p *preprocessor) lineDirective(line, index int) {
+	fmt.Fprintf(&p.out, "#line %d %d\n", line, index)
+}
+
+// parseInclude recognises `#include "name"`. ok is false for any other line;
+// a malformed include is an error rather than being passed through to a
+// driver that would reject it with a far less helpful message.
+func parseInclude(line string) (name string, ok bool, err error) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") {
+		return "", false, nil
+	}
+	directive := strings.TrimSpace(trimmed[1:])
+	if !strings.HasPrefix(directive, "include") {
+		return "", false, nil
+	}
+	rest := strings.TrimSpace(directive[len("include"):])
+	if len(rest) < 2 || rest[0] != '"' {
+		return "", false, fmt.Errorf("malformed #include, want #include \"file\"")
+	}
+	end := strings.IndexByte(rest[1:], '"')
+	if end <= 0 {
+		return "", false, fmt.Errorf("malformed #include, want #include \"file\"")
+	}
+	if tail := strings.TrimSpace(rest[end+2:]); tail != "" && !strings.HasPrefix(tail, "//") {
+		return "", false, fmt.Errorf("unexpected %q after #include", tail)
+	}
+	return rest[1 : end+1], true, nil
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	return strings.Split(s, "\n")
+}
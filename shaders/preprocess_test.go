@@ -0,0 +1,186 @@
+package shaders
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// files is an in-memory stand-in for the embedded shader directory.
+type files map[string]string
+
+func (f files) read(name string) (string, error) {
+	content, ok := f[name]
+	if !ok {
+		return "", fmt.Errorf("no such file %q", name)
+	}
+	return content, nil
+}
+
+func TestPreprocessInjectsDefinesAfterVersion(t *testing.T) {
+	fs := files{"main.frag": "#version 460 core\nvoid main() {}\n"}
+	src, err := preprocess("main.frag", Defines{"MAX_POINT_LIGHT": "4", "HAS_NORMAL_MAP": ""}, fs.read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#version 460 core\n" +
+		"#define HAS_NORMAL_MAP\n" +
+		"#define MAX_POINT_LIGHT 4\n" +
+		"#line 2 0\n" +
+		"void main() {}\n"
+	if src.text != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", src.text, want)
+	}
+}
+
+func TestPreprocessWithoutDefinesLeavesSourceAlone(t *testing.T) {
+	body := "#version 460 core\nout vec4 c;\nvoid main() { c = vec4(1.0); }\n"
+	src, err := preprocess("main.frag", nil, files{"main.frag": body}.read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.text != body {
+		t.Fatalf("got:\n%s\nwant:\n%s", src.text, body)
+	}
+}
+
+func TestPreprocessExpandsIncludes(t *testing.T) {
+	fs := files{
+		"main.frag":   "#version 460 core\n#include \"lights.glsl\"\nvoid main() {}\n",
+		"lights.glsl": "struct DirLight { vec3 direction; };\n",
+	}
+	src, err := preprocess("main.frag", nil, fs.read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#version 460 core\n" +
+		"#line 1 1\n" +
+		"struct DirLight { vec3 direction; };\n" +
+		"#line 3 0\n" +
+		"void main() {}\n"
+	if src.text != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", src.text, want)
+	}
+	if len(src.files) != 2 || src.files[0] != "main.frag" || src.files[1] != "lights.glsl" {
+		t.Fatalf("files = %v", src.files)
+	}
+}
+
+func TestPreprocessIncludesSharedChunkOnce(t *testing.T) {
+	fs := files{
+		"main.frag":   "#version 460 core\n#include \"a.glsl\"\n#include \"b.glsl\"\n",
+		"a.glsl":      "#include \"common.glsl\"\nfloat a;\n",
+		"b.glsl":      "#include \"common.glsl\"\nfloat b;\n",
+		"common.glsl": "struct Shared { float x; };\n",
+	}
+	src, err := preprocess("main.frag", nil, fs.read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(src.text, "struct Shared"); n != 1 {
+		t.Fatalf("shared chunk pasted %d times:\n%s", n, src.text)
+	}
+	if !strings.Contains(src.text, "float a;") || !strings.Contains(src.text, "float b;") {
+		t.Fatalf("missing chunk bodies:\n%s", src.text)
+	}
+}
+
+func TestPreprocessRejectsIncludeCycle(t *testing.T) {
+	fs := files{
+		"main.frag": "#version 460 core\n#include \"a.glsl\"\n",
+		"a.glsl":    "#include \"b.glsl\"\n",
+		"b.glsl":    "#include \"a.glsl\"\n",
+	}
+	_, err := preprocess("main.frag", nil, fs.read)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("err = %v, want include cycle", err)
+	}
+	if !strings.Contains(err.Error(), "main.frag -> a.glsl -> b.glsl -> a.glsl") {
+		t.Fatalf("cycle not spelled out: %v", err)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fs      files
+		defines Defines
+		want    string
+	}{
+		{
+			name: "missing version",
+			fs:   files{"main.frag": "void main() {}\n"},
+			want: "#version",
+		},
+		{
+			name: "missing include",
+			fs:   files{"main.frag": "#version 460 core\n#include \"nope.glsl\"\n"},
+			want: "main.frag:2",
+		},
+		{
+			name: "malformed include",
+			fs:   files{"main.frag": "#version 460 core\n#include <lights.glsl>\n"},
+			want: "malformed #include",
+		},
+		{
+			name: "version in chunk",
+			fs: files{
+				"main.frag": "#version 460 core\n#include \"c.glsl\"\n",
+				"c.glsl":    "#version 460 core\n",
+			},
+			want: "must not declare #version",
+		},
+		{
+			name:    "bad define name",
+			fs:      files{"main.frag": "#version 460 core\n"},
+			defines: Defines{"2FAST": "1"},
+			want:    "not a valid identifier",
+		},
+		{
+			name:    "multiline define",
+			fs:      files{"main.frag": "#version 460 core\n"},
+			defines: Defines{"X": "1\n#define Y 2"},
+			want:    "single line",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := preprocess("main.frag", tt.defines, tt.fs.read)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefinesKeyIsOrderIndependent(t *testing.T) {
+	a := Defines{"SKINNED": "", "MAX_POINT_LIGHT": "4"}
+	b := Defines{"MAX_POINT_LIGHT": "4", "SKINNED": ""}
+	if a.Key() != b.Key() {
+		t.Fatalf("%q != %q", a.Key(), b.Key())
+	}
+	if got, want := a.Key(), "MAX_POINT_LIGHT=4;SKINNED"; got != want {
+		t.Fatalf("Key() = %q, want %q", got, want)
+	}
+}
+
+// The real shaders go through the same code path at startup; this catches a
+// broken include or a chunk that was renamed without the shader noticing.
+func TestEmbeddedShadersPreprocess(t *testing.T) {
+	names := []string{
+		"lighting.vert", "lighting.frag",
+		"debug_box.vert", "debug_box.frag",
+		"skybox.vert", "skybox.frag",
+	}
+	for _, name := range names {
+		src, err := preprocess(name, Defines{"MAX_POINT_LIGHT": "4"}, readShaderFile)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Contains(src.text, "#include") {
+			t.Fatalf("%s: unexpanded include left behind", name)
+		}
+	}
+}
@@ -9,11 +9,25 @@ import (
 	"github.com/go-gl/gl/v4.6-core/gl"
 )
 
-//go:embed *.vert *.frag
+//go:embed *.vert *.frag *.glsl
 var shaderDir embed.FS
 
 type Shader struct {
 	ProgramId uint32
+
+	// name is the stage pair, for warnings.
+	name string
+
+	// uniforms maps every active uniform name the program exposes to its
+	// location, filled once at link time. Set* used to call
+	// glGetUniformLocation (and allocate a C string) on every call, which the
+	// render loop did per light and per draw, every frame.
+	uniforms map[string]int32
+
+	// missing records names that were asked for but aren't active, so a typo
+	// or a uniform the compiler optimised out is reported once instead of
+	// every frame.
+	missing map[string]bool
 }
 
 func (s *Shader) Use() {
@@ -25,11 +39,18 @@ func (s *Shader) Delete() {
 }
 
 func CreateShaderProgram(nameVertex, nameFragment string) (*Shader, error) {
-	vertexShader, err := compileShader(nameVertex, gl.VERTEX_SHADER)
+	return CreateShaderProgramWithDefines(nameVertex, nameFragment, nil)
+}
+
+// CreateShaderProgramWithDefines is CreateShaderProgram with defines injected
+// into both stages, so a permutation (say HAS_NORMAL_MAP) or a constant owned
+// by Go code reaches the GLSL without editing the file.
+func CreateShaderProgramWithDefines(nameVertex, nameFragment string, defines Defines) (*Shader, error) {
+	vertexShader, err := compileShader(nameVertex, gl.VERTEX_SHADER, defines)
 	if err != nil {
 		return nil, err
 	}
-	fragmentShader, err := compileShader(nameFragment, gl.FRAGMENT_SHADER)
+	fragmentShader, err := compileShader(nameFragment, gl.FRAGMENT_SHADER, defines)
 	if err != nil {
 		gl.DeleteShader(vertexShader)
 		return nil, err
@@ -51,16 +72,23 @@ func CreateShaderProgram(nameVertex, nameFragment string) (*Shader, error) {
 		return nil, utils.Logger().Errorf("ERROR::SHADER::PROGRAM::COMPILATION_FAILED\n%s\n", string(infoLog))
 	}
 
-	return &Shader{ProgramId: shaderProgram}, nil
+	shader := &Shader{
+		ProgramId: shaderProgram,
+		name:      nameVertex + "+" + nameFragment,
+		uniforms:  map[string]int32{},
+		missing:   map[string]bool{},
+	}
+	shader.introspect()
+	return shader, nil
 }
 
-func compileShader(name string, shaderType uint32) (uint32, error) {
-	shaderSourceStr, err := getShader(name)
+func compileShader(name string, shaderType uint32, defines Defines) (uint32, error) {
+	src, err := getShader(name, defines)
 	if err != nil {
 		return 0, err
 	}
 
-	shaderSource, freeShader := gl.Strs(shaderSourceStr + "\x00")
+	shaderSource, freeShader := gl.Strs(src.text + "\x00")
 	shader := gl.CreateShader(shaderType)
 	gl.ShaderSource(shader, 1, shaderSource, nil)
 	freeShader()
@@ -73,17 +101,27 @@ func compileShader(name string, shaderType uint32) (uint32, error) {
 		infoLog := make([]byte, 512)
 		gl.GetShaderInfoLog(shader, 512, nil, &infoLog[0])
 		gl.DeleteShader(shader)
-		return 0, fmt.Errorf("ERROR::SHADER::%s::COMPILATION_FAILED %s", strings.ToUpper(name), string(infoLog))
+		return 0, fmt.Errorf("ERROR::SHADER::%s::COMPILATION_FAILED %s\n%s",
+			strings.ToUpper(name), string(infoLog), src.legend())
 	}
 
 	return shader, nil
 }
 
-func getShader(name string) (string, error) {
-	content, err := shaderDir.ReadFile(name)
+// getShader reads name from the embedded shader directory and runs it through
+// the preprocessor, resolving includes against the same directory.
+func getShader(name string, defines Defines) (source, error) {
+	src, err := preprocess(name, defines, readShaderFile)
 	if err != nil {
-		return "", utils.Logger().Errorf("failed to read file: %s\n", err)
+		return source{}, utils.Logger().Errorf("failed to preprocess shader: %s\n", err)
 	}
+	return src, nil
+}
 
+func readShaderFile(name string) (string, error) {
+	content, err := shaderDir.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
 	return string(content), nil
 }
@@ -10,49 +10,49 @@ func (s *Shader) SetBool(name string, val bool) {
 	if val {
 		valInt = 1
 	}
-	gl.Uniform1i(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), valInt)
+	gl.Uniform1i(s.location(name), valInt)
 }
 
 func (s *Shader) SetInt(name string, val int32) {
-	gl.Uniform1i(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), val)
+	gl.Uniform1i(s.location(name), val)
 }
 
 func (s *Shader) SetFloat(name string, val float32) {
-	gl.Uniform1f(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), val)
+	gl.Uniform1f(s.location(name), val)
 }
 
 func (s *Shader) SetVec2Val(name string, val mgl32.Vec2) {
-	gl.Uniform2fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, &val[0])
+	gl.Uniform2fv(s.location(name), 1, &val[0])
 }
 
 func (s *Shader) SetVec2(name string, x, y float32) {
-	gl.Uniform2f(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), x, y)
+	gl.Uniform2f(s.location(name), x, y)
 }
 
 func (s *Shader) SetVec3Val(name string, val mgl32.Vec3) {
-	gl.Uniform3fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, &val[0])
+	gl.Uniform3fv(s.location(name), 1, &val[0])
 }
 
 func (s *Shader) SetVec3(name string, x, y, z float32) {
-	gl.Uniform3f(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), x, y, z)
+	gl.Uniform3f(s.location(name), x, y, z)
 }
 
 func (s *Shader) SetVec4Val(name string, val mgl32.Vec4) {
-	gl.Uniform4fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, &val[0])
+	gl.Uniform4fv(s.location(name), 1, &val[0])
 }
 
 func (s *Shader) SetVec4(name string, x, y, z, w float32) {
-	gl.Uniform4f(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), x, y, z, w)
+	gl.Uniform4f(s.location(name), x, y, z, w)
 }
 
 func (s *Shader) SetMat2(name string, val mgl32.Mat2) {
-	gl.UniformMatrix2fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, false, &val[0])
+	gl.UniformMatrix2fv(s.location(name), 1, false, &val[0])
 }
 
 func (s *Shader) SetMat3(name string, val mgl32.Mat3) {
-	gl.UniformMatrix3fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, false, &val[0])
+	gl.UniformMatrix3fv(s.location(name), 1, false, &val[0])
 }
 
 func (s *Shader) SetMat4(name string, val mgl32.Mat4) {
-	gl.UniformMatrix4fv(gl.GetUniformLocation(s.ProgramId, gl.Str(name+"\x00")), 1, false, &val[0])
+	gl.UniformMatrix4fv(s.location(name), 1, false, &val[0])
 }
@@ -0,0 +1,104 @@
+package shaders
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Std140 packs values into a byte slice following the GLSL std140 layout, so
+// the Go side of a uniform block is written field by field in declaration
+// order and the padding takes care of itself. The rules that matter here:
+// scalars align to 4, vec2 to 8, vec3 and vec4 to 16 (a vec3 still only
+// occupies 12 bytes, so a following float slots in behind it), bool is a
+// 4-byte int, and a struct (and so each element of an array of structs)
+// starts and ends on a 16-byte boundary.
+//
+// The zero value is ready to use; Reset lets a caller keep one per block and
+// reuse its backing array every frame.
+type Std140 struct {
+	buf []byte
+}
+
+// Reset empties the buffer, keeping its capacity.
+func (w *Std140) Reset() {
+	w.buf = w.buf[:0]
+}
+
+// Bytes returns the packed block, padded to a 16-byte multiple as the block's
+// own size would be.
+func (w *Std140) Bytes() []byte {
+	w.align(16)
+	return w.buf
+}
+
+// Len is the number of bytes written so far, before the trailing pad.
+func (w *Std140) Len() int {
+	return len(w.buf)
+}
+
+func (w *Std140) align(n int) {
+	for len(w.buf)%n != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *Std140) putFloat(v float32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(v))
+}
+
+func (w *Std140) Float(v float32) {
+	w.align(4)
+	w.putFloat(v)
+}
+
+func (w *Std140) Int(v int32) {
+	w.align(4)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v))
+}
+
+func (w *Std140) Bool(v bool) {
+	var i int32
+	if v {
+		i = 1
+	}
+	w.Int(i)
+}
+
+func (w *Std140) Vec2(v mgl32.Vec2) {
+	w.align(8)
+	w.putFloat(v[0])
+	w.putFloat(v[1])
+}
+
+func (w *Std140) Vec3(v mgl32.Vec3) {
+	w.align(16)
+	for _, c := range v {
+		w.putFloat(c)
+	}
+}
+
+func (w *Std140) Vec4(v mgl32.Vec4) {
+	w.align(16)
+	for _, c := range v {
+		w.putFloat(c)
+	}
+}
+
+// Mat4 writes a column-major matrix: four vec4 columns.
+func (w *Std140) Mat4(m mgl32.Mat4) {
+	w.align(16)
+	for _, c := range m {
+		w.putFloat(c)
+	}
+}
+
+// BeginStruct and EndStruct bracket the members of a struct value.
+func (w *Std140) BeginStruct() {
+	w.align(16)
+}
+
+func (w *Std140) EndStruct() {
+	w.align(16)
+}
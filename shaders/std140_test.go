@@ -0,0 +1,109 @@
+package shaders
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func floatAt(b []byte, offset int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(b[offset:]))
+}
+
+// The PointLight struct from lights.glsl: a vec3 followed by floats packs the
+// first float into the vec3's fourth slot, and the struct rounds up to 16.
+func TestStd140PointLightLayout(t *testing.T) {
+	var w Std140
+	w.BeginStruct()
+	w.Vec3(mgl32.Vec3{1, 2, 3}) // position   @0
+	w.Float(4)                  // constant   @12
+	w.Float(5)                  // linear     @16
+	w.Float(6)                  // quadratic  @20
+	w.Vec3(mgl32.Vec3{7, 7, 7}) // ambient    @32
+	w.Vec3(mgl32.Vec3{8, 8, 8}) // diffuse    @48
+	w.Vec3(mgl32.Vec3{9, 9, 9}) // specular   @64
+	w.EndStruct()
+
+	b := w.Bytes()
+	if len(b) != 80 {
+		t.Fatalf("struct size = %d, want 80", len(b))
+	}
+	for offset, want := range map[int]float32{0: 1, 8: 3, 12: 4, 16: 5, 20: 6, 32: 7, 48: 8, 64: 9, 72: 9} {
+		if got := floatAt(b, offset); got != want {
+			t.Errorf("offset %d = %v, want %v", offset, got, want)
+		}
+	}
+}
+
+func TestStd140Alignment(t *testing.T) {
+	var w Std140
+	w.Float(1)               // @0
+	w.Vec2(mgl32.Vec2{2, 2}) // @8
+	w.Bool(true)             // @16
+	w.Vec4(mgl32.Vec4{3})    // @32
+	w.Mat4(mgl32.Ident4())   // @48
+	w.Int(-1)                // @112
+	if w.Len() != 116 {
+		t.Fatalf("Len = %d, want 116", w.Len())
+	}
+	b := w.Bytes()
+	if len(b) != 128 {
+		t.Fatalf("padded size = %d, want 128", len(b))
+	}
+	if floatAt(b, 8) != 2 || binary.LittleEndian.Uint32(b[16:]) != 1 || floatAt(b, 32) != 3 {
+		t.Fatalf("scalars misplaced: % x", b[:48])
+	}
+	if floatAt(b, 48) != 1 || floatAt(b, 48+5*4) != 1 || floatAt(b, 48+4) != 0 {
+		t.Fatal("matrix not written column-major at 48")
+	}
+	if int32(binary.LittleEndian.Uint32(b[112:])) != -1 {
+		t.Fatal("int not at 112")
+	}
+}
+
+func TestStd140ResetReusesBuffer(t *testing.T) {
+	var w Std140
+	w.Vec4(mgl32.Vec4{1, 2, 3, 4})
+	first := w.Bytes()
+	w.Reset()
+	w.Vec4(mgl32.Vec4{5, 6, 7, 8})
+	second := w.Bytes()
+	if &first[0] != &second[0] {
+		t.Fatal("Reset reallocated the backing array")
+	}
+	if floatAt(second, 0) != 5 {
+		t.Fatalf("got %v after reset", floatAt(second, 0))
+	}
+}
+
+func TestUniformElementNames(t *testing.T) {
+	tests := []struct {
+		reported string
+		size     int32
+		bare     string
+		elements []string
+	}{
+		{"model", 1, "model", []string{"model"}},
+		{"material.base_color", 1, "material.base_color", []string{"material.base_color"}},
+		{"weights[0]", 3, "weights", []string{"weights[0]", "weights[1]", "weights[2]"}},
+		{"pointLights[2].position", 1, "pointLights[2].position", []string{"pointLights[2].position"}},
+	}
+	for _, tt := range tests {
+		bare, elements := uniformElementNames(tt.reported, tt.size)
+		if bare != tt.bare {
+			t.Errorf("%s: bare = %q, want %q", tt.reported, bare, tt.bare)
+		}
+		if len(elements) != len(tt.elements) {
+			t.Errorf("%s: elements = %v, want %v", tt.reported, elements, tt.elements)
+			continue
+		}
+		for i := range elements {
+			if elements[i] != tt.elements[i] {
+				t.Errorf("%s: elements = %v, want %v", tt.reported, elements, tt.elements)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package shaders
+
+import "github.com/go-gl/gl/v4.6-core/gl"
+
+// UniformBuffer is the GL buffer behind a uniform block, attached to one
+// binding point. Every program bound to that point reads the same data, which
+// is what lets the camera and lights be uploaded once a frame instead of once
+// per program.
+type UniformBuffer struct {
+	id      uint32
+	binding uint32
+	size    int
+}
+
+// NewUniformBuffer creates an empty buffer for binding. Upload allocates it.
+func NewUniformBuffer(binding uint32) *UniformBuffer {
+	b := &UniformBuffer{binding: binding}
+	gl.GenBuffers(1, &b.id)
+	return b
+}
+
+// Upload replaces the buffer's contents and (re)attaches it to its binding.
+// Growing reallocates; same size or smaller reuses the storage.
+func (b *UniformBuffer) Upload(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.id)
+	if len(data) > b.size {
+		gl.BufferData(gl.UNIFORM_BUFFER, len(data), gl.Ptr(data), gl.DYNAMIC_DRAW)
+		b.size = len(data)
+	} else {
+		gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), gl.Ptr(data))
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, b.binding, b.id)
+}
+
+func (b *UniformBuffer) Delete() {
+	gl.DeleteBuffers(1, &b.id)
+	b.size = 0
+}
@@ -0,0 +1,138 @@
+package shaders
+
+import (
+	"3d-engine/utils"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Uniform block names the engine shares between programs, and the binding
+// points their buffers live at. A program that declares one of these blocks
+// (usually by including camera.glsl or lights.glsl) is bound to the matching
+// point at link time, so the block is uploaded once per frame and every
+// program sees it without a per-program Set* call.
+const (
+	CameraBlock = "Camera"
+	LightsBlock = "Lights"
+
+	CameraBlockBinding uint32 = 0
+	LightsBlockBinding uint32 = 1
+)
+
+var blockBindings = map[string]uint32{
+	CameraBlock: CameraBlockBinding,
+	LightsBlock: LightsBlockBinding,
+}
+
+// introspect fills the location cache from the program's active uniforms and
+// binds the shared uniform blocks. It runs once, right after linking.
+func (s *Shader) introspect() {
+	var count, maxLength int32
+	gl.GetProgramiv(s.ProgramId, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(s.ProgramId, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	buf := make([]uint8, maxLength+1)
+
+	for i := uint32(0); i < uint32(count); i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(s.ProgramId, i, int32(len(buf)), &length, &size, &xtype, &buf[0])
+		bare, elements := uniformElementNames(string(buf[:length]), size)
+
+		for j, element := range elements {
+			location := gl.GetUniformLocation(s.ProgramId, gl.Str(element+"\x00"))
+			// Members of uniform blocks are listed too but have no location;
+			// they are written through the block's buffer instead.
+			if location < 0 {
+				continue
+			}
+			s.uniforms[element] = location
+			if j == 0 && bare != element {
+				s.uniforms[bare] = location
+			}
+		}
+	}
+
+	s.bindSharedBlocks()
+}
+
+// bindSharedBlocks points every shared block the program declares at its
+// engine-wide binding.
+func (s *Shader) bindSharedBlocks() {
+	var count, maxLength int32
+	gl.GetProgramiv(s.ProgramId, gl.ACTIVE_UNIFORM_BLOCKS, &count)
+	gl.GetProgramiv(s.ProgramId, gl.ACTIVE_UNIFORM_BLOCK_MAX_NAME_LENGTH, &maxLength)
+	if count == 0 {
+		return
+	}
+	buf := make([]uint8, maxLength+1)
+
+	for i := uint32(0); i < uint32(count); i++ {
+		var length int32
+		gl.GetActiveUniformBlockName(s.ProgramId, i, int32(len(buf)), &length, &buf[0])
+		name := string(buf[:length])
+		binding, ok := blockBindings[name]
+		if !ok {
+			utils.Logger().Printf("shader %s: uniform block %q has no engine binding; bind it with BindUniformBlock",
+				s.name, name)
+			continue
+		}
+		gl.UniformBlockBinding(s.ProgramId, i, binding)
+	}
+}
+
+// BindUniformBlock points the named block at a binding, for blocks outside
+// the shared set. It warns once if the program has no such block.
+func (s *Shader) BindUniformBlock(name string, binding uint32) {
+	index := gl.GetUniformBlockIndex(s.ProgramId, gl.Str(name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		s.warnMissing("uniform block " + name)
+		return
+	}
+	gl.UniformBlockBinding(s.ProgramId, index, binding)
+}
+
+// HasUniform reports whether name is an active uniform, without warning.
+func (s *Shader) HasUniform(name string) bool {
+	_, ok := s.uniforms[name]
+	return ok
+}
+
+// location returns the cached location of name, or -1 (which glUniform*
+// silently ignores) after warning once that the program has no such uniform.
+func (s *Shader) location(name string) int32 {
+	if location, ok := s.uniforms[name]; ok {
+		return location
+	}
+	s.warnMissing("uniform " + name)
+	return -1
+}
+
+func (s *Shader) warnMissing(what string) {
+	if s.missing[what] {
+		return
+	}
+	s.missing[what] = true
+	utils.Logger().Printf("shader %s: no active %s (misspelt, or optimised out by the compiler)", s.name, what)
+}
+
+// uniformElementNames expands what glGetActiveUniform reports into the names
+// callers use. An array of a basic type is reported once, as "name[0]" with a
+// size, but is addressed both as "name" and as each "name[i]"; everything
+// else (including each member of an array of structs, which GL lists
+// separately) is its own single name.
+func uniformElementNames(reported string, size int32) (bare string, elements []string) {
+	if !strings.HasSuffix(reported, "[0]") {
+		return reported, []string{reported}
+	}
+	bare = strings.TrimSuffix(reported, "[0]")
+	if size < 1 {
+		size = 1
+	}
+	elements = make([]string, size)
+	for i := range elements {
+		elements[i] = bare + "[" + strconv.Itoa(i) + "]"
+	}
+	return bare, elements
+}
@@ -0,0 +1,232 @@
+package textures
+
+import (
+	"encoding/binary"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// BlockFormat is the in-memory layout of an Image's levels.
+type BlockFormat uint8
+
+const (
+	// RGBA8 is uncompressed, four bytes per texel.
+	RGBA8 BlockFormat = iota
+	// BC1 (DXT1): RGB with optional 1-bit alpha, 8 bytes per 4x4 block.
+	BC1
+	// BC2 (DXT3): RGB plus explicit 4-bit alpha.
+	BC2
+	// BC3 (DXT5): RGB plus interpolated alpha.
+	BC3
+	// BC4 and BC5 hold one and two channels, for masks and normal maps.
+	BC4
+	BC4Signed
+	BC5
+	BC5Signed
+	// BC6H holds half-float HDR RGB.
+	BC6H
+	BC6HSigned
+	// BC7 is high-quality RGB(A).
+	BC7
+)
+
+var blockFormatNames = map[BlockFormat]string{
+	RGBA8: "RGBA8", BC1: "BC1", BC2: "BC2", BC3: "BC3",
+	BC4: "BC4", BC4Signed: "BC4_SNORM", BC5: "BC5", BC5Signed: "BC5_SNORM",
+	BC6H: "BC6H", BC6HSigned: "BC6H_SF16", BC7: "BC7",
+}
+
+func (f BlockFormat) String() string {
+	if name, ok := blockFormatNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Compressed reports whether f is a 4x4 block format.
+func (f BlockFormat) Compressed() bool {
+	return f != RGBA8
+}
+
+// blockBytes is the size of one 4x4 block.
+func (f BlockFormat) blockBytes() int {
+	switch f {
+	case BC1, BC4, BC4Signed:
+		return 8
+	default:
+		return 16
+	}
+}
+
+// levelBytes is the minimum size of a w x h level in this format.
+func (f BlockFormat) levelBytes(w, h int32) int {
+	if !f.Compressed() {
+		return int(w) * int(h) * 4
+	}
+	bw, bh := (int(w)+3)/4, (int(h)+3)/4
+	return bw * bh * f.blockBytes()
+}
+
+// internalFormat picks the GL internal format for f in the given colour space.
+// Formats with no sRGB variant (single/dual channel and HDR) are data by
+// nature and ignore the request.
+func (f BlockFormat) internalFormat(space ColorSpace) uint32 {
+	srgb := space == SRGB
+	switch f {
+	case RGBA8:
+		if srgb {
+			return gl.SRGB8_ALPHA8
+		}
+		return gl.RGBA8
+	case BC1:
+		if srgb {
+			return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT
+		}
+		return gl.COMPRESSED_RGBA_S3TC_DXT1_EXT
+	case BC2:
+		if srgb {
+			return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT
+		}
+		return gl.COMPRESSED_RGBA_S3TC_DXT3_EXT
+	case BC3:
+		if srgb {
+			return gl.COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT
+		}
+		return gl.COMPRESSED_RGBA_S3TC_DXT5_EXT
+	case BC4:
+		return gl.COMPRESSED_RED_RGTC1
+	case BC4Signed:
+		return gl.COMPRESSED_SIGNED_RED_RGTC1
+	case BC5:
+		return gl.COMPRESSED_RG_RGTC2
+	case BC5Signed:
+		return gl.COMPRESSED_SIGNED_RG_RGTC2
+	case BC6H:
+		return gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT
+	case BC6HSigned:
+		return gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT
+	case BC7:
+		if srgb {
+			return gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM
+		}
+		return gl.COMPRESSED_RGBA_BPTC_UNORM
+	}
+	return 0
+}
+
+// blocksHaveAlpha scans a compressed level for any texel that could be less
+// than opaque. BC1-BC3 are checked exactly. BC7 is checked exactly for mode 6
+// (the usual encoder choice for opaque RGBA) and conservatively otherwise:
+// any block in an alpha-carrying mode counts. Formats without alpha never are.
+func blocksHaveAlpha(f BlockFormat, level []byte) bool {
+	size := f.blockBytes()
+	for off := 0; off+size <= len(level); off += size {
+		block := level[off : off+size]
+		switch f {
+		case BC1:
+			if bc1BlockHasAlpha(block) {
+				return true
+			}
+		case BC2:
+			if binary.LittleEndian.Uint64(block) != ^uint64(0) {
+				return true
+			}
+		case BC3:
+			if bc3AlphaBlockHasAlpha(block[:8]) {
+				return true
+			}
+		case BC7:
+			if bc7BlockHasAlpha(block) {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// bc1BlockHasAlpha: when color0 <= color1 the block is in 3-colour mode and
+// index 3 is transparent black.
+func bc1BlockHasAlpha(block []byte) bool {
+	c0 := binary.LittleEndian.Uint16(block[0:])
+	c1 := binary.LittleEndian.Uint16(block[2:])
+	if c0 > c1 {
+		return false
+	}
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := 0; i < 16; i++ {
+		if (indices>>(2*i))&3 == 3 {
+			return true
+		}
+	}
+	return false
+}
+
+// bc3AlphaBlockHasAlpha decodes the alpha palette of a BC3 (or BC4) block and
+// checks whether any texel selects an entry below 255.
+func bc3AlphaBlockHasAlpha(block []byte) bool {
+	a0, a1 := int(block[0]), int(block[1])
+	var palette [8]int
+	palette[0], palette[1] = a0, a1
+	if a0 > a1 {
+		for i := 1; i <= 6; i++ {
+			palette[i+1] = ((7-i)*a0 + i*a1) / 7
+		}
+	} else {
+		for i := 1; i <= 4; i++ {
+			palette[i+1] = ((5-i)*a0 + i*a1) / 5
+		}
+		palette[6], palette[7] = 0, 255
+	}
+
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(block[2+i]) << (8 * i)
+	}
+	for i := 0; i < 16; i++ {
+		if palette[(bits>>(3*i))&7] < 255 {
+			return true
+		}
+	}
+	return false
+}
+
+func bc7BlockHasAlpha(block []byte) bool {
+	mode := -1
+	for i := 0; i < 8; i++ {
+		if block[0]&(1<<i) != 0 {
+			mode = i
+			break
+		}
+	}
+	switch {
+	case mode < 4:
+		// Modes 0-3 are RGB only; -1 is a reserved encoding that decodes to
+		// zero, which is not worth a blended pass.
+		return false
+	case mode == 6:
+		// Mode 6: 7 mode bits, then RGBA endpoints at 7 bits each and one
+		// p-bit per endpoint. Opaque only if both alpha endpoints are 255.
+		lo := binary.LittleEndian.Uint64(block[0:])
+		hi := binary.LittleEndian.Uint64(block[8:])
+		bit := func(pos uint) uint64 {
+			if pos < 64 {
+				return (lo >> pos) & 1
+			}
+			return (hi >> (pos - 64)) & 1
+		}
+		field := func(pos, n uint) uint64 {
+			var v uint64
+			for i := uint(0); i < n; i++ {
+				v |= bit(pos+i) << i
+			}
+			return v
+		}
+		a0 := field(49, 7)<<1 | bit(63)
+		a1 := field(56, 7)<<1 | bit(64)
+		return a0 != 255 || a1 != 255
+	default:
+		return true
+	}
+}
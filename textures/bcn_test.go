@@ -0,0 +1,74 @@
+package textures
+
+import "testing"
+
+func TestLevelBytes(t *testing.T) {
+	tests := []struct {
+		format BlockFormat
+		w, h   int32
+		want   int
+	}{
+		{RGBA8, 3, 2, 24},
+		{BC1, 4, 4, 8},
+		{BC1, 5, 5, 32}, // partial blocks round up
+		{BC1, 1, 1, 8},
+		{BC3, 8, 4, 32},
+		{BC4, 8, 8, 32},
+		{BC7, 16, 16, 256},
+	}
+	for _, tt := range tests {
+		if got := tt.format.levelBytes(tt.w, tt.h); got != tt.want {
+			t.Errorf("%s %dx%d = %d bytes, want %d", tt.format, tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestBlocksHaveAlpha(t *testing.T) {
+	// BC1 in 3-colour mode (color0 <= color1) with one texel on index 3.
+	punchThrough := []byte{0x00, 0x00, 0xFF, 0xFF, 0x03, 0, 0, 0}
+	// Same mode but no texel uses index 3: still opaque.
+	threeColourOpaque := []byte{0x00, 0x00, 0xFF, 0xFF, 0x00, 0, 0, 0}
+
+	bc2Opaque := append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, opaqueBC1...)
+	bc2Alpha := append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F}, opaqueBC1...)
+
+	// 6-value mode with index 7 (=255) everywhere: opaque despite a0 < 255.
+	bc3Index7 := append([]byte{10, 200, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, opaqueBC1...)
+	// 6-value mode with index 6 (=0) on the first texel.
+	bc3Index6 := append([]byte{10, 200, 0x06, 0, 0, 0, 0, 0}, opaqueBC1...)
+
+	bc7Mode6Opaque := make([]byte, 16)
+	bc7Mode6Opaque[0] = 1 << 6
+	// A0 (bits 49-55), A1 (56-62) and both p-bits (63, 64) set.
+	bc7Mode6Opaque[6] = 0xFE
+	bc7Mode6Opaque[7] = 0xFF
+	bc7Mode6Opaque[8] = 0x01
+	bc7Mode6Alpha := append([]byte(nil), bc7Mode6Opaque...)
+	bc7Mode6Alpha[7] = 0x7F
+	bc7Mode1 := make([]byte, 16)
+	bc7Mode1[0] = 1 << 1
+
+	tests := []struct {
+		name   string
+		format BlockFormat
+		level  []byte
+		want   bool
+	}{
+		{"bc1 opaque", BC1, opaqueBC1, false},
+		{"bc1 punch-through", BC1, punchThrough, true},
+		{"bc1 three colour opaque", BC1, threeColourOpaque, false},
+		{"bc2 opaque", BC2, bc2Opaque, false},
+		{"bc2 alpha", BC2, bc2Alpha, true},
+		{"bc3 index 7", BC3, bc3Index7, false},
+		{"bc3 index 6", BC3, bc3Index6, true},
+		{"bc4 never", BC4, make([]byte, 8), false},
+		{"bc7 mode 6 opaque", BC7, bc7Mode6Opaque, false},
+		{"bc7 mode 6 alpha", BC7, bc7Mode6Alpha, true},
+		{"bc7 mode 1", BC7, bc7Mode1, false},
+	}
+	for _, tt := range tests {
+		if got := blocksHaveAlpha(tt.format, tt.level); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"3d-engine/utils"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 )
 
 // entry is one cached GL texture and the number of holders keeping it alive.
+// The path and options are kept so a hot reload can re-upload it as acquired.
 type entry struct {
 	id          uint32
 	transparent bool
 	refs        int
+
+	path string
+	opts Options
 }
 
 // cache is process-wide because GL texture names are, and because this engine
@@ -21,6 +28,11 @@ type entry struct {
 var cache = struct {
 	mu      sync.Mutex
 	entries map[string]*entry
+
+	// watcher is nil unless WatchFiles turned hot reload on.
+	watcher  *utils.FileWatcher
+	queue    func(func())
+	reloaded func(Reloaded)
 }{entries: map[string]*entry{}}
 
 // Acquire uploads the texture the first time it is asked for and hands out the
@@ -28,9 +40,19 @@ var cache = struct {
 // texture file therefore share one upload, and neither can free it out from
 // under the other.
 //
-// Every Acquire must be paired with a Release.
-func Acquire(path string) (id uint32, transparent bool, err error) {
-	key, err := cacheKey(path)
+// The options are part of the key, so the file acquired as a colour map and as
+// a data map is two uploads; see Options.
+//
+// Every Acquire must be paired with a Release with the same options.
+func Acquire(path string, opts Options) (id uint32, transparent bool, err error) {
+	return AcquireImage(path, opts, nil)
+}
+
+// AcquireImage is Acquire for a caller that already decoded the file off the
+// GL thread. img is only uploaded if the texture isn't resident yet; nil
+// means decode it now.
+func AcquireImage(path string, opts Options, img *Image) (id uint32, transparent bool, err error) {
+	key, err := cacheKey(path, opts)
 	if err != nil {
 		return 0, false, err
 	}
@@ -43,21 +65,30 @@ func Acquire(path string) (id uint32, transparent bool, err error) {
 		return existing.id, existing.transparent, nil
 	}
 
-	isTransparent := false
-	textureID, err := Load(path, &isTransparent)
+	var textureID uint32
+	var isTransparent bool
+	if img != nil {
+		textureID, err = Upload(img, opts)
+		isTransparent = img.Transparent
+	} else {
+		textureID, isTransparent, err = Load(path, opts)
+	}
 	if err != nil {
 		return 0, false, err
 	}
 
-	cache.entries[key] = &entry{id: textureID, transparent: isTransparent, refs: 1}
+	cache.entries[key] = &entry{id: textureID, transparent: isTransparent, refs: 1, path: path, opts: opts}
+	if cache.watcher != nil {
+		cache.watcher.Add(path)
+	}
 	return textureID, isTransparent, nil
 }
 
 // Release drops one hold on a texture and deletes it from the GPU when the last
 // holder lets go. Releasing something that was never acquired is an error
 // rather than a silent no-op, since it means the refcounts are already wrong.
-func Release(path string) error {
-	key, err := cacheKey(path)
+func Release(path string, opts Options) error {
+	key, err := cacheKey(path, opts)
 	if err != nil {
 		return err
 	}
@@ -67,7 +98,7 @@ func Release(path string) error {
 
 	existing, ok := cache.entries[key]
 	if !ok {
-		return fmt.Errorf("texture %q was released without being acquired", path)
+		return fmt.Errorf("texture %q (%s) was released without being acquired", path, opts.key())
 	}
 
 	existing.refs--
@@ -77,9 +108,118 @@ func Release(path string) error {
 
 	gl.DeleteTextures(1, &existing.id)
 	delete(cache.entries, key)
+	if cache.watcher != nil {
+		cache.watcher.Remove(existing.path)
+	}
 	return nil
 }
 
+// Resident reports whether the texture is currently uploaded with these
+// options. It is a hint for skipping a decode, not a promise: the last holder
+// may release it a moment later.
+func Resident(path string, opts Options) bool {
+	key, err := cacheKey(path, opts)
+	if err != nil {
+		return false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	_, ok := cache.entries[key]
+	return ok
+}
+
+// Reloaded describes one texture a hot reload re-uploaded. The GL name is
+// unchanged, so anything sampling it picks up the new pixels by itself; only
+// state derived from the image, like whether it has alpha, needs refreshing.
+type Reloaded struct {
+	Path        string
+	Options     Options
+	Transparent bool
+}
+
+// WatchFiles turns on hot reload for every texture the cache holds, now and
+// later. A changed file is decoded on the watcher's goroutine and handed to
+// queue, which must run the function on the GL thread (App.Defer, in the
+// engine); the upload there goes into the existing texture name, so every
+// holder updates in place and refcounts are untouched. reloaded, if not nil,
+// is called on the GL thread for each texture re-uploaded.
+//
+// Must be called from the GL thread. Calling it again replaces the settings.
+func WatchFiles(interval time.Duration, queue func(func()), reloaded func(Reloaded)) {
+	StopWatching()
+
+	watcher := utils.NewFileWatcher(interval, func(path string) {
+		img, err := Decode(path)
+		if err != nil {
+			// Most often a file caught mid-export; the next save retries.
+			utils.Logger().Printf("Not reloading texture %s: %v", path, err)
+			return
+		}
+		queue(func() { reloadTexture(path, img) })
+	})
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.watcher = watcher
+	cache.queue = queue
+	cache.reloaded = reloaded
+	for _, existing := range cache.entries {
+		watcher.Add(existing.path)
+	}
+}
+
+// StopWatching turns hot reload off. Must be called from the GL thread; a
+// reload already decoded may still be queued and is applied harmlessly.
+func StopWatching() {
+	cache.mu.Lock()
+	watcher := cache.watcher
+	cache.watcher = nil
+	cache.queue = nil
+	cache.reloaded = nil
+	cache.mu.Unlock()
+
+	// Outside the lock: Close waits for a callback that may be decoding.
+	if watcher != nil {
+		watcher.Close()
+	}
+}
+
+// reloadTexture uploads img into every resident texture made from path, under
+// each one's own options. A texture released since the decode is skipped.
+func reloadTexture(path string, img *Image) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	cache.mu.Lock()
+	var done []Reloaded
+	for _, existing := range cache.entries {
+		if resolved, _ := filepath.Abs(existing.path); resolved != absolute {
+			continue
+		}
+		if err := upload(existing.id, img, existing.opts); err != nil {
+			// upload only fails before touching the texture, so the old
+			// contents are still there and still valid.
+			utils.Logger().Printf("Reloading texture %s: %v", path, err)
+			continue
+		}
+		existing.transparent = img.Transparent
+		done = append(done, Reloaded{Path: existing.path, Options: existing.opts, Transparent: img.Transparent})
+	}
+	reloaded := cache.reloaded
+	cache.mu.Unlock()
+
+	for _, r := range done {
+		utils.Logger().Println("Reloaded texture:", r.Path)
+		if reloaded != nil {
+			reloaded(r)
+		}
+	}
+}
+
 // Stats reports how many distinct textures are resident and how many holds are
 // outstanding. Used by tests and the leak check.
 func Stats() (resident int, holds int) {
@@ -93,10 +233,10 @@ func Stats() (resident int, holds int) {
 	return resident, holds
 }
 
-func cacheKey(path string) (string, error) {
+func cacheKey(path string, opts Options) (string, error) {
 	absolute, err := filepath.Abs(path)
 	if err != nil {
 		return "", fmt.Errorf("could not resolve texture path %q: %w", path, err)
 	}
-	return absolute, nil
+	return absolute + "|" + opts.key(), nil
 }
@@ -0,0 +1,131 @@
+package textures
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// DDS layout constants. Only what a 2D BCn texture with mips needs: cubemaps,
+// volumes, arrays and the legacy uncompressed pixel formats are refused.
+const (
+	ddsMagic          = 0x20534444 // "DDS "
+	ddsHeaderSize     = 124
+	ddsFlagMipCount   = 0x20000
+	ddsPixelFourCC    = 0x4
+	ddsCaps2Cubemap   = 0x200
+	ddsCaps2Volume    = 0x200000
+	ddsDX10HeaderSize = 20
+)
+
+var fourCCFormats = map[string]BlockFormat{
+	"DXT1": BC1,
+	"DXT2": BC2,
+	"DXT3": BC2,
+	"DXT4": BC3,
+	"DXT5": BC3,
+	"ATI1": BC4,
+	"BC4U": BC4,
+	"BC4S": BC4Signed,
+	"ATI2": BC5,
+	"BC5U": BC5,
+	"BC5S": BC5Signed,
+}
+
+// dxgiFormats maps the DXGI_FORMAT values a DX10 header can carry. The sRGB
+// variants map to the same block format: colour space comes from Options, the
+// way it does for PNGs, so one rule decides it for every file type.
+var dxgiFormats = map[uint32]BlockFormat{
+	28: RGBA8, // R8G8B8A8_UNORM
+	29: RGBA8, // R8G8B8A8_UNORM_SRGB
+	71: BC1, 72: BC1,
+	74: BC2, 75: BC2,
+	77: BC3, 78: BC3,
+	80: BC4, 81: BC4Signed,
+	83: BC5, 84: BC5Signed,
+	95: BC6H, 96: BC6HSigned,
+	98: BC7, 99: BC7,
+}
+
+func parseDDS(data []byte) (*Image, error) {
+	if len(data) < 4+ddsHeaderSize {
+		return nil, errors.New("dds: file too short for header")
+	}
+	le := binary.LittleEndian
+	if le.Uint32(data) != ddsMagic {
+		return nil, errors.New("dds: bad magic")
+	}
+	if le.Uint32(data[4:]) != ddsHeaderSize {
+		return nil, fmt.Errorf("dds: header size %d, want %d", le.Uint32(data[4:]), ddsHeaderSize)
+	}
+
+	flags := le.Uint32(data[8:])
+	height := int32(le.Uint32(data[12:]))
+	width := int32(le.Uint32(data[16:]))
+	mipCount := 1
+	if flags&ddsFlagMipCount != 0 && le.Uint32(data[28:]) > 1 {
+		mipCount = int(le.Uint32(data[28:]))
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("dds: invalid size %dx%d", width, height)
+	}
+	if caps2 := le.Uint32(data[112:]); caps2&(ddsCaps2Cubemap|ddsCaps2Volume) != 0 {
+		return nil, errors.New("dds: cubemaps and volume textures are not supported")
+	}
+
+	pfFlags := le.Uint32(data[80:])
+	if pfFlags&ddsPixelFourCC == 0 {
+		return nil, errors.New("dds: only BCn (FourCC or DX10) files are supported")
+	}
+	fourCC := string(data[84:88])
+	offset := 4 + ddsHeaderSize
+
+	var format BlockFormat
+	if fourCC == "DX10" {
+		if len(data) < offset+ddsDX10HeaderSize {
+			return nil, errors.New("dds: file too short for DX10 header")
+		}
+		dxgi := le.Uint32(data[offset:])
+		if arraySize := le.Uint32(data[offset+12:]); arraySize > 1 {
+			return nil, errors.New("dds: texture arrays are not supported")
+		}
+		f, ok := dxgiFormats[dxgi]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported DXGI format %d", dxgi)
+		}
+		format = f
+		offset += ddsDX10HeaderSize
+	} else {
+		f, ok := fourCCFormats[fourCC]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported FourCC %q", fourCC)
+		}
+		format = f
+	}
+
+	img := &Image{Width: width, Height: height, Format: format}
+	for i := 0; i < mipCount; i++ {
+		size := format.levelBytes(levelSize(width, i), levelSize(height, i))
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("dds: mip level %d runs past the end of the file", i)
+		}
+		img.Levels = append(img.Levels, data[offset:offset+size])
+		offset += size
+	}
+	if err := checkLevels(img); err != nil {
+		return nil, fmt.Errorf("dds: %w", err)
+	}
+	img.Transparent = imageHasAlpha(img)
+	return img, nil
+}
+
+// imageHasAlpha checks the base level of any supported format.
+func imageHasAlpha(img *Image) bool {
+	if len(img.Levels) == 0 {
+		return false
+	}
+	if img.Format == RGBA8 {
+		return rgbaHasAlpha(img.Levels[0])
+	}
+	return blocksHaveAlpha(img.Format, img.Levels[0])
+}
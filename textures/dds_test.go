@@ -0,0 +1,102 @@
+package textures
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// ddsFile builds a minimal DDS: header, optional DX10 header, then payload.
+func ddsFile(width, height, mips uint32, fourCC string, dxgi uint32, payload []byte) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 4+ddsHeaderSize)
+	le.PutUint32(b[0:], ddsMagic)
+	le.PutUint32(b[4:], ddsHeaderSize)
+	flags := uint32(0x1007)
+	if mips > 1 {
+		flags |= ddsFlagMipCount
+	}
+	le.PutUint32(b[8:], flags)
+	le.PutUint32(b[12:], height)
+	le.PutUint32(b[16:], width)
+	le.PutUint32(b[28:], mips)
+	le.PutUint32(b[76:], 32)
+	le.PutUint32(b[80:], ddsPixelFourCC)
+	copy(b[84:88], fourCC)
+	if fourCC == "DX10" {
+		dx10 := make([]byte, ddsDX10HeaderSize)
+		le.PutUint32(dx10[0:], dxgi)
+		le.PutUint32(dx10[4:], 3) // TEXTURE2D
+		le.PutUint32(dx10[12:], 1)
+		b = append(b, dx10...)
+	}
+	return append(b, payload...)
+}
+
+// opaqueBC1 is one block in 4-colour mode (color0 > color1).
+var opaqueBC1 = []byte{0xFF, 0xFF, 0x00, 0x00, 0, 0, 0, 0}
+
+func repeat(block []byte, n int) []byte {
+	out := make([]byte, 0, len(block)*n)
+	for i := 0; i < n; i++ {
+		out = append(out, block...)
+	}
+	return out
+}
+
+func TestParseDDSLegacyWithMips(t *testing.T) {
+	// 8x8 BC1: 4 blocks, then 4x4 (1 block), 2x2 (1), 1x1 (1).
+	payload := repeat(opaqueBC1, 4+1+1+1)
+	img, err := parseDDS(ddsFile(8, 8, 4, "DXT1", 0, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != BC1 || img.Width != 8 || img.Height != 8 {
+		t.Fatalf("got %s %dx%d", img.Format, img.Width, img.Height)
+	}
+	if len(img.Levels) != 4 {
+		t.Fatalf("levels = %d, want 4", len(img.Levels))
+	}
+	for i, want := range []int{32, 8, 8, 8} {
+		if len(img.Levels[i]) != want {
+			t.Fatalf("level %d is %d bytes, want %d", i, len(img.Levels[i]), want)
+		}
+	}
+	if img.Transparent {
+		t.Fatal("opaque BC1 reported transparent")
+	}
+}
+
+func TestParseDDSDX10(t *testing.T) {
+	payload := make([]byte, 16) // one BC7 block, mode 0
+	payload[0] = 0x01
+	img, err := parseDDS(ddsFile(4, 4, 1, "DX10", 99, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != BC7 || len(img.Levels) != 1 {
+		t.Fatalf("got %s with %d levels", img.Format, len(img.Levels))
+	}
+}
+
+func TestParseDDSRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"short", []byte("DDS "), "too short"},
+		{"magic", append([]byte("PNG "), make([]byte, ddsHeaderSize)...), "bad magic"},
+		{"fourcc", ddsFile(4, 4, 1, "ABCD", 0, opaqueBC1), "unsupported FourCC"},
+		{"dxgi", ddsFile(4, 4, 1, "DX10", 2, make([]byte, 64)), "unsupported DXGI"},
+		{"truncated", ddsFile(8, 8, 1, "DXT1", 0, opaqueBC1), "past the end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDDS(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
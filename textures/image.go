@@ -0,0 +1,105 @@
+package textures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"neilpa.me/go-stbi"
+)
+
+// Image is a texture decoded into memory and not yet on the GPU. Decoding is
+// pure CPU work and safe from any goroutine; only Upload needs the GL thread.
+type Image struct {
+	Width  int32
+	Height int32
+	Format BlockFormat
+
+	// Levels is the mip chain, largest first. An RGBA8 image decoded from a
+	// PNG or JPEG carries just the base level and has the rest generated on
+	// upload; compressed formats can't be regenerated by the driver, so they
+	// bring whatever chain the file has.
+	Levels [][]byte
+
+	// Transparent reports whether any texel of the base level may be less than
+	// fully opaque. The renderer sends meshes using such a texture through the
+	// sorted, blended pass.
+	Transparent bool
+}
+
+// Decode reads a texture file. KTX2 and DDS are parsed for their BCn (or
+// plain RGBA8) payload; everything else goes through stb_image and comes out
+// as RGBA8.
+func Decode(path string) (*Image, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dds":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		img, err := parseDDS(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return img, nil
+	case ".ktx2":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		img, err := parseKTX2(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return img, nil
+	default:
+		return decodeSTB(path)
+	}
+}
+
+func decodeSTB(path string) (*Image, error) {
+	img, err := stbi.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Image{
+		Width:       int32(img.Rect.Dx()),
+		Height:      int32(img.Rect.Dy()),
+		Format:      RGBA8,
+		Levels:      [][]byte{img.Pix},
+		Transparent: rgbaHasAlpha(img.Pix),
+	}, nil
+}
+
+func rgbaHasAlpha(pix []byte) bool {
+	for i := 3; i < len(pix); i += 4 { // Alpha channel is every 4th byte
+		if pix[i] < 255 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLevels verifies every level is at least as large as the format says it
+// must be, so a truncated file fails here rather than inside the driver.
+func checkLevels(img *Image) error {
+	for i, level := range img.Levels {
+		w, h := levelSize(img.Width, i), levelSize(img.Height, i)
+		if want := img.Format.levelBytes(w, h); len(level) < want {
+			return fmt.Errorf("mip level %d is %d bytes, %s %dx%d needs %d",
+				i, len(level), img.Format, w, h, want)
+		}
+	}
+	return nil
+}
+
+// levelSize is the dimension of mip level i of a base dimension.
+func levelSize(base int32, i int) int32 {
+	size := base >> uint(i)
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
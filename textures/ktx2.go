@@ -0,0 +1,87 @@
+package textures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ktx2Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+const (
+	ktx2HeaderSize     = 80
+	ktx2LevelIndexSize = 24
+)
+
+// vkFormats maps the VkFormat values this loader accepts. As with DDS the
+// sRGB and UNORM variants decode to the same block format and Options decides
+// the colour space.
+var vkFormats = map[uint32]BlockFormat{
+	37: RGBA8, 43: RGBA8, // R8G8B8A8_UNORM / _SRGB
+	131: BC1, 132: BC1, 133: BC1, 134: BC1,
+	135: BC2, 136: BC2,
+	137: BC3, 138: BC3,
+	139: BC4, 140: BC4Signed,
+	141: BC5, 142: BC5Signed,
+	143: BC6H, 144: BC6HSigned,
+	145: BC7, 146: BC7,
+}
+
+// parseKTX2 reads a 2D, single-layer, single-face KTX2 file without
+// supercompression. Basis Universal and Zstandard payloads need a transcoder
+// this engine doesn't carry, so they are refused with a message saying so
+// rather than uploaded as garbage.
+func parseKTX2(data []byte) (*Image, error) {
+	if len(data) < ktx2HeaderSize || !bytes.Equal(data[:12], ktx2Identifier) {
+		return nil, errors.New("ktx2: bad identifier")
+	}
+	le := binary.LittleEndian
+	vkFormat := le.Uint32(data[12:])
+	width := int32(le.Uint32(data[20:]))
+	height := int32(le.Uint32(data[24:]))
+	depth := le.Uint32(data[28:])
+	layers := le.Uint32(data[32:])
+	faces := le.Uint32(data[36:])
+	levels := int(le.Uint32(data[40:]))
+	supercompression := le.Uint32(data[44:])
+
+	switch {
+	case supercompression != 0:
+		return nil, fmt.Errorf("ktx2: supercompression scheme %d is not supported", supercompression)
+	case width <= 0 || height <= 0:
+		return nil, fmt.Errorf("ktx2: invalid size %dx%d", width, height)
+	case depth > 1 || layers > 1 || faces != 1:
+		return nil, errors.New("ktx2: only 2D textures with one layer and one face are supported")
+	}
+	format, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("ktx2: unsupported VkFormat %d", vkFormat)
+	}
+	// Zero means "generate mipmaps on load", which the driver can do for
+	// RGBA8 and we simply can't for block formats: one level either way.
+	if levels == 0 {
+		levels = 1
+	}
+
+	indexEnd := ktx2HeaderSize + levels*ktx2LevelIndexSize
+	if len(data) < indexEnd {
+		return nil, errors.New("ktx2: file too short for level index")
+	}
+
+	img := &Image{Width: width, Height: height, Format: format}
+	for i := 0; i < levels; i++ {
+		entry := data[ktx2HeaderSize+i*ktx2LevelIndexSize:]
+		offset := le.Uint64(entry)
+		length := le.Uint64(entry[8:])
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("ktx2: mip level %d runs past the end of the file", i)
+		}
+		img.Levels = append(img.Levels, data[offset:offset+length])
+	}
+	if err := checkLevels(img); err != nil {
+		return nil, fmt.Errorf("ktx2: %w", err)
+	}
+	img.Transparent = imageHasAlpha(img)
+	return img, nil
+}
@@ -0,0 +1,81 @@
+package textures
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// ktx2File builds a KTX2 with the given levels laid out after the index.
+func ktx2File(vkFormat, width, height, supercompression uint32, levels ...[]byte) []byte {
+	le := binary.LittleEndian
+	header := make([]byte, ktx2HeaderSize+len(levels)*ktx2LevelIndexSize)
+	copy(header, ktx2Identifier)
+	le.PutUint32(header[12:], vkFormat)
+	le.PutUint32(header[16:], 1)
+	le.PutUint32(header[20:], width)
+	le.PutUint32(header[24:], height)
+	le.PutUint32(header[36:], 1) // faces
+	le.PutUint32(header[40:], uint32(len(levels)))
+	le.PutUint32(header[44:], supercompression)
+
+	offset := uint64(len(header))
+	var body []byte
+	for i, level := range levels {
+		entry := header[ktx2HeaderSize+i*ktx2LevelIndexSize:]
+		le.PutUint64(entry[0:], offset)
+		le.PutUint64(entry[8:], uint64(len(level)))
+		le.PutUint64(entry[16:], uint64(len(level)))
+		offset += uint64(len(level))
+		body = append(body, level...)
+	}
+	return append(header, body...)
+}
+
+func TestParseKTX2BC3(t *testing.T) {
+	// Alpha endpoints 255/255 in 8-value mode with index 0 everywhere: opaque.
+	block := append([]byte{255, 254, 0, 0, 0, 0, 0, 0}, opaqueBC1...)
+	img, err := parseKTX2(ktx2File(138, 8, 4, 0, repeat(block, 2), block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != BC3 || len(img.Levels) != 2 {
+		t.Fatalf("got %s with %d levels", img.Format, len(img.Levels))
+	}
+	if img.Transparent {
+		t.Fatal("opaque BC3 reported transparent")
+	}
+}
+
+func TestParseKTX2RGBA8(t *testing.T) {
+	pix := []byte{1, 2, 3, 255, 4, 5, 6, 128}
+	img, err := parseKTX2(ktx2File(43, 2, 1, 0, pix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != RGBA8 || !img.Transparent {
+		t.Fatalf("got %s transparent=%v, want RGBA8 with alpha", img.Format, img.Transparent)
+	}
+}
+
+func TestParseKTX2Rejects(t *testing.T) {
+	block := make([]byte, 16)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"identifier", make([]byte, 100), "bad identifier"},
+		{"supercompressed", ktx2File(145, 4, 4, 1, block), "supercompression"},
+		{"format", ktx2File(9999, 4, 4, 0, block), "unsupported VkFormat"},
+		{"short level", ktx2File(145, 8, 8, 0, block), "mip level 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseKTX2(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package textures
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ColorSpace says how texel values are to be interpreted when sampled.
+type ColorSpace uint8
+
+const (
+	// Linear stores texels as-is. Normal, specular, height and every other map
+	// that holds data rather than a colour must be linear, or the sRGB decode
+	// bends the values the shader does maths on.
+	Linear ColorSpace = iota
+	// SRGB has the GPU decode texels from sRGB to linear when sampling, which
+	// is what colour maps painted on a monitor are actually stored in.
+	SRGB
+)
+
+func (c ColorSpace) String() string {
+	if c == SRGB {
+		return "srgb"
+	}
+	return "linear"
+}
+
+// Options are the sampler and colour-space settings a texture is uploaded
+// with. They are part of the cache key: the same file acquired as a colour map
+// and as a data map is two different GPU textures, because sharing one would
+// leave whichever caller came second sampling the wrong values.
+type Options struct {
+	ColorSpace ColorSpace
+
+	// Anisotropy is the maximum anisotropic filtering level. Zero means "use
+	// the configured default" (SetDefaultAnisotropy); 1 turns it off.
+	// Upload clamps it to what the driver supports.
+	Anisotropy float32
+}
+
+// ColorMap are the options for textures that hold colour: diffuse, albedo,
+// emission.
+func ColorMap() Options {
+	return Options{ColorSpace: SRGB}
+}
+
+// DataMap are the options for textures that hold data: normals, specular
+// intensity, height.
+func DataMap() Options {
+	return Options{ColorSpace: Linear}
+}
+
+var defaultAnisotropy = struct {
+	mu    sync.Mutex
+	level float32
+}{level: 1}
+
+// SetDefaultAnisotropy sets the level used by Options that leave Anisotropy
+// at zero. The engine calls it once from config before the first scene loads;
+// textures already resident keep the level they were uploaded with.
+func SetDefaultAnisotropy(level float32) {
+	if level < 1 {
+		level = 1
+	}
+	defaultAnisotropy.mu.Lock()
+	defaultAnisotropy.level = level
+	defaultAnisotropy.mu.Unlock()
+}
+
+// resolved fills in the defaults, so two Options that upload identically
+// also compare (and key) identically.
+func (o Options) resolved() Options {
+	if o.Anisotropy == 0 {
+		defaultAnisotropy.mu.Lock()
+		o.Anisotropy = defaultAnisotropy.level
+		defaultAnisotropy.mu.Unlock()
+	}
+	if o.Anisotropy < 1 {
+		o.Anisotropy = 1
+	}
+	return o
+}
+
+func (o Options) key() string {
+	o = o.resolved()
+	return fmt.Sprintf("%s|aniso=%g", o.ColorSpace, o.Anisotropy)
+}
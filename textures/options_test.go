@@ -0,0 +1,28 @@
+package textures
+
+import "testing"
+
+func TestCacheKeySeparatesColourSpaceAndSampler(t *testing.T) {
+	SetDefaultAnisotropy(4)
+	defer SetDefaultAnisotropy(1)
+
+	colour, err := cacheKey("wall.png", ColorMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := cacheKey("wall.png", DataMap())
+	if colour == data {
+		t.Fatal("colour and data maps share a cache key")
+	}
+
+	sharp, _ := cacheKey("wall.png", Options{ColorSpace: SRGB, Anisotropy: 16})
+	if sharp == colour {
+		t.Fatal("different anisotropy shares a cache key")
+	}
+
+	// Zero means the default, so spelling the default out is the same texture.
+	explicit, _ := cacheKey("wall.png", Options{ColorSpace: SRGB, Anisotropy: 4})
+	if explicit != colour {
+		t.Fatalf("%q != %q", explicit, colour)
+	}
+}
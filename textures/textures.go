@@ -1,6 +1,9 @@
 package textures
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"neilpa.me/go-stbi"
 )
@@ -11,34 +14,109 @@ type Texture struct {
 	Data   []byte
 }
 
-func Load(name string, isTransparent *bool) (uint32, error) {
+// Load decodes and uploads a texture in one step. It must run on the GL
+// thread; use Decode then Upload to keep the file parsing off it.
+func Load(name string, opts Options) (id uint32, transparent bool, err error) {
+	img, err := Decode(name)
+	if err != nil {
+		return 0, false, err
+	}
+	id, err = Upload(img, opts)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, img.Transparent, nil
+}
+
+// Upload creates a GL texture from a decoded image: every level the image
+// carries, generated mipmaps for an uncompressed single level, trilinear
+// filtering whenever there is a chain to filter between, and anisotropy as
+// asked (clamped to what the driver allows).
+func Upload(img *Image, opts Options) (uint32, error) {
 	var textureId uint32
 	gl.GenTextures(1, &textureId)
+	if err := upload(textureId, img, opts); err != nil {
+		gl.DeleteTextures(1, &textureId)
+		return 0, err
+	}
+	return textureId, nil
+}
+
+func upload(textureId uint32, img *Image, opts Options) error {
+	if len(img.Levels) == 0 {
+		return fmt.Errorf("texture has no image data")
+	}
+	opts = opts.resolved()
+	internal := img.Format.internalFormat(opts.ColorSpace)
+	if internal == 0 {
+		return fmt.Errorf("no GL format for %s", img.Format)
+	}
+
 	gl.BindTexture(gl.TEXTURE_2D, textureId)
 
+	for i, level := range img.Levels {
+		w, h := levelSize(img.Width, i), levelSize(img.Height, i)
+		if img.Format.Compressed() {
+			size := img.Format.levelBytes(w, h)
+			gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(i), internal, w, h, 0, int32(size), gl.Ptr(level))
+		} else {
+			gl.TexImage2D(gl.TEXTURE_2D, int32(i), int32(internal), w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(level))
+		}
+	}
+
+	levels := len(img.Levels)
+	if !img.Format.Compressed() && levels == 1 {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+		levels = 0 // the whole chain now exists
+	}
+	// Without an explicit limit a partial chain from a compressed file makes
+	// the texture incomplete and it samples as black. It is always set, not
+	// only for partial chains, because a hot reload uploads into a texture
+	// that may still carry the limit from its previous contents.
+	maxLevel := int32(1000) // GL's default: every level there is
+	if levels > 0 {
+		maxLevel = int32(levels - 1)
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, maxLevel)
+
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	texture, err := getImage(name)
-	if err != nil {
-		return 0, err
+	// The mip chain used to be generated and then ignored, because the min
+	// filter was plain LINEAR.
+	if levels == 1 {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	} else {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
 	}
-
-	for i := 3; i < len(texture.Data); i += 4 { // Alpha channel is every 4th byte
-		if texture.Data[i] < 255 {
-			*isTransparent = true
-			break
-		}
+	if level := clampAnisotropy(opts.Anisotropy); level > 1 {
+		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAX_ANISOTROPY, level)
 	}
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, texture.Width, texture.Height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(texture.Data))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+var maxAnisotropy struct {
+	once  sync.Once
+	level float32
+}
 
-	return textureId, nil
+// clampAnisotropy limits a requested level to the driver's maximum, queried
+// once. Anisotropic filtering is core since GL 4.6, so the query is always
+// valid on the context this engine creates.
+func clampAnisotropy(level float32) float32 {
+	maxAnisotropy.once.Do(func() {
+		gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &maxAnisotropy.level)
+	})
+	if level > maxAnisotropy.level {
+		return maxAnisotropy.level
+	}
+	return level
 }
 
+// LoadCubemap loads the six faces of a skybox. Skies are colour, so the faces
+// are stored as sRGB.
 func LoadCubemap(path string) (uint32, error) {
 	var textureId uint32
 	gl.GenTextures(1, &textureId)
@@ -50,32 +128,31 @@ func LoadCubemap(path string) (uint32, error) {
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
 
-	var face_name string
-	for i := uint32(0); i < 6; i++ {
-		switch i {
-		case 0:
-			face_name = "right"
-		case 1:
-			face_name = "left"
-		case 2:
-			face_name = "top"
-		case 3:
-			face_name = "bottom"
-		case 4:
-			face_name = "front"
-		case 5:
-			face_name = "back"
-		}
-		texture, err := getImage(path + "/" + face_name + ".jpg")
+	for i, file := range CubemapFiles(path) {
+		texture, err := getImage(file)
 		if err != nil {
 			return 0, err
 		}
-		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, 0, gl.RGBA, texture.Width, texture.Height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(texture.Data))
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0, gl.SRGB8_ALPHA8, texture.Width, texture.Height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(texture.Data))
 	}
 
 	return textureId, nil
 }
 
+// CubemapFaces are the file names LoadCubemap expects in a skybox directory,
+// in GL face order.
+var CubemapFaces = [6]string{"right", "left", "top", "bottom", "front", "back"}
+
+// CubemapFiles lists the six face images LoadCubemap reads from a skybox
+// directory, in GL face order.
+func CubemapFiles(path string) []string {
+	files := make([]string, len(CubemapFaces))
+	for i, face := range CubemapFaces {
+		files[i] = path + "/" + face + ".jpg"
+	}
+	return files
+}
+
 func getImage(name string) (*Texture, error) {
 	img, err := stbi.Load(name)
 	if err != nil {
@@ -17,16 +17,34 @@ type Config struct {
 	DefaultSceneMode  string            `yaml:"defaultSceneMode"`
 	SceneModes        map[string]string `yaml:"sceneModes"`
 
-	Input   InputConfig   `yaml:"input"`
-	Physics PhysicsConfig `yaml:"physics"`
-	Player  PlayerConfig  `yaml:"player"`
-	RPC     RPCConfig     `yaml:"rpc"`
+	Input    InputConfig    `yaml:"input"`
+	Physics  PhysicsConfig  `yaml:"physics"`
+	Player   PlayerConfig   `yaml:"player"`
+	RPC      RPCConfig      `yaml:"rpc"`
+	Textures TexturesConfig `yaml:"textures"`
+	Assets   AssetsConfig   `yaml:"assets"`
+
+	Streaming StreamingConfig `yaml:"streaming"`
 }
 
 // InputConfig rebinds actions. An action listed here replaces the engine
 // default outright, so the old key stops working rather than both being live.
+// Each entry is a binding as input.ParseBinding reads it: a key or chord, or a
+// mouse or gamepad input with its options, e.g. "-GamepadLeftY deadzone=0.2".
 type InputConfig struct {
 	Actions map[string][]string `yaml:"actions"`
+	// Contexts bind actions that only apply while the named input context is
+	// pushed, on top of Actions. The engine pushes "editor" while the editor
+	// is showing; a game pushes its own.
+	Contexts map[string]InputContextConfig `yaml:"contexts"`
+}
+
+// InputContextConfig is one input context's bindings, in the same form as
+// InputConfig.Actions.
+type InputContextConfig struct {
+	// Blocking hides every context beneath this one while it is pushed.
+	Blocking bool                `yaml:"blocking"`
+	Actions  map[string][]string `yaml:"actions"`
 }
 
 // PhysicsConfig replaces the gravityStrength/gravityDirection package vars.
@@ -51,6 +69,90 @@ type PlayerConfig struct {
 type RPCConfig struct {
 	Address string `yaml:"address"`
 	Disable bool   `yaml:"disable"`
+
+	// HTTPAddress, when set, also serves the RPC server as JSON over HTTP,
+	// with its stream on a WebSocket, for clients without gRPC. It shares
+	// TLS, Tokens and ReadOnly with the gRPC server.
+	HTTPAddress string `yaml:"httpAddress"`
+
+	// Socket serves on a Unix socket at this path instead of on Address. Only
+	// its owner can connect to it, which on a shared machine is the simplest
+	// way to keep other users out.
+	Socket string `yaml:"socket"`
+
+	// TLS serves over TLS when it names a certificate and key.
+	TLS RPCTLSConfig `yaml:"tls"`
+
+	// Tokens are the bearer tokens a client may present, in an
+	// "authorization: Bearer <token>" header, and the role each grants. With
+	// none, every client is let in with the read-write role.
+	Tokens []RPCToken `yaml:"tokens"`
+
+	// ReadOnly gives every client the read-only role, whatever its token
+	// says: it can look at the world and follow its changes, and nothing
+	// else.
+	ReadOnly bool `yaml:"readOnly"`
+
+	// SceneRoot confines what clients do with scene files: a scene they save
+	// to a path of their choosing, and every file an inline scene they send
+	// reads, must be inside it. Empty leaves them free to, which suits an
+	// editor on the same machine and little else.
+	SceneRoot string `yaml:"sceneRoot"`
+}
+
+// RPCTLSConfig names the PEM files the RPC server's TLS uses.
+type RPCTLSConfig struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+// RPCToken is one bearer token and the role it grants: "readWrite", the
+// default, or "readOnly".
+type RPCToken struct {
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"`
+}
+
+// TexturesConfig holds the sampler settings applied to every texture a model
+// loads.
+type TexturesConfig struct {
+	// Anisotropy is the anisotropic filtering level, clamped to what the GPU
+	// supports. 1 (or leaving it out) turns it off; 8 or 16 keeps floors and
+	// walls seen at a glancing angle sharp for very little cost.
+	Anisotropy float32 `yaml:"anisotropy"`
+}
+
+// AssetsConfig tunes asynchronous scene loading.
+type AssetsConfig struct {
+	// UploadBudgetMs is how much of each frame may go on uploading meshes and
+	// textures for a scene that is loading in the background. Smaller keeps the
+	// frame rate steadier; larger finishes the load sooner.
+	UploadBudgetMs float32 `yaml:"uploadBudgetMs"`
+	// LoadWorkers is how many models are parsed in parallel. Zero picks one
+	// per core, up to four.
+	LoadWorkers int `yaml:"loadWorkers"`
+	// HotReload re-imports models and textures when their files change on
+	// disk, so a re-export shows up without reloading the scene. Meant for
+	// development: it costs a stat per resident asset every interval.
+	HotReload           bool `yaml:"hotReload"`
+	HotReloadIntervalMs int  `yaml:"hotReloadIntervalMs"`
+}
+
+// StreamingConfig tunes how a scene's cells stream in and out around the
+// camera.
+type StreamingConfig struct {
+	// LoadRadius is how close the camera has to come to a cell's bounds for
+	// the cell to start loading.
+	LoadRadius float32 `yaml:"loadRadius"`
+	// UnloadRadius is how far the camera has to move from a loaded cell for it
+	// to be unloaded. It is larger than LoadRadius so that standing on the
+	// edge does not load and unload the same cell every few frames; anything
+	// smaller is raised to LoadRadius.
+	UnloadRadius float32 `yaml:"unloadRadius"`
+	// SpawnBudget is how many entities a loaded cell may add to the world per
+	// frame. The models are uploaded by then; this spreads out the rest, the
+	// component starts and the bookkeeping, over a big cell's first frames.
+	SpawnBudget int `yaml:"spawnBudget"`
 }
 
 // applyDefaults fills in anything the file left out with the values the engine
@@ -82,6 +184,23 @@ func (c *Config) applyDefaults() {
 	if c.RPC.Address == "" {
 		c.RPC.Address = "localhost:8080"
 	}
+
+	if c.Assets.UploadBudgetMs == 0 {
+		c.Assets.UploadBudgetMs = 4
+	}
+	if c.Assets.HotReloadIntervalMs == 0 {
+		c.Assets.HotReloadIntervalMs = 500
+	}
+
+	if c.Streaming.LoadRadius == 0 {
+		c.Streaming.LoadRadius = 150
+	}
+	if c.Streaming.UnloadRadius == 0 {
+		c.Streaming.UnloadRadius = c.Streaming.LoadRadius * 1.25
+	}
+	if c.Streaming.SpawnBudget == 0 {
+		c.Streaming.SpawnBudget = 64
+	}
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -33,21 +33,60 @@ type Args struct {
 	ScenePath  string
 	DebugLevel DebugLevel
 	NoEditor   bool
+	RecordPath string
+	ReplayPath string
+}
+
+// Command is a mode the binary can run in instead of starting the engine —
+// `3DEngine migrate scene.yml` — named on the command line before its own
+// options and arguments. Data is its go-flags options struct, whose Execute
+// does the work.
+type Command struct {
+	Name  string
+	Short string
+	Long  string
+	Data  flags.Commander
 }
 
 // ParseArgs parses the command line and applies the verbosity to the logger.
-func ParseArgs() Args {
+//
+// When the command line names one of commands, ParseArgs runs it and exits the
+// process, with status 1 if it failed; the engine is never started. Without
+// one, the engine options are returned as before.
+func ParseArgs(commands ...Command) Args {
 	var opts struct {
 		Verbose  []bool `short:"v" long:"verbose" description:"Show verbose debug information"`
 		Config   string `short:"c" long:"config" description:"The path to the config" default:"./config.yml"`
 		Scene    string `short:"s" long:"scene" description:"The path to the scene" default:"./scene.yml"`
 		NoEditor bool   `long:"no-editor" description:"Run without the in-process editor overlay"`
+		Record   string `long:"record" description:"Record the session's input to this file"`
+		Replay   string `long:"replay" description:"Play back input recorded with --record"`
+	}
+
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.SubcommandsOptional = true
+	for _, command := range commands {
+		if _, err := parser.AddCommand(command.Name, command.Short, command.Long, command.Data); err != nil {
+			Logger().Fatalln(err)
+		}
 	}
 
-	_, err := flags.Parse(&opts)
+	// Commands run from inside Parse, so the verbosity has to be applied
+	// before they start rather than after Parse returns.
+	ran := false
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		Logger().SetLevel(DebugLevel(len(opts.Verbose)))
+		ran = true
+		return command.Execute(args)
+	}
+
+	_, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
+	if ran {
+		os.Exit(0)
+	}
 
 	level := DebugLevel(len(opts.Verbose))
 	Logger().SetLevel(level)
@@ -57,5 +96,7 @@ func ParseArgs() Args {
 		ScenePath:  opts.Scene,
 		DebugLevel: level,
 		NoEditor:   opts.NoEditor,
+		RecordPath: opts.Record,
+		ReplayPath: opts.Replay,
 	}
 }
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileWatcher polls a set of files and reports the ones that change. It polls
+// rather than using inotify and friends because it only ever watches the
+// handful of assets a scene has resident, and because polling behaves the same
+// on every platform, network drives included.
+//
+// A change is reported once the file has looked the same on two polls in a
+// row. Exporters and image editors often write in several steps (truncate,
+// write, rename over), and reloading half a file is worse than reloading one
+// poll late.
+type FileWatcher struct {
+	interval time.Duration
+	onChange func(path string)
+
+	mu    sync.Mutex
+	files map[string]*watchedFile
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+type watchedFile struct {
+	// refs counts Adds, so two holders of one file can Remove independently.
+	refs int
+	// seen is the version last reported (or the one there when first added).
+	seen fileStamp
+	// pending is a new version seen on the last poll, waiting to settle.
+	pending *fileStamp
+}
+
+// NewFileWatcher starts polling every interval. onChange is called with the
+// absolute path of each changed file, on the watcher's own goroutine.
+func NewFileWatcher(interval time.Duration, onChange func(path string)) *FileWatcher {
+	w := &FileWatcher{
+		interval: interval,
+		onChange: onChange,
+		files:    map[string]*watchedFile{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Add starts watching path, or adds a hold if it is already watched.
+func (w *FileWatcher) Add(path string) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		Logger().Printf("Not watching %q: %v", path, err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if file, ok := w.files[absolute]; ok {
+		file.refs++
+		return
+	}
+	w.files[absolute] = &watchedFile{refs: 1, seen: stat(absolute)}
+}
+
+// Remove drops one hold on path and stops watching it with the last.
+func (w *FileWatcher) Remove(path string) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, ok := w.files[absolute]
+	if !ok {
+		return
+	}
+	file.refs--
+	if file.refs <= 0 {
+		delete(w.files, absolute)
+	}
+}
+
+// Close stops polling and waits for an in-progress onChange to return.
+func (w *FileWatcher) Close() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *FileWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			for _, path := range w.poll() {
+				w.onChange(path)
+			}
+		}
+	}
+}
+
+// poll stats every watched file once and returns the ones whose change has
+// settled. The callbacks run after the lock is dropped, so a callback may Add
+// or Remove.
+func (w *FileWatcher) poll() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for path, file := range w.files {
+		current := stat(path)
+		switch {
+		case current == file.seen:
+			file.pending = nil
+		case !current.exists:
+			// Mid-save, most likely: wait for it to come back.
+			file.pending = nil
+		case file.pending != nil && *file.pending == current:
+			file.seen = current
+			file.pending = nil
+			changed = append(changed, path)
+		default:
+			file.pending = &current
+		}
+	}
+	return changed
+}
+
+func stat(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// These drive poll directly rather than waiting on the ticker, so they are
+// deterministic. The watcher is created with an interval long enough that its
+// own goroutine never gets a turn.
+func newPolledWatcher(t *testing.T) *FileWatcher {
+	t.Helper()
+
+	w := NewFileWatcher(time.Hour, func(string) {})
+	t.Cleanup(w.Close)
+	return w
+}
+
+func writeFile(t *testing.T, path, contents string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Set explicitly: two writes inside one filesystem tick would otherwise
+	// look identical.
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileWatcherReportsSettledChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mesh.obj")
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, path, "v 0 0 0", start)
+
+	w := newPolledWatcher(t)
+	w.Add(path)
+
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("unchanged file reported: %v", changed)
+	}
+
+	writeFile(t, path, "v 1 1 1", start.Add(time.Second))
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("change reported before it settled: %v", changed)
+	}
+	changed := w.poll()
+	if len(changed) != 1 || changed[0] != path {
+		t.Fatalf("poll = %v, want [%s]", changed, path)
+	}
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("change reported twice: %v", changed)
+	}
+}
+
+func TestFileWatcherWaitsOutAWriteInProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "albedo.png")
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, path, "a", start)
+
+	w := newPolledWatcher(t)
+	w.Add(path)
+
+	// Removed, then written twice: only the final version is reported.
+	os.Remove(path)
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("missing file reported: %v", changed)
+	}
+	writeFile(t, path, "ab", start.Add(time.Second))
+	w.poll()
+	writeFile(t, path, "abc", start.Add(2*time.Second))
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("change reported while still being written: %v", changed)
+	}
+	if changed := w.poll(); len(changed) != 1 {
+		t.Fatalf("poll = %v, want the settled file", changed)
+	}
+}
+
+func TestFileWatcherCountsHolds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.png")
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, path, "a", start)
+
+	w := newPolledWatcher(t)
+	w.Add(path)
+	w.Add(path)
+	w.Remove(path)
+
+	writeFile(t, path, "b", start.Add(time.Second))
+	w.poll()
+	if changed := w.poll(); len(changed) != 1 {
+		t.Fatalf("file still held once should be watched, poll = %v", changed)
+	}
+
+	w.Remove(path)
+	writeFile(t, path, "c", start.Add(2*time.Second))
+	w.poll()
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("released file still watched, poll = %v", changed)
+	}
+}